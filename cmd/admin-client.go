@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v6/pkg/s3signer"
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+// adminClient - signs and sends requests to the admin API of a radio server.
+type adminClient struct {
+	endpoint   *url.URL
+	accessKey  string
+	secretKey  string
+	region     string
+	httpClient *http.Client
+}
+
+// newAdminClient - initializes an admin client from the command line flags.
+func newAdminClient(ctx *cli.Context) (*adminClient, error) {
+	u, err := url.Parse(ctx.String("endpoint"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported endpoint scheme %q, expected http or https", u.Scheme)
+	}
+	if ctx.String("access-key") == "" || ctx.String("secret-key") == "" {
+		return nil, errors.New("admin access key and secret key are required")
+	}
+
+	transport := NewCustomHTTPTransport()
+	if ctx.Bool("insecure") {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &adminClient{
+		endpoint:   u,
+		accessKey:  ctx.String("access-key"),
+		secretKey:  ctx.String("secret-key"),
+		region:     ctx.String("region"),
+		httpClient: &http.Client{Transport: transport},
+	}, nil
+}
+
+// do - executes a signed admin API request, non 2xx responses are
+// converted to errors.
+func (c *adminClient) do(method, path string, query url.Values, body []byte) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = adminAPIPathPrefix + adminAPIVersionPrefix + path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	req.Header.Set(xhttp.AmzContentSha256, hex.EncodeToString(sum[:]))
+	req.ContentLength = int64(len(body))
+	req = s3signer.SignV4(*req, c.accessKey, c.secretKey, "", c.region)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var errResp APIErrorResponse
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxConfigSize))
+	if err = json.Unmarshal(data, &errResp); err != nil || errResp.Code == "" {
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return nil, fmt.Errorf("%s: %s", errResp.Code, errResp.Message)
+}
+
+// doJSON - executes an admin API request and decodes the JSON response.
+func (c *adminClient) doJSON(method, path string, query url.Values, body []byte, v interface{}) error {
+	resp, err := c.do(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,1184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/handlers"
+	"github.com/minio/minio/pkg/trace"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// adminAPIHandlers provides HTTP handlers for the admin API.
+type adminAPIHandlers struct{}
+
+// Maximum size of a config document accepted by SetConfigHandler.
+const maxConfigSize = 1 << 20
+
+// writeErrorResponseJSON - writes an admin API error, always as JSON.
+func writeErrorResponseJSON(ctx context.Context, w http.ResponseWriter, err APIError, r *http.Request) {
+	ctx = context.WithValue(ctx, errorFormatContextKey{}, string(mimeJSON))
+	writeErrorResponse(ctx, w, err, r.URL)
+}
+
+// validateAdminReq - verifies the request is signed with the admin credential.
+func validateAdminReq(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	if s3Err := checkAdminRequestAuth(ctx, r, globalServerRegion); s3Err != ErrNone {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(s3Err), r)
+		return false
+	}
+	return true
+}
+
+// ServerInfoHandler - GET /minio/admin/v1/info
+// Returns server properties, remotes and statistics.
+func (a adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ServerInfo")
+
+	defer logger.AuditLog(w, r, "ServerInfo")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(getServerInfo()))
+}
+
+// RuntimeInfoHandler - GET /minio/admin/v1/runtime
+// Returns Go runtime and process statistics.
+func (a adminAPIHandlers) RuntimeInfoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RuntimeInfo")
+
+	defer logger.AuditLog(w, r, "RuntimeInfo")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(getRuntimeInfo()))
+}
+
+// HealHandler - POST /minio/admin/v1/heal/{bucket}?prefix=&start-after=&dry-run=&verify=&full=
+// Copies objects missing or diverged on some remotes of a mirrored bucket.
+// Every handled object and the progress are streamed as HealUpdates,
+// followed by the summary.
+func (a adminAPIHandlers) HealHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Heal")
+
+	defer logger.AuditLog(w, r, "Heal")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+	query := r.URL.Query()
+	opts := HealOpts{
+		Prefix:     query.Get("prefix"),
+		StartAfter: query.Get("start-after"),
+		Verify:     query.Get("verify"),
+	}
+	opts.DryRun, _ = strconv.ParseBool(query.Get("dry-run"))
+	opts.Full, _ = strconv.ParseBool(query.Get("full"))
+
+	if _, ok = radioObjAPI.mirrorClients[bucket]; !ok {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, BucketNotFound{Bucket: bucket}), r)
+		return
+	}
+	switch opts.Verify {
+	case "", healVerifyExistence, healVerifyVersion, healVerifyChecksum:
+	default:
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+			"verify must be one of existence, version or checksum", r)
+		return
+	}
+
+	w.Header().Set(xhttp.ContentType, string(mimeJSON))
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	result, err := radioObjAPI.HealBucket(ctx, bucket, opts, func(update HealUpdate) {
+		enc.Encode(update)
+		w.(http.Flusher).Flush()
+	})
+	if err != nil {
+		logger.LogIf(ctx, err)
+		result.Error = err.Error()
+	}
+	enc.Encode(HealUpdate{Result: &result})
+	w.(http.Flusher).Flush()
+}
+
+// ConsistencyReportHandler - GET /minio/admin/v1/report/{bucket}?prefix=
+// Compares the listings of the remotes of a mirrored bucket without
+// repairing anything. Every diverging copy is streamed as a ReportUpdate,
+// followed by the summary.
+func (a adminAPIHandlers) ConsistencyReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConsistencyReport")
+
+	defer logger.AuditLog(w, r, "ConsistencyReport")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+	if _, ok = radioObjAPI.mirrorClients[bucket]; !ok {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, BucketNotFound{Bucket: bucket}), r)
+		return
+	}
+
+	w.Header().Set(xhttp.ContentType, string(mimeJSON))
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	report, err := radioObjAPI.ConsistencyReport(ctx, bucket, r.URL.Query().Get("prefix"), func(item DivergenceItem) {
+		enc.Encode(ReportUpdate{Item: &item})
+		w.(http.Flusher).Flush()
+	})
+	if err != nil {
+		logger.LogIf(ctx, err)
+		report.Error = err.Error()
+	}
+	enc.Encode(ReportUpdate{Result: &report})
+	w.(http.Flusher).Flush()
+}
+
+// CachePurgeResult - result of a cache purge.
+type CachePurgeResult struct {
+	Bucket string `json:"bucket,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Purged int    `json:"purged"`
+}
+
+// CachePurgeHandler - POST /minio/admin/v1/cache/purge?bucket=&prefix=
+// Removes cached objects, all of them if no bucket is given.
+func (a adminAPIHandlers) CachePurgeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CachePurge")
+
+	defer logger.AuditLog(w, r, "CachePurge")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	cacheAPI := newCachedObjectLayerFn()
+	if cacheAPI == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminCacheDisabled), r)
+		return
+	}
+
+	result := CachePurgeResult{
+		Bucket: r.URL.Query().Get("bucket"),
+		Prefix: r.URL.Query().Get("prefix"),
+	}
+	if result.Bucket == "" && result.Prefix != "" {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidBucketName), r)
+		return
+	}
+
+	var err error
+	result.Purged, err = cacheAPI.Purge(ctx, result.Bucket, result.Prefix)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(result))
+}
+
+// mustTrace - returns true if the trace entry should be sent, admin
+// API calls are never traced to avoid tracing the trace call itself.
+func mustTrace(entry interface{}, errOnly bool) bool {
+	trcInfo, ok := entry.(trace.Info)
+	if !ok {
+		return false
+	}
+	if HasPrefix(trcInfo.ReqInfo.Path, adminAPIPathPrefix) {
+		return false
+	}
+	return !errOnly || trcInfo.RespInfo.StatusCode >= http.StatusBadRequest
+}
+
+// TraceHandler - GET /minio/admin/v1/trace?err=
+// Streams HTTP trace entries as JSON lines until the client disconnects.
+func (a adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "HTTPTrace")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	errOnly, _ := strconv.ParseBool(r.URL.Query().Get("err"))
+
+	// Avoid reusing tcp connection if read timeout is hit
+	// This is needed to make r.Context().Done() work as
+	// expected in case of read timeout
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Content-Type", string(mimeJSON))
+	w.WriteHeader(http.StatusOK)
+
+	traceCh := make(chan interface{})
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	globalHTTPTrace.Subscribe(traceCh, doneCh, func(entry interface{}) bool {
+		return mustTrace(entry, errOnly)
+	})
+
+	keepAliveTicker := time.NewTicker(500 * time.Millisecond)
+	defer keepAliveTicker.Stop()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case entry := <-traceCh:
+			if err := enc.Encode(entry); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case <-keepAliveTicker.C:
+			if _, err := w.Write([]byte(" ")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// GetConfigHandler - GET /minio/admin/v1/config
+// Returns the config.yml this server was started with.
+func (a adminAPIHandlers) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetConfig")
+
+	defer logger.AuditLog(w, r, "GetConfig")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(globalRadioConfigFile)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	writeResponse(w, http.StatusOK, data, mimeNone)
+}
+
+// SetConfigHandler - PUT /minio/admin/v1/config
+// Validates and replaces config.yml, changes are applied on restart.
+func (a adminAPIHandlers) SetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetConfig")
+
+	defer logger.AuditLog(w, r, "SetConfig")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	if r.ContentLength < 0 || r.ContentLength > maxConfigSize {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminConfigTooLarge), r)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	if _, err = loadRadioConfig(data); err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminConfigInvalid), err.Error(), r)
+		return
+	}
+	// A missing config shows as all sections changed.
+	oldData, _ := ioutil.ReadFile(globalRadioConfigFile)
+
+	// Write to a temporary file first and rename, so that
+	// a partial write never leaves a corrupt config behind.
+	tmpFile, err := ioutil.TempFile(filepath.Dir(globalRadioConfigFile), ".config.yml.")
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	if _, err = tmpFile.Write(data); err == nil {
+		err = tmpFile.Sync()
+	}
+	if cerr := tmpFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), globalRadioConfigFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	logger.LogIf(ctx, globalConfigAudit.record(r, "SetConfig", configChanges(oldData, data)))
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// writeCustomErrorResponseJSON - writes an admin API error with a custom message.
+func writeCustomErrorResponseJSON(ctx context.Context, w http.ResponseWriter, err APIError, errBody string, r *http.Request) {
+	ctx = context.WithValue(ctx, errorFormatContextKey{}, string(mimeJSON))
+	writeCustomErrorResponseXML(ctx, w, err, errBody, r.URL)
+}
+
+// ProfileHandler - POST /minio/admin/v1/profile?type=&duration=
+// Captures a Go profile of this server, cpu by default, for duration and
+// returns it as a file for go tool pprof, or go tool trace for traces.
+func (a adminAPIHandlers) ProfileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Profile")
+
+	defer logger.AuditLog(w, r, "Profile")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	profileType := query.Get("type")
+	if profileType == "" {
+		profileType = "cpu"
+	}
+	if _, ok := profileTypes[profileType]; !ok {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+			"type must be one of cpu, trace, block, mutex, heap, allocs, goroutine or threadcreate", r)
+		return
+	}
+	duration := defaultProfileDuration
+	if d := query.Get("duration"); d != "" {
+		var err error
+		if duration, err = time.ParseDuration(d); err != nil || duration <= 0 || duration > maxProfileDuration {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				"duration must be a positive duration of at most "+maxProfileDuration.String(), r)
+			return
+		}
+	}
+
+	data, err := captureProfile(r.Context(), profileType, duration)
+	if err == errProfilerBusy {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminProfilerBusy), r)
+		return
+	}
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	ext := ".pprof"
+	if profileType == "trace" {
+		ext = ".trace"
+	}
+	w.Header().Set(xhttp.ContentDisposition, fmt.Sprintf("attachment; filename=\"radio-%s-%s%s\"",
+		profileType, UTCNow().Format("20060102T150405Z"), ext))
+	writeResponse(w, http.StatusOK, data, "application/octet-stream")
+}
+
+// ConfigHistoryHandler - GET /minio/admin/v1/config/history?cursor=&limit=
+// Returns up to limit config changes made through the admin API after
+// cursor, the oldest first, and the cursor of the next page.
+func (a adminAPIHandlers) ConfigHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConfigHistory")
+
+	defer logger.AuditLog(w, r, "ConfigHistory")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	if globalConfigAudit == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminConfigAuditDisabled), r)
+		return
+	}
+
+	query := r.URL.Query()
+	var cursor uint64
+	if c := query.Get("cursor"); c != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(c, 10, 64); err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), "cursor must be a number", r)
+			return
+		}
+	}
+	limit := defaultConfigAuditLimit
+	if l := query.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 || limit > maxConfigAuditLimit {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				"limit must be between 1 and "+strconv.Itoa(maxConfigAuditLimit), r)
+			return
+		}
+	}
+
+	entries, next, err := globalConfigAudit.read(cursor, limit)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(ConfigAuditResponse{Entries: entries, Cursor: next}))
+}
+
+// MaintenanceStatusHandler - GET /minio/admin/v1/maintenance
+// Returns the read-only mode and the maintenance state of all remotes.
+func (a adminAPIHandlers) MaintenanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "MaintenanceStatus")
+
+	defer logger.AuditLog(w, r, "MaintenanceStatus")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(radioObjAPI.getMaintenanceStatus()))
+}
+
+// CapabilitiesHandler - GET /minio/admin/v1/capabilities
+// Returns the capabilities of all remotes of mirrors found by their last
+// probe.
+func (a adminAPIHandlers) CapabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Capabilities")
+
+	defer logger.AuditLog(w, r, "Capabilities")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(radioObjAPI.getCapabilityMatrix()))
+}
+
+// ProbeCapabilitiesHandler - POST /minio/admin/v1/capabilities/probe
+// Probes all remotes of mirrors again and returns their capabilities.
+func (a adminAPIHandlers) ProbeCapabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ProbeCapabilities")
+
+	defer logger.AuditLog(w, r, "ProbeCapabilities")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(radioObjAPI.probeRemotes(ctx)))
+}
+
+// SetReadOnlyHandler - PUT /minio/admin/v1/maintenance/read-only?enable=
+// Turns the read-only mode on or off, writes are rejected with 503 while
+// it is on.
+func (a adminAPIHandlers) SetReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetReadOnly")
+
+	defer logger.AuditLog(w, r, "SetReadOnly")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	enable, err := strconv.ParseBool(r.URL.Query().Get("enable"))
+	if err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+	globalReadOnly.Store(enable)
+	logger.LogIf(ctx, globalConfigAudit.record(r, "SetReadOnly", map[string]string{
+		"enable": strconv.FormatBool(enable),
+	}))
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// SetRemoteMaintenanceHandler - PUT /minio/admin/v1/maintenance/remote?endpoint=&bucket=&enable=
+// Moves a remote bucket in or out of maintenance, writes skipped during
+// the maintenance are healed once it ends.
+func (a adminAPIHandlers) SetRemoteMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetRemoteMaintenance")
+
+	defer logger.AuditLog(w, r, "SetRemoteMaintenance")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	enable, err := strconv.ParseBool(query.Get("enable"))
+	if err == nil {
+		err = radioObjAPI.SetRemoteMaintenance(ctx, query.Get("endpoint"), query.Get("bucket"), enable)
+	}
+	if err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+	logger.LogIf(ctx, globalConfigAudit.record(r, "SetRemoteMaintenance", map[string]string{
+		"endpoint": query.Get("endpoint"),
+		"bucket":   query.Get("bucket"),
+		"enable":   strconv.FormatBool(enable),
+	}))
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// FencesHandler - GET /minio/admin/v1/fences
+// Returns the write fences of this server and the writes they rejected.
+func (a adminAPIHandlers) FencesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Fences")
+
+	defer logger.AuditLog(w, r, "Fences")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(globalWriteFences.list()))
+}
+
+// SetFenceHandler - PUT /minio/admin/v1/fences/{bucket}?start=&end=&reason=
+// Rejects the writes to a bucket from start, now by default, until end or
+// until the fence is lifted, times are RFC 3339. Replaces the previous
+// fence of the bucket.
+func (a adminAPIHandlers) SetFenceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetFence")
+
+	defer logger.AuditLog(w, r, "SetFence")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+	_, mirrored := radioObjAPI.mirrorClients[bucket]
+	_, erasure := radioObjAPI.erasureClients[bucket]
+	if !mirrored && !erasure {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, BucketNotFound{Bucket: bucket}), r)
+		return
+	}
+
+	query := r.URL.Query()
+	fence := WriteFence{Bucket: bucket, Reason: query.Get("reason")}
+	var err error
+	if start := query.Get("start"); start != "" {
+		fence.Start, err = time.Parse(time.RFC3339, start)
+	}
+	if end := query.Get("end"); end != "" && err == nil {
+		fence.End, err = time.Parse(time.RFC3339, end)
+	}
+	if err == nil {
+		fence, err = globalWriteFences.set(fence, UTCNow())
+	}
+	if err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+	logger.LogIf(ctx, globalConfigAudit.record(r, "SetFence", map[string]string{
+		"bucket": bucket,
+		"start":  query.Get("start"),
+		"end":    query.Get("end"),
+		"reason": fence.Reason,
+	}))
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(fence))
+}
+
+// LiftFenceHandler - DELETE /minio/admin/v1/fences/{bucket}
+// Lifts the write fence of a bucket, returns it with the writes it
+// rejected.
+func (a adminAPIHandlers) LiftFenceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "LiftFence")
+
+	defer logger.AuditLog(w, r, "LiftFence")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+	fence, err := globalWriteFences.lift(bucket)
+	if err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+	logger.LogIf(ctx, globalConfigAudit.record(r, "LiftFence", map[string]string{
+		"bucket":   bucket,
+		"rejected": strconv.FormatInt(fence.Rejected, 10),
+	}))
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(fence))
+}
+
+// ChaosStatusHandler - GET /minio/admin/v1/chaos
+// Returns the faults injected into the requests to remotes.
+func (a adminAPIHandlers) ChaosStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ChaosStatus")
+
+	defer logger.AuditLog(w, r, "ChaosStatus")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	if globalChaos == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChaosDisabled), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(globalChaos.status()))
+}
+
+// SetChaosHandler - PUT /minio/admin/v1/chaos
+// Replaces the faults injected into the requests to remotes by the YAML
+// or JSON rules in the body, an empty list stops all faults.
+func (a adminAPIHandlers) SetChaosHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetChaos")
+
+	defer logger.AuditLog(w, r, "SetChaos")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	if globalChaos == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChaosDisabled), r)
+		return
+	}
+
+	if r.ContentLength < 0 || r.ContentLength > maxConfigSize {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChaosInvalid),
+			"Fault injection rules exceed the allowed maximum of 1MiB.", r)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	rules, err := parseChaosRules(data)
+	if err != nil {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChaosInvalid), err.Error(), r)
+		return
+	}
+	globalChaos.set(rules)
+	logger.LogIf(ctx, globalConfigAudit.record(r, "SetChaos", map[string]string{
+		"rules": strconv.Itoa(len(rules)),
+	}))
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(globalChaos.status()))
+}
+
+// writeBatchJobErrorJSON - writes the error of a batch job request.
+func writeBatchJobErrorJSON(ctx context.Context, w http.ResponseWriter, err error, r *http.Request) {
+	if _, ok := err.(BucketNotFound); ok {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	if err == errBatchJobNotFound {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminNoSuchBatchJob), r)
+		return
+	}
+	writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminBatchJobInvalid), err.Error(), r)
+}
+
+// StartBatchJobHandler - POST /minio/admin/v1/batch
+// Starts the batch job in the YAML or JSON body and returns its status.
+func (a adminAPIHandlers) StartBatchJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "StartBatchJob")
+
+	defer logger.AuditLog(w, r, "StartBatchJob")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	if r.ContentLength < 0 || r.ContentLength > maxConfigSize {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminBatchJobInvalid),
+			"Batch job exceeds the allowed maximum of 1MiB.", r)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	spec, err := parseBatchJob(data)
+	if err != nil {
+		writeBatchJobErrorJSON(ctx, w, err, r)
+		return
+	}
+	status, err := radioObjAPI.StartBatchJob(spec)
+	if err != nil {
+		writeBatchJobErrorJSON(ctx, w, err, r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(status))
+}
+
+// ListBatchJobsHandler - GET /minio/admin/v1/batch
+// Returns the status of all batch jobs.
+func (a adminAPIHandlers) ListBatchJobsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListBatchJobs")
+
+	defer logger.AuditLog(w, r, "ListBatchJobs")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(radioObjAPI.BatchJobs()))
+}
+
+// BatchJobStatusHandler - GET /minio/admin/v1/batch/{id}
+// Returns the status of a batch job.
+func (a adminAPIHandlers) BatchJobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "BatchJobStatus")
+
+	defer logger.AuditLog(w, r, "BatchJobStatus")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	status, err := radioObjAPI.BatchJob(mux.Vars(r)["id"])
+	if err != nil {
+		writeBatchJobErrorJSON(ctx, w, err, r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(status))
+}
+
+// CancelBatchJobHandler - DELETE /minio/admin/v1/batch/{id}
+// Cancels a batch job, it stops once the objects in progress are handled.
+func (a adminAPIHandlers) CancelBatchJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CancelBatchJob")
+
+	defer logger.AuditLog(w, r, "CancelBatchJob")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	if err := radioObjAPI.CancelBatchJob(mux.Vars(r)["id"]); err != nil {
+		writeBatchJobErrorJSON(ctx, w, err, r)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// ResumeBatchJobHandler - POST /minio/admin/v1/batch/{id}/resume
+// Starts a job continuing a failed or canceled batch job after its
+// checkpoint and returns its status.
+func (a adminAPIHandlers) ResumeBatchJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ResumeBatchJob")
+
+	defer logger.AuditLog(w, r, "ResumeBatchJob")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	status, err := radioObjAPI.ResumeBatchJob(mux.Vars(r)["id"])
+	if err != nil {
+		writeBatchJobErrorJSON(ctx, w, err, r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(status))
+}
+
+// PresignHandler - POST /minio/admin/v1/presign?bucket=&object=&method=&access-key=&expiry=&endpoint=
+// Returns a URL presigned with a front-end credential of radio, response-*
+// parameters are passed on to the URL. The URL points at the endpoint of
+// the admin request unless endpoint is given.
+func (a adminAPIHandlers) PresignHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Presign")
+
+	defer logger.AuditLog(w, r, "Presign")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := PresignOpts{
+		Method:    query.Get("method"),
+		Bucket:    query.Get("bucket"),
+		Object:    query.Get("object"),
+		AccessKey: query.Get("access-key"),
+		Overrides: url.Values{},
+	}
+	for k, v := range query {
+		if strings.HasPrefix(k, "response-") {
+			opts.Overrides[k] = v
+		}
+	}
+	if expiry := query.Get("expiry"); expiry != "" {
+		var err error
+		if opts.Expiry, err = time.ParseDuration(expiry); err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+			return
+		}
+	}
+
+	endpoint := &url.URL{Scheme: handlers.GetSourceScheme(r), Host: r.Host}
+	if endpoint.Scheme == "" {
+		endpoint.Scheme = getURLScheme(r.TLS != nil)
+	}
+	if e := query.Get("endpoint"); e != "" {
+		var err error
+		if endpoint, err = url.Parse(e); err != nil || endpoint.Host == "" {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				"endpoint must be a URL such as https://radio.domain.com", r)
+			return
+		}
+	}
+
+	result, err := radioObjAPI.Presign(endpoint, opts)
+	if err != nil {
+		if _, ok := err.(BucketNotFound); ok {
+			writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+			return
+		}
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(result))
+}
+
+// UploadTokenHandler - POST /minio/admin/v1/upload-token?bucket=&prefix=&max-size=&expiry=
+// Mints a token uploading objects below prefix of bucket with single PUT
+// requests, of at most max-size bytes if set.
+func (a adminAPIHandlers) UploadTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "UploadToken")
+
+	defer logger.AuditLog(w, r, "UploadToken")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	claims := UploadTokenClaims{
+		Bucket: query.Get("bucket"),
+		Prefix: query.Get("prefix"),
+	}
+	if maxSize := query.Get("max-size"); maxSize != "" {
+		size, err := humanize.ParseBytes(maxSize)
+		if err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+			return
+		}
+		claims.MaxSize = int64(size)
+	}
+	var expiry time.Duration
+	if e := query.Get("expiry"); e != "" {
+		var err error
+		if expiry, err = time.ParseDuration(e); err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+			return
+		}
+	}
+
+	result, err := radioObjAPI.MintUploadToken(claims, expiry)
+	if err != nil {
+		if _, ok := err.(BucketNotFound); ok {
+			writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+			return
+		}
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(result))
+}
+
+const (
+	defaultChangesLimit = 1000
+	maxChangesLimit     = 10000
+	// Longest wait of a long-poll for changes.
+	maxChangesWait = 5 * time.Minute
+	// Interval of keep-alive comments of change streams.
+	changesKeepAlive = 15 * time.Second
+)
+
+// ChangesHandler - GET /minio/admin/v1/changes?cursor=&limit=&wait=&bucket=&prefix=
+// Returns the changes after cursor, waiting up to wait for new changes if
+// there are none. cursor=latest returns the cursor of the last change.
+// Requests accepting text/event-stream receive the changes as server-sent
+// events until they disconnect, resuming after Last-Event-ID if set.
+func (a adminAPIHandlers) ChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Changes")
+
+	defer logger.AuditLog(w, r, "Changes")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+	feed := radioObjAPI.changes
+	if feed == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChangeFeedDisabled), r)
+		return
+	}
+
+	query := r.URL.Query()
+	stream := strings.Contains(r.Header.Get(xhttp.Accept), "text/event-stream")
+	cursorStr := query.Get("cursor")
+	if id := r.Header.Get("Last-Event-ID"); stream && id != "" {
+		cursorStr = id
+	}
+	var cursor uint64
+	switch cursorStr {
+	case "":
+	case "latest":
+		cursor = feed.latest()
+	default:
+		var err error
+		if cursor, err = strconv.ParseUint(cursorStr, 10, 64); err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), "cursor must be a number or latest", r)
+			return
+		}
+	}
+	limit := defaultChangesLimit
+	if l := query.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 || limit > maxChangesLimit {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				"limit must be between 1 and "+strconv.Itoa(maxChangesLimit), r)
+			return
+		}
+	}
+	var wait time.Duration
+	if wt := query.Get("wait"); wt != "" {
+		var err error
+		if wait, err = time.ParseDuration(wt); err != nil || wait < 0 || wait > maxChangesWait {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				"wait must be a duration of at most "+maxChangesWait.String(), r)
+			return
+		}
+	}
+	bucket, prefix := query.Get("bucket"), query.Get("prefix")
+
+	writeChangesError := func(err error) {
+		switch err {
+		case errChangeCursorExpired:
+			writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminChangeCursorExpired), r)
+		case errChangeCursorInvalid:
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), err.Error(), r)
+		default:
+			writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		}
+	}
+
+	if stream {
+		// Check the cursor before the stream starts.
+		if _, _, err := feed.read(cursor, 1, bucket, prefix); err != nil {
+			writeChangesError(err)
+			return
+		}
+		w.Header().Set("Connection", "close")
+		w.Header().Set(xhttp.ContentType, "text/event-stream")
+		w.Header().Set(xhttp.CacheControl, "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flush := w.(http.Flusher).Flush
+		flush()
+		if err := feed.writeChangeEvents(r.Context(), w, flush, cursor, limit, bucket, prefix, changesKeepAlive); err != nil {
+			logger.LogIf(ctx, err)
+		}
+		return
+	}
+
+	events, next, err := feed.read(cursor, limit, bucket, prefix)
+	for err == nil && len(events) == 0 && wait > 0 {
+		started := UTCNow()
+		if !feed.wait(r.Context(), next, wait) {
+			break
+		}
+		wait -= UTCNow().Sub(started)
+		events, next, err = feed.read(next, limit, bucket, prefix)
+	}
+	if err != nil {
+		writeChangesError(err)
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(ChangesResponse{Events: events, Cursor: next}))
+}
+
+// UsageReportHandler - GET /minio/admin/v1/usage?from=&to=&access_key=
+// Returns the daily usage of every access key, or of access_key, from
+// from to to inclusive, dates such as 2020-01-31 defaulting to today.
+func (a adminAPIHandlers) UsageReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "UsageReport")
+
+	defer logger.AuditLog(w, r, "UsageReport")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	if globalUsage == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminUsageDisabled), r)
+		return
+	}
+
+	query := r.URL.Query()
+	today := UTCNow().Truncate(24 * time.Hour)
+	parseDate := func(name string) (time.Time, bool) {
+		s := query.Get(name)
+		if s == "" {
+			return today, true
+		}
+		d, err := time.Parse(usageDateLayout, s)
+		if err != nil {
+			writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+				name+" must be a date such as 2020-01-31", r)
+			return d, false
+		}
+		return d, true
+	}
+	from, ok := parseDate("from")
+	if !ok {
+		return
+	}
+	to, ok := parseDate("to")
+	if !ok {
+		return
+	}
+	if to.Before(from) || to.Sub(from) >= maxUsageReportDays*24*time.Hour {
+		writeCustomErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest),
+			"from must not be after to, and at most "+strconv.Itoa(maxUsageReportDays)+" days before", r)
+		return
+	}
+
+	report, err := globalUsage.report(from, to, query.Get("access_key"))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	writeSuccessResponseJSON(w, encodeResponseJSON(report))
+}
+
+// TenantsHandler - GET /minio/admin/v1/tenants
+// Returns the limits and usage of the tenants on this server.
+func (a adminAPIHandlers) TenantsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "Tenants")
+
+	defer logger.AuditLog(w, r, "Tenants")
+
+	if !validateAdminReq(ctx, w, r) {
+		return
+	}
+
+	writeSuccessResponseJSON(w, encodeResponseJSON(globalTenants.status()))
+}
@@ -0,0 +1,951 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio/pkg/trace"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// adminFlags - flags common to all admin sub-commands.
+var adminFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "endpoint",
+		Value:  "http://localhost:" + globalRadioDefaultPort,
+		Usage:  "radio server endpoint",
+		EnvVar: "RADIO_ADMIN_CLIENT_ENDPOINT",
+	},
+	cli.StringFlag{
+		Name:   "access-key",
+		Usage:  "admin access key",
+		EnvVar: "RADIO_ADMIN_CLIENT_ACCESS_KEY",
+	},
+	cli.StringFlag{
+		Name:   "secret-key",
+		Usage:  "admin secret key",
+		EnvVar: "RADIO_ADMIN_CLIENT_SECRET_KEY",
+	},
+	cli.StringFlag{
+		Name:  "region",
+		Value: "us-east-1",
+		Usage: "region used to sign requests",
+	},
+	cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "disable TLS certificate verification",
+	},
+}
+
+var adminCmd = cli.Command{
+	Name:  "admin",
+	Usage: "Manage a running radio server",
+	Subcommands: []cli.Command{
+		{
+			Name:   "info",
+			Usage:  "display server information",
+			Flags:  adminFlags,
+			Action: adminInfoMain,
+		},
+		{
+			Name:   "runtime",
+			Usage:  "display goroutine, memory, GC and connection statistics",
+			Flags:  adminFlags,
+			Action: adminRuntimeMain,
+		},
+		{
+			Name:      "heal",
+			Usage:     "heal objects missing or diverged across the remotes of a mirrored bucket",
+			ArgsUsage: "BUCKET",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "prefix",
+					Usage: "heal only objects under this prefix",
+				},
+				cli.StringFlag{
+					Name:  "start-after",
+					Usage: "resume a heal after this object, the last object reported",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "only report objects which need healing",
+				},
+				cli.StringFlag{
+					Name:  "verify",
+					Usage: "heal objects which are missing with 'existence', hold another version with 'version' or differ in content with 'checksum'",
+					Value: healVerifyVersion,
+				},
+				cli.BoolFlag{
+					Name:  "full",
+					Usage: "walk all objects instead of only the prefixes written to since the last heal",
+				},
+			}, adminFlags...),
+			Action: adminHealMain,
+		},
+		{
+			Name:      "report",
+			Usage:     "report objects missing or diverged across the remotes of a mirrored bucket, as JSON lines",
+			ArgsUsage: "BUCKET",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "prefix",
+					Usage: "compare only objects under this prefix",
+				},
+			}, adminFlags...),
+			Action: adminReportMain,
+		},
+		{
+			Name:  "cache",
+			Usage: "manage the server cache",
+			Subcommands: []cli.Command{
+				{
+					Name:      "purge",
+					Usage:     "remove cached objects of BUCKET, or all cached objects",
+					ArgsUsage: "[BUCKET]",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "prefix",
+							Usage: "purge only objects under this prefix",
+						},
+					}, adminFlags...),
+					Action: adminCachePurgeMain,
+				},
+			},
+		},
+		{
+			Name:  "trace",
+			Usage: "show HTTP trace of the server",
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "errors, e",
+					Usage: "trace only failed requests",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the trace entries as JSON lines, such as to be replayed by radio replay",
+				},
+			}, adminFlags...),
+			Action: adminTraceMain,
+		},
+		{
+			Name:  "config",
+			Usage: "get and set server configuration",
+			Subcommands: []cli.Command{
+				{
+					Name:      "get",
+					Usage:     "print the server config.yml, or the value of KEY such as 'cache.quota'",
+					ArgsUsage: "[KEY]",
+					Flags:     adminFlags,
+					Action:    adminConfigGetMain,
+				},
+				{
+					Name:      "set",
+					Usage:     "replace the server config.yml, changes are applied on restart",
+					ArgsUsage: "FILE",
+					Flags:     adminFlags,
+					Action:    adminConfigSetMain,
+				},
+				{
+					Name:  "history",
+					Usage: "print the config changes made through the admin API after a cursor, as JSON lines",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "cursor",
+							Usage: "print the changes after this cursor, the first change by default",
+						},
+					}, adminFlags...),
+					Action: adminConfigHistoryMain,
+				},
+			},
+		},
+		{
+			Name:  "maintenance",
+			Usage: "manage read-only mode and maintenance of remotes",
+			Subcommands: []cli.Command{
+				{
+					Name:   "status",
+					Usage:  "display read-only mode and maintenance state of all remotes",
+					Flags:  adminFlags,
+					Action: adminMaintenanceStatusMain,
+				},
+				{
+					Name:      "read-only",
+					Usage:     "reject all writes with 503 while on",
+					ArgsUsage: "on|off",
+					Flags:     adminFlags,
+					Action:    adminMaintenanceReadOnlyMain,
+				},
+				{
+					Name:      "remote",
+					Usage:     "exclude a remote bucket from reads and writes while on, skipped writes are healed once off",
+					ArgsUsage: "ENDPOINT BUCKET on|off",
+					Flags:     adminFlags,
+					Action:    adminMaintenanceRemoteMain,
+				},
+			},
+		},
+		{
+			Name:  "fence",
+			Usage: "reject writes to buckets during a cutover while serving reads",
+			Subcommands: []cli.Command{
+				{
+					Name:   "status",
+					Usage:  "display the fences and the writes they rejected",
+					Flags:  adminFlags,
+					Action: adminFenceStatusMain,
+				},
+				{
+					Name:      "set",
+					Usage:     "fence a bucket, replacing its previous fence",
+					ArgsUsage: "BUCKET",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "start",
+							Usage: "RFC 3339 time the fence starts at, now by default",
+						},
+						cli.StringFlag{
+							Name:  "end",
+							Usage: "RFC 3339 time the fence ends at, it lasts until lifted by default",
+						},
+						cli.StringFlag{
+							Name:  "reason",
+							Usage: "reason recorded with the fence",
+						},
+					}, adminFlags...),
+					Action: adminFenceSetMain,
+				},
+				{
+					Name:      "lift",
+					Usage:     "lift the fence of a bucket and display the writes it rejected",
+					ArgsUsage: "BUCKET",
+					Flags:     adminFlags,
+					Action:    adminFenceLiftMain,
+				},
+			},
+		},
+		{
+			Name:  "capabilities",
+			Usage: "display the capabilities of all remotes found by their last probe",
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "probe",
+					Usage: "probe all remotes again first",
+				},
+			}, adminFlags...),
+			Action: adminCapabilitiesMain,
+		},
+		{
+			Name:   "tenants",
+			Usage:  "display the limits and usage of the tenants",
+			Flags:  adminFlags,
+			Action: adminTenantsMain,
+		},
+		{
+			Name:  "chaos",
+			Usage: "inject faults into the requests to remotes of a server started with --chaos, for testing only",
+			Subcommands: []cli.Command{
+				{
+					Name:   "status",
+					Usage:  "display the injected faults and the requests faulted by each",
+					Flags:  adminFlags,
+					Action: adminChaosStatusMain,
+				},
+				{
+					Name:      "set",
+					Usage:     "replace the injected faults by the rules in the YAML or JSON FILE",
+					ArgsUsage: "FILE",
+					Flags:     adminFlags,
+					Action:    adminChaosSetMain,
+				},
+				{
+					Name:   "clear",
+					Usage:  "stop injecting faults",
+					Flags:  adminFlags,
+					Action: adminChaosClearMain,
+				},
+			},
+		},
+		{
+			Name:  "batch",
+			Usage: "run bulk replicate, copy, delete and cache warm jobs on the server",
+			Subcommands: []cli.Command{
+				{
+					Name:      "start",
+					Usage:     "start the batch job described in the YAML or JSON FILE",
+					ArgsUsage: "FILE",
+					Flags:     adminFlags,
+					Action:    adminBatchStartMain,
+				},
+				{
+					Name:   "list",
+					Usage:  "display the status of all batch jobs",
+					Flags:  adminFlags,
+					Action: adminBatchListMain,
+				},
+				{
+					Name:      "status",
+					Usage:     "display the status of a batch job",
+					ArgsUsage: "ID",
+					Flags:     adminFlags,
+					Action:    adminBatchStatusMain,
+				},
+				{
+					Name:      "cancel",
+					Usage:     "cancel a running batch job",
+					ArgsUsage: "ID",
+					Flags:     adminFlags,
+					Action:    adminBatchCancelMain,
+				},
+				{
+					Name:      "resume",
+					Usage:     "continue a failed or canceled batch job after its checkpoint",
+					ArgsUsage: "ID",
+					Flags:     adminFlags,
+					Action:    adminBatchResumeMain,
+				},
+			},
+		},
+		{
+			Name:      "presign",
+			Usage:     "generate a presigned URL of an object",
+			ArgsUsage: "BUCKET OBJECT",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "method",
+					Usage: "HTTP method of the URL, GET, HEAD, PUT or DELETE",
+					Value: http.MethodGet,
+				},
+				cli.DurationFlag{
+					Name:  "expiry",
+					Usage: "validity of the URL, at most 168h",
+					Value: defaultPresignExpiry,
+				},
+				cli.StringFlag{
+					Name:  "sign-key",
+					Usage: "front-end access key signing the URL, required with more than one credential",
+				},
+				cli.StringFlag{
+					Name:  "url",
+					Usage: "radio endpoint of the URL, such as https://radio.domain.com, the admin endpoint by default",
+				},
+				cli.StringSliceFlag{
+					Name:  "override",
+					Usage: "response header override of GET and HEAD URLs, such as response-content-disposition=attachment",
+				},
+			}, adminFlags...),
+			Action: adminPresignMain,
+		},
+		{
+			Name:      "upload-token",
+			Usage:     "mint a token uploading objects to BUCKET with single PUT requests, for devices without credentials",
+			ArgsUsage: "BUCKET",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "prefix",
+					Usage: "prefix the keys of uploaded objects must start with",
+				},
+				cli.StringFlag{
+					Name:  "max-size",
+					Usage: "largest object uploaded, such as 10MiB",
+				},
+				cli.DurationFlag{
+					Name:  "expiry",
+					Usage: "validity of the token, at most 720h",
+					Value: defaultUploadTokenExpiry,
+				},
+			}, adminFlags...),
+			Action: adminUploadTokenMain,
+		},
+		{
+			Name:  "changes",
+			Usage: "print the changes made through the server after a cursor, as JSON lines",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "cursor",
+					Usage: "print the changes after this cursor, the oldest retained change by default, or 'latest'",
+				},
+				cli.StringFlag{
+					Name:  "bucket",
+					Usage: "print only the changes of this bucket",
+				},
+				cli.StringFlag{
+					Name:  "prefix",
+					Usage: "print only the changes of objects under this prefix",
+				},
+				cli.BoolFlag{
+					Name:  "follow, f",
+					Usage: "wait for new changes instead of exiting",
+				},
+			}, adminFlags...),
+			Action: adminChangesMain,
+		},
+		{
+			Name:  "profile",
+			Usage: "capture a Go profile of the server, for go tool pprof or go tool trace",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "type",
+					Value: "cpu",
+					Usage: "profile type, one of cpu, trace, block, mutex, heap, allocs, goroutine or threadcreate",
+				},
+				cli.DurationFlag{
+					Name:  "duration",
+					Value: defaultProfileDuration,
+					Usage: "time the cpu, trace, block and mutex profiles are recorded, at most 5m",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "file the profile is written to, named by the server by default",
+				},
+			}, adminFlags...),
+			Action: adminProfileMain,
+		},
+		{
+			Name:  "usage",
+			Usage: "display the daily usage of the access keys",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "first day of the report, such as 2020-01-01, today by default",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "last day of the report, today by default",
+				},
+				cli.StringFlag{
+					Name:  "key",
+					Usage: "display only the usage of this access key",
+				},
+			}, adminFlags...),
+			Action: adminUsageMain,
+		},
+	},
+}
+
+func mustNewAdminClient(ctx *cli.Context) *adminClient {
+	clnt, err := newAdminClient(ctx)
+	logger.FatalIf(err, "Unable to initialize admin client")
+	return clnt
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", " ")
+	logger.FatalIf(err, "Unable to encode response")
+	fmt.Println(string(data))
+}
+
+func adminInfoMain(ctx *cli.Context) {
+	var info ServerInfo
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/info", nil, nil, &info)
+	logger.FatalIf(err, "Unable to fetch server info")
+	printJSON(info)
+}
+
+func adminRuntimeMain(ctx *cli.Context) {
+	var info RuntimeInfo
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/runtime", nil, nil, &info)
+	logger.FatalIf(err, "Unable to fetch runtime info")
+	printJSON(info)
+}
+
+func adminHealMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "heal", 1)
+	}
+	query := url.Values{}
+	query.Set("prefix", ctx.String("prefix"))
+	query.Set("start-after", ctx.String("start-after"))
+	query.Set("dry-run", strconv.FormatBool(ctx.Bool("dry-run")))
+	query.Set("verify", ctx.String("verify"))
+	query.Set("full", strconv.FormatBool(ctx.Bool("full")))
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPost, "/heal/"+url.PathEscape(ctx.Args().First()),
+		query, nil)
+	logger.FatalIf(err, "Unable to heal bucket")
+	defer resp.Body.Close()
+
+	// The last object reported as handled, the heal can be resumed
+	// after it.
+	lastObject := ctx.String("start-after")
+	fatalIf := func(err error) {
+		if lastObject != "" {
+			logger.FatalIf(err, "Unable to heal bucket, resume with --start-after %s", lastObject)
+		}
+		logger.FatalIf(err, "Unable to heal bucket")
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var update HealUpdate
+		if err = dec.Decode(&update); err != nil {
+			fatalIf(err)
+		}
+		switch {
+		case update.Item != nil:
+			printJSON(update.Item)
+		case update.Progress != nil:
+			printJSON(update.Progress)
+			if update.Progress.LastObject != "" {
+				lastObject = update.Progress.LastObject
+			}
+		case update.Result != nil:
+			printJSON(update.Result)
+			if update.Result.LastObject != "" {
+				lastObject = update.Result.LastObject
+			}
+			if update.Result.Error != "" {
+				fatalIf(errors.New(update.Result.Error))
+			}
+			return
+		}
+	}
+}
+
+func adminReportMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "report", 1)
+	}
+	query := url.Values{}
+	query.Set("prefix", ctx.String("prefix"))
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodGet, "/report/"+url.PathEscape(ctx.Args().First()),
+		query, nil)
+	logger.FatalIf(err, "Unable to report bucket")
+	defer resp.Body.Close()
+
+	// One JSON document per line, so that the report can be processed
+	// while it is produced.
+	dec := json.NewDecoder(resp.Body)
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		var update ReportUpdate
+		if err = dec.Decode(&update); err != nil {
+			logger.FatalIf(err, "Unable to report bucket")
+		}
+		if update.Item != nil {
+			enc.Encode(update.Item)
+			continue
+		}
+		if update.Result != nil {
+			enc.Encode(update.Result)
+			if update.Result.Error != "" {
+				logger.FatalIf(errors.New(update.Result.Error), "Unable to report bucket")
+			}
+			return
+		}
+	}
+}
+
+func adminCachePurgeMain(ctx *cli.Context) {
+	if len(ctx.Args()) > 1 {
+		cli.ShowCommandHelpAndExit(ctx, "purge", 1)
+	}
+	query := url.Values{}
+	query.Set("bucket", ctx.Args().First())
+	query.Set("prefix", ctx.String("prefix"))
+
+	var result CachePurgeResult
+	err := mustNewAdminClient(ctx).doJSON(http.MethodPost, "/cache/purge", query, nil, &result)
+	logger.FatalIf(err, "Unable to purge cache")
+	printJSON(result)
+}
+
+func adminTraceMain(ctx *cli.Context) {
+	query := url.Values{}
+	query.Set("err", strconv.FormatBool(ctx.Bool("errors")))
+	resp, err := mustNewAdminClient(ctx).do(http.MethodGet, "/trace", query, nil)
+	logger.FatalIf(err, "Unable to start trace")
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var info trace.Info
+		if err = dec.Decode(&info); err != nil {
+			return
+		}
+		if ctx.Bool("json") {
+			data, _ := json.Marshal(info)
+			fmt.Println(string(data))
+			continue
+		}
+		fmt.Printf("%s %s %s %s %d %s\n", info.ReqInfo.Time.Format("15:04:05.000"),
+			info.FuncName, info.ReqInfo.Method, info.ReqInfo.Path,
+			info.RespInfo.StatusCode, info.CallStats.Latency)
+	}
+}
+
+// parseOnOff - parses the on|off argument of maintenance commands.
+func parseOnOff(ctx *cli.Context, arg, command string) bool {
+	switch arg {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	cli.ShowCommandHelpAndExit(ctx, command, 1)
+	return false
+}
+
+func adminMaintenanceStatusMain(ctx *cli.Context) {
+	var status MaintenanceStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/maintenance", nil, nil, &status)
+	logger.FatalIf(err, "Unable to fetch maintenance status")
+	printJSON(status)
+}
+
+func adminMaintenanceReadOnlyMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "read-only", 1)
+	}
+	query := url.Values{}
+	query.Set("enable", strconv.FormatBool(parseOnOff(ctx, ctx.Args().First(), "read-only")))
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPut, "/maintenance/read-only", query, nil)
+	logger.FatalIf(err, "Unable to set read-only mode")
+	resp.Body.Close()
+	fmt.Printf("Read-only mode turned %s.\n", ctx.Args().First())
+}
+
+func adminMaintenanceRemoteMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 3 {
+		cli.ShowCommandHelpAndExit(ctx, "remote", 1)
+	}
+	query := url.Values{}
+	query.Set("endpoint", ctx.Args().Get(0))
+	query.Set("bucket", ctx.Args().Get(1))
+	query.Set("enable", strconv.FormatBool(parseOnOff(ctx, ctx.Args().Get(2), "remote")))
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPut, "/maintenance/remote", query, nil)
+	logger.FatalIf(err, "Unable to set remote maintenance")
+	resp.Body.Close()
+	fmt.Printf("Maintenance of %s/%s turned %s.\n", ctx.Args().Get(0), ctx.Args().Get(1), ctx.Args().Get(2))
+}
+
+func adminFenceStatusMain(ctx *cli.Context) {
+	var fences []WriteFence
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/fences", nil, nil, &fences)
+	logger.FatalIf(err, "Unable to fetch the write fences")
+	printJSON(fences)
+}
+
+func adminFenceSetMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "set", 1)
+	}
+	query := url.Values{}
+	for _, name := range []string{"start", "end", "reason"} {
+		if v := ctx.String(name); v != "" {
+			query.Set(name, v)
+		}
+	}
+
+	var fence WriteFence
+	err := mustNewAdminClient(ctx).doJSON(http.MethodPut, "/fences/"+ctx.Args().First(), query, nil, &fence)
+	logger.FatalIf(err, "Unable to fence the bucket")
+	printJSON(fence)
+}
+
+func adminFenceLiftMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "lift", 1)
+	}
+	var fence WriteFence
+	err := mustNewAdminClient(ctx).doJSON(http.MethodDelete, "/fences/"+ctx.Args().First(), nil, nil, &fence)
+	logger.FatalIf(err, "Unable to lift the fence")
+	printJSON(fence)
+}
+
+func adminCapabilitiesMain(ctx *cli.Context) {
+	method, path := http.MethodGet, "/capabilities"
+	if ctx.Bool("probe") {
+		method, path = http.MethodPost, "/capabilities/probe"
+	}
+	var matrix CapabilityMatrix
+	err := mustNewAdminClient(ctx).doJSON(method, path, nil, nil, &matrix)
+	logger.FatalIf(err, "Unable to fetch capabilities")
+	printJSON(matrix)
+}
+
+func adminTenantsMain(ctx *cli.Context) {
+	var status TenantsStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/tenants", nil, nil, &status)
+	logger.FatalIf(err, "Unable to fetch tenants")
+	printJSON(status)
+}
+
+func adminChaosStatusMain(ctx *cli.Context) {
+	var status ChaosStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/chaos", nil, nil, &status)
+	logger.FatalIf(err, "Unable to fetch the injected faults")
+	printJSON(status)
+}
+
+func adminChaosSetMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "set", 1)
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	logger.FatalIf(err, "Unable to read the fault injection rules")
+
+	var status ChaosStatus
+	err = mustNewAdminClient(ctx).doJSON(http.MethodPut, "/chaos", nil, data, &status)
+	logger.FatalIf(err, "Unable to set the injected faults")
+	printJSON(status)
+}
+
+func adminChaosClearMain(ctx *cli.Context) {
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPut, "/chaos", nil, []byte(`{"rules": []}`))
+	logger.FatalIf(err, "Unable to clear the injected faults")
+	resp.Body.Close()
+	fmt.Println("Fault injection stopped.")
+}
+
+func adminBatchStartMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "start", 1)
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	logger.FatalIf(err, "Unable to read batch job")
+
+	var status BatchJobStatus
+	err = mustNewAdminClient(ctx).doJSON(http.MethodPost, "/batch", nil, data, &status)
+	logger.FatalIf(err, "Unable to start batch job")
+	printJSON(status)
+}
+
+func adminBatchListMain(ctx *cli.Context) {
+	var statuses []BatchJobStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/batch", nil, nil, &statuses)
+	logger.FatalIf(err, "Unable to list batch jobs")
+	printJSON(statuses)
+}
+
+func adminBatchStatusMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "status", 1)
+	}
+	var status BatchJobStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/batch/"+url.PathEscape(ctx.Args().First()), nil, nil, &status)
+	logger.FatalIf(err, "Unable to fetch batch job status")
+	printJSON(status)
+}
+
+func adminBatchCancelMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "cancel", 1)
+	}
+	resp, err := mustNewAdminClient(ctx).do(http.MethodDelete, "/batch/"+url.PathEscape(ctx.Args().First()), nil, nil)
+	logger.FatalIf(err, "Unable to cancel batch job")
+	resp.Body.Close()
+	fmt.Printf("Batch job %s canceled.\n", ctx.Args().First())
+}
+
+func adminBatchResumeMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "resume", 1)
+	}
+	var status BatchJobStatus
+	err := mustNewAdminClient(ctx).doJSON(http.MethodPost, "/batch/"+url.PathEscape(ctx.Args().First())+"/resume",
+		nil, nil, &status)
+	logger.FatalIf(err, "Unable to resume batch job")
+	printJSON(status)
+}
+
+// lookupConfigKey - returns the value at a dotted key path such as
+// 'cache.quota' or 'mirror.0.local.bucket'.
+func lookupConfigKey(doc interface{}, key string) (interface{}, bool) {
+	for _, field := range strings.Split(key, ".") {
+		switch v := doc.(type) {
+		case map[interface{}]interface{}:
+			var ok bool
+			if doc, ok = v[field]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(field)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+func adminConfigGetMain(ctx *cli.Context) {
+	resp, err := mustNewAdminClient(ctx).do(http.MethodGet, "/config", nil, nil)
+	logger.FatalIf(err, "Unable to get server config")
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	logger.FatalIf(err, "Unable to get server config")
+
+	if !ctx.Args().Present() {
+		os.Stdout.Write(data)
+		return
+	}
+
+	var doc interface{}
+	logger.FatalIf(yaml.Unmarshal(data, &doc), "Unable to parse server config")
+	value, ok := lookupConfigKey(doc, ctx.Args().First())
+	if !ok {
+		logger.FatalIf(fmt.Errorf("key %s not found", ctx.Args().First()), "Unable to get server config")
+	}
+	data, err = yaml.Marshal(value)
+	logger.FatalIf(err, "Unable to encode config value")
+	os.Stdout.Write(data)
+}
+
+func adminConfigSetMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "set", 1)
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	logger.FatalIf(err, "Unable to read config file")
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPut, "/config", nil, data)
+	logger.FatalIf(err, "Unable to set server config")
+	resp.Body.Close()
+	fmt.Println("Server config updated, restart the server to apply the changes.")
+}
+
+func adminConfigHistoryMain(ctx *cli.Context) {
+	clnt := mustNewAdminClient(ctx)
+	query := url.Values{}
+	query.Set("cursor", ctx.String("cursor"))
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		var history ConfigAuditResponse
+		err := clnt.doJSON(http.MethodGet, "/config/history", query, nil, &history)
+		logger.FatalIf(err, "Unable to fetch config changes after cursor %s", query.Get("cursor"))
+		for _, e := range history.Entries {
+			enc.Encode(e)
+		}
+		if len(history.Entries) == 0 {
+			return
+		}
+		query.Set("cursor", strconv.FormatUint(history.Cursor, 10))
+	}
+}
+
+func adminPresignMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		cli.ShowCommandHelpAndExit(ctx, "presign", 1)
+	}
+	query := url.Values{}
+	query.Set("bucket", ctx.Args().Get(0))
+	query.Set("object", ctx.Args().Get(1))
+	query.Set("method", ctx.String("method"))
+	query.Set("expiry", ctx.Duration("expiry").String())
+	query.Set("access-key", ctx.String("sign-key"))
+	query.Set("endpoint", ctx.String("url"))
+	for _, override := range ctx.StringSlice("override") {
+		kv := strings.SplitN(override, "=", 2)
+		if len(kv) != 2 {
+			logger.FatalIf(fmt.Errorf("%q is not KEY=VALUE", override), "Invalid override")
+		}
+		query.Add(kv[0], kv[1])
+	}
+
+	var result PresignResult
+	err := mustNewAdminClient(ctx).doJSON(http.MethodPost, "/presign", query, nil, &result)
+	logger.FatalIf(err, "Unable to presign URL")
+	fmt.Println(result.URL)
+}
+
+func adminUploadTokenMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "upload-token", 1)
+	}
+	query := url.Values{}
+	query.Set("bucket", ctx.Args().Get(0))
+	query.Set("prefix", ctx.String("prefix"))
+	query.Set("max-size", ctx.String("max-size"))
+	query.Set("expiry", ctx.Duration("expiry").String())
+
+	var result UploadTokenResult
+	err := mustNewAdminClient(ctx).doJSON(http.MethodPost, "/upload-token", query, nil, &result)
+	logger.FatalIf(err, "Unable to mint upload token")
+	printJSON(result)
+}
+
+func adminChangesMain(ctx *cli.Context) {
+	clnt := mustNewAdminClient(ctx)
+	query := url.Values{}
+	query.Set("cursor", ctx.String("cursor"))
+	query.Set("bucket", ctx.String("bucket"))
+	query.Set("prefix", ctx.String("prefix"))
+	if ctx.Bool("follow") {
+		query.Set("wait", time.Minute.String())
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		var changes ChangesResponse
+		err := clnt.doJSON(http.MethodGet, "/changes", query, nil, &changes)
+		logger.FatalIf(err, "Unable to fetch changes after cursor %s", query.Get("cursor"))
+		for _, ev := range changes.Events {
+			enc.Encode(ev)
+		}
+		cursor := strconv.FormatUint(changes.Cursor, 10)
+		if cursor == query.Get("cursor") && !ctx.Bool("follow") {
+			// All changes were printed.
+			return
+		}
+		query.Set("cursor", cursor)
+	}
+}
+
+func adminProfileMain(ctx *cli.Context) {
+	query := url.Values{}
+	query.Set("type", ctx.String("type"))
+	query.Set("duration", ctx.Duration("duration").String())
+
+	resp, err := mustNewAdminClient(ctx).do(http.MethodPost, "/profile", query, nil)
+	logger.FatalIf(err, "Unable to capture %s profile", ctx.String("type"))
+	defer resp.Body.Close()
+
+	output := ctx.String("output")
+	if output == "" {
+		_, params, err := mime.ParseMediaType(resp.Header.Get(xhttp.ContentDisposition))
+		logger.FatalIf(err, "Unable to read the profile name")
+		output = filepath.Base(params["filename"])
+	}
+	f, err := os.Create(output)
+	logger.FatalIf(err, "Unable to create %s", output)
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	logger.FatalIf(err, "Unable to write %s", output)
+	fmt.Println("Profile written to", output)
+}
+
+func adminUsageMain(ctx *cli.Context) {
+	query := url.Values{}
+	query.Set("from", ctx.String("from"))
+	query.Set("to", ctx.String("to"))
+	query.Set("access_key", ctx.String("key"))
+
+	var report UsageReport
+	err := mustNewAdminClient(ctx).doJSON(http.MethodGet, "/usage", query, nil, &report)
+	logger.FatalIf(err, "Unable to fetch the usage report")
+	printJSON(report)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultProfileDuration = 30 * time.Second
+	maxProfileDuration     = 5 * time.Minute
+)
+
+// profileTypes - the profiles captured by captureProfile, true for those
+// recorded for a duration, false for snapshots.
+var profileTypes = map[string]bool{
+	"cpu":          true,
+	"trace":        true,
+	"block":        true,
+	"mutex":        true,
+	"heap":         false,
+	"allocs":       false,
+	"goroutine":    false,
+	"threadcreate": false,
+}
+
+var errProfilerBusy = errors.New("a profile is already being captured")
+
+// profiling - 1 while captureProfile runs, one profile is captured at
+// a time.
+var profiling int32
+
+// captureProfile - returns the profile of profileType, recorded for
+// duration unless it is a snapshot. Recording stops early once ctx is
+// canceled.
+func captureProfile(ctx context.Context, profileType string, duration time.Duration) ([]byte, error) {
+	timed, ok := profileTypes[profileType]
+	if !ok {
+		return nil, errors.New("unknown profile type " + profileType)
+	}
+	if !atomic.CompareAndSwapInt32(&profiling, 0, 1) {
+		return nil, errProfilerBusy
+	}
+	defer atomic.StoreInt32(&profiling, 0)
+
+	wait := func() {
+		t := time.NewTimer(duration)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+	}
+
+	var buf bytes.Buffer
+	switch profileType {
+	case "cpu":
+		if err := rpprof.StartCPUProfile(&buf); err != nil {
+			return nil, err
+		}
+		wait()
+		rpprof.StopCPUProfile()
+	case "trace":
+		if err := trace.Start(&buf); err != nil {
+			return nil, err
+		}
+		wait()
+		trace.Stop()
+	case "block":
+		runtime.SetBlockProfileRate(1)
+		wait()
+		runtime.SetBlockProfileRate(0)
+	case "mutex":
+		prev := runtime.SetMutexProfileFraction(1)
+		wait()
+		runtime.SetMutexProfileFraction(prev)
+	}
+	if err := ctx.Err(); timed && err != nil {
+		return nil, err
+	}
+	if profileType != "cpu" && profileType != "trace" {
+		if err := rpprof.Lookup(profileType).WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// pprofAuthHandler - serves a profile to the same basic credentials as
+// the console.
+func pprofAuthHandler(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !checkConsoleAuth(w, r) {
+			return
+		}
+		h(w, r)
+	}
+}
+
+// newPprofHandler - returns the handler of the Go profiles, kept off the
+// S3 listener so profiling can be firewalled separately.
+func newPprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprofAuthHandler(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", pprofAuthHandler(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", pprofAuthHandler(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", pprofAuthHandler(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", pprofAuthHandler(pprof.Trace))
+	return mux
+}
+
+// startPprofServer - serves the Go profiles on addr, with TLS when the
+// server has certificates.
+func startPprofServer(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if globalTLSCerts != nil {
+		l = tls.NewListener(l, &tls.Config{
+			GetCertificate: globalTLSCerts.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		})
+	}
+	server := &http.Server{Handler: newPprofHandler()}
+	go server.Serve(l)
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+)
+
+func TestPprofHandlerAuth(t *testing.T) {
+	defer func(cred auth.Credentials) { globalAdminCred = cred }(globalAdminCred)
+
+	handler := newPprofHandler()
+	serve := func(accessKey, secretKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+		if accessKey != "" {
+			req.SetBasicAuth(accessKey, secretKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Without an admin credential the profiles are not served.
+	globalAdminCred = auth.Credentials{}
+	if code := serve("admin", "secret"); code != http.StatusNotFound {
+		t.Fatalf("expected %d without admin credential, got %d", http.StatusNotFound, code)
+	}
+
+	cred, err := auth.CreateCredentials("ZX7mIIOGC12QBMJ45F0Z", "7ule1ga5JMfMmQXCoEPNcM2jij")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalAdminCred = cred
+
+	testCases := []struct {
+		accessKey, secretKey string
+		code                 int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{cred.AccessKey, "wrong-secret-key", http.StatusUnauthorized},
+		{cred.AccessKey, cred.SecretKey, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		if code := serve(testCase.accessKey, testCase.secretKey); code != testCase.code {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.code, code)
+		}
+	}
+}
+
+func TestCaptureProfile(t *testing.T) {
+	for _, profileType := range []string{"cpu", "trace", "mutex", "heap", "goroutine"} {
+		data, err := captureProfile(context.Background(), profileType, 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("%s: %v", profileType, err)
+		}
+		if len(data) == 0 {
+			t.Errorf("%s: expected a profile", profileType)
+		}
+	}
+
+	if _, err := captureProfile(context.Background(), "unknown", time.Second); err == nil {
+		t.Error("expected an unknown profile type to fail")
+	}
+
+	// Recording stops once the client went away.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := captureProfile(ctx, "cpu", time.Minute); err != context.Canceled {
+		t.Errorf("expected a canceled capture to fail, got %v", err)
+	}
+
+	// One profile is captured at a time.
+	atomic.StoreInt32(&profiling, 1)
+	defer atomic.StoreInt32(&profiling, 0)
+	if _, err := captureProfile(context.Background(), "heap", 0); err != errProfilerBusy {
+		t.Errorf("expected %v, got %v", errProfilerBusy, err)
+	}
+}
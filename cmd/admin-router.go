@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	adminAPIVersion       = "v1"
+	adminAPIPathPrefix    = minioReservedBucketPath + "/admin"
+	adminAPIVersionPrefix = SlashSeparator + adminAPIVersion
+)
+
+// registerAdminRouter - add handler functions for the admin API.
+func registerAdminRouter(router *mux.Router) {
+	adminAPI := adminAPIHandlers{}
+	adminRouter := router.PathPrefix(adminAPIPathPrefix + adminAPIVersionPrefix).Subrouter()
+
+	// Server info
+	adminRouter.Methods(http.MethodGet).Path("/info").HandlerFunc(httpTraceHdrs(adminAPI.ServerInfoHandler))
+
+	// Runtime statistics
+	adminRouter.Methods(http.MethodGet).Path("/runtime").HandlerFunc(httpTraceHdrs(adminAPI.RuntimeInfoHandler))
+
+	// Heal a mirrored bucket
+	adminRouter.Methods(http.MethodPost).Path("/heal/{bucket}").HandlerFunc(httpTraceHdrs(adminAPI.HealHandler))
+
+	// Report objects diverging across the remotes of a mirrored bucket
+	adminRouter.Methods(http.MethodGet).Path("/report/{bucket}").HandlerFunc(httpTraceHdrs(adminAPI.ConsistencyReportHandler))
+
+	// Purge cached objects
+	adminRouter.Methods(http.MethodPost).Path("/cache/purge").HandlerFunc(httpTraceHdrs(adminAPI.CachePurgeHandler))
+
+	// Profiling
+	adminRouter.Methods(http.MethodPost).Path("/profile").HandlerFunc(httpTraceHdrs(adminAPI.ProfileHandler))
+
+	// HTTP trace
+	adminRouter.Methods(http.MethodGet).Path("/trace").HandlerFunc(adminAPI.TraceHandler)
+
+	// Config get/set
+	adminRouter.Methods(http.MethodGet).Path("/config").HandlerFunc(httpTraceHdrs(adminAPI.GetConfigHandler))
+	adminRouter.Methods(http.MethodPut).Path("/config").HandlerFunc(httpTraceHdrs(adminAPI.SetConfigHandler))
+	adminRouter.Methods(http.MethodGet).Path("/config/history").HandlerFunc(httpTraceHdrs(adminAPI.ConfigHistoryHandler))
+
+	// Read-only mode and maintenance of remotes
+	adminRouter.Methods(http.MethodGet).Path("/maintenance").HandlerFunc(httpTraceHdrs(adminAPI.MaintenanceStatusHandler))
+	adminRouter.Methods(http.MethodPut).Path("/maintenance/read-only").HandlerFunc(httpTraceHdrs(adminAPI.SetReadOnlyHandler))
+	adminRouter.Methods(http.MethodPut).Path("/maintenance/remote").HandlerFunc(httpTraceHdrs(adminAPI.SetRemoteMaintenanceHandler))
+
+	// Write fences
+	adminRouter.Methods(http.MethodGet).Path("/fences").HandlerFunc(httpTraceHdrs(adminAPI.FencesHandler))
+	adminRouter.Methods(http.MethodPut).Path("/fences/{bucket}").HandlerFunc(httpTraceHdrs(adminAPI.SetFenceHandler))
+	adminRouter.Methods(http.MethodDelete).Path("/fences/{bucket}").HandlerFunc(httpTraceHdrs(adminAPI.LiftFenceHandler))
+
+	// Capabilities of remotes
+	adminRouter.Methods(http.MethodGet).Path("/capabilities").HandlerFunc(httpTraceHdrs(adminAPI.CapabilitiesHandler))
+	adminRouter.Methods(http.MethodPost).Path("/capabilities/probe").HandlerFunc(httpTraceHdrs(adminAPI.ProbeCapabilitiesHandler))
+
+	// Batch jobs
+	adminRouter.Methods(http.MethodPost).Path("/batch").HandlerFunc(httpTraceHdrs(adminAPI.StartBatchJobHandler))
+	adminRouter.Methods(http.MethodGet).Path("/batch").HandlerFunc(httpTraceHdrs(adminAPI.ListBatchJobsHandler))
+	adminRouter.Methods(http.MethodGet).Path("/batch/{id}").HandlerFunc(httpTraceHdrs(adminAPI.BatchJobStatusHandler))
+	adminRouter.Methods(http.MethodDelete).Path("/batch/{id}").HandlerFunc(httpTraceHdrs(adminAPI.CancelBatchJobHandler))
+	adminRouter.Methods(http.MethodPost).Path("/batch/{id}/resume").HandlerFunc(httpTraceHdrs(adminAPI.ResumeBatchJobHandler))
+
+	// Presigned URLs
+	adminRouter.Methods(http.MethodPost).Path("/presign").HandlerFunc(httpTraceHdrs(adminAPI.PresignHandler))
+	// Mint upload tokens
+	adminRouter.Methods(http.MethodPost).Path("/upload-token").HandlerFunc(httpTraceHdrs(adminAPI.UploadTokenHandler))
+
+	// Change feed
+	adminRouter.Methods(http.MethodGet).Path("/changes").HandlerFunc(httpTraceHdrs(adminAPI.ChangesHandler))
+
+	// Usage report
+	adminRouter.Methods(http.MethodGet).Path("/usage").HandlerFunc(httpTraceHdrs(adminAPI.UsageReportHandler))
+
+	// Tenants
+	adminRouter.Methods(http.MethodGet).Path("/tenants").HandlerFunc(httpTraceHdrs(adminAPI.TenantsHandler))
+
+	// Fault injection, with --chaos
+	adminRouter.Methods(http.MethodGet).Path("/chaos").HandlerFunc(httpTraceHdrs(adminAPI.ChaosStatusHandler))
+	adminRouter.Methods(http.MethodPut).Path("/chaos").HandlerFunc(httpTraceHdrs(adminAPI.SetChaosHandler))
+
+	// If none of the routes match add default error handler routes
+	adminRouter.NotFoundHandler = http.HandlerFunc(httpTraceAll(errorResponseHandler))
+	adminRouter.MethodNotAllowedHandler = http.HandlerFunc(httpTraceAll(errorResponseHandler))
+}
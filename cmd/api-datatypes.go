@@ -1,10 +1,20 @@
 package cmd
 
+import "encoding/xml"
+
 // ObjectIdentifier carries key name for the object to delete.
 type ObjectIdentifier struct {
 	ObjectName string `xml:"Key"`
 }
 
+// RestoreObjectRequest - xml of a restore request, radio only checks it
+// is well formed and passes it to the remotes as is.
+type RestoreObjectRequest struct {
+	XMLName xml.Name `xml:"RestoreRequest"`
+	Days    int      `xml:"Days,omitempty"`
+	Tier    string   `xml:"GlacierJobParameters>Tier,omitempty"`
+}
+
 // DeleteObjectsRequest - xml carrying the object key names which needs to be deleted.
 type DeleteObjectsRequest struct {
 	// Element to enable quiet mode for the request
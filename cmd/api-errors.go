@@ -57,12 +57,15 @@ const (
 	ErrInvalidMaxUploads
 	ErrInvalidMaxParts
 	ErrInvalidPartNumberMarker
+	ErrInvalidObjectAttributes
 	ErrInvalidRequestBody
 	ErrInvalidCopySource
 	ErrInvalidMetadataDirective
 	ErrInvalidCopyDest
 	ErrInvalidPolicyDocument
 	ErrInvalidObjectState
+	ErrRestoreAlreadyInProgress
+	ErrInvalidWriteOffset
 	ErrMalformedXML
 	ErrMissingContentLength
 	ErrMissingContentMD5
@@ -70,6 +73,7 @@ const (
 	ErrNoSuchBucket
 	ErrNoSuchBucketPolicy
 	ErrNoSuchBucketLifecycle
+	ErrNoSuchWebsiteConfiguration
 	ErrNoSuchKey
 	ErrNoSuchUpload
 	ErrNoSuchVersion
@@ -175,6 +179,25 @@ const (
 	// Radio storage class error codes
 	ErrInvalidStorageClass
 	ErrBackendDown
+	ErrAdminConfigTooLarge
+	ErrAdminConfigInvalid
+	ErrAdminCacheDisabled
+	ErrChecksumMismatch
+	ErrInvalidChecksum
+	ErrServerReadOnly
+	ErrAdminBatchJobInvalid
+	ErrAdminNoSuchBatchJob
+	ErrContentInfected
+	ErrContentScanFailed
+	ErrAdminChangeFeedDisabled
+	ErrAdminChangeCursorExpired
+	ErrAdminUsageDisabled
+	ErrAdminConfigAuditDisabled
+	ErrAdminProfilerBusy
+	ErrAdminChaosDisabled
+	ErrAdminChaosInvalid
+	ErrTenantQuotaExceeded
+	ErrBucketWriteFenced
 	// Add new extended error codes here.
 	// Please open a https://github.com/minio/minio/issues before adding
 	// new error codes here.
@@ -337,6 +360,11 @@ var errorCodes = errorCodeMap{
 		Description:    "Argument partNumberMarker must be an integer.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidObjectAttributes: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid attribute name specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidPolicyDocument: {
 		Code:           "InvalidPolicyDocument",
 		Description:    "The content of the form does not meet the conditions specified in the policy document.",
@@ -432,6 +460,11 @@ var errorCodes = errorCodeMap{
 		Description:    "The bucket lifecycle configuration does not exist",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrNoSuchWebsiteConfiguration: {
+		Code:           "NoSuchWebsiteConfiguration",
+		Description:    "The specified bucket does not have a website configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrNoSuchKey: {
 		Code:           "NoSuchKey",
 		Description:    "The specified key does not exist.",
@@ -487,6 +520,16 @@ var errorCodes = errorCodeMap{
 		Description:    "The operation is not valid for the current state of the object.",
 		HTTPStatusCode: http.StatusForbidden,
 	},
+	ErrRestoreAlreadyInProgress: {
+		Code:           "RestoreAlreadyInProgress",
+		Description:    "Object restore is already in progress.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrInvalidWriteOffset: {
+		Code:           "InvalidWriteOffset",
+		Description:    "The write offset value that you specified does not match the current object size.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrAuthorizationHeaderMalformed: {
 		Code:           "AuthorizationHeaderMalformed",
 		Description:    "The authorization header is malformed; the region is wrong; expecting 'us-east-1'.",
@@ -1281,6 +1324,106 @@ var errorCodes = errorCodeMap{
 		Description:    "Invalid according to Policy: Policy Condition failed",
 		HTTPStatusCode: http.StatusForbidden,
 	},
+	ErrServerNotInitialized: {
+		Code:           "XMinioServerNotInitialized",
+		Description:    "Server not initialized, please try again.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrAdminConfigTooLarge: {
+		Code:           "XRadioAdminConfigTooLarge",
+		Description:    "Configuration data provided exceeds the allowed maximum of 1MiB.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminConfigInvalid: {
+		Code:           "XRadioAdminConfigInvalid",
+		Description:    "The configuration provided is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminCacheDisabled: {
+		Code:           "XRadioAdminCacheDisabled",
+		Description:    "Caching is not enabled on this server.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrChecksumMismatch: {
+		Code:           "BadDigest",
+		Description:    "The checksum you specified did not match the calculated checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidChecksum: {
+		Code:           "InvalidRequest",
+		Description:    "Value for x-amz-checksum or x-amz-trailer header is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrServerReadOnly: {
+		Code:           "XRadioServerReadOnly",
+		Description:    "Server is in read-only mode, writes are not accepted.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrAdminBatchJobInvalid: {
+		Code:           "XRadioAdminBatchJobInvalid",
+		Description:    "The batch job provided is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchBatchJob: {
+		Code:           "XRadioAdminNoSuchBatchJob",
+		Description:    "The specified batch job does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrContentInfected: {
+		Code:           "XRadioContentInfected",
+		Description:    "The content you uploaded was rejected by the content scanner.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrContentScanFailed: {
+		Code:           "XRadioContentScanFailed",
+		Description:    "The content you uploaded could not be scanned, please try again.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrAdminChangeFeedDisabled: {
+		Code:           "XRadioAdminChangeFeedDisabled",
+		Description:    "The change feed is not enabled on this server.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminChangeCursorExpired: {
+		Code:           "XRadioAdminChangeCursorExpired",
+		Description:    "The changes after the cursor are no longer retained, resynchronize and restart from the latest cursor.",
+		HTTPStatusCode: http.StatusGone,
+	},
+	ErrAdminUsageDisabled: {
+		Code:           "XRadioAdminUsageDisabled",
+		Description:    "Usage accounting is not enabled on this server.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminConfigAuditDisabled: {
+		Code:           "XRadioAdminConfigAuditDisabled",
+		Description:    "The config audit log is not enabled on this server.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminProfilerBusy: {
+		Code:           "XRadioAdminProfilerBusy",
+		Description:    "A profile is already being captured on this server, please try again later.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminChaosDisabled: {
+		Code:           "XRadioAdminChaosDisabled",
+		Description:    "Fault injection is not enabled on this server, start it with --chaos.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminChaosInvalid: {
+		Code:           "XRadioAdminChaosInvalid",
+		Description:    "The fault injection rules are invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTenantQuotaExceeded: {
+		Code:           "XRadioTenantQuotaExceeded",
+		Description:    "The upload exceeds the storage quota of the tenant owning the bucket.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrBucketWriteFenced: {
+		Code:           "XRadioBucketWriteFenced",
+		Description:    "Writes to the bucket are fenced, it is only served for reads.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 	// Add your error structure here.
 }
 
@@ -1332,6 +1475,12 @@ func toAPIErrorCode(ctx context.Context, err error) (apiErr APIErrorCode) {
 		apiErr = ErrStorageFull
 	case hash.BadDigest:
 		apiErr = ErrBadDigest
+	case ChecksumMismatch:
+		apiErr = ErrChecksumMismatch
+	case ContentInfected:
+		apiErr = ErrContentInfected
+	case ContentScanFailed:
+		apiErr = ErrContentScanFailed
 	case AllAccessDisabled:
 		apiErr = ErrAllAccessDisabled
 	case IncompleteBody:
@@ -1360,12 +1509,20 @@ func toAPIErrorCode(ctx context.Context, err error) (apiErr APIErrorCode) {
 		apiErr = ErrMethodNotAllowed
 	case ObjectNameInvalid:
 		apiErr = ErrInvalidObjectName
+	case InvalidObjectState:
+		apiErr = ErrInvalidObjectState
+	case ObjectRestoreInProgress:
+		apiErr = ErrRestoreAlreadyInProgress
+	case InvalidWriteOffset:
+		apiErr = ErrInvalidWriteOffset
 	case ObjectNamePrefixAsSlash:
 		apiErr = ErrInvalidObjectNamePrefixSlash
 	case InvalidUploadID:
 		apiErr = ErrNoSuchUpload
 	case InvalidPart:
 		apiErr = ErrInvalidPart
+	case InvalidPartOrder:
+		apiErr = ErrInvalidPartOrder
 	case InsufficientWriteQuorum:
 		apiErr = ErrSlowDown
 	case InsufficientReadQuorum:
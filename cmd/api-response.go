@@ -261,8 +261,9 @@ type Object struct {
 	ETag         string
 	Size         int64
 
-	// Owner of the object.
-	Owner Owner
+	// Owner of the object, listed by ListObjectsV2 with fetch-owner
+	// only.
+	Owner *Owner `xml:"Owner,omitempty"`
 
 	// The class of storage used to store the object.
 	StorageClass string
@@ -313,6 +314,38 @@ type CompleteMultipartUploadResponse struct {
 	ETag     string
 }
 
+// ObjectAttributesChecksum - additional checksum of an object returned by
+// GetObjectAttributes.
+type ObjectAttributesChecksum struct {
+	ChecksumCRC32  string `xml:",omitempty"`
+	ChecksumCRC32C string `xml:",omitempty"`
+	ChecksumSHA1   string `xml:",omitempty"`
+	ChecksumSHA256 string `xml:",omitempty"`
+}
+
+// ObjectAttributesParts - parts of a multipart object returned by
+// GetObjectAttributes, individual parts are only listed by S3 for
+// objects uploaded with part checksums.
+type ObjectAttributesParts struct {
+	IsTruncated          bool
+	MaxParts             int
+	NextPartNumberMarker int
+	PartNumberMarker     int
+	PartsCount           int
+}
+
+// GetObjectAttributesResponse container for GetObjectAttributes response,
+// only the requested attributes are set.
+type GetObjectAttributesResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ GetObjectAttributesResponse" json:"-"`
+
+	ETag         string                    `xml:",omitempty"`
+	Checksum     *ObjectAttributesChecksum `xml:",omitempty"`
+	ObjectParts  *ObjectAttributesParts    `xml:",omitempty"`
+	StorageClass string                    `xml:",omitempty"`
+	ObjectSize   *int64                    `xml:",omitempty"`
+}
+
 // DeleteError structure.
 type DeleteError struct {
 	Code    string
@@ -355,7 +388,7 @@ func getObjectLocation(r *http.Request, domains []string, bucket, object string)
 	}
 	proto := handlers.GetSourceScheme(r)
 	if proto == "" {
-		proto = getURLScheme(globalIsSSL)
+		proto = getURLScheme(r.TLS != nil)
 	}
 	u := &url.URL{
 		Host:   r.Host,
@@ -440,14 +473,21 @@ func generateListVersionsResponse(bucket, prefix, marker, delimiter, encodingTyp
 	return data
 }
 
+// requestOwner - returns the owner of the objects listed to the request,
+// the radio user whose access key signed it.
+func requestOwner(ctx context.Context) Owner {
+	return Owner{
+		ID:          globalRadioDefaultOwnerID,
+		DisplayName: logger.GetReqInfo(ctx).AccessKey,
+	}
+}
+
 // generates an ListObjectsV1 response for the said bucket with other enumerated options.
-func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
+func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType string, maxKeys int, owner Owner, resp ListObjectsInfo) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
-	var owner = Owner{}
 	var data = ListObjectsResponse{}
 
-	owner.ID = globalRadioDefaultOwnerID
 	for _, object := range resp.Objects {
 		var content = Object{}
 		if object.Name == "" {
@@ -460,7 +500,7 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingTy
 		}
 		content.Size = object.Size
 		content.StorageClass = object.StorageClass
-		content.Owner = owner
+		content.Owner = &owner
 		contents = append(contents, content)
 	}
 	data.Name = bucket
@@ -484,16 +524,11 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingTy
 }
 
 // generates an ListObjectsV2 response for the said bucket with other enumerated options.
-func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter, encodingType string, fetchOwner, isTruncated bool, maxKeys int, objects []ObjectInfo, prefixes []string, metadata bool) ListObjectsV2Response {
+func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter, encodingType string, fetchOwner, isTruncated bool, maxKeys int, owner Owner, objects []ObjectInfo, prefixes []string, metadata bool) ListObjectsV2Response {
 	var contents []Object
 	var commonPrefixes []CommonPrefix
-	var owner = Owner{}
 	var data = ListObjectsV2Response{}
 
-	if fetchOwner {
-		owner.ID = globalRadioDefaultOwnerID
-	}
-
 	for _, object := range objects {
 		var content = Object{}
 		if object.Name == "" {
@@ -506,7 +541,9 @@ func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter,
 		}
 		content.Size = object.Size
 		content.StorageClass = object.StorageClass
-		content.Owner = owner
+		if fetchOwner {
+			content.Owner = &owner
+		}
 		if metadata {
 			content.UserMetadata = make(StringMap)
 			for k, v := range CleanMinioInternalMetadataKeys(object.UserDefined) {
@@ -557,6 +594,58 @@ func generateCopyObjectPartResponse(etag string, lastModified time.Time) CopyObj
 	}
 }
 
+// generates GetObjectAttributesResponse with the requested attributes of
+// objInfo, parts are counted from the multipart ETag.
+func generateGetObjectAttributesResponse(objInfo ObjectInfo, attrs map[string]bool, partNumberMarker, maxParts int) GetObjectAttributesResponse {
+	var resp GetObjectAttributesResponse
+	if attrs["ETag"] {
+		resp.ETag = objInfo.ETag
+	}
+	if attrs["Checksum"] {
+		var checksum ObjectAttributesChecksum
+		for k, v := range objInfo.UserDefined {
+			if !HasPrefix(k, checksumMetaPrefix) {
+				continue
+			}
+			switch "X-Amz-Checksum-" + strings.TrimPrefix(k, checksumMetaPrefix) {
+			case xhttp.AmzChecksumCRC32:
+				checksum.ChecksumCRC32 = v
+			case xhttp.AmzChecksumCRC32C:
+				checksum.ChecksumCRC32C = v
+			case xhttp.AmzChecksumSHA1:
+				checksum.ChecksumSHA1 = v
+			case xhttp.AmzChecksumSHA256:
+				checksum.ChecksumSHA256 = v
+			}
+		}
+		if checksum != (ObjectAttributesChecksum{}) {
+			resp.Checksum = &checksum
+		}
+	}
+	if attrs["ObjectParts"] {
+		if i := strings.LastIndex(objInfo.ETag, "-"); i >= 0 {
+			if partsCount, err := strconv.Atoi(objInfo.ETag[i+1:]); err == nil {
+				resp.ObjectParts = &ObjectAttributesParts{
+					MaxParts:         maxParts,
+					PartNumberMarker: partNumberMarker,
+					PartsCount:       partsCount,
+				}
+			}
+		}
+	}
+	if attrs["StorageClass"] {
+		resp.StorageClass = objInfo.StorageClass
+		if resp.StorageClass == "" {
+			resp.StorageClass = globalRadioDefaultStorageClass
+		}
+	}
+	if attrs["ObjectSize"] {
+		size := objInfo.Size
+		resp.ObjectSize = &size
+	}
+	return resp
+}
+
 // generates InitiateMultipartUploadResponse for given bucket, key and uploadID.
 func generateInitiateMultipartUploadResponse(bucket, key, uploadID string) InitiateMultipartUploadResponse {
 	return InitiateMultipartUploadResponse{
@@ -662,6 +751,8 @@ type mimeType string
 const (
 	// Means no response type.
 	mimeNone mimeType = ""
+	// Means response type is JSON.
+	mimeJSON mimeType = "application/json"
 	// Means response type is XML.
 	mimeXML mimeType = "application/xml"
 )
@@ -672,6 +763,12 @@ func writeSuccessResponseXML(w http.ResponseWriter, response []byte) {
 	writeResponse(w, http.StatusOK, response, mimeXML)
 }
 
+// writeSuccessResponseJSON writes success headers and response if any,
+// with content-type set to `application/json`.
+func writeSuccessResponseJSON(w http.ResponseWriter, response []byte) {
+	writeResponse(w, http.StatusOK, response, mimeJSON)
+}
+
 // writeSuccessNoContent writes success headers with http status 204
 func writeSuccessNoContent(w http.ResponseWriter) {
 	writeResponse(w, http.StatusNoContent, nil, mimeNone)
@@ -699,8 +796,17 @@ func writeErrorResponse(ctx context.Context, w http.ResponseWriter, err APIError
 	// Generate error response.
 	errorResponse := getAPIErrorResponse(ctx, err, reqURL.Path,
 		w.Header().Get(xhttp.AmzRequestID), globalDeploymentID)
-	encodedErrorResponse := encodeResponse(errorResponse)
-	writeResponse(w, err.HTTPStatusCode, encodedErrorResponse, mimeXML)
+	writeAPIErrorResponse(ctx, w, err.HTTPStatusCode, errorResponse)
+}
+
+// writeAPIErrorResponse - encodes the error response as XML, or as JSON
+// when the client opted in for JSON errors.
+func writeAPIErrorResponse(ctx context.Context, w http.ResponseWriter, statusCode int, errorResponse APIErrorResponse) {
+	if wantsJSONErrors(ctx) {
+		writeResponse(w, statusCode, encodeResponseJSON(errorResponse), mimeJSON)
+		return
+	}
+	writeResponse(w, statusCode, encodeResponse(errorResponse), mimeXML)
 }
 
 func writeErrorResponseHeadersOnly(w http.ResponseWriter, err APIError) {
@@ -734,6 +840,5 @@ func writeCustomErrorResponseXML(ctx context.Context, w http.ResponseWriter, err
 		HostID:     globalDeploymentID,
 	}
 
-	encodedErrorResponse := encodeResponse(errorResponse)
-	writeResponse(w, err.HTTPStatusCode, encodedErrorResponse, mimeXML)
+	writeAPIErrorResponse(ctx, w, err.HTTPStatusCode, errorResponse)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateGetObjectAttributesResponse(t *testing.T) {
+	objInfo := ObjectInfo{
+		ETag: "9b2cf535f27731c974343645a3985328-3",
+		Size: 15 << 20,
+		UserDefined: map[string]string{
+			"X-Amz-Meta-Radio-Checksum-Crc32c": "yZRlqg==",
+			"X-Amz-Meta-Radio-Tag":             "v1",
+			"Content-Type":                     "application/octet-stream",
+		},
+	}
+	all := map[string]bool{"ETag": true, "Checksum": true, "ObjectParts": true, "StorageClass": true, "ObjectSize": true}
+
+	testCases := []struct {
+		objInfo  ObjectInfo
+		attrs    map[string]bool
+		expected string
+	}{
+		{
+			objInfo, all,
+			`<GetObjectAttributesResponse xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+				`<ETag>9b2cf535f27731c974343645a3985328-3</ETag>` +
+				`<Checksum><ChecksumCRC32C>yZRlqg==</ChecksumCRC32C></Checksum>` +
+				`<ObjectParts><IsTruncated>false</IsTruncated><MaxParts>10000</MaxParts>` +
+				`<NextPartNumberMarker>0</NextPartNumberMarker><PartNumberMarker>0</PartNumberMarker>` +
+				`<PartsCount>3</PartsCount></ObjectParts>` +
+				`<StorageClass>STANDARD</StorageClass><ObjectSize>15728640</ObjectSize>` +
+				`</GetObjectAttributesResponse>`,
+		},
+		// Only the requested attributes.
+		{
+			objInfo, map[string]bool{"ObjectSize": true},
+			`<GetObjectAttributesResponse xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+				`<ObjectSize>15728640</ObjectSize></GetObjectAttributesResponse>`,
+		},
+		// Single part objects without checksums, empty objects have a size.
+		{
+			ObjectInfo{ETag: "d41d8cd98f00b204e9800998ecf8427e", StorageClass: "REDUCED_REDUNDANCY"}, all,
+			`<GetObjectAttributesResponse xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+				`<ETag>d41d8cd98f00b204e9800998ecf8427e</ETag>` +
+				`<StorageClass>REDUCED_REDUNDANCY</StorageClass><ObjectSize>0</ObjectSize>` +
+				`</GetObjectAttributesResponse>`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		resp := generateGetObjectAttributesResponse(testCase.objInfo, testCase.attrs, 0, maxPartsList)
+		data, err := xml.Marshal(resp)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if string(data) != testCase.expected {
+			t.Errorf("Test %d: expected\n%s\ngot\n%s", i+1, testCase.expected, data)
+		}
+	}
+}
+
+func TestGenerateListObjectsV2ResponseOwner(t *testing.T) {
+	objects := []ObjectInfo{{Name: "year=2020/part-0000.parquet", ModTime: time.Unix(0, 0), ETag: "abc", Size: 10}}
+	owner := Owner{ID: globalRadioDefaultOwnerID, DisplayName: "radio"}
+
+	for _, fetchOwner := range []bool{false, true} {
+		resp := generateListObjectsV2Response("bucket", "", "", "", "year=2019/", "", "",
+			fetchOwner, false, 1000, owner, objects, nil, false)
+		data, err := xml.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<StartAfter>year=2019/</StartAfter>") {
+			t.Errorf("expected start-after to be returned, got %s", data)
+		}
+		expected := "<Owner><ID>" + globalRadioDefaultOwnerID + "</ID><DisplayName>radio</DisplayName></Owner>"
+		if strings.Contains(string(data), expected) != fetchOwner || strings.Contains(string(data), "<Owner>") != fetchOwner {
+			t.Errorf("fetch-owner %v: unexpected owner in %s", fetchOwner, data)
+		}
+	}
+
+	// Listings v1 always carry the owner.
+	resp := generateListObjectsV1Response("bucket", "", "", "", "", 1000, owner, ListObjectsInfo{Objects: objects})
+	if resp.Contents[0].Owner == nil || *resp.Contents[0].Owner != owner {
+		t.Errorf("expected the owner to be listed, got %v", resp.Contents[0].Owner)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/minio/minio/pkg/hash"
 	iampolicy "github.com/minio/minio/pkg/iam/policy"
 	"github.com/minio/minio/pkg/policy"
+	"github.com/minio/radio/cmd/logger"
 )
 
 // Verify if request has JWT.
@@ -71,6 +72,7 @@ const (
 	authTypeSignedV2
 	authTypeJWT
 	authTypeSTS
+	authTypeUploadToken
 )
 
 // Get request authentication type.
@@ -85,6 +87,8 @@ func getRequestAuthType(r *http.Request) authType {
 		return authTypeSigned
 	} else if isRequestPresignedSignatureV4(r) {
 		return authTypePresigned
+	} else if isRequestUploadToken(r) {
+		return authTypeUploadToken
 	} else if isRequestJWT(r) {
 		return authTypeJWT
 	} else if isRequestPostPolicySignatureV4(r) {
@@ -122,7 +126,7 @@ func checkClaimsFromToken(r *http.Request, cred auth.Credentials) APIErrorCode {
 func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Action, bucketName, objectName string) (s3Err APIErrorCode) {
 	var cred auth.Credentials
 	switch getRequestAuthType(r) {
-	case authTypeUnknown, authTypeStreamingSigned:
+	case authTypeUnknown, authTypeStreamingSigned, authTypeUploadToken:
 		return ErrAccessDenied
 	case authTypePresignedV2, authTypeSignedV2:
 		if s3Err = isReqAuthenticatedV2(r); s3Err != ErrNone {
@@ -143,8 +147,13 @@ func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Ac
 	if s3Err != ErrNone {
 		return s3Err
 	}
+	logger.GetReqInfo(ctx).AccessKey = cred.AccessKey
 
-	return checkClaimsFromToken(r, cred)
+	if s3Err = checkClaimsFromToken(r, cred); s3Err != ErrNone {
+		return s3Err
+	}
+	traceRequestAccessKey(r.Context(), cred.AccessKey)
+	return postAuthHooks(r, HookAuth{AccessKey: cred.AccessKey, Action: string(action), Bucket: bucketName, Object: objectName})
 }
 
 // Verify if request has valid AWS Signature Version '2'.
@@ -172,7 +181,26 @@ func isReqAuthenticated(ctx context.Context, r *http.Request, region string, sty
 	if errCode := reqSignatureV4Verify(r, region, stype); errCode != ErrNone {
 		return errCode
 	}
+	return verifyReqContentDigests(ctx, r)
+}
 
+// checkAdminRequestAuth - verifies that the request carries an AWS
+// Signature Version '4' made with the admin credential. The admin
+// credential authenticates the admin API only.
+func checkAdminRequestAuth(ctx context.Context, r *http.Request, region string) APIErrorCode {
+	if !globalAdminCred.IsValid() || !isRequestSignatureV4(r) {
+		return ErrAccessDenied
+	}
+	sha256sum := getContentSha256Cksum(r, serviceS3)
+	if errCode := doesSignatureMatchKey(sha256sum, r, region, serviceS3, checkAdminKeyValid); errCode != ErrNone {
+		return errCode
+	}
+	return verifyReqContentDigests(ctx, r)
+}
+
+// verifyReqContentDigests - verifies 'Content-Md5' and 'X-Amz-Content-Sha256'
+// of an authenticated request while its body is read.
+func verifyReqContentDigests(ctx context.Context, r *http.Request) APIErrorCode {
 	var (
 		err                       error
 		contentMD5, contentSHA256 []byte
@@ -230,6 +258,7 @@ var supportedS3AuthTypes = map[authType]struct{}{
 	authTypeSignedV2:        {},
 	authTypePostPolicy:      {},
 	authTypeStreamingSigned: {},
+	authTypeUploadToken:     {},
 }
 
 // Validate if the authType is valid and supported.
@@ -240,32 +269,66 @@ func isSupportedS3AuthType(aType authType) bool {
 
 // handler for validating incoming authorization headers.
 func (a authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requests to the reserved bucket (health, metrics, lock, console
+	// and admin routes) carry their own authentication.
+	if HasPrefix(r.URL.Path, minioReservedBucketPath+SlashSeparator) {
+		a.handler.ServeHTTP(w, r)
+		return
+	}
+	// Static websites are served to anonymous requests.
+	if isWebsiteRequest(r) {
+		a.handler.ServeHTTP(w, r)
+		return
+	}
 	aType := getRequestAuthType(r)
+	if aType == authTypeUploadToken {
+		// Devices with upload tokens are many, requests outside of the
+		// scope of any token are rejected before they are routed.
+		if _, s3Err := verifyUploadToken(r, UTCNow()); s3Err != ErrNone {
+			writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(s3Err), r.URL)
+			return
+		}
+	}
 	if isSupportedS3AuthType(aType) {
 		// Let top level caller validate for anonymous and known signed requests.
 		a.handler.ServeHTTP(w, r)
 		return
 	}
-	writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrSignatureVersionNotSupported), r.URL)
+	writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrSignatureVersionNotSupported), r.URL)
 }
 
-// isPutActionAllowed - check if PUT operation is allowed on the resource, this
-// call verifies bucket policies and IAM policies, supports multi user
-// checks etc.
-func isPutActionAllowed(atype authType, bucketName, objectName string, r *http.Request, action iampolicy.Action) (s3Err APIErrorCode) {
+// getPutActionAuth - returns the access key of a PUT operation on the
+// resource with the action, supports multi user checks etc. The request
+// is not authenticated yet, its signature is verified by the caller,
+// which passes the returned HookAuth to postAuthHooks afterwards.
+func getPutActionAuth(atype authType, bucketName, objectName string, r *http.Request, action iampolicy.Action) (putAuth HookAuth, s3Err APIErrorCode) {
 	var cred auth.Credentials
+	putAuth = HookAuth{Action: string(action), Bucket: bucketName, Object: objectName}
 	switch atype {
 	case authTypeUnknown:
-		return ErrAccessDenied
+		return putAuth, ErrAccessDenied
 	case authTypeSignedV2, authTypePresignedV2:
 		cred, s3Err = getReqAccessKeyV2(r)
 	case authTypeStreamingSigned, authTypePresigned, authTypeSigned:
 		region := globalServerRegion
 		cred, s3Err = getReqAccessKeyV4(r, region, serviceS3)
+	case authTypeUploadToken:
+		// The token is validated here, it is the credential, identified
+		// by its ID.
+		claims, s3Err := checkUploadToken(r, bucketName, objectName, UTCNow())
+		if s3Err != ErrNone {
+			return putAuth, s3Err
+		}
+		putAuth.AccessKey = uploadTokenAccessKeyPrefix + claims.ID
+		return putAuth, ErrNone
 	}
 	if s3Err != ErrNone {
-		return s3Err
+		return putAuth, s3Err
 	}
 
-	return checkClaimsFromToken(r, cred)
+	if s3Err = checkClaimsFromToken(r, cred); s3Err != ErrNone {
+		return putAuth, s3Err
+	}
+	putAuth.AccessKey = cred.AccessKey
+	return putAuth, ErrNone
 }
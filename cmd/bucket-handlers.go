@@ -57,6 +57,66 @@ func (api objectAPIHandlers) GetBucketLocationHandler(w http.ResponseWriter, r *
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 }
 
+// GetBucketWebsiteHandler - GET Bucket website
+// -------------------------
+// Returns the static website of a mirror bucket, configured in the
+// radio config.
+func (api objectAPIHandlers) GetBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetBucketWebsite")
+
+	defer logger.AuditLog(w, r, "GetBucketWebsite")
+
+	bucket, _ := request2BucketObjectName(r)
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, getBucketWebsiteAction, bucket, ""); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(ctx, bucket); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	l, ok := objectAPI.(*radioObjects)
+	if !ok {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNoSuchWebsiteConfiguration), r.URL)
+		return
+	}
+	website, ok := l.website(bucket)
+	if !ok {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNoSuchWebsiteConfiguration), r.URL)
+		return
+	}
+
+	writeSuccessResponseXML(w, encodeResponse(website.toXML()))
+}
+
+// PutBucketWebsiteHandler - PUT and DELETE Bucket website
+// -------------------------
+// Websites are configured in the radio config, changing them with the
+// S3 API is not supported.
+func (api objectAPIHandlers) PutBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "PutBucketWebsite")
+
+	defer logger.AuditLog(w, r, "PutBucketWebsite")
+
+	bucket, _ := request2BucketObjectName(r)
+
+	if s3Error := checkRequestAuthType(ctx, r, putBucketWebsiteAction, bucket, ""); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
+	writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
+}
+
 // ListMultipartUploadsHandler - GET Bucket (List Multipart uploads)
 // -------------------------
 // This operation lists in-progress multipart uploads. An in-progress
@@ -146,6 +206,8 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	bucketsInfo = globalTenants.filterBuckets(logger.GetReqInfo(ctx).AccessKey, bucketsInfo)
+
 	// Generate response.
 	response := generateListBucketsResponse(bucketsInfo)
 	encodedSuccessResponse := encodeResponse(response)
@@ -372,11 +434,25 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	}
 
 	// Verify policy signature.
+	auth := HookAuth{AccessKey: postPolicyAccessKey(formValues),
+		Action: string(policy.PutObjectAction), Bucket: bucket, Object: object}
 	errCode := doesPolicySignatureMatch(formValues)
+	if errCode == ErrNone {
+		if errCode = globalWriteFences.admit(r, auth); errCode == ErrNone {
+			errCode = globalTenants.authorize(r, auth)
+		}
+	}
 	if errCode != ErrNone {
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(errCode), r.URL)
 		return
 	}
+	// The upload counts against the quota of its tenant once it succeeds.
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			globalTenants.refund(r, auth)
+		}
+	}()
 
 	policyBytes, err := base64.StdEncoding.DecodeString(formValues.Get("Policy"))
 	if err != nil {
@@ -423,7 +499,13 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	hashReader, err := hash.NewReader(fileBody, fileSize, "", "", fileSize, globalCLIContext.StrictS3Compat)
+	body, err := globalContentTypes.apply(bucket, object, metadata, fileBody)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	hashReader, err := hash.NewReader(body, fileSize, "", "", fileSize, globalCLIContext.StrictS3Compat)
 	if err != nil {
 		logger.LogIf(ctx, err)
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
@@ -432,13 +514,13 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	rawReader := hashReader
 	pReader := NewPutObjReader(rawReader, nil, nil)
 
-	// get radio encryption options
-	var opts ObjectOptions
+	opts := ObjectOptions{UserDefined: metadata}
 	objInfo, err := objectAPI.PutObject(ctx, bucket, object, pReader, opts)
 	if err != nil {
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
+	uploaded = true
 
 	location := getObjectLocation(r, globalDomainNames, bucket, object)
 	w.Header()[xhttp.ETag] = []string{`"` + objInfo.ETag + `"`}
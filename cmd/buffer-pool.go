@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"io"
+	"sync"
+
+	"github.com/minio/radio/cmd/config/api"
+	"github.com/ncw/directio"
+)
+
+// bufferPool - recycles byte slices of size bytes, so that copies of
+// object data do not allocate a buffer per request.
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// newBufferPool - returns a pool of buffers of size bytes allocated by
+// alloc.
+func newBufferPool(size int, alloc func(int) []byte) *bufferPool {
+	p := &bufferPool{size: size}
+	p.pool.New = func() interface{} {
+		b := alloc(size)
+		return &b
+	}
+	return p
+}
+
+func makeBuffer(size int) []byte {
+	return make([]byte, size)
+}
+
+// get - returns a buffer of the pool.
+func (p *bufferPool) get() *[]byte {
+	return p.pool.Get().(*[]byte)
+}
+
+// put - returns bufp to the pool.
+func (p *bufferPool) put(bufp *[]byte) {
+	if cap(*bufp) < p.size {
+		return
+	}
+	*bufp = (*bufp)[:p.size]
+	p.pool.Put(bufp)
+}
+
+var (
+	// globalCopyBuffers - buffers of the copies between clients and
+	// remotes, replaced once the API config is read.
+	globalCopyBuffers = newBufferPool(api.DefaultCopyBufferSize, makeBuffer)
+
+	// globalCacheBlocks - blocks of the disk cache, shared by all
+	// cache drives.
+	globalCacheBlocks = newBufferPool(int(cacheBlkSize), directio.AlignedBlock)
+)
+
+// copyBuffer - copies src to dst like io.Copy, with a buffer of
+// globalCopyBuffers.
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	bufp := globalCopyBuffers.get()
+	defer globalCopyBuffers.put(bufp)
+	return io.CopyBuffer(dst, src, *bufp)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	p := newBufferPool(1024, makeBuffer)
+	bufp := p.get()
+	if len(*bufp) != 1024 {
+		t.Fatalf("expected a buffer of 1024 bytes, got %d", len(*bufp))
+	}
+	*bufp = (*bufp)[:10]
+	p.put(bufp)
+	if bufp = p.get(); len(*bufp) != 1024 {
+		t.Errorf("expected a recycled buffer of 1024 bytes, got %d", len(*bufp))
+	}
+
+	// Buffers of a previous size are dropped.
+	small := make([]byte, 512)
+	p.put(&small)
+	for i := 0; i < 10; i++ {
+		if bufp = p.get(); len(*bufp) != 1024 {
+			t.Fatalf("expected a buffer of 1024 bytes, got %d", len(*bufp))
+		}
+	}
+
+	data := bytes.Repeat([]byte("radio"), 100000)
+	var dst bytes.Buffer
+	if n, err := copyBuffer(&dst, bytes.NewReader(data)); err != nil || n != int64(len(data)) || !bytes.Equal(dst.Bytes(), data) {
+		t.Errorf("expected %d bytes copied, got %d, %v", len(data), n, err)
+	}
+}
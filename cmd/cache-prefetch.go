@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	// maximum number of clients prefetched for at once, bounds the
+	// memory used to about 1.5 windows per session.
+	prefetchMaxSessions = 64
+	// sessions not read from for this long are dropped.
+	prefetchSessionTimeout = time.Minute
+)
+
+// prefetchKey - identifies the reads of one client from one object.
+type prefetchKey struct {
+	client string
+	bucket string
+	object string
+}
+
+// prefetchSession - state of the sequential reads of one client, data
+// holds the bytes of the object starting at offset start.
+type prefetchSession struct {
+	objInfo    ObjectInfo
+	next       int64
+	start      int64
+	data       []byte
+	loading    bool
+	lastAccess time.Time
+}
+
+// prefetcher - detects clients reading an object in consecutive ranges,
+// such as media players and backup restores, and reads the next window
+// of the object from the backend ahead of their requests.
+type prefetcher struct {
+	mu       sync.Mutex
+	window   int64
+	sessions map[prefetchKey]*prefetchSession
+	stats    *CacheStats
+
+	getObjectNInfoFn func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error)
+}
+
+func newPrefetcher(window int64, stats *CacheStats, getObjectNInfoFn func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error)) *prefetcher {
+	return &prefetcher{
+		window:           window,
+		sessions:         make(map[prefetchKey]*prefetchSession),
+		stats:            stats,
+		getObjectNInfoFn: getObjectNInfoFn,
+	}
+}
+
+// prefetchKeyFromContext - returns the session key of a read, reads of
+// unknown clients are not prefetched.
+func prefetchKeyFromContext(ctx context.Context, bucket, object string) (prefetchKey, bool) {
+	reqInfo := logger.GetReqInfo(ctx)
+	if reqInfo == nil || reqInfo.RemoteHost == "" {
+		return prefetchKey{}, false
+	}
+	return prefetchKey{client: reqInfo.RemoteHost, bucket: bucket, object: object}, true
+}
+
+// isPrefetchRange - only explicit ranges are prefetched, suffix ranges
+// do not indicate sequential reads.
+func isPrefetchRange(rs *HTTPRangeSpec) bool {
+	return rs != nil && !rs.IsSuffixLength && rs.Start >= 0 && rs.End >= rs.Start
+}
+
+// Get - serves the range of an object from the data prefetched for the
+// client, returns false if the range was not prefetched.
+func (p *prefetcher) Get(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, opts ObjectOptions) (*GetObjectReader, bool) {
+	key, ok := prefetchKeyFromContext(ctx, bucket, object)
+	if !ok || !isPrefetchRange(rs) {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	s, ok := p.sessions[key]
+	if !ok {
+		p.mu.Unlock()
+		return nil, false
+	}
+	offset, length, err := rs.GetOffsetLength(s.objInfo.Size)
+	if err != nil || offset < s.start || offset+length > s.start+int64(len(s.data)) {
+		p.mu.Unlock()
+		return nil, false
+	}
+	objInfo := s.objInfo
+	// Prefetched data is never modified, only replaced.
+	data := s.data[offset-s.start : offset-s.start+length]
+	s.next = offset + length
+	s.lastAccess = UTCNow()
+	if s.next-s.start > int64(len(s.data))/2 {
+		p.load(key, s, s.start+int64(len(s.data)))
+	}
+	p.mu.Unlock()
+
+	fn, _, _, err := NewGetObjectReader(rs, objInfo, opts.CheckCopyPrecondFn)
+	if err != nil {
+		return nil, false
+	}
+	gr, err := fn(bytes.NewReader(data), h, opts.CheckCopyPrecondFn)
+	if err != nil {
+		return nil, false
+	}
+	p.stats.incPrefetchHit()
+	p.stats.incBytesServed(length)
+	return gr, true
+}
+
+// Observe - records a range read from the backend, once a client reads
+// consecutive ranges the next window is prefetched.
+func (p *prefetcher) Observe(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, objInfo ObjectInfo) {
+	key, ok := prefetchKeyFromContext(ctx, bucket, object)
+	if !ok || !isPrefetchRange(rs) {
+		return
+	}
+	offset, length, err := rs.GetOffsetLength(objInfo.Size)
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[key]
+	if ok && s.objInfo.ETag == objInfo.ETag && s.next == offset {
+		// Sequential read which missed the prefetched data.
+		p.stats.incPrefetchMiss()
+		s.next = offset + length
+		s.lastAccess = UTCNow()
+		if !s.loading {
+			s.start, s.data = s.next, nil
+			p.load(key, s, s.next)
+		}
+		return
+	}
+
+	p.expire()
+	if !ok && len(p.sessions) >= prefetchMaxSessions {
+		return
+	}
+	p.sessions[key] = &prefetchSession{
+		objInfo:    objInfo,
+		next:       offset + length,
+		start:      offset + length,
+		lastAccess: UTCNow(),
+	}
+}
+
+// load - reads the window following offset from the backend in the
+// background, the caller holds the lock.
+func (p *prefetcher) load(key prefetchKey, s *prefetchSession, offset int64) {
+	if s.loading || offset >= s.objInfo.Size {
+		return
+	}
+	end := offset + p.window
+	if end > s.objInfo.Size {
+		end = s.objInfo.Size
+	}
+	s.loading = true
+
+	go func() {
+		rs := &HTTPRangeSpec{Start: offset, End: end - 1}
+		var data []byte
+		gr, err := p.getObjectNInfoFn(context.Background(), key.bucket, key.object, rs, http.Header{}, ReadLock, ObjectOptions{})
+		if err == nil {
+			data, err = ioutil.ReadAll(gr)
+			gr.Close()
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		s.loading = false
+		if p.sessions[key] != s {
+			// Invalidated meanwhile.
+			return
+		}
+		if err != nil || gr.ObjInfo.ETag != s.objInfo.ETag {
+			delete(p.sessions, key)
+			return
+		}
+		// Keep the data not read yet in front of the new window.
+		if bufEnd := s.start + int64(len(s.data)); offset == bufEnd && s.next >= s.start && s.next <= bufEnd {
+			tail := s.data[s.next-s.start:]
+			s.data = append(append(make([]byte, 0, len(tail)+len(data)), tail...), data...)
+			s.start = s.next
+		} else {
+			s.start, s.data = offset, data
+		}
+	}()
+}
+
+// expire - drops sessions which were not read from recently, the caller
+// holds the lock.
+func (p *prefetcher) expire() {
+	for key, s := range p.sessions {
+		if UTCNow().Sub(s.lastAccess) > prefetchSessionTimeout {
+			delete(p.sessions, key)
+		}
+	}
+}
+
+// Invalidate - drops the prefetched data of an object for all clients.
+func (p *prefetcher) Invalidate(bucket, object string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key := range p.sessions {
+		if key.bucket == bucket && key.object == object {
+			delete(p.sessions, key)
+		}
+	}
+}
+
+// Purge - drops the prefetched data of all objects of bucket under
+// prefix, an empty bucket drops everything.
+func (p *prefetcher) Purge(bucket, prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key := range p.sessions {
+		if bucket == "" || (key.bucket == bucket && HasPrefix(key.object, prefix)) {
+			delete(p.sessions, key)
+		}
+	}
+}
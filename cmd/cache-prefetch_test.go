@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// fakePrefetchBackend - serves ranges of a single object and records
+// the ranges requested.
+type fakePrefetchBackend struct {
+	data    []byte
+	objInfo ObjectInfo
+
+	mu     sync.Mutex
+	ranges []HTTPRangeSpec
+}
+
+func (f *fakePrefetchBackend) GetObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+	f.mu.Lock()
+	f.ranges = append(f.ranges, *rs)
+	f.mu.Unlock()
+	offset, length, err := rs.GetOffsetLength(f.objInfo.Size)
+	if err != nil {
+		return nil, err
+	}
+	return NewGetObjectReaderFromReader(bytes.NewReader(f.data[offset:offset+length]), f.objInfo, nil)
+}
+
+// waitPrefetch - waits until no window is being loaded.
+func waitPrefetch(t *testing.T, p *prefetcher) {
+	for i := 0; i < 200; i++ {
+		p.mu.Lock()
+		loading := false
+		for _, s := range p.sessions {
+			loading = loading || s.loading
+		}
+		p.mu.Unlock()
+		if !loading {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("Prefetch did not complete")
+}
+
+// Tests that consecutive ranges of a client are served from the window
+// read ahead, other reads are not.
+func TestPrefetcher(t *testing.T) {
+	data := newFakeObjectData(64 * 1024)
+	backend := &fakePrefetchBackend{
+		data:    data,
+		objInfo: ObjectInfo{Bucket: "bucket", Name: "object", Size: int64(len(data)), ETag: "etag"},
+	}
+	stats := newCacheStats()
+	p := newPrefetcher(8*1024, stats, backend.GetObjectNInfo)
+
+	client := logger.SetReqInfo(context.Background(), &logger.ReqInfo{RemoteHost: "10.0.0.1"})
+	other := logger.SetReqInfo(context.Background(), &logger.ReqInfo{RemoteHost: "10.0.0.2"})
+
+	read := func(ctx context.Context, rs *HTTPRangeSpec) (hit bool) {
+		gr, ok := p.Get(ctx, "bucket", "object", rs, http.Header{}, ObjectOptions{})
+		if !ok {
+			gr, _ = backend.GetObjectNInfo(ctx, "bucket", "object", rs, http.Header{}, ReadLock, ObjectOptions{})
+			p.Observe(ctx, "bucket", "object", rs, gr.ObjInfo)
+		}
+		got, err := ioutil.ReadAll(gr)
+		gr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, length, _ := rs.GetOffsetLength(int64(len(data)))
+		if !bytes.Equal(got, data[offset:offset+length]) {
+			t.Fatalf("Range %d-%d does not match the object", rs.Start, rs.End)
+		}
+		waitPrefetch(t, p)
+		return ok
+	}
+
+	// The first range starts a session, the second one is sequential
+	// and prefetches the window behind it.
+	if read(client, &HTTPRangeSpec{Start: 0, End: 1023}) || read(client, &HTTPRangeSpec{Start: 1024, End: 2047}) {
+		t.Fatal("Expected the first ranges to miss")
+	}
+	for offset := int64(2048); offset < 16*1024; offset += 1024 {
+		if !read(client, &HTTPRangeSpec{Start: offset, End: offset + 1023}) {
+			t.Fatalf("Expected range at %d to be served from prefetched data", offset)
+		}
+	}
+	if hits := stats.getPrefetchHits(); hits != 14 {
+		t.Errorf("Expected 14 prefetch hits, got %d", hits)
+	}
+	if misses := stats.getPrefetchMisses(); misses != 1 {
+		t.Errorf("Expected 1 prefetch miss, got %d", misses)
+	}
+
+	// Other clients, suffix ranges and seeks are not served.
+	if read(other, &HTTPRangeSpec{Start: 16 * 1024, End: 17*1024 - 1}) {
+		t.Error("Expected another client to miss")
+	}
+	if read(client, &HTTPRangeSpec{IsSuffixLength: true, Start: -1024}) {
+		t.Error("Expected suffix range to miss")
+	}
+	if read(client, &HTTPRangeSpec{Start: 40 * 1024, End: 41*1024 - 1}) {
+		t.Error("Expected seek to miss")
+	}
+
+	// Invalidated objects are not served.
+	read(client, &HTTPRangeSpec{Start: 41 * 1024, End: 42*1024 - 1})
+	p.Invalidate("bucket", "object")
+	if read(client, &HTTPRangeSpec{Start: 42 * 1024, End: 43*1024 - 1}) {
+		t.Error("Expected invalidated object to miss")
+	}
+
+	// Reads of the last window do not prefetch beyond the object.
+	for _, rs := range backend.ranges {
+		if rs.End >= int64(len(data)) {
+			t.Errorf("Prefetched beyond the object %d-%d", rs.Start, rs.End)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	// counters per row of the count-min sketch, must be a power of 2.
+	tinyLFUWidth = 1 << 20
+	// number of rows, each row is indexed by a different hash.
+	tinyLFUDepth = 4
+	// counters saturate at 15 like the 4-bit counters of TinyLFU.
+	tinyLFUMaxCount = 15
+	// all counters are halved after this many increments so old
+	// popularity fades away.
+	tinyLFUSampleSize = 10 * tinyLFUWidth
+)
+
+// tinyLFU - approximate access frequency of cached objects, used to
+// admit objects only if they are accessed more often than the objects
+// they would evict. A doorkeeper bit set absorbs one hit wonders so
+// that single-pass scans do not pollute the counters.
+type tinyLFU struct {
+	mu         sync.Mutex
+	rows       [tinyLFUDepth][]uint8
+	doorkeeper []uint64
+	additions  int
+}
+
+func newTinyLFU() *tinyLFU {
+	t := &tinyLFU{
+		doorkeeper: make([]uint64, tinyLFUWidth/64),
+	}
+	for i := range t.rows {
+		t.rows[i] = make([]uint8, tinyLFUWidth)
+	}
+	return t
+}
+
+// indexes - returns the counter index of key for every row by double
+// hashing a single 64-bit hash.
+func (t *tinyLFU) indexes(key string) (idx [tinyLFUDepth]uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)
+	for i := range idx {
+		idx[i] = (h1 + uint32(i)*h2) & (tinyLFUWidth - 1)
+	}
+	return idx
+}
+
+// Increment - records an access of key.
+func (t *tinyLFU) Increment(key string) {
+	idx := t.indexes(key)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The first access only sets the doorkeeper bit.
+	word, bit := idx[0]/64, uint64(1)<<(idx[0]%64)
+	if t.doorkeeper[word]&bit == 0 {
+		t.doorkeeper[word] |= bit
+	} else {
+		for i, j := range idx {
+			if t.rows[i][j] < tinyLFUMaxCount {
+				t.rows[i][j]++
+			}
+		}
+	}
+
+	t.additions++
+	if t.additions >= tinyLFUSampleSize {
+		t.reset()
+	}
+}
+
+// reset - halves all counters and clears the doorkeeper.
+func (t *tinyLFU) reset() {
+	for i := range t.rows {
+		for j := range t.rows[i] {
+			t.rows[i][j] >>= 1
+		}
+	}
+	for i := range t.doorkeeper {
+		t.doorkeeper[i] = 0
+	}
+	t.additions = 0
+}
+
+// Estimate - returns the approximate number of accesses of key.
+func (t *tinyLFU) Estimate(key string) int {
+	idx := t.indexes(key)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	min := uint8(tinyLFUMaxCount)
+	for i, j := range idx {
+		if t.rows[i][j] < min {
+			min = t.rows[i][j]
+		}
+	}
+	count := int(min)
+	if t.doorkeeper[idx[0]/64]&(uint64(1)<<(idx[0]%64)) != 0 {
+		count++
+	}
+	return count
+}
+
+// Admit - returns true if candidate should replace victim.
+func (t *tinyLFU) Admit(candidate, victim string) bool {
+	return t.Estimate(candidate) > t.Estimate(victim)
+}
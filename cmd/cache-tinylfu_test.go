@@ -0,0 +1,73 @@
+package cmd
+
+import "testing"
+
+// Tests the count-min sketch estimates behind the doorkeeper.
+func TestTinyLFUEstimate(t *testing.T) {
+	freq := newTinyLFU()
+
+	testCases := []struct {
+		key       string
+		accesses  int
+		estimated int
+	}{
+		{"never", 0, 0},
+		// The first access only sets the doorkeeper bit.
+		{"once", 1, 1},
+		{"twice", 2, 2},
+		{"often", 10, 10},
+		// Counters saturate, the doorkeeper adds one.
+		{"always", 100, tinyLFUMaxCount + 1},
+	}
+
+	for _, testCase := range testCases {
+		for i := 0; i < testCase.accesses; i++ {
+			freq.Increment(testCase.key)
+		}
+	}
+	for i, testCase := range testCases {
+		if got := freq.Estimate(testCase.key); got != testCase.estimated {
+			t.Errorf("Test %d: Expected estimate %d for %s, got %d", i+1, testCase.estimated, testCase.key, got)
+		}
+	}
+
+	if !freq.Admit("often", "once") {
+		t.Error("Expected frequent candidate to be admitted")
+	}
+	if freq.Admit("once", "often") {
+		t.Error("Expected infrequent candidate to be rejected")
+	}
+	if freq.Admit("twice", "twice") {
+		t.Error("Expected candidate as frequent as the victim to be rejected")
+	}
+}
+
+// Tests that counters are halved and the doorkeeper cleared once the
+// sample size is reached.
+func TestTinyLFUReset(t *testing.T) {
+	freq := newTinyLFU()
+	for i := 0; i < 9; i++ {
+		freq.Increment("hot")
+	}
+	freq.Increment("cold")
+	if got := freq.Estimate("hot"); got != 9 {
+		t.Fatalf("Expected estimate 9 before reset, got %d", got)
+	}
+
+	freq.additions = tinyLFUSampleSize - 1
+	freq.Increment("other")
+
+	if freq.additions != 0 {
+		t.Errorf("Expected additions to be reset, got %d", freq.additions)
+	}
+	// 8 counted accesses are halved, the doorkeeper bit is cleared.
+	if got := freq.Estimate("hot"); got != 4 {
+		t.Errorf("Expected estimate 4 after reset, got %d", got)
+	}
+	if got := freq.Estimate("cold"); got != 0 {
+		t.Errorf("Expected estimate 0 after reset, got %d", got)
+	}
+	if got := freq.Estimate("other"); got != 0 {
+		t.Errorf("Expected estimate 0 after reset, got %d", got)
+	}
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/radio/cmd/config"
+	"github.com/minio/radio/cmd/config/api"
 	"github.com/minio/radio/cmd/config/cache"
 	"github.com/minio/radio/cmd/logger"
 )
@@ -14,17 +16,112 @@ func lookupConfigEnv(rconfig radioConfig) (err error) {
 		return fmt.Errorf("Invalid region configuration: %w", err)
 	}
 
-	globalCacheConfig, err = cache.LookupConfig()
+	globalAPIConfig, err = api.LookupConfig(rconfig.API.MaxObjectSize,
+		rconfig.API.MaxHeaderSize, rconfig.API.MaxMetadataSize, rconfig.API.JSONErrors)
 	if err != nil {
+		return fmt.Errorf("Invalid api configuration: %w", err)
+	}
+	if err = api.LookupParallelGetConfig(&globalAPIConfig, rconfig.API.ParallelGetThreshold,
+		rconfig.API.ParallelGetPartSize, rconfig.API.ParallelGetMaxMemory); err != nil {
+		return fmt.Errorf("Invalid api configuration: %w", err)
+	}
+	if err = api.LookupSlowRequestConfig(&globalAPIConfig, rconfig.API.SlowRequestThreshold); err != nil {
+		return fmt.Errorf("Invalid api configuration: %w", err)
+	}
+	if err = api.LookupCopyBufferConfig(&globalAPIConfig, rconfig.API.CopyBufferSize); err != nil {
+		return fmt.Errorf("Invalid api configuration: %w", err)
+	}
+	globalCopyBuffers = newBufferPool(globalAPIConfig.CopyBufferSize, makeBuffer)
+	if err = api.LookupListenerConfig(&globalAPIConfig, rconfig.API.MaxHeaderBytes, rconfig.API.MaxHeaderCount,
+		rconfig.API.ReadHeaderTimeout, rconfig.API.IdleTimeout, rconfig.API.MaxConnsPerIP,
+		globalAPIConfig.MaxHeaderSize); err != nil {
+		return fmt.Errorf("Invalid api configuration: %w", err)
+	}
+	if globalAPIConfig.ParallelGetThreshold > 0 {
+		globalParallelGetSlots = make(chan struct{},
+			globalAPIConfig.ParallelGetMaxMemory/globalAPIConfig.ParallelGetPartSize)
+	}
+
+	globalCacheConfig, err = cache.LookupConfig(rconfig.Cache.Drives, rconfig.Cache.Exclude,
+		rconfig.Cache.Quota, rconfig.Cache.Expiry)
+	if err != nil {
+		return fmt.Errorf("Unable to setup cache: %w", err)
+	}
+	if err = cache.LookupDriveConfig(&globalCacheConfig, rconfig.Cache.Dedup, rconfig.Cache.Index); err != nil {
+		return fmt.Errorf("Unable to setup cache: %w", err)
+	}
+
+	if err = cache.LookupRAMConfig(&globalCacheConfig, rconfig.Cache.RAMSize,
+		rconfig.Cache.RAMMaxObjectSize); err != nil {
+		return fmt.Errorf("Unable to setup cache: %w", err)
+	}
+	globalCacheConfig.Enabled = globalCacheConfig.Enabled || globalCacheConfig.RAMSize > 0
+
+	if err = cache.LookupPolicyConfig(&globalCacheConfig, rconfig.Cache.Policy,
+		rconfig.Cache.Admission); err != nil {
+		return fmt.Errorf("Unable to setup cache: %w", err)
+	}
+
+	if err = cache.LookupPrefetchConfig(&globalCacheConfig, rconfig.Cache.PrefetchWindow); err != nil {
 		return fmt.Errorf("Unable to setup cache: %w", err)
 	}
+	globalCacheConfig.Enabled = globalCacheConfig.Enabled || globalCacheConfig.PrefetchWindow > 0
+
+	if globalTenants, err = newTenantRegistry(rconfig); err != nil {
+		return fmt.Errorf("Unable to setup tenants: %w", err)
+	}
+
+	globalContentTypes = newContentTypeDetection(rconfig)
+
+	globalCacheSync = newFileSync(rconfig.Durability.Cache)
+	if globalCacheSync.policy == fsyncInterval {
+		go globalCacheSync.run("cache")
+	}
+	globalJournalSync = newFileSync(rconfig.Durability.Journal)
+	if globalJournalSync.policy == fsyncInterval {
+		go globalJournalSync.run("journal")
+	}
+	if rconfig.Durability.Cache.DirectIO != "" {
+		size, err := humanize.ParseBytes(rconfig.Durability.Cache.DirectIO)
+		if err != nil {
+			return fmt.Errorf("Invalid durability configuration: %w", err)
+		}
+		globalCacheDirectIO = int64(size)
+	}
+
+	if rconfig.Authorizer.Endpoint != "" {
+		RegisterRequestHook(newAuthorizer(rconfig.Authorizer))
+	}
+
+	if rconfig.RequestStats.enabled() {
+		producer, err := newKafkaProducer(rconfig.RequestStats)
+		if err != nil {
+			return fmt.Errorf("Unable to connect to the request stats brokers: %w", err)
+		}
+		globalRequestStats = newRequestStatsExporter(producer, rconfig.RequestStats)
+	}
+
+	if rconfig.Usage.Dir != "" {
+		usage, err := newUsageTracker(rconfig.Usage)
+		if err != nil {
+			return fmt.Errorf("Unable to setup usage accounting: %w", err)
+		}
+		globalUsage = usage
+		go usage.run()
+	}
+
+	if rconfig.Fanout.Memory != "" {
+		if globalFanout, err = newFanoutOptions(rconfig.Fanout); err != nil {
+			return fmt.Errorf("Unable to setup upload buffering: %w", err)
+		}
+	}
 
-	if !globalCacheConfig.Enabled {
-		globalCacheConfig.Drives = rconfig.Cache.Drives
-		globalCacheConfig.Exclude = rconfig.Cache.Exclude
-		globalCacheConfig.Quota = rconfig.Cache.Quota
-		globalCacheConfig.Expiry = rconfig.Cache.Expiry
-		globalCacheConfig.Enabled = len(rconfig.Cache.Drives) > 0
+	if rconfig.ConfigAudit.File != "" {
+		audit, err := openConfigAuditLog(rconfig.ConfigAudit.File)
+		if err != nil {
+			return fmt.Errorf("Unable to open the config audit log: %w", err)
+		}
+		globalConfigAudit = audit
 	}
 
 	// Enable console logging
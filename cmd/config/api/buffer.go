@@ -0,0 +1,30 @@
+package api
+
+import (
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Copy buffer ENVs
+const (
+	EnvAPICopyBufferSize = "RADIO_API_COPY_BUFFER_SIZE"
+
+	DefaultCopyBufferSize = 128 * humanize.KiByte
+	maxCopyBufferSize     = 16 * humanize.MiByte
+)
+
+// LookupCopyBufferConfig - sets the size of the buffers copying object
+// data between clients and remotes, falling back to the value provided
+// in config.yml.
+func LookupCopyBufferConfig(cfg *Config, size string) error {
+	bufSize, err := parseSize(env.Get(EnvAPICopyBufferSize, size), DefaultCopyBufferSize)
+	if err != nil {
+		return config.ErrInvalidAPICopyBufferSize(err)
+	}
+	if bufSize < humanize.KiByte || bufSize > maxCopyBufferSize {
+		return config.ErrInvalidAPICopyBufferSize(nil).Msg("copy buffer size must be between 1KiB and 16MiB")
+	}
+	cfg.CopyBufferSize = int(bufSize)
+	return nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// API ENVs
+const (
+	EnvAPIMaxObjectSize   = "RADIO_API_MAX_OBJECT_SIZE"
+	EnvAPIMaxHeaderSize   = "RADIO_API_MAX_HEADER_SIZE"
+	EnvAPIMaxMetadataSize = "RADIO_API_MAX_METADATA_SIZE"
+	EnvAPIJSONErrors      = "RADIO_API_JSON_ERRORS"
+)
+
+// Default limits, see https://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
+const (
+	// Maximum object size per PUT request is 5TiB, this is a divergence
+	// from S3 on purpose to support large uploads with presigned URLs.
+	DefaultMaxObjectSize = 5 * humanize.TiByte
+	// Maximum size for http headers.
+	DefaultMaxHeaderSize = 8 * humanize.KiByte
+	// Maximum size for user-defined metadata.
+	DefaultMaxMetadataSize = 2 * humanize.KiByte
+)
+
+// Config represents request limits enforced by the API layer.
+type Config struct {
+	MaxObjectSize   int64 `json:"max_object_size"`
+	MaxHeaderSize   int64 `json:"max_header_size"`
+	MaxMetadataSize int64 `json:"max_metadata_size"`
+	// JSONErrors enables JSON error responses for all clients, otherwise
+	// only clients sending 'Accept: application/json' receive them.
+	JSONErrors bool `json:"json_errors"`
+	// Objects of at least ParallelGetThreshold bytes are read in parts
+	// of ParallelGetPartSize from all remotes at once, 0 disables it.
+	ParallelGetThreshold int64 `json:"parallel_get_threshold"`
+	ParallelGetPartSize  int64 `json:"parallel_get_part_size"`
+	// ParallelGetMaxMemory limits the parts buffered by all parallel
+	// reads together.
+	ParallelGetMaxMemory int64 `json:"parallel_get_max_memory"`
+	// S3 requests taking SlowRequestThreshold or longer are logged,
+	// 0 disables it.
+	SlowRequestThreshold time.Duration `json:"slow_request_threshold"`
+	// Limits enforced on client connections before requests reach the
+	// handlers.
+	MaxHeaderBytes    int           `json:"max_header_bytes"`
+	MaxHeaderCount    int           `json:"max_header_count"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`
+	// MaxConnsPerIP limits the open connections of a client address,
+	// 0 disables it.
+	MaxConnsPerIP int `json:"max_conns_per_ip"`
+	// CopyBufferSize is the size of the pooled buffers copying object
+	// data between clients and remotes.
+	CopyBufferSize int `json:"copy_buffer_size"`
+}
+
+// DefaultConfig - returns the limits used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		MaxObjectSize:   DefaultMaxObjectSize,
+		MaxHeaderSize:   DefaultMaxHeaderSize,
+		MaxMetadataSize: DefaultMaxMetadataSize,
+	}
+}
+
+// parseSize parses human readable sizes such as "5TiB", an empty
+// value returns the provided default.
+func parseSize(value string, defaultSize int64) (int64, error) {
+	if value == "" {
+		return defaultSize, nil
+	}
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 || size > uint64(DefaultMaxObjectSize) {
+		return 0, config.Errorf(config.SafeModeKind, "size %s must be between 1B and 5TiB", value)
+	}
+	return int64(size), nil
+}
+
+// LookupConfig - extracts API limits from environment variables,
+// falling back to the values provided in config.yml.
+func LookupConfig(maxObjectSize, maxHeaderSize, maxMetadataSize string, jsonErrors bool) (cfg Config, err error) {
+	cfg.MaxObjectSize, err = parseSize(env.Get(EnvAPIMaxObjectSize, maxObjectSize), DefaultMaxObjectSize)
+	if err != nil {
+		return cfg, config.ErrInvalidAPIMaxObjectSize(err)
+	}
+	cfg.MaxHeaderSize, err = parseSize(env.Get(EnvAPIMaxHeaderSize, maxHeaderSize), DefaultMaxHeaderSize)
+	if err != nil {
+		return cfg, config.ErrInvalidAPIMaxHeaderSize(err)
+	}
+	cfg.MaxMetadataSize, err = parseSize(env.Get(EnvAPIMaxMetadataSize, maxMetadataSize), DefaultMaxMetadataSize)
+	if err != nil {
+		return cfg, config.ErrInvalidAPIMaxMetadataSize(err)
+	}
+	if cfg.MaxMetadataSize > cfg.MaxHeaderSize {
+		return cfg, config.ErrInvalidAPIMaxMetadataSize(nil).Msg("max metadata size cannot exceed max header size")
+	}
+	cfg.JSONErrors = jsonErrors
+	if v := env.Get(EnvAPIJSONErrors, ""); v != "" {
+		cfg.JSONErrors, err = strconv.ParseBool(v)
+		if err != nil {
+			return cfg, config.ErrInvalidAPIJSONErrors(err)
+		}
+	}
+	return cfg, nil
+}
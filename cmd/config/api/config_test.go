@@ -0,0 +1,187 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		value        string
+		expectedSize int64
+		success      bool
+	}{
+		{"", 10, true},
+		{"1KiB", humanize.KiByte, true},
+		{"5TiB", DefaultMaxObjectSize, true},
+		{"512", 512, true},
+		{"0", 0, false},
+		{"6TiB", 0, false},
+		{"abc", 0, false},
+	}
+
+	for i, testCase := range testCases {
+		size, err := parseSize(testCase.value, 10)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && size != testCase.expectedSize {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.expectedSize, size)
+		}
+	}
+}
+
+func TestLookupConfig(t *testing.T) {
+	cfg, err := LookupConfig("", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg != DefaultConfig() {
+		t.Errorf("Expected %v, got %v", DefaultConfig(), cfg)
+	}
+
+	if _, err = LookupConfig("", "1KiB", "2KiB", false); err == nil {
+		t.Error("Expected failure when metadata size exceeds header size")
+	}
+}
+
+func TestLookupParallelGetConfig(t *testing.T) {
+	testCases := []struct {
+		threshold, partSize, maxMemory string
+		expectedThreshold              int64
+		expectedPartSize               int64
+		expectedMaxMemory              int64
+		success                        bool
+	}{
+		{"", "", "", 0, 0, 0, true},
+		{"", "abc", "abc", 0, 0, 0, true},
+		{"64MiB", "", "", 64 * humanize.MiByte, DefaultParallelGetPartSize, DefaultParallelGetMaxMemory, true},
+		{"64MiB", "8MiB", "256MiB", 64 * humanize.MiByte, 8 * humanize.MiByte, 256 * humanize.MiByte, true},
+		{"64MiB", "8MiB", "8MiB", 64 * humanize.MiByte, 8 * humanize.MiByte, 8 * humanize.MiByte, true},
+		{"8MiB", "16MiB", "", 0, 0, 0, false},
+		{"abc", "", "", 0, 0, 0, false},
+		{"64MiB", "0", "", 0, 0, 0, false},
+		{"64MiB", "16MiB", "8MiB", 0, 0, 0, false},
+		{"64MiB", "16MiB", "abc", 0, 0, 0, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupParallelGetConfig(&cfg, testCase.threshold, testCase.partSize, testCase.maxMemory)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && (cfg.ParallelGetThreshold != testCase.expectedThreshold ||
+			cfg.ParallelGetPartSize != testCase.expectedPartSize ||
+			cfg.ParallelGetMaxMemory != testCase.expectedMaxMemory) {
+			t.Errorf("Test %d: Expected %d/%d/%d, got %d/%d/%d", i+1, testCase.expectedThreshold,
+				testCase.expectedPartSize, testCase.expectedMaxMemory, cfg.ParallelGetThreshold,
+				cfg.ParallelGetPartSize, cfg.ParallelGetMaxMemory)
+		}
+	}
+}
+
+func TestLookupSlowRequestConfig(t *testing.T) {
+	testCases := []struct {
+		threshold         string
+		expectedThreshold time.Duration
+		success           bool
+	}{
+		{"", 0, true},
+		{"2s", 2 * time.Second, true},
+		{"500ms", 500 * time.Millisecond, true},
+		{"0s", 0, false},
+		{"-1s", 0, false},
+		{"abc", 0, false},
+		{"2", 0, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupSlowRequestConfig(&cfg, testCase.threshold)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && cfg.SlowRequestThreshold != testCase.expectedThreshold {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expectedThreshold, cfg.SlowRequestThreshold)
+		}
+	}
+}
+
+func TestLookupCopyBufferConfig(t *testing.T) {
+	testCases := []struct {
+		size         string
+		expectedSize int
+		success      bool
+	}{
+		{"", DefaultCopyBufferSize, true},
+		{"32KiB", 32 * humanize.KiByte, true},
+		{"16MiB", 16 * humanize.MiByte, true},
+		{"512", 0, false},
+		{"32MiB", 0, false},
+		{"abc", 0, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupCopyBufferConfig(&cfg, testCase.size)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && cfg.CopyBufferSize != testCase.expectedSize {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.expectedSize, cfg.CopyBufferSize)
+		}
+	}
+}
+
+func TestLookupListenerConfig(t *testing.T) {
+	testCases := []struct {
+		maxHeaderBytes    string
+		maxHeaderCount    int
+		readHeaderTimeout string
+		idleTimeout       string
+		maxConnsPerIP     int
+		expected          Config
+		success           bool
+	}{
+		{"", 0, "", "", 0, Config{MaxHeaderBytes: DefaultMaxHeaderBytes, MaxHeaderCount: DefaultMaxHeaderCount,
+			ReadHeaderTimeout: DefaultReadHeaderTimeout, IdleTimeout: DefaultIdleTimeout}, true},
+		{"16KiB", 50, "5s", "30s", 64, Config{MaxHeaderBytes: 16 * humanize.KiByte, MaxHeaderCount: 50,
+			ReadHeaderTimeout: 5 * time.Second, IdleTimeout: 30 * time.Second, MaxConnsPerIP: 64}, true},
+		{"4KiB", 0, "", "", 0, Config{}, false},
+		{"2MiB", 0, "", "", 0, Config{}, false},
+		{"", -1, "", "", 0, Config{}, false},
+		{"", 0, "0s", "", 0, Config{}, false},
+		{"", 0, "", "abc", 0, Config{}, false},
+		{"", 0, "", "", -1, Config{}, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupListenerConfig(&cfg, testCase.maxHeaderBytes, testCase.maxHeaderCount,
+			testCase.readHeaderTimeout, testCase.idleTimeout, testCase.maxConnsPerIP, DefaultMaxHeaderSize)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && cfg != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, cfg)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Listener ENVs
+const (
+	EnvAPIMaxHeaderBytes    = "RADIO_API_MAX_HEADER_BYTES"
+	EnvAPIMaxHeaderCount    = "RADIO_API_MAX_HEADER_COUNT"
+	EnvAPIReadHeaderTimeout = "RADIO_API_READ_HEADER_TIMEOUT"
+	EnvAPIIdleTimeout       = "RADIO_API_IDLE_TIMEOUT"
+	EnvAPIMaxConnsPerIP     = "RADIO_API_MAX_CONNS_PER_IP"
+)
+
+// Default listener limits, a client sending its request slowly or
+// keeping a connection open without sending one is disconnected.
+const (
+	// Maximum size of the request line and headers read by the server,
+	// the headers are further limited by the max header size.
+	DefaultMaxHeaderBytes = 64 * humanize.KiByte
+	// Maximum number of header values of a request.
+	DefaultMaxHeaderCount = 200
+	// Maximum time to read the headers of a request.
+	DefaultReadHeaderTimeout = 10 * time.Second
+	// Maximum time a keep-alive connection waits for the next request.
+	DefaultIdleTimeout = 2 * time.Minute
+)
+
+// parseTimeout parses durations such as "10s", an empty value returns
+// the provided default.
+func parseTimeout(value string, defaultTimeout time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultTimeout, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, config.Errorf(config.SafeModeKind, "timeout %s must be positive", value)
+	}
+	return d, nil
+}
+
+// parseCount parses a count set in the environment, falling back to
+// the value provided in config.yml or the default if it is 0.
+func parseCount(envName string, value, defaultCount int) (int, error) {
+	if v := env.Get(envName, ""); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, err
+		}
+		value = n
+	}
+	if value < 0 {
+		return 0, config.Errorf(config.SafeModeKind, "%d must not be negative", value)
+	}
+	if value == 0 {
+		return defaultCount, nil
+	}
+	return value, nil
+}
+
+// LookupListenerConfig - sets the limits enforced on client connections,
+// falling back to the values provided in config.yml. The max header
+// bytes cannot be below maxHeaderSize, the limit of the headers alone.
+func LookupListenerConfig(cfg *Config, maxHeaderBytes string, maxHeaderCount int,
+	readHeaderTimeout, idleTimeout string, maxConnsPerIP int, maxHeaderSize int64) (err error) {
+	size, err := parseSize(env.Get(EnvAPIMaxHeaderBytes, maxHeaderBytes), DefaultMaxHeaderBytes)
+	if err != nil {
+		return config.ErrInvalidAPIListener(err)
+	}
+	if size > humanize.MiByte {
+		return config.ErrInvalidAPIListener(nil).Msg("max header bytes cannot exceed 1MiB")
+	}
+	if size < maxHeaderSize {
+		return config.ErrInvalidAPIListener(nil).Msg("max header bytes cannot be below the max header size")
+	}
+	cfg.MaxHeaderBytes = int(size)
+	if cfg.MaxHeaderCount, err = parseCount(EnvAPIMaxHeaderCount, maxHeaderCount, DefaultMaxHeaderCount); err != nil {
+		return config.ErrInvalidAPIListener(err)
+	}
+	cfg.ReadHeaderTimeout, err = parseTimeout(env.Get(EnvAPIReadHeaderTimeout, readHeaderTimeout), DefaultReadHeaderTimeout)
+	if err != nil {
+		return config.ErrInvalidAPIListener(err)
+	}
+	cfg.IdleTimeout, err = parseTimeout(env.Get(EnvAPIIdleTimeout, idleTimeout), DefaultIdleTimeout)
+	if err != nil {
+		return config.ErrInvalidAPIListener(err)
+	}
+	if cfg.MaxConnsPerIP, err = parseCount(EnvAPIMaxConnsPerIP, maxConnsPerIP, 0); err != nil {
+		return config.ErrInvalidAPIListener(err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Parallel GET ENVs
+const (
+	EnvAPIParallelGetThreshold = "RADIO_API_PARALLEL_GET_THRESHOLD"
+	EnvAPIParallelGetPartSize  = "RADIO_API_PARALLEL_GET_PART_SIZE"
+	EnvAPIParallelGetMaxMemory = "RADIO_API_PARALLEL_GET_MAX_MEMORY"
+
+	DefaultParallelGetPartSize  = 16 * humanize.MiByte
+	DefaultParallelGetMaxMemory = 1 * humanize.GiByte
+)
+
+// LookupParallelGetConfig - sets the size above which objects are read
+// from all remotes in parallel, falling back to the values provided in
+// config.yml. Reads are split into parts of the part size, the parts
+// buffered by all parallel reads together are limited to max memory. An
+// empty threshold disables parallel reads.
+func LookupParallelGetConfig(cfg *Config, threshold, partSize, maxMemory string) (err error) {
+	threshold = env.Get(EnvAPIParallelGetThreshold, threshold)
+	if threshold == "" {
+		cfg.ParallelGetThreshold, cfg.ParallelGetPartSize, cfg.ParallelGetMaxMemory = 0, 0, 0
+		return nil
+	}
+	cfg.ParallelGetThreshold, err = parseSize(threshold, 0)
+	if err != nil {
+		return config.ErrInvalidAPIParallelGet(err)
+	}
+	cfg.ParallelGetPartSize, err = parseSize(env.Get(EnvAPIParallelGetPartSize, partSize), DefaultParallelGetPartSize)
+	if err != nil {
+		return config.ErrInvalidAPIParallelGet(err)
+	}
+	if cfg.ParallelGetPartSize > cfg.ParallelGetThreshold {
+		return config.ErrInvalidAPIParallelGet(nil).Msg("parallel get part size cannot exceed the threshold")
+	}
+	cfg.ParallelGetMaxMemory, err = parseSize(env.Get(EnvAPIParallelGetMaxMemory, maxMemory), DefaultParallelGetMaxMemory)
+	if err != nil {
+		return config.ErrInvalidAPIParallelGet(err)
+	}
+	if cfg.ParallelGetMaxMemory < cfg.ParallelGetPartSize {
+		return config.ErrInvalidAPIParallelGet(nil).Msg("parallel get max memory cannot be less than the part size")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"time"
+
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Slow request ENVs
+const (
+	EnvAPISlowRequestThreshold = "RADIO_API_SLOW_REQUEST_THRESHOLD"
+)
+
+// LookupSlowRequestConfig - sets the duration above which S3 requests
+// are logged as slow, falling back to the value provided in config.yml.
+// An empty threshold disables the slow request log.
+func LookupSlowRequestConfig(cfg *Config, threshold string) error {
+	threshold = env.Get(EnvAPISlowRequestThreshold, threshold)
+	if threshold == "" {
+		cfg.SlowRequestThreshold = 0
+		return nil
+	}
+	d, err := time.ParseDuration(threshold)
+	if err != nil {
+		return config.ErrInvalidAPISlowRequestThreshold(err)
+	}
+	if d <= 0 {
+		return config.ErrInvalidAPISlowRequestThreshold(nil).Msg("slow request threshold must be positive")
+	}
+	cfg.SlowRequestThreshold = d
+	return nil
+}
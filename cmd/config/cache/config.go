@@ -18,6 +18,28 @@ type Config struct {
 	MaxUse  int      `json:"maxuse"`
 	Quota   int      `json:"quota"`
 	Exclude []string `json:"exclude"`
+
+	// RAMSize caps the in-memory tier holding objects up to
+	// RAMMaxObjectSize bytes in front of the drives, 0 disables it.
+	RAMSize          int64 `json:"ram_size"`
+	RAMMaxObjectSize int64 `json:"ram_max_object_size"`
+
+	// Policy selects lru or lfu eviction, Admission selects whether
+	// all reads or only frequent (tinylfu) reads are cached.
+	Policy    string `json:"policy"`
+	Admission string `json:"admission"`
+
+	// PrefetchWindow is read ahead of sequential range reads of a
+	// client, 0 disables prefetching.
+	PrefetchWindow int64 `json:"prefetch_window"`
+
+	// Dedup stores the identical data of cached objects once per
+	// drive.
+	Dedup bool `json:"dedup"`
+
+	// Index keeps an index of the objects cached on each drive, so that
+	// evictions need no walk of the drives.
+	Index bool `json:"index"`
 }
 
 // UnmarshalJSON - implements JSON unmarshal interface for unmarshalling
@@ -45,6 +67,10 @@ func (cfg *Config) UnmarshalJSON(data []byte) (err error) {
 		return errors.New("config quota value should not be null or negative")
 	}
 
+	if _cfg.RAMSize < 0 || _cfg.RAMMaxObjectSize < 0 {
+		return errors.New("config ram cache sizes should not be negative")
+	}
+
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"os"
 	"reflect"
 	"runtime"
 	"testing"
@@ -98,3 +99,141 @@ func TestParseCacheExclude(t *testing.T) {
 		}
 	}
 }
+
+// Tests parsing of the in-memory tier sizes.
+func TestLookupRAMConfig(t *testing.T) {
+	testCases := []struct {
+		ramSize          string
+		ramMaxObjectSize string
+		expectedSize     int64
+		expectedMaxSize  int64
+		success          bool
+	}{
+		{"", "", 0, 0, true},
+		{"", "4MiB", 0, 0, true},
+		{"256MiB", "", 256 << 20, 1 << 20, true},
+		{"256MiB", "64KiB", 256 << 20, 64 << 10, true},
+		{"1MiB", "2MiB", 0, 0, false},
+		{"256MiB", "0", 0, 0, false},
+		{"lots", "", 0, 0, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupRAMConfig(&cfg, testCase.ramSize, testCase.ramMaxObjectSize)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && (cfg.RAMSize != testCase.expectedSize || cfg.RAMMaxObjectSize != testCase.expectedMaxSize) {
+			t.Errorf("Test %d: Expected %d/%d, got %d/%d", i+1, testCase.expectedSize,
+				testCase.expectedMaxSize, cfg.RAMSize, cfg.RAMMaxObjectSize)
+		}
+	}
+}
+
+// Tests parsing of eviction and admission policies.
+func TestLookupPolicyConfig(t *testing.T) {
+	testCases := []struct {
+		policy            string
+		admission         string
+		expectedPolicy    string
+		expectedAdmission string
+		success           bool
+	}{
+		{"", "", PolicyLRU, AdmissionAll, true},
+		{"LFU", "tinylfu", PolicyLFU, AdmissionTinyLFU, true},
+		{"lru", "TinyLFU", PolicyLRU, AdmissionTinyLFU, true},
+		{"arc", "", "", "", false},
+		{"lru", "never", "", "", false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupPolicyConfig(&cfg, testCase.policy, testCase.admission)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && (cfg.Policy != testCase.expectedPolicy || cfg.Admission != testCase.expectedAdmission) {
+			t.Errorf("Test %d: Expected %s/%s, got %s/%s", i+1, testCase.expectedPolicy,
+				testCase.expectedAdmission, cfg.Policy, cfg.Admission)
+		}
+	}
+}
+
+// Tests parsing of the prefetch window.
+func TestLookupPrefetchConfig(t *testing.T) {
+	testCases := []struct {
+		window         string
+		expectedWindow int64
+		success        bool
+	}{
+		{"", 0, true},
+		{"0", 0, true},
+		{"8MiB", 8 << 20, true},
+		{"1GiB", 1 << 30, true},
+		{"2GiB", 0, false},
+		{"lots", 0, false},
+	}
+
+	for i, testCase := range testCases {
+		var cfg Config
+		err := LookupPrefetchConfig(&cfg, testCase.window)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err == nil && cfg.PrefetchWindow != testCase.expectedWindow {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.expectedWindow, cfg.PrefetchWindow)
+		}
+	}
+}
+
+// Tests that the RADIO_CACHE_ ENVs override the cache of config.yml.
+func TestLookupConfig(t *testing.T) {
+	cfg, err := LookupConfig(nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Enabled || cfg.Quota != 80 || cfg.Expiry != 90 {
+		t.Errorf("Expected the defaults without drives, got %+v", cfg)
+	}
+
+	envs := map[string]string{
+		EnvCacheQuota: "70",
+		EnvCacheIndex: "true",
+		EnvCacheSize:  "64MiB",
+	}
+	for k, v := range envs {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	cfg, err = LookupConfig([]string{"/mnt/cache1"}, nil, 60, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LookupDriveConfig(&cfg, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err = LookupRAMConfig(&cfg, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Enabled || cfg.Quota != 70 || cfg.MaxUse != 70 || cfg.Expiry != 30 {
+		t.Errorf("Expected the quota to be overridden, got %+v", cfg)
+	}
+	if !cfg.Dedup || !cfg.Index || cfg.RAMSize != 64<<20 {
+		t.Errorf("Expected the drives and RAM tier to be overridden, got %+v", cfg)
+	}
+
+	os.Setenv(EnvCacheQuota, "120")
+	if _, err = LookupConfig([]string{"/mnt/cache1"}, nil, 60, 30); err == nil {
+		t.Error("Expected a quota above 100 to be rejected")
+	}
+}
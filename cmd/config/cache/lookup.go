@@ -8,7 +8,8 @@ import (
 	"github.com/minio/radio/cmd/config"
 )
 
-// Cache ENVs
+// Cache ENVs, every key of the cache section of config.yml is overridden
+// by one of the RADIO_CACHE_ ENVs of this package.
 const (
 	Drives  = "drives"
 	Exclude = "exclude"
@@ -21,6 +22,8 @@ const (
 	EnvCacheExpiry  = "RADIO_CACHE_EXPIRY"
 	EnvCacheMaxUse  = "RADIO_CACHE_MAXUSE"
 	EnvCacheQuota   = "RADIO_CACHE_QUOTA"
+	EnvCacheDedup   = "RADIO_CACHE_DEDUP"
+	EnvCacheIndex   = "RADIO_CACHE_INDEX"
 
 	DefaultExpiry = "90"
 	DefaultQuota  = "80"
@@ -30,23 +33,24 @@ const (
 	cacheDelimiter = ","
 )
 
-// LookupConfig - extracts cache configuration provided by environment
-// variables and merge them with provided CacheConfiguration.
-func LookupConfig() (Config, error) {
-	cfg := Config{}
-
-	drives := env.Get(EnvCacheDrives, "")
-	if len(drives) == 0 {
-		return cfg, nil
+// LookupConfig - returns the drive cache configuration of config.yml, its
+// drives, exclude patterns, quota and expiry each overridden by its ENV.
+func LookupConfig(drives, exclude []string, quota, expiry int) (cfg Config, err error) {
+	cfg = Config{
+		Drives:  drives,
+		Exclude: exclude,
+		Quota:   quota,
+		Expiry:  expiry,
 	}
 
-	var err error
-	cfg.Drives, err = parseCacheDrives(drives)
-	if err != nil {
-		return cfg, err
+	if drivesStr := env.Get(EnvCacheDrives, ""); drivesStr != "" {
+		cfg.Drives, err = parseCacheDrives(drivesStr)
+		if err != nil {
+			return cfg, err
+		}
 	}
+	cfg.Enabled = len(cfg.Drives) > 0
 
-	cfg.Enabled = true
 	if excludes := env.Get(EnvCacheExclude, ""); excludes != "" {
 		cfg.Exclude, err = parseCacheExcludes(excludes)
 		if err != nil {
@@ -54,38 +58,50 @@ func LookupConfig() (Config, error) {
 		}
 	}
 
-	if expiryStr := env.Get(EnvCacheExpiry, DefaultExpiry); expiryStr != "" {
+	if expiryStr := env.Get(EnvCacheExpiry, ""); expiryStr != "" {
 		cfg.Expiry, err = strconv.Atoi(expiryStr)
 		if err != nil {
 			return cfg, config.ErrInvalidCacheExpiryValue(err)
 		}
 	}
-
-	if maxUseStr := env.Get(EnvCacheMaxUse, DefaultQuota); maxUseStr != "" {
-		cfg.MaxUse, err = strconv.Atoi(maxUseStr)
-		if err != nil {
-			return cfg, config.ErrInvalidCacheQuota(err)
-		}
-		// maxUse should be a valid percentage.
-		if cfg.MaxUse < 0 || cfg.MaxUse > 100 {
-			err := errors.New("config max use value should not be null or negative")
-			return cfg, config.ErrInvalidCacheQuota(err)
-		}
-		cfg.Quota = cfg.MaxUse
+	if cfg.Expiry == 0 {
+		cfg.Expiry, _ = strconv.Atoi(DefaultExpiry)
 	}
 
-	if quotaStr := env.Get(EnvCacheQuota, DefaultQuota); quotaStr != "" {
+	// RADIO_CACHE_MAXUSE is the former name of RADIO_CACHE_QUOTA.
+	quotaStr := env.Get(EnvCacheQuota, env.Get(EnvCacheMaxUse, ""))
+	if quotaStr != "" {
 		cfg.Quota, err = strconv.Atoi(quotaStr)
 		if err != nil {
 			return cfg, config.ErrInvalidCacheQuota(err)
 		}
-		// quota should be a valid percentage.
-		if cfg.Quota < 0 || cfg.Quota > 100 {
-			err := errors.New("config quota value should not be null or negative")
-			return cfg, config.ErrInvalidCacheQuota(err)
-		}
-		cfg.MaxUse = cfg.Quota
 	}
+	if cfg.Quota == 0 {
+		cfg.Quota, _ = strconv.Atoi(DefaultQuota)
+	}
+	// quota should be a valid percentage.
+	if cfg.Quota < 0 || cfg.Quota > 100 {
+		err = errors.New("config quota value should not be null or negative")
+		return cfg, config.ErrInvalidCacheQuota(err)
+	}
+	cfg.MaxUse = cfg.Quota
 
 	return cfg, nil
 }
+
+// LookupDriveConfig - sets the deduplication and the index of the cache
+// drives from ENVs, falling back to the values provided in config.yml.
+func LookupDriveConfig(cfg *Config, dedup, index bool) (err error) {
+	if dedupStr := env.Get(EnvCacheDedup, ""); dedupStr != "" {
+		if dedup, err = strconv.ParseBool(dedupStr); err != nil {
+			return config.ErrInvalidCacheDedup(err)
+		}
+	}
+	if indexStr := env.Get(EnvCacheIndex, ""); indexStr != "" {
+		if index, err = strconv.ParseBool(indexStr); err != nil {
+			return config.ErrInvalidCacheIndex(err)
+		}
+	}
+	cfg.Dedup, cfg.Index = dedup, index
+	return nil
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Cache policy ENVs and values
+const (
+	EnvCachePolicy    = "RADIO_CACHE_POLICY"
+	EnvCacheAdmission = "RADIO_CACHE_ADMISSION"
+
+	// PolicyLRU evicts least recently used objects first.
+	PolicyLRU = "lru"
+	// PolicyLFU evicts least frequently used objects first.
+	PolicyLFU = "lfu"
+
+	// AdmissionAll caches every object read.
+	AdmissionAll = "all"
+	// AdmissionTinyLFU only caches objects read more often than
+	// the objects they would evict.
+	AdmissionTinyLFU = "tinylfu"
+)
+
+// LookupPolicyConfig - sets the eviction and admission policies from
+// ENVs, falling back to the values provided in config.yml.
+func LookupPolicyConfig(cfg *Config, policy, admission string) error {
+	cfg.Policy = strings.ToLower(env.Get(EnvCachePolicy, policy))
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = PolicyLRU
+	case PolicyLRU, PolicyLFU:
+	default:
+		return config.ErrInvalidCachePolicy(nil).Msg("unknown cache policy %q", cfg.Policy)
+	}
+
+	cfg.Admission = strings.ToLower(env.Get(EnvCacheAdmission, admission))
+	switch cfg.Admission {
+	case "":
+		cfg.Admission = AdmissionAll
+	case AdmissionAll, AdmissionTinyLFU:
+	default:
+		return config.ErrInvalidCachePolicy(nil).Msg("unknown cache admission policy %q", cfg.Admission)
+	}
+	return nil
+}
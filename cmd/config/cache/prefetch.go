@@ -0,0 +1,35 @@
+package cache
+
+import (
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// Prefetch ENVs
+const (
+	EnvCachePrefetchWindow = "RADIO_CACHE_PREFETCH_WINDOW"
+
+	// MaxPrefetchWindow - largest window prefetched for a single client.
+	MaxPrefetchWindow = 1 * humanize.GiByte
+)
+
+// LookupPrefetchConfig - sets the window read ahead of sequential range
+// reads from ENVs, falling back to the value provided in config.yml. An
+// empty or zero window disables prefetching.
+func LookupPrefetchConfig(cfg *Config, window string) error {
+	window = env.Get(EnvCachePrefetchWindow, window)
+	if window == "" {
+		cfg.PrefetchWindow = 0
+		return nil
+	}
+	size, err := humanize.ParseBytes(window)
+	if err != nil {
+		return config.ErrInvalidCachePrefetch(err)
+	}
+	if size > MaxPrefetchWindow {
+		return config.ErrInvalidCachePrefetch(nil).Msg("prefetch window cannot exceed %s", humanize.IBytes(MaxPrefetchWindow))
+	}
+	cfg.PrefetchWindow = int64(size)
+	return nil
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/env"
+	"github.com/minio/radio/cmd/config"
+)
+
+// RAM cache ENVs
+const (
+	EnvCacheSize             = "RADIO_CACHE_SIZE"
+	EnvCacheRAMMaxObjectSize = "RADIO_CACHE_RAM_MAX_OBJECT_SIZE"
+
+	DefaultRAMMaxObjectSize = 1 * humanize.MiByte
+)
+
+// LookupRAMConfig - sets the in-memory tier limits from ENVs, falling
+// back to the values provided in config.yml. Objects larger than the
+// max object size are only cached on the drives.
+func LookupRAMConfig(cfg *Config, ramSize, ramMaxObjectSize string) error {
+	ramSize = env.Get(EnvCacheSize, ramSize)
+	if ramSize == "" {
+		cfg.RAMSize, cfg.RAMMaxObjectSize = 0, 0
+		return nil
+	}
+	size, err := humanize.ParseBytes(ramSize)
+	if err != nil {
+		return config.ErrInvalidCacheRAMSize(err)
+	}
+	cfg.RAMSize = int64(size)
+
+	cfg.RAMMaxObjectSize = DefaultRAMMaxObjectSize
+	if ramMaxObjectSize = env.Get(EnvCacheRAMMaxObjectSize, ramMaxObjectSize); ramMaxObjectSize != "" {
+		size, err = humanize.ParseBytes(ramMaxObjectSize)
+		if err != nil {
+			return config.ErrInvalidCacheRAMSize(err)
+		}
+		cfg.RAMMaxObjectSize = int64(size)
+	}
+	if cfg.RAMMaxObjectSize == 0 || cfg.RAMMaxObjectSize > cfg.RAMSize {
+		return config.ErrInvalidCacheRAMSize(nil).Msg("ram max object size must be between 1B and the ram size")
+	}
+	return nil
+}
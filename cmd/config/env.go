@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of all ENVs overriding config.yml keys.
+const EnvPrefix = "RADIO_"
+
+// envName - converts a key path to its ENV name, for example
+// mirror.0.remote.1.endpoint becomes RADIO_MIRROR_0_REMOTE_1_ENDPOINT.
+func envName(path []string) string {
+	return strings.ToUpper(strings.Join(path, "_"))
+}
+
+// yamlKey - returns the yaml key of a struct field, or "" if skipped.
+func yamlKey(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" || field.PkgPath != "" {
+		return ""
+	}
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "" {
+		tag = strings.ToLower(field.Name)
+	}
+	return tag
+}
+
+// hasEnvWithPrefix - returns true if any ENV starts with prefix.
+func hasEnvWithPrefix(environ []string, prefix string) bool {
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, name, value string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s: unsupported type %s", name, v.Type())
+		}
+		var values []string
+		if value != "" {
+			values = strings.Split(value, ValueSeparator)
+		}
+		v.Set(reflect.ValueOf(values).Convert(v.Type()))
+	default:
+		return fmt.Errorf("%s: unsupported type %s", name, v.Type())
+	}
+	return nil
+}
+
+func applyEnv(environ []string, v reflect.Value, path []string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := yamlKey(t.Field(i))
+			// Keys tagged env:"-" are overridden by ENVs of their own.
+			if key == "" || t.Field(i).Tag.Get("env") == "-" {
+				continue
+			}
+			if err := applyEnv(environ, v.Field(i), append(path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			// Override existing entries, then append new entries
+			// as long as ENVs exist for the next index.
+			for i := 0; ; i++ {
+				elemPath := append(path, strconv.Itoa(i))
+				if i >= v.Len() {
+					if !hasEnvWithPrefix(environ, envName(elemPath)+"_") {
+						return nil
+					}
+					v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+				}
+				if err := applyEnv(environ, v.Index(i), elemPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	name := envName(path)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	return setValue(v, name, value)
+}
+
+// ApplyEnvOverrides - overrides every key of the yaml tagged struct pointed
+// to by cfg with the ENV named after its key path, slices of structs are
+// indexed and extended, slices of strings are comma separated. Fields
+// tagged env:"-" are skipped.
+//
+//	RADIO_ADMIN_ACCESS_KEY=radioadmin
+//	RADIO_MIRROR_0_REMOTE_1_ENDPOINT=http://minio2:9000
+func ApplyEnvOverrides(cfg interface{}) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: expected pointer to struct, found %T", cfg)
+	}
+	return applyEnv(os.Environ(), v.Elem(), []string{strings.TrimSuffix(EnvPrefix, "_")})
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRemote struct {
+	Bucket   string `yaml:"bucket"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+type testConfig struct {
+	Cache struct {
+		Drives []string `yaml:"drives"`
+		Quota  int      `yaml:"quota"`
+	} `yaml:"cache"`
+	Admin struct {
+		AccessKey string `yaml:"access_key"`
+	} `yaml:"admin" env:"-"`
+	Debug   bool          `yaml:"debug"`
+	Refresh time.Duration `yaml:"refresh"`
+	Remote  []testRemote  `yaml:"remote"`
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	envs := map[string]string{
+		"RADIO_CACHE_DRIVES":      "/mnt/cache1,/mnt/cache2",
+		"RADIO_CACHE_QUOTA":       "70",
+		"RADIO_ADMIN_ACCESS_KEY":  "radioadmin",
+		"RADIO_DEBUG":             "true",
+		"RADIO_REFRESH":           "5m",
+		"RADIO_REMOTE_0_BUCKET":   "bucket0",
+		"RADIO_REMOTE_1_ENDPOINT": "http://minio2:9000",
+	}
+	for k, v := range envs {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cfg := testConfig{Remote: []testRemote{{Bucket: "bucket", Endpoint: "http://minio1:9000"}}}
+	if err := ApplyEnvOverrides(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfg.Cache.Drives, []string{"/mnt/cache1", "/mnt/cache2"}) {
+		t.Errorf("Unexpected drives %v", cfg.Cache.Drives)
+	}
+	if cfg.Cache.Quota != 70 {
+		t.Errorf("Expected quota 70, got %d", cfg.Cache.Quota)
+	}
+	if cfg.Admin.AccessKey != "" {
+		t.Errorf("Expected keys tagged env:\"-\" to be skipped, got %q", cfg.Admin.AccessKey)
+	}
+	if !cfg.Debug {
+		t.Error("Expected debug to be enabled")
+	}
+	if cfg.Refresh != 5*time.Minute {
+		t.Errorf("Expected refresh 5m, got %s", cfg.Refresh)
+	}
+	expectedRemotes := []testRemote{
+		{Bucket: "bucket0", Endpoint: "http://minio1:9000"},
+		{Endpoint: "http://minio2:9000"},
+	}
+	if !reflect.DeepEqual(cfg.Remote, expectedRemotes) {
+		t.Errorf("Expected %v, got %v", expectedRemotes, cfg.Remote)
+	}
+}
+
+func TestApplyEnvOverridesInvalid(t *testing.T) {
+	os.Setenv("RADIO_CACHE_QUOTA", "abc")
+	defer os.Unsetenv("RADIO_CACHE_QUOTA")
+
+	var cfg testConfig
+	if err := ApplyEnvOverrides(&cfg); err == nil {
+		t.Error("Expected failure for invalid integer")
+	}
+	if err := ApplyEnvOverrides(cfg); err == nil {
+		t.Error("Expected failure for non pointer config")
+	}
+}
@@ -26,6 +26,84 @@ var (
 		" Valid cache quota value must be between 0-100",
 	)
 
+	ErrInvalidAPIMaxObjectSize = newErrFn(
+		"Invalid API max object size value",
+		"Please check the passed value in your config.yml",
+		"Max object size must be a positive size such as 5TiB or 500GiB",
+	)
+
+	ErrInvalidCacheRAMSize = newErrFn(
+		"Invalid cache RAM size value",
+		"Please check the passed value in your config.yml",
+		"RAM cache sizes must be sizes such as 256MiB, the max object size cannot exceed the RAM size",
+	)
+
+	ErrInvalidCachePolicy = newErrFn(
+		"Invalid cache policy value",
+		"Please check the passed value in your config.yml",
+		"Cache policy must be 'lru' or 'lfu', admission must be 'all' or 'tinylfu'",
+	)
+
+	ErrInvalidCachePrefetch = newErrFn(
+		"Invalid cache prefetch window value",
+		"Please check the passed value in your config.yml",
+		"Prefetch window must be a size such as 8MiB, up to 1GiB",
+	)
+
+	ErrInvalidCacheDedup = newErrFn(
+		"Invalid cache dedup value",
+		"Please check the passed value in your config.yml",
+		"Cache dedup must be 'true' or 'false'",
+	)
+
+	ErrInvalidCacheIndex = newErrFn(
+		"Invalid cache index value",
+		"Please check the passed value in your config.yml",
+		"Cache index must be 'true' or 'false'",
+	)
+
+	ErrInvalidAPIMaxHeaderSize = newErrFn(
+		"Invalid API max header size value",
+		"Please check the passed value in your config.yml",
+		"Max header size must be a positive size such as 8KiB",
+	)
+
+	ErrInvalidAPIMaxMetadataSize = newErrFn(
+		"Invalid API max metadata size value",
+		"Please check the passed value in your config.yml",
+		"Max metadata size must be a positive size such as 2KiB, it cannot exceed the max header size",
+	)
+
+	ErrInvalidAPIParallelGet = newErrFn(
+		"Invalid API parallel get value",
+		"Please check the passed value in your config.yml",
+		"Parallel get threshold, part size and max memory must be sizes such as 64MiB, the part size cannot exceed the threshold or the max memory",
+	)
+
+	ErrInvalidAPISlowRequestThreshold = newErrFn(
+		"Invalid API slow request threshold value",
+		"Please check the passed value in your config.yml",
+		"Slow request threshold must be a positive duration such as 2s or 500ms",
+	)
+
+	ErrInvalidAPICopyBufferSize = newErrFn(
+		"Invalid API copy buffer size value",
+		"Please check the passed value in your config.yml",
+		"Copy buffer size must be a size between 1KiB and 16MiB such as 128KiB",
+	)
+
+	ErrInvalidAPIListener = newErrFn(
+		"Invalid API listener value",
+		"Please check the passed value in your config.yml",
+		"Max header bytes must be a size up to 1MiB, not below the max header size, timeouts must be positive durations such as 10s, counts must not be negative",
+	)
+
+	ErrInvalidAPIJSONErrors = newErrFn(
+		"Invalid API json errors value",
+		"Please check the passed value of RADIO_API_JSON_ERRORS",
+		"Valid values are 'true' or 'false'",
+	)
+
 	ErrInvalidAddressFlag = newErrFn(
 		"--address input is invalid",
 		"Please check --address parameter",
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+)
+
+// secretKeys - the config.yml keys holding secrets.
+var secretKeys = map[string]bool{
+	"secret_key": true,
+	"auth_token": true,
+	"password":   true,
+	"token":      true,
+}
+
+func appendSecrets(secrets []string, v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := yamlKey(t.Field(i))
+			if key == "" {
+				continue
+			}
+			if f := v.Field(i); secretKeys[key] && f.Kind() == reflect.String {
+				if f.String() != "" {
+					secrets = append(secrets, f.String())
+				}
+				continue
+			}
+			secrets = appendSecrets(secrets, v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			secrets = appendSecrets(secrets, v.Index(i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			secrets = appendSecrets(secrets, v.Elem())
+		}
+	}
+	return secrets
+}
+
+// Secrets - returns the values of the secret keys, such as secret_key and
+// auth_token, of the yaml tagged struct pointed to by cfg.
+func Secrets(cfg interface{}) []string {
+	return appendSecrets(nil, reflect.ValueOf(cfg))
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecrets(t *testing.T) {
+	type bucket struct {
+		AccessKey string `yaml:"access_key"`
+		SecretKey string `yaml:"secret_key"`
+	}
+	var cfg struct {
+		Admin      bucket   `yaml:"admin"`
+		Remote     []bucket `yaml:"remote"`
+		Authorizer *struct {
+			AuthToken string `yaml:"auth_token"`
+		} `yaml:"authorizer"`
+		Password string `yaml:"-"`
+	}
+	cfg.Admin = bucket{AccessKey: "admin", SecretKey: "admin-secret"}
+	cfg.Remote = []bucket{{AccessKey: "remote1", SecretKey: "remote1-secret"}, {AccessKey: "remote2"}}
+	cfg.Authorizer = &struct {
+		AuthToken string `yaml:"auth_token"`
+	}{AuthToken: "authorizer-token"}
+	cfg.Password = "skipped"
+
+	expected := []string{"admin-secret", "remote1-secret", "authorizer-token"}
+	if secrets := Secrets(&cfg); !reflect.DeepEqual(secrets, expected) {
+		t.Fatalf("expected %v, got %v", expected, secrets)
+	}
+}
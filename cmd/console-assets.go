@@ -0,0 +1,226 @@
+package cmd
+
+// consoleIndexHTML - the console is a single self contained page which
+// polls the info API and renders the bucket layout and server statistics.
+// It also browses buckets, shows the state of an object on every remote
+// and uploads or downloads test objects.
+const consoleIndexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Radio Console</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; min-width: 40em; }
+th, td { text-align: left; padding: 0.3em 1em; border-bottom: 1px solid #ddd; }
+th { background: #f4f4f4; }
+.muted { color: #888; }
+a { color: #06c; cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>Radio Console</h1>
+<div id="server" class="muted">Loading...</div>
+<h2>Buckets</h2>
+<table>
+<thead><tr><th>Bucket</th><th>Type</th><th>Remote Endpoint</th><th>Remote Bucket</th></tr></thead>
+<tbody id="buckets"></tbody>
+</table>
+<h2>Objects</h2>
+<div>
+<select id="bucket"></select>
+<span id="prefix" class="muted"></span>
+</div>
+<table>
+<thead><tr><th>Name</th><th>Size</th><th>Last Modified</th><th></th></tr></thead>
+<tbody id="objects"></tbody>
+</table>
+<div><a id="more" style="display:none">More...</a></div>
+<div id="status"></div>
+<h2>Upload test object</h2>
+<div>
+<input id="key" placeholder="object name">
+<input id="file" type="file">
+<button id="upload">Upload</button>
+<span id="uploaded" class="muted"></span>
+</div>
+<h2>Network</h2>
+<table><tbody id="network"></tbody></table>
+<h2>S3 Requests</h2>
+<table>
+<thead><tr><th>API</th><th>Current</th><th>Total</th><th>Errors</th><th>Canceled</th></tr></thead>
+<tbody id="requests"></tbody>
+</table>
+<script>
+function esc(s) {
+  var d = document.createElement("div");
+  d.textContent = String(s);
+  return d.innerHTML;
+}
+function row(cells) {
+  return "<tr>" + cells.map(function(c) { return "<td>" + esc(c) + "</td>"; }).join("") + "</tr>";
+}
+var state = {bucket: "", prefix: "", marker: ""};
+function api(path, params) {
+  var q = Object.keys(params).map(function(k) {
+    return encodeURIComponent(k) + "=" + encodeURIComponent(params[k]);
+  }).join("&");
+  return "api/" + path + "?" + q;
+}
+function getJSON(url) {
+  return fetch(url, {credentials: "same-origin"}).then(function(resp) {
+    return resp.json().then(function(body) {
+      if (!resp.ok) { throw new Error(body.Message || body.Code || resp.status); }
+      return body;
+    });
+  });
+}
+function link(text, fn) {
+  var a = document.createElement("a");
+  a.textContent = text;
+  a.onclick = fn;
+  return a;
+}
+function cell(tr, content) {
+  var td = document.createElement("td");
+  if (typeof content === "string" || typeof content === "number") {
+    td.textContent = content;
+  } else if (content) {
+    td.appendChild(content);
+  }
+  tr.appendChild(td);
+}
+function list(prefix, marker) {
+  state.prefix = prefix;
+  state.marker = marker;
+  document.getElementById("prefix").textContent = "/" + prefix;
+  getJSON(api("objects", {bucket: state.bucket, prefix: prefix, marker: marker}))
+    .then(function(res) {
+      var tbody = document.getElementById("objects");
+      if (!marker) { tbody.innerHTML = ""; }
+      if (!marker && prefix) {
+        var up = prefix.replace(/[^\/]*\/$/, "");
+        var tr = document.createElement("tr");
+        cell(tr, link("..", function() { list(up, ""); }));
+        tbody.appendChild(tr);
+      }
+      (res.prefixes || []).forEach(function(p) {
+        var tr = document.createElement("tr");
+        cell(tr, link(p.substring(prefix.length), function() { list(p, ""); }));
+        tbody.appendChild(tr);
+      });
+      (res.objects || []).forEach(function(o) {
+        var tr = document.createElement("tr");
+        cell(tr, link(o.name.substring(prefix.length), function() { status(o.name); }));
+        cell(tr, o.size);
+        cell(tr, o.lastModified);
+        var a = document.createElement("a");
+        a.textContent = "Download";
+        a.href = api("download", {bucket: state.bucket, object: o.name});
+        cell(tr, a);
+        tbody.appendChild(tr);
+      });
+      var more = document.getElementById("more");
+      more.style.display = res.nextMarker ? "" : "none";
+      more.onclick = function() { list(prefix, res.nextMarker); };
+    })
+    .catch(function(err) { document.getElementById("objects").innerHTML = row(["Error: " + err.message]); });
+}
+function status(object) {
+  var div = document.getElementById("status");
+  getJSON(api("status", {bucket: state.bucket, object: object}))
+    .then(function(res) {
+      var rows = "";
+      res.replicas.forEach(function(r) {
+        rows += row([r.endpoint, r.bucket, r.error || (r.inSync ? "in sync" : "out of sync"),
+          r.version || "-", r.etag || "-", r.size]);
+      });
+      div.innerHTML = "<h3>" + esc(object) + "</h3><table><thead><tr><th>Endpoint</th><th>Bucket</th>" +
+        "<th>State</th><th>Version</th><th>ETag</th><th>Size</th></tr></thead><tbody>" + rows + "</tbody></table>";
+    })
+    .catch(function(err) { div.textContent = "Error: " + err.message; });
+}
+document.getElementById("bucket").onchange = function(e) {
+  state.bucket = e.target.value;
+  list("", "");
+};
+document.getElementById("upload").onclick = function() {
+  var file = document.getElementById("file").files[0];
+  var key = document.getElementById("key").value || (file && state.prefix + file.name);
+  var out = document.getElementById("uploaded");
+  if (!file || !state.bucket) { out.textContent = "Select a bucket and a file"; return; }
+  fetch(api("upload", {bucket: state.bucket, object: key}), {
+    method: "PUT",
+    credentials: "same-origin",
+    headers: {"X-Radio-Console": "1", "Content-Type": file.type || "application/octet-stream"},
+    body: file
+  }).then(function(resp) {
+    return resp.json().then(function(body) {
+      if (!resp.ok) { throw new Error(body.Message || body.Code || resp.status); }
+      out.textContent = "Uploaded " + body.name + " (" + body.size + " bytes)";
+      list(state.prefix, "");
+      status(body.name);
+    });
+  }).catch(function(err) { out.textContent = "Error: " + err.message; });
+};
+function renderBuckets(buckets) {
+  var sel = document.getElementById("bucket");
+  var names = buckets.filter(function(b) { return b.type == "mirror"; })
+    .map(function(b) { return b.bucket; }).sort();
+  if (sel.options.length == names.length) { return; }
+  sel.innerHTML = "";
+  names.forEach(function(name) {
+    var opt = document.createElement("option");
+    opt.value = opt.textContent = name;
+    sel.appendChild(opt);
+  });
+  if (names.length && !state.bucket) {
+    state.bucket = names[0];
+    list("", "");
+  }
+}
+function render(info) {
+  var uptime = Math.floor(info.uptime / 1e9);
+  document.getElementById("server").textContent = "Version " + info.version +
+    " | Deployment " + info.deploymentID + " | Region " + (info.region || "-") +
+    " | Uptime " + uptime + "s";
+  var rows = "";
+  (info.buckets || []).forEach(function(b) {
+    var kind = b.type + (b.parity ? " (parity " + b.parity + ")" : "");
+    (b.remotes || []).forEach(function(r, i) {
+      rows += row([i == 0 ? b.bucket : "", i == 0 ? kind : "", r.endpoint, r.bucket]);
+    });
+  });
+  document.getElementById("buckets").innerHTML = rows;
+  renderBuckets(info.buckets || []);
+  var n = info.network;
+  document.getElementById("network").innerHTML =
+    row(["Transferred", n.transferred]) + row(["Received", n.received]) +
+    row(["Transferred (S3)", n.transferredS3]) + row(["Received (S3)", n.receivedS3]);
+  var h = info.http, apis = {};
+  var canceled = h.totalS3Canceled || {};
+  [h.currentS3Requests, h.totalS3Requests, h.totalS3Errors, canceled].forEach(function(s) {
+    Object.keys(s.apiStats || {}).forEach(function(k) { apis[k] = true; });
+  });
+  rows = "";
+  Object.keys(apis).sort().forEach(function(k) {
+    rows += row([k, h.currentS3Requests.apiStats[k] || 0,
+      h.totalS3Requests.apiStats[k] || 0, h.totalS3Errors.apiStats[k] || 0,
+      (canceled.apiStats || {})[k] || 0]);
+  });
+  document.getElementById("requests").innerHTML = rows;
+}
+function refresh() {
+  fetch("api/info", {credentials: "same-origin"})
+    .then(function(resp) { return resp.json(); })
+    .then(render)
+    .catch(function(err) { document.getElementById("server").textContent = "Error: " + err; });
+}
+refresh();
+setInterval(refresh, 5000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/minio/pkg/policy"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// checkConsoleAuth - verifies the HTTP basic credentials of a console
+// request against the admin credential, challenging the browser otherwise.
+func checkConsoleAuth(w http.ResponseWriter, r *http.Request) bool {
+	if !globalAdminCred.IsValid() {
+		writeResponse(w, http.StatusNotFound, nil, mimeNone)
+		return false
+	}
+	accessKey, secretKey, ok := r.BasicAuth()
+	if ok && subtle.ConstantTimeCompare([]byte(accessKey), []byte(globalAdminCred.AccessKey)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(secretKey), []byte(globalAdminCred.SecretKey)) == 1 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="radio"`)
+	writeResponse(w, http.StatusUnauthorized, nil, mimeNone)
+	return false
+}
+
+// consoleIndexHandler - serves the embedded console page.
+func consoleIndexHandler(w http.ResponseWriter, r *http.Request) {
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+	setCommonHeaders(w)
+	w.Header().Set(xhttp.ContentType, "text/html; charset=utf-8")
+	w.Header().Set(xhttp.ContentLength, strconv.Itoa(len(consoleIndexHTML)))
+	w.Header().Set(xhttp.CacheControl, "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(consoleIndexHTML))
+}
+
+// consoleInfoHandler - returns server information used by the console.
+func consoleInfoHandler(w http.ResponseWriter, r *http.Request) {
+	defer logger.AuditLog(w, r, "ConsoleInfo")
+
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+	writeSuccessResponseJSON(w, encodeResponseJSON(getServerInfo()))
+}
+
+// Header the console sends with uploads, browsers do not add custom
+// headers to cross-site form submissions reusing the basic credentials.
+const consoleRequestHeader = "X-Radio-Console"
+
+// Maximum number of objects listed per console page.
+const consoleMaxKeys = 100
+
+// ConsoleObjectsResult - a page of objects and prefixes of a bucket.
+type ConsoleObjectsResult struct {
+	Bucket     string          `json:"bucket"`
+	Prefix     string          `json:"prefix"`
+	Prefixes   []string        `json:"prefixes"`
+	Objects    []ConsoleObject `json:"objects"`
+	NextMarker string          `json:"nextMarker,omitempty"`
+}
+
+// ConsoleObject - an object listed by the console.
+type ConsoleObject struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// consoleObjectsHandler - lists one level of objects of a bucket.
+func consoleObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConsoleListObjects")
+
+	defer logger.AuditLog(w, r, "ConsoleListObjects")
+
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	bucket, prefix := query.Get("bucket"), query.Get("prefix")
+	loi, err := objectAPI.ListObjects(ctx, bucket, prefix, query.Get("marker"), SlashSeparator, consoleMaxKeys)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	result := ConsoleObjectsResult{
+		Bucket:   bucket,
+		Prefix:   prefix,
+		Prefixes: loi.Prefixes,
+		Objects:  make([]ConsoleObject, 0, len(loi.Objects)),
+	}
+	for _, oi := range loi.Objects {
+		result.Objects = append(result.Objects, ConsoleObject{
+			Name:         oi.Name,
+			Size:         oi.Size,
+			ETag:         oi.ETag,
+			LastModified: oi.ModTime,
+		})
+	}
+	if loi.IsTruncated {
+		result.NextMarker = loi.NextMarker
+	}
+	writeSuccessResponseJSON(w, encodeResponseJSON(result))
+}
+
+// consoleObjectStatusHandler - reports the state of an object on every
+// remote of its bucket.
+func consoleObjectStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConsoleObjectStatus")
+
+	defer logger.AuditLog(w, r, "ConsoleObjectStatus")
+
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	status, err := getObjectReplicationStatus(query.Get("bucket"), query.Get("object"))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	writeSuccessResponseJSON(w, encodeResponseJSON(status))
+}
+
+// consoleDownloadHandler - downloads an object as an attachment.
+func consoleDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConsoleDownload")
+
+	defer logger.AuditLog(w, r, "ConsoleDownload")
+
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	bucket, object := query.Get("bucket"), query.Get("object")
+	getObjectNInfo := objectAPI.GetObjectNInfo
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		getObjectNInfo = cacheAPI.GetObjectNInfo
+	}
+	gr, err := getObjectNInfo(ctx, bucket, object, nil, http.Header{}, ReadLock, ObjectOptions{})
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	defer gr.Close()
+
+	if err = setObjectHeaders(w, gr.ObjInfo, nil); err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	w.Header().Set(xhttp.ContentDisposition, mime.FormatMediaType("attachment",
+		map[string]string{"filename": path.Base(object)}))
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, gr); err != nil {
+		logger.LogIf(ctx, err)
+	}
+}
+
+// consoleUploadHandler - uploads a test object, the body is stored as is.
+func consoleUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ConsoleUpload")
+
+	defer logger.AuditLog(w, r, "ConsoleUpload")
+
+	if !checkConsoleAuth(w, r) {
+		return
+	}
+	if r.Header.Get(consoleRequestHeader) == "" {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAccessDenied), r)
+		return
+	}
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r)
+		return
+	}
+
+	query := r.URL.Query()
+	bucket, object := query.Get("bucket"), query.Get("object")
+	if object == "" {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidObjectName), r)
+		return
+	}
+	if s3Err := globalWriteFences.admit(r, HookAuth{Action: string(policy.PutObjectAction), Bucket: bucket, Object: object}); s3Err != ErrNone {
+		discardRequestBody(w, r)
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(s3Err), r)
+		return
+	}
+	size := r.ContentLength
+	if size < 0 {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r)
+		return
+	}
+	if isMaxObjectSize(size) {
+		discardRequestBody(w, r)
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r)
+		return
+	}
+
+	metadata := map[string]string{}
+	if contentType := r.Header.Get(xhttp.ContentType); contentType != "" {
+		metadata[strings.ToLower(xhttp.ContentType)] = contentType
+	}
+	reader, err := globalContentTypes.apply(bucket, object, metadata, r.Body)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	hashReader, err := hash.NewReader(reader, size, "", "", size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+
+	putObject := objectAPI.PutObject
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		putObject = cacheAPI.PutObject
+	}
+	objInfo, err := putObject(ctx, bucket, object, NewPutObjReader(hashReader, nil, nil),
+		ObjectOptions{UserDefined: metadata})
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAPIError(ctx, err), r)
+		return
+	}
+	writeSuccessResponseJSON(w, encodeResponseJSON(ConsoleObject{
+		Name:         objInfo.Name,
+		Size:         objInfo.Size,
+		ETag:         objInfo.ETag,
+		LastModified: objInfo.ModTime,
+	}))
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	consolePath       = "/console"
+	consolePathPrefix = minioReservedBucketPath + consolePath
+)
+
+// registerConsoleRouter - add handler functions for the web console.
+func registerConsoleRouter(router *mux.Router) {
+	consoleRouter := router.PathPrefix(consolePathPrefix).Subrouter()
+
+	consoleRouter.Methods(http.MethodGet).Path("/api/info").HandlerFunc(httpTraceHdrs(consoleInfoHandler))
+	consoleRouter.Methods(http.MethodGet).Path("/api/objects").HandlerFunc(httpTraceHdrs(consoleObjectsHandler))
+	consoleRouter.Methods(http.MethodGet).Path("/api/status").HandlerFunc(httpTraceHdrs(consoleObjectStatusHandler))
+	consoleRouter.Methods(http.MethodGet).Path("/api/download").HandlerFunc(httpTraceHdrs(consoleDownloadHandler))
+	consoleRouter.Methods(http.MethodPut).Path("/api/upload").HandlerFunc(httpTraceHdrs(consoleUploadHandler))
+	consoleRouter.Methods(http.MethodGet).Path("/").HandlerFunc(httpTraceHdrs(consoleIndexHandler))
+
+	// Redirect to the trailing slash, so that relative links work.
+	consoleRouter.Methods(http.MethodGet).Path("").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, consolePathPrefix+SlashSeparator, http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Files are checked for modifications at most once per interval.
+const credentialsFileCheckInterval = 30 * time.Second
+
+// fileCredentials - credentials.Provider reading the access key, secret key
+// and optional session token from individual files, such as Docker or
+// Kubernetes secrets. Credentials are reloaded when the files change.
+type fileCredentials struct {
+	accessKeyFile    string
+	secretKeyFile    string
+	sessionTokenFile string
+
+	modTimes  []time.Time
+	nextCheck time.Time
+}
+
+func newFileCredentials(accessKeyFile, secretKeyFile, sessionTokenFile string) *credentials.Credentials {
+	return credentials.New(&fileCredentials{
+		accessKeyFile:    accessKeyFile,
+		secretKeyFile:    secretKeyFile,
+		sessionTokenFile: sessionTokenFile,
+	})
+}
+
+func (f *fileCredentials) files() []string {
+	files := []string{f.accessKeyFile, f.secretKeyFile}
+	if f.sessionTokenFile != "" {
+		files = append(files, f.sessionTokenFile)
+	}
+	return files
+}
+
+func readCredentialsFile(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Retrieve - reads the credentials from the files.
+func (f *fileCredentials) Retrieve() (credentials.Value, error) {
+	var modTimes []time.Time
+	for _, file := range f.files() {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return credentials.Value{}, err
+		}
+		modTimes = append(modTimes, fi.ModTime())
+	}
+
+	accessKey, err := readCredentialsFile(f.accessKeyFile)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	secretKey, err := readCredentialsFile(f.secretKeyFile)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	sessionToken, err := readCredentialsFile(f.sessionTokenFile)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	f.modTimes = modTimes
+	f.nextCheck = time.Now().Add(credentialsFileCheckInterval)
+	value := credentials.Value{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    sessionToken,
+		SignerType:      credentials.SignatureV4,
+	}
+	registerCredentialsSecrets(value)
+	return value, nil
+}
+
+// registerCredentialsSecrets - keeps the secrets of credentials read
+// after startup, such as rotated keys, out of the logs as those of
+// config.yml.
+func registerCredentialsSecrets(value credentials.Value) {
+	logger.RegisterSecret(value.SecretAccessKey)
+	logger.RegisterSecret(value.SessionToken)
+}
+
+// IsExpired - returns true if any of the files changed since last Retrieve.
+func (f *fileCredentials) IsExpired() bool {
+	if f.modTimes == nil {
+		return true
+	}
+	if time.Now().Before(f.nextCheck) {
+		return false
+	}
+	f.nextCheck = time.Now().Add(credentialsFileCheckInterval)
+	for i, file := range f.files() {
+		fi, err := os.Stat(file)
+		if err != nil {
+			// Keep using the current credentials while a secret is
+			// being replaced, Retrieve reports the error if persistent.
+			return false
+		}
+		if !fi.ModTime().Equal(f.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Default refresh interval of secrets which carry no lease duration.
+const defaultVaultRefreshInterval = 5 * time.Minute
+
+// vaultConfig - location of remote credentials stored in a HashiCorp Vault
+// KV secrets engine, both KV version 1 and 2 are supported.
+type vaultConfig struct {
+	Address string `yaml:"address"`
+	// Token or TokenFile authenticate to Vault, TokenFile is re-read
+	// on every refresh.
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// Path of the secret, such as secret/data/radio/remote1 for KV v2.
+	Path string `yaml:"path"`
+	// Keys inside the secret, default to access_key, secret_key
+	// and session_token.
+	AccessKeyField    string        `yaml:"access_key_field"`
+	SecretKeyField    string        `yaml:"secret_key_field"`
+	SessionTokenField string        `yaml:"session_token_field"`
+	RefreshInterval   time.Duration `yaml:"refresh_interval"`
+}
+
+// vaultCredentials - credentials.Provider reading remote credentials from
+// Vault. The secret is re-read once its lease or the refresh interval ends
+// and renewable Vault tokens are renewed before they expire.
+type vaultCredentials struct {
+	credentials.Expiry
+
+	cfg        vaultConfig
+	httpClient *http.Client
+
+	tokenExpiry time.Time
+	renewable   bool
+}
+
+func newVaultCredentials(cfg vaultConfig) *credentials.Credentials {
+	if cfg.AccessKeyField == "" {
+		cfg.AccessKeyField = "access_key"
+	}
+	if cfg.SecretKeyField == "" {
+		cfg.SecretKeyField = "secret_key"
+	}
+	if cfg.SessionTokenField == "" {
+		cfg.SessionTokenField = "session_token"
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultVaultRefreshInterval
+	}
+	cfg.Address = strings.TrimSuffix(cfg.Address, "/")
+	return credentials.New(&vaultCredentials{
+		cfg:        cfg,
+		httpClient: &http.Client{Transport: NewCustomHTTPTransport()},
+	})
+}
+
+// vaultResponse - subset of a Vault API response.
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		LeaseDuration int  `json:"lease_duration"`
+		Renewable     bool `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func (v *vaultCredentials) token() (string, error) {
+	if v.cfg.TokenFile == "" {
+		return v.cfg.Token, nil
+	}
+	token, err := readCredentialsFile(v.cfg.TokenFile)
+	logger.RegisterSecret(token)
+	return token, err
+}
+
+func (v *vaultCredentials) do(method, path string, body []byte) (*vaultResponse, error) {
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, v.cfg.Address+"/v1/"+strings.TrimPrefix(path, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var vresp vaultResponse
+	if err = json.Unmarshal(data, &vresp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault %s: %s %s", path, resp.Status, strings.Join(vresp.Errors, ", "))
+	}
+	return &vresp, nil
+}
+
+// renewToken - looks up the token on first use, afterwards renews it
+// once less than half of its TTL remains.
+func (v *vaultCredentials) renewToken() error {
+	if v.tokenExpiry.IsZero() {
+		vresp, err := v.do(http.MethodGet, "auth/token/lookup-self", nil)
+		if err != nil {
+			return err
+		}
+		ttl, _ := vresp.Data["ttl"].(float64)
+		v.renewable, _ = vresp.Data["renewable"].(bool)
+		v.tokenExpiry = time.Now().Add(time.Duration(ttl) * time.Second)
+		if ttl == 0 {
+			// Root and periodic tokens without a TTL never expire.
+			v.renewable = false
+		}
+	}
+	if !v.renewable || time.Until(v.tokenExpiry) > v.cfg.RefreshInterval*2 {
+		return nil
+	}
+	vresp, err := v.do(http.MethodPost, "auth/token/renew-self", []byte("{}"))
+	if err != nil {
+		return err
+	}
+	if vresp.Auth != nil {
+		v.renewable = vresp.Auth.Renewable
+		v.tokenExpiry = time.Now().Add(time.Duration(vresp.Auth.LeaseDuration) * time.Second)
+	}
+	return nil
+}
+
+// Retrieve - reads the secret from Vault.
+func (v *vaultCredentials) Retrieve() (credentials.Value, error) {
+	if err := v.renewToken(); err != nil {
+		return credentials.Value{}, err
+	}
+
+	vresp, err := v.do(http.MethodGet, v.cfg.Path, nil)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	data := vresp.Data
+	// KV version 2 nests the secret under data.data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok = data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	accessKey, _ := data[v.cfg.AccessKeyField].(string)
+	secretKey, _ := data[v.cfg.SecretKeyField].(string)
+	sessionToken, _ := data[v.cfg.SessionTokenField].(string)
+	if accessKey == "" || secretKey == "" {
+		return credentials.Value{}, errors.New("vault " + v.cfg.Path + ": secret is missing " +
+			v.cfg.AccessKeyField + " or " + v.cfg.SecretKeyField)
+	}
+
+	refresh := v.cfg.RefreshInterval
+	if lease := time.Duration(vresp.LeaseDuration) * time.Second; lease > 0 && lease < refresh {
+		refresh = lease
+	}
+	v.SetExpiration(time.Now().Add(refresh), -1)
+
+	value := credentials.Value{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    sessionToken,
+		SignerType:      credentials.SignatureV4,
+	}
+	registerCredentialsSecrets(value)
+	return value, nil
+}
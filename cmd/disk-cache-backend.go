@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,7 +22,7 @@ import (
 	xhttp "github.com/minio/radio/cmd/http"
 	"github.com/minio/minio/pkg/disk"
 	"github.com/minio/radio/cmd/logger"
-	"github.com/ncw/directio"
+	"github.com/minio/sha256-simd"
 )
 
 const (
@@ -51,6 +53,13 @@ type cacheMeta struct {
 	Checksum CacheChecksumInfoV1 `json:"checksum,omitempty"`
 	// Metadata map for current object.
 	Meta map[string]string `json:"meta,omitempty"`
+	// Bucket and Object name the cached object, empty for
+	// objects cached by older releases.
+	Bucket string `json:"bucket,omitempty"`
+	Object string `json:"object,omitempty"`
+	// Digest is the SHA-256 content hash of deduplicated objects, their
+	// data is linked to the data of the same hash.
+	Digest string `json:"digest,omitempty"`
 }
 
 func (m *cacheMeta) ToObjectInfo(bucket, object string) (o ObjectInfo) {
@@ -101,7 +110,16 @@ type diskCache struct {
 	onlineMutex *sync.RWMutex
 	// purge() listens on this channel to start the cache-purge process
 	purgeChan chan struct{}
-	pool      sync.Pool
+	// freq is set with the lfu policy, purge then evicts least
+	// frequently read objects first.
+	freq *tinyLFU
+	// dedup stores identical data of cached objects once, dedupMu
+	// serializes linking to and releasing the data.
+	dedup   bool
+	dedupMu sync.Mutex
+	// index tracks the cached objects so that purge needs no walk of
+	// the drive, nil unless enabled.
+	index *cacheIndex
 }
 
 // Inits the disk cache dir if it is not initialized already.
@@ -116,12 +134,6 @@ func newDiskCache(dir string, expiry int, quotaPct int) (*diskCache, error) {
 		purgeChan:   make(chan struct{}),
 		online:      true,
 		onlineMutex: &sync.RWMutex{},
-		pool: sync.Pool{
-			New: func() interface{} {
-				b := directio.AlignedBlock(int(cacheBlkSize))
-				return &b
-			},
-		},
 	}
 	return &cache, nil
 }
@@ -173,69 +185,202 @@ func (c *diskCache) diskAvailable(size int64) bool {
 
 // Purge cache entries that were not accessed.
 func (c *diskCache) purge() {
-	ctx := context.Background()
+	ctx := logger.WithTags(newBackgroundContext("CachePurge", "", ""), "cachePath", c.dir)
 	for {
-		olderThan := c.expiry
-		for !c.diskUsageLow() {
-			// delete unaccessed objects older than expiry duration
-			expiry := UTCNow().AddDate(0, 0, -1*olderThan)
-			olderThan /= 2
-			if olderThan < 1 {
+		c.purgeDedup(ctx)
+		if c.freq != nil && !c.diskUsageLow() {
+			c.purgeLeastFrequent(ctx)
+		}
+		if c.index != nil {
+			c.purgeIndexed(ctx)
+		} else {
+			c.purgeUnaccessed(ctx)
+		}
+		lastRunTime := time.Now()
+		for {
+			<-c.purgeChan
+			timeElapsed := time.Since(lastRunTime)
+			if timeElapsed > time.Hour {
 				break
 			}
-			deletedCount := 0
+		}
+	}
+}
+
+// purgeUnaccessed - evicts the objects not read within the expiry, then
+// within half of it and so on, until disk usage is low again.
+func (c *diskCache) purgeUnaccessed(ctx context.Context) {
+	olderThan := c.expiry
+	for !c.diskUsageLow() {
+		// delete unaccessed objects older than expiry duration
+		expiry := UTCNow().AddDate(0, 0, -1*olderThan)
+		olderThan /= 2
+		if olderThan < 1 {
+			break
+		}
+		deletedCount := 0
+
+		objDirs, err := ioutil.ReadDir(c.dir)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-			objDirs, err := ioutil.ReadDir(c.dir)
+		for _, obj := range objDirs {
+			if obj.Name() == minioMetaBucket {
+				continue
+			}
+			// stat entry to get atime
+			var fi os.FileInfo
+			fi, err := os.Stat(pathJoin(c.dir, obj.Name(), cacheDataFile))
 			if err != nil {
-				log.Fatal(err)
+				continue
 			}
 
-			for _, obj := range objDirs {
-				if obj.Name() == minioMetaBucket {
-					continue
-				}
-				// stat entry to get atime
-				var fi os.FileInfo
-				fi, err := os.Stat(pathJoin(c.dir, obj.Name(), cacheDataFile))
-				if err != nil {
-					continue
-				}
+			objInfo, err := c.statCache(pathJoin(c.dir, obj.Name()))
+			if err != nil {
+				// delete any partially filled cache entry left behind.
+				c.removeEntry(pathJoin(c.dir, obj.Name()), false)
+				continue
+			}
+			cc := cacheControlOpts(objInfo)
 
-				objInfo, err := c.statCache(pathJoin(c.dir, obj.Name()))
-				if err != nil {
-					// delete any partially filled cache entry left behind.
-					removeAll(pathJoin(c.dir, obj.Name()))
-					continue
+			if atime.Get(fi).Before(expiry) ||
+				cc.isStale(objInfo.ModTime) {
+				if err = c.removeEntry(pathJoin(c.dir, obj.Name()), false); err != nil {
+					logger.LogIf(ctx, err)
 				}
-				cc := cacheControlOpts(objInfo)
-
-				if atime.Get(fi).Before(expiry) ||
-					cc.isStale(objInfo.ModTime) {
-					if err = removeAll(pathJoin(c.dir, obj.Name())); err != nil {
-						logger.LogIf(ctx, err)
-					}
-					deletedCount++
-					// break early if sufficient disk space reclaimed.
-					if !c.diskUsageLow() {
-						break
-					}
+				deletedCount++
+				// break early if sufficient disk space reclaimed.
+				if !c.diskUsageLow() {
+					break
 				}
 			}
-			if deletedCount == 0 {
-				break
-			}
 		}
-		lastRunTime := time.Now()
-		for {
-			<-c.purgeChan
-			timeElapsed := time.Since(lastRunTime)
-			if timeElapsed > time.Hour {
-				break
-			}
+		if deletedCount == 0 {
+			break
+		}
+	}
+}
+
+// purgeIndexed - evicts the least recently read objects of the index,
+// not read for a day at least, until disk usage is low again.
+func (c *diskCache) purgeIndexed(ctx context.Context) {
+	expiry := UTCNow().AddDate(0, 0, -1)
+	for !c.diskUsageLow() {
+		e, ok := c.index.oldest()
+		if !ok || !e.Atime.Before(expiry) {
+			return
+		}
+		if err := c.removeEntry(pathJoin(c.dir, e.Name), false); err != nil {
+			logger.LogIf(ctx, err)
+			// keep evicting the entries read next
+			c.index.remove(e.Name)
+		}
+	}
+}
+
+// purgeLeastFrequent - evicts cached objects in order of increasing
+// access frequency until disk usage is low again.
+func (c *diskCache) purgeLeastFrequent(ctx context.Context) {
+	var names []string
+	if c.index != nil {
+		names = c.index.names(0)
+	} else {
+		objDirs, err := ioutil.ReadDir(c.dir)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			return
+		}
+		names = make([]string, 0, len(objDirs))
+		for _, obj := range objDirs {
+			names = append(names, obj.Name())
+		}
+	}
+	for _, name := range c.leastFrequent(names) {
+		if c.diskUsageLow() {
+			return
+		}
+		if err := c.removeEntry(pathJoin(c.dir, name), false); err != nil {
+			logger.LogIf(ctx, err)
 		}
 	}
 }
 
+// leastFrequent - returns the cached objects among names in order of
+// increasing access frequency, the meta bucket is skipped.
+func (c *diskCache) leastFrequent(names []string) []string {
+	type cacheEntry struct {
+		name string
+		freq int
+	}
+	entries := make([]cacheEntry, 0, len(names))
+	for _, name := range names {
+		if name == minioMetaBucket {
+			continue
+		}
+		entries = append(entries, cacheEntry{name, c.freq.Estimate(name)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].freq < entries[j].freq
+	})
+
+	sorted := make([]string, len(entries))
+	for i := range entries {
+		sorted[i] = entries[i].name
+	}
+	return sorted
+}
+
+// cacheVictimSamples - number of cached objects sampled to find the
+// object an admitted object would evict.
+const cacheVictimSamples = 16
+
+// victim - returns the key of the object purge would evict next among a
+// sample of the cached objects, the least frequently read object with the
+// lfu policy and the least recently read object otherwise. Returns false
+// if nothing is cached.
+func (c *diskCache) victim() (string, bool) {
+	if c.index != nil && c.freq == nil {
+		e, ok := c.index.oldest()
+		return e.Name, ok
+	}
+	var names []string
+	if c.index != nil {
+		names = c.index.names(cacheVictimSamples)
+	} else {
+		d, err := os.Open(c.dir)
+		if err != nil {
+			return "", false
+		}
+		defer d.Close()
+		// Directory order of the hashed entry names is effectively random.
+		names, _ = d.Readdirnames(cacheVictimSamples)
+	}
+
+	if c.freq != nil {
+		if sorted := c.leastFrequent(names); len(sorted) > 0 {
+			return sorted[0], true
+		}
+		return "", false
+	}
+
+	var victim string
+	var oldest time.Time
+	for _, name := range names {
+		if name == minioMetaBucket {
+			continue
+		}
+		fi, err := os.Stat(pathJoin(c.dir, name, cacheDataFile))
+		if err != nil {
+			continue
+		}
+		if at := atime.Get(fi); victim == "" || at.Before(oldest) {
+			victim, oldest = name, at
+		}
+	}
+	return victim, victim != ""
+}
+
 // sets cache drive status
 func (c *diskCache) setOnline(status bool) {
 	c.onlineMutex.Lock()
@@ -262,18 +407,25 @@ func (c *diskCache) Stat(ctx context.Context, bucket, object string) (oi ObjectI
 	return
 }
 
-// statCache is a convenience function for purge() to get ObjectInfo for cached object
-func (c *diskCache) statCache(cacheObjPath string) (oi ObjectInfo, e error) {
-	// Statgi the file to get file size.
-	metaPath := path.Join(cacheObjPath, cacheMetaJSONFile)
-	f, err := os.Open(metaPath)
+// loadCacheMeta - reads the metadata of the object cached at cacheObjPath.
+func loadCacheMeta(cacheObjPath string) (*cacheMeta, error) {
+	f, err := os.Open(path.Join(cacheObjPath, cacheMetaJSONFile))
 	if err != nil {
-		return oi, err
+		return nil, err
 	}
 	defer f.Close()
 
 	meta := &cacheMeta{Version: cacheMetaVersion}
 	if err := jsonLoad(f, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// statCache is a convenience function for purge() to get ObjectInfo for cached object
+func (c *diskCache) statCache(cacheObjPath string) (oi ObjectInfo, e error) {
+	meta, err := loadCacheMeta(cacheObjPath)
+	if err != nil {
 		return oi, err
 	}
 	fi, err := os.Stat(pathJoin(cacheObjPath, cacheDataFile))
@@ -281,11 +433,11 @@ func (c *diskCache) statCache(cacheObjPath string) (oi ObjectInfo, e error) {
 		return oi, err
 	}
 	meta.Stat.ModTime = atime.Get(fi)
-	return meta.ToObjectInfo("", ""), nil
+	return meta.ToObjectInfo(meta.Bucket, meta.Object), nil
 }
 
 // saves object metadata to disk cache
-func (c *diskCache) saveMetadata(ctx context.Context, bucket, object string, meta map[string]string, actualSize int64) error {
+func (c *diskCache) saveMetadata(ctx context.Context, bucket, object string, meta map[string]string, actualSize int64, digest string) error {
 	fileName := getCacheSHADir(c.dir, bucket, object)
 	metaPath := pathJoin(fileName, cacheMetaJSONFile)
 
@@ -295,7 +447,7 @@ func (c *diskCache) saveMetadata(ctx context.Context, bucket, object string, met
 	}
 	defer f.Close()
 
-	m := cacheMeta{Meta: meta, Version: cacheMetaVersion}
+	m := cacheMeta{Meta: meta, Version: cacheMetaVersion, Bucket: bucket, Object: object, Digest: digest}
 	m.Stat.Size = actualSize
 	m.Stat.ModTime = UTCNow()
 	m.Checksum = CacheChecksumInfoV1{Algorithm: HighwayHash256S.String(), Blocksize: cacheBlkSize}
@@ -303,8 +455,24 @@ func (c *diskCache) saveMetadata(ctx context.Context, bucket, object string, met
 	if err != nil {
 		return err
 	}
-	_, err = f.Write(jsonData)
-	return err
+	if _, err = f.Write(jsonData); err != nil {
+		return err
+	}
+	if err = globalCacheSync.written(f); err != nil {
+		return err
+	}
+	if c.index != nil {
+		e := cacheIndexEntry{
+			Name:   path.Base(fileName),
+			Bucket: bucket,
+			Object: object,
+			Size:   actualSize,
+			Atime:  m.Stat.ModTime,
+			ETag:   extractETag(meta),
+		}
+		logger.LogIf(ctx, c.index.put(e))
+	}
+	return nil
 }
 
 // Backend metadata could have changed through server side copy - reset cache metadata if that is the case
@@ -330,13 +498,18 @@ func (c *diskCache) updateMetadataIfChanged(ctx context.Context, bucket, object
 		bkObjectInfo.ETag != cacheObjInfo.ETag ||
 		bkObjectInfo.ContentType != cacheObjInfo.ContentType ||
 		bkObjectInfo.Expires != cacheObjInfo.Expires {
-		return c.saveMetadata(ctx, bucket, object, getMetadata(bkObjectInfo), bkObjectInfo.Size)
+		// The data is unchanged, and so is its deduplication.
+		var digest string
+		if m, err := loadCacheMeta(getCacheSHADir(c.dir, bucket, object)); err == nil {
+			digest = m.Digest
+		}
+		return c.saveMetadata(ctx, bucket, object, getMetadata(bkObjectInfo), bkObjectInfo.Size, digest)
 	}
 	return nil
 }
 
 func getCacheSHADir(dir, bucket, object string) string {
-	return path.Join(dir, getSHA256Hash([]byte(path.Join(bucket, object))))
+	return path.Join(dir, cacheKey(bucket, object))
 }
 
 // Cache data to disk with bitrot checksum added for each block of 1MB
@@ -353,18 +526,31 @@ func (c *diskCache) bitrotWriteToCache(cachePath string, reader io.Reader, size
 	if err := checkPathLength(filePath); err != nil {
 		return 0, err
 	}
-	f, err := os.Create(filePath)
-	if err != nil {
-		return 0, osErrToFSFileErr(err)
+	var w io.Writer
+	var f *os.File
+	var dw *directWriter
+	var err error
+	if globalCacheDirectIO > 0 && int64(size) >= globalCacheDirectIO {
+		// File systems without O_DIRECT are written through the page cache.
+		dw, _ = createDirect(filePath)
+	}
+	if dw != nil {
+		defer dw.f.Close()
+		w = dw
+	} else {
+		if f, err = os.Create(filePath); err != nil {
+			return 0, osErrToFSFileErr(err)
+		}
+		defer f.Close()
+		w = f
 	}
-	defer f.Close()
 
 	var bytesWritten int64
 
 	h := HighwayHash256S.New()
 
-	bufp := c.pool.Get().(*[]byte)
-	defer c.pool.Put(bufp)
+	bufp := globalCacheBlocks.get()
+	defer globalCacheBlocks.put(bufp)
 
 	var n, n2 int
 	for {
@@ -382,10 +568,10 @@ func (c *diskCache) bitrotWriteToCache(cachePath string, reader io.Reader, size
 			return 0, err
 		}
 		hashBytes := h.Sum(nil)
-		if _, err = f.Write(hashBytes); err != nil {
+		if _, err = w.Write(hashBytes); err != nil {
 			return 0, err
 		}
-		if n2, err = f.Write((*bufp)[:n]); err != nil {
+		if n2, err = w.Write((*bufp)[:n]); err != nil {
 			return 0, err
 		}
 		bytesWritten += int64(n2)
@@ -393,7 +579,10 @@ func (c *diskCache) bitrotWriteToCache(cachePath string, reader io.Reader, size
 			break
 		}
 	}
-	return bytesWritten, nil
+	if dw != nil {
+		return bytesWritten, dw.finish(globalCacheSync)
+	}
+	return bytesWritten, globalCacheSync.written(f)
 }
 
 // Caches the object to disk
@@ -409,6 +598,11 @@ func (c *diskCache) Put(ctx context.Context, bucket, object string, data io.Read
 		return errDiskFull
 	}
 	cachePath := getCacheSHADir(c.dir, bucket, object)
+	// The data of a cached object may be linked to by other objects,
+	// it must not be overwritten.
+	if err := c.removeEntry(cachePath, false); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(cachePath, 0777); err != nil {
 		return err
 	}
@@ -417,6 +611,11 @@ func (c *diskCache) Put(ctx context.Context, bucket, object string, data io.Read
 		metadata[k] = v
 	}
 	var reader = data
+	var sha256Hash hash.Hash
+	if c.dedup {
+		sha256Hash = sha256.New()
+		reader = io.TeeReader(data, sha256Hash)
+	}
 	var actualSize = uint64(size)
 	var err error
 	n, err := c.bitrotWriteToCache(cachePath, reader, actualSize)
@@ -431,7 +630,16 @@ func (c *diskCache) Put(ctx context.Context, bucket, object string, data io.Read
 		removeAll(cachePath)
 		return IncompleteBody{}
 	}
-	return c.saveMetadata(ctx, bucket, object, metadata, n)
+	var digest string
+	if sha256Hash != nil {
+		digest = hex.EncodeToString(sha256Hash.Sum(nil))
+		if err = c.dedupData(cachePath, digest); err != nil {
+			// cached without deduplication
+			logger.LogIf(ctx, err)
+			digest = ""
+		}
+	}
+	return c.saveMetadata(ctx, bucket, object, metadata, n, digest)
 }
 
 // checks streaming bitrot checksum of cached object before returning data
@@ -467,8 +675,8 @@ func (c *diskCache) bitrotReadFromCache(ctx context.Context, filePath string, of
 	if err != nil {
 		return err
 	}
-	bufp := c.pool.Get().(*[]byte)
-	defer c.pool.Put(bufp)
+	bufp := globalCacheBlocks.get()
+	defer globalCacheBlocks.put(bufp)
 
 	for block := startBlock; block <= endBlock; block++ {
 		switch {
@@ -538,6 +746,9 @@ func (c *diskCache) Get(ctx context.Context, bucket, object string, rs *HTTPRang
 	if objInfo, err = c.Stat(ctx, bucket, object); err != nil {
 		return nil, toObjectErr(err, bucket, object)
 	}
+	if c.index != nil {
+		logger.LogIf(ctx, c.index.touch(path.Base(cacheObjPath), UTCNow()))
+	}
 
 	var nsUnlocker = func() {}
 	// For a directory, we need to send an reader that returns no bytes.
@@ -557,7 +768,7 @@ func (c *diskCache) Get(ctx context.Context, bucket, object string, rs *HTTPRang
 	go func() {
 		err := c.bitrotReadFromCache(ctx, filePath, off, length, pw)
 		if err != nil {
-			removeAll(cacheObjPath)
+			c.removeEntry(cacheObjPath, true)
 		}
 		pw.CloseWithError(err)
 	}()
@@ -571,10 +782,47 @@ func (c *diskCache) Get(ctx context.Context, bucket, object string, rs *HTTPRang
 // Deletes the cached object
 func (c *diskCache) Delete(ctx context.Context, bucket, object string) (err error) {
 	cachePath := getCacheSHADir(c.dir, bucket, object)
-	return removeAll(cachePath)
+	return c.removeEntry(cachePath, false)
 
 }
 
+// Purge - removes all cached objects of bucket under prefix, an empty
+// bucket removes all objects. Objects cached without their names are
+// always removed since they cannot be matched.
+func (c *diskCache) Purge(ctx context.Context, bucket, prefix string) (purged int, err error) {
+	if c.index != nil && bucket != "" {
+		for _, name := range c.index.list(bucket, prefix) {
+			if err = c.removeEntry(pathJoin(c.dir, name), false); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		return purged, nil
+	}
+	objDirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, obj := range objDirs {
+		if obj.Name() == minioMetaBucket {
+			continue
+		}
+		cacheObjPath := pathJoin(c.dir, obj.Name())
+		if bucket != "" {
+			// partially filled entries fail to stat and are removed as well
+			oi, serr := c.statCache(cacheObjPath)
+			if serr == nil && oi.Bucket != "" && (oi.Bucket != bucket || !HasPrefix(oi.Name, prefix)) {
+				continue
+			}
+		}
+		if err = c.removeEntry(cacheObjPath, false); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
 // convenience function to check if object is cached on this diskCache
 func (c *diskCache) Exists(ctx context.Context, bucket, object string) bool {
 	if _, err := os.Stat(getCacheSHADir(c.dir, bucket, object)); err != nil {
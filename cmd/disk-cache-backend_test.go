@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Tests that the lfu policy evicts the least frequently read objects
+// first and keeps the meta bucket.
+func TestDiskCachePurgeLeastFrequent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A quota of zero never reports low disk usage, so every object
+	// is evicted.
+	dcache, err := newDiskCache(dir, 90, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dcache.freq = newTinyLFU()
+
+	accesses := map[string]int{"cold": 1, "warm": 3, "hot": 6}
+	for _, name := range []string{"cold", "warm", "hot", minioMetaBucket} {
+		if err = os.MkdirAll(pathJoin(dir, name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(pathJoin(dir, name, cacheDataFile), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < accesses[name]; i++ {
+			dcache.freq.Increment(name)
+		}
+	}
+
+	sorted := dcache.leastFrequent([]string{"hot", minioMetaBucket, "cold", "warm"})
+	if expected := []string{"cold", "warm", "hot"}; !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("Expected eviction order %v, got %v", expected, sorted)
+	}
+
+	if victim, ok := dcache.victim(); !ok || victim != "cold" {
+		t.Errorf("Expected victim cold, got %q", victim)
+	}
+
+	dcache.purgeLeastFrequent(context.Background())
+	for _, name := range []string{"cold", "warm", "hot"} {
+		if _, err = os.Stat(pathJoin(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be evicted", name)
+		}
+	}
+	if _, err = os.Stat(pathJoin(dir, minioMetaBucket)); err != nil {
+		t.Errorf("Expected meta bucket to be kept, %s", err)
+	}
+	if _, ok := dcache.victim(); ok {
+		t.Error("Expected no victim in an empty cache")
+	}
+}
+
+// Tests that objects of identical content share their data on the drive
+// until the last of them is removed.
+func TestDiskCacheDedup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dcache, err := newDiskCache(dir, 90, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dcache.dedup = true
+
+	ctx := context.Background()
+	layer := bytes.Repeat([]byte("layer"), 1000)
+	for _, object := range []string{"a", "b", "c"} {
+		data := layer
+		if object == "c" {
+			data = []byte("other")
+		}
+		if err = dcache.Put(ctx, "bucket", object, bytes.NewReader(data), int64(len(data)), ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stat := func(object string) os.FileInfo {
+		fi, serr := os.Stat(pathJoin(getCacheSHADir(dir, "bucket", object), cacheDataFile))
+		if serr != nil {
+			t.Fatal(serr)
+		}
+		return fi
+	}
+	if !os.SameFile(stat("a"), stat("b")) {
+		t.Error("Expected identical objects to share their data")
+	}
+	if os.SameFile(stat("a"), stat("c")) {
+		t.Error("Expected different objects not to share their data")
+	}
+	meta, err := loadCacheMeta(getCacheSHADir(dir, "bucket", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dedupPath := dcache.dedupPath(meta.Digest)
+
+	gr, err := dcache.Get(ctx, "bucket", "b", nil, http.Header{}, ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gr)
+	gr.Close()
+	if err != nil || !bytes.Equal(data, layer) {
+		t.Fatalf("Expected the content of b, got %v", err)
+	}
+
+	// Replacing an object leaves the shared data intact.
+	if err = dcache.Put(ctx, "bucket", "a", bytes.NewReader([]byte("new")), 3, ObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dcache.Purge(ctx, "bucket", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dedupPath); err != nil {
+		t.Fatalf("Expected the data of b to be kept, %s", err)
+	}
+	if err = dcache.Delete(ctx, "bucket", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dedupPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the data to be removed with the last object, got %v", err)
+	}
+}
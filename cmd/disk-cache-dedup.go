@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Directory below the meta bucket of a cache drive holding the data of
+// cached objects by content hash, shared by the objects of identical
+// content. Cached objects hard link their data file to it, the link
+// count of the data is the number of objects referencing it plus one.
+const cacheDedupDir = "dedup"
+
+// dedupPath - returns the path of the data of content hash digest.
+func (c *diskCache) dedupPath(digest string) string {
+	return pathJoin(c.dir, minioMetaBucket, cacheDedupDir, digest[:2], digest)
+}
+
+// dedupData - replaces the data of the object cached at cachePath by a
+// link to the data of the same content hash digest cached before, or
+// stores its data for objects of the same content cached next.
+func (c *diskCache) dedupData(cachePath, digest string) error {
+	dedupPath := c.dedupPath(digest)
+	dataPath := pathJoin(cachePath, cacheDataFile)
+
+	c.dedupMu.Lock()
+	defer c.dedupMu.Unlock()
+	if _, err := os.Stat(dedupPath); err == nil {
+		tmpPath := dataPath + ".dedup"
+		if err = os.Link(dedupPath, tmpPath); err != nil {
+			return err
+		}
+		if err = os.Rename(tmpPath, dataPath); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(path.Dir(dedupPath), 0777); err != nil {
+		return err
+	}
+	return os.Link(dataPath, dedupPath)
+}
+
+// releaseDedup - removes the data of content hash digest once no cached
+// object references it anymore.
+func (c *diskCache) releaseDedup(digest string) {
+	dedupPath := c.dedupPath(digest)
+
+	c.dedupMu.Lock()
+	defer c.dedupMu.Unlock()
+	fi, err := os.Stat(dedupPath)
+	if err != nil {
+		return
+	}
+	if links, ok := linkCount(fi); ok && links <= 1 {
+		os.Remove(dedupPath)
+	}
+}
+
+// removeEntry - removes the object cached at cacheObjPath, along with its
+// deduplicated data once no other cached object references it. corrupt
+// data is removed right away, so that no object is linked to it anymore.
+func (c *diskCache) removeEntry(cacheObjPath string, corrupt bool) error {
+	var digest string
+	if meta, err := loadCacheMeta(cacheObjPath); err == nil {
+		digest = meta.Digest
+	}
+	if digest != "" && corrupt {
+		c.dedupMu.Lock()
+		os.Remove(c.dedupPath(digest))
+		c.dedupMu.Unlock()
+	}
+	if err := removeAll(cacheObjPath); err != nil {
+		return err
+	}
+	if digest != "" {
+		c.releaseDedup(digest)
+	}
+	if c.index != nil {
+		logger.LogIf(c.indexContext(), c.index.remove(path.Base(cacheObjPath)))
+	}
+	return nil
+}
+
+// purgeDedup - removes the deduplicated data no cached object references,
+// left behind by entries removed while the server stopped.
+func (c *diskCache) purgeDedup(ctx context.Context) {
+	dedupDir := pathJoin(c.dir, minioMetaBucket, cacheDedupDir)
+	dirs, err := ioutil.ReadDir(dedupDir)
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(pathJoin(dedupDir, dir.Name()))
+		if err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+		for _, fi := range files {
+			c.releaseDedup(fi.Name())
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cmd
+
+import "os"
+
+// linkCount - link counts are not available, deduplication is not
+// supported.
+func linkCount(fi os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+// +build linux darwin freebsd netbsd openbsd
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkCount - returns the number of hard links to the file of fi.
+func linkCount(fi os.FileInfo) (uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Nlink), true
+}
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/djherbis/atime"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// File below the meta bucket of a cache drive holding the index of the
+// objects cached on the drive.
+const cacheIndexFile = "index.bin"
+
+// Records of the index file, appended as the cache changes.
+const (
+	cacheIndexPut    byte = 'p'
+	cacheIndexTouch  byte = 't'
+	cacheIndexDelete byte = 'd'
+)
+
+var errCacheIndexCorrupt = errors.New("cache index is corrupt")
+
+// cacheIndexEntry - an object cached on a drive by its cache key Name.
+type cacheIndexEntry struct {
+	Name   string
+	Bucket string
+	Object string
+	Size   int64
+	Atime  time.Time
+	ETag   string
+
+	// position in the lru heap
+	pos int
+}
+
+// cacheLRU - the entries of an index, least recently read first.
+type cacheLRU []*cacheIndexEntry
+
+func (h cacheLRU) Len() int           { return len(h) }
+func (h cacheLRU) Less(i, j int) bool { return h[i].Atime.Before(h[j].Atime) }
+func (h cacheLRU) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos, h[j].pos = i, j
+}
+
+func (h *cacheLRU) Push(x interface{}) {
+	e := x.(*cacheIndexEntry)
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *cacheLRU) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// cacheIndex - the size, last read and ETag of the objects cached on a
+// drive, so that lookups and evictions need no walk of the drive. The
+// index is kept in memory and persisted in an append-only file, which is
+// compacted once it holds twice as many records as entries.
+type cacheIndex struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	records int
+	entries map[string]*cacheIndexEntry
+	lru     cacheLRU
+}
+
+// openCacheIndex - loads the index of the cache drive dir, the index is
+// rebuilt from the cached objects if it is missing or corrupt.
+func (c *diskCache) openCacheIndex() error {
+	x := &cacheIndex{
+		path:    pathJoin(c.dir, minioMetaBucket, cacheIndexFile),
+		entries: make(map[string]*cacheIndexEntry),
+	}
+	if err := os.MkdirAll(pathJoin(c.dir, minioMetaBucket), 0777); err != nil {
+		return err
+	}
+	err := x.load()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.LogIf(c.indexContext(), err)
+		}
+		x.entries = make(map[string]*cacheIndexEntry)
+		x.lru = nil
+		c.rebuildCacheIndex(x)
+	}
+	if err != nil || x.records > 2*len(x.entries) {
+		if err = x.compact(); err != nil {
+			return err
+		}
+	} else if x.f, err = os.OpenFile(x.path, os.O_WRONLY|os.O_APPEND, 0666); err != nil {
+		return err
+	}
+	c.index = x
+	return nil
+}
+
+// indexContext - returns the context the index of c logs its errors to.
+func (c *diskCache) indexContext() context.Context {
+	return logger.WithTags(newBackgroundContext("CacheIndex", "", ""), "cachePath", c.dir)
+}
+
+// rebuildCacheIndex - adds the objects cached on the drive to x, entries
+// left behind partially filled are removed.
+func (c *diskCache) rebuildCacheIndex(x *cacheIndex) {
+	objDirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		logger.LogIf(c.indexContext(), err)
+		return
+	}
+	for _, obj := range objDirs {
+		if obj.Name() == minioMetaBucket {
+			continue
+		}
+		cacheObjPath := pathJoin(c.dir, obj.Name())
+		meta, err := loadCacheMeta(cacheObjPath)
+		if err != nil {
+			c.removeEntry(cacheObjPath, false)
+			continue
+		}
+		fi, err := os.Stat(pathJoin(cacheObjPath, cacheDataFile))
+		if err != nil {
+			c.removeEntry(cacheObjPath, false)
+			continue
+		}
+		x.set(cacheIndexEntry{
+			Name:   obj.Name(),
+			Bucket: meta.Bucket,
+			Object: meta.Object,
+			Size:   meta.Stat.Size,
+			Atime:  atime.Get(fi),
+			ETag:   extractETag(meta.Meta),
+		})
+	}
+}
+
+// load - reads the index file into x.
+func (x *cacheIndex) load() error {
+	f, err := os.Open(x.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	data, unmap, err := mapCacheIndex(f, fi.Size())
+	if err != nil {
+		return err
+	}
+	defer unmap()
+
+	for len(data) > 0 {
+		n, err := x.replay(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+		x.records++
+	}
+	return nil
+}
+
+// replay - applies the record at the start of data to x, returns the
+// length of the record.
+func (x *cacheIndex) replay(data []byte) (int, error) {
+	r := cacheIndexReader{data: data[1:]}
+	switch data[0] {
+	case cacheIndexPut:
+		e := cacheIndexEntry{Atime: time.Unix(0, r.varint()).UTC(), Size: r.varint()}
+		e.Name, e.Bucket, e.Object, e.ETag = r.str(), r.str(), r.str(), r.str()
+		if r.err == nil {
+			x.set(e)
+		}
+	case cacheIndexTouch:
+		at, name := time.Unix(0, r.varint()).UTC(), r.str()
+		if e, ok := x.entries[name]; ok && r.err == nil {
+			e.Atime = at
+			heap.Fix(&x.lru, e.pos)
+		}
+	case cacheIndexDelete:
+		if name := r.str(); r.err == nil {
+			x.unset(name)
+		}
+	default:
+		return 0, errCacheIndexCorrupt
+	}
+	return len(data) - len(r.data), r.err
+}
+
+// cacheIndexReader - decodes the fields of an index record.
+type cacheIndexReader struct {
+	data []byte
+	err  error
+}
+
+func (r *cacheIndexReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errCacheIndexCorrupt
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *cacheIndexReader) str() string {
+	l := r.varint()
+	if r.err != nil || l < 0 || l > int64(len(r.data)) {
+		r.err = errCacheIndexCorrupt
+		return ""
+	}
+	s := string(r.data[:l])
+	r.data = r.data[l:]
+	return s
+}
+
+// encodeCacheIndex - returns the record op of the index with fields.
+func encodeCacheIndex(op byte, ints []int64, strs ...string) []byte {
+	buf := make([]byte, 1, 1+binary.MaxVarintLen64*(len(ints)+len(strs)))
+	buf[0] = op
+	tmp := make([]byte, binary.MaxVarintLen64)
+	for _, v := range ints {
+		buf = append(buf, tmp[:binary.PutVarint(tmp, v)]...)
+	}
+	for _, s := range strs {
+		buf = append(buf, tmp[:binary.PutVarint(tmp, int64(len(s)))]...)
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+func (e *cacheIndexEntry) record() []byte {
+	return encodeCacheIndex(cacheIndexPut, []int64{e.Atime.UnixNano(), e.Size}, e.Name, e.Bucket, e.Object, e.ETag)
+}
+
+// set - adds or replaces the entry e in memory.
+func (x *cacheIndex) set(e cacheIndexEntry) {
+	if old, ok := x.entries[e.Name]; ok {
+		e.pos = old.pos
+		*old = e
+		heap.Fix(&x.lru, old.pos)
+		return
+	}
+	entry := &e
+	x.entries[e.Name] = entry
+	heap.Push(&x.lru, entry)
+}
+
+// unset - removes the entry name from memory.
+func (x *cacheIndex) unset(name string) {
+	if e, ok := x.entries[name]; ok {
+		heap.Remove(&x.lru, e.pos)
+		delete(x.entries, name)
+	}
+}
+
+// append - persists record, the file is compacted once it grows to twice
+// the records needed. Must be called with mu held.
+func (x *cacheIndex) append(record []byte) error {
+	if _, err := x.f.Write(record); err != nil {
+		return err
+	}
+	if err := globalJournalSync.written(x.f); err != nil {
+		return err
+	}
+	x.records++
+	if x.records > 2*len(x.entries)+1024 {
+		return x.compact()
+	}
+	return nil
+}
+
+// compact - rewrites the index file with one record per entry. Must be
+// called with mu held.
+func (x *cacheIndex) compact() error {
+	tmpPath := x.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	for _, e := range x.lru {
+		buf = append(buf, e.record()...)
+		if int64(len(buf)) >= cacheBlkSize {
+			if _, err = f.Write(buf); err != nil {
+				break
+			}
+			buf = buf[:0]
+		}
+	}
+	if err == nil {
+		_, err = f.Write(buf)
+	}
+	if err == nil && globalJournalSync.enabled() {
+		// The index must not be lost to the rename.
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, x.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if x.f != nil {
+		x.f.Close()
+	}
+	if x.f, err = os.OpenFile(x.path, os.O_WRONLY|os.O_APPEND, 0666); err != nil {
+		return err
+	}
+	x.records = len(x.entries)
+	return nil
+}
+
+// put - records the object cached as e.
+func (x *cacheIndex) put(e cacheIndexEntry) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.set(e)
+	return x.append(e.record())
+}
+
+// touch - records a read of the object cached as name at at.
+func (x *cacheIndex) touch(name string, at time.Time) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	e, ok := x.entries[name]
+	if !ok {
+		return nil
+	}
+	e.Atime = at
+	heap.Fix(&x.lru, e.pos)
+	return x.append(encodeCacheIndex(cacheIndexTouch, []int64{at.UnixNano()}, name))
+}
+
+// remove - records the removal of the object cached as name.
+func (x *cacheIndex) remove(name string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.entries[name]; !ok {
+		return nil
+	}
+	x.unset(name)
+	return x.append(encodeCacheIndex(cacheIndexDelete, nil, name))
+}
+
+// lookup - returns the entry of the object cached as name.
+func (x *cacheIndex) lookup(name string) (cacheIndexEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	e, ok := x.entries[name]
+	if !ok {
+		return cacheIndexEntry{}, false
+	}
+	return *e, true
+}
+
+// oldest - returns the least recently read entry.
+func (x *cacheIndex) oldest() (cacheIndexEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.lru) == 0 {
+		return cacheIndexEntry{}, false
+	}
+	return *x.lru[0], true
+}
+
+// names - returns the names of up to n entries in no particular order,
+// all entries if n is not positive.
+func (x *cacheIndex) names(n int) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if n <= 0 || n > len(x.entries) {
+		n = len(x.entries)
+	}
+	names := make([]string, 0, n)
+	for name := range x.entries {
+		if len(names) == n {
+			break
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// list - returns the names of the objects of bucket cached under prefix,
+// and of the objects cached without their names.
+func (x *cacheIndex) list(bucket, prefix string) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var names []string
+	for name, e := range x.entries {
+		if e.Bucket == "" || (e.Bucket == bucket && strings.HasPrefix(e.Object, prefix)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
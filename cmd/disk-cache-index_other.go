@@ -0,0 +1,18 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// mapCacheIndex - memory maps are not used, the index file f is read
+// into memory.
+func mapCacheIndex(f *os.File, size int64) ([]byte, func() error, error) {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
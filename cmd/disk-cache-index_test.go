@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Tests that the index follows the cached objects, survives a restart
+// and is rebuilt from the drive once lost.
+func TestDiskCacheIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dcache, err := newDiskCache(dir, 90, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dcache.openCacheIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, object := range []string{"logs/a", "logs/b", "data/c"} {
+		data := []byte(object)
+		if err = dcache.Put(ctx, "bucket", object, bytes.NewReader(data), int64(len(data)), ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	name := func(object string) string { return cacheKey("bucket", object) }
+
+	if e, ok := dcache.index.lookup(name("logs/b")); !ok || e.Size != 6 || e.Object != "logs/b" {
+		t.Errorf("Expected logs/b to be indexed, got %+v", e)
+	}
+	if victim, ok := dcache.victim(); !ok || victim != name("logs/a") {
+		t.Errorf("Expected victim logs/a, got %q", victim)
+	}
+	gr, err := dcache.Get(ctx, "bucket", "logs/a", nil, http.Header{}, ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr.Close()
+	if victim, _ := dcache.victim(); victim != name("logs/b") {
+		t.Errorf("Expected victim logs/b once logs/a was read, got %q", victim)
+	}
+
+	if err = dcache.Delete(ctx, "bucket", "data/c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dcache.index.lookup(name("data/c")); ok {
+		t.Error("Expected data/c to be removed from the index")
+	}
+
+	// The index is loaded from its file on restart.
+	reopen := func() {
+		dcache, err = newDiskCache(dir, 90, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = dcache.openCacheIndex(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reopen()
+	names := dcache.index.names(0)
+	sort.Strings(names)
+	expected := []string{name("logs/a"), name("logs/b")}
+	sort.Strings(expected)
+	if len(names) != 2 || names[0] != expected[0] || names[1] != expected[1] {
+		t.Errorf("Expected %v to be indexed, got %v", expected, names)
+	}
+	if victim, _ := dcache.victim(); victim != name("logs/b") {
+		t.Errorf("Expected victim logs/b after a restart, got %q", victim)
+	}
+
+	// A corrupt index is rebuilt from the drive.
+	indexPath := pathJoin(dir, minioMetaBucket, cacheIndexFile)
+	if err = ioutil.WriteFile(indexPath, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reopen()
+	if len(dcache.index.names(0)) != 2 {
+		t.Errorf("Expected the index to be rebuilt, got %v", dcache.index.names(0))
+	}
+
+	if purged, err := dcache.Purge(ctx, "bucket", "logs/"); err != nil || purged != 2 {
+		t.Errorf("Expected 2 objects purged, got %d, %v", purged, err)
+	}
+	if _, ok := dcache.victim(); ok {
+		t.Error("Expected no victim in an empty cache")
+	}
+}
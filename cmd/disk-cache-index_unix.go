@@ -0,0 +1,21 @@
+// +build linux darwin freebsd netbsd openbsd
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapCacheIndex - maps the size bytes of the index file f into memory,
+// the function returned unmaps them.
+func mapCacheIndex(f *os.File, size int64) ([]byte, func() error, error) {
+	if size == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
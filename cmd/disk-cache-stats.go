@@ -10,6 +10,10 @@ type CacheStats struct {
 	BytesServed atomic.Uint64
 	Hits        atomic.Uint64
 	Misses      atomic.Uint64
+	RAMHits     atomic.Uint64
+
+	PrefetchHits   atomic.Uint64
+	PrefetchMisses atomic.Uint64
 }
 
 // Increase total bytes served from cache
@@ -27,6 +31,21 @@ func (s *CacheStats) incMiss() {
 	s.Misses.Add(uint64(1))
 }
 
+// Increase in-memory tier hits by 1
+func (s *CacheStats) incRAMHit() {
+	s.RAMHits.Add(uint64(1))
+}
+
+// Increase ranges served from prefetched data by 1
+func (s *CacheStats) incPrefetchHit() {
+	s.PrefetchHits.Add(uint64(1))
+}
+
+// Increase sequential ranges missing prefetched data by 1
+func (s *CacheStats) incPrefetchMiss() {
+	s.PrefetchMisses.Add(uint64(1))
+}
+
 // Get total bytes served
 func (s *CacheStats) getBytesServed() uint64 {
 	return s.BytesServed.Load()
@@ -42,6 +61,21 @@ func (s *CacheStats) getMisses() uint64 {
 	return s.Misses.Load()
 }
 
+// Get total in-memory tier hits
+func (s *CacheStats) getRAMHits() uint64 {
+	return s.RAMHits.Load()
+}
+
+// Get total ranges served from prefetched data
+func (s *CacheStats) getPrefetchHits() uint64 {
+	return s.PrefetchHits.Load()
+}
+
+// Get total sequential ranges missing prefetched data
+func (s *CacheStats) getPrefetchMisses() uint64 {
+	return s.PrefetchMisses.Load()
+}
+
 // Prepare new CacheStats structure
 func newCacheStats() *CacheStats {
 	return &CacheStats{}
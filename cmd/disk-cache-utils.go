@@ -135,6 +135,12 @@ func cacheControlOpts(o ObjectInfo) (c cacheControl) {
 	return c
 }
 
+// cacheKey - returns the key of an object in the cache, equal to
+// the name of its directory on the cache drives.
+func cacheKey(bucket, object string) string {
+	return getSHA256Hash([]byte(path.Join(bucket, object)))
+}
+
 // backendDownError returns true if err is due to backend failure or faulty disk if in server mode
 func backendDownError(err error) bool {
 	_, backendDown := err.(BackendDown)
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -40,6 +41,9 @@ type CacheObjectLayer interface {
 	DeleteObject(ctx context.Context, bucket, object string) error
 	DeleteObjects(ctx context.Context, bucket string, objects []string) ([]error, error)
 	PutObject(ctx context.Context, bucket, object string, data *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error)
+	// Invalidation operations.
+	Invalidate(ctx context.Context, bucket, object string)
+	Purge(ctx context.Context, bucket, prefix string) (int, error)
 	// Storage operations.
 	StorageInfo(ctx context.Context) CacheStorageInfo
 	CacheStats() *CacheStats
@@ -60,6 +64,18 @@ type cacheObjects struct {
 	// nsMutex namespace lock
 	nsMutex *NSLockMap
 
+	// in-memory tier for small objects, nil if disabled
+	ram *memoryCache
+
+	// reads ahead of sequential range reads, nil if disabled
+	prefetch *prefetcher
+
+	// access frequency of objects, nil unless the lfu policy or
+	// tinylfu admission is configured
+	freq *tinyLFU
+	// if true only objects read more than once are cached on drives
+	admitFrequent bool
+
 	// Cache stats
 	cacheStats *CacheStats
 
@@ -118,15 +134,51 @@ func (c *cacheObjects) DeleteObject(ctx context.Context, bucket, object string)
 	if c.isCacheExclude(bucket, object) || c.skipCache() {
 		return
 	}
+	c.Invalidate(ctx, bucket, object)
+	return
+}
 
-	dcache, cerr := c.getCacheLoc(bucket, object)
-	if cerr != nil {
-		return
+// Invalidate - removes an object from all cache tiers, called once the
+// object was overwritten or deleted on the backend.
+func (c *cacheObjects) Invalidate(ctx context.Context, bucket, object string) {
+	if c.ram != nil {
+		c.ram.Delete(bucket, object)
 	}
-	if dcache.Exists(ctx, bucket, object) {
-		c.delete(ctx, dcache, bucket, object)
+	if c.prefetch != nil {
+		c.prefetch.Invalidate(bucket, object)
+	}
+	for _, dcache := range c.cache {
+		if dcache == nil || !dcache.IsOnline() {
+			continue
+		}
+		if dcache.Exists(ctx, bucket, object) {
+			logger.LogIf(ctx, c.delete(ctx, dcache, bucket, object))
+		}
 	}
-	return
+}
+
+// Purge - removes all cached objects of bucket under prefix from all
+// cache tiers, an empty bucket purges everything. Needed when objects
+// were modified on the remotes without going through radio.
+func (c *cacheObjects) Purge(ctx context.Context, bucket, prefix string) (int, error) {
+	var purged int
+	if c.ram != nil {
+		purged += c.ram.Purge(bucket, prefix)
+	}
+	if c.prefetch != nil {
+		c.prefetch.Purge(bucket, prefix)
+	}
+	for _, dcache := range c.cache {
+		if dcache == nil || !dcache.IsOnline() {
+			continue
+		}
+		n, err := dcache.Purge(ctx, bucket, prefix)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
 }
 
 // DeleteObjects batch deletes objects in slice, and clears any cached entries
@@ -156,6 +208,73 @@ func getMetadata(objInfo ObjectInfo) map[string]string {
 }
 
 func (c *cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error) {
+	if c.freq != nil && !c.isCacheExclude(bucket, object) {
+		c.freq.Increment(cacheKey(bucket, object))
+	}
+	if c.prefetch != nil && isPrefetchRange(rs) && !c.isCacheExclude(bucket, object) && !c.skipCache() {
+		if gr, ok := c.prefetch.Get(ctx, bucket, object, rs, h, opts); ok {
+			return gr, nil
+		}
+		defer func() {
+			if err == nil {
+				c.prefetch.Observe(ctx, bucket, object, rs, gr.ObjInfo)
+			}
+		}()
+	}
+	if c.ram == nil || c.isCacheExclude(bucket, object) || c.skipCache() {
+		return c.getObjectNInfo(ctx, bucket, object, rs, h, lockType, opts)
+	}
+
+	if gr, ok := c.getFromRAM(ctx, bucket, object, rs, h, opts); ok {
+		return gr, nil
+	}
+
+	// Taken before reading from the backend, so that an invalidation
+	// racing with the read drops the data read.
+	generation := c.ram.Generation()
+	gr, err = c.getObjectNInfo(ctx, bucket, object, rs, h, lockType, opts)
+	if err == nil && rs == nil && !cacheControlOpts(gr.ObjInfo).noStore {
+		c.ram.Fill(bucket, object, gr, generation)
+	}
+	return gr, err
+}
+
+// getFromRAM - serves an object from the in-memory tier, entries without
+// fresh cache control are revalidated against the backend ETag like
+// entries on the cache drives.
+func (c *cacheObjects) getFromRAM(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, opts ObjectOptions) (*GetObjectReader, bool) {
+	objInfo, data, ok := c.ram.Get(bucket, object)
+	if !ok {
+		return nil, false
+	}
+
+	cc := cacheControlOpts(objInfo)
+	if (cc.isEmpty() || cc.isStale(objInfo.ModTime)) && !cc.onlyIfCached {
+		bkInfo, err := c.GetObjectInfoFn(ctx, bucket, object, opts)
+		switch {
+		case backendDownError(err):
+			// serve the cached copy while the backend is down
+		case err != nil || bkInfo.ETag != objInfo.ETag:
+			c.ram.Delete(bucket, object)
+			return nil, false
+		}
+	}
+
+	fn, off, length, err := NewGetObjectReader(rs, objInfo, opts.CheckCopyPrecondFn)
+	if err != nil {
+		return nil, false
+	}
+	gr, err := fn(bytes.NewReader(data[off:off+length]), h, opts.CheckCopyPrecondFn)
+	if err != nil {
+		return nil, false
+	}
+	c.cacheStats.incHit()
+	c.cacheStats.incRAMHit()
+	c.cacheStats.incBytesServed(length)
+	return gr, true
+}
+
+func (c *cacheObjects) getObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error) {
 	if c.isCacheExclude(bucket, object) || c.skipCache() {
 		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, lockType, opts)
 	}
@@ -215,6 +334,9 @@ func (c *cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string
 
 	// Since we got here, we are serving the request from backend,
 	// and also adding the object to the cache.
+	if !isCacheWarm(ctx) && !c.admit(dcache, bucket, object) {
+		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, lockType, opts)
+	}
 	if !dcache.diskUsageLow() {
 		select {
 		case dcache.purgeChan <- struct{}{}:
@@ -260,6 +382,43 @@ func (c *cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string
 	return NewGetObjectReaderFromReader(teeReader, bkReader.ObjInfo, opts.CheckCopyPrecondFn, cleanupBackend, cleanupPipe)
 }
 
+type cacheWarmKey struct{}
+
+// withCacheWarm - returns ctx for reads warming the cache, the objects
+// read are cached whatever the admission policy.
+func withCacheWarm(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheWarmKey{}, true)
+}
+
+func isCacheWarm(ctx context.Context) bool {
+	warm, _ := ctx.Value(cacheWarmKey{}).(bool)
+	return warm
+}
+
+// admit - returns true if the object should be added to dcache, with
+// tinylfu admission objects read only once are skipped and, once the cache
+// needs to evict, objects are only admitted if they were read more often
+// than the object they would evict. Single-pass scans thus do not evict
+// frequently read objects.
+func (c *cacheObjects) admit(dcache *diskCache, bucket, object string) bool {
+	if !c.admitFrequent || c.freq == nil {
+		return true
+	}
+	key := cacheKey(bucket, object)
+	if c.freq.Estimate(key) <= 1 {
+		return false
+	}
+	if dcache.diskUsageLow() {
+		// Nothing is evicted.
+		return true
+	}
+	victim, ok := dcache.victim()
+	if !ok {
+		return true
+	}
+	return c.freq.Admit(key, victim)
+}
+
 // Returns ObjectInfo from cache if available.
 func (c *cacheObjects) GetObjectInfo(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
 	getObjectInfoFn := c.GetObjectInfoFn
@@ -429,13 +588,15 @@ func (c *cacheObjects) hashIndex(bucket, object string) int {
 
 // newCache initializes the cacheFSObjects for the "drives" specified in config.json
 // or the global env overrides.
-func newCache(config cache.Config) ([]*diskCache, bool, error) {
+func newCache(config cache.Config, freq *tinyLFU) ([]*diskCache, bool, error) {
 	var caches []*diskCache
 	ctx := logger.SetReqInfo(context.Background(), &logger.ReqInfo{})
 	formats, migrating, err := loadAndValidateCacheFormat(ctx, config.Drives)
 	if err != nil {
 		return nil, false, err
 	}
+	// drives evict least frequently read objects first with lfu
+	lfu := config.Policy == cache.PolicyLFU
 	for i, dir := range config.Drives {
 		// skip diskCache creation for cache drives missing a format.json
 		if formats[i] == nil {
@@ -455,6 +616,25 @@ func newCache(config cache.Config) ([]*diskCache, bool, error) {
 		if err != nil {
 			return nil, false, err
 		}
+		if lfu {
+			cache.freq = freq
+		}
+		if config.Dedup {
+			fi, err := os.Stat(dir)
+			if err != nil {
+				return nil, false, err
+			}
+			if _, ok := linkCount(fi); !ok {
+				return nil, false, errors.New("Hard link counts required for cache deduplication")
+			}
+			cache.dedup = true
+		}
+		// The index is opened once the drive is migrated.
+		if config.Index && !migrating {
+			if err = cache.openCacheIndex(); err != nil {
+				return nil, false, err
+			}
+		}
 		// Start the purging go-routine for entries that have expired if no migration in progress
 		if !migrating {
 			go cache.purge()
@@ -512,6 +692,11 @@ func (c *cacheObjects) migrateCacheFromV1toV2(ctx context.Context) {
 			logger.LogIf(ctx, err)
 			continue
 		}
+		if globalCacheConfig.Index {
+			if err = c.cache[index].openCacheIndex(); err != nil {
+				logger.LogIf(ctx, err)
+			}
+		}
 		go c.cache[index].purge()
 	}
 
@@ -528,7 +713,14 @@ func (c *cacheObjects) migrateCacheFromV1toV2(ctx context.Context) {
 
 // PutObject - caches the uploaded object for single Put operations
 func (c *cacheObjects) PutObject(ctx context.Context, bucket, object string, r *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error) {
-	putObjectFn := c.PutObjectFn
+	putObjectFn := func(ctx context.Context, bucket, object string, r *PutObjReader, opts ObjectOptions) (ObjectInfo, error) {
+		objInfo, err := c.PutObjectFn(ctx, bucket, object, r, opts)
+		if err == nil {
+			// drop cached copies of the previous version
+			c.Invalidate(ctx, bucket, object)
+		}
+		return objInfo, err
+	}
 	dcache, err := c.getCacheToLoc(ctx, bucket, object)
 	if err != nil {
 		// disk cache could not be located,execute backend call.
@@ -553,7 +745,7 @@ func (c *cacheObjects) PutObject(ctx context.Context, bucket, object string, r *
 
 	objInfo, err = putObjectFn(ctx, bucket, object, r, opts)
 
-	if err == nil {
+	if err == nil && c.admit(dcache, bucket, object) {
 		go func() {
 			// fill cache in the background
 			bReader, bErr := c.GetObjectNInfoFn(ctx, bucket, object, nil, http.Header{},
@@ -576,10 +768,21 @@ func (c *cacheObjects) PutObject(ctx context.Context, bucket, object string, r *
 
 // Returns cacheObjects for use by Server.
 func newServerCacheObjects(ctx context.Context, config cache.Config) (CacheObjectLayer, error) {
+	var freq *tinyLFU
+	admitFrequent := config.Admission == cache.AdmissionTinyLFU
+	if config.Policy == cache.PolicyLFU || admitFrequent {
+		freq = newTinyLFU()
+	}
+
 	// list of disk caches for cache "drives" specified in config.json or RADIO_CACHE_DRIVES env var.
-	cache, migrateSw, err := newCache(config)
-	if err != nil {
-		return nil, err
+	var cache []*diskCache
+	var migrateSw bool
+	if len(config.Drives) > 0 {
+		var err error
+		cache, migrateSw, err = newCache(config, freq)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	c := &cacheObjects{
@@ -589,6 +792,9 @@ func newServerCacheObjects(ctx context.Context, config cache.Config) (CacheObjec
 		migMutex:   sync.Mutex{},
 		nsMutex:    newNSLock(false),
 		cacheStats: newCacheStats(),
+		freq:       freq,
+
+		admitFrequent: admitFrequent,
 		GetObjectInfoFn: func(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
 			return newObjectLayerFn().GetObjectInfo(ctx, bucket, object, opts)
 		},
@@ -612,6 +818,12 @@ func newServerCacheObjects(ctx context.Context, config cache.Config) (CacheObjec
 	c.NewNSLockFn = func(ctx context.Context, bucket, object string) RWLocker {
 		return c.nsMutex.NewNSLock(ctx, nil, bucket, object)
 	}
+	if config.RAMSize > 0 {
+		c.ram = newMemoryCache(config, freq)
+	}
+	if config.PrefetchWindow > 0 {
+		c.prefetch = newPrefetcher(config.PrefetchWindow, c.cacheStats, c.GetObjectNInfoFn)
+	}
 
 	if migrateSw {
 		go c.migrateCacheFromV1toV2(ctx)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	xhttp "github.com/minio/radio/cmd/http"
 	"github.com/minio/radio/cmd/logger"
 	"github.com/rs/cors"
+	"go.uber.org/atomic"
 )
 
 // HandlerFunc - useful to chain different middleware http.Handler
@@ -28,31 +30,41 @@ func registerHandlers(h http.Handler, handlerFns ...HandlerFunc) http.Handler {
 // which is more than enough to accommodate any form data fields and headers.
 const requestFormDataSize = 64 * humanize.MiByte
 
-// For any HTTP request, request body should be not more than 16GiB + requestFormDataSize
-// where, 16GiB is the maximum allowed object size for object upload.
-const requestMaxBodySize = globalMaxObjectSize + requestFormDataSize
-
 type requestSizeLimitHandler struct {
-	handler     http.Handler
-	maxBodySize int64
+	handler http.Handler
 }
 
 func setRequestSizeLimitHandler(h http.Handler) http.Handler {
-	return requestSizeLimitHandler{handler: h, maxBodySize: requestMaxBodySize}
+	return requestSizeLimitHandler{handler: h}
 }
 
+// ServeHTTP - rejects requests advertising a body larger than the configured
+// max object size + requestFormDataSize before any data is streamed, and
+// limits the body of requests without a known length.
 func (h requestSizeLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	maxBodySize := globalAPIConfig.MaxObjectSize + requestFormDataSize
+	if r.ContentLength > maxBodySize {
+		discardRequestBody(w, r)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL)
+		return
+	}
 	// Restricting read data to a given maximum length
-	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 	h.handler.ServeHTTP(w, r)
 }
 
-const (
-	// Maximum size for http headers - See: https://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
-	maxHeaderSize = 8 * 1024
-	// Maximum size for user-defined metadata - See: https://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
-	maxUserDataSize = 2 * 1024
-)
+// Maximum number of bytes of an unread request body which net/http drains
+// after the handler returns so the connection can be reused.
+const maxDiscardBodySize = 256 * humanize.KiByte
+
+// discardRequestBody - must be called before writing an error response for
+// a request whose body will not be read. Bodies net/http would not drain
+// on its own close the connection instead of reading them.
+func discardRequestBody(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength < 0 || r.ContentLength > maxDiscardBodySize {
+		w.Header().Set(xhttp.Connection, "close")
+	}
+}
 
 type requestHeaderSizeLimitHandler struct {
 	http.Handler
@@ -62,23 +74,24 @@ func setRequestHeaderSizeLimitHandler(h http.Handler) http.Handler {
 	return requestHeaderSizeLimitHandler{h}
 }
 
-// ServeHTTP restricts the size of the http header to 8 KB and the size
-// of the user-defined metadata to 2 KB.
+// ServeHTTP restricts the size of the http header and the size of the
+// user-defined metadata to the configured limits, 8 KB and 2 KB by default.
 func (h requestHeaderSizeLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if isHTTPHeaderSizeTooLarge(r.Header) {
-		writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrMetadataTooLarge), r.URL)
+		discardRequestBody(w, r)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrMetadataTooLarge), r.URL)
 		return
 	}
 	h.Handler.ServeHTTP(w, r)
 }
 
-// isHTTPHeaderSizeTooLarge returns true if the provided
-// header is larger than 8 KB or the user-defined metadata
-// is larger than 2 KB.
+// isHTTPHeaderSizeTooLarge returns true if the provided header is larger
+// than the max header size or the user-defined metadata is larger than
+// the max metadata size.
 func isHTTPHeaderSizeTooLarge(header http.Header) bool {
-	var size, usersize int
+	var size, usersize int64
 	for key := range header {
-		length := len(key) + len(header.Get(key))
+		length := int64(len(key) + len(header.Get(key)))
 		size += length
 		for _, prefix := range userMetadataKeyPrefixes {
 			if HasPrefix(key, prefix) {
@@ -86,13 +99,62 @@ func isHTTPHeaderSizeTooLarge(header http.Header) bool {
 				break
 			}
 		}
-		if usersize > maxUserDataSize || size > maxHeaderSize {
+		if usersize > globalAPIConfig.MaxMetadataSize || size > globalAPIConfig.MaxHeaderSize {
 			return true
 		}
 	}
 	return false
 }
 
+type errorFormatContextKey struct{}
+
+type errorResponseFormatHandler struct {
+	http.Handler
+}
+
+func setErrorResponseFormatHandler(h http.Handler) http.Handler {
+	return errorResponseFormatHandler{h}
+}
+
+// ServeHTTP - marks the request context for JSON error responses.
+func (h errorResponseFormatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Handler.ServeHTTP(w, withErrorFormat(r))
+}
+
+// withErrorFormat - returns r with its context marked for JSON error
+// responses when enabled globally or when the client accepts
+// 'application/json'.
+func withErrorFormat(r *http.Request) *http.Request {
+	if globalAPIConfig.JSONErrors || acceptsJSON(r.Header) {
+		return r.WithContext(context.WithValue(r.Context(), errorFormatContextKey{}, string(mimeJSON)))
+	}
+	return r
+}
+
+// acceptsJSON - returns true if the client explicitly accepts JSON.
+func acceptsJSON(header http.Header) bool {
+	for _, accept := range header[xhttp.Accept] {
+		for _, mType := range strings.Split(accept, ",") {
+			if i := strings.Index(mType, ";"); i >= 0 {
+				mType = mType[:i]
+			}
+			if strings.TrimSpace(mType) == string(mimeJSON) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wantsJSONErrors - returns true if errors should be encoded as JSON.
+func wantsJSONErrors(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	mType, _ := ctx.Value(errorFormatContextKey{}).(string)
+	return mType == string(mimeJSON)
+}
+
 // ReservedMetadataPrefix is the prefix of a metadata key which
 // is reserved and for internal use only.
 const ReservedMetadataPrefix = "X-Minio-Internal-"
@@ -109,7 +171,7 @@ func filterReservedMetadata(h http.Handler) http.Handler {
 // would be treated as metadata.
 func (h reservedMetadataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if containsReservedMetadata(r.Header) {
-		writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrUnsupportedMetadata), r.URL)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrUnsupportedMetadata), r.URL)
 		return
 	}
 	h.Handler.ServeHTTP(w, r)
@@ -190,14 +252,14 @@ func (h timeValidityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			// All our internal APIs are sensitive towards Date
 			// header, for all requests where Date header is not
 			// present we will reject such clients.
-			writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(errCode), r.URL)
+			writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(errCode), r.URL)
 			return
 		}
 		// Verify if the request date header is shifted by less than globalMaxSkewTime parameter in the past
 		// or in the future, reject request otherwise.
 		curTime := UTCNow()
 		if curTime.Sub(amzDate) > globalMaxSkewTime || amzDate.Sub(curTime) > globalMaxSkewTime {
-			writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrRequestTimeTooSkewed), r.URL)
+			writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrRequestTimeTooSkewed), r.URL)
 			return
 		}
 	}
@@ -216,6 +278,13 @@ func setCorsHandler(h http.Handler) http.Handler {
 		xhttp.ContentEncoding,
 		xhttp.ContentLength,
 		xhttp.ContentType,
+		// Headers set by the response-* overrides of GET and HEAD
+		// requests, browsers only treat the * below as a wildcard
+		// for requests without credentials.
+		xhttp.ContentDisposition,
+		xhttp.ContentLanguage,
+		xhttp.CacheControl,
+		xhttp.Expires,
 		"X-Amz*",
 		"x-amz*",
 		"*",
@@ -305,20 +374,20 @@ func hasMultipleAuth(r *http.Request) bool {
 func (h requestValidityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Check for bad components in URL path.
 	if hasBadPathComponent(r.URL.Path) {
-		writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrInvalidResourceName), r.URL)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrInvalidResourceName), r.URL)
 		return
 	}
 	// Check for bad components in URL query values.
 	for _, vv := range r.URL.Query() {
 		for _, v := range vv {
 			if hasBadPathComponent(v) {
-				writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrInvalidResourceName), r.URL)
+				writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrInvalidResourceName), r.URL)
 				return
 			}
 		}
 	}
 	if hasMultipleAuth(r) {
-		writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrInvalidRequest), r.URL)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrInvalidRequest), r.URL)
 		return
 	}
 	h.handler.ServeHTTP(w, r)
@@ -367,7 +436,8 @@ type criticalErrorHandler struct{ handler http.Handler }
 func (h criticalErrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		if err := recover(); err == logger.ErrCritical { // handle
-			writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrInternalError), r.URL)
+			// Runs before the error format of the request was set.
+			writeErrorResponse(withErrorFormat(r).Context(), w, errorCodes.ToAPIErr(ErrInternalError), r.URL)
 		} else if err != nil {
 			panic(err) // forward other panic calls
 		}
@@ -382,13 +452,100 @@ type sseTLSHandler struct{ handler http.Handler }
 
 func (h sseTLSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Deny SSE-C requests if not made over TLS
-	if !globalIsSSL && (SSEC.IsRequested(r.Header) || SSECopy.IsRequested(r.Header)) {
+	if r.TLS == nil && (SSEC.IsRequested(r.Header) || SSECopy.IsRequested(r.Header)) {
 		if r.Method == http.MethodHead {
 			writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrInsecureSSECustomerRequest))
 		} else {
-			writeErrorResponse(context.Background(), w, errorCodes.ToAPIErr(ErrInsecureSSECustomerRequest), r.URL)
+			writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrInsecureSSECustomerRequest), r.URL)
 		}
 		return
 	}
 	h.handler.ServeHTTP(w, r)
 }
+
+func setReadOnlyHandler(h http.Handler) http.Handler { return readOnlyHandler{h} }
+
+// readOnlyHandler rejects S3 and console writes with 503 while the server
+// is in read-only mode, admin requests pass so that it can be turned off.
+type readOnlyHandler struct{ handler http.Handler }
+
+func (h readOnlyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if globalReadOnly.Load() && isWriteRequest(r) {
+		discardRequestBody(w, r)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrServerReadOnly), r.URL)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// isWriteRequest - returns true for requests modifying objects or buckets.
+func isWriteRequest(r *http.Request) bool {
+	if HasPrefix(r.URL.Path, minioReservedBucketPath) && !HasPrefix(r.URL.Path, consolePathPrefix) {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	case http.MethodPost:
+		// SelectObjectContent only reads.
+		_, ok := r.URL.Query()["select"]
+		return !ok
+	}
+	return true
+}
+
+type expectContinueContextKey struct{}
+
+// expectContinueBody - the body of a request expecting 100 Continue,
+// records whether the handler read it.
+type expectContinueBody struct {
+	io.ReadCloser
+	read atomic.Bool
+}
+
+func (b *expectContinueBody) Read(p []byte) (int, error) {
+	b.read.Store(true)
+	return b.ReadCloser.Read(p)
+}
+
+type expectContinueHandler struct {
+	http.Handler
+}
+
+func setExpectContinueHandler(h http.Handler) http.Handler {
+	return expectContinueHandler{h}
+}
+
+// expectsContinue - returns true if the client of r waits for 100
+// Continue before sending the body. net/http wraps the body of these
+// requests to send the 100 Continue on its first read.
+func expectsContinue(r *http.Request) bool {
+	if r.Body == nil || r.ContentLength == 0 {
+		return false
+	}
+	return strings.EqualFold(r.Header.Get(xhttp.Expect), "100-continue")
+}
+
+// ServeHTTP - passes requests expecting 100 Continue on as a copy, so
+// that the handlers replacing the body leave the body net/http wrapped
+// in place. net/http then only sends the 100 Continue once the body is
+// read, after the request is authenticated and authorized, and closes
+// the connection if it never was, instead of waiting for a body the
+// client withholds.
+func (h expectContinueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !expectsContinue(r) {
+		h.Handler.ServeHTTP(w, r)
+		return
+	}
+	body := &expectContinueBody{ReadCloser: r.Body}
+	r = r.WithContext(context.WithValue(r.Context(), expectContinueContextKey{}, body))
+	r.Body = body
+	h.Handler.ServeHTTP(w, r)
+}
+
+// bodyWithheld - returns true if r expected 100 Continue and its body was
+// never read, the client did not send it.
+func bodyWithheld(r *http.Request) bool {
+	body, ok := r.Context().Value(expectContinueContextKey{}).(*expectContinueBody)
+	return ok && !body.read.Load()
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/radio/cmd/config/api"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// zeroReader - endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// Tests that the request size limit handler rejects bodies larger than
+// the max object size, with and without a known content length.
+func TestRequestSizeLimitHandler(t *testing.T) {
+	defer func(cfg api.Config) { globalAPIConfig = cfg }(globalAPIConfig)
+	globalAPIConfig = api.DefaultConfig()
+	globalAPIConfig.MaxObjectSize = 1
+	maxBodySize := globalAPIConfig.MaxObjectSize + requestFormDataSize
+
+	var (
+		read    int64
+		readErr error
+		called  bool
+	)
+	handler := setRequestSizeLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		read, readErr = io.Copy(ioutil.Discard, r.Body)
+	}))
+
+	testCases := []struct {
+		contentLength int64
+		bodySize      int64
+		rejected      bool
+		readFails     bool
+	}{
+		// Body within the limit.
+		{1024, 1024, false, false},
+		// Advertised body larger than the limit.
+		{maxBodySize + 1, maxBodySize + 1, true, false},
+		// Unknown length within the limit.
+		{-1, 1024, false, false},
+		// Unknown length exceeding the limit.
+		{-1, maxBodySize + 1, false, true},
+	}
+
+	for i, testCase := range testCases {
+		read, readErr, called = 0, nil, false
+		req := httptest.NewRequest(http.MethodPut, "/bucket/object",
+			io.LimitReader(zeroReader{}, testCase.bodySize))
+		req.ContentLength = testCase.contentLength
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if testCase.rejected {
+			if called {
+				t.Errorf("Test %d: Expected the request to be rejected", i+1)
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Test %d: Expected status %d, got %d", i+1, http.StatusBadRequest, rec.Code)
+			}
+			if rec.Header().Get(xhttp.Connection) != "close" {
+				t.Errorf("Test %d: Expected the connection to be closed", i+1)
+			}
+			continue
+		}
+		if !called {
+			t.Fatalf("Test %d: Expected the request to be passed on", i+1)
+		}
+		if testCase.readFails {
+			if readErr == nil || read != maxBodySize {
+				t.Errorf("Test %d: Expected the body to be cut at %d bytes, read %d (%v)", i+1, maxBodySize, read, readErr)
+			}
+			continue
+		}
+		if readErr != nil || read != testCase.bodySize {
+			t.Errorf("Test %d: Expected %d bytes, read %d (%v)", i+1, testCase.bodySize, read, readErr)
+		}
+	}
+}
+
+// Tests that writes are rejected while the server is read-only, reads
+// and admin requests pass.
+func TestReadOnlyHandler(t *testing.T) {
+	defer globalReadOnly.Store(false)
+
+	handler := setReadOnlyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testCases := []struct {
+		method   string
+		target   string
+		readOnly bool
+		rejected bool
+	}{
+		{http.MethodPut, "/bucket/object", false, false},
+		{http.MethodPut, "/bucket/object", true, true},
+		{http.MethodDelete, "/bucket/object", true, true},
+		{http.MethodPost, "/bucket?delete", true, true},
+		{http.MethodPost, "/bucket/object?select&select-type=2", true, false},
+		{http.MethodGet, "/bucket/object", true, false},
+		{http.MethodHead, "/bucket/object", true, false},
+		{http.MethodPut, consolePathPrefix + "/api/upload", true, true},
+		{http.MethodPut, adminAPIPathPrefix + adminAPIVersionPrefix + "/maintenance/read-only", true, false},
+	}
+	for i, testCase := range testCases {
+		globalReadOnly.Store(testCase.readOnly)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(testCase.method, testCase.target, nil))
+		if rejected := rec.Code == http.StatusServiceUnavailable; rejected != testCase.rejected {
+			t.Errorf("Test %d: expected rejected %t, got status %d", i+1, testCase.rejected, rec.Code)
+		}
+	}
+}
+
+// Tests that critical errors are sent in the error format of the client.
+func TestCriticalErrorHandlerJSON(t *testing.T) {
+	handler := criticalErrorHandler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(logger.ErrCritical)
+	})}
+	for accept, mType := range map[string]string{"": string(mimeXML), "application/json": string(mimeJSON)} {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/object", nil)
+		r.Header.Set(xhttp.Accept, accept)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != http.StatusInternalServerError || rec.Header().Get(xhttp.ContentType) != mType {
+			t.Errorf("Accept %q: expected %s, got %d %s", accept, mType, rec.Code, rec.Header().Get(xhttp.ContentType))
+		}
+	}
+}
+
+// Tests that browsers may read the headers set by response-* overrides.
+func TestCorsExposedHeaders(t *testing.T) {
+	handler := setCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setHeadGetRespHeaders(w, r.URL.Query())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/bucket/object?response-content-disposition=attachment", nil)
+	r.Header.Set("Origin", "https://app.domain.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	exposed := rec.Header().Get("Access-Control-Expose-Headers")
+	for _, header := range []string{xhttp.ContentDisposition, xhttp.CacheControl} {
+		if !strings.Contains(exposed, header) {
+			t.Errorf("expected %s to be exposed, got %q", header, exposed)
+		}
+	}
+}
+
+// Tests that clients expecting 100 Continue only get it once the body is
+// read, and get the rejection right away otherwise instead of the server
+// waiting for the body.
+func TestExpectContinueHandler(t *testing.T) {
+	defer func(cfg api.Config) { globalAPIConfig = cfg }(globalAPIConfig)
+	globalAPIConfig = api.DefaultConfig()
+
+	withheld := make(chan bool, 1)
+	handler := setExpectContinueHandler(setRequestSizeLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/allowed" {
+			io.Copy(ioutil.Discard, r.Body)
+		} else {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		withheld <- bodyWithheld(r)
+	})))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for _, testCase := range []struct {
+		object   string
+		status   string
+		withheld bool
+	}{
+		{"denied", "403", true},
+		{"allowed", "100", false},
+	} {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		fmt.Fprintf(conn, "PUT /bucket/%s HTTP/1.1\r\nHost: radio\r\nContent-Length: 1048576\r\nExpect: 100-continue\r\n\r\n", testCase.object)
+		status, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("%s: expected a response before the body was sent, %v", testCase.object, err)
+		}
+		if !strings.HasPrefix(status, "HTTP/1.1 "+testCase.status) {
+			t.Errorf("%s: expected status %s, got %q", testCase.object, testCase.status, status)
+		}
+		if testCase.object == "allowed" {
+			conn.Write(make([]byte, 1048576))
+		}
+		if w := <-withheld; w != testCase.withheld {
+			t.Errorf("%s: expected body withheld %v, got %v", testCase.object, testCase.withheld, w)
+		}
+		conn.Close()
+	}
+}
+
+// Tests that requests expecting 100 Continue are found by their header.
+func TestExpectsContinue(t *testing.T) {
+	testCases := []struct {
+		expect string
+		body   string
+		want   bool
+	}{
+		{"100-continue", "data", true},
+		{"100-Continue", "data", true},
+		{"", "data", false},
+		{"100-continue", "", false},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(http.MethodPut, "/bucket/object", strings.NewReader(testCase.body))
+		if testCase.expect != "" {
+			r.Header.Set("Expect", testCase.expect)
+		}
+		if got := expectsContinue(r); got != testCase.want {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"crypto/x509"
 	"os"
+	"sync"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -10,6 +11,7 @@ import (
 	"github.com/minio/minio/pkg/auth"
 	"github.com/minio/minio/pkg/certs"
 	"github.com/minio/minio/pkg/pubsub"
+	"github.com/minio/radio/cmd/config/api"
 	"github.com/minio/radio/cmd/config/cache"
 )
 
@@ -96,7 +98,20 @@ var (
 	// Global HTTP request statisitics
 	globalHTTPStats = newHTTPStats()
 
-	globalLocalCreds = map[string]auth.Credentials{}
+	// Front-end credentials by access key, replaced as a whole by
+	// setLocalCredentials when rotated secrets are picked up.
+	globalLocalCreds   = map[string]auth.Credentials{}
+	globalLocalCredsMu sync.RWMutex
+
+	// Credential allowed to access the console and the admin API,
+	// both are disabled when not configured.
+	globalAdminCred auth.Credentials
+
+	// Path to config.yml this server was started with.
+	globalRadioConfigFile string
+
+	// Time when this server was started.
+	globalBootTime = UTCNow()
 
 	globalPublicCerts []*x509.Certificate
 
@@ -108,6 +123,13 @@ var (
 	// Disk cache drives
 	globalCacheConfig cache.Config
 
+	// API request limits
+	globalAPIConfig = api.DefaultConfig()
+
+	// Part buffers of parallel reads, shared by all requests to
+	// bound their memory to api.parallel_get_max_memory.
+	globalParallelGetSlots chan struct{}
+
 	// Deployment ID - unique per deployment
 	globalDeploymentID string
 
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	xhttp "github.com/minio/radio/cmd/http"
 	"github.com/minio/radio/cmd/logger"
@@ -76,8 +77,33 @@ var userMetadataKeyPrefixes = []string{
 	"X-Minio-Meta-",
 }
 
-// extractMetadata extracts metadata from HTTP header and HTTP queryString.
+// reservedMetadataPrefix - user metadata radio records on the remotes
+// itself, such as the radio tag and verified checksums. Clients must not
+// be able to set it.
+const reservedMetadataPrefix = "X-Amz-Meta-Radio-"
+
+// isReservedMetadata - returns true if key is metadata reserved by radio.
+func isReservedMetadata(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), strings.ToLower(reservedMetadataPrefix))
+}
+
+// extractMetadata extracts metadata from HTTP header and HTTP queryString,
+// the Content-Type is detected from the key if the request carries none.
 func extractMetadata(ctx context.Context, r *http.Request) (metadata map[string]string, err error) {
+	metadata, err = extractRequestMetadata(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	bucket, object := request2BucketObjectName(r)
+	if _, err = globalContentTypes.apply(bucket, object, metadata, nil); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// extractRequestMetadata - extracts metadata like extractMetadata, without
+// setting a Content-Type the request carries none.
+func extractRequestMetadata(ctx context.Context, r *http.Request) (metadata map[string]string, err error) {
 	query := r.URL.Query()
 	header := r.Header
 	metadata = make(map[string]string)
@@ -93,10 +119,6 @@ func extractMetadata(ctx context.Context, r *http.Request) (metadata map[string]
 		return nil, err
 	}
 
-	// Set content-type to default value if it is not set.
-	if _, ok := metadata["content-type"]; !ok {
-		metadata["content-type"] = "application/octet-stream"
-	}
 	// Success.
 	return metadata, nil
 }
@@ -116,6 +138,10 @@ func extractMetadataFromMap(ctx context.Context, v map[string][]string, m map[st
 		}
 	}
 	for key := range v {
+		if isReservedMetadata(key) {
+			// Never taken from the client.
+			continue
+		}
 		for _, prefix := range userMetadataKeyPrefixes {
 			if !strings.HasPrefix(strings.ToLower(key), strings.ToLower(prefix)) {
 				continue
@@ -265,7 +291,19 @@ func collectAPIStats(api string, f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		isS3Request := !strings.HasPrefix(r.URL.Path, minioReservedBucketPath)
-		apiStatsWriter := &recordAPIStats{w, UTCNow(), false, 0, isS3Request}
+		// The ReqInfo of the request, filled in by the handler.
+		r = r.WithContext(newContext(r, w, api))
+		apiStatsWriter := &recordAPIStats{writer: w, TTFB: UTCNow(), isS3Request: isS3Request}
+		if isS3Request && r.Body != nil {
+			r.Body = &recordAPIBody{ReadCloser: r.Body, stats: apiStatsWriter}
+		}
+
+		var rt *requestTrace
+		slowThreshold := globalAPIConfig.SlowRequestThreshold
+		exportStats := isS3Request && globalRequestStats.sample()
+		if isS3Request && (slowThreshold > 0 || exportStats || globalUsage != nil) {
+			r, rt = withRequestTrace(r)
+		}
 
 		// Time start before the call is about to start.
 		tBefore := UTCNow()
@@ -274,7 +312,35 @@ func collectAPIStats(api string, f http.HandlerFunc) http.HandlerFunc {
 			globalHTTPStats.currentS3Requests.Inc(api)
 		}
 		// Execute the request
-		f.ServeHTTP(apiStatsWriter, r)
+		s3Err := preAuthHooks(r, api)
+		if isS3Request && s3Err == ErrNone {
+			s3Err = globalTenants.admit(r)
+		}
+		if isS3Request && s3Err != ErrNone {
+			writeErrorResponse(r.Context(), apiStatsWriter, errorCodes.ToAPIErr(s3Err), r.URL)
+		} else {
+			f.ServeHTTP(apiStatsWriter, r)
+		}
+		if isS3Request {
+			postResponseHooks(r, HookResponse{API: api, StatusCode: apiStatsWriter.respStatusCode,
+				Duration: UTCNow().Sub(tBefore)})
+		}
+
+		if exportStats {
+			exportRequestStats(api, r, apiStatsWriter, rt, tBefore)
+		}
+		if rt != nil && globalUsage != nil {
+			recordUsage(api, apiStatsWriter, rt)
+		}
+		if rt != nil && slowThreshold > 0 {
+			if duration := UTCNow().Sub(tBefore); duration >= slowThreshold {
+				var ttfb time.Duration
+				if apiStatsWriter.firstByteRead {
+					ttfb = apiStatsWriter.TTFB.Sub(tBefore)
+				}
+				logSlowRequest(api, r, rt, duration, ttfb)
+			}
+		}
 
 		if isS3Request {
 			globalHTTPStats.currentS3Requests.Dec(api)
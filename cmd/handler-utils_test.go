@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Tests that metadata reserved by radio is never taken from the client.
+func TestExtractMetadataReserved(t *testing.T) {
+	testCases := []struct {
+		header   http.Header
+		query    url.Values
+		expected map[string]string
+	}{
+		{
+			header: http.Header{
+				"X-Amz-Meta-Color":                []string{"blue"},
+				"X-Amz-Meta-Radio-Tag":            []string{"spoofed"},
+				"X-Amz-Meta-Radio-Checksum-Crc32": []string{"AAAAAA=="},
+			},
+			expected: map[string]string{
+				"X-Amz-Meta-Color": "blue",
+				"content-type":     "application/octet-stream",
+			},
+		},
+		{
+			query: url.Values{
+				"x-amz-meta-radio-checksum-sha256": []string{"spoofed"},
+				"x-amz-meta-radiostation":          []string{"fm"},
+			},
+			expected: map[string]string{
+				"x-amz-meta-radiostation": "fm",
+				"content-type":            "application/octet-stream",
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		r := &http.Request{Header: testCase.header, URL: &url.URL{RawQuery: testCase.query.Encode()}}
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		metadata, err := extractMetadata(context.Background(), r)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !reflect.DeepEqual(metadata, testCase.expected) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, metadata)
+		}
+	}
+}
+
+// Tests that the handler fills in the ReqInfo attached to the request, so
+// that the hooks and the handler log the same request.
+func TestNewContextReqInfo(t *testing.T) {
+	r := httptest.NewRequest(http.MethodHead, "http://localhost:9000/bucket/object", nil)
+	w := httptest.NewRecorder()
+	r = r.WithContext(newContext(r, w, "headobject"))
+	reqInfo, ok := logger.LookupReqInfo(r.Context())
+	if !ok {
+		t.Fatal("expected the request info to be attached")
+	}
+
+	if s3Err := postAuthHooks(r, HookAuth{AccessKey: "radio"}); s3Err != ErrNone {
+		t.Fatalf("expected the request to be allowed, got %v", s3Err)
+	}
+	ctx := newContext(r, w, "HeadObject")
+	if logger.GetReqInfo(ctx) != reqInfo {
+		t.Fatal("expected the attached request info to be reused")
+	}
+	if reqInfo.API != "HeadObject" || reqInfo.AccessKey != "radio" {
+		t.Errorf("expected the API and requester to be recorded, got %+v", reqInfo)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import "io"
+
+// holdbackReader - withholds the last byte read from r until r returned
+// io.EOF. Uploads are verified against Content-MD5 and the signed
+// payload hash when r reaches EOF, since remotes cannot complete an
+// upload without its last byte a mismatch aborts the upload on every
+// remote before any of them commits the object.
+type holdbackReader struct {
+	r    io.Reader
+	last [1]byte
+	held bool
+	err  error
+}
+
+func newHoldbackReader(r io.Reader) *holdbackReader {
+	return &holdbackReader{r: r}
+}
+
+func (h *holdbackReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if h.err != nil {
+		if h.err == io.EOF && h.held {
+			p[0], h.held = h.last[0], false
+			return 1, io.EOF
+		}
+		return 0, h.err
+	}
+
+	if h.held {
+		// Release the held byte in front and read behind it, if
+		// there is no room behind it read ahead into last.
+		held := h.last[0]
+		buf := p[1:]
+		if len(buf) == 0 {
+			buf = h.last[:]
+		}
+		n, err = h.r.Read(buf)
+		if err != nil && err != io.EOF {
+			h.err = err
+			return 0, err
+		}
+		if len(p) == 1 {
+			if n == 0 && err == nil {
+				// Nothing read ahead, r may still fail so keep
+				// holding the byte.
+				h.last[0] = held
+				return 0, nil
+			}
+			// last holds the byte read ahead, if any.
+			p[0] = held
+			h.held = n == 1
+			h.err = err
+			return 1, nil
+		}
+		p[0] = held
+		n++
+	} else {
+		n, err = h.r.Read(p)
+		if err != nil && err != io.EOF {
+			h.err = err
+			return 0, err
+		}
+	}
+
+	if err == io.EOF {
+		h.err = io.EOF
+		return n, io.EOF
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	h.last[0], h.held = p[n-1], true
+	return n - 1, nil
+}
+
+// Err - returns the error r failed with, nil while r did not fail.
+func (h *holdbackReader) Err() error {
+	if h.err == io.EOF {
+		return nil
+	}
+	return h.err
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// stutterReader - returns data in reads of at most one byte with an empty
+// read before every byte and before the end, which is err instead of
+// io.EOF if set.
+type stutterReader struct {
+	data  []byte
+	err   error
+	empty bool
+	eof   bool
+}
+
+func (s *stutterReader) Read(p []byte) (int, error) {
+	if s.empty = !s.empty; s.empty {
+		return 0, nil
+	}
+	if len(s.data) == 0 {
+		s.eof = true
+		if s.err != nil {
+			return 0, s.err
+		}
+		return 0, io.EOF
+	}
+	p[0], s.data = s.data[0], s.data[1:]
+	return 1, nil
+}
+
+// Tests that the last byte is only released once the underlying reader
+// returned io.EOF and never if it failed.
+func TestHoldbackReader(t *testing.T) {
+	errDigest := errors.New("digest mismatch")
+	testCases := []struct {
+		data    []byte
+		srcErr  error
+		bufSize int
+		// underlying reader returns empty reads
+		stutter bool
+		// expected bytes before the error
+		expected []byte
+		err      error
+	}{
+		// Zero-length bodies.
+		{data: nil, bufSize: 1, expected: nil},
+		{data: nil, bufSize: 32, expected: nil},
+		{data: nil, bufSize: 1, stutter: true, expected: nil},
+		// 1-byte reads.
+		{data: []byte("a"), bufSize: 1, expected: []byte("a")},
+		{data: []byte("abcdef"), bufSize: 1, expected: []byte("abcdef")},
+		{data: []byte("abcdef"), bufSize: 1, stutter: true, expected: []byte("abcdef")},
+		{data: []byte("abcdef"), bufSize: 4, stutter: true, expected: []byte("abcdef")},
+		{data: []byte("abcdef"), bufSize: 32, expected: []byte("abcdef")},
+		// Digest mismatch at EOF withholds the last byte.
+		{data: []byte("abcdef"), srcErr: errDigest, bufSize: 1, expected: []byte("abcde"), err: errDigest},
+		{data: []byte("abcdef"), srcErr: errDigest, bufSize: 1, stutter: true, expected: []byte("abcde"), err: errDigest},
+		{data: []byte("abcdef"), srcErr: errDigest, bufSize: 32, expected: []byte("abcde"), err: errDigest},
+		{data: []byte("abcdef"), srcErr: errDigest, bufSize: 32, stutter: true, expected: []byte("abcde"), err: errDigest},
+		{data: []byte("a"), srcErr: errDigest, bufSize: 1, stutter: true, expected: nil, err: errDigest},
+		// Error mid-stream.
+		{data: []byte("abc"), srcErr: io.ErrUnexpectedEOF, bufSize: 2, stutter: true, expected: []byte("ab"), err: io.ErrUnexpectedEOF},
+	}
+
+	for i, testCase := range testCases {
+		var src io.Reader
+		var stutter *stutterReader
+		if testCase.stutter {
+			stutter = &stutterReader{data: testCase.data, err: testCase.srcErr}
+			src = stutter
+		} else {
+			src = io.MultiReader(bytes.NewReader(testCase.data), errReader{testCase.srcErr})
+		}
+		h := newHoldbackReader(src)
+
+		var got []byte
+		var err error
+		buf := make([]byte, testCase.bufSize)
+		for {
+			var n int
+			n, err = h.Read(buf)
+			got = append(got, buf[:n]...)
+			if stutter != nil && !stutter.eof && len(got) >= len(testCase.data) && len(got) > 0 {
+				t.Fatalf("Test %d: Last byte released before the underlying reader ended", i+1)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		if err != testCase.err {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.err, err)
+		}
+		if h.Err() != testCase.err {
+			t.Errorf("Test %d: Expected Err() %v, got %v", i+1, testCase.err, h.Err())
+		}
+		if !bytes.Equal(got, testCase.expected) {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, got)
+		}
+	}
+}
+
+// errReader - fails every read with err, io.EOF if err is nil.
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read(p []byte) (int, error) {
+	if e.err == nil {
+		return 0, io.EOF
+	}
+	return 0, e.err
+}
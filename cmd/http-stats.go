@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -13,7 +15,7 @@ import (
 type ServerConnStats struct {
 	TotalInputBytes  uint64 `json:"transferred"`
 	TotalOutputBytes uint64 `json:"received"`
-	Throughput       uint64 `json:"throughput,omitempty"`
+	Throughput       uint64 `json:"throughput,omitempty"` // bytes per second over the last minute
 	S3InputBytes     uint64 `json:"transferredS3"`
 	S3OutputBytes    uint64 `json:"receivedS3"`
 }
@@ -30,6 +32,10 @@ type ServerHTTPStats struct {
 	CurrentS3Requests ServerHTTPAPIStats `json:"currentS3Requests"`
 	TotalS3Requests   ServerHTTPAPIStats `json:"totalS3Requests"`
 	TotalS3Errors     ServerHTTPAPIStats `json:"totalS3Errors"`
+	TotalS3Canceled   ServerHTTPAPIStats `json:"totalS3Canceled"`
+	// TotalS3RejectedBeforeBody counts the uploads expecting 100
+	// Continue rejected before the client sent the body.
+	TotalS3RejectedBeforeBody ServerHTTPAPIStats `json:"totalS3RejectedBeforeBody"`
 }
 
 // ConnStats - Network statistics
@@ -40,16 +46,19 @@ type ConnStats struct {
 	totalOutputBytes atomic.Uint64
 	s3InputBytes     atomic.Uint64
 	s3OutputBytes    atomic.Uint64
+	throughput       *rateMeter
 }
 
 // Increase total input bytes
 func (s *ConnStats) incInputBytes(n int) {
 	s.totalInputBytes.Add(uint64(n))
+	s.throughput.Add(n, UTCNow())
 }
 
 // Increase total output bytes
 func (s *ConnStats) incOutputBytes(n int) {
 	s.totalOutputBytes.Add(uint64(n))
+	s.throughput.Add(n, UTCNow())
 }
 
 // Return total input bytes
@@ -88,13 +97,14 @@ func (s *ConnStats) toServerConnStats() ServerConnStats {
 		TotalInputBytes:  s.getTotalInputBytes(),
 		TotalOutputBytes: s.getTotalOutputBytes(),
 		S3InputBytes:     s.getS3InputBytes(),
+		Throughput:       s.throughput.Rate(UTCNow()),
 		S3OutputBytes:    s.getS3OutputBytes(),
 	}
 }
 
 // Prepare new ConnStats structure
 func newConnStats() *ConnStats {
-	return &ConnStats{}
+	return &ConnStats{throughput: newRateMeter(UTCNow())}
 }
 
 // HTTPAPIStats holds statistics information about
@@ -133,11 +143,29 @@ func (stats *HTTPAPIStats) Dec(api string) {
 	}
 }
 
-// Load returns the recorded stats.
+// Load returns a copy of the recorded stats.
 func (stats *HTTPAPIStats) Load() map[string]int {
+	stats.RLock()
+	defer stats.RUnlock()
+	apiStats := make(map[string]int, len(stats.APIStats))
+	for api, val := range stats.APIStats {
+		apiStats[api] = val
+	}
+	return apiStats
+}
+
+// LoadAndReset returns the recorded stats and resets them in one step,
+// for exporters of the counts since the last call. Not meant for stats
+// which are decremented, the requests in flight would be lost.
+func (stats *HTTPAPIStats) LoadAndReset() map[string]int {
 	stats.Lock()
 	defer stats.Unlock()
-	return stats.APIStats
+	apiStats := stats.APIStats
+	if apiStats == nil {
+		apiStats = make(map[string]int)
+	}
+	stats.APIStats = nil
+	return apiStats
 }
 
 // HTTPStats holds statistics information about
@@ -146,6 +174,9 @@ type HTTPStats struct {
 	currentS3Requests HTTPAPIStats
 	totalS3Requests   HTTPAPIStats
 	totalS3Errors     HTTPAPIStats
+	totalS3Canceled   HTTPAPIStats
+
+	totalS3RejectedBeforeBody HTTPAPIStats
 }
 
 // Converts http stats into struct to be sent back to the client.
@@ -163,6 +194,14 @@ func (st *HTTPStats) toServerHTTPStats() ServerHTTPStats {
 	serverStats.TotalS3Errors = ServerHTTPAPIStats{
 		APIStats: st.totalS3Errors.Load(),
 	}
+
+	serverStats.TotalS3Canceled = ServerHTTPAPIStats{
+		APIStats: st.totalS3Canceled.Load(),
+	}
+
+	serverStats.TotalS3RejectedBeforeBody = ServerHTTPAPIStats{
+		APIStats: st.totalS3RejectedBeforeBody.Load(),
+	}
 	return serverStats
 }
 
@@ -173,8 +212,26 @@ func (st *HTTPStats) updateStats(api string, r *http.Request, w *recordAPIStats,
 
 	if w.isS3Request && !strings.HasSuffix(r.URL.Path, prometheusMetricsPath) {
 		st.totalS3Requests.Inc(api)
-		if !successReq && w.respStatusCode != 0 {
-			st.totalS3Errors.Inc(api)
+		if canceled, direction := canceledTransfer(r, w); canceled {
+			// Clients going away are not errors of radio or the remotes.
+			st.totalS3Canceled.Inc(api)
+			httpRequestsCanceled.With(prometheus.Labels{"api": api}).Inc()
+			if direction != "" {
+				httpTransfersCanceled.With(prometheus.Labels{"api": api, "direction": direction}).Inc()
+			}
+		} else {
+			if !successReq && w.respStatusCode != 0 {
+				st.totalS3Errors.Inc(api)
+				if bodyWithheld(r) {
+					st.totalS3RejectedBeforeBody.Inc(api)
+					httpRejectedBeforeBody.With(prometheus.Labels{"api": api}).Inc()
+				}
+			}
+			httpRequestsTotal.With(prometheus.Labels{
+				"api":    api,
+				"method": metricsMethod(r.Method),
+				"status": statusClass(w.respStatusCode),
+			}).Inc()
 		}
 	}
 
@@ -184,6 +241,49 @@ func (st *HTTPStats) updateStats(api string, r *http.Request, w *recordAPIStats,
 	}
 }
 
+// canceledTransfer - returns true if the client went away during the
+// request, with the direction of the abandoned transfer, "upload" or
+// "download", if there was one.
+func canceledTransfer(r *http.Request, w *recordAPIStats) (bool, string) {
+	switch {
+	case w.uploadAborted.Load():
+		return true, "upload"
+	case w.downloadAborted.Load():
+		return true, "download"
+	case r.Context().Err() != context.Canceled:
+		return false, ""
+	}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		return true, "upload"
+	case http.MethodGet:
+		return true, "download"
+	}
+	return true, ""
+}
+
+// metricsMethod - returns the method label of a request, unknown
+// methods share one label to bound the number of series.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+// statusClass - returns the class of a response status code, a handler
+// which never wrote a header responded with 200.
+func statusClass(code int) string {
+	switch {
+	case code == 0:
+		return "2xx"
+	case code >= 100 && code < 600:
+		return strconv.Itoa(code/100) + "xx"
+	}
+	return "other"
+}
+
 // Prepare new HTTPStats structure
 func newHTTPStats() *HTTPStats {
 	return &HTTPStats{}
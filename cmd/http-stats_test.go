@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStatusClass(t *testing.T) {
+	testCases := []struct {
+		code  int
+		class string
+	}{
+		{0, "2xx"},
+		{200, "2xx"},
+		{206, "2xx"},
+		{304, "3xx"},
+		{404, "4xx"},
+		{503, "5xx"},
+		{99, "other"},
+		{600, "other"},
+	}
+	for i, testCase := range testCases {
+		if class := statusClass(testCase.code); class != testCase.class {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.class, class)
+		}
+	}
+}
+
+func TestMetricsMethod(t *testing.T) {
+	testCases := []struct {
+		method string
+		label  string
+	}{
+		{"GET", "GET"},
+		{"PUT", "PUT"},
+		{"DELETE", "DELETE"},
+		{"PROPFIND", "OTHER"},
+		{"get", "OTHER"},
+	}
+	for i, testCase := range testCases {
+		if label := metricsMethod(testCase.method); label != testCase.label {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.label, label)
+		}
+	}
+}
+
+func TestHTTPAPIStatsLoad(t *testing.T) {
+	var stats HTTPAPIStats
+	if apiStats := stats.Load(); len(apiStats) != 0 {
+		t.Fatalf("expected no stats, got %v", apiStats)
+	}
+
+	stats.Inc("getobject")
+	stats.Inc("getobject")
+	stats.Inc("putobject")
+
+	apiStats := stats.Load()
+	expected := map[string]int{"getobject": 2, "putobject": 1}
+	if !reflect.DeepEqual(apiStats, expected) {
+		t.Fatalf("expected %v, got %v", expected, apiStats)
+	}
+
+	// The returned map is a copy.
+	apiStats["getobject"] = 10
+	stats.Inc("getobject")
+	if apiStats = stats.Load(); apiStats["getobject"] != 3 {
+		t.Fatalf("expected 3 getobject requests, got %d", apiStats["getobject"])
+	}
+}
+
+func TestHTTPAPIStatsLoadAndReset(t *testing.T) {
+	var stats HTTPAPIStats
+	if apiStats := stats.LoadAndReset(); apiStats == nil || len(apiStats) != 0 {
+		t.Fatalf("expected empty stats, got %v", apiStats)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				stats.Inc("getobject")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				apiStats := stats.LoadAndReset()
+				mu.Lock()
+				total += apiStats["getobject"]
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every increment is returned by exactly one call.
+	total += stats.LoadAndReset()["getobject"]
+	if total != 4000 {
+		t.Fatalf("expected 4000 getobject requests, got %d", total)
+	}
+	if apiStats := stats.Load(); len(apiStats) != 0 {
+		t.Fatalf("expected no stats after reset, got %v", apiStats)
+	}
+}
+
+// errWriter - a ResponseWriter failing every write.
+type errWriter struct {
+	http.ResponseWriter
+}
+
+func (e errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestCanceledTransfer(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		method    string
+		ctx       context.Context
+		bodyErr   error
+		writeErr  bool
+		canceled  bool
+		direction string
+	}{
+		{http.MethodGet, context.Background(), io.EOF, false, false, ""},
+		{http.MethodPut, context.Background(), io.EOF, false, false, ""},
+		// Body rejected by radio, not a client going away.
+		{http.MethodPut, context.Background(), errors.New("http: request body too large"), false, false, ""},
+		{http.MethodPut, context.Background(), io.ErrUnexpectedEOF, false, true, "upload"},
+		{http.MethodPut, context.Background(), &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, false, true, "upload"},
+		{http.MethodGet, context.Background(), io.EOF, true, true, "download"},
+		{http.MethodGet, canceledCtx, io.EOF, false, true, "download"},
+		{http.MethodPost, canceledCtx, io.EOF, false, true, "upload"},
+		{http.MethodDelete, canceledCtx, io.EOF, false, true, ""},
+	}
+
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(testCase.method, "/bucket/object", nil).WithContext(testCase.ctx)
+		var w http.ResponseWriter = httptest.NewRecorder()
+		if testCase.writeErr {
+			w = errWriter{w}
+		}
+		stats := &recordAPIStats{writer: w, isS3Request: true}
+		body := &recordAPIBody{ReadCloser: ioutil.NopCloser(io.MultiReader(strings.NewReader("data"), errReader{testCase.bodyErr})), stats: stats}
+		ioutil.ReadAll(body)
+		stats.Write([]byte("response"))
+
+		canceled, direction := canceledTransfer(r, stats)
+		if canceled != testCase.canceled || direction != testCase.direction {
+			t.Errorf("Test %d: expected %v/%q, got %v/%q", i+1, testCase.canceled, testCase.direction, canceled, direction)
+		}
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/minio/minio/pkg/handlers"
 	trace "github.com/minio/minio/pkg/trace"
 	"github.com/minio/radio/cmd/logger"
 )
@@ -102,20 +101,21 @@ func Trace(f http.HandlerFunc, logBody bool, w http.ResponseWriter, r *http.Requ
 		Time:     time.Now().UTC(),
 		Method:   r.Method,
 		Path:     r.URL.Path,
-		RawQuery: r.URL.RawQuery,
-		Client:   handlers.GetSourceIP(r),
-		Headers:  reqHeaders,
-		Body:     reqBodyRecorder.Data(),
+		RawQuery: logger.RedactQuery(r.URL.RawQuery),
+		Client:   getSourceIP(r),
+		Headers:  logger.RedactHeader(reqHeaders),
 	}
 	rw := logger.NewResponseWriter(w)
 	rw.LogBody = logBody
 	f(rw, r)
+	// The body is recorded while f reads it.
+	rq.Body = []byte(logger.Redact(string(reqBodyRecorder.Data())))
 
 	rs := trace.ResponseInfo{
 		Time:       time.Now().UTC(),
-		Headers:    rw.Header().Clone(),
+		Headers:    logger.RedactHeader(rw.Header()),
 		StatusCode: rw.StatusCode,
-		Body:       rw.Body(),
+		Body:       []byte(logger.Redact(string(rw.Body()))),
 	}
 
 	if rs.StatusCode == 0 {
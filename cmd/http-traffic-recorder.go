@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"time"
+
+	"go.uber.org/atomic"
 )
 
 // records the incoming bytes from the underlying request.Body.
@@ -63,6 +66,13 @@ type recordAPIStats struct {
 	firstByteRead  bool
 	respStatusCode int
 	isS3Request    bool
+	// set once the client went away while sending the request body or
+	// receiving the response.
+	uploadAborted   atomic.Bool
+	downloadAborted atomic.Bool
+	// bytes of the request body read and of the response written.
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
 }
 
 // Calls the underlying WriteHeader.
@@ -82,10 +92,41 @@ func (r *recordAPIStats) Write(p []byte) (n int, err error) {
 		r.TTFB = UTCNow()
 		r.firstByteRead = true
 	}
-	return r.writer.Write(p)
+	n, err = r.writer.Write(p)
+	r.bytesOut.Add(int64(n))
+	if err != nil {
+		r.downloadAborted.Store(true)
+	}
+	return n, err
 }
 
 // Calls the underlying Flush.
 func (r *recordAPIStats) Flush() {
 	r.writer.(http.Flusher).Flush()
 }
+
+// Records the client going away while the request body is read.
+type recordAPIBody struct {
+	io.ReadCloser
+	stats *recordAPIStats
+}
+
+// Calls the underlying Read.
+func (b *recordAPIBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.stats.bytesIn.Add(int64(n))
+	if err != nil && isClientGoneErr(err) {
+		b.stats.uploadAborted.Store(true)
+	}
+	return n, err
+}
+
+// isClientGoneErr - returns true for errors reading a request body of a
+// client which closed the connection.
+func isClientGoneErr(err error) bool {
+	if err == io.ErrUnexpectedEOF {
+		return true
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
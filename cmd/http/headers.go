@@ -13,6 +13,7 @@ const (
 	ContentLanguage    = "Content-Language"
 	ContentRange       = "Content-Range"
 	Connection         = "Connection"
+	Expect             = "Expect"
 	AcceptRanges       = "Accept-Ranges"
 	AmzBucketRegion    = "X-Amz-Bucket-Region"
 	ServerInfo         = "Server"
@@ -22,6 +23,7 @@ const (
 	ContentDisposition = "Content-Disposition"
 	Authorization      = "Authorization"
 	Action             = "Action"
+	Accept             = "Accept"
 )
 
 // Standard S3 HTTP request constants
@@ -61,6 +63,25 @@ const (
 	AmzSecurityToken        = "X-Amz-Security-Token"
 	AmzDecodedContentLength = "X-Amz-Decoded-Content-Length"
 
+	// Additional checksums
+	AmzChecksumCRC32  = "X-Amz-Checksum-Crc32"
+	AmzChecksumCRC32C = "X-Amz-Checksum-Crc32c"
+	AmzChecksumSHA1   = "X-Amz-Checksum-Sha1"
+	AmzChecksumSHA256 = "X-Amz-Checksum-Sha256"
+	AmzChecksumMode   = "X-Amz-Checksum-Mode"
+	AmzTrailer        = "X-Amz-Trailer"
+
+	// RestoreObject
+	AmzRestore = "X-Amz-Restore"
+
+	// Appends to objects
+	AmzWriteOffsetBytes = "X-Amz-Write-Offset-Bytes"
+
+	// GetObjectAttributes
+	AmzObjectAttributes = "X-Amz-Object-Attributes"
+	AmzMaxParts         = "X-Amz-Max-Parts"
+	AmzPartNumberMarker = "X-Amz-Part-Number-Marker"
+
 	// Signature v2 related constants
 	AmzSignatureV2 = "Signature"
 	AmzAccessKeyID = "AWSAccessKeyId"
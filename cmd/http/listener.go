@@ -1,11 +1,14 @@
 package http
 
 import (
+	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -17,11 +20,137 @@ type acceptResult struct {
 
 // httpListener - HTTP listener capable of handling multiple server addresses.
 type httpListener struct {
-	mutex               sync.Mutex         // to guard Close() method.
-	tcpListeners        []*net.TCPListener // underlaying TCP listeners.
-	acceptCh            chan acceptResult  // channel where all TCP listeners write accepted connection.
-	doneCh              chan struct{}      // done channel for TCP listener goroutines.
+	mutex               sync.Mutex          // to guard Close() method.
+	tcpListeners        []*net.TCPListener  // underlaying TCP listeners.
+	tlsConfigs          []*tls.Config       // TLS configuration of each TCP listener, nil for plain HTTP.
+	unixListeners       []*net.UnixListener // underlaying unix socket listeners.
+	acceptCh            chan acceptResult   // channel where all TCP listeners write accepted connection.
+	doneCh              chan struct{}       // done channel for TCP listener goroutines.
 	tcpKeepAliveTimeout time.Duration
+	maxConnsPerIP       int            // open connections allowed per client IP, 0 for no limit.
+	connsMutex          sync.Mutex     // to guard 'conns' field.
+	conns               map[string]int // open connections per client IP.
+	rejectedConns       uint64         // connections closed as their client IP is at the limit.
+	trustedProxies      []*net.IPNet   // sources whose PROXY protocol headers are honored.
+}
+
+// Maximum time to read the PROXY protocol header of a connection.
+const proxyHeaderTimeout = 10 * time.Second
+
+// acceptedConn - connection with the client address sent in its PROXY
+// protocol header and counted against the limit of its client IP. It
+// embeds *net.TCPConn to keep the methods net/http looks for, such as
+// CloseWrite and ReadFrom.
+type acceptedConn struct {
+	*net.TCPConn
+	reader     *bufio.Reader // data read along with the PROXY header, nil once consumed.
+	remoteAddr net.Addr      // client address of the PROXY header, nil if none.
+	once       sync.Once
+	release    func() // releases the connection from the limit, nil if not counted.
+}
+
+// Read - reads the data read along with the PROXY header first.
+func (c *acceptedConn) Read(b []byte) (int, error) {
+	if c.reader != nil {
+		if c.reader.Buffered() > 0 {
+			return c.reader.Read(b)
+		}
+		c.reader = nil
+	}
+	return c.TCPConn.Read(b)
+}
+
+// RemoteAddr - returns the client address of the PROXY header, if any.
+func (c *acceptedConn) RemoteAddr() net.Addr {
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.TCPConn.RemoteAddr()
+}
+
+// Close - closes the connection and releases it from the limit once.
+func (c *acceptedConn) Close() error {
+	if c.release != nil {
+		c.once.Do(c.release)
+	}
+	return c.TCPConn.Close()
+}
+
+// isTrustedProxy - returns true if the PROXY header of connections from
+// addr is honored.
+func (listener *httpListener) isTrustedProxy(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipNet := range listener.trustedProxies {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire - counts a new connection of ip, it returns false if ip has
+// reached the limit already.
+func (listener *httpListener) acquire(ip string) bool {
+	listener.connsMutex.Lock()
+	defer listener.connsMutex.Unlock()
+	if listener.conns[ip] >= listener.maxConnsPerIP {
+		return false
+	}
+	listener.conns[ip]++
+	return true
+}
+
+// release - releases a connection of ip counted by acquire.
+func (listener *httpListener) release(ip string) {
+	listener.connsMutex.Lock()
+	defer listener.connsMutex.Unlock()
+	if listener.conns[ip]--; listener.conns[ip] <= 0 {
+		delete(listener.conns, ip)
+	}
+}
+
+// accept - returns tcpConn with the client address of its PROXY header,
+// if sent by a trusted proxy, and counted against the limit of its
+// client IP. It returns nil if the header is invalid or the client has
+// reached the limit.
+func (listener *httpListener) accept(tcpConn *net.TCPConn) net.Conn {
+	trusted := listener.isTrustedProxy(tcpConn.RemoteAddr())
+	if !trusted && listener.maxConnsPerIP <= 0 {
+		return tcpConn
+	}
+	conn := &acceptedConn{TCPConn: tcpConn}
+	if trusted {
+		conn.reader = bufio.NewReaderSize(tcpConn, 256)
+		tcpConn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+		addr, err := readProxyHeader(conn.reader)
+		if err != nil {
+			return nil
+		}
+		tcpConn.SetReadDeadline(time.Time{})
+		conn.remoteAddr = addr
+	}
+	if listener.maxConnsPerIP <= 0 {
+		return conn
+	}
+	ip := conn.RemoteAddr().String()
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		ip = addr.IP.String()
+	}
+	if !listener.acquire(ip) {
+		atomic.AddUint64(&listener.rejectedConns, 1)
+		return nil
+	}
+	conn.release = func() { listener.release(ip) }
+	return conn
+}
+
+// getRejectedConns - returns the number of connections closed as their
+// client IP was at the limit.
+func (listener *httpListener) getRejectedConns() uint64 {
+	return atomic.LoadUint64(&listener.rejectedConns)
 }
 
 // isRoutineNetErr returns true if error is due to a network timeout,
@@ -66,16 +195,25 @@ func (listener *httpListener) start() {
 	}
 
 	// Closure to handle single connection.
-	handleConn := func(tcpConn *net.TCPConn, doneCh <-chan struct{}) {
+	handleConn := func(tcpConn *net.TCPConn, tlsConfig *tls.Config, doneCh <-chan struct{}) {
 		// Tune accepted TCP connection.
 		tcpConn.SetKeepAlive(true)
 		tcpConn.SetKeepAlivePeriod(listener.tcpKeepAliveTimeout)
 
-		send(acceptResult{tcpConn, nil}, doneCh)
+		conn := listener.accept(tcpConn)
+		if conn == nil {
+			tcpConn.Close()
+			return
+		}
+		// The handshake is done by the HTTP server on first read.
+		if tlsConfig != nil {
+			conn = tls.Server(conn, tlsConfig)
+		}
+		send(acceptResult{conn, nil}, doneCh)
 	}
 
 	// Closure to handle TCPListener until done channel is closed.
-	handleListener := func(tcpListener *net.TCPListener, doneCh <-chan struct{}) {
+	handleListener := func(tcpListener *net.TCPListener, tlsConfig *tls.Config, doneCh <-chan struct{}) {
 		for {
 			tcpConn, err := tcpListener.AcceptTCP()
 			if err != nil {
@@ -84,14 +222,32 @@ func (listener *httpListener) start() {
 					return
 				}
 			} else {
-				go handleConn(tcpConn, doneCh)
+				go handleConn(tcpConn, tlsConfig, doneCh)
+			}
+		}
+	}
+
+	// Closure to handle UnixListener until done channel is closed, unix
+	// connections are served as they are.
+	handleUnixListener := func(unixListener *net.UnixListener, doneCh <-chan struct{}) {
+		for {
+			unixConn, err := unixListener.AcceptUnix()
+			if err != nil {
+				if !send(acceptResult{nil, err}, doneCh) {
+					return
+				}
+			} else {
+				go send(acceptResult{unixConn, nil}, doneCh)
 			}
 		}
 	}
 
 	// Start separate goroutine for each TCP listener to handle connection.
-	for _, tcpListener := range listener.tcpListeners {
-		go handleListener(tcpListener, listener.doneCh)
+	for i, tcpListener := range listener.tcpListeners {
+		go handleListener(tcpListener, listener.tlsConfigs[i], listener.doneCh)
+	}
+	for _, unixListener := range listener.unixListeners {
+		go handleUnixListener(unixListener, listener.doneCh)
 	}
 }
 
@@ -105,7 +261,7 @@ func (listener *httpListener) Accept() (conn net.Conn, err error) {
 	return nil, syscall.EINVAL
 }
 
-// Close - closes underneath all TCP listeners.
+// Close - closes underneath all TCP and unix socket listeners.
 func (listener *httpListener) Close() (err error) {
 	listener.mutex.Lock()
 	defer listener.mutex.Unlock()
@@ -116,6 +272,10 @@ func (listener *httpListener) Close() (err error) {
 	for i := range listener.tcpListeners {
 		listener.tcpListeners[i].Close()
 	}
+	// Closing removes the socket files.
+	for i := range listener.unixListeners {
+		listener.unixListeners[i].Close()
+	}
 	close(listener.doneCh)
 
 	listener.doneCh = nil
@@ -143,6 +303,9 @@ func (listener *httpListener) Addrs() (addrs []net.Addr) {
 	for i := range listener.tcpListeners {
 		addrs = append(addrs, listener.tcpListeners[i].Addr())
 	}
+	for i := range listener.unixListeners {
+		addrs = append(addrs, listener.unixListeners[i].Addr())
+	}
 
 	return addrs
 }
@@ -151,10 +314,16 @@ func (listener *httpListener) Addrs() (addrs []net.Addr) {
 // httpListener is capable to
 // * listen to multiple addresses
 // * controls incoming connections only doing HTTP protocol
-func newHTTPListener(serverAddrs []string,
-	tcpKeepAliveTimeout time.Duration) (listener *httpListener, err error) {
+// * limits the open connections per client IP
+// * serves each address with its own TLS configuration, if any
+// * listens to unix domain sockets
+// * honors the PROXY protocol headers sent by trusted proxies
+func newHTTPListener(serverAddrs []string, tlsConfigs []*tls.Config, unixSockets []UnixSocket,
+	tcpKeepAliveTimeout time.Duration, maxConnsPerIP int, trustedProxies []*net.IPNet) (listener *httpListener, err error) {
 
 	var tcpListeners []*net.TCPListener
+	var listenerTLSConfigs []*tls.Config
+	var unixListeners []*net.UnixListener
 
 	// Close all opened listeners on error
 	defer func() {
@@ -166,9 +335,12 @@ func newHTTPListener(serverAddrs []string,
 			// Ignore error on close.
 			tcpListener.Close()
 		}
+		for _, unixListener := range unixListeners {
+			unixListener.Close()
+		}
 	}()
 
-	for _, serverAddr := range serverAddrs {
+	for i, serverAddr := range serverAddrs {
 		var l net.Listener
 		if l, err = listen("tcp", serverAddr); err != nil {
 			if l, err = fallbackListen("tcp", serverAddr); err != nil {
@@ -182,11 +354,29 @@ func newHTTPListener(serverAddrs []string,
 		}
 
 		tcpListeners = append(tcpListeners, tcpListener)
+		if i < len(tlsConfigs) {
+			listenerTLSConfigs = append(listenerTLSConfigs, tlsConfigs[i])
+		} else {
+			listenerTLSConfigs = append(listenerTLSConfigs, nil)
+		}
+	}
+
+	for _, unixSocket := range unixSockets {
+		var unixListener *net.UnixListener
+		if unixListener, err = listenUnix(unixSocket); err != nil {
+			return nil, err
+		}
+		unixListeners = append(unixListeners, unixListener)
 	}
 
 	listener = &httpListener{
 		tcpListeners:        tcpListeners,
+		tlsConfigs:          listenerTLSConfigs,
+		unixListeners:       unixListeners,
 		tcpKeepAliveTimeout: tcpKeepAliveTimeout,
+		maxConnsPerIP:       maxConnsPerIP,
+		trustedProxies:      trustedProxies,
+		conns:               make(map[string]int),
 	}
 	listener.start()
 
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -135,7 +138,11 @@ func TestNewHTTPListener(t *testing.T) {
 	for _, testCase := range testCases {
 		listener, err := newHTTPListener(
 			testCase.serverAddrs,
+			nil,
+			nil,
 			testCase.tcpKeepAliveTimeout,
+			0,
+			nil,
 		)
 
 		if !testCase.expectedErr {
@@ -169,7 +176,11 @@ func TestHTTPListenerStartClose(t *testing.T) {
 	for i, testCase := range testCases {
 		listener, err := newHTTPListener(
 			testCase.serverAddrs,
+			nil,
+			nil,
 			time.Duration(0),
+			0,
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("Test %d: error: expected = <nil>, got = %v", i+1, err)
@@ -209,7 +220,11 @@ func TestHTTPListenerAddr(t *testing.T) {
 	for i, testCase := range testCases {
 		listener, err := newHTTPListener(
 			testCase.serverAddrs,
+			nil,
+			nil,
 			time.Duration(0),
+			0,
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("Test %d: error: expected = <nil>, got = %v", i+1, err)
@@ -246,7 +261,11 @@ func TestHTTPListenerAddrs(t *testing.T) {
 	for i, testCase := range testCases {
 		listener, err := newHTTPListener(
 			testCase.serverAddrs,
+			nil,
+			nil,
 			time.Duration(0),
+			0,
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("Test %d: error: expected = <nil>, got = %v", i+1, err)
@@ -266,6 +285,139 @@ func TestHTTPListenerAddrs(t *testing.T) {
 	}
 }
 
+func TestHTTPListenerTLSConfigs(t *testing.T) {
+	tlsConfig := &tls.Config{GetCertificate: getCert}
+	listener, err := newHTTPListener([]string{"127.0.0.1:0", "127.0.0.1:0"},
+		[]*tls.Config{tlsConfig, nil}, nil, time.Duration(0), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	for i, addr := range listener.Addrs() {
+		client, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, isTLS := conn.(*tls.Conn); isTLS != (i == 0) {
+			t.Errorf("Test %d: expected TLS %v, got %T", i+1, i == 0, conn)
+		}
+		conn.Close()
+		client.Close()
+	}
+}
+
+func TestHTTPListenerUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-http-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := UnixSocket{Path: filepath.Join(dir, "radio.sock"), Mode: 0600, GID: -1}
+
+	// A socket file left behind is replaced.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket.Path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := newHTTPListener([]string{"127.0.0.1:0"}, nil, []UnixSocket{socket}, time.Duration(0), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(socket.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", fi.Mode().Perm())
+	}
+	if _, err = newHTTPListener([]string{"127.0.0.1:0"}, nil, []UnixSocket{socket}, time.Duration(0), 0, nil); err == nil {
+		t.Error("expected a socket in use to be rejected")
+	}
+
+	// Unix connections are not limited per client IP.
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("unix", socket.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if _, ok := conn.(*net.UnixConn); !ok {
+			t.Errorf("expected a unix connection, got %T", conn)
+		}
+	}
+
+	listener.Close()
+	if _, err = os.Stat(socket.Path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket file to be removed, got %v", err)
+	}
+}
+
+func TestHTTPListenerMaxConnsPerIP(t *testing.T) {
+	listener, err := newHTTPListener([]string{"127.0.0.1:0"}, nil, nil, time.Duration(0), 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accept := func() net.Conn {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	client1, client2 := dial(), dial()
+	defer client1.Close()
+	defer client2.Close()
+	conn1, conn2 := accept(), accept()
+
+	// The third connection is closed without being accepted.
+	client3 := dial()
+	defer client3.Close()
+	client3.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = client3.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected the connection over the limit to be closed, got %v", err)
+	}
+	if n := listener.getRejectedConns(); n != 1 {
+		t.Fatalf("expected 1 rejected connection, got %d", n)
+	}
+
+	// Closing an accepted connection, even twice, frees a single slot.
+	conn1.Close()
+	conn1.Close()
+	client4 := dial()
+	defer client4.Close()
+	conn4 := accept()
+	conn4.Close()
+	conn2.Close()
+	listener.connsMutex.Lock()
+	defer listener.connsMutex.Unlock()
+	if len(listener.conns) != 0 {
+		t.Fatalf("expected no open connections, got %v", listener.conns)
+	}
+}
+
 type myTimeoutErr struct {
 	timeout bool
 }
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// proxyV2Signature - first bytes of a PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// Maximum length of a PROXY protocol v1 header line, including CRLF.
+const proxyV1MaxLength = 107
+
+var errInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+// readProxyHeader - reads the PROXY protocol v1 or v2 header sent ahead
+// of the connection data, if any. It returns the client address carried
+// by the header, nil if there is no header or the connection was opened
+// by the proxy itself, such as for health checks.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch b[0] {
+	case 'P':
+		if b, err = r.Peek(6); err != nil || string(b) != "PROXY " {
+			return nil, err
+		}
+		return readProxyV1Header(r)
+	case proxyV2Signature[0]:
+		if b, err = r.Peek(len(proxyV2Signature)); err != nil || !bytes.Equal(b, proxyV2Signature) {
+			return nil, err
+		}
+		return readProxyV2Header(r)
+	}
+	return nil, nil
+}
+
+// readProxyV1Header - reads a header such as
+// "PROXY TCP4 192.0.2.10 198.51.100.1 56324 443\r\n".
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		if err == bufio.ErrBufferFull {
+			err = errInvalidProxyHeader
+		}
+		return nil, err
+	}
+	if len(line) > proxyV1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errInvalidProxyHeader
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errInvalidProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, errInvalidProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header - reads a binary header, its TLVs are skipped.
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	hdr := make([]byte, len(proxyV2Signature)+4)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	verCmd, famProto := hdr[12], hdr[13]
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if verCmd>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version %d", verCmd>>4)
+	}
+	switch verCmd & 0xf {
+	case 0:
+		// LOCAL, sent by the proxy on its own behalf.
+		return nil, nil
+	case 1:
+		// PROXY
+	default:
+		return nil, errInvalidProxyHeader
+	}
+	switch famProto {
+	case 0x11:
+		// TCP over IPv4
+		if len(body) < 12 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:]))}, nil
+	case 0x21:
+		// TCP over IPv6
+		if len(body) < 36 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:]))}, nil
+	}
+	// Unspecified, UDP and unix addresses are not client addresses of
+	// this listener.
+	return nil, nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadProxyHeader(t *testing.T) {
+	v2 := func(verCmd, famProto byte, body string) string {
+		return string(proxyV2Signature) + string([]byte{verCmd, famProto, 0, byte(len(body))}) + body
+	}
+	testCases := []struct {
+		data         string
+		expectedAddr string
+		success      bool
+	}{
+		{"GET / HTTP/1.1\r\n\r\n", "", true},
+		{"PUT /bucket/object HTTP/1.1\r\n\r\n", "", true},
+		{"\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03", "", true},
+		{"PROXY TCP4 192.0.2.10 198.51.100.1 56324 443\r\nGET / HTTP/1.1\r\n\r\n", "192.0.2.10:56324", true},
+		{"PROXY TCP6 2001:db8::10 2001:db8::1 56324 443\r\nGET / HTTP/1.1\r\n\r\n", "[2001:db8::10]:56324", true},
+		{"PROXY UNKNOWN\r\nGET / HTTP/1.1\r\n\r\n", "", true},
+		{"PROXY TCP4 2001:db8::10 198.51.100.1 56324 443\r\n", "", false},
+		{"PROXY TCP4 192.0.2.10 198.51.100.1 99999 443\r\n", "", false},
+		{"PROXY TCP4 192.0.2.10\r\n", "", false},
+		{"PROXY TCP4 192.0.2.10 198.51.100.1 56324 443\n", "", false},
+		{"PROXY " + strings.Repeat("A", 300) + "\r\n", "", false},
+		{v2(0x21, 0x11, "\xc0\x00\x02\x0a\xc6\x33\x64\x01\xdc\x04\x01\xbb") + "GET / HTTP/1.1\r\n\r\n", "192.0.2.10:56324", true},
+		// TLVs are skipped.
+		{v2(0x21, 0x11, "\xc0\x00\x02\x0a\xc6\x33\x64\x01\xdc\x04\x01\xbb\x04\x00\x01\x00") + "GET / HTTP/1.1\r\n\r\n", "192.0.2.10:56324", true},
+		{v2(0x21, 0x21, "\x20\x01\x0d\xb8"+strings.Repeat("\x00", 11)+"\x10"+"\x20\x01\x0d\xb8"+strings.Repeat("\x00", 11)+"\x01\xdc\x04\x01\xbb") + "GET / HTTP/1.1\r\n\r\n", "[2001:db8::10]:56324", true},
+		{v2(0x20, 0x00, "") + "GET / HTTP/1.1\r\n\r\n", "", true},
+		{v2(0x21, 0x11, "\xc0\x00\x02\x0a"), "", false},
+		{v2(0x11, 0x11, "\xc0\x00\x02\x0a\xc6\x33\x64\x01\xdc\x04\x01\xbb"), "", false},
+		{v2(0x22, 0x11, "\xc0\x00\x02\x0a\xc6\x33\x64\x01\xdc\x04\x01\xbb"), "", false},
+	}
+
+	for i, testCase := range testCases {
+		r := bufio.NewReaderSize(strings.NewReader(testCase.data), 256)
+		addr, err := readProxyHeader(r)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if addr == nil && testCase.expectedAddr != "" || addr != nil && addr.String() != testCase.expectedAddr {
+			t.Errorf("Test %d: Expected address %q, got %v", i+1, testCase.expectedAddr, addr)
+		}
+		// The data following the header is left to read.
+		rest, _ := ioutil.ReadAll(r)
+		if !strings.HasSuffix(testCase.data, string(rest)) || (addr != nil && strings.HasPrefix(string(rest), "PROXY")) {
+			t.Errorf("Test %d: unexpected data left %q", i+1, rest)
+		}
+	}
+}
+
+func TestHTTPListenerProxyProtocol(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	listener, err := newHTTPListener([]string{"127.0.0.1:0"}, nil, nil, time.Duration(0), 1, []*net.IPNet{loopback})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Connections are limited per client address of the PROXY header.
+	for _, client := range []string{"192.0.2.10", "192.0.2.11"} {
+		c, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if _, err = c.Write([]byte("PROXY TCP4 " + client + " 127.0.0.1 56324 443\r\nGET / HTTP/1.1\r\n\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if addr := conn.RemoteAddr().String(); addr != client+":56324" {
+			t.Errorf("expected client address %s:56324, got %s", client, addr)
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || line != "GET / HTTP/1.1\r\n" {
+			t.Errorf("expected the request after the header, got %q %v", line, err)
+		}
+	}
+	if n := listener.getRejectedConns(); n != 0 {
+		t.Errorf("expected no rejected connections, got %d", n)
+	}
+
+	// Invalid headers close the connection.
+	c, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.Write([]byte("PROXY TCP4 invalid\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = c.Read(make([]byte, 1)); err == nil {
+		t.Error("expected the connection with an invalid header to be closed")
+	}
+}
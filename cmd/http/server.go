@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"runtime/pprof"
 	"sync"
@@ -32,13 +33,20 @@ const (
 // Server - extended http.Server supports multiple addresses to serve and enhanced connection handling.
 type Server struct {
 	http.Server
-	Addrs               []string      // addresses on which the server listens for new connection.
-	ShutdownTimeout     time.Duration // timeout used for graceful server shutdown.
-	TCPKeepAliveTimeout time.Duration // timeout used for underneath TCP connection.
-	listenerMutex       sync.Mutex    // to guard 'listener' field.
-	listener            *httpListener // HTTP listener for all 'Addrs' field.
-	inShutdown          uint32        // indicates whether the server is in shutdown or not
-	requestCount        int32         // counter holds no. of request in progress.
+	Addrs               []string               // addresses on which the server listens for new connection.
+	AddrTLSConfigs      map[string]*tls.Config // TLS configuration of an address overriding TLSConfig, nil for plain HTTP.
+	UnixSockets         []UnixSocket           // unix domain sockets served without TLS in addition to 'Addrs'.
+	ShutdownTimeout     time.Duration          // timeout used for graceful server shutdown.
+	TCPKeepAliveTimeout time.Duration          // timeout used for underneath TCP connection.
+	MaxConnsPerIP       int                    // open connections allowed per client IP, 0 for no limit.
+	MaxHeaderCount      int                    // header values allowed per request, 0 for no limit.
+	TrustedProxies      []*net.IPNet           // sources whose PROXY protocol headers are honored.
+	listenerMutex       sync.Mutex             // to guard 'listener' field.
+	listener            *httpListener          // HTTP listener for all 'Addrs' field.
+	inShutdown          uint32                 // indicates whether the server is in shutdown or not
+	requestCount        int32                  // counter holds no. of request in progress.
+	connCount           int32                  // counter holds no. of open client connections.
+	rejectedRequests    uint64                 // counter holds no. of requests rejected for too many headers.
 }
 
 // GetRequestCount - returns number of request in progress.
@@ -46,6 +54,46 @@ func (srv *Server) GetRequestCount() int32 {
 	return atomic.LoadInt32(&srv.requestCount)
 }
 
+// GetConnCount - returns number of open client connections.
+func (srv *Server) GetConnCount() int32 {
+	return atomic.LoadInt32(&srv.connCount)
+}
+
+// GetRejectedConnCount - returns number of connections closed as their
+// client IP had MaxConnsPerIP connections open.
+func (srv *Server) GetRejectedConnCount() uint64 {
+	srv.listenerMutex.Lock()
+	defer srv.listenerMutex.Unlock()
+	if srv.listener == nil {
+		return 0
+	}
+	return srv.listener.getRejectedConns()
+}
+
+// GetRejectedRequestCount - returns number of requests rejected for
+// more than MaxHeaderCount header values.
+func (srv *Server) GetRejectedRequestCount() uint64 {
+	return atomic.LoadUint64(&srv.rejectedRequests)
+}
+
+// headerCount - returns the number of header values of header.
+func headerCount(header http.Header) (n int) {
+	for _, values := range header {
+		n += len(values)
+	}
+	return n
+}
+
+// trackConnState - counts the connections opened and closed.
+func (srv *Server) trackConnState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt32(&srv.connCount, 1)
+	case http.StateHijacked, http.StateClosed:
+		atomic.AddInt32(&srv.connCount, -1)
+	}
+}
+
 // Start - start HTTP server
 func (srv *Server) Start() (err error) {
 	// Take a copy of server fields.
@@ -56,13 +104,27 @@ func (srv *Server) Start() (err error) {
 	handler := srv.Handler // if srv.Handler holds non-synced state -> possible data race
 
 	addrs := set.CreateStringSet(srv.Addrs...).ToSlice() // copy and remove duplicates
+	tlsConfigs := make([]*tls.Config, len(addrs))
+	for i, addr := range addrs {
+		addrTLSConfig, ok := srv.AddrTLSConfigs[addr]
+		if !ok {
+			tlsConfigs[i] = tlsConfig
+		} else if addrTLSConfig != nil {
+			tlsConfigs[i] = addrTLSConfig.Clone()
+		}
+	}
 	tcpKeepAliveTimeout := srv.TCPKeepAliveTimeout
+	maxHeaderCount := srv.MaxHeaderCount
 
 	// Create new HTTP listener.
 	var listener *httpListener
 	listener, err = newHTTPListener(
 		addrs,
+		tlsConfigs,
+		srv.UnixSockets,
 		tcpKeepAliveTimeout,
+		srv.MaxConnsPerIP,
+		srv.TrustedProxies,
 	)
 	if err != nil {
 		return err
@@ -70,6 +132,7 @@ func (srv *Server) Start() (err error) {
 
 	// Wrap given handler to do additional
 	// * return 503 (service unavailable) if the server in shutdown.
+	// * return 431 (request header fields too large) for too many headers.
 	wrappedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&srv.requestCount, 1)
 		defer atomic.AddInt32(&srv.requestCount, -1)
@@ -80,6 +143,13 @@ func (srv *Server) Start() (err error) {
 			return
 		}
 
+		if maxHeaderCount > 0 && headerCount(r.Header) > maxHeaderCount {
+			atomic.AddUint64(&srv.rejectedRequests, 1)
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusRequestHeaderFieldsTooLarge)
+			return
+		}
+
 		// Handle request using passed handler.
 		handler.ServeHTTP(w, r)
 	})
@@ -89,10 +159,8 @@ func (srv *Server) Start() (err error) {
 	srv.listener = listener
 	srv.listenerMutex.Unlock()
 
-	// Start servicing with listener.
-	if tlsConfig != nil {
-		return srv.Server.Serve(tls.NewListener(listener, tlsConfig))
-	}
+	// Start servicing with listener, it serves TLS connections
+	// of the addresses with a TLS configuration.
 	return srv.Server.Serve(listener)
 }
 
@@ -161,26 +229,33 @@ var defaultCipherSuites = []uint16{
 // Go only provides constant-time implementations of Curve25519 and NIST P-256 curve.
 var secureCurves = []tls.CurveID{tls.X25519, tls.CurveP256}
 
+// NewTLSConfig - returns the TLS configuration of the server for the
+// certificates returned by getCert.
+func NewTLSConfig(getCert certs.GetCertificateFunc) *tls.Config {
+	tlsConfig := &tls.Config{
+		// TLS hardening
+		PreferServerCipherSuites: true,
+		CipherSuites:             defaultCipherSuites,
+		CurvePreferences:         secureCurves,
+		MinVersion:               tls.VersionTLS12,
+		// Do not edit the next line, protos priority is kept
+		// on purpose in this manner for HTTP 2.0, we would
+		// still like HTTP 2.0 clients to negotiate connection
+		// to server if needed but by default HTTP 1.1 is
+		// expected. We need to change this in future
+		// when we wish to go back to HTTP 2.0 as default
+		// priority for HTTP protocol negotiation.
+		NextProtos: []string{"http/1.1", "h2"},
+	}
+	tlsConfig.GetCertificate = getCert
+	return tlsConfig
+}
+
 // NewServer - creates new HTTP server using given arguments.
 func NewServer(addrs []string, handler http.Handler, getCert certs.GetCertificateFunc) *Server {
 	var tlsConfig *tls.Config
 	if getCert != nil {
-		tlsConfig = &tls.Config{
-			// TLS hardening
-			PreferServerCipherSuites: true,
-			CipherSuites:             defaultCipherSuites,
-			CurvePreferences:         secureCurves,
-			MinVersion:               tls.VersionTLS12,
-			// Do not edit the next line, protos priority is kept
-			// on purpose in this manner for HTTP 2.0, we would
-			// still like HTTP 2.0 clients to negotiate connection
-			// to server if needed but by default HTTP 1.1 is
-			// expected. We need to change this in future
-			// when we wish to go back to HTTP 2.0 as default
-			// priority for HTTP protocol negotiation.
-			NextProtos: []string{"http/1.1", "h2"},
-		}
-		tlsConfig.GetCertificate = getCert
+		tlsConfig = NewTLSConfig(getCert)
 	}
 
 	httpServer := &Server{
@@ -191,6 +266,7 @@ func NewServer(addrs []string, handler http.Handler, getCert certs.GetCertificat
 	httpServer.Handler = handler
 	httpServer.TLSConfig = tlsConfig
 	httpServer.MaxHeaderBytes = DefaultMaxHeaderBytes
+	httpServer.ConnState = httpServer.trackConnState
 
 	return httpServer
 }
@@ -2,9 +2,11 @@ package http
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/minio/minio/pkg/certs"
 )
@@ -66,3 +68,73 @@ func TestNewServer(t *testing.T) {
 		}
 	}
 }
+
+func TestServerConnCount(t *testing.T) {
+	server := NewServer([]string{"127.0.0.1:9000"}, http.NotFoundHandler(), nil)
+
+	conn1, conn2 := &net.TCPConn{}, &net.TCPConn{}
+	server.ConnState(conn1, http.StateNew)
+	server.ConnState(conn2, http.StateNew)
+	server.ConnState(conn1, http.StateActive)
+	server.ConnState(conn1, http.StateIdle)
+	if count := server.GetConnCount(); count != 2 {
+		t.Fatalf("expected 2 open connections, got %d", count)
+	}
+
+	server.ConnState(conn1, http.StateClosed)
+	server.ConnState(conn2, http.StateHijacked)
+	if count := server.GetConnCount(); count != 0 {
+		t.Fatalf("expected no open connections, got %d", count)
+	}
+}
+
+func TestServerMaxHeaderCount(t *testing.T) {
+	server := NewServer([]string{"127.0.0.1:0"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Hello, world")
+	}), nil)
+	server.MaxHeaderCount = 3
+	go server.Start()
+	defer server.Shutdown()
+
+	var addr string
+	for i := 0; i < 100 && addr == ""; i++ {
+		server.listenerMutex.Lock()
+		if server.listener != nil {
+			addr = server.listener.Addr().String()
+		}
+		server.listenerMutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if addr == "" {
+		t.Fatal("server did not start")
+	}
+
+	testCases := []struct {
+		headers        int
+		expectedStatus int
+	}{
+		{1, http.StatusOK},
+		{3, http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The client adds User-Agent and Accept-Encoding.
+		for j := 0; j < testCase.headers; j++ {
+			req.Header.Add("X-Test", "value")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.expectedStatus, resp.StatusCode)
+		}
+	}
+	if n := server.GetRejectedRequestCount(); n != 1 {
+		t.Errorf("expected 1 rejected request, got %d", n)
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// UnixSocket - unix domain socket served in addition to the TCP addresses.
+type UnixSocket struct {
+	Path string      // path of the socket file, replaced if left by a previous server.
+	Mode os.FileMode // permissions of the socket file, 0 to keep the default.
+	GID  int         // group owning the socket file, -1 to keep the default.
+}
+
+// listenUnix - listens on the socket s, a socket file left behind by a
+// server which is gone is removed first.
+func listenUnix(s UnixSocket) (*net.UnixListener, error) {
+	if fi, err := os.Lstat(s.Path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", s.Path)
+		}
+		if conn, err := net.DialTimeout("unix", s.Path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", s.Path)
+		}
+		if err = os.Remove(s.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: s.Path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	if s.Mode != 0 {
+		if err = os.Chmod(s.Path, s.Mode); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	if s.GID >= 0 {
+		if err = os.Chown(s.Path, -1, s.GID); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
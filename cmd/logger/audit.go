@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/minio/minio/pkg/handlers"
 	"github.com/minio/radio/cmd/logger/message/audit"
 )
 
@@ -107,6 +108,9 @@ func (lrw *ResponseWriter) Size() int {
 // AuditTargets is the list of enabled audit loggers
 var AuditTargets = []Target{}
 
+// SourceIP returns the client address logged for a request
+var SourceIP = handlers.GetSourceIP
+
 // AddAuditTarget adds a new audit logger target to the
 // list of enabled loggers
 func AddAuditTarget(t Target) {
@@ -151,6 +155,10 @@ func AuditLog(w http.ResponseWriter, r *http.Request, api string) {
 	// Send audit logs only to http targets.
 	for _, t := range AuditTargets {
 		entry := audit.ToEntry(w, r, nil, globalDeploymentID)
+		redactValues(entry.ReqQuery, isRedactedQueryParam)
+		redactValues(entry.ReqHeader, isRedactedHeader)
+		redactValues(entry.RespHeader, isRedactedHeader)
+		entry.RemoteHost = SourceIP(r)
 		entry.API.Name = api
 		entry.API.Bucket = bucket
 		entry.API.Object = object
@@ -20,6 +20,14 @@ type Logger interface {
 }
 
 func consoleLog(console Logger, msg string, args ...interface{}) {
+	// The arguments may quote credentials, the message is redacted once
+	// formatted.
+	if len(args) > 0 {
+		msg = strings.Replace(Redact(fmt.Sprintf(msg, args...)), "%", "%%", -1)
+		args = nil
+	} else {
+		msg = Redact(msg)
+	}
 	switch {
 	case jsonFlag:
 		// Strip escape control characters from json message
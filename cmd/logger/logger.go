@@ -294,14 +294,14 @@ func logIf(ctx context.Context, err error, errKind ...interface{}) {
 
 	tags := make(map[string]string)
 	for _, entry := range req.GetTags() {
-		tags[entry.Key] = entry.Val
+		tags[entry.Key] = Redact(entry.Val)
 	}
 
 	// Get full stack trace
 	trace := getTrace(3)
 
 	// Get the cause for the Error
-	message := err.Error()
+	message := Redact(err.Error())
 	if req.DeploymentID == "" {
 		req.DeploymentID = globalDeploymentID
 	}
@@ -313,6 +313,7 @@ func logIf(ctx context.Context, err error, errKind ...interface{}) {
 		Host:         req.Host,
 		RequestID:    req.RequestID,
 		UserAgent:    req.UserAgent,
+		AccessKey:    req.AccessKey,
 		Time:         time.Now().UTC().Format(time.RFC3339Nano),
 		API: &log.API{
 			Name: API,
@@ -332,6 +333,7 @@ func logIf(ctx context.Context, err error, errKind ...interface{}) {
 		entry.API.Args.Bucket = hashString(entry.API.Args.Bucket)
 		entry.API.Args.Object = hashString(entry.API.Args.Object)
 		entry.RemoteHost = hashString(entry.RemoteHost)
+		entry.AccessKey = hashString(entry.AccessKey)
 		entry.Trace.Message = reflect.TypeOf(err).String()
 		entry.Trace.Variables = make(map[string]string)
 	}
@@ -31,6 +31,7 @@ type Entry struct {
 	Host         string `json:"host,omitempty"`
 	RequestID    string `json:"requestID,omitempty"`
 	UserAgent    string `json:"userAgent,omitempty"`
+	AccessKey    string `json:"accessKey,omitempty"`
 	Message      string `json:"message,omitempty"`
 	Trace        *Trace `json:"error,omitempty"`
 }
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the credentials, signatures and secrets in log, trace
+// and audit output.
+const Redacted = "*REDACTED*"
+
+// Secrets shorter than this are not redacted from messages, they would
+// match ordinary words.
+const minSecretLength = 8
+
+// redactedHeaders - request and response headers carrying credentials.
+var redactedHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Amz-Security-Token": true,
+	"X-Amz-Server-Side-Encryption-Customer-Key":             true,
+	"X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key": true,
+}
+
+// redactedQueryParams - query parameters of presigned V2 and V4 requests
+// carrying credentials, matched case-insensitively.
+var redactedQueryParams = []string{
+	"X-Amz-Signature",
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"Signature",
+	"AWSAccessKeyId",
+}
+
+// Credentials in free text, such as an error quoting a presigned URL or
+// an Authorization header.
+var (
+	credentialParamRE = regexp.MustCompile(`(?i)\b((?:X-Amz-)?(?:Signature|Credential|Security-Token)|AWSAccessKeyId)=[^&,;\s"'<]+`)
+	authorizationRE   = regexp.MustCompile(`\b(Bearer|AWS|AWS4-HMAC-SHA256) [^\s"']+`)
+	// Secret fields of YAML and JSON documents, such as a config or
+	// credentials returned by a provider.
+	secretFieldRE = regexp.MustCompile(`(?i)(^|[\s"'{,;&?])((?:secret_?(?:access_?)?key|(?:session_?|auth_?)?token|password)"?\s*[:=]\s*"?)[^\s"',}&;]+`)
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret - redacts secret, such as a secret key or token of the
+// configuration, from all messages logged from now on.
+func RegisterSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// Redact - returns s with credentials, signatures and registered secrets
+// replaced by Redacted.
+func Redact(s string) string {
+	s = credentialParamRE.ReplaceAllString(s, "${1}="+Redacted)
+	s = authorizationRE.ReplaceAllString(s, "${1} "+Redacted)
+	s = secretFieldRE.ReplaceAllString(s, "${1}${2}"+Redacted)
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+	}
+	return s
+}
+
+// isRedactedHeader - returns true if the values of header key are
+// credentials.
+func isRedactedHeader(key string) bool {
+	return redactedHeaders[http.CanonicalHeaderKey(key)]
+}
+
+// isRedactedQueryParam - returns true if the values of query parameter
+// key are credentials.
+func isRedactedQueryParam(key string) bool {
+	for _, param := range redactedQueryParams {
+		if strings.EqualFold(key, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeader - returns a copy of h with the values of the headers
+// carrying credentials replaced, and the others redacted like messages.
+func RedactHeader(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for k, vv := range h {
+		values := make([]string, len(vv))
+		for i, v := range vv {
+			if isRedactedHeader(k) {
+				values[i] = Redacted
+			} else {
+				values[i] = Redact(v)
+			}
+		}
+		redacted[k] = values
+	}
+	return redacted
+}
+
+// RedactQuery - returns rawQuery with the values of the parameters carrying
+// credentials replaced, the order and encoding of the others are kept.
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		rawKey := param
+		if j := strings.IndexByte(param, '='); j >= 0 {
+			rawKey = param[:j]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if isRedactedQueryParam(key) {
+			params[i] = rawKey + "=" + Redacted
+		} else {
+			params[i] = Redact(param)
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactValues - redacts the values of m in place, those of the keys
+// matched by isRedacted are replaced.
+func redactValues(m map[string]string, isRedacted func(string) bool) {
+	for k, v := range m {
+		if isRedacted(k) {
+			m[k] = Redacted
+		} else {
+			m[k] = Redact(v)
+		}
+	}
+}
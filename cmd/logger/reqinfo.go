@@ -27,6 +27,7 @@ type ReqInfo struct {
 	API          string   // API name - GetObject PutObject NewMultipartUpload etc.
 	BucketName   string   // Bucket name
 	ObjectName   string   // Object name
+	AccessKey    string   // Access key of the requester
 	tags         []KeyVal // Any additional info not accommodated by above fields
 	sync.RWMutex
 }
@@ -64,9 +65,9 @@ func (r *ReqInfo) SetTags(key string, val string) *ReqInfo {
 	defer r.Unlock()
 	// Search of tag key already exists in tags
 	var updated bool
-	for _, tag := range r.tags {
-		if tag.Key == key {
-			tag.Val = val
+	for i := range r.tags {
+		if r.tags[i].Key == key {
+			r.tags[i].Val = val
 			updated = true
 			break
 		}
@@ -97,6 +98,54 @@ func SetReqInfo(ctx context.Context, req *ReqInfo) context.Context {
 	return context.WithValue(ctx, contextLogKey, req)
 }
 
+// Clone - returns a copy of r with its own tags.
+func (r *ReqInfo) Clone() *ReqInfo {
+	if r == nil {
+		return nil
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return &ReqInfo{
+		RemoteHost:   r.RemoteHost,
+		Host:         r.Host,
+		UserAgent:    r.UserAgent,
+		DeploymentID: r.DeploymentID,
+		RequestID:    r.RequestID,
+		API:          r.API,
+		BucketName:   r.BucketName,
+		ObjectName:   r.ObjectName,
+		AccessKey:    r.AccessKey,
+		tags:         append([]KeyVal(nil), r.tags...),
+	}
+}
+
+// WithTags - returns ctx carrying a copy of its ReqInfo with the key and
+// value pairs of kvs set as tags. Parts of a request running side by side,
+// such as the calls to several remotes, log their own tags this way
+// without overwriting those of each other.
+func WithTags(ctx context.Context, kvs ...string) context.Context {
+	req, ok := LookupReqInfo(ctx)
+	if ok {
+		req = req.Clone()
+	} else {
+		req = &ReqInfo{}
+	}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		req.SetTags(kvs[i], kvs[i+1])
+	}
+	return SetReqInfo(ctx, req)
+}
+
+// LookupReqInfo - returns the ReqInfo set in ctx, unlike GetReqInfo
+// none is created if there is none.
+func LookupReqInfo(ctx context.Context) (*ReqInfo, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	r, ok := ctx.Value(contextLogKey).(*ReqInfo)
+	return r, ok
+}
+
 // GetReqInfo returns ReqInfo if set.
 func GetReqInfo(ctx context.Context) *ReqInfo {
 	if ctx != nil {
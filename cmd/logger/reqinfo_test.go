@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReqInfoTags(t *testing.T) {
+	req := &ReqInfo{API: "GetObject", BucketName: "bucket"}
+	req.SetTags("remote", "a")
+	req.SetTags("remote", "b")
+	if tags := req.GetTags(); len(tags) != 1 || tags[0].Val != "b" {
+		t.Errorf("expected the tag to be updated, got %v", tags)
+	}
+
+	ctx := SetReqInfo(context.Background(), req)
+	tagged := WithTags(ctx, "remote", "c", "cachePath", "/cache")
+	if tags := req.GetTags(); len(tags) != 1 || tags[0].Val != "b" {
+		t.Errorf("expected the parent tags to be kept, got %v", tags)
+	}
+	clone := GetReqInfo(tagged)
+	if clone == req || clone.API != "GetObject" || clone.BucketName != "bucket" {
+		t.Errorf("expected a copy of the request info, got %+v", clone)
+	}
+	if tags := clone.GetTags(); len(tags) != 2 || tags[0].Val != "c" || tags[1].Key != "cachePath" {
+		t.Errorf("expected the tags to be set on the copy, got %v", tags)
+	}
+
+	if _, ok := LookupReqInfo(context.Background()); ok {
+		t.Error("expected no request info")
+	}
+	if r, ok := LookupReqInfo(ctx); !ok || r != req {
+		t.Error("expected the request info set")
+	}
+	if tags := GetReqInfo(WithTags(context.Background(), "job", "Inventory")).GetTags(); len(tags) != 1 {
+		t.Errorf("expected a request info to be created, got %v", tags)
+	}
+}
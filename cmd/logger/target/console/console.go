@@ -84,13 +84,18 @@ func (c *Target) Send(e interface{}, logKind string) error {
 		userAgent = "\nUserAgent: " + entry.UserAgent
 	}
 
+	var accessKey string
+	if entry.AccessKey != "" {
+		accessKey = "\nAccessKey: " + entry.AccessKey
+	}
+
 	if len(entry.Trace.Variables) > 0 {
 		tagString = "\n       " + tagString
 	}
 
 	var msg = color.FgRed(color.Bold(entry.Trace.Message))
-	var output = fmt.Sprintf("\n%s\n%s%s%s%s%s%s\nError: %s%s\n%s",
-		apiString, timeString, deploymentID, requestID, remoteHost, host, userAgent,
+	var output = fmt.Sprintf("\n%s\n%s%s%s%s%s%s%s\nError: %s%s\n%s",
+		apiString, timeString, deploymentID, requestID, remoteHost, host, userAgent, accessKey,
 		msg, tagString, strings.Join(trace, "\n"))
 
 	fmt.Println(output)
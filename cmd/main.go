@@ -90,6 +90,9 @@ func newApp(name string) *cli.App {
 	// Register all commands.
 	// registerCommand(serverCmd)
 	registerCommand(radioCmd)
+	registerCommand(adminCmd)
+	registerCommand(configCmd)
+	registerCommand(replayCmd)
 
 	// Set up app.
 	cli.HelpFlag = cli.BoolFlag{
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"sync"
+
+	"github.com/minio/radio/cmd/config/cache"
+)
+
+// number of least recently used entries sampled to find the least
+// frequently used entry with the lfu policy.
+const memoryCacheLFUSamples = 5
+
+// memoryCache - size capped in-memory cache tier in front of the cache
+// drives, holding small objects only. Entries are evicted in least
+// recently used order once the tier is full, or least frequently used
+// order with the lfu policy.
+type memoryCache struct {
+	mu            sync.Mutex
+	maxSize       int64
+	maxObjectSize int64
+	size          int64
+	lru           *list.List
+	entries       map[memoryCacheKey]*list.Element
+	// generation is incremented by every invalidation, fills started
+	// before an invalidation are dropped.
+	generation uint64
+
+	freq          *tinyLFU
+	lfu           bool
+	admitFrequent bool
+}
+
+type memoryCacheKey struct {
+	bucket string
+	object string
+}
+
+type memoryCacheEntry struct {
+	key memoryCacheKey
+	// freqKey - key of the object in the frequency sketch.
+	freqKey string
+	objInfo ObjectInfo
+	data    []byte
+}
+
+func newMemoryCache(config cache.Config, freq *tinyLFU) *memoryCache {
+	return &memoryCache{
+		maxSize:       config.RAMSize,
+		maxObjectSize: config.RAMMaxObjectSize,
+		lru:           list.New(),
+		entries:       make(map[memoryCacheKey]*list.Element),
+		freq:          freq,
+		lfu:           config.Policy == cache.PolicyLFU,
+		admitFrequent: config.Admission == cache.AdmissionTinyLFU,
+	}
+}
+
+// Get - returns the cached object info and data, the returned
+// data must not be modified.
+func (m *memoryCache) Get(bucket, object string) (ObjectInfo, []byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[memoryCacheKey{bucket, object}]
+	if !ok {
+		return ObjectInfo{}, nil, false
+	}
+	m.lru.MoveToFront(elem)
+	entry := elem.Value.(*memoryCacheEntry)
+	return entry.objInfo, entry.data, true
+}
+
+// Generation - returns the current invalidation generation, to be passed
+// to SetIfValid.
+func (m *memoryCache) Generation() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.generation
+}
+
+// SetIfValid - caches data of an object read from the backend, replacing
+// any previous entry, unless any object was invalidated since generation
+// was obtained as the data might be stale then.
+func (m *memoryCache) SetIfValid(bucket, object string, objInfo ObjectInfo, data []byte, generation uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.generation != generation {
+		return
+	}
+	m.set(bucket, object, objInfo, data)
+}
+
+// set - caches data of an object, the caller holds the lock.
+func (m *memoryCache) set(bucket, object string, objInfo ObjectInfo, data []byte) {
+	size := int64(len(data))
+	if size > m.maxObjectSize || size > m.maxSize {
+		return
+	}
+	key := memoryCacheKey{bucket, object}
+	freqKey := cacheKey(bucket, object)
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+	for m.size+size > m.maxSize {
+		victim := m.victim()
+		if m.admitFrequent && !m.freq.Admit(freqKey, victim.Value.(*memoryCacheEntry).freqKey) {
+			return
+		}
+		m.remove(victim)
+	}
+	m.entries[key] = m.lru.PushFront(&memoryCacheEntry{
+		key:     key,
+		freqKey: freqKey,
+		objInfo: objInfo,
+		data:    data,
+	})
+	m.size += size
+}
+
+// Delete - removes an object from the cache.
+func (m *memoryCache) Delete(bucket, object string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.generation++
+	if elem, ok := m.entries[memoryCacheKey{bucket, object}]; ok {
+		m.remove(elem)
+	}
+}
+
+// victim - returns the entry to evict next, with the lfu policy the
+// least frequently used among the least recently used entries.
+func (m *memoryCache) victim() *list.Element {
+	victim := m.lru.Back()
+	if !m.lfu {
+		return victim
+	}
+	minFreq := m.freq.Estimate(victim.Value.(*memoryCacheEntry).freqKey)
+	elem := victim.Prev()
+	for i := 1; elem != nil && i < memoryCacheLFUSamples; i++ {
+		if freq := m.freq.Estimate(elem.Value.(*memoryCacheEntry).freqKey); freq < minFreq {
+			victim, minFreq = elem, freq
+		}
+		elem = elem.Prev()
+	}
+	return victim
+}
+
+// Purge - removes all objects of bucket under prefix, an empty bucket
+// removes all objects.
+func (m *memoryCache) Purge(bucket, prefix string) (purged int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.generation++
+	for elem := m.lru.Front(); elem != nil; {
+		next := elem.Next()
+		key := elem.Value.(*memoryCacheEntry).key
+		if bucket == "" || (key.bucket == bucket && HasPrefix(key.object, prefix)) {
+			m.remove(elem)
+			purged++
+		}
+		elem = next
+	}
+	return purged
+}
+
+func (m *memoryCache) remove(elem *list.Element) {
+	entry := m.lru.Remove(elem).(*memoryCacheEntry)
+	delete(m.entries, entry.key)
+	m.size -= int64(len(entry.data))
+}
+
+// Fill - wraps the reader of a complete object so the object is cached
+// once it was read entirely, partial reads leave the cache untouched.
+// Objects invalidated while being read are not cached.
+func (m *memoryCache) Fill(bucket, object string, gr *GetObjectReader, generation uint64) {
+	size := gr.ObjInfo.Size
+	if size < 0 || size > m.maxObjectSize {
+		return
+	}
+	objInfo := gr.ObjInfo
+	gr.pReader = &memoryCacheFiller{
+		r:   gr.pReader,
+		buf: bytes.NewBuffer(make([]byte, 0, size)),
+		done: func(data []byte) {
+			if int64(len(data)) == size {
+				m.SetIfValid(bucket, object, objInfo, data, generation)
+			}
+		},
+	}
+}
+
+// memoryCacheFiller - io.Reader copying everything read into buf, done
+// is called once the underlying reader returns io.EOF.
+type memoryCacheFiller struct {
+	r    io.Reader
+	buf  *bytes.Buffer
+	done func(data []byte)
+}
+
+func (f *memoryCacheFiller) Read(p []byte) (n int, err error) {
+	n, err = f.r.Read(p)
+	if f.done == nil {
+		return n, err
+	}
+	if f.buf.Len()+n > f.buf.Cap() {
+		// More data than announced by the object info, skip caching.
+		f.done, f.buf = nil, nil
+		return n, err
+	}
+	f.buf.Write(p[:n])
+	if err == io.EOF {
+		f.done(f.buf.Bytes())
+		f.done = nil
+	}
+	return n, err
+}
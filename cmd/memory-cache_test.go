@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/minio/radio/cmd/config/cache"
+)
+
+func newTestMemoryCache() *memoryCache {
+	return newMemoryCache(cache.Config{RAMSize: 1 << 20, RAMMaxObjectSize: 1 << 10}, nil)
+}
+
+// Tests that purging a bucket only removes its objects under prefix.
+func TestMemoryCachePurge(t *testing.T) {
+	m := newTestMemoryCache()
+	objects := []memoryCacheKey{
+		{"bucket1", "photos/a"},
+		{"bucket1", "photos/b"},
+		{"bucket1", "videos/a"},
+		{"bucket2", "photos/a"},
+	}
+	fill := func() {
+		for _, key := range objects {
+			m.SetIfValid(key.bucket, key.object, ObjectInfo{}, []byte(key.object), m.Generation())
+		}
+	}
+
+	testCases := []struct {
+		bucket, prefix string
+		purged         int
+		kept           []memoryCacheKey
+	}{
+		{"bucket1", "photos/", 2, []memoryCacheKey{{"bucket1", "videos/a"}, {"bucket2", "photos/a"}}},
+		{"bucket1", "", 3, []memoryCacheKey{{"bucket2", "photos/a"}}},
+		{"bucket2", "photos/", 1, objects[:3]},
+		{"bucket3", "", 0, objects},
+		{"", "", 4, nil},
+	}
+
+	for i, testCase := range testCases {
+		fill()
+		if purged := m.Purge(testCase.bucket, testCase.prefix); purged != testCase.purged {
+			t.Errorf("Test %d: Expected %d objects purged, got %d", i+1, testCase.purged, purged)
+		}
+		if len(m.entries) != len(testCase.kept) {
+			t.Errorf("Test %d: Expected %d objects kept, got %d", i+1, len(testCase.kept), len(m.entries))
+		}
+		for _, key := range testCase.kept {
+			if _, _, ok := m.Get(key.bucket, key.object); !ok {
+				t.Errorf("Test %d: Expected %s/%s to be kept", i+1, key.bucket, key.object)
+			}
+		}
+	}
+}
+
+// Tests that an object invalidated while it is read from the backend
+// is not cached with the data read before the invalidation.
+func TestMemoryCacheFillInvalidated(t *testing.T) {
+	data := []byte("stale object data")
+	testCases := []struct {
+		invalidate func(m *memoryCache)
+		cached     bool
+	}{
+		{func(m *memoryCache) {}, true},
+		{func(m *memoryCache) { m.Delete("bucket", "object") }, false},
+		{func(m *memoryCache) { m.Delete("bucket", "other") }, false},
+		{func(m *memoryCache) { m.Purge("bucket", "") }, false},
+	}
+
+	for i, testCase := range testCases {
+		m := newTestMemoryCache()
+		gr := &GetObjectReader{
+			ObjInfo: ObjectInfo{Bucket: "bucket", Name: "object", Size: int64(len(data))},
+			pReader: bytes.NewReader(data),
+		}
+		m.Fill("bucket", "object", gr, m.Generation())
+
+		testCase.invalidate(m)
+		if _, err := ioutil.ReadAll(gr.pReader); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+
+		_, cached, ok := m.Get("bucket", "object")
+		if ok != testCase.cached {
+			t.Errorf("Test %d: Expected cached %t, got %t", i+1, testCase.cached, ok)
+		}
+		if ok && !bytes.Equal(cached, data) {
+			t.Errorf("Test %d: Expected cached data %q, got %q", i+1, data, cached)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// metadataRulesConfig - rules transforming the metadata of objects written
+// to the remotes of a mirror bucket, applied in the order remove, rename
+// and set. Remove entries ending with * match all keys with that prefix,
+// values of set may contain template variables such as {{requester}}.
+type metadataRulesConfig struct {
+	Remove []string          `yaml:"remove"`
+	Rename map[string]string `yaml:"rename"`
+	Set    map[string]string `yaml:"set"`
+}
+
+// Template variables of metadata values set by rules.
+var metadataRuleVars = map[string]bool{
+	"requester": true,
+	"source_ip": true,
+	"bucket":    true,
+	"object":    true,
+	"date":      true,
+	"time":      true,
+}
+
+var metadataRuleVarRegexp = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// isMetadataRuleKey - returns true if rules may modify key, user metadata
+// and the supported headers except metadata reserved by radio.
+func isMetadataRuleKey(key string) bool {
+	if isReservedMetadata(key) {
+		return false
+	}
+	for _, prefix := range userMetadataKeyPrefixes {
+		if strings.HasPrefix(strings.ToLower(key), strings.ToLower(prefix)) {
+			return len(key) > len(prefix)
+		}
+	}
+	for _, supportedHeader := range supportedHeaders {
+		if strings.EqualFold(key, supportedHeader) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMetadataRulePattern - returns true if pattern is a key rules may
+// modify or, ending with *, a prefix of user metadata keys.
+func isMetadataRulePattern(pattern string) bool {
+	prefix := strings.TrimSuffix(pattern, "*")
+	if prefix == pattern {
+		return isMetadataRuleKey(pattern)
+	}
+	for _, userPrefix := range userMetadataKeyPrefixes {
+		if strings.HasPrefix(strings.ToLower(prefix), strings.ToLower(userPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMetadataRules - validates the keys and template variables of
+// the metadata rules of a bucket.
+func validateMetadataRules(errs *radioConfigErrors, path string, rules metadataRulesConfig) {
+	for i, pattern := range rules.Remove {
+		if !isMetadataRulePattern(pattern) {
+			errs.add(fmt.Sprintf("%s.remove[%d]", path, i), "%q is not user metadata or a supported header", pattern)
+		}
+	}
+	for from, to := range rules.Rename {
+		if !isMetadataRuleKey(from) || !isMetadataRuleKey(to) {
+			errs.add(path+".rename", "%q to %q must both be user metadata or supported headers", from, to)
+		}
+	}
+	for key, value := range rules.Set {
+		if !isMetadataRuleKey(key) {
+			errs.add(path+".set", "%q is not user metadata or a supported header", key)
+		}
+		for _, match := range metadataRuleVarRegexp.FindAllStringSubmatch(value, -1) {
+			if !metadataRuleVars[match[1]] {
+				errs.add(path+".set."+key, "unknown template variable %q", match[1])
+			}
+		}
+	}
+}
+
+// matchMetadataKey - returns true if key matches pattern, case
+// insensitive.
+func matchMetadataKey(pattern, key string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(strings.ToLower(key), strings.ToLower(strings.TrimSuffix(pattern, "*")))
+	}
+	return strings.EqualFold(pattern, key)
+}
+
+// apply - returns a copy of metadata transformed by the rules, keys are
+// canonicalized. Metadata reserved by radio is never modified.
+func (rules metadataRulesConfig) apply(ctx context.Context, bucket, object string, metadata map[string]string) map[string]string {
+	if len(rules.Remove) == 0 && len(rules.Rename) == 0 && len(rules.Set) == 0 {
+		return metadata
+	}
+
+	mm := make(map[string]string, len(metadata)+len(rules.Set))
+	for k, v := range metadata {
+		mm[http.CanonicalHeaderKey(k)] = v
+	}
+
+	for _, pattern := range rules.Remove {
+		for k := range mm {
+			if !isReservedMetadata(k) && matchMetadataKey(pattern, k) {
+				delete(mm, k)
+			}
+		}
+	}
+	for from, to := range rules.Rename {
+		from = http.CanonicalHeaderKey(from)
+		if v, ok := mm[from]; ok {
+			delete(mm, from)
+			mm[http.CanonicalHeaderKey(to)] = v
+		}
+	}
+
+	if len(rules.Set) > 0 {
+		reqInfo := logger.GetReqInfo(ctx)
+		now := UTCNow()
+		vars := map[string]string{
+			"requester": reqInfo.AccessKey,
+			"source_ip": reqInfo.RemoteHost,
+			"bucket":    bucket,
+			"object":    object,
+			"date":      now.Format("2006-01-02"),
+			"time":      now.Format(time.RFC3339),
+		}
+		for k, v := range rules.Set {
+			mm[http.CanonicalHeaderKey(k)] = metadataRuleVarRegexp.ReplaceAllStringFunc(v, func(match string) string {
+				return vars[match[2:len(match)-2]]
+			})
+		}
+	}
+	return mm
+}
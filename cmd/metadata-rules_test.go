@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+func TestMetadataRulesApply(t *testing.T) {
+	rules := metadataRulesConfig{
+		Remove: []string{"x-amz-meta-pii-*", "content-disposition"},
+		Rename: map[string]string{"x-amz-meta-old": "x-amz-meta-new"},
+		Set: map[string]string{
+			"x-amz-meta-cost-center": "media",
+			"x-amz-meta-uploader":    "{{requester}}@{{source_ip}}/{{bucket}}/{{object}}",
+		},
+	}
+	ctx := logger.SetReqInfo(context.Background(), &logger.ReqInfo{AccessKey: "alice", RemoteHost: "10.0.0.1"})
+	metadata := map[string]string{
+		"content-type":             "text/plain",
+		"content-disposition":      "attachment; filename=ssn.txt",
+		"X-Amz-Meta-Pii-Email":     "alice@example.com",
+		"x-amz-meta-pii-phone":     "555",
+		"X-Amz-Meta-Old":           "value",
+		"X-Amz-Meta-Radio-Tag":     "tag",
+		"X-Amz-Meta-Cost-Center":   "client",
+		"x-amz-meta-unrelated-key": "kept",
+	}
+	expected := map[string]string{
+		"Content-Type":             "text/plain",
+		"X-Amz-Meta-New":           "value",
+		"X-Amz-Meta-Radio-Tag":     "tag",
+		"X-Amz-Meta-Cost-Center":   "media",
+		"X-Amz-Meta-Uploader":      "alice@10.0.0.1/bucket/dir/object",
+		"X-Amz-Meta-Unrelated-Key": "kept",
+	}
+	if mm := rules.apply(ctx, "bucket", "dir/object", metadata); !reflect.DeepEqual(mm, expected) {
+		t.Errorf("expected %v, got %v", expected, mm)
+	}
+	if _, ok := metadata["X-Amz-Meta-Pii-Email"]; !ok {
+		t.Errorf("metadata of the client was modified")
+	}
+
+	// The radio tag is reserved even for matching patterns.
+	rules = metadataRulesConfig{Remove: []string{"x-amz-meta-*"}}
+	mm := rules.apply(ctx, "bucket", "object", map[string]string{"X-Amz-Meta-Radio-Tag": "tag", "X-Amz-Meta-A": "a"})
+	if !reflect.DeepEqual(mm, map[string]string{"X-Amz-Meta-Radio-Tag": "tag"}) {
+		t.Errorf("expected only the radio tag, got %v", mm)
+	}
+}
+
+func TestValidateMetadataRules(t *testing.T) {
+	testCases := []struct {
+		rules metadataRulesConfig
+		valid bool
+	}{
+		{metadataRulesConfig{}, true},
+		{metadataRulesConfig{Remove: []string{"x-amz-meta-pii-*", "Cache-Control"}}, true},
+		{metadataRulesConfig{Set: map[string]string{"x-amz-meta-date": "{{date}}T{{time}}"}}, true},
+		{metadataRulesConfig{Rename: map[string]string{"x-amz-meta-a": "content-language"}}, true},
+		{metadataRulesConfig{Remove: []string{"authorization"}}, false},
+		{metadataRulesConfig{Remove: []string{"*"}}, false},
+		{metadataRulesConfig{Set: map[string]string{"x-amz-meta-radio-tag": "tag"}}, false},
+		{metadataRulesConfig{Set: map[string]string{"x-amz-meta-": "value"}}, false},
+		{metadataRulesConfig{Set: map[string]string{"x-amz-meta-user": "{{user}}"}}, false},
+		{metadataRulesConfig{Rename: map[string]string{"x-amz-meta-a": "x-amz-meta-radio-a"}}, false},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateMetadataRules(&errs, "mirror[0].metadata", testCase.rules)
+		if valid := len(errs) == 0; valid != testCase.valid {
+			t.Errorf("Test %d: expected valid %v, got %v", i+1, testCase.valid, errs)
+		}
+	}
+}
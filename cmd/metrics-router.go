@@ -8,9 +8,15 @@ const (
 	prometheusMetricsPath = "/prometheus/metrics"
 )
 
-// registerMetricsRouter - add handler functions for metrics.
-func registerMetricsRouter(router *mux.Router) {
+// registerMetricsRouter - add handler functions for metrics, served to the
+// scrapers allowed by mcfg.
+func registerMetricsRouter(router *mux.Router, mcfg metricsConfig) error {
+	handler, err := metricsAuthHandler(mcfg, metricsHandler())
+	if err != nil {
+		return err
+	}
 	// metrics router
 	metricsRouter := router.NewRoute().PathPrefix(minioReservedBucketPath).Subrouter()
-	metricsRouter.Handle(prometheusMetricsPath, metricsHandler())
+	metricsRouter.Handle(prometheusMetricsPath, handler)
+	return nil
 }
@@ -18,6 +18,125 @@ var (
 		},
 		[]string{"api"},
 	)
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_requests_by_status_total",
+			Help: "Total number of S3 requests by API, method and response status class",
+		},
+		[]string{"api", "method", "status"},
+	)
+	httpRequestsCanceled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_requests_canceled_total",
+			Help: "Total number of S3 requests canceled by the client",
+		},
+		[]string{"api"},
+	)
+	httpTransfersCanceled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_transfers_canceled_total",
+			Help: "Total number of S3 uploads and downloads abandoned by the client",
+		},
+		[]string{"api", "direction"},
+	)
+	httpRejectedBeforeBody = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_uploads_rejected_before_body_total",
+			Help: "Total number of S3 uploads expecting 100 Continue rejected before the body was sent",
+		},
+		[]string{"api"},
+	)
+	httpSlowRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_requests_slow_total",
+			Help: "Total number of S3 requests exceeding the slow request threshold",
+		},
+		[]string{"api"},
+	)
+	shadowRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_requests_total",
+			Help: "Total number of requests duplicated to shadow remotes by API, remote and result",
+		},
+		[]string{"api", "remote", "result"},
+	)
+	shadowReadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "shadow_read_duration_seconds",
+			Help:    "Time taken by shadow remotes to serve the sampled reads",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"remote"},
+	)
+	contentScanBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "content_scan_bytes_total",
+			Help: "Total number of bytes of uploads sent to the content scanner",
+		},
+		[]string{"bucket"},
+	)
+	contentScanResults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "content_scan_results_total",
+			Help: "Total number of uploads scanned by bucket and result",
+		},
+		[]string{"bucket", "result"},
+	)
+	integritySamples = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "integrity_samples_total",
+			Help: "Total number of objects sampled for integrity by bucket and result",
+		},
+		[]string{"bucket", "result"},
+	)
+	integrityMismatches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "integrity_mismatches_total",
+			Help: "Total number of sampled copies differing from the copy held by most remotes",
+		},
+		[]string{"bucket", "remote"},
+	)
+	fencedWrites = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fenced_writes_total",
+			Help: "Total number of writes rejected by the write fence of their bucket",
+		},
+		[]string{"bucket"},
+	)
+	requestStatsRecords = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "request_stats_records_total",
+			Help: "Total number of request records exported to Kafka by result",
+		},
+		[]string{"result"},
+	)
+	fanoutBufferBytes = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "fanout_buffer_bytes",
+			Help: "Memory buffering uploads to remotes lagging behind the others",
+		},
+		fanoutBufferedBytes,
+	)
+	fanoutSpilledBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fanout_spilled_bytes_total",
+			Help: "Total number of bytes of uploads to lagging remotes buffered on disk",
+		},
+	)
+	putCoalesced = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_uploads_coalesced_total",
+			Help: "Total number of S3 uploads answered with the result of an identical upload in flight",
+		},
+		[]string{"bucket", "result"},
+	)
+	putCoalescedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "s3_uploads_coalesced_bytes_total",
+			Help: "Total number of bytes of coalesced S3 uploads not sent to the remotes",
+		},
+		[]string{"bucket"},
+	)
 	minioVersionInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "minio",
@@ -35,6 +154,23 @@ var (
 
 func init() {
 	prometheus.MustRegister(httpRequestsDuration)
+	prometheus.MustRegister(httpRequestsTotal)
+	prometheus.MustRegister(httpRequestsCanceled)
+	prometheus.MustRegister(httpTransfersCanceled)
+	prometheus.MustRegister(httpRejectedBeforeBody)
+	prometheus.MustRegister(httpSlowRequests)
+	prometheus.MustRegister(shadowRequestsTotal)
+	prometheus.MustRegister(shadowReadDuration)
+	prometheus.MustRegister(contentScanBytes)
+	prometheus.MustRegister(contentScanResults)
+	prometheus.MustRegister(integritySamples)
+	prometheus.MustRegister(integrityMismatches)
+	prometheus.MustRegister(fencedWrites)
+	prometheus.MustRegister(requestStatsRecords)
+	prometheus.MustRegister(fanoutBufferBytes)
+	prometheus.MustRegister(fanoutSpilledBytes)
+	prometheus.MustRegister(putCoalesced)
+	prometheus.MustRegister(putCoalescedBytes)
 	prometheus.MustRegister(newMinioCollector())
 	prometheus.MustRegister(minioVersionInfo)
 }
@@ -65,6 +201,42 @@ func (c *minioCollector) Collect(ch chan<- prometheus.Metric) {
 	// Expose Radio's version information
 	minioVersionInfo.WithLabelValues(Version, CommitID).Set(float64(1.0))
 
+	// Go runtime and process metrics are exported by the default registry.
+	if httpServer := newHTTPServerFn(); httpServer != nil {
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("s3", "connections", "current"),
+				"Total number of open client connections of current Radio server instance",
+				nil, nil),
+			prometheus.GaugeValue,
+			float64(httpServer.GetConnCount()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("s3", "requests", "inflight"),
+				"Total number of requests in progress in current Radio server instance",
+				nil, nil),
+			prometheus.GaugeValue,
+			float64(httpServer.GetRequestCount()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("s3", "connections", "rejected_total"),
+				"Total number of client connections closed as their client IP reached max_conns_per_ip",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(httpServer.GetRejectedConnCount()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("s3", "requests", "rejected_total"),
+				"Total number of requests rejected for more than max_header_count header values",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(httpServer.GetRejectedRequestCount()),
+		)
+	}
+
 	// Fetch disk space info
 	objLayer := newObjectLayerFn()
 	// Service not initialized yet
@@ -157,6 +329,30 @@ func (c *minioCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.CounterValue,
 			float64(newCachedObjectLayerFn().CacheStats().getBytesServed()),
 		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("cache", "ram_hits", "total"),
+				"Total number of in-memory cache hits in current Radio instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(newCachedObjectLayerFn().CacheStats().getRAMHits()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("cache", "prefetch_hits", "total"),
+				"Total number of ranges served from prefetched data in current Radio instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(newCachedObjectLayerFn().CacheStats().getPrefetchHits()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("cache", "prefetch_misses", "total"),
+				"Total number of sequential ranges not prefetched in time in current Radio instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(newCachedObjectLayerFn().CacheStats().getPrefetchMisses()),
+		)
 	}
 }
 
@@ -170,6 +366,27 @@ func metricsHandler() http.Handler {
 	err = registry.Register(httpRequestsDuration)
 	logger.LogIf(context.Background(), err)
 
+	err = registry.Register(httpRequestsTotal)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(httpRequestsCanceled)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(httpTransfersCanceled)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(httpRejectedBeforeBody)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(httpSlowRequests)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(shadowRequestsTotal)
+	logger.LogIf(context.Background(), err)
+
+	err = registry.Register(shadowReadDuration)
+	logger.LogIf(context.Background(), err)
+
 	err = registry.Register(newMinioCollector())
 	logger.LogIf(context.Background(), err)
 
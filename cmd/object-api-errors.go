@@ -172,6 +172,33 @@ func (e ObjectAlreadyExists) Error() string {
 	return "Object: " + e.Bucket + "#" + e.Object + " already exists"
 }
 
+// InvalidObjectState the operation is not valid for the storage class
+// of the object.
+type InvalidObjectState GenericError
+
+func (e InvalidObjectState) Error() string {
+	return "Operation not valid for the current state of object: " + e.Bucket + "#" + e.Object
+}
+
+// ObjectRestoreInProgress a restore of the object is already in progress.
+type ObjectRestoreInProgress GenericError
+
+func (e ObjectRestoreInProgress) Error() string {
+	return "Object restore is already in progress: " + e.Bucket + "#" + e.Object
+}
+
+// InvalidWriteOffset the offset of an append is not the size of the object.
+type InvalidWriteOffset struct {
+	Bucket string
+	Object string
+	Offset int64
+	Size   int64
+}
+
+func (e InvalidWriteOffset) Error() string {
+	return fmt.Sprintf("Write offset %d of %s#%s does not match its size %d", e.Offset, e.Bucket, e.Object, e.Size)
+}
+
 // ObjectExistsAsDirectory object already exists as a directory.
 type ObjectExistsAsDirectory GenericError
 
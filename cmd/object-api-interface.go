@@ -19,6 +19,7 @@ type ObjectOptions struct {
 	ServerSideEncryption encrypt.ServerSide
 	UserDefined          map[string]string
 	CheckCopyPrecondFn   CheckCopyPreconditionFn
+	Checksum             *objectChecksum // additional checksum of the upload
 }
 
 // LockType represents required locking for ObjectLayer operations
@@ -57,6 +58,8 @@ type ObjectLayer interface {
 	CopyObject(ctx context.Context, srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo, srcOpts, dstOpts ObjectOptions) (objInfo ObjectInfo, err error)
 	DeleteObject(ctx context.Context, bucket, object string) error
 	DeleteObjects(ctx context.Context, bucket string, objects []string) ([]error, error)
+	RestoreObject(ctx context.Context, bucket, object string, request []byte) (restored bool, err error)
+	AppendObject(ctx context.Context, bucket, object string, offset int64, data *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error)
 
 	// Multipart operations.
 	ListMultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error)
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+const (
+	// Payload hash of aws-chunked uploads without chunk signatures
+	// whose checksum follows the data as a trailer.
+	unsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	// Payload hash of aws-chunked uploads with signed chunks and a
+	// signed trailer, not supported.
+	streamingContentSHA256Trailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+
+	// Additional checksums of objects are stored on the remotes as
+	// user metadata, for example X-Amz-Meta-Radio-Checksum-Crc32c.
+	checksumMetaPrefix = "X-Amz-Meta-Radio-Checksum-"
+
+	// maximum length of a chunk header or trailer line
+	maxTrailerLineLength = 4096
+
+	// Uploads with a trailing checksum up to this size are spooled
+	// in memory, larger uploads to a temporary file.
+	maxSpoolMemorySize = 4 << 20
+)
+
+// Supported additional checksums by their header.
+var checksumHashes = map[string]func() hash.Hash{
+	xhttp.AmzChecksumCRC32: func() hash.Hash {
+		return crc32.NewIEEE()
+	},
+	xhttp.AmzChecksumCRC32C: func() hash.Hash {
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	},
+	xhttp.AmzChecksumSHA1:   sha1.New,
+	xhttp.AmzChecksumSHA256: sha256.New,
+}
+
+// ChecksumMismatch - the additional checksum of an upload did not match.
+type ChecksumMismatch struct {
+	Header string
+}
+
+func (e ChecksumMismatch) Error() string {
+	return "The " + e.Header + " you specified did not match the calculated checksum."
+}
+
+// objectChecksum - additional checksum of an upload, the expected value
+// comes from its x-amz-checksum-* header or from the trailer of an
+// aws-chunked body.
+type objectChecksum struct {
+	header   string
+	expected string
+	trailing bool
+	hasher   hash.Hash
+}
+
+// isRequestUnsignedTrailer - returns true if the body is aws-chunked with
+// a trailing checksum.
+func isRequestUnsignedTrailer(r *http.Request) bool {
+	return r.Header.Get(xhttp.AmzContentSha256) == unsignedPayloadTrailer
+}
+
+// getObjectChecksum - returns the additional checksum of an upload, nil
+// if the client did not send any.
+func getObjectChecksum(r *http.Request) (*objectChecksum, APIErrorCode) {
+	var c *objectChecksum
+	for header, newHash := range checksumHashes {
+		value := r.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		hasher := newHash()
+		sum, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(sum) != hasher.Size() || c != nil {
+			return nil, ErrInvalidChecksum
+		}
+		c = &objectChecksum{header: header, expected: value, hasher: hasher}
+	}
+
+	if trailer := r.Header.Get(xhttp.AmzTrailer); trailer != "" {
+		header := http.CanonicalHeaderKey(strings.TrimSpace(trailer))
+		newHash, ok := checksumHashes[header]
+		if !ok || c != nil {
+			return nil, ErrInvalidChecksum
+		}
+		c = &objectChecksum{header: header, trailing: true, hasher: newHash()}
+	}
+	return c, ErrNone
+}
+
+// newChecksumReader - decodes aws-chunked bodies with trailing checksums
+// and verifies the additional checksum of an upload, if any, once the
+// reader reaches EOF. Signed chunks with a signed trailer are rejected
+// with ErrNotImplemented.
+func newChecksumReader(r *http.Request, reader io.Reader) (io.Reader, *objectChecksum, APIErrorCode) {
+	if r.Header.Get(xhttp.AmzContentSha256) == streamingContentSHA256Trailer {
+		return nil, nil, ErrNotImplemented
+	}
+	checksum, s3Err := getObjectChecksum(r)
+	if s3Err != ErrNone {
+		return nil, nil, s3Err
+	}
+	if isRequestUnsignedTrailer(r) {
+		reader = &unsignedTrailerReader{
+			r:        bufio.NewReader(reader),
+			checksum: checksum,
+		}
+	}
+	if checksum != nil {
+		reader = &checksumReader{r: reader, checksum: checksum}
+	}
+	return reader, checksum, ErrNone
+}
+
+// spoolUpload - reads the body of an upload of size bytes entirely and
+// returns a reader replaying it, reading the body verifies its checksums.
+// cleanup releases the spooled body.
+func spoolUpload(r io.Reader, size int64) (spooled io.ReadSeeker, cleanup func(), err error) {
+	if size >= 0 && size <= maxSpoolMemorySize {
+		buf := bytes.NewBuffer(make([]byte, 0, size))
+		if _, err = io.Copy(buf, r); err != nil {
+			return nil, nil, err
+		}
+		return bytes.NewReader(buf.Bytes()), func() {}, nil
+	}
+
+	f, err := ioutil.TempFile("", "radio-upload-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err = copyBuffer(f, r); err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return f, cleanup, nil
+}
+
+// Value - returns the base64 encoded checksum of the data read so far.
+func (c *objectChecksum) Value() string {
+	return base64.StdEncoding.EncodeToString(c.hasher.Sum(nil))
+}
+
+// MetaKey - returns the user metadata key storing the checksum.
+func (c *objectChecksum) MetaKey() string {
+	return checksumMetaPrefix + strings.TrimPrefix(c.header, "X-Amz-Checksum-")
+}
+
+type checksumReader struct {
+	r        io.Reader
+	checksum *objectChecksum
+}
+
+func (c *checksumReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.checksum.hasher.Write(p[:n])
+	if err == io.EOF && c.checksum.expected != c.checksum.Value() {
+		return n, ChecksumMismatch{Header: c.checksum.header}
+	}
+	return n, err
+}
+
+// unsignedTrailerReader - decodes an aws-chunked body without chunk
+// signatures and records the expected checksum from its trailer.
+type unsignedTrailerReader struct {
+	r        *bufio.Reader
+	checksum *objectChecksum
+	left     int64
+	err      error
+}
+
+func (u *unsignedTrailerReader) Read(p []byte) (n int, err error) {
+	if u.left == 0 && u.err == nil {
+		u.err = u.nextChunk()
+	}
+	if u.left == 0 {
+		return 0, u.err
+	}
+
+	if int64(len(p)) > u.left {
+		p = p[:u.left]
+	}
+	n, err = u.r.Read(p)
+	u.left -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		u.err = err
+		return n, err
+	}
+	if u.left == 0 {
+		// Every chunk ends in CRLF.
+		var line string
+		if line, u.err = u.readLine(); u.err == nil && line != "" {
+			u.err = errMalformedEncoding
+		}
+	}
+	return n, nil
+}
+
+// nextChunk - reads the next chunk header, the last chunk of size 0 is
+// followed by the trailers and an empty line.
+func (u *unsignedTrailerReader) nextChunk() error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+	if err != nil || size < 0 {
+		return errMalformedEncoding
+	}
+	if size > 0 {
+		u.left = size
+		return nil
+	}
+
+	for {
+		line, err = u.readLine()
+		if err != nil || line == "" {
+			if err == nil || err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return err
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return errMalformedEncoding
+		}
+		name := http.CanonicalHeaderKey(strings.TrimSpace(line[:i]))
+		if u.checksum != nil && u.checksum.trailing && name == u.checksum.header {
+			u.checksum.expected = strings.TrimSpace(line[i+1:])
+		}
+	}
+}
+
+// readLine - reads a CRLF terminated line, without the CRLF.
+func (u *unsignedTrailerReader) readLine() (string, error) {
+	var line []byte
+	for {
+		b, err := u.r.ReadByte()
+		if err == io.EOF {
+			if len(line) == 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", errMalformedEncoding
+		}
+		if err != nil {
+			return "", err
+		}
+		if b == '\n' {
+			if len(line) == 0 || line[len(line)-1] != '\r' {
+				return "", errMalformedEncoding
+			}
+			return string(line[:len(line)-1]), nil
+		}
+		if len(line) >= maxTrailerLineLength {
+			return "", errLineTooLong
+		}
+		line = append(line, b)
+	}
+}
+
+// setChecksumHeaders - returns the stored additional checksums of an
+// object if the client enabled x-amz-checksum-mode, checksums do not
+// apply to range requests.
+func setChecksumHeaders(w http.ResponseWriter, r *http.Request, objInfo ObjectInfo, rs *HTTPRangeSpec) {
+	if rs != nil || !strings.EqualFold(r.Header.Get(xhttp.AmzChecksumMode), "ENABLED") {
+		return
+	}
+	for k, v := range objInfo.UserDefined {
+		if HasPrefix(k, checksumMetaPrefix) {
+			w.Header().Set("X-Amz-Checksum-"+strings.TrimPrefix(k, checksumMetaPrefix), v)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+// Tests that spooled uploads replay the body and fail on a checksum
+// mismatch before anything is sent to the remotes.
+func TestSpoolUpload(t *testing.T) {
+	small := []byte("radio")
+	large := bytes.Repeat([]byte("r"), maxSpoolMemorySize+1)
+	sum := func(data []byte) string {
+		s := sha256.Sum256(data)
+		return base64.StdEncoding.EncodeToString(s[:])
+	}
+
+	testCases := []struct {
+		data     []byte
+		expected string
+		err      error
+	}{
+		{small, sum(small), nil},
+		{large, sum(large), nil},
+		{small, sum(large), ChecksumMismatch{Header: xhttp.AmzChecksumSHA256}},
+		{large, sum(small), ChecksumMismatch{Header: xhttp.AmzChecksumSHA256}},
+	}
+
+	for i, testCase := range testCases {
+		checksum := &objectChecksum{header: xhttp.AmzChecksumSHA256, trailing: true, hasher: sha256.New()}
+		reader := &checksumReader{r: bytes.NewReader(testCase.data), checksum: checksum}
+		// The trailer is parsed by the time the body reaches EOF.
+		checksum.expected = testCase.expected
+
+		spooled, cleanup, err := spoolUpload(reader, int64(len(testCase.data)))
+		if err != testCase.err {
+			t.Fatalf("Test %d: Expected error %v, got %v", i+1, testCase.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		got, err := ioutil.ReadAll(spooled)
+		cleanup()
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !bytes.Equal(got, testCase.data) {
+			t.Errorf("Test %d: Spooled body does not match the upload", i+1)
+		}
+	}
+}
+
+// Tests the checksum headers accepted with uploads.
+func TestNewChecksumReader(t *testing.T) {
+	crc32c := base64.StdEncoding.EncodeToString([]byte{0, 0, 0, 0})
+	testCases := []struct {
+		header   http.Header
+		checksum string
+		s3Err    APIErrorCode
+	}{
+		{http.Header{}, "", ErrNone},
+		{http.Header{xhttp.AmzChecksumCRC32C: []string{crc32c}}, xhttp.AmzChecksumCRC32C, ErrNone},
+		{http.Header{xhttp.AmzContentSha256: []string{unsignedPayloadTrailer},
+			xhttp.AmzTrailer: []string{"x-amz-checksum-crc32"}}, xhttp.AmzChecksumCRC32, ErrNone},
+		// Signed trailers are not supported.
+		{http.Header{xhttp.AmzContentSha256: []string{streamingContentSHA256Trailer},
+			xhttp.AmzTrailer: []string{"x-amz-checksum-crc32"}}, "", ErrNotImplemented},
+		{http.Header{xhttp.AmzChecksumCRC32C: []string{"invalid"}}, "", ErrInvalidChecksum},
+		{http.Header{xhttp.AmzTrailer: []string{"x-amz-checksum-md5"}}, "", ErrInvalidChecksum},
+		{http.Header{xhttp.AmzChecksumCRC32C: []string{crc32c},
+			xhttp.AmzTrailer: []string{"x-amz-checksum-crc32"}}, "", ErrInvalidChecksum},
+	}
+
+	for i, testCase := range testCases {
+		r := &http.Request{Header: testCase.header}
+		_, checksum, s3Err := newChecksumReader(r, strings.NewReader(""))
+		if s3Err != testCase.s3Err {
+			t.Errorf("Test %d: Expected error %d, got %d", i+1, testCase.s3Err, s3Err)
+			continue
+		}
+		var header string
+		if checksum != nil {
+			header = checksum.header
+		}
+		if header != testCase.checksum {
+			t.Errorf("Test %d: Expected checksum %q, got %q", i+1, testCase.checksum, header)
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"net/url"
 	"sort"
 	"strconv"
-
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v6/pkg/encrypt"
@@ -208,6 +208,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
+	setChecksumHeaders(w, r, objInfo, rs)
 
 	setHeadGetRespHeaders(w, r.URL.Query())
 
@@ -218,7 +219,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		w.WriteHeader(http.StatusPartialContent)
 	}
 	// Write object content to response body
-	if _, err = io.Copy(httpWriter, gr); err != nil {
+	if _, err = copyBuffer(httpWriter, gr); err != nil {
 		if !httpWriter.HasWritten() && !statusCodeWritten { // write error response only if no data or headers has been written to client yet
 			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		}
@@ -298,6 +299,7 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
 		return
 	}
+	setChecksumHeaders(w, r, objInfo, rs)
 
 	// Set any additional requested response headers.
 	setHeadGetRespHeaders(w, r.URL.Query())
@@ -310,6 +312,156 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// Attributes which can be requested from GetObjectAttributes.
+var objectAttributes = map[string]bool{
+	"ETag":         true,
+	"Checksum":     true,
+	"ObjectParts":  true,
+	"StorageClass": true,
+	"ObjectSize":   true,
+}
+
+// parseObjectAttributes - returns the attributes requested by the comma
+// separated x-amz-object-attributes headers, at least one is required.
+func parseObjectAttributes(h http.Header) (map[string]bool, APIErrorCode) {
+	attrs := make(map[string]bool)
+	for _, value := range h[xhttp.AmzObjectAttributes] {
+		for _, attr := range strings.Split(value, ",") {
+			attr = strings.TrimSpace(attr)
+			if !objectAttributes[attr] {
+				return nil, ErrInvalidObjectAttributes
+			}
+			attrs[attr] = true
+		}
+	}
+	if len(attrs) == 0 {
+		return nil, ErrInvalidObjectAttributes
+	}
+	return attrs, ErrNone
+}
+
+// GetObjectAttributesHandler - GET Object attributes
+// -----------
+// Returns the requested attributes of an object without its data, radio
+// counts the parts of multipart objects but does not list them.
+func (api objectAPIHandlers) GetObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetObjectAttributes")
+
+	defer logger.AuditLog(w, r, "GetObjectAttributes")
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	bucket, object := request2BucketObjectName(r)
+
+	if vid := r.URL.Query().Get("versionId"); vid != "" && vid != "null" {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNoSuchVersion), r.URL)
+		return
+	}
+
+	getObjectInfo := objectAPI.GetObjectInfo
+	if api.CacheAPI() != nil {
+		getObjectInfo = api.CacheAPI().GetObjectInfo
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.GetObjectAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
+	attrs, s3Error := parseObjectAttributes(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
+	maxParts := maxPartsList
+	if v := r.Header.Get(xhttp.AmzMaxParts); v != "" {
+		var err error
+		if maxParts, err = strconv.Atoi(v); err != nil || maxParts < 0 {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMaxParts), r.URL)
+			return
+		}
+	}
+	var partNumberMarker int
+	if v := r.Header.Get(xhttp.AmzPartNumberMarker); v != "" {
+		var err error
+		if partNumberMarker, err = strconv.Atoi(v); err != nil || partNumberMarker < 0 {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPartNumberMarker), r.URL)
+			return
+		}
+	}
+
+	objInfo, err := getObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	if !objInfo.ModTime.IsZero() {
+		w.Header().Set(xhttp.LastModified, objInfo.ModTime.UTC().Format(http.TimeFormat))
+	}
+	response := generateGetObjectAttributesResponse(objInfo, attrs, partNumberMarker, maxParts)
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
+
+// restoreObjectAction - the policy action of RestoreObject.
+const restoreObjectAction = policy.Action("s3:RestoreObject")
+
+// PostRestoreObjectHandler - POST Object?restore
+// -----------
+// Restores a temporary copy of an archived object on the remotes holding
+// it archived, HEAD and GET report the status of the restore in the
+// x-amz-restore header.
+func (api objectAPIHandlers) PostRestoreObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "PostRestoreObject")
+
+	defer logger.AuditLog(w, r, "PostRestoreObject")
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	bucket, object := request2BucketObjectName(r)
+
+	if s3Error := checkRequestAuthType(ctx, r, restoreObjectAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
+	restoreBytes, err := goioutil.ReadAll(io.LimitReader(r.Body, maxRestoreRequestSize))
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+	restoreRequest := &RestoreObjectRequest{}
+	if err = xml.Unmarshal(restoreBytes, restoreRequest); err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedXML), r.URL)
+		return
+	}
+
+	restored, err := objectAPI.RestoreObject(ctx, bucket, object, restoreBytes)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+	if api.CacheAPI() != nil {
+		// The restore status is reported with the object info.
+		api.CacheAPI().Invalidate(ctx, bucket, object)
+	}
+
+	if restored {
+		writeSuccessResponseHeadersOnly(w)
+	} else {
+		writeResponse(w, http.StatusAccepted, nil, mimeNone)
+	}
+}
+
 // Extract metadata relevant for an CopyObject operation based on conditional
 // header values specified in X-Amz-Metadata-Directive.
 func getCpObjMetadataFromHeader(ctx context.Context, r *http.Request, userMeta map[string]string) (map[string]string, error) {
@@ -486,6 +638,9 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
+	if api.CacheAPI() != nil {
+		api.CacheAPI().Invalidate(ctx, dstBucket, dstObject)
+	}
 
 	response := generateCopyObjectResponse(objInfo.ETag, objInfo.ModTime)
 	encodedSuccessResponse := encodeResponse(response)
@@ -529,10 +684,21 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidDigest), r.URL)
 		return
 	}
+
+	// X-Amz-Write-Offset-Bytes appends the body to the object, whose
+	// size it must be.
+	appendOffset := int64(-1)
+	if offset, ok := r.Header[xhttp.AmzWriteOffsetBytes]; ok {
+		if appendOffset, err = strconv.ParseInt(offset[0], 10, 64); err != nil || appendOffset < 0 {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidWriteOffset), r.URL)
+			return
+		}
+	}
+
 	/// if Content-Length is unknown/missing, deny the request
 	size := r.ContentLength
 	rAuthType := getRequestAuthType(r)
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || isRequestUnsignedTrailer(r) {
 		if sizeStr, ok := r.Header[xhttp.AmzDecodedContentLength]; ok {
 			if sizeStr[0] == "" {
 				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL)
@@ -552,17 +718,18 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 
 	/// maximum Upload size for objects in a single operation
 	if isMaxObjectSize(size) {
+		discardRequestBody(w, r)
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL)
 		return
 	}
 
-	metadata, err := extractMetadata(ctx, r)
+	metadata, err := extractRequestMetadata(ctx, r)
 	if err != nil {
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
 
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || isRequestUnsignedTrailer(r) {
 		if contentEncoding, ok := metadata["content-encoding"]; ok {
 			contentEncoding = trimAwsChunkedContentEncoding(contentEncoding)
 			if contentEncoding != "" {
@@ -591,8 +758,9 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	)
 	reader = r.Body
 
-	// Check if put is allowed
-	if s3Err = isPutActionAllowed(rAuthType, bucket, object, r, iampolicy.PutObjectAction); s3Err != ErrNone {
+	// Get the access key of the put, allowed once it is authenticated.
+	putAuth, s3Err := getPutActionAuth(rAuthType, bucket, object, r, iampolicy.PutObjectAction)
+	if s3Err != ErrNone {
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL)
 		return
 	}
@@ -622,6 +790,31 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	// Check if put is allowed
+	traceRequestAccessKey(ctx, putAuth.AccessKey)
+	if s3Err = postAuthHooks(r, putAuth); s3Err != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL)
+		return
+	}
+	// The upload counts against the quota of its tenant once it succeeds.
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			globalTenants.refund(r, putAuth)
+		}
+	}()
+
+	reader, checksum, s3Err := newChecksumReader(r, reader)
+	if s3Err != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL)
+		return
+	}
+
+	if reader, err = globalContentTypes.apply(bucket, object, metadata, reader); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
 	actualSize := size
 
 	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
@@ -637,15 +830,31 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		putObject = api.CacheAPI().PutObject
 	}
 
-	// Create the object..
-	objInfo, err := putObject(ctx, bucket, object, pReader, ObjectOptions{UserDefined: metadata})
+	opts := ObjectOptions{UserDefined: metadata, Checksum: checksum}
+	var objInfo ObjectInfo
+	if appendOffset >= 0 {
+		objInfo, err = objectAPI.AppendObject(ctx, bucket, object, appendOffset, pReader, opts)
+		if err == nil && api.CacheAPI() != nil {
+			api.CacheAPI().Invalidate(ctx, bucket, object)
+		}
+	} else {
+		// Create the object, once for identical concurrent uploads.
+		key := dedupPutKey(bucket, object, size, md5hex, sha256hex, metadata)
+		objInfo, err = globalPutDedup.do(ctx, bucket, key, size, hashReader, func() (ObjectInfo, error) {
+			return putObject(ctx, bucket, object, pReader, opts)
+		})
+	}
 	if err != nil {
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
+	uploaded = true
 
 	etag := objInfo.ETag
 	w.Header()[xhttp.ETag] = []string{"\"" + etag + "\""}
+	if checksum != nil {
+		w.Header().Set(checksum.header, checksum.Value())
+	}
 
 	writeSuccessResponseHeadersOnly(w)
 }
@@ -897,7 +1106,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 
 	rAuthType := getRequestAuthType(r)
 	// For auth type streaming signature, we need to gather a different content length.
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || isRequestUnsignedTrailer(r) {
 		if sizeStr, ok := r.Header[xhttp.AmzDecodedContentLength]; ok {
 			if sizeStr[0] == "" {
 				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL)
@@ -917,6 +1126,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 
 	/// maximum Upload size for multipart objects in a single operation
 	if isMaxAllowedPartSize(size) {
+		discardRequestBody(w, r)
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL)
 		return
 	}
@@ -943,7 +1153,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		s3Error   APIErrorCode
 	)
 	reader = r.Body
-	if s3Error = isPutActionAllowed(rAuthType, bucket, object, r, iampolicy.PutObjectAction); s3Error != ErrNone {
+	// Get the access key of the put, allowed once it is authenticated.
+	putAuth, s3Error := getPutActionAuth(rAuthType, bucket, object, r, iampolicy.PutObjectAction)
+	if s3Error != ErrNone {
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
 		return
 	}
@@ -972,6 +1184,26 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		}
 	}
 
+	// Check if put is allowed
+	traceRequestAccessKey(ctx, putAuth.AccessKey)
+	if s3Error = postAuthHooks(r, putAuth); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+	// The upload counts against the quota of its tenant once it succeeds.
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			globalTenants.refund(r, putAuth)
+		}
+	}()
+
+	reader, checksum, s3Error := newChecksumReader(r, reader)
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+
 	actualSize := size
 	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
 	if err != nil {
@@ -989,9 +1221,13 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
 		return
 	}
+	uploaded = true
 
 	etag := partInfo.ETag
 	w.Header()[xhttp.ETag] = []string{"\"" + etag + "\""}
+	if checksum != nil {
+		w.Header().Set(checksum.header, checksum.Value())
+	}
 
 	writeSuccessResponseHeadersOnly(w)
 }
@@ -1220,6 +1456,9 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		}
 		return
 	}
+	if api.CacheAPI() != nil {
+		api.CacheAPI().Invalidate(ctx, bucket, object)
+	}
 
 	// Get object location.
 	location := getObjectLocation(r, globalDomainNames, bucket, object)
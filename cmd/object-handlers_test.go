@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+func TestParseObjectAttributes(t *testing.T) {
+	testCases := []struct {
+		values  []string
+		attrs   map[string]bool
+		errCode APIErrorCode
+	}{
+		{nil, nil, ErrInvalidObjectAttributes},
+		{[]string{""}, nil, ErrInvalidObjectAttributes},
+		{[]string{"ETag"}, map[string]bool{"ETag": true}, ErrNone},
+		{[]string{"ETag, ObjectSize,StorageClass"}, map[string]bool{"ETag": true, "ObjectSize": true, "StorageClass": true}, ErrNone},
+		{[]string{"Checksum", "ObjectParts"}, map[string]bool{"Checksum": true, "ObjectParts": true}, ErrNone},
+		{[]string{"ETag,Size"}, nil, ErrInvalidObjectAttributes},
+		{[]string{"etag"}, nil, ErrInvalidObjectAttributes},
+	}
+
+	for i, testCase := range testCases {
+		h := http.Header{}
+		for _, value := range testCase.values {
+			h.Add("X-Amz-Object-Attributes", value)
+		}
+		attrs, errCode := parseObjectAttributes(h)
+		if errCode != testCase.errCode {
+			t.Errorf("Test %d: expected error %v, got %v", i+1, testCase.errCode, errCode)
+			continue
+		}
+		if errCode == ErrNone && !reflect.DeepEqual(attrs, testCase.attrs) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.attrs, attrs)
+		}
+	}
+}
+
+// Tests that response-* parameters override the object headers.
+func TestSetHeadGetRespHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	objInfo := ObjectInfo{ContentType: "application/octet-stream", Size: 1,
+		UserDefined: map[string]string{"Cache-Control": "no-cache"}}
+	if err := setObjectHeaders(w, objInfo, nil); err != nil {
+		t.Fatal(err)
+	}
+	setHeadGetRespHeaders(w, url.Values{
+		"response-content-type":        {"image/jpeg"},
+		"response-content-disposition": {`attachment; filename="a.jpg"`},
+		"response-cache-control":       {"max-age=3600"},
+		"response-x-amz-meta-a":        {"b"},
+	})
+
+	expected := http.Header{
+		xhttp.ContentType:        {"image/jpeg"},
+		xhttp.ContentDisposition: {`attachment; filename="a.jpg"`},
+		xhttp.CacheControl:       {"max-age=3600"},
+	}
+	for k, v := range expected {
+		if got := w.Header()[k]; !reflect.DeepEqual(got, v) {
+			t.Errorf("%s: expected %v, got %v", k, v, got)
+		}
+	}
+	if got := w.Header().Get("X-Amz-Meta-A"); got != "" {
+		t.Errorf("expected unsupported parameters to be ignored, got %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Objects smaller than this are rewritten with the data appended, larger
+// ones are composed of parts copied from the object, which must be at
+// least this large but for the last part.
+const appendMinCopySize = 5 * humanize.MiByte
+
+// Serializes the appends to an object, while the parts of the object
+// are composed.
+const appendLockSuffix = ".radio-append"
+
+// appendCopyParts - returns the lengths of the parts an object of size
+// bytes is copied in, as few parts of at most maxPartSize as possible and
+// evenly sized, none smaller than half of maxPartSize but for objects
+// copied in one part.
+func appendCopyParts(size, maxPartSize int64) []int64 {
+	n := (size + maxPartSize - 1) / maxPartSize
+	parts := make([]int64, n)
+	for i := range parts {
+		parts[i] = size / n
+		if int64(i) < size%n {
+			parts[i]++
+		}
+	}
+	return parts
+}
+
+// AppendObject appends data to object, which must be offset bytes large.
+// Remotes have no appends, the object is composed in a multipart upload
+// of parts copied from it on the remotes followed by data, small objects
+// are rewritten. An object which does not exist is created at offset 0.
+func (l *radioObjects) AppendObject(ctx context.Context, bucket string, object string, offset int64, data *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error) {
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok {
+		return objInfo, BucketNotFound{Bucket: bucket}
+	}
+	if opts.ServerSideEncryption != nil {
+		// The parts would need the key of the object and of the copy.
+		return objInfo, NotImplemented{}
+	}
+
+	appendLock := l.NewNSLock(ctx, bucket, pathJoin(object, appendLockSuffix))
+	if err = appendLock.GetLock(globalObjectTimeout); err != nil {
+		return objInfo, err
+	}
+	defer appendLock.Unlock()
+
+	info, err := l.getObjectInfo(ctx, bucket, object, opts)
+	if err != nil {
+		err = ErrorRespToObjectError(err, bucket, object)
+		if _, ok := err.(ObjectNotFound); ok && offset == 0 {
+			return l.PutObject(ctx, bucket, object, data, opts)
+		}
+		return objInfo, err
+	}
+	if offset != info.Size {
+		return objInfo, InvalidWriteOffset{Bucket: bucket, Object: object, Offset: offset, Size: info.Size}
+	}
+	if isMaxObjectSize(info.Size + data.Size()) {
+		return objInfo, ObjectTooLarge{Bucket: bucket, Object: object}
+	}
+	if data.Size() == 0 {
+		return info, nil
+	}
+	if info.Size < appendMinCopySize {
+		return l.rewriteAppend(ctx, bucket, object, info, data, opts)
+	}
+
+	uploadID, err := l.NewMultipartUpload(ctx, bucket, object, ObjectOptions{UserDefined: info.UserDefined})
+	if err != nil {
+		return objInfo, err
+	}
+	var parts []CompletePart
+	var start int64
+	for _, length := range appendCopyParts(info.Size, rs3s.maxPartSize()) {
+		// The parts are copied from the ETag of info, they fail if
+		// the object was replaced meanwhile.
+		p, err := l.CopyObjectPart(ctx, bucket, object, bucket, object, uploadID, len(parts)+1, start, length,
+			info, ObjectOptions{}, ObjectOptions{})
+		if err != nil {
+			logger.LogIf(ctx, l.AbortMultipartUpload(ctx, bucket, object, uploadID))
+			return objInfo, err
+		}
+		parts = append(parts, CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+		start += length
+	}
+	p, err := l.PutObjectPart(ctx, bucket, object, uploadID, len(parts)+1, data, opts)
+	if err != nil {
+		logger.LogIf(ctx, l.AbortMultipartUpload(ctx, bucket, object, uploadID))
+		return objInfo, err
+	}
+	parts = append(parts, CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+	if _, err = l.CompleteMultipartUpload(ctx, bucket, object, uploadID, parts, opts); err != nil {
+		logger.LogIf(ctx, l.AbortMultipartUpload(ctx, bucket, object, uploadID))
+		return objInfo, err
+	}
+	return l.getObjectInfo(ctx, bucket, object, opts)
+}
+
+// rewriteAppend - uploads object again with data appended to its
+// content, for objects too small to be copied in parts.
+func (l *radioObjects) rewriteAppend(ctx context.Context, bucket, object string, info ObjectInfo, data *PutObjReader, opts ObjectOptions) (ObjectInfo, error) {
+	gr, err := l.GetObjectNInfo(ctx, bucket, object, nil, http.Header{}, ReadLock, opts)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(gr, appendMinCopySize))
+	gr.Close()
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if gr.ObjInfo.ETag != info.ETag || int64(len(content)) != info.Size {
+		// Replaced since it was looked up.
+		return ObjectInfo{}, InvalidWriteOffset{Bucket: bucket, Object: object, Offset: info.Size, Size: gr.ObjInfo.Size}
+	}
+
+	size := info.Size + data.Size()
+	reader, err := hash.NewReader(io.MultiReader(bytes.NewReader(content), data), size, "", "", size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	opts.UserDefined = info.UserDefined
+	return l.PutObject(ctx, bucket, object, NewPutObjReader(reader, nil, nil), opts)
+}
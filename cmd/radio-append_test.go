@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+func TestAppendCopyParts(t *testing.T) {
+	testCases := []struct {
+		size, maxPartSize int64
+		parts             int
+	}{
+		{appendMinCopySize, globalMaxPartSize, 1},
+		{globalMaxPartSize, globalMaxPartSize, 1},
+		{globalMaxPartSize + 1, globalMaxPartSize, 2},
+		{12 * humanize.GiByte, globalMaxPartSize, 3},
+		{100 * humanize.MiByte, 64 * humanize.MiByte, 2},
+		{1 << 40, 100 * humanize.MiByte, 10486},
+	}
+	for i, testCase := range testCases {
+		parts := appendCopyParts(testCase.size, testCase.maxPartSize)
+		if len(parts) != testCase.parts {
+			t.Fatalf("Test %d: expected %d parts, got %d", i+1, testCase.parts, len(parts))
+		}
+		var size int64
+		for _, length := range parts {
+			if length > testCase.maxPartSize || length < appendMinCopySize {
+				t.Errorf("Test %d: part of %d bytes out of range", i+1, length)
+			}
+			if length-parts[len(parts)-1] > 1 {
+				t.Errorf("Test %d: parts not evenly sized: %d and %d", i+1, length, parts[len(parts)-1])
+			}
+			size += length
+		}
+		if size != testCase.size {
+			t.Errorf("Test %d: expected parts of %d bytes in total, got %d", i+1, testCase.size, size)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultAuthorizerTimeout = 5 * time.Second
+
+	// Decisions cached at most, the cache is cleared once full.
+	authorizerCacheSize = 10000
+)
+
+// authorizerConfig - external authorizer called for every authorized
+// action of a request.
+type authorizerConfig struct {
+	Endpoint  string        `yaml:"endpoint"`
+	AuthToken string        `yaml:"auth_token"`
+	Timeout   time.Duration `yaml:"timeout"`
+	// CacheTTL caches decisions for requests of the same identity,
+	// action, bucket and key.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// FailOpen allows requests the authorizer could not decide,
+	// they are denied by default.
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// validateAuthorizerConfig - validates the authorizer config.
+func validateAuthorizerConfig(errs *radioConfigErrors, path string, c authorizerConfig) {
+	if c.Endpoint == "" {
+		if c != (authorizerConfig{}) {
+			errs.add(path+".endpoint", "required for an authorizer")
+		}
+		return
+	}
+	if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(path+".endpoint", "%q is not an http(s) URL", c.Endpoint)
+	}
+	if c.Timeout < 0 {
+		errs.add(path+".timeout", "must not be negative")
+	}
+	if c.CacheTTL < 0 {
+		errs.add(path+".cache_ttl", "must not be negative")
+	}
+}
+
+// AuthorizerRequest - body POSTed to the authorizer.
+type AuthorizerRequest struct {
+	Method   string             `json:"method"`
+	API      string             `json:"api"`
+	Action   string             `json:"action"`
+	Bucket   string             `json:"bucket"`
+	Key      string             `json:"key,omitempty"`
+	Identity AuthorizerIdentity `json:"identity"`
+	// SourceIP of the client, resolved through the trusted proxies,
+	// for conditions such as aws:SourceIp.
+	SourceIP string `json:"sourceIp"`
+}
+
+// AuthorizerIdentity - the credential a request was signed with. Radio
+// credentials carry no claims, authorizers decode them from the session
+// token given with the request, if any.
+type AuthorizerIdentity struct {
+	AccessKey    string `json:"accessKey"`
+	SessionToken string `json:"sessionToken,omitempty"`
+}
+
+// AuthorizerResponse - decision returned by the authorizer.
+type AuthorizerResponse struct {
+	Allow       bool                  `json:"allow"`
+	Reason      string                `json:"reason,omitempty"`
+	Constraints AuthorizerConstraints `json:"constraints"`
+}
+
+// AuthorizerConstraints - limits on an allowed request.
+type AuthorizerConstraints struct {
+	// Prefix the key, or the prefix of listings, must start with.
+	Prefix string `json:"prefix,omitempty"`
+	// MaxSize of uploaded objects and parts.
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// ReadOnly only allows s3:Get* and s3:List* actions.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// check - returns an error if r violates the constraints.
+func (c AuthorizerConstraints) check(r *http.Request, auth HookAuth) error {
+	if c.ReadOnly && !strings.HasPrefix(auth.Action, "s3:Get") && !strings.HasPrefix(auth.Action, "s3:List") {
+		return fmt.Errorf("%s is not allowed by a read-only authorization", auth.Action)
+	}
+	if c.Prefix != "" {
+		key := auth.Object
+		if key == "" {
+			key = r.URL.Query().Get("prefix")
+		}
+		if !strings.HasPrefix(key, c.Prefix) {
+			return fmt.Errorf("%q is outside the authorized prefix %q", key, c.Prefix)
+		}
+	}
+	if c.MaxSize > 0 && (r.Method == http.MethodPut || r.Method == http.MethodPost) {
+		size := r.ContentLength
+		if sizeStr := r.Header.Get(xhttp.AmzDecodedContentLength); sizeStr != "" {
+			size, _ = strconv.ParseInt(sizeStr, 10, 64)
+		}
+		if size > c.MaxSize {
+			return fmt.Errorf("size %d exceeds the authorized %d", size, c.MaxSize)
+		}
+	}
+	return nil
+}
+
+type authorizerDecision struct {
+	resp    AuthorizerResponse
+	expires time.Time
+}
+
+// authorizer - RequestHook calling the external authorizer after
+// radio authenticated a request.
+type authorizer struct {
+	NopRequestHook
+
+	cfg    authorizerConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[AuthorizerRequest]authorizerDecision
+}
+
+func newAuthorizer(cfg authorizerConfig) *authorizer {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultAuthorizerTimeout
+	}
+	return &authorizer{
+		cfg:    cfg,
+		client: &http.Client{Transport: NewCustomHTTPTransport(), Timeout: cfg.Timeout},
+		cache:  make(map[AuthorizerRequest]authorizerDecision),
+	}
+}
+
+// PostAuth - asks the authorizer about the action and checks the
+// constraints of an allowed request.
+func (a *authorizer) PostAuth(r *http.Request, auth HookAuth) error {
+	req := AuthorizerRequest{
+		Method:   r.Method,
+		API:      logger.GetReqInfo(r.Context()).API,
+		Action:   auth.Action,
+		Bucket:   auth.Bucket,
+		Key:      auth.Object,
+		Identity: AuthorizerIdentity{AccessKey: auth.AccessKey, SessionToken: getSessionToken(r)},
+		SourceIP: getSourceIP(r),
+	}
+
+	resp, err := a.decide(req)
+	if err != nil {
+		if a.cfg.FailOpen {
+			logger.LogIf(r.Context(), fmt.Errorf("authorizer: %v, allowing the request", err))
+			return nil
+		}
+		return fmt.Errorf("authorizer: %v", err)
+	}
+	if !resp.Allow {
+		return fmt.Errorf("denied by the authorizer: %s", resp.Reason)
+	}
+	return resp.Constraints.check(r, auth)
+}
+
+// decide - returns the cached decision for req, or calls the authorizer.
+func (a *authorizer) decide(req AuthorizerRequest) (AuthorizerResponse, error) {
+	now := UTCNow()
+	if a.cfg.CacheTTL > 0 {
+		a.mu.Lock()
+		d, ok := a.cache[req]
+		a.mu.Unlock()
+		if ok && now.Before(d.expires) {
+			return d.resp, nil
+		}
+	}
+
+	resp, err := a.call(req)
+	if err != nil || a.cfg.CacheTTL == 0 {
+		return resp, err
+	}
+	a.mu.Lock()
+	if len(a.cache) >= authorizerCacheSize {
+		a.cache = make(map[AuthorizerRequest]authorizerDecision)
+	}
+	a.cache[req] = authorizerDecision{resp: resp, expires: now.Add(a.cfg.CacheTTL)}
+	a.mu.Unlock()
+	return resp, nil
+}
+
+// call - POSTs req to the authorizer.
+func (a *authorizer) call(req AuthorizerRequest) (resp AuthorizerResponse, err error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	hreq, err := http.NewRequest(http.MethodPost, a.cfg.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return resp, err
+	}
+	hreq.Header.Set(xhttp.ContentType, "application/json")
+	if a.cfg.AuthToken != "" {
+		hreq.Header.Set(xhttp.Authorization, "Bearer "+a.cfg.AuthToken)
+	}
+	hresp, err := a.client.Do(hreq)
+	if err != nil {
+		return resp, err
+	}
+	defer xhttp.DrainBody(hresp.Body)
+	if hresp.StatusCode != http.StatusOK {
+		return resp, fmt.Errorf("unexpected status %s", hresp.Status)
+	}
+	if err = json.NewDecoder(hresp.Body).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("invalid response: %v", err)
+	}
+	return resp, nil
+}
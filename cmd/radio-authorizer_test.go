@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorizer(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req AuthorizerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SourceIP != "192.0.2.1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := AuthorizerResponse{Allow: req.Identity.AccessKey == "alice",
+			Constraints: AuthorizerConstraints{Prefix: "home/alice/", MaxSize: 10}}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	a := newAuthorizer(authorizerConfig{Endpoint: server.URL, AuthToken: "token", CacheTTL: time.Minute})
+	testCases := []struct {
+		method string
+		url    string
+		size   int64
+		auth   HookAuth
+		allow  bool
+	}{
+		{http.MethodGet, "/bucket/home/alice/a", 0, HookAuth{"alice", "s3:GetObject", "bucket", "home/alice/a"}, true},
+		{http.MethodGet, "/bucket/home/bob/a", 0, HookAuth{"alice", "s3:GetObject", "bucket", "home/bob/a"}, false},
+		{http.MethodGet, "/bucket?prefix=home/alice/", 0, HookAuth{"alice", "s3:ListBucket", "bucket", ""}, true},
+		{http.MethodGet, "/bucket", 0, HookAuth{"alice", "s3:ListBucket", "bucket", ""}, false},
+		{http.MethodPut, "/bucket/home/alice/b", 100, HookAuth{"alice", "s3:PutObject", "bucket", "home/alice/b"}, false},
+		{http.MethodGet, "/bucket/home/alice/a", 0, HookAuth{"bob", "s3:GetObject", "bucket", "home/alice/a"}, false},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(testCase.method, testCase.url, strings.NewReader(""))
+		r.ContentLength = testCase.size
+		if err := a.PostAuth(r, testCase.auth); (err == nil) != testCase.allow {
+			t.Errorf("Test %d: expected allow %t, got %v", i+1, testCase.allow, err)
+		}
+	}
+
+	// The first request is cached.
+	r := httptest.NewRequest(http.MethodGet, "/bucket/home/alice/a", nil)
+	before := calls
+	if err := a.PostAuth(r, testCases[0].auth); err != nil || calls != before {
+		t.Errorf("expected a cached decision, got %v after %d calls", err, calls-before)
+	}
+
+	// Requests the authorizer could not decide.
+	for _, failOpen := range []bool{false, true} {
+		a = newAuthorizer(authorizerConfig{Endpoint: server.URL, FailOpen: failOpen})
+		if err := a.PostAuth(r, testCases[0].auth); (err == nil) != failOpen {
+			t.Errorf("fail open %t: got %v", failOpen, err)
+		}
+	}
+}
@@ -0,0 +1,744 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/radio/cmd/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// Types of batch jobs.
+const (
+	// batchJobReplicate - copies objects missing or holding another
+	// version on a remote of a mirror bucket from another remote.
+	batchJobReplicate = "replicate"
+	// batchJobCopy - copies objects to a mirror bucket through radio.
+	batchJobCopy = "copy"
+	// batchJobDelete - deletes objects last modified before a date.
+	batchJobDelete = "delete"
+	// batchJobWarm - reads objects into the cache at a bounded rate.
+	batchJobWarm = "warm"
+	// batchJobPull - copies new or changed objects of a bucket not
+	// served by radio to a mirror bucket.
+	batchJobPull = "pull"
+)
+
+// States of a batch job.
+const (
+	batchJobRunning   = "running"
+	batchJobCompleted = "completed"
+	batchJobFailed    = "failed"
+	batchJobCanceled  = "canceled"
+)
+
+const (
+	batchJobDefaultWorkers = 4
+	batchJobMaxWorkers     = 64
+
+	// Finished jobs are listed for this long.
+	batchJobRetention = 24 * time.Hour
+)
+
+var (
+	errBatchJobNotFound = errors.New("batch job not found")
+	errBatchJobRunning  = errors.New("batch job is still running")
+)
+
+// BatchJobRemote - a remote bucket backing a mirror bucket.
+type BatchJobRemote struct {
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	Bucket   string `yaml:"bucket" json:"bucket"`
+}
+
+// BatchJobSpec - a batch job as submitted in YAML, or JSON with the same
+// keys, such as
+//
+//	type: delete
+//	bucket: logs
+//	prefix: 2019/
+//	delete:
+//	  before: 2020-01-01T00:00:00Z
+//
+// Objects below prefix are walked in key order after start_after, up to
+// workers of them are handled in parallel.
+type BatchJobSpec struct {
+	Type       string `yaml:"type" json:"type"`
+	Bucket     string `yaml:"bucket" json:"bucket"`
+	Prefix     string `yaml:"prefix" json:"prefix,omitempty"`
+	StartAfter string `yaml:"start_after" json:"start_after,omitempty"`
+	Workers    int    `yaml:"workers" json:"workers"`
+	// DryRun only counts the objects which would be handled.
+	DryRun bool `yaml:"dry_run" json:"dry_run,omitempty"`
+
+	Replicate *BatchReplicateSpec `yaml:"replicate" json:"replicate,omitempty"`
+	Copy      *BatchCopySpec      `yaml:"copy" json:"copy,omitempty"`
+	Delete    *BatchDeleteSpec    `yaml:"delete" json:"delete,omitempty"`
+	Warm      *BatchWarmSpec      `yaml:"warm" json:"warm,omitempty"`
+	Pull      *BatchPullSpec      `yaml:"pull" json:"pull,omitempty"`
+}
+
+// writes - returns true if the job writes to the remotes.
+func (spec BatchJobSpec) writes() bool {
+	return !spec.DryRun && spec.Type != batchJobWarm
+}
+
+// writeBucket - returns the bucket the job writes to.
+func (spec BatchJobSpec) writeBucket() string {
+	if spec.Type == batchJobCopy && spec.Copy != nil {
+		return spec.Copy.Bucket
+	}
+	return spec.Bucket
+}
+
+// BatchReplicateSpec - replicates the objects of the remote Source to
+// the remote Target, both backing the bucket of the job. Objects Target
+// holds in the same version are skipped.
+type BatchReplicateSpec struct {
+	Source BatchJobRemote `yaml:"source" json:"source"`
+	Target BatchJobRemote `yaml:"target" json:"target"`
+}
+
+// BatchCopySpec - copies the objects to Bucket, with the prefix of the
+// job replaced by Prefix.
+type BatchCopySpec struct {
+	Bucket string `yaml:"bucket" json:"bucket"`
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+}
+
+// BatchDeleteSpec - deletes the objects last modified before Before, an
+// RFC 3339 date, or more than OlderThan, a duration such as 720h, before
+// the job started.
+type BatchDeleteSpec struct {
+	Before    string `yaml:"before" json:"before,omitempty"`
+	OlderThan string `yaml:"older_than" json:"older_than,omitempty"`
+}
+
+// BatchWarmSpec - reads the objects into the cache, at most Rate bytes
+// per second such as 50MiB if set. Keys lists the objects to read in
+// place of all objects below the prefix of the job.
+type BatchWarmSpec struct {
+	Keys []string `yaml:"keys" json:"keys,omitempty"`
+	Rate string   `yaml:"rate" json:"rate,omitempty"`
+}
+
+// BatchPullSpec - copies the objects of Source, a pull source of the
+// bucket of the job, missing or holding another version in the bucket.
+// Objects last modified before ModifiedAfter, an RFC 3339 date, are
+// skipped if set.
+type BatchPullSpec struct {
+	Source        BatchJobRemote `yaml:"source" json:"source"`
+	ModifiedAfter string         `yaml:"modified_after" json:"modified_after,omitempty"`
+}
+
+// modifiedAfter - returns the time objects to copy were last modified
+// after, zero to copy all objects.
+func (p BatchPullSpec) modifiedAfter() (time.Time, error) {
+	if p.ModifiedAfter == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, p.ModifiedAfter)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("pull.modified_after: %v", err)
+	}
+	return t, nil
+}
+
+// rate - returns the bytes per second objects are read at, 0 if
+// unbounded.
+func (w BatchWarmSpec) rate() (int64, error) {
+	if w.Rate == "" {
+		return 0, nil
+	}
+	rate, err := humanize.ParseBytes(w.Rate)
+	if err != nil {
+		return 0, fmt.Errorf("warm.rate: %v", err)
+	}
+	if rate == 0 {
+		return 0, errors.New("warm.rate must be positive")
+	}
+	return int64(rate), nil
+}
+
+// cutoff - returns the time objects to delete were last modified before.
+func (d BatchDeleteSpec) cutoff(started time.Time) (time.Time, error) {
+	switch {
+	case d.Before != "" && d.OlderThan != "":
+		return time.Time{}, errors.New("delete.before and delete.older_than are exclusive")
+	case d.Before != "":
+		return time.Parse(time.RFC3339, d.Before)
+	case d.OlderThan != "":
+		age, err := time.ParseDuration(d.OlderThan)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return started.Add(-age), nil
+	}
+	return time.Time{}, errors.New("delete.before or delete.older_than is required")
+}
+
+// parseBatchJob - parses a batch job and checks that it is complete.
+func parseBatchJob(data []byte) (BatchJobSpec, error) {
+	var spec BatchJobSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return spec, err
+	}
+	if spec.Bucket == "" {
+		return spec, errors.New("bucket is required")
+	}
+	if spec.Workers == 0 {
+		spec.Workers = batchJobDefaultWorkers
+	}
+	if spec.Workers < 1 || spec.Workers > batchJobMaxWorkers {
+		return spec, fmt.Errorf("workers must be between 1 and %d", batchJobMaxWorkers)
+	}
+
+	sections := 0
+	for _, set := range []bool{spec.Replicate != nil, spec.Copy != nil, spec.Delete != nil, spec.Warm != nil, spec.Pull != nil} {
+		if set {
+			sections++
+		}
+	}
+	var ok bool
+	switch spec.Type {
+	case batchJobReplicate:
+		ok = spec.Replicate != nil
+	case batchJobCopy:
+		ok = spec.Copy != nil
+	case batchJobDelete:
+		ok = spec.Delete != nil
+	case batchJobWarm:
+		ok = spec.Warm != nil
+	case batchJobPull:
+		ok = spec.Pull != nil
+	default:
+		return spec, fmt.Errorf("unknown type %q", spec.Type)
+	}
+	if !ok || sections != 1 {
+		return spec, fmt.Errorf("a job of type %s requires only the %s section", spec.Type, spec.Type)
+	}
+	if spec.Delete != nil {
+		if _, err := spec.Delete.cutoff(time.Now()); err != nil {
+			return spec, err
+		}
+	}
+	if spec.Pull != nil {
+		if _, err := spec.Pull.modifiedAfter(); err != nil {
+			return spec, err
+		}
+	}
+	if spec.Warm != nil {
+		if _, err := spec.Warm.rate(); err != nil {
+			return spec, err
+		}
+		// Keys are handled in key order like listed objects, for the
+		// checkpoint.
+		sort.Strings(spec.Warm.Keys)
+		keys := spec.Warm.Keys[:0]
+		for _, key := range spec.Warm.Keys {
+			if !HasPrefix(key, spec.Prefix) {
+				return spec, fmt.Errorf("warm.keys: %s is not below prefix", key)
+			}
+			if len(keys) == 0 || keys[len(keys)-1] != key {
+				keys = append(keys, key)
+			}
+		}
+		spec.Warm.Keys = keys
+	}
+	return spec, nil
+}
+
+// BatchJobStatus - state and progress of a batch job. All objects up to
+// Checkpoint in key order were handled, a failed or canceled job resumes
+// after it.
+type BatchJobStatus struct {
+	ID       string       `json:"id"`
+	Spec     BatchJobSpec `json:"spec"`
+	State    string       `json:"state"`
+	Started  time.Time    `json:"started"`
+	Finished *time.Time   `json:"finished,omitempty"`
+	// ResumedFrom is the job this job resumes.
+	ResumedFrom    string `json:"resumedFrom,omitempty"`
+	ObjectsScanned int    `json:"objectsScanned"`
+	// ObjectsHandled were copied, deleted or read into the cache, the
+	// others were skipped.
+	ObjectsHandled int    `json:"objectsHandled"`
+	ObjectsFailed  int    `json:"objectsFailed"`
+	Checkpoint     string `json:"checkpoint,omitempty"`
+	// Error is the error which stopped the job, or the last failure.
+	Error string `json:"error,omitempty"`
+}
+
+// batchObjectFunc - handles one listed object of a batch job, returns
+// false if it was skipped.
+type batchObjectFunc func(ctx context.Context, info miniogo.ObjectInfo) (bool, error)
+
+// batchItem - a listed object handled by a worker.
+type batchItem struct {
+	info   miniogo.ObjectInfo
+	done   bool
+	failed bool
+}
+
+type batchJob struct {
+	mu     sync.Mutex
+	status BatchJobStatus
+	cancel context.CancelFunc
+	// pending holds the listed objects the checkpoint did not pass yet,
+	// in key order.
+	pending []*batchItem
+	// done is closed once the job ended.
+	done chan struct{}
+}
+
+// add - records a listed object before it is passed to a worker.
+func (j *batchJob) add(info miniogo.ObjectInfo) *batchItem {
+	item := &batchItem{info: info}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.ObjectsScanned++
+	j.pending = append(j.pending, item)
+	return item
+}
+
+// finish - records the result of a worker and moves the checkpoint past
+// the objects handled without failure.
+func (j *batchJob) finish(item *batchItem, handled bool, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	item.done = true
+	switch {
+	case err != nil:
+		item.failed = true
+		j.status.ObjectsFailed++
+		j.status.Error = item.info.Key + ": " + err.Error()
+	case handled:
+		j.status.ObjectsHandled++
+	}
+	for len(j.pending) > 0 && j.pending[0].done && !j.pending[0].failed {
+		j.status.Checkpoint = j.pending[0].info.Key
+		j.pending = j.pending[1:]
+	}
+}
+
+// end - records the end of the job, err stopped it.
+func (j *batchJob) end(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := UTCNow()
+	j.status.Finished = &now
+	switch {
+	case err == context.Canceled:
+		j.status.State = batchJobCanceled
+	case err != nil:
+		j.status.State = batchJobFailed
+		j.status.Error = err.Error()
+	case j.status.ObjectsFailed > 0:
+		j.status.State = batchJobFailed
+	default:
+		j.status.State = batchJobCompleted
+	}
+}
+
+func (j *batchJob) getStatus() BatchJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// batchJobTracker - the batch jobs started on this server, finished jobs
+// are kept for batchJobRetention.
+type batchJobTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*batchJob
+}
+
+func newBatchJobTracker() *batchJobTracker {
+	return &batchJobTracker{jobs: make(map[string]*batchJob)}
+}
+
+func (t *batchJobTracker) add(job *batchJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, j := range t.jobs {
+		if status := j.getStatus(); status.Finished != nil && time.Since(*status.Finished) > batchJobRetention {
+			delete(t.jobs, id)
+		}
+	}
+	t.jobs[job.status.ID] = job
+}
+
+func (t *batchJobTracker) get(id string) (*batchJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	return job, ok
+}
+
+// list - returns the status of all jobs, oldest first.
+func (t *batchJobTracker) list() []BatchJobStatus {
+	t.mu.Lock()
+	statuses := make([]BatchJobStatus, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		statuses = append(statuses, job.getStatus())
+	}
+	t.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Started.Before(statuses[j].Started)
+	})
+	return statuses
+}
+
+// batchRemote - returns the remote r of rs3s, shadows included.
+func batchRemote(rs3s mirrorConfig, r BatchJobRemote) (bucketClient, error) {
+	for _, clnt := range append(rs3s.clnts[:len(rs3s.clnts):len(rs3s.clnts)], rs3s.shadows...) {
+		if clnt.EndpointURL().String() == r.Endpoint && clnt.Bucket == r.Bucket {
+			return clnt, nil
+		}
+	}
+	return bucketClient{}, fmt.Errorf("no remote bucket %s at %s", r.Bucket, r.Endpoint)
+}
+
+// batchJobFuncs - returns the remote listing the objects of the job and
+// the function handling each of them.
+func (l *radioObjects) batchJobFuncs(spec BatchJobSpec, started time.Time) (bucketClient, batchObjectFunc, error) {
+	rs3s, ok := l.mirrorClients[spec.Bucket]
+	if !ok {
+		return bucketClient{}, nil, BucketNotFound{Bucket: spec.Bucket}
+	}
+
+	switch spec.Type {
+	case batchJobReplicate:
+		src, err := batchRemote(rs3s, spec.Replicate.Source)
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		dst, err := batchRemote(rs3s, spec.Replicate.Target)
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		if src.EndpointURL().String() == dst.EndpointURL().String() && src.Bucket == dst.Bucket {
+			return bucketClient{}, nil, errors.New("replicate.source and replicate.target are the same remote")
+		}
+		return src, func(ctx context.Context, info miniogo.ObjectInfo) (bool, error) {
+			return l.batchReplicate(ctx, spec, src, dst, info.Key)
+		}, nil
+
+	case batchJobCopy:
+		dstRs3s, ok := l.mirrorClients[spec.Copy.Bucket]
+		if !ok {
+			return bucketClient{}, nil, BucketNotFound{Bucket: spec.Copy.Bucket}
+		}
+		if len(dstRs3s.clnts) != len(rs3s.clnts) {
+			return bucketClient{}, nil, errors.New("copy.bucket must have as many remotes as bucket")
+		}
+		if spec.Copy.Bucket == spec.Bucket && HasPrefix(spec.Copy.Prefix, spec.Prefix) {
+			// The copies would be listed again.
+			return bucketClient{}, nil, errors.New("copy.prefix must not be below prefix in the same bucket")
+		}
+		return rs3s.listClient(), func(ctx context.Context, info miniogo.ObjectInfo) (bool, error) {
+			if spec.DryRun {
+				return true, nil
+			}
+			srcInfo, err := l.GetObjectInfo(ctx, spec.Bucket, info.Key, ObjectOptions{})
+			if err != nil {
+				if _, ok := err.(ObjectNotFound); ok {
+					// removed since it was listed
+					return false, nil
+				}
+				return false, err
+			}
+			dstObject := spec.Copy.Prefix + strings.TrimPrefix(info.Key, spec.Prefix)
+			if _, err = l.CopyObject(ctx, spec.Bucket, info.Key, spec.Copy.Bucket, dstObject, srcInfo, ObjectOptions{}, ObjectOptions{}); err != nil {
+				return false, err
+			}
+			if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+				cacheAPI.Invalidate(ctx, spec.Copy.Bucket, dstObject)
+			}
+			return true, nil
+		}, nil
+
+	case batchJobDelete:
+		cutoff, err := spec.Delete.cutoff(started)
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		return rs3s.listClient(), func(ctx context.Context, info miniogo.ObjectInfo) (bool, error) {
+			if !info.LastModified.Before(cutoff) {
+				return false, nil
+			}
+			if spec.DryRun {
+				return true, nil
+			}
+			if err := l.DeleteObject(ctx, spec.Bucket, info.Key); err != nil {
+				return true, err
+			}
+			if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+				cacheAPI.Invalidate(ctx, spec.Bucket, info.Key)
+			}
+			return true, nil
+		}, nil
+
+	case batchJobWarm:
+		cacheAPI := newCachedObjectLayerFn()
+		if cacheAPI == nil {
+			return bucketClient{}, nil, errors.New("cache is disabled")
+		}
+		rate, err := spec.Warm.rate()
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		var limiter *rateLimiter
+		if rate > 0 {
+			// Shared by the workers, rate bounds the job.
+			limiter = newRateLimiter(rate)
+		}
+		return rs3s.listClient(), func(ctx context.Context, info miniogo.ObjectInfo) (bool, error) {
+			if spec.DryRun {
+				return true, nil
+			}
+			return l.batchWarm(ctx, cacheAPI, spec.Bucket, info.Key, limiter)
+		}, nil
+
+	case batchJobPull:
+		src, err := rs3s.pullSource(spec.Pull.Source)
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		after, err := spec.Pull.modifiedAfter()
+		if err != nil {
+			return bucketClient{}, nil, err
+		}
+		return src.clnt, func(ctx context.Context, info miniogo.ObjectInfo) (bool, error) {
+			if !info.LastModified.After(after) {
+				return false, nil
+			}
+			return l.batchPull(ctx, spec, src.clnt, info)
+		}, nil
+	}
+	return bucketClient{}, nil, fmt.Errorf("unknown type %q", spec.Type)
+}
+
+// batchReplicate - copies object from src to dst unless dst holds the
+// same version.
+func (l *radioObjects) batchReplicate(ctx context.Context, spec BatchJobSpec, src, dst bucketClient, object string) (bool, error) {
+	if src.inMaintenance() || dst.inMaintenance() {
+		return false, errRemoteMaintenance
+	}
+	srcInfo, err := src.StatObject(src.Bucket, src.remoteKey(object), miniogo.StatObjectOptions{})
+	if err != nil {
+		if _, ok := ErrorRespToObjectError(err, src.Bucket, object).(ObjectNotFound); ok {
+			// removed since it was listed
+			return false, nil
+		}
+		return false, err
+	}
+	dstInfo, err := dst.StatObject(dst.Bucket, dst.remoteKey(object), miniogo.StatObjectOptions{})
+	if err == nil && objectVersion(dstInfo) == objectVersion(srcInfo) {
+		return false, nil
+	}
+	if err != nil {
+		if _, ok := ErrorRespToObjectError(err, dst.Bucket, object).(ObjectNotFound); !ok {
+			return false, err
+		}
+	}
+	if spec.DryRun {
+		return true, nil
+	}
+	return true, l.healObject(ctx, spec.Bucket, object, objectVersion(srcInfo), src, dst)
+}
+
+// batchWarm - reads object through the cache, which caches it, paced by
+// limiter unless nil.
+func (l *radioObjects) batchWarm(ctx context.Context, cacheAPI CacheObjectLayer, bucket, object string, limiter *rateLimiter) (bool, error) {
+	gr, err := cacheAPI.GetObjectNInfo(withCacheWarm(ctx), bucket, object, nil, http.Header{}, ReadLock, ObjectOptions{})
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			// removed since it was listed, or a listed key which
+			// does not exist
+			return false, nil
+		}
+		return false, err
+	}
+	defer gr.Close()
+	var w io.Writer = ioutil.Discard
+	if limiter != nil {
+		w = rateLimitedWriter{ctx: ctx, l: limiter, w: w}
+	}
+	_, err = io.Copy(w, gr)
+	return err == nil, err
+}
+
+// batchKeys - returns the objects of keys after startAfter, as they would
+// be listed.
+func batchKeys(keys []string, startAfter string, doneCh <-chan struct{}) <-chan miniogo.ObjectInfo {
+	objCh := make(chan miniogo.ObjectInfo)
+	go func() {
+		defer close(objCh)
+		for _, key := range keys {
+			if key <= startAfter {
+				continue
+			}
+			select {
+			case objCh <- miniogo.ObjectInfo{Key: key}:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return objCh
+}
+
+// StartBatchJob - validates spec against the configured buckets and
+// starts the job in the background.
+func (l *radioObjects) StartBatchJob(spec BatchJobSpec) (BatchJobStatus, error) {
+	return l.startBatchJob(spec, "")
+}
+
+func (l *radioObjects) startBatchJob(spec BatchJobSpec, resumedFrom string) (BatchJobStatus, error) {
+	if globalReadOnly.Load() && spec.writes() {
+		return BatchJobStatus{}, errors.New("server is in read-only mode")
+	}
+	if spec.writes() && globalWriteFences.fenced(spec.writeBucket(), UTCNow()) {
+		return BatchJobStatus{}, errors.New("writes to bucket " + spec.writeBucket() + " are fenced")
+	}
+	started := UTCNow()
+	lister, handle, err := l.batchJobFuncs(spec, started)
+	if err != nil {
+		return BatchJobStatus{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &batchJob{
+		status: BatchJobStatus{
+			ID:          mustGetUUID(),
+			Spec:        spec,
+			State:       batchJobRunning,
+			Started:     started,
+			ResumedFrom: resumedFrom,
+			Checkpoint:  spec.StartAfter,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	l.batchJobs.add(job)
+	go l.runBatchJob(ctx, job, lister, handle)
+	return job.getStatus(), nil
+}
+
+// runBatchJob - passes the objects listed by lister to the workers of the
+// job until the listing ends, fails or ctx is canceled.
+func (l *radioObjects) runBatchJob(ctx context.Context, job *batchJob, lister bucketClient, handle batchObjectFunc) {
+	defer job.cancel()
+	spec := job.status.Spec
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	var objCh <-chan miniogo.ObjectInfo
+	if spec.Warm != nil && len(spec.Warm.Keys) > 0 {
+		objCh = batchKeys(spec.Warm.Keys, spec.StartAfter, doneCh)
+	} else {
+		objCh = lister.listAllObjects(spec.Prefix, spec.StartAfter, true, doneCh)
+	}
+
+	itemCh := make(chan *batchItem)
+	var wg sync.WaitGroup
+	for i := 0; i < spec.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range itemCh {
+				handled, err := handle(ctx, item.info)
+				if err != nil {
+					logger.LogIf(ctx, fmt.Errorf("batch job %s: %s: %v", job.status.ID, item.info.Key, err))
+				}
+				job.finish(item, handled, err)
+			}
+		}()
+	}
+
+	var err error
+	for obj := range objCh {
+		if obj.Err != nil {
+			err = ErrorRespToObjectError(obj.Err, spec.Bucket)
+			break
+		}
+		if globalReadOnly.Load() && spec.writes() {
+			err = errors.New("server is in read-only mode")
+			break
+		}
+		if spec.writes() && globalWriteFences.fenced(spec.writeBucket(), UTCNow()) {
+			err = errors.New("writes to bucket " + spec.writeBucket() + " are fenced")
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		item := job.add(obj)
+		select {
+		case itemCh <- item:
+		case <-ctx.Done():
+		}
+	}
+	close(itemCh)
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	job.end(err)
+	close(job.done)
+}
+
+// BatchJobs - returns the status of all batch jobs, oldest first.
+func (l *radioObjects) BatchJobs() []BatchJobStatus {
+	return l.batchJobs.list()
+}
+
+// BatchJob - returns the status of the batch job id.
+func (l *radioObjects) BatchJob(id string) (BatchJobStatus, error) {
+	job, ok := l.batchJobs.get(id)
+	if !ok {
+		return BatchJobStatus{}, errBatchJobNotFound
+	}
+	return job.getStatus(), nil
+}
+
+// CancelBatchJob - cancels the batch job id, objects being handled are
+// finished first.
+func (l *radioObjects) CancelBatchJob(id string) error {
+	job, ok := l.batchJobs.get(id)
+	if !ok {
+		return errBatchJobNotFound
+	}
+	job.cancel()
+	return nil
+}
+
+// ResumeBatchJob - starts a new job continuing the failed or canceled
+// batch job id after its checkpoint, failed objects are retried.
+func (l *radioObjects) ResumeBatchJob(id string) (BatchJobStatus, error) {
+	job, ok := l.batchJobs.get(id)
+	if !ok {
+		return BatchJobStatus{}, errBatchJobNotFound
+	}
+	status := job.getStatus()
+	if status.Finished == nil {
+		return BatchJobStatus{}, errBatchJobRunning
+	}
+	if status.State == batchJobCompleted {
+		return BatchJobStatus{}, errors.New("batch job completed")
+	}
+	spec := status.Spec
+	spec.StartAfter = status.Checkpoint
+	return l.startBatchJob(spec, id)
+}
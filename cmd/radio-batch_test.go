@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+// Tests parsing and validation of batch jobs.
+func TestParseBatchJob(t *testing.T) {
+	testCases := []struct {
+		job     string
+		workers int
+		valid   bool
+	}{
+		{"type: delete\nbucket: logs\ndelete:\n  older_than: 720h\n", batchJobDefaultWorkers, true},
+		{`{"type": "delete", "bucket": "logs", "workers": 8, "delete": {"before": "2020-01-01T00:00:00Z"}}`, 8, true},
+		{"type: copy\nbucket: logs\nprefix: a/\ncopy:\n  bucket: archive\n", batchJobDefaultWorkers, true},
+		{"type: replicate\nbucket: logs\nreplicate:\n  source: {endpoint: http://a:9000, bucket: b1}\n  target: {endpoint: http://b:9000, bucket: b2}\n",
+			batchJobDefaultWorkers, true},
+		// Missing bucket.
+		{"type: delete\ndelete:\n  older_than: 720h\n", 0, false},
+		// Unknown type.
+		{"type: move\nbucket: logs\n", 0, false},
+		// Section of another type.
+		{"type: delete\nbucket: logs\ncopy:\n  bucket: archive\n", 0, false},
+		// Two sections.
+		{"type: delete\nbucket: logs\ndelete:\n  older_than: 720h\ncopy:\n  bucket: archive\n", 0, false},
+		// Both dates.
+		{"type: delete\nbucket: logs\ndelete:\n  older_than: 720h\n  before: 2020-01-01T00:00:00Z\n", 0, false},
+		// No date.
+		{"type: delete\nbucket: logs\ndelete: {}\n", 0, false},
+		// Unknown key.
+		{"type: delete\nbucket: logs\nolder_than: 720h\n", 0, false},
+		// Too many workers.
+		{"type: delete\nbucket: logs\nworkers: 1000\ndelete:\n  older_than: 720h\n", 0, false},
+		{"type: warm\nbucket: media\nprefix: event/\nwarm:\n  rate: 50MiB\n", batchJobDefaultWorkers, true},
+		{"type: warm\nbucket: media\nwarm:\n  keys: [b, a]\n", batchJobDefaultWorkers, true},
+		// Invalid rate.
+		{"type: warm\nbucket: media\nwarm:\n  rate: fast\n", 0, false},
+		{"type: warm\nbucket: media\nwarm:\n  rate: 0\n", 0, false},
+		// Key outside of the prefix.
+		{"type: warm\nbucket: media\nprefix: event/\nwarm:\n  keys: [event/a, other/b]\n", 0, false},
+		{"type: pull\nbucket: datasets\npull:\n  source: {endpoint: https://s3.amazonaws.com, bucket: producer}\n  modified_after: 2020-01-01T00:00:00Z\n",
+			batchJobDefaultWorkers, true},
+		// Invalid date.
+		{"type: pull\nbucket: datasets\npull:\n  source: {endpoint: https://s3.amazonaws.com, bucket: producer}\n  modified_after: yesterday\n", 0, false},
+	}
+	for i, testCase := range testCases {
+		spec, err := parseBatchJob([]byte(testCase.job))
+		if valid := err == nil; valid != testCase.valid {
+			t.Errorf("Test %d: expected valid %t, got %v", i+1, testCase.valid, err)
+			continue
+		}
+		if testCase.valid && spec.Workers != testCase.workers {
+			t.Errorf("Test %d: expected %d workers, got %d", i+1, testCase.workers, spec.Workers)
+		}
+	}
+}
+
+// Tests that the checkpoint only passes objects handled without failure,
+// whatever order the workers finish in.
+func TestBatchJobCheckpoint(t *testing.T) {
+	job := &batchJob{}
+	a := job.add(miniogo.ObjectInfo{Key: "a"})
+	b := job.add(miniogo.ObjectInfo{Key: "b"})
+	c := job.add(miniogo.ObjectInfo{Key: "c"})
+	d := job.add(miniogo.ObjectInfo{Key: "d"})
+
+	job.finish(b, true, nil)
+	if status := job.getStatus(); status.Checkpoint != "" {
+		t.Fatalf("expected no checkpoint before a finished, got %q", status.Checkpoint)
+	}
+	job.finish(a, false, nil)
+	if status := job.getStatus(); status.Checkpoint != "b" {
+		t.Fatalf("expected checkpoint b, got %q", status.Checkpoint)
+	}
+	job.finish(d, true, nil)
+	job.finish(c, false, errRemoteMaintenance)
+	job.end(nil)
+
+	status := job.getStatus()
+	if status.Checkpoint != "b" {
+		t.Errorf("expected the checkpoint to stay before the failed object, got %q", status.Checkpoint)
+	}
+	if status.State != batchJobFailed || status.ObjectsFailed != 1 || status.ObjectsHandled != 2 || status.ObjectsScanned != 4 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+// Tests that the keys of warm jobs are handled in key order once, after
+// the checkpoint they resume from.
+func TestBatchWarmKeys(t *testing.T) {
+	spec, err := parseBatchJob([]byte("type: warm\nbucket: media\nwarm:\n  keys: [c, a, b, a]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(spec.Warm.Keys, expected) {
+		t.Fatalf("expected keys %q, got %q", expected, spec.Warm.Keys)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	var keys []string
+	for obj := range batchKeys(spec.Warm.Keys, "a", doneCh) {
+		keys = append(keys, obj.Key)
+	}
+	if expected := []string{"b", "c"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %q after a, got %q", expected, keys)
+	}
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/s3utils"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Prefix of the objects written by the probes of the remotes, they are
+// removed once the probe is done.
+const probePrefix = ".radio-probe/"
+
+// Tags set on the probe object to test tagging.
+const probeTagging = `<Tagging><TagSet><Tag><Key>radio-probe</Key><Value>1</Value></Tag></TagSet></Tagging>`
+
+// probeConfig - probing of the capabilities of the remotes of mirrors.
+type probeConfig struct {
+	// SkipStartup probes the remotes only through the admin API, not on
+	// startup. Probes write and remove a few small objects under
+	// .radio-probe/ in every remote bucket.
+	SkipStartup bool `yaml:"skip_startup"`
+}
+
+// Outcomes of the probe of a capability.
+const (
+	capabilitySupported   = "supported"
+	capabilityUnsupported = "unsupported"
+	// The probe failed for another reason, or the remote was not probed
+	// yet. Requests are passed to the remote as if it was supported.
+	capabilityUnknown = "unknown"
+)
+
+// RemoteCapability - outcome of the probe of one capability of a remote.
+type RemoteCapability struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// unsupported - returns true if the remote is known to lack the
+// capability.
+func (c RemoteCapability) unsupported() bool {
+	return c.Status == capabilityUnsupported
+}
+
+// RemoteCapabilities - capabilities of one remote bucket of a mirror.
+type RemoteCapabilities struct {
+	Bucket      string           `json:"bucket"`
+	Endpoint    string           `json:"endpoint"`
+	Remote      string           `json:"remote"`
+	Shadow      bool             `json:"shadow,omitempty"`
+	ProbedAt    time.Time        `json:"probedAt"`
+	Multipart   RemoteCapability `json:"multipart"`
+	Copy        RemoteCapability `json:"copy"`
+	Tagging     RemoteCapability `json:"tagging"`
+	VirtualHost RemoteCapability `json:"virtualHost"`
+	// MaxPartSize is configured by max_part_size, probing it would
+	// upload gigabytes.
+	MaxPartSize int64 `json:"maxPartSize"`
+}
+
+// CapabilityMatrix - capabilities of all remotes of mirror buckets.
+type CapabilityMatrix struct {
+	Remotes []RemoteCapabilities `json:"remotes"`
+}
+
+// parseMaxPartSize - parses the max_part_size of a remote, 5GiB if
+// empty.
+func parseMaxPartSize(s string) (int64, error) {
+	if s == "" {
+		return globalMaxPartSize, nil
+	}
+	size, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, err
+	}
+	if size < 5*humanize.MiByte || size > globalMaxPartSize {
+		return 0, fmt.Errorf("must be between 5MiB and 5GiB, found %s", s)
+	}
+	return int64(size), nil
+}
+
+// probeResult - returns the capability tested by a request failing with
+// err, remotes answering NotImplemented or MethodNotAllowed lack it.
+func probeResult(err error) RemoteCapability {
+	if err == nil {
+		return RemoteCapability{Status: capabilitySupported}
+	}
+	status := capabilityUnknown
+	switch errResp := miniogo.ToErrorResponse(err); {
+	case errResp.Code == "NotImplemented", errResp.Code == "MethodNotAllowed",
+		errResp.StatusCode == http.StatusNotImplemented, errResp.StatusCode == http.StatusMethodNotAllowed:
+		status = capabilityUnsupported
+	}
+	return RemoteCapability{Status: status, Error: err.Error()}
+}
+
+// probe - tests the capabilities of the remote with objects under
+// probePrefix.
+func (clnt bucketClient) probe(ctx context.Context) RemoteCapabilities {
+	caps := RemoteCapabilities{
+		Endpoint:    clnt.EndpointURL().String(),
+		Remote:      clnt.Bucket,
+		Shadow:      clnt.shadow,
+		ProbedAt:    UTCNow(),
+		MaxPartSize: clnt.maxPartSize,
+	}
+	object := probePrefix + mustGetUUID()
+	key := clnt.remoteKey(object)
+
+	uploadID, err := clnt.NewMultipartUpload(clnt.Bucket, key, miniogo.PutObjectOptions{})
+	if err == nil {
+		logger.LogIf(ctx, clnt.AbortMultipartUpload(clnt.Bucket, key, uploadID))
+	}
+	caps.Multipart = probeResult(err)
+
+	body := []byte("radio capability probe")
+	if _, err = clnt.PutObject(clnt.Bucket, key, bytes.NewReader(body), int64(len(body)), "", "", nil, nil); err != nil {
+		// Copy and tagging are tested on the probe object.
+		caps.Copy = RemoteCapability{Status: capabilityUnknown, Error: err.Error()}
+		caps.Tagging = caps.Copy
+	} else {
+		copyKey := clnt.remoteKey(object + ".copy")
+		_, err = clnt.CopyObject(clnt.Bucket, key, clnt.Bucket, copyKey, nil)
+		if err == nil {
+			logger.LogIf(ctx, clnt.RemoveObject(clnt.Bucket, copyKey))
+		}
+		caps.Copy = probeResult(err)
+
+		resp, err := clnt.signedRequest(ctx, http.MethodPut, object, "tagging", []byte(probeTagging))
+		if err == nil {
+			resp.Body.Close()
+		}
+		caps.Tagging = probeResult(err)
+		logger.LogIf(ctx, clnt.RemoveObject(clnt.Bucket, key))
+	}
+
+	caps.VirtualHost = clnt.probeVirtualHost()
+	return caps
+}
+
+// probeVirtualHost - tests if the bucket of the remote is reachable
+// virtual-host style, at BUCKET.HOST.
+func (clnt bucketClient) probeVirtualHost() RemoteCapability {
+	u := clnt.EndpointURL()
+	if net.ParseIP(u.Hostname()) != nil {
+		return RemoteCapability{Status: capabilityUnsupported, Error: "endpoint is an IP address"}
+	}
+	region := clnt.signingRegion
+	if region == "" {
+		region = s3utils.GetRegionFromURL(*u)
+	}
+	vclnt, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+		Creds:        clnt.creds,
+		Secure:       u.Scheme == "https",
+		Region:       region,
+		BucketLookup: miniogo.BucketLookupDNS,
+	})
+	if err != nil {
+		return probeResult(err)
+	}
+	vclnt.SetCustomTransport(NewCustomHTTPTransport())
+	// The bucket was found on startup, whatever fails here
+	// is due to the virtual-host style: DNS, TLS or routing.
+	switch found, err := vclnt.BucketExists(clnt.Bucket); {
+	case err != nil:
+		return RemoteCapability{Status: capabilityUnsupported, Error: err.Error()}
+	case !found:
+		return RemoteCapability{Status: capabilityUnsupported, Error: "bucket not found"}
+	}
+	return RemoteCapability{Status: capabilitySupported}
+}
+
+// capabilities - returns the capabilities found by the last probe of the
+// remote, false if it was not probed yet.
+func (clnt bucketClient) capabilities() (RemoteCapabilities, bool) {
+	if clnt.state == nil {
+		return RemoteCapabilities{}, false
+	}
+	clnt.state.mu.Lock()
+	defer clnt.state.mu.Unlock()
+	if clnt.state.capabilities == nil {
+		return RemoteCapabilities{}, false
+	}
+	return *clnt.state.capabilities, true
+}
+
+func (clnt bucketClient) setCapabilities(caps RemoteCapabilities) {
+	clnt.state.mu.Lock()
+	clnt.state.capabilities = &caps
+	clnt.state.mu.Unlock()
+}
+
+// lacking - returns the names of the capabilities caps is known to lack,
+// but for the virtual-host style which radio does not rely on.
+func (caps RemoteCapabilities) lacking() []string {
+	var names []string
+	for _, c := range []struct {
+		name string
+		RemoteCapability
+	}{
+		{"multipart", caps.Multipart},
+		{"copy", caps.Copy},
+		{"tagging", caps.Tagging},
+	} {
+		if c.unsupported() {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// probeRemotes - probes all remotes of mirrors in parallel and records
+// their capabilities, remotes lacking some are logged.
+func (l *radioObjects) probeRemotes(ctx context.Context) CapabilityMatrix {
+	var wg sync.WaitGroup
+	for bucket, rs3s := range l.mirrorClients {
+		clnts := append(append([]bucketClient{}, rs3s.clnts...), rs3s.shadows...)
+		for _, clnt := range clnts {
+			wg.Add(1)
+			go func(bucket string, clnt bucketClient) {
+				defer wg.Done()
+				caps := clnt.probe(ctx)
+				caps.Bucket = bucket
+				clnt.setCapabilities(caps)
+				if lacking := caps.lacking(); len(lacking) > 0 {
+					logger.LogIf(ctx, fmt.Errorf("remote %s/%s of bucket %s does not support %s",
+						caps.Endpoint, caps.Remote, bucket, strings.Join(lacking, ", ")))
+				}
+			}(bucket, clnt)
+		}
+	}
+	wg.Wait()
+	return l.getCapabilityMatrix()
+}
+
+// getCapabilityMatrix - returns the capabilities of all remotes of
+// mirrors, unknown for remotes not probed yet.
+func (l *radioObjects) getCapabilityMatrix() CapabilityMatrix {
+	var matrix CapabilityMatrix
+	for bucket, rs3s := range l.mirrorClients {
+		for _, clnt := range append(append([]bucketClient{}, rs3s.clnts...), rs3s.shadows...) {
+			caps, ok := clnt.capabilities()
+			if !ok {
+				unknown := RemoteCapability{Status: capabilityUnknown}
+				caps = RemoteCapabilities{
+					Bucket:      bucket,
+					Endpoint:    clnt.EndpointURL().String(),
+					Remote:      clnt.Bucket,
+					Shadow:      clnt.shadow,
+					Multipart:   unknown,
+					Copy:        unknown,
+					Tagging:     unknown,
+					VirtualHost: unknown,
+					MaxPartSize: clnt.maxPartSize,
+				}
+			}
+			matrix.Remotes = append(matrix.Remotes, caps)
+		}
+	}
+	sort.Slice(matrix.Remotes, func(i, j int) bool {
+		a, b := matrix.Remotes[i], matrix.Remotes[j]
+		if a.Bucket != b.Bucket {
+			return a.Bucket < b.Bucket
+		}
+		return a.Endpoint+"/"+a.Remote < b.Endpoint+"/"+b.Remote
+	})
+	return matrix
+}
+
+func multipartCapability(caps RemoteCapabilities) RemoteCapability { return caps.Multipart }
+func copyCapability(caps RemoteCapabilities) RemoteCapability      { return caps.Copy }
+
+// requireCapability - fails with NotImplemented if a remote of rs3s
+// taking writes is known to lack the capability returned by get, instead
+// of failing the request on the remote halfway. Shadows are not checked,
+// their failures never fail requests.
+func (rs3s mirrorConfig) requireCapability(get func(RemoteCapabilities) RemoteCapability) error {
+	for _, clnt := range rs3s.clnts {
+		if clnt.inMaintenance() {
+			continue
+		}
+		if caps, ok := clnt.capabilities(); ok && get(caps).unsupported() {
+			return NotImplemented{}
+		}
+	}
+	return nil
+}
+
+// maxPartSize - returns the largest part all remotes of rs3s accept.
+func (rs3s mirrorConfig) maxPartSize() int64 {
+	size := int64(globalMaxPartSize)
+	for _, clnt := range rs3s.clnts {
+		if clnt.maxPartSize > 0 && clnt.maxPartSize < size {
+			size = clnt.maxPartSize
+		}
+	}
+	return size
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestParseMaxPartSize(t *testing.T) {
+	testCases := []struct {
+		s       string
+		size    int64
+		success bool
+	}{
+		{"", globalMaxPartSize, true},
+		{"100MiB", 100 * humanize.MiByte, true},
+		{"5GiB", globalMaxPartSize, true},
+		{"1MiB", 0, false},
+		{"6GiB", 0, false},
+		{"large", 0, false},
+	}
+	for i, testCase := range testCases {
+		size, err := parseMaxPartSize(testCase.s)
+		if (err == nil) != testCase.success {
+			t.Fatalf("Test %d: expected success %t, got %v", i+1, testCase.success, err)
+		}
+		if size != testCase.size {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.size, size)
+		}
+	}
+}
+
+func TestProbeResult(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status string
+	}{
+		{nil, capabilitySupported},
+		{miniogo.ErrorResponse{Code: "NotImplemented", StatusCode: http.StatusNotImplemented}, capabilityUnsupported},
+		{miniogo.ErrorResponse{Code: "MethodNotAllowed", StatusCode: http.StatusMethodNotAllowed}, capabilityUnsupported},
+		{miniogo.ErrorResponse{Code: "501 Not Implemented", StatusCode: http.StatusNotImplemented}, capabilityUnsupported},
+		{miniogo.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, capabilityUnknown},
+		{errors.New("connection refused"), capabilityUnknown},
+	}
+	for i, testCase := range testCases {
+		if c := probeResult(testCase.err); c.Status != testCase.status {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.status, c.Status)
+		}
+	}
+}
+
+func TestRequireCapability(t *testing.T) {
+	newClient := func(multipart string, maxPartSize int64) bucketClient {
+		clnt := bucketClient{
+			maxPartSize: maxPartSize,
+			state:       &remoteState{pending: make(map[string]struct{})},
+		}
+		if multipart != "" {
+			clnt.setCapabilities(RemoteCapabilities{
+				Multipart: RemoteCapability{Status: multipart},
+			})
+		}
+		return clnt
+	}
+
+	// Remotes not probed yet or with unknown capabilities are assumed
+	// capable.
+	rs3s := mirrorConfig{clnts: []bucketClient{
+		newClient("", globalMaxPartSize),
+		newClient(capabilityUnknown, 100*humanize.MiByte),
+		newClient(capabilitySupported, 0),
+	}}
+	if err := rs3s.requireCapability(multipartCapability); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if size := rs3s.maxPartSize(); size != 100*humanize.MiByte {
+		t.Errorf("expected the smallest max part size, got %d", size)
+	}
+
+	rs3s.clnts = append(rs3s.clnts, newClient(capabilityUnsupported, 0))
+	if _, ok := rs3s.requireCapability(multipartCapability).(NotImplemented); !ok {
+		t.Error("expected a remote lacking multipart uploads to fail fast")
+	}
+	if err := rs3s.requireCapability(copyCapability); err != nil {
+		t.Errorf("expected no error for another capability, got %v", err)
+	}
+
+	// Remotes in maintenance take no writes.
+	rs3s.clnts[3].state.maintenance.Store(true)
+	if err := rs3s.requireCapability(multipartCapability); err != nil {
+		t.Errorf("expected a remote in maintenance to be ignored, got %v", err)
+	}
+}
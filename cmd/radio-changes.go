@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Operations recorded by the change feed.
+const (
+	changeOpPut      = "PutObject"
+	changeOpCopy     = "CopyObject"
+	changeOpComplete = "CompleteMultipartUpload"
+	changeOpDelete   = "DeleteObject"
+)
+
+const (
+	defaultChangeRetention   = 7 * 24 * time.Hour
+	defaultChangeSegmentSize = 64 << 20
+
+	// Suffix of the segment files, named after the sequence number of
+	// their first event.
+	changeSegmentSuffix = ".log"
+
+	// Interval between removals of expired segments.
+	changeExpiryInterval = time.Hour
+
+	// Longest event line read from a segment.
+	maxChangeEventSize = 1 << 20
+)
+
+var (
+	errChangeCursorExpired = errors.New("cursor is older than the retained changes")
+	errChangeCursorInvalid = errors.New("cursor is ahead of the change feed")
+)
+
+// changeFeedConfig - log of the mutations performed through this server,
+// kept in segment files in Dir for Retention.
+type changeFeedConfig struct {
+	Dir       string        `yaml:"dir"`
+	Retention time.Duration `yaml:"retention"`
+	// SegmentSize of the files of the log, such as 64MiB.
+	SegmentSize string `yaml:"segment_size"`
+	// Fsync syncs every event to disk before the request completes.
+	Fsync bool `yaml:"fsync"`
+}
+
+// validateChangeFeedConfig - validates the change feed config.
+func validateChangeFeedConfig(errs *radioConfigErrors, path string, c changeFeedConfig) {
+	if c.Dir == "" {
+		if c != (changeFeedConfig{}) {
+			errs.add(path+".dir", "required for a change feed")
+		}
+		return
+	}
+	if c.Retention < 0 {
+		errs.add(path+".retention", "must not be negative")
+	}
+	if c.SegmentSize != "" {
+		if _, err := humanize.ParseBytes(c.SegmentSize); err != nil {
+			errs.add(path+".segment_size", "%v", err)
+		}
+	}
+}
+
+// ChangeEvent - a mutation performed through radio. Radio buckets are not
+// versioned, ETag identifies the content written.
+type ChangeEvent struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"`
+	Bucket string    `json:"bucket"`
+	Key    string    `json:"key"`
+	Size   int64     `json:"size"`
+	ETag   string    `json:"etag,omitempty"`
+}
+
+// ChangesResponse - events after a cursor, Cursor is the cursor of the
+// next request.
+type ChangesResponse struct {
+	Events []ChangeEvent `json:"events"`
+	Cursor uint64        `json:"cursor"`
+}
+
+// changeFeed - append only log of ChangeEvents. Cursors are the sequence
+// number of the last event read, 0 reads from the oldest retained event.
+type changeFeed struct {
+	dir         string
+	retention   time.Duration
+	segmentSize int64
+	fsync       bool
+
+	mu sync.Mutex
+	// seq of the last event written.
+	seq uint64
+	// segments holds the first sequence number of each segment file,
+	// in order, the last one is file.
+	segments []uint64
+	file     *os.File
+	fileSize int64
+	// notify is closed, and replaced, once events are written.
+	notify chan struct{}
+}
+
+func changeSegmentPath(dir string, first uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", first, changeSegmentSuffix))
+}
+
+// newChangeFeed - opens the change feed in c.Dir, appending after the
+// last complete event.
+func newChangeFeed(c changeFeedConfig) (*changeFeed, error) {
+	f := &changeFeed{
+		dir:         c.Dir,
+		retention:   c.Retention,
+		segmentSize: defaultChangeSegmentSize,
+		fsync:       c.Fsync,
+		notify:      make(chan struct{}),
+	}
+	if f.retention == 0 {
+		f.retention = defaultChangeRetention
+	}
+	if c.SegmentSize != "" {
+		size, err := humanize.ParseBytes(c.SegmentSize)
+		if err != nil {
+			return nil, err
+		}
+		f.segmentSize = int64(size)
+	}
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, changeSegmentSuffix) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, changeSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		f.segments = append(f.segments, first)
+	}
+	sort.Slice(f.segments, func(i, j int) bool { return f.segments[i] < f.segments[j] })
+
+	if len(f.segments) == 0 {
+		return f, f.rotate(1)
+	}
+	last := f.segments[len(f.segments)-1]
+	if f.seq, f.fileSize, err = recoverChangeSegment(changeSegmentPath(f.dir, last)); err != nil {
+		return nil, err
+	}
+	if f.seq == 0 {
+		f.seq = last - 1
+	}
+	f.file, err = os.OpenFile(changeSegmentPath(f.dir, last), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// recoverChangeSegment - returns the seq of the last event of the segment
+// at path, 0 if it is empty, and truncates a partially written event left
+// by a crash.
+func recoverChangeSegment(path string) (seq uint64, size int64, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		if err = os.Truncate(path, int64(complete)); err != nil {
+			return 0, 0, err
+		}
+		data = data[:complete]
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if last := lines[len(lines)-1]; len(last) > 0 {
+		var ev ChangeEvent
+		if err = json.Unmarshal(last, &ev); err != nil {
+			return 0, 0, fmt.Errorf("%s: %v", path, err)
+		}
+		seq = ev.Seq
+	}
+	return seq, int64(complete), nil
+}
+
+// rotate - starts the segment of the event first, f.mu must be held.
+func (f *changeFeed) rotate(first uint64) error {
+	file, err := os.OpenFile(changeSegmentPath(f.dir, first), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file, f.fileSize = file, 0
+	f.segments = append(f.segments, first)
+	return nil
+}
+
+// record - appends an event of op on bucket/object, f may be nil.
+func (f *changeFeed) record(ctx context.Context, op string, objInfo ObjectInfo) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ev := ChangeEvent{
+		Seq:    f.seq + 1,
+		Time:   UTCNow(),
+		Op:     op,
+		Bucket: objInfo.Bucket,
+		Key:    objInfo.Name,
+		Size:   objInfo.Size,
+		ETag:   objInfo.ETag,
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+	line = append(line, '\n')
+
+	if f.fileSize > 0 && f.fileSize+int64(len(line)) > f.segmentSize {
+		if err = f.rotate(ev.Seq); err != nil {
+			logger.LogIf(ctx, fmt.Errorf("change feed: %v", err))
+			return
+		}
+	}
+	n, err := f.file.Write(line)
+	f.fileSize += int64(n)
+	if err == nil {
+		if f.fsync {
+			err = f.file.Sync()
+		} else {
+			err = globalJournalSync.written(f.file)
+		}
+	}
+	if err != nil {
+		logger.LogIf(ctx, fmt.Errorf("change feed: unable to record %s of %s/%s: %v", op, ev.Bucket, ev.Key, err))
+		if n > 0 && n < len(line) {
+			// Don't leave a partial event before the next one.
+			if terr := f.file.Truncate(f.fileSize - int64(n)); terr == nil {
+				f.fileSize -= int64(n)
+			}
+		}
+		return
+	}
+	f.seq = ev.Seq
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// read - returns up to limit events after cursor of bucket and below
+// prefix, all buckets if bucket is empty, and the cursor after the events
+// read.
+func (f *changeFeed) read(cursor uint64, limit int, bucket, prefix string) ([]ChangeEvent, uint64, error) {
+	f.mu.Lock()
+	last := f.seq
+	segments := append([]uint64(nil), f.segments...)
+	f.mu.Unlock()
+
+	if cursor == 0 {
+		cursor = segments[0] - 1
+	}
+	switch {
+	case cursor > last:
+		return nil, cursor, errChangeCursorInvalid
+	case cursor == last:
+		return nil, cursor, nil
+	case cursor+1 < segments[0]:
+		return nil, cursor, errChangeCursorExpired
+	}
+
+	// Start with the last segment holding events before cursor+1.
+	start := sort.Search(len(segments), func(i int) bool { return segments[i] > cursor+1 }) - 1
+	events := []ChangeEvent{}
+	for _, first := range segments[start:] {
+		file, err := os.Open(changeSegmentPath(f.dir, first))
+		if os.IsNotExist(err) {
+			// Expired while reading.
+			return nil, cursor, errChangeCursorExpired
+		}
+		if err != nil {
+			return nil, cursor, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxChangeEventSize)
+		for scanner.Scan() {
+			var ev ChangeEvent
+			if err = json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+				file.Close()
+				return nil, cursor, err
+			}
+			if ev.Seq <= cursor {
+				continue
+			}
+			if ev.Seq > last || len(events) >= limit {
+				file.Close()
+				return events, cursor, nil
+			}
+			cursor = ev.Seq
+			if (bucket == "" || ev.Bucket == bucket) && strings.HasPrefix(ev.Key, prefix) {
+				events = append(events, ev)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, cursor, err
+		}
+	}
+	return events, cursor, nil
+}
+
+// latest - returns the cursor of the last event.
+func (f *changeFeed) latest() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq
+}
+
+// wait - waits up to timeout for events after cursor, returns false if
+// none were written.
+func (f *changeFeed) wait(ctx context.Context, cursor uint64, timeout time.Duration) bool {
+	f.mu.Lock()
+	seq, notify := f.seq, f.notify
+	f.mu.Unlock()
+	if seq > cursor {
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-notify:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// expire - removes the segments whose last event is older than the
+// retention, the segment written to is kept.
+func (f *changeFeed) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cutoff := UTCNow().Add(-f.retention)
+	for len(f.segments) > 1 {
+		path := changeSegmentPath(f.dir, f.segments[0])
+		fi, err := os.Stat(path)
+		if err == nil && fi.ModTime().After(cutoff) {
+			return
+		}
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			logger.LogIf(context.Background(), fmt.Errorf("change feed: %v", err))
+			return
+		}
+		f.segments = f.segments[1:]
+	}
+}
+
+// runExpiry - removes expired segments until the process exits.
+func (f *changeFeed) runExpiry() {
+	ticker := time.NewTicker(changeExpiryInterval)
+	defer ticker.Stop()
+	for {
+		f.expire()
+		<-ticker.C
+	}
+}
+
+// writeChangeEvents - streams the events after cursor as server-sent
+// events, with the sequence number as their id, until the client goes
+// away.
+func (f *changeFeed) writeChangeEvents(ctx context.Context, w io.Writer, flush func(), cursor uint64,
+	limit int, bucket, prefix string, keepAlive time.Duration) error {
+	for {
+		events, next, err := f.read(cursor, limit, bucket, prefix)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.Seq, data); err != nil {
+				return err
+			}
+		}
+		if len(events) > 0 {
+			flush()
+		}
+		if cursor = next; f.wait(ctx, cursor, keepAlive) {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return nil
+		}
+		// A comment keeps proxies from closing the idle stream.
+		if _, err = io.WriteString(w, ": keep-alive\n\n"); err != nil {
+			return err
+		}
+		flush()
+	}
+}
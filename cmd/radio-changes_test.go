@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateChangeFeedConfig(t *testing.T) {
+	testCases := []struct {
+		c    changeFeedConfig
+		errs int
+	}{
+		{changeFeedConfig{}, 0},
+		{changeFeedConfig{Dir: "/var/lib/radio/changes", Retention: 24 * time.Hour, SegmentSize: "16MiB", Fsync: true}, 0},
+		{changeFeedConfig{Fsync: true}, 1},
+		{changeFeedConfig{Dir: "/var/lib/radio/changes", Retention: -time.Hour, SegmentSize: "big"}, 2},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateChangeFeedConfig(&errs, "changes", testCase.c)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func changeKeys(events []ChangeEvent) string {
+	var keys []string
+	for _, ev := range events {
+		keys = append(keys, ev.Op+":"+ev.Bucket+"/"+ev.Key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestChangeFeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-changes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Segments of a single event.
+	cfg := changeFeedConfig{Dir: dir, SegmentSize: "200B"}
+	f, err := newChangeFeed(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	f.record(ctx, changeOpPut, ObjectInfo{Bucket: "photos", Name: "a.jpg", Size: 10, ETag: "etag-a"})
+	f.record(ctx, changeOpPut, ObjectInfo{Bucket: "docs", Name: "b.txt", Size: 20, ETag: "etag-b"})
+	f.record(ctx, changeOpCopy, ObjectInfo{Bucket: "photos", Name: "c.jpg", Size: 10, ETag: "etag-a"})
+	f.record(ctx, changeOpDelete, ObjectInfo{Bucket: "photos", Name: "a.jpg"})
+	if len(f.segments) < 2 {
+		t.Fatalf("expected the log to be rotated, got segments %v", f.segments)
+	}
+
+	events, cursor, err := f.read(0, 2, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := changeKeys(events); keys != "PutObject:photos/a.jpg,PutObject:docs/b.txt" || cursor != 2 {
+		t.Fatalf("unexpected events %s, cursor %d", keys, cursor)
+	}
+	events, cursor, err = f.read(cursor, 10, "photos", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := changeKeys(events); keys != "CopyObject:photos/c.jpg,DeleteObject:photos/a.jpg" || cursor != 4 {
+		t.Fatalf("unexpected events %s, cursor %d", keys, cursor)
+	}
+	if events, cursor, err = f.read(4, 10, "", ""); err != nil || len(events) != 0 || cursor != 4 {
+		t.Fatalf("expected no events after the last one, got %v %d %v", events, cursor, err)
+	}
+	if _, _, err = f.read(5, 10, "", ""); err != errChangeCursorInvalid {
+		t.Fatalf("expected %v, got %v", errChangeCursorInvalid, err)
+	}
+
+	// Reopening continues the sequence after a partially written event.
+	f.file.Write([]byte(`{"seq":5,"ti`))
+	f.file.Close()
+	if f, err = newChangeFeed(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if f.latest() != 4 {
+		t.Fatalf("expected cursor 4 after reopening, got %d", f.latest())
+	}
+	f.record(ctx, changeOpComplete, ObjectInfo{Bucket: "docs", Name: "d.bin", Size: 30, ETag: "etag-d"})
+	if events, cursor, err = f.read(4, 10, "docs", "d"); err != nil || changeKeys(events) != "CompleteMultipartUpload:docs/d.bin" || cursor != 5 {
+		t.Fatalf("unexpected events %v, cursor %d, err %v", events, cursor, err)
+	}
+
+	// Expired segments are removed, their cursors are rejected.
+	old := UTCNow().Add(-2 * defaultChangeRetention)
+	for _, first := range f.segments[:2] {
+		if err = os.Chtimes(changeSegmentPath(dir, first), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.expire()
+	if _, _, err = f.read(1, 10, "", ""); err != errChangeCursorExpired {
+		t.Fatalf("expected %v, got %v", errChangeCursorExpired, err)
+	}
+	if events, _, err = f.read(0, 10, "", ""); err != nil || len(events) != 3 || events[0].Seq != 3 {
+		t.Fatalf("expected the retained events from cursor 0, got %v %v", events, err)
+	}
+	f.file.Close()
+}
+
+func TestChangeFeedWait(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-changes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f, err := newChangeFeed(changeFeedConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.file.Close()
+
+	if f.wait(context.Background(), 0, 10*time.Millisecond) {
+		t.Fatal("expected no changes")
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		f.record(context.Background(), changeOpPut, ObjectInfo{Bucket: "photos", Name: "a.jpg"})
+	}()
+	if !f.wait(context.Background(), 0, time.Minute) {
+		t.Fatal("expected to be notified of the change")
+	}
+
+	// Streams end once the client goes away.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	if err = f.writeChangeEvents(ctx, &buf, func() {}, 0, 10, "", "", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "id: 1\ndata: {\"seq\":1,") || !strings.Contains(buf.String(), ": keep-alive\n\n") {
+		t.Errorf("unexpected stream %q", buf.String())
+	}
+}
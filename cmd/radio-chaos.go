@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	xhttp "github.com/minio/radio/cmd/http"
+	"gopkg.in/yaml.v2"
+)
+
+// Faults injected into the requests to remotes.
+const (
+	// chaosFaultError answers the request with an S3 error response.
+	chaosFaultError = "error"
+	// chaosFaultReset fails the request like a connection reset.
+	chaosFaultReset = "reset"
+	// chaosFaultTruncate cuts the response body of the remote in half.
+	chaosFaultTruncate = "truncate"
+)
+
+// chaosAPIs - the S3 APIs of the requests to remotes, as told apart by
+// s3RequestAPI.
+var chaosAPIs = map[string]bool{
+	"HeadBucket":              true,
+	"GetBucketLocation":       true,
+	"ListObjects":             true,
+	"ListMultipartUploads":    true,
+	"DeleteObjects":           true,
+	"HeadObject":              true,
+	"GetObject":               true,
+	"PutObject":               true,
+	"CopyObject":              true,
+	"DeleteObject":            true,
+	"RestoreObject":           true,
+	"GetObjectTagging":        true,
+	"PutObjectTagging":        true,
+	"DeleteObjectTagging":     true,
+	"NewMultipartUpload":      true,
+	"PutObjectPart":           true,
+	"CopyObjectPart":          true,
+	"ListObjectParts":         true,
+	"CompleteMultipartUpload": true,
+	"AbortMultipartUpload":    true,
+}
+
+// globalChaos - the faults injected into the requests to remotes, nil
+// unless the server was started with --chaos.
+var globalChaos *chaosInjector
+
+// ChaosRule - a fault injected into the requests of APIs to the remote
+// bucket Bucket at Endpoint, of all remotes and APIs if empty.
+type ChaosRule struct {
+	Endpoint string   `yaml:"endpoint" json:"endpoint,omitempty"`
+	Bucket   string   `yaml:"bucket" json:"bucket,omitempty"`
+	APIs     []string `yaml:"apis" json:"apis,omitempty"`
+	// Probability of a matching request to be faulted, 1 if unset.
+	Probability float64 `yaml:"probability" json:"probability"`
+	// Latency delays matching requests, such as 2s, before the fault.
+	Latency string `yaml:"latency" json:"latency,omitempty"`
+	// Fault is error, reset or truncate, requests are only delayed if
+	// empty.
+	Fault string `yaml:"fault" json:"fault,omitempty"`
+	// StatusCode of the error responses, 503 by default.
+	StatusCode int `yaml:"status_code" json:"statusCode,omitempty"`
+	// Injected counts the requests faulted by the rule.
+	Injected uint64 `yaml:"-" json:"injected"`
+
+	latency time.Duration
+}
+
+// ChaosStatus - the faults injected into the requests to remotes.
+type ChaosStatus struct {
+	Rules []ChaosRule `json:"rules"`
+}
+
+// parseChaosRules - parses and validates the YAML or JSON rules in data.
+func parseChaosRules(data []byte) ([]ChaosRule, error) {
+	var spec struct {
+		Rules []ChaosRule `yaml:"rules"`
+	}
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, err
+	}
+	for i := range spec.Rules {
+		rule := &spec.Rules[i]
+		if rule.Endpoint != "" {
+			rule.Endpoint = strings.TrimSuffix(rule.Endpoint, "/")
+		}
+		for _, api := range rule.APIs {
+			if !chaosAPIs[api] {
+				return nil, fmt.Errorf("rules[%d]: unknown api %s", i, api)
+			}
+		}
+		if rule.Probability == 0 {
+			rule.Probability = 1
+		}
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return nil, fmt.Errorf("rules[%d]: probability must be between 0 and 1", i)
+		}
+		if rule.Latency != "" {
+			latency, err := time.ParseDuration(rule.Latency)
+			if err != nil || latency < 0 {
+				return nil, fmt.Errorf("rules[%d]: invalid latency %s", i, rule.Latency)
+			}
+			rule.latency = latency
+		}
+		switch rule.Fault {
+		case "":
+			if rule.latency == 0 {
+				return nil, fmt.Errorf("rules[%d]: fault or latency is required", i)
+			}
+		case chaosFaultError:
+			if rule.StatusCode == 0 {
+				rule.StatusCode = http.StatusServiceUnavailable
+			}
+		case chaosFaultReset, chaosFaultTruncate:
+		default:
+			return nil, fmt.Errorf("rules[%d]: fault must be %s, %s or %s", i,
+				chaosFaultError, chaosFaultReset, chaosFaultTruncate)
+		}
+		if rule.StatusCode != 0 && (rule.Fault != chaosFaultError || rule.StatusCode < 400 || rule.StatusCode > 599) {
+			return nil, fmt.Errorf("rules[%d]: status_code must be between 400 and 599 for error faults", i)
+		}
+		rule.Injected = 0
+	}
+	return spec.Rules, nil
+}
+
+// chaosInjector - the rules of the faults injected into the requests to
+// remotes, replaced through the admin API.
+type chaosInjector struct {
+	mu    sync.Mutex
+	rules []ChaosRule
+}
+
+// set - replaces the rules, no faults are injected if empty.
+func (c *chaosInjector) set(rules []ChaosRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = rules
+}
+
+// status - returns the rules and the requests faulted by each.
+func (c *chaosInjector) status() ChaosStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := ChaosStatus{Rules: make([]ChaosRule, len(c.rules))}
+	copy(status.Rules, c.rules)
+	return status
+}
+
+// pick - returns the rule faulting a request of api to bucket at
+// endpoint, false if the request is left alone. The first matching rule
+// drawn by its probability applies.
+func (c *chaosInjector) pick(endpoint, bucket, api string) (ChaosRule, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if (rule.Endpoint != "" && rule.Endpoint != endpoint) || (rule.Bucket != "" && rule.Bucket != bucket) {
+			continue
+		}
+		if len(rule.APIs) > 0 && !contains(rule.APIs, api) {
+			continue
+		}
+		if rule.Probability < 1 && rand.Float64() >= rule.Probability {
+			continue
+		}
+		rule.Injected++
+		return *rule, true
+	}
+	return ChaosRule{}, false
+}
+
+// s3RequestAPI - returns the S3 API of the request r to bucket, sent path
+// or virtual-host style.
+func s3RequestAPI(r *http.Request, bucket string) string {
+	object := strings.TrimPrefix(r.URL.Path, SlashSeparator)
+	if !strings.HasPrefix(r.URL.Host, bucket+".") {
+		if object == bucket {
+			object = ""
+		}
+		object = strings.TrimPrefix(object, bucket+SlashSeparator)
+	}
+	query := r.URL.Query()
+	has := func(key string) bool {
+		_, ok := query[key]
+		return ok
+	}
+	_, copySource := r.Header[xhttp.AmzCopySource]
+
+	if object == "" {
+		switch {
+		case r.Method == http.MethodHead:
+			return "HeadBucket"
+		case r.Method == http.MethodPost && has("delete"):
+			return "DeleteObjects"
+		case has("location"):
+			return "GetBucketLocation"
+		case has("uploads"):
+			return "ListMultipartUploads"
+		}
+		return "ListObjects"
+	}
+	switch {
+	case has("uploadId"):
+		switch r.Method {
+		case http.MethodPut:
+			if copySource {
+				return "CopyObjectPart"
+			}
+			return "PutObjectPart"
+		case http.MethodPost:
+			return "CompleteMultipartUpload"
+		case http.MethodDelete:
+			return "AbortMultipartUpload"
+		}
+		return "ListObjectParts"
+	case has("uploads"):
+		return "NewMultipartUpload"
+	case has("tagging"):
+		switch r.Method {
+		case http.MethodPut:
+			return "PutObjectTagging"
+		case http.MethodDelete:
+			return "DeleteObjectTagging"
+		}
+		return "GetObjectTagging"
+	case has("restore"):
+		return "RestoreObject"
+	}
+	switch r.Method {
+	case http.MethodHead:
+		return "HeadObject"
+	case http.MethodPut:
+		if copySource {
+			return "CopyObject"
+		}
+		return "PutObject"
+	case http.MethodDelete:
+		return "DeleteObject"
+	}
+	return "GetObject"
+}
+
+// chaosErrorCodes - the S3 error codes of injected error responses.
+var chaosErrorCodes = map[int]string{
+	http.StatusBadRequest:          "InvalidRequest",
+	http.StatusForbidden:           "AccessDenied",
+	http.StatusNotFound:            "NoSuchKey",
+	http.StatusInternalServerError: "InternalError",
+	http.StatusServiceUnavailable:  "ServiceUnavailable",
+}
+
+// chaosErrorResponse - returns an S3 error response with statusCode to r.
+func chaosErrorResponse(r *http.Request, statusCode int) *http.Response {
+	code, ok := chaosErrorCodes[statusCode]
+	if !ok {
+		code = strings.Replace(http.StatusText(statusCode), " ", "", -1)
+	}
+	var body string
+	if r.Method != http.MethodHead {
+		body = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>`+
+			`<Error><Code>%s</Code><Message>Fault injected by radio.</Message><Resource>%s</Resource></Error>`,
+			code, r.URL.Path)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/xml"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// errChaosReset - the error of requests failed by reset faults.
+var errChaosReset = &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+// truncatedBody - a response body ending early with io.ErrUnexpectedEOF.
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// chaosTransport - injects the faults of c into the requests to the
+// remote bucket at endpoint.
+type chaosTransport struct {
+	c        *chaosInjector
+	endpoint string
+	bucket   string
+	base     http.RoundTripper
+}
+
+// newChaosTransport - returns base injecting the faults of globalChaos
+// into the requests to the remote bucket at endpoint, base itself unless
+// the server was started with --chaos.
+func newChaosTransport(endpoint, bucket string, base http.RoundTripper) http.RoundTripper {
+	if globalChaos == nil {
+		return base
+	}
+	return chaosTransport{c: globalChaos, endpoint: strings.TrimSuffix(endpoint, "/"), bucket: bucket, base: base}
+}
+
+func (t chaosTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rule, ok := t.c.pick(t.endpoint, t.bucket, s3RequestAPI(r, t.bucket))
+	if !ok {
+		return t.base.RoundTrip(r)
+	}
+	// The body of requests is closed by RoundTrip, also when it fails.
+	closeBody := func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+	}
+	if rule.latency > 0 {
+		timer := time.NewTimer(rule.latency)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			closeBody()
+			return nil, r.Context().Err()
+		}
+	}
+	switch rule.Fault {
+	case chaosFaultError:
+		closeBody()
+		return chaosErrorResponse(r, rule.StatusCode), nil
+	case chaosFaultReset:
+		closeBody()
+		return nil, errChaosReset
+	case chaosFaultTruncate:
+		resp, err := t.base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: resp.ContentLength / 2}
+		return resp, nil
+	}
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChaosRules(t *testing.T) {
+	rules, err := parseChaosRules([]byte(`
+rules:
+  - endpoint: http://minio2:9000/
+    bucket: bucket2
+    apis: [GetObject, HeadObject]
+    fault: error
+  - probability: 0.1
+    latency: 2s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Endpoint != "http://minio2:9000" || rules[0].StatusCode != http.StatusServiceUnavailable || rules[0].Probability != 1 {
+		t.Errorf("unexpected defaults of %+v", rules[0])
+	}
+	if rules[1].latency != 2*time.Second || rules[1].Fault != "" {
+		t.Errorf("unexpected latency rule %+v", rules[1])
+	}
+
+	for i, data := range []string{
+		`rules: [{fault: explode}]`,
+		`rules: [{fault: error, apis: [GetObjects]}]`,
+		`rules: [{fault: error, probability: 1.5}]`,
+		`rules: [{fault: error, status_code: 200}]`,
+		`rules: [{fault: reset, status_code: 500}]`,
+		`rules: [{latency: soon}]`,
+		`rules: [{bucket: bucket2}]`,
+		`rules: [{fault: error, unknown: true}]`,
+	} {
+		if _, err = parseChaosRules([]byte(data)); err == nil {
+			t.Errorf("Test %d: expected %s to be invalid", i+1, data)
+		}
+	}
+	if rules, err = parseChaosRules([]byte(`{"rules": []}`)); err != nil || len(rules) != 0 {
+		t.Errorf("expected no rules, got %v, %v", rules, err)
+	}
+}
+
+func TestS3RequestAPI(t *testing.T) {
+	testCases := []struct {
+		method, target string
+		copySource     bool
+		api            string
+	}{
+		{http.MethodHead, "http://minio:9000/bucket", false, "HeadBucket"},
+		{http.MethodGet, "http://minio:9000/bucket/?location=", false, "GetBucketLocation"},
+		{http.MethodGet, "http://minio:9000/bucket/?list-type=2&prefix=a", false, "ListObjects"},
+		{http.MethodPost, "http://minio:9000/bucket/?delete=", false, "DeleteObjects"},
+		{http.MethodGet, "http://minio:9000/bucket/a/b.txt", false, "GetObject"},
+		{http.MethodGet, "http://minio:9000/bucketx/a.txt", false, "GetObject"},
+		{http.MethodHead, "http://minio:9000/bucket/a.txt", false, "HeadObject"},
+		{http.MethodPut, "http://minio:9000/bucket/a.txt", false, "PutObject"},
+		{http.MethodPut, "http://minio:9000/bucket/a.txt", true, "CopyObject"},
+		{http.MethodDelete, "http://minio:9000/bucket/a.txt", false, "DeleteObject"},
+		{http.MethodPost, "http://minio:9000/bucket/a.txt?uploads=", false, "NewMultipartUpload"},
+		{http.MethodPut, "http://minio:9000/bucket/a.txt?partNumber=1&uploadId=x", false, "PutObjectPart"},
+		{http.MethodPut, "http://minio:9000/bucket/a.txt?partNumber=1&uploadId=x", true, "CopyObjectPart"},
+		{http.MethodPost, "http://minio:9000/bucket/a.txt?uploadId=x", false, "CompleteMultipartUpload"},
+		{http.MethodDelete, "http://minio:9000/bucket/a.txt?uploadId=x", false, "AbortMultipartUpload"},
+		{http.MethodGet, "http://minio:9000/bucket/a.txt?uploadId=x", false, "ListObjectParts"},
+		{http.MethodPut, "http://minio:9000/bucket/a.txt?tagging=", false, "PutObjectTagging"},
+		{http.MethodPost, "http://minio:9000/bucket/a.txt?restore=", false, "RestoreObject"},
+		// Virtual-host style.
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/", false, "ListObjects"},
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/bucket/a.txt", false, "GetObject"},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(testCase.method, testCase.target, nil)
+		if testCase.copySource {
+			r.Header.Set("X-Amz-Copy-Source", "/bucket/b.txt")
+		}
+		if api := s3RequestAPI(r, "bucket"); api != testCase.api {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.api, api)
+		}
+	}
+}
+
+func TestChaosTransport(t *testing.T) {
+	defer func(c *chaosInjector) { globalChaos = c }(globalChaos)
+
+	var served int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		io.WriteString(w, strings.Repeat("a", 1024))
+	}))
+	defer server.Close()
+
+	globalChaos = nil
+	if _, ok := newChaosTransport(server.URL, "bucket", http.DefaultTransport).(chaosTransport); ok {
+		t.Fatal("expected no fault injection without --chaos")
+	}
+
+	globalChaos = &chaosInjector{}
+	client := &http.Client{Transport: newChaosTransport(server.URL+"/", "bucket", http.DefaultTransport)}
+	get := func(object string) (*http.Response, error) {
+		return client.Get(server.URL + "/bucket/" + object)
+	}
+	setRules := func(data string) {
+		t.Helper()
+		rules, err := parseChaosRules([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		globalChaos.set(rules)
+	}
+
+	setRules(`rules: [{endpoint: ` + server.URL + `, bucket: bucket, apis: [GetObject], fault: error, status_code: 500}]`)
+	resp, err := get("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(string(body), "<Code>InternalError</Code>") || served != 0 {
+		t.Errorf("expected an injected InternalError, got %d %s", resp.StatusCode, body)
+	}
+	if status := globalChaos.status(); status.Rules[0].Injected != 1 {
+		t.Errorf("expected 1 injected fault, got %d", status.Rules[0].Injected)
+	}
+
+	// Other remotes and APIs are left alone.
+	setRules(`rules: [{bucket: other, fault: reset}, {apis: [PutObject], fault: reset}]`)
+	if resp, err = get("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || served != 1 {
+		t.Errorf("expected the request to be served, got %d", resp.StatusCode)
+	}
+
+	setRules(`rules: [{fault: reset}]`)
+	if _, err = get("a.txt"); err == nil || !strings.Contains(err.Error(), "reset") {
+		t.Errorf("expected a connection reset, got %v", err)
+	}
+
+	setRules(`rules: [{fault: truncate, latency: 10ms}]`)
+	start := time.Now()
+	if resp, err = get("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != io.ErrUnexpectedEOF || len(body) != 512 {
+		t.Errorf("expected a truncated body, got %d bytes, %v", len(body), err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("expected the request to be delayed")
+	}
+}
@@ -328,6 +328,10 @@ func ErrorRespToObjectError(err error, params ...string) error {
 		err = InvalidUploadID{}
 	case "EntityTooSmall":
 		err = PartTooSmall{}
+	case "InvalidObjectState":
+		err = InvalidObjectState{Bucket: bucket, Object: object}
+	case "RestoreAlreadyInProgress":
+		err = ObjectRestoreInProgress{Bucket: bucket, Object: object}
 	}
 
 	return err
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultConfigAuditLimit = 100
+	maxConfigAuditLimit     = 1000
+)
+
+// globalConfigAudit - log of the admin API config changes, nil unless
+// configured.
+var globalConfigAudit *configAuditLog
+
+// configAuditConfig - appends the config changes made through the admin
+// API to File.
+type configAuditConfig struct {
+	File string `yaml:"file"`
+}
+
+// ConfigAuditEntry - a config change, who made it, when and what. Every
+// entry carries the hash of the previous one, so that a modified or
+// removed entry breaks the chain.
+type ConfigAuditEntry struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	AccessKey string    `json:"accessKey"`
+	SourceIP  string    `json:"sourceIp"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	// Action is the admin API call, such as SetConfig.
+	Action  string            `json:"action"`
+	Details map[string]string `json:"details,omitempty"`
+	// PrevHash and Hash are the hex SHA-256 of the previous entry and
+	// of this entry with an empty Hash.
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// ConfigAuditResponse - config changes after a cursor, and the cursor of
+// the next request.
+type ConfigAuditResponse struct {
+	Entries []ConfigAuditEntry `json:"entries"`
+	Cursor  uint64             `json:"cursor"`
+}
+
+// sum - returns the hash of e.
+func (e ConfigAuditEntry) sum() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// configAuditLog - append-only file of config changes, one JSON entry per
+// line.
+type configAuditLog struct {
+	mu   sync.Mutex
+	file string
+	f    *os.File
+	// seq and hash of the last entry.
+	seq  uint64
+	hash string
+}
+
+// scanConfigAudit - calls fn with the entries of file in order, verifying
+// their chain.
+func scanConfigAudit(file string, fn func(e ConfigAuditEntry) bool) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var seq uint64
+	var hash string
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, maxConfigSize)
+	for s.Scan() {
+		var e ConfigAuditEntry
+		if err = json.Unmarshal(s.Bytes(), &e); err != nil {
+			return fmt.Errorf("%s: entry %d: %v", file, seq+1, err)
+		}
+		sum, err := e.sum()
+		if err != nil {
+			return err
+		}
+		if e.Seq != seq+1 || e.PrevHash != hash || e.Hash != sum {
+			return fmt.Errorf("%s: entry %d was modified", file, seq+1)
+		}
+		seq, hash = e.Seq, e.Hash
+		if !fn(e) {
+			return nil
+		}
+	}
+	return s.Err()
+}
+
+// openConfigAuditLog - opens the log in file for appending, failing if
+// its entries were modified.
+func openConfigAuditLog(file string) (*configAuditLog, error) {
+	l := &configAuditLog{file: file}
+	err := scanConfigAudit(file, func(e ConfigAuditEntry) bool {
+		l.seq, l.hash = e.Seq, e.Hash
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l.f, err = os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// record - appends the change action made by the admin request r, l may
+// be nil.
+func (l *configAuditLog) record(r *http.Request, action string, details map[string]string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := ConfigAuditEntry{
+		Seq:       l.seq + 1,
+		Time:      UTCNow(),
+		AccessKey: globalAdminCred.AccessKey,
+		SourceIP:  getSourceIP(r),
+		UserAgent: r.UserAgent(),
+		Action:    action,
+		Details:   details,
+		PrevHash:  l.hash,
+	}
+	var err error
+	if e.Hash, err = e.sum(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err = l.f.Write(append(data, '\n')); err == nil {
+		err = l.f.Sync()
+	}
+	if err != nil {
+		return err
+	}
+	l.seq, l.hash = e.Seq, e.Hash
+	return nil
+}
+
+// read - returns up to limit entries after cursor and the cursor of the
+// next read.
+func (l *configAuditLog) read(cursor uint64, limit int) ([]ConfigAuditEntry, uint64, error) {
+	entries := []ConfigAuditEntry{}
+	err := scanConfigAudit(l.file, func(e ConfigAuditEntry) bool {
+		if e.Seq > cursor {
+			entries = append(entries, e)
+		}
+		return len(entries) < limit
+	})
+	if err != nil {
+		return nil, cursor, err
+	}
+	if len(entries) > 0 {
+		cursor = entries[len(entries)-1].Seq
+	}
+	return entries, cursor, nil
+}
+
+// configChanges - returns the details of the replacement of the config
+// oldData by newData: the top-level sections changed and the hash of the
+// new config. Values are left out as they hold credentials.
+func configChanges(oldData, newData []byte) map[string]string {
+	var oldDoc, newDoc map[string]interface{}
+	// An unreadable old config shows as all sections changed.
+	yaml.Unmarshal(oldData, &oldDoc)
+	yaml.Unmarshal(newData, &newDoc)
+	var changed []string
+	for key, value := range newDoc {
+		if !reflect.DeepEqual(oldDoc[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldDoc {
+		if _, ok := newDoc[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+
+	sum := sha256.Sum256(newData)
+	return map[string]string{
+		"changed": strings.Join(changed, ","),
+		"sha256":  hex.EncodeToString(sum[:]),
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config-audit.log")
+	audit, err := openConfigAuditLog(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("PUT", "/minio/admin/v1/maintenance/read-only?enable=true", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	for _, enable := range []string{"true", "false"} {
+		if err = audit.record(r, "SetReadOnly", map[string]string{"enable": enable}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Entries are appended after a restart.
+	if audit, err = openConfigAuditLog(file); err != nil {
+		t.Fatal(err)
+	}
+	if err = audit.record(r, "SetConfig", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, cursor, err := audit.read(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || cursor != 2 || entries[0].SourceIP != "192.0.2.1" || entries[1].Details["enable"] != "false" {
+		t.Fatalf("unexpected entries %+v, cursor %d", entries, cursor)
+	}
+	if entries, cursor, err = audit.read(cursor, 2); err != nil || len(entries) != 1 || cursor != 3 ||
+		entries[0].Action != "SetConfig" || entries[0].PrevHash == "" {
+		t.Fatalf("unexpected entries %+v, cursor %d, %v", entries, cursor, err)
+	}
+	if entries, cursor, err = audit.read(cursor, 2); err != nil || len(entries) != 0 || cursor != 3 {
+		t.Fatalf("expected no entries after the last, got %+v, cursor %d, %v", entries, cursor, err)
+	}
+
+	// Modified entries are detected.
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"enable":"false"`), []byte(`"enable":"true"`), 1)
+	if err = ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = openConfigAuditLog(file); err == nil {
+		t.Error("expected a modified log to fail")
+	}
+	if _, _, err = audit.read(0, 10); err == nil {
+		t.Error("expected reading a modified log to fail")
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	oldData := []byte("version: 2\ncache:\n  quota: 80\nmirror:\n  - local:\n      bucket: a\n")
+	newData := []byte("version: 2\ncache:\n  quota: 90\nusage:\n  dir: /var/lib/radio/usage\n")
+	details := configChanges(oldData, newData)
+	if details["changed"] != "cache,mirror,usage" || len(details["sha256"]) != 64 {
+		t.Errorf("unexpected changes %v", details)
+	}
+	if details = configChanges(newData, newData); details["changed"] != "" {
+		t.Errorf("expected no changes, got %v", details)
+	}
+}
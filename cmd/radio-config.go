@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v6/pkg/s3utils"
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/radio/cmd/config"
+	"github.com/minio/radio/cmd/config/api"
+	"github.com/minio/radio/cmd/config/cache"
+	"github.com/minio/radio/cmd/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported config.yml schema versions. Configs without a version are
+// treated as v1 which ignores unknown fields, v2 rejects them.
+const (
+	radioConfigVersionV1 = 1
+	radioConfigVersionV2 = 2
+
+	radioConfigVersionLatest = radioConfigVersionV2
+)
+
+// radioConfigErrors - all problems found while validating a config.
+type radioConfigErrors []string
+
+func (errs radioConfigErrors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(errs, "\n  ")
+}
+
+func (errs *radioConfigErrors) add(path, format string, args ...interface{}) {
+	*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// parseRadioConfig - parses config.yml, syntax and unknown field errors
+// carry the line number.
+func parseRadioConfig(data []byte) (rconfig radioConfig, err error) {
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err = yaml.Unmarshal(data, &header); err != nil {
+		return rconfig, err
+	}
+
+	switch header.Version {
+	case 0, radioConfigVersionV1:
+		err = yaml.Unmarshal(data, &rconfig)
+	case radioConfigVersionV2:
+		err = yaml.UnmarshalStrict(data, &rconfig)
+	default:
+		return rconfig, fmt.Errorf("unsupported config version %d, latest supported version is %d",
+			header.Version, radioConfigVersionLatest)
+	}
+	return rconfig, err
+}
+
+// loadRadioConfig - parses and validates config.yml as written, semantic
+// errors carry the key path. ENV overrides are not applied, they belong to
+// the environment of the running server and not to the file.
+func loadRadioConfig(data []byte) (rconfig radioConfig, err error) {
+	if rconfig, err = parseRadioConfig(data); err != nil {
+		return rconfig, err
+	}
+	return rconfig, validateRadioConfig(rconfig)
+}
+
+// loadServerConfig - parses config.yml, applies ENV overrides and validates
+// the result, used when the server starts.
+func loadServerConfig(data []byte) (rconfig radioConfig, err error) {
+	if rconfig, err = parseRadioConfig(data); err != nil {
+		return rconfig, err
+	}
+
+	// RADIO_REMOTE_0_ENDPOINT overrides the first remote of every mirror
+	// bucket, RADIO_MIRROR_1_REMOTE_0_ENDPOINT that of the second only.
+	for i := range rconfig.Mirror {
+		remotes := struct {
+			Remote []bucketConfig `yaml:"remote"`
+		}{rconfig.Mirror[i].Remote}
+		if err = config.ApplyEnvOverrides(&remotes); err != nil {
+			return rconfig, err
+		}
+		rconfig.Mirror[i].Remote = remotes.Remote
+	}
+
+	// Every key can be overridden through its ENV.
+	if err = config.ApplyEnvOverrides(&rconfig); err != nil {
+		return rconfig, err
+	}
+
+	return rconfig, validateRadioConfig(rconfig)
+}
+
+func validateBucketConfig(errs *radioConfigErrors, path string, bCfg bucketConfig, remote bool) {
+	if err := s3utils.CheckValidBucketName(bCfg.Bucket); err != nil {
+		errs.add(path+".bucket", "%v", err)
+	}
+	if !remote {
+		switch strings.ToLower(bCfg.Credentials.Provider) {
+		case "", credsProviderStatic:
+			if !auth.IsAccessKeyValid(bCfg.AccessKey) {
+				errs.add(path+".access_key", "access key must be minimum 3 or more characters long")
+			}
+			if !auth.IsSecretKeyValid(bCfg.SecretKey) {
+				errs.add(path+".secret_key", "secret key must be minimum 8 or more characters long")
+			}
+		case credsProviderSecretFiles, credsProviderVault:
+			validateCredentialsConfig(errs, path, bCfg)
+		default:
+			errs.add(path+".credentials.provider", "provider %q is not supported for local buckets",
+				bCfg.Credentials.Provider)
+		}
+		if bCfg.StorageClass.Default != "" || len(bCfg.StorageClass.Map) > 0 {
+			errs.add(path+".storage_class", "storage classes can only be mapped for remote buckets")
+		}
+		if !bCfg.Keys.isZero() {
+			errs.add(path+".keys", "keys can only be mapped for remote buckets")
+		}
+		if bCfg.Shadow {
+			errs.add(path+".shadow", "only remote buckets can be shadows")
+		}
+		if bCfg.ReadSample != 0 {
+			errs.add(path+".read_sample", "reads can only be sampled to shadow remotes")
+		}
+		if bCfg.Region != "" || len(bCfg.Networks) > 0 {
+			errs.add(path+".region", "only remote buckets can be located")
+		}
+		if bCfg.MaxPartSize != "" {
+			errs.add(path+".max_part_size", "only remote buckets have a part size limit")
+		}
+		if bCfg.SigningRegion != "" || bCfg.Addressing != "" {
+			errs.add(path+".addressing", "only requests to remote buckets are addressed and signed")
+		}
+		return
+	}
+
+	validateEndpointConfig(errs, path, bCfg)
+	validateCredentialsConfig(errs, path, bCfg)
+
+	for from, to := range bCfg.StorageClass.Map {
+		if from == "" || to == "" {
+			errs.add(path+".storage_class.map", "storage class %q is mapped to %q, both must be set", from, to)
+		}
+	}
+	validateKeyMapping(errs, path+".keys", bCfg.Keys)
+
+	switch {
+	case bCfg.ReadSample < 0 || bCfg.ReadSample > 100:
+		errs.add(path+".read_sample", "must be between 0-100")
+	case bCfg.ReadSample > 0 && !bCfg.Shadow:
+		errs.add(path+".read_sample", "reads can only be sampled to shadow remotes")
+	}
+	if _, err := parseIPNets(bCfg.Networks); err != nil {
+		errs.add(path+".networks", "%v", err)
+	}
+	if _, err := parseMaxPartSize(bCfg.MaxPartSize); err != nil {
+		errs.add(path+".max_part_size", "%v", err)
+	}
+}
+
+func validateCredentialsConfig(errs *radioConfigErrors, path string, bCfg bucketConfig) {
+	ccfg := bCfg.Credentials
+	switch strings.ToLower(ccfg.Provider) {
+	case "", credsProviderStatic:
+		// Remotes without keys are accessed anonymously.
+		if (bCfg.AccessKey == "") != (bCfg.SecretKey == "") {
+			errs.add(path, "access_key and secret_key are both required for static credentials")
+		}
+	case credsProviderEnv, credsProviderIAM:
+	case credsProviderFile:
+		if ccfg.File == "" {
+			errs.add(path+".credentials.file", "required for %s credentials", credsProviderFile)
+		}
+	case credsProviderWebIdentity:
+		if ccfg.Endpoint == "" {
+			errs.add(path+".credentials.endpoint", "required for %s credentials", credsProviderWebIdentity)
+		}
+		if ccfg.TokenFile == "" {
+			errs.add(path+".credentials.token_file", "required for %s credentials", credsProviderWebIdentity)
+		}
+	case credsProviderSecretFiles:
+		if ccfg.AccessKeyFile == "" {
+			errs.add(path+".credentials.access_key_file", "required for %s credentials", credsProviderSecretFiles)
+		}
+		if ccfg.SecretKeyFile == "" {
+			errs.add(path+".credentials.secret_key_file", "required for %s credentials", credsProviderSecretFiles)
+		}
+	case credsProviderVault:
+		if ccfg.Vault.Address == "" {
+			errs.add(path+".credentials.vault.address", "required for %s credentials", credsProviderVault)
+		}
+		if ccfg.Vault.Path == "" {
+			errs.add(path+".credentials.vault.path", "required for %s credentials", credsProviderVault)
+		}
+		if ccfg.Vault.Token == "" && ccfg.Vault.TokenFile == "" {
+			errs.add(path+".credentials.vault", "token or token_file is required for %s credentials", credsProviderVault)
+		}
+		if ccfg.Vault.RefreshInterval < 0 {
+			errs.add(path+".credentials.vault.refresh_interval", "must not be negative")
+		}
+	default:
+		errs.add(path+".credentials.provider", "unknown provider %q", ccfg.Provider)
+	}
+}
+
+// validateRadioConfig - performs semantic validation of the whole config.
+func validateRadioConfig(rconfig radioConfig) error {
+	var errs radioConfigErrors
+
+	if len(rconfig.Mirror) == 0 && len(rconfig.Erasure) == 0 {
+		errs.add("mirror", "at least one mirror or erasure bucket is required")
+	}
+
+	checkLocal := func(localBuckets map[string]string, path string, bCfg bucketConfig) {
+		validateBucketConfig(&errs, path, bCfg, false)
+		if prev, ok := localBuckets[bCfg.Bucket]; ok {
+			errs.add(path+".bucket", "bucket %q is already configured at %s", bCfg.Bucket, prev)
+		}
+		localBuckets[bCfg.Bucket] = path
+	}
+
+	tenantRefs := make(map[string]string)
+	mirrorBuckets := make(map[string]string)
+	for i, mcfg := range rconfig.Mirror {
+		path := fmt.Sprintf("mirror[%d]", i)
+		checkLocal(mirrorBuckets, path+".local", mcfg.Local)
+		tenantRefs[path+".tenant"] = mcfg.Tenant
+		shadows := 0
+		for j, rcfg := range mcfg.Remote {
+			validateBucketConfig(&errs, fmt.Sprintf("%s.remote[%d]", path, j), rcfg, true)
+			if rcfg.Shadow {
+				shadows++
+			}
+		}
+		if len(mcfg.Remote) == shadows {
+			errs.add(path+".remote", "at least one remote which is not a shadow is required")
+		}
+		validateMetadataRules(&errs, path+".metadata", mcfg.Metadata)
+		validateContentTypeConfig(&errs, path+".content_type", mcfg.ContentType)
+	}
+	websiteHosts := make(map[string]string)
+	mirrorRemotes := make(map[string]int)
+	trashes := make(map[string]trashConfig)
+	for _, mcfg := range rconfig.Mirror {
+		for _, rcfg := range mcfg.Remote {
+			if !rcfg.Shadow {
+				mirrorRemotes[mcfg.Local.Bucket]++
+			}
+		}
+		trashes[mcfg.Local.Bucket] = mcfg.Trash
+	}
+	for i, mcfg := range rconfig.Mirror {
+		validateInventoryConfig(&errs, fmt.Sprintf("mirror[%d].inventory", i), mcfg.Inventory, mirrorBuckets)
+		validateWebsiteConfig(&errs, fmt.Sprintf("mirror[%d].website", i), mcfg.Website, websiteHosts)
+		validateTrashConfig(&errs, fmt.Sprintf("mirror[%d].trash", i), mcfg.Local.Bucket, mcfg.Trash,
+			mirrorRemotes, trashes)
+		validateIntegrityConfig(&errs, fmt.Sprintf("mirror[%d].integrity", i), mcfg.Local.Bucket, mcfg.Integrity,
+			mirrorRemotes)
+		for j, pcfg := range mcfg.Pull {
+			validatePullConfig(&errs, fmt.Sprintf("mirror[%d].pull[%d]", i, j), pcfg, mcfg.Remote)
+		}
+	}
+	validateScanConfig(&errs, "scan", rconfig.Scan, mirrorBuckets)
+	validateContentTypeConfig(&errs, "content_type", rconfig.ContentType)
+	validateChangeFeedConfig(&errs, "changes", rconfig.Changes)
+	validateDurabilityConfig(&errs, "durability", rconfig.Durability)
+	validateUsageConfig(&errs, "usage", rconfig.Usage)
+	validateFanoutConfig(&errs, "fanout", rconfig.Fanout)
+	if f := rconfig.Locality.GeoIPFile; f != "" && !isFile(f) {
+		errs.add("locality.geoip_file", "%s: no such file", f)
+	}
+
+	erasureBuckets := make(map[string]string)
+	for i, ecfg := range rconfig.Erasure {
+		path := fmt.Sprintf("erasure[%d]", i)
+		checkLocal(erasureBuckets, path+".local", ecfg.Local)
+		tenantRefs[path+".tenant"] = ecfg.Tenant
+		if ecfg.Parity < 1 || ecfg.Parity >= len(ecfg.Remote) {
+			errs.add(path+".parity", "parity must be between 1 and %d", len(ecfg.Remote)-1)
+		}
+		for j, rcfg := range ecfg.Remote {
+			validateBucketConfig(&errs, fmt.Sprintf("%s.remote[%d]", path, j), rcfg, true)
+			if rcfg.Shadow {
+				errs.add(fmt.Sprintf("%s.remote[%d].shadow", path, j), "only remotes of mirror buckets can be shadows")
+			}
+			if rcfg.Region != "" || len(rcfg.Networks) > 0 {
+				errs.add(fmt.Sprintf("%s.remote[%d].region", path, j), "only remotes of mirror buckets can be located")
+			}
+		}
+	}
+
+	if rconfig.Cache.Quota < 0 || rconfig.Cache.Quota > 100 {
+		errs.add("cache.quota", "must be between 0-100")
+	}
+	if rconfig.Cache.Expiry < 0 {
+		errs.add("cache.expiry", "must not be negative")
+	}
+	var cacheCfg cache.Config
+	if err := cache.LookupRAMConfig(&cacheCfg, rconfig.Cache.RAMSize, rconfig.Cache.RAMMaxObjectSize); err != nil {
+		errs.add("cache.ram_size", "%v", err)
+	}
+	if err := cache.LookupPolicyConfig(&cacheCfg, rconfig.Cache.Policy, rconfig.Cache.Admission); err != nil {
+		errs.add("cache.policy", "%v", err)
+	}
+	if err := cache.LookupPrefetchConfig(&cacheCfg, rconfig.Cache.PrefetchWindow); err != nil {
+		errs.add("cache.prefetch_window", "%v", err)
+	}
+
+	if rconfig.Admin.AccessKey != "" || rconfig.Admin.SecretKey != "" {
+		if _, err := auth.CreateCredentials(rconfig.Admin.AccessKey, rconfig.Admin.SecretKey); err != nil {
+			errs.add("admin", "%v", err)
+		}
+	}
+	if rconfig.Admin.PprofAddress != "" {
+		if rconfig.Admin.AccessKey == "" {
+			errs.add("admin.pprof_address", "requires an admin credential")
+		}
+		if _, _, err := net.SplitHostPort(rconfig.Admin.PprofAddress); err != nil {
+			errs.add("admin.pprof_address", "%v", err)
+		}
+	}
+
+	apiCfg, err := api.LookupConfig(rconfig.API.MaxObjectSize, rconfig.API.MaxHeaderSize,
+		rconfig.API.MaxMetadataSize, rconfig.API.JSONErrors)
+	if err != nil {
+		errs.add("api", "%v", err)
+	}
+	if err := api.LookupParallelGetConfig(&apiCfg, rconfig.API.ParallelGetThreshold,
+		rconfig.API.ParallelGetPartSize, rconfig.API.ParallelGetMaxMemory); err != nil {
+		errs.add("api.parallel_get_threshold", "%v", err)
+	}
+	if err := api.LookupSlowRequestConfig(&apiCfg, rconfig.API.SlowRequestThreshold); err != nil {
+		errs.add("api.slow_request_threshold", "%v", err)
+	}
+	if err := api.LookupListenerConfig(&apiCfg, rconfig.API.MaxHeaderBytes, rconfig.API.MaxHeaderCount,
+		rconfig.API.ReadHeaderTimeout, rconfig.API.IdleTimeout, rconfig.API.MaxConnsPerIP,
+		apiCfg.MaxHeaderSize); err != nil {
+		errs.add("api", "%v", err)
+	}
+	if _, err := parseIPNets(rconfig.API.ProxyProtocolSources); err != nil {
+		errs.add("api.proxy_protocol_sources", "%v", err)
+	}
+	if _, err := parseIPNets(rconfig.API.TrustedProxies); err != nil {
+		errs.add("api.trusted_proxies", "%v", err)
+	}
+	validateListenConfig(&errs, "listen", rconfig.Listen)
+	validateMetricsConfig(&errs, "metrics", rconfig.Metrics)
+	validateAuthorizerConfig(&errs, "authorizer", rconfig.Authorizer)
+	validateTenantsConfig(&errs, "tenants", rconfig.Tenants, tenantRefs)
+	validateSFTPConfig(&errs, "sftp", rconfig.SFTP)
+	validateWebDAVConfig(&errs, "webdav", rconfig.WebDAV, mirrorBuckets)
+	validateRequestStatsConfig(&errs, "request_stats", rconfig.RequestStats)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var configCmd = cli.Command{
+	Name:  "config",
+	Usage: "Manage radio configuration files",
+	Subcommands: []cli.Command{
+		{
+			Name:      "validate",
+			Usage:     "validate a radio configuration file",
+			ArgsUsage: "FILE",
+			Action:    configValidateMain,
+		},
+	},
+}
+
+func configValidateMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "validate", 1)
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	logger.FatalIf(err, "Unable to read config file")
+
+	_, err = loadRadioConfig(data)
+	logger.FatalIf(err, "Invalid config file %s", ctx.Args().First())
+
+	fmt.Printf("%s is valid.\n", ctx.Args().First())
+}
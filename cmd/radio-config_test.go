@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/radio/cmd/config/cache"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Tests that remotes with static credentials need both keys, or none
+// to be accessed anonymously.
+func TestLoadRadioConfigStaticRemote(t *testing.T) {
+	testCases := []struct {
+		version string
+		keys    string
+		valid   bool
+	}{
+		{"", "", true},
+		{"version: 1", "", true},
+		{"version: 2", "", true},
+		{"version: 1", "access_key: remoteaccess\n      secret_key: remotesecret", true},
+		{"version: 1", "access_key: remoteaccess", false},
+		{"version: 2", "secret_key: remotesecret", false},
+	}
+	for i, testCase := range testCases {
+		data := testCase.version + `
+mirror:
+  - local:
+      bucket: radiobucket1
+      access_key: radioaccess
+      secret_key: radiosecret
+    remote:
+    - bucket: bucket1
+      endpoint: https://s3.amazonaws.com
+      ` + testCase.keys + "\n"
+		_, err := loadRadioConfig([]byte(data))
+		if (err == nil) != testCase.valid {
+			t.Errorf("Test %d: expected valid %t, got %v", i+1, testCase.valid, err)
+		}
+		if err != nil && !testCase.valid && !strings.Contains(err.Error(), "mirror[0].remote[0]: access_key and secret_key") {
+			t.Errorf("Test %d: expected the remote keys to be rejected, got %v", i+1, err)
+		}
+	}
+}
+
+// Tests that RADIO_REMOTE_ ENVs override the remotes of every mirror
+// bucket, unless overridden for a bucket, and that the cache section is
+// left to the ENVs of config/cache.
+func TestLoadServerConfigEnv(t *testing.T) {
+	envs := map[string]string{
+		"RADIO_REMOTE_0_ENDPOINT":          "https://remote0.domain.com",
+		"RADIO_MIRROR_1_REMOTE_0_ENDPOINT": "https://remote1.domain.com",
+		"RADIO_CACHE_QUOTA":                "70",
+	}
+	for k, v := range envs {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	data := `
+cache:
+  quota: 60
+mirror:
+  - local:
+      bucket: radiobucket1
+      access_key: radioaccess
+      secret_key: radiosecret
+    remote:
+    - bucket: bucket1
+      endpoint: https://s3.amazonaws.com
+  - local:
+      bucket: radiobucket2
+      access_key: radioaccess2
+      secret_key: radiosecret2
+    remote:
+    - bucket: bucket2
+      endpoint: https://s3.amazonaws.com
+`
+	rconfig, err := loadServerConfig([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint := rconfig.Mirror[0].Remote[0].Endpoint; endpoint != envs["RADIO_REMOTE_0_ENDPOINT"] {
+		t.Errorf("Expected the remotes of all buckets to be overridden, got %s", endpoint)
+	}
+	if endpoint := rconfig.Mirror[1].Remote[0].Endpoint; endpoint != envs["RADIO_MIRROR_1_REMOTE_0_ENDPOINT"] {
+		t.Errorf("Expected the remote of the bucket to be overridden, got %s", endpoint)
+	}
+	if rconfig.Cache.Quota != 60 {
+		t.Errorf("Expected the cache to be left to its own ENVs, got quota %d", rconfig.Cache.Quota)
+	}
+}
+
+// Tests that the cache is initialized when only the cache section of
+// the config enables it.
+func TestInitCacheObjectLayerConfig(t *testing.T) {
+	defer func(cfg cache.Config, cacheAPI CacheObjectLayer, targets []logger.Target) {
+		globalCacheConfig, globalCacheObjectAPI, logger.Targets = cfg, cacheAPI, targets
+	}(globalCacheConfig, globalCacheObjectAPI, logger.Targets)
+	globalCacheObjectAPI = nil
+
+	var rconfig radioConfig
+	rconfig.Cache.RAMSize = "64MiB"
+	if err := lookupConfigEnv(rconfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := initCacheObjectLayer(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if newCachedObjectLayerFn() == nil {
+		t.Error("Expected the cache to be initialized from the config")
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// Content-Type detection modes.
+const (
+	contentTypeDetectOff       = "off"
+	contentTypeDetectExtension = "extension"
+	contentTypeDetectSniff     = "sniff"
+)
+
+// Bytes of content http.DetectContentType considers.
+const contentTypeSniffLen = 512
+
+const defaultContentType = "application/octet-stream"
+
+// contentTypeConfig - the Content-Type of objects uploaded without one,
+// application/octet-stream unless Detect guesses it from the extension of
+// the key, or with sniff from the first 512 bytes of the content if the
+// extension is unknown. Set for all buckets and overridden per mirror
+// bucket.
+type contentTypeConfig struct {
+	Detect string `yaml:"detect"`
+}
+
+// validateContentTypeConfig - validates the detection of Content-Types.
+func validateContentTypeConfig(errs *radioConfigErrors, path string, c contentTypeConfig) {
+	switch c.Detect {
+	case "", contentTypeDetectOff, contentTypeDetectExtension, contentTypeDetectSniff:
+	default:
+		errs.add(path+".detect", "%q is not off, extension or sniff", c.Detect)
+	}
+}
+
+// contentTypeDetection - the detection mode of every bucket.
+type contentTypeDetection struct {
+	mode    string
+	buckets map[string]string
+}
+
+// globalContentTypes - detects the Content-Type of uploads without one.
+var globalContentTypes = &contentTypeDetection{mode: contentTypeDetectOff}
+
+func newContentTypeDetection(rconfig radioConfig) *contentTypeDetection {
+	d := &contentTypeDetection{mode: rconfig.ContentType.Detect, buckets: make(map[string]string)}
+	if d.mode == "" {
+		d.mode = contentTypeDetectOff
+	}
+	for _, mcfg := range rconfig.Mirror {
+		if mcfg.ContentType.Detect != "" {
+			d.buckets[mcfg.Local.Bucket] = mcfg.ContentType.Detect
+		}
+	}
+	return d
+}
+
+// bucketMode - returns the detection mode of bucket.
+func (d *contentTypeDetection) bucketMode(bucket string) string {
+	if mode, ok := d.buckets[bucket]; ok {
+		return mode
+	}
+	return d.mode
+}
+
+// apply - sets the Content-Type of an upload of object to bucket whose
+// metadata carries none. The content is sniffed from reader, nil for
+// requests without content such as NewMultipartUpload, the reader
+// returned replays the bytes sniffed.
+func (d *contentTypeDetection) apply(bucket, object string, metadata map[string]string, reader io.Reader) (io.Reader, error) {
+	if _, ok := metadata["content-type"]; ok {
+		return reader, nil
+	}
+	metadata["content-type"] = defaultContentType
+
+	mode := d.bucketMode(bucket)
+	if mode == contentTypeDetectOff {
+		return reader, nil
+	}
+	if contentType := mime.TypeByExtension(path.Ext(object)); contentType != "" {
+		metadata["content-type"] = contentType
+		return reader, nil
+	}
+	if mode != contentTypeDetectSniff || reader == nil {
+		return reader, nil
+	}
+
+	head := make([]byte, contentTypeSniffLen)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if n > 0 {
+		metadata["content-type"] = http.DetectContentType(head[:n])
+	}
+	return io.MultiReader(bytes.NewReader(head[:n]), reader), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateContentTypeConfig(t *testing.T) {
+	for detect, valid := range map[string]bool{"": true, "off": true, "extension": true, "sniff": true, "on": false} {
+		var errs radioConfigErrors
+		validateContentTypeConfig(&errs, "content_type", contentTypeConfig{Detect: detect})
+		if (len(errs) == 0) != valid {
+			t.Errorf("%q: expected valid %v, got %v", detect, valid, errs)
+		}
+	}
+}
+
+func TestContentTypeDetection(t *testing.T) {
+	var rconfig radioConfig
+	err := yaml.Unmarshal([]byte(`{
+  "content_type": {"detect": "extension"},
+  "mirror": [
+    {"local": {"bucket": "raw"}, "content_type": {"detect": "off"}},
+    {"local": {"bucket": "uploads"}, "content_type": {"detect": "sniff"}},
+    {"local": {"bucket": "site"}}
+  ]
+}`), &rconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newContentTypeDetection(rconfig)
+
+	html := "<!DOCTYPE html><html><body>radio</body></html>"
+	testCases := []struct {
+		bucket, object, contentType string
+		expected                    string
+	}{
+		{"site", "index.html", "", "text/html; charset=utf-8"},
+		{"site", "logo.png", "", "image/png"},
+		// Content is only sniffed where enabled.
+		{"site", "index", "", "application/octet-stream"},
+		{"uploads", "index", "", "text/html; charset=utf-8"},
+		{"uploads", "logo.png", "", "image/png"},
+		{"raw", "index.html", "", "application/octet-stream"},
+		// Types sent by clients are kept.
+		{"uploads", "index", "text/x-radio", "text/x-radio"},
+	}
+	for i, testCase := range testCases {
+		metadata := map[string]string{}
+		if testCase.contentType != "" {
+			metadata["content-type"] = testCase.contentType
+		}
+		reader, err := d.apply(testCase.bucket, testCase.object, metadata, strings.NewReader(html))
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if metadata["content-type"] != testCase.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.expected, metadata["content-type"])
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil || string(data) != html {
+			t.Errorf("Test %d: expected the content to be replayed, got %q, %v", i+1, data, err)
+		}
+	}
+
+	// Requests without content are detected by extension only.
+	metadata := map[string]string{}
+	if reader, err := d.apply("uploads", "index", metadata, nil); err != nil || reader != nil {
+		t.Fatalf("expected no reader, got %v, %v", reader, err)
+	}
+	if metadata["content-type"] != defaultContentType {
+		t.Errorf("expected %q, got %q", defaultContentType, metadata["content-type"])
+	}
+}
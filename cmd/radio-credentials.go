@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Interval at which front-end credentials backed by secret files or
+// Vault are re-read.
+const localCredentialsRefreshInterval = time.Minute
+
+// Supported credential providers for remote buckets.
+const (
+	credsProviderStatic      = "static"
+	credsProviderEnv         = "env"
+	credsProviderFile        = "file"
+	credsProviderIAM         = "iam"
+	credsProviderWebIdentity = "web_identity"
+	credsProviderSecretFiles = "secret_files"
+	credsProviderVault       = "vault"
+)
+
+// credentialsConfig - selects how credentials for a remote are obtained,
+// when not set static access_key/secret_key are used.
+type credentialsConfig struct {
+	// Provider is one of static, env, file, iam, web_identity,
+	// secret_files or vault.
+	Provider string `yaml:"provider"`
+	// Endpoint overrides the IAM metadata endpoint or the STS endpoint.
+	Endpoint string `yaml:"endpoint"`
+	// File is the AWS shared credentials file, used by 'file'.
+	File string `yaml:"file"`
+	// Profile is the profile name inside File, defaults to 'default'.
+	Profile string `yaml:"profile"`
+	// TokenFile holds the OIDC token used by 'web_identity', it is
+	// re-read on every refresh so rotated tokens are picked up.
+	TokenFile string `yaml:"token_file"`
+	// AccessKeyFile, SecretKeyFile and the optional SessionTokenFile
+	// hold one value each, used by 'secret_files'.
+	AccessKeyFile    string `yaml:"access_key_file"`
+	SecretKeyFile    string `yaml:"secret_key_file"`
+	SessionTokenFile string `yaml:"session_token_file"`
+	// Vault locates the secret used by 'vault'.
+	Vault vaultConfig `yaml:"vault"`
+}
+
+// rotates - returns true if the credentials are read from secrets which
+// may change while radio is running.
+func (c credentialsConfig) rotates() bool {
+	switch strings.ToLower(c.Provider) {
+	case credsProviderSecretFiles, credsProviderVault:
+		return true
+	}
+	return false
+}
+
+// newRemoteCredentials - returns the credentials for a remote bucket. All
+// providers other than static refresh themselves transparently once the
+// retrieved credentials expire.
+func newRemoteCredentials(bCfg bucketConfig) (*credentials.Credentials, error) {
+	ccfg := bCfg.Credentials
+	switch strings.ToLower(ccfg.Provider) {
+	case "", credsProviderStatic:
+		return credentials.NewStaticV4(bCfg.AccessKey, bCfg.SecretKey, bCfg.SessionToken), nil
+	case credsProviderEnv:
+		return credentials.NewEnvAWS(), nil
+	case credsProviderFile:
+		return credentials.NewFileAWSCredentials(ccfg.File, ccfg.Profile), nil
+	case credsProviderIAM:
+		// Handles both EC2 instance profiles and ECS task roles, the
+		// latter is detected through AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+		return credentials.NewIAM(ccfg.Endpoint), nil
+	case credsProviderWebIdentity:
+		if ccfg.Endpoint == "" {
+			return nil, fmt.Errorf("remote bucket %s: 'endpoint' is required for %s credentials",
+				bCfg.Bucket, credsProviderWebIdentity)
+		}
+		if ccfg.TokenFile == "" {
+			return nil, fmt.Errorf("remote bucket %s: 'token_file' is required for %s credentials",
+				bCfg.Bucket, credsProviderWebIdentity)
+		}
+		tokenFile := ccfg.TokenFile
+		return credentials.NewSTSWebIdentity(ccfg.Endpoint, func() (*credentials.WebIdentityToken, error) {
+			data, err := ioutil.ReadFile(tokenFile)
+			if err != nil {
+				return nil, err
+			}
+			return &credentials.WebIdentityToken{
+				Token: strings.TrimSpace(string(data)),
+			}, nil
+		})
+	case credsProviderSecretFiles:
+		if ccfg.AccessKeyFile == "" || ccfg.SecretKeyFile == "" {
+			return nil, fmt.Errorf("remote bucket %s: 'access_key_file' and 'secret_key_file' are required for %s credentials",
+				bCfg.Bucket, credsProviderSecretFiles)
+		}
+		return newFileCredentials(ccfg.AccessKeyFile, ccfg.SecretKeyFile, ccfg.SessionTokenFile), nil
+	case credsProviderVault:
+		if ccfg.Vault.Address == "" || ccfg.Vault.Path == "" {
+			return nil, fmt.Errorf("remote bucket %s: 'vault.address' and 'vault.path' are required for %s credentials",
+				bCfg.Bucket, credsProviderVault)
+		}
+		return newVaultCredentials(ccfg.Vault), nil
+	}
+	return nil, fmt.Errorf("remote bucket %s: unknown credentials provider %q", bCfg.Bucket, ccfg.Provider)
+}
+
+// newLocalCredentials - returns the front-end credentials of a local
+// bucket, only static, secret_files and vault providers are allowed.
+func newLocalCredentials(bCfg bucketConfig) (*credentials.Credentials, error) {
+	switch strings.ToLower(bCfg.Credentials.Provider) {
+	case "", credsProviderStatic, credsProviderSecretFiles, credsProviderVault:
+		return newRemoteCredentials(bCfg)
+	}
+	return nil, fmt.Errorf("local bucket %s: credentials provider %q is not supported",
+		bCfg.Bucket, bCfg.Credentials.Provider)
+}
+
+// setLocalCredentials - retrieves the current value of all front-end
+// credentials and replaces globalLocalCreds with them.
+func setLocalCredentials(providers []*credentials.Credentials) error {
+	creds := make(map[string]auth.Credentials, len(providers))
+	for _, provider := range providers {
+		v, err := provider.Get()
+		if err != nil {
+			return err
+		}
+		cred, err := auth.CreateCredentials(v.AccessKeyID, v.SecretAccessKey)
+		if err != nil {
+			return err
+		}
+		cred.SessionToken = v.SessionToken
+		creds[cred.AccessKey] = cred
+	}
+
+	globalLocalCredsMu.Lock()
+	globalLocalCreds = creds
+	globalLocalCredsMu.Unlock()
+	return nil
+}
+
+// refreshLocalCredentials - periodically picks up rotated front-end
+// credentials, on failure the previous credentials stay in place.
+func refreshLocalCredentials(providers []*credentials.Credentials) {
+	ticker := time.NewTicker(localCredentialsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-GlobalServiceDoneCh:
+			return
+		case <-ticker.C:
+			logger.LogIf(context.Background(), setLocalCredentials(providers))
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Tests resolving remote credentials from inline keys, ENVs and files.
+func TestNewRemoteCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-creds-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sharedFile := filepath.Join(dir, "credentials")
+	if err = ioutil.WriteFile(sharedFile, []byte(`[default]
+aws_access_key_id = FILEACCESSKEY
+aws_secret_access_key = FILESECRETKEY
+
+[backup]
+aws_access_key_id = BACKUPACCESSKEY
+aws_secret_access_key = BACKUPSECRETKEY
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	accessFile := filepath.Join(dir, "access_key")
+	secretFile := filepath.Join(dir, "secret_key")
+	if err = ioutil.WriteFile(accessFile, []byte("SECRETFILEACCESS\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(secretFile, []byte("SECRETFILESECRET\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	os.Setenv("AWS_ACCESS_KEY_ID", "ENVACCESSKEY")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "ENVSECRETKEY")
+
+	testCases := []struct {
+		bCfg              bucketConfig
+		expectedAccessKey string
+		expectedSecretKey string
+		// configuration error, returned on startup
+		configErr bool
+		// retrieval error, returned when the remote is accessed
+		getErr bool
+	}{
+		// Inline keys, with and without an explicit static provider.
+		{
+			bCfg:              bucketConfig{Bucket: "b", AccessKey: "INLINEACCESS", SecretKey: "INLINESECRET"},
+			expectedAccessKey: "INLINEACCESS",
+			expectedSecretKey: "INLINESECRET",
+		},
+		{
+			bCfg: bucketConfig{Bucket: "b", AccessKey: "INLINEACCESS", SecretKey: "INLINESECRET",
+				Credentials: credentialsConfig{Provider: "Static"}},
+			expectedAccessKey: "INLINEACCESS",
+			expectedSecretKey: "INLINESECRET",
+		},
+		// A provider takes precedence over inline keys.
+		{
+			bCfg: bucketConfig{Bucket: "b", AccessKey: "INLINEACCESS", SecretKey: "INLINESECRET",
+				Credentials: credentialsConfig{Provider: credsProviderEnv}},
+			expectedAccessKey: "ENVACCESSKEY",
+			expectedSecretKey: "ENVSECRETKEY",
+		},
+		{
+			bCfg:              bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderFile, File: sharedFile}},
+			expectedAccessKey: "FILEACCESSKEY",
+			expectedSecretKey: "FILESECRETKEY",
+		},
+		{
+			bCfg: bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderFile,
+				File: sharedFile, Profile: "backup"}},
+			expectedAccessKey: "BACKUPACCESSKEY",
+			expectedSecretKey: "BACKUPSECRETKEY",
+		},
+		{
+			bCfg: bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderSecretFiles,
+				AccessKeyFile: accessFile, SecretKeyFile: secretFile}},
+			expectedAccessKey: "SECRETFILEACCESS",
+			expectedSecretKey: "SECRETFILESECRET",
+		},
+		// Missing files are only detected once the remote is accessed.
+		{
+			bCfg: bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderFile,
+				File: filepath.Join(dir, "missing")}},
+			getErr: true,
+		},
+		{
+			bCfg: bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderSecretFiles,
+				AccessKeyFile: filepath.Join(dir, "missing"), SecretKeyFile: secretFile}},
+			getErr: true,
+		},
+		// Incomplete provider configuration fails on startup.
+		{
+			bCfg:      bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderSecretFiles, AccessKeyFile: accessFile}},
+			configErr: true,
+		},
+		{
+			bCfg:      bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderWebIdentity, TokenFile: accessFile}},
+			configErr: true,
+		},
+		{
+			bCfg:      bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: credsProviderVault}},
+			configErr: true,
+		},
+		{
+			bCfg:      bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: "unknown"}},
+			configErr: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		creds, err := newRemoteCredentials(testCase.bCfg)
+		if err != nil && !testCase.configErr {
+			t.Fatalf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && testCase.configErr {
+			t.Fatalf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		v, err := creds.Get()
+		if err != nil && !testCase.getErr {
+			t.Fatalf("Test %d: Expected credentials but failed instead %s", i+1, err)
+		}
+		if err == nil && testCase.getErr {
+			t.Fatalf("Test %d: Expected retrieval failure but passed instead", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if v.AccessKeyID != testCase.expectedAccessKey || v.SecretAccessKey != testCase.expectedSecretKey {
+			t.Errorf("Test %d: Expected %s/%s, got %s/%s", i+1, testCase.expectedAccessKey,
+				testCase.expectedSecretKey, v.AccessKeyID, v.SecretAccessKey)
+		}
+	}
+}
+
+// Tests that secrets rotated in credential files are redacted from the
+// logs once they are read.
+func TestFileCredentialsRegisterSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-creds-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	accessFile := filepath.Join(dir, "access_key")
+	secretFile := filepath.Join(dir, "secret_key")
+	if err = ioutil.WriteFile(accessFile, []byte("ROTATEDACCESS\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(secretFile, []byte("ROTATEDSECRETKEY\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fileCredentials{accessKeyFile: accessFile, secretKeyFile: secretFile}
+	if _, err = f.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if msg := logger.Redact("signing with ROTATEDSECRETKEY failed"); strings.Contains(msg, "ROTATEDSECRETKEY") {
+		t.Errorf("Expected the rotated secret to be redacted, got %s", msg)
+	}
+}
+
+// Tests that local buckets only accept providers usable for front-end
+// credentials.
+func TestNewLocalCredentials(t *testing.T) {
+	for _, provider := range []string{"", credsProviderStatic, credsProviderSecretFiles, credsProviderVault} {
+		bCfg := bucketConfig{Bucket: "b", AccessKey: "ACCESS", SecretKey: "SECRETKEY",
+			Credentials: credentialsConfig{
+				Provider:      provider,
+				AccessKeyFile: "access",
+				SecretKeyFile: "secret",
+				Vault:         vaultConfig{Address: "https://vault:8200", Path: "secret/radio"},
+			}}
+		if _, err := newLocalCredentials(bCfg); err != nil {
+			t.Errorf("Provider %q: Expected success but failed instead %s", provider, err)
+		}
+	}
+	for _, provider := range []string{credsProviderEnv, credsProviderFile, credsProviderIAM, credsProviderWebIdentity} {
+		bCfg := bucketConfig{Bucket: "b", Credentials: credentialsConfig{Provider: provider}}
+		if _, err := newLocalCredentials(bCfg); err == nil {
+			t.Errorf("Provider %q: Expected failure but passed instead", provider)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/radio/cmd/logger"
+	"github.com/ncw/directio"
+)
+
+// Fsync policies of written files.
+const (
+	fsyncNever    = "never"
+	fsyncAlways   = "always"
+	fsyncInterval = "interval"
+)
+
+const defaultFsyncInterval = time.Second
+
+// durabilityConfig - when the writes of the cache drives and of the
+// journals, the change feed and the cache index, reach the disk.
+type durabilityConfig struct {
+	Cache   durabilityPolicy `yaml:"cache"`
+	Journal durabilityPolicy `yaml:"journal"`
+}
+
+// durabilityPolicy - Fsync is never, the default, leaving writes to the
+// operating system, always, syncing every write before the request
+// completes, or interval, syncing the files written every FsyncInterval,
+// 1s by default. DirectIO, such as 64MiB, writes cache fills of at least
+// this size with O_DIRECT, bypassing the page cache, disabled by default.
+type durabilityPolicy struct {
+	Fsync         string        `yaml:"fsync"`
+	FsyncInterval time.Duration `yaml:"fsync_interval"`
+	DirectIO      string        `yaml:"direct_io"`
+}
+
+// validateDurabilityConfig - validates the durability policies.
+func validateDurabilityConfig(errs *radioConfigErrors, path string, c durabilityConfig) {
+	validateDurabilityPolicy(errs, path+".cache", c.Cache)
+	validateDurabilityPolicy(errs, path+".journal", c.Journal)
+	if c.Journal.DirectIO != "" {
+		errs.add(path+".journal.direct_io", "only cache fills are written with O_DIRECT")
+	}
+}
+
+func validateDurabilityPolicy(errs *radioConfigErrors, path string, p durabilityPolicy) {
+	switch p.Fsync {
+	case "", fsyncNever, fsyncAlways:
+		if p.FsyncInterval != 0 {
+			errs.add(path+".fsync_interval", "requires fsync: interval")
+		}
+	case fsyncInterval:
+		if p.FsyncInterval < 0 {
+			errs.add(path+".fsync_interval", "must not be negative")
+		}
+	default:
+		errs.add(path+".fsync", "%q is not never, always or interval", p.Fsync)
+	}
+	if p.DirectIO != "" {
+		if _, err := humanize.ParseBytes(p.DirectIO); err != nil {
+			errs.add(path+".direct_io", "%v", err)
+		}
+	}
+}
+
+// fileSync - syncs written files to disk according to a policy.
+type fileSync struct {
+	policy   string
+	interval time.Duration
+
+	mu sync.Mutex
+	// dirty holds the paths written since the last sync with the
+	// interval policy.
+	dirty map[string]struct{}
+}
+
+var (
+	// globalCacheSync - syncs the data and metadata of cached objects.
+	globalCacheSync = &fileSync{policy: fsyncNever}
+	// globalJournalSync - syncs the change feed and the cache index.
+	globalJournalSync = &fileSync{policy: fsyncNever}
+	// globalCacheDirectIO - cache fills of at least this size are
+	// written with O_DIRECT, 0 disables it.
+	globalCacheDirectIO int64
+)
+
+// newFileSync - returns the fileSync of p, run must be started with the
+// interval policy.
+func newFileSync(p durabilityPolicy) *fileSync {
+	s := &fileSync{policy: p.Fsync, interval: p.FsyncInterval, dirty: make(map[string]struct{})}
+	if s.policy == "" {
+		s.policy = fsyncNever
+	}
+	if s.policy == fsyncInterval && s.interval == 0 {
+		s.interval = defaultFsyncInterval
+	}
+	return s
+}
+
+// enabled - returns true unless writes are left to the operating system.
+func (s *fileSync) enabled() bool {
+	return s.policy != fsyncNever
+}
+
+// written - syncs f right away with the always policy, or records it for
+// the next sync with the interval policy.
+func (s *fileSync) written(f *os.File) error {
+	switch s.policy {
+	case fsyncAlways:
+		return f.Sync()
+	case fsyncInterval:
+		s.mu.Lock()
+		s.dirty[f.Name()] = struct{}{}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// run - syncs the files written every interval, name tags the errors
+// logged.
+func (s *fileSync) run(name string) {
+	ctx := logger.WithTags(newBackgroundContext("Fsync", "", ""), "files", name)
+	for range time.Tick(s.interval) {
+		logger.LogIf(ctx, s.syncDirty())
+	}
+}
+
+// syncDirty - syncs the files written since the last call, files removed
+// meanwhile are skipped. Returns the first error.
+func (s *fileSync) syncDirty() error {
+	s.mu.Lock()
+	dirty := s.dirty
+	s.dirty = make(map[string]struct{})
+	s.mu.Unlock()
+
+	var firstErr error
+	for path := range dirty {
+		f, err := os.Open(path)
+		if err == nil {
+			err = f.Sync()
+			f.Close()
+		}
+		if err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// directWriter - writes a file with O_DIRECT in aligned blocks, the tail
+// not filling a block is appended without O_DIRECT on Close.
+type directWriter struct {
+	path string
+	f    *os.File
+	buf  []byte
+	n    int
+}
+
+// createDirect - creates the file path for writing with O_DIRECT. Returns
+// an error if the file system does not support O_DIRECT.
+func createDirect(path string) (*directWriter, error) {
+	f, err := directio.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &directWriter{path: path, f: f, buf: directio.AlignedBlock(int(cacheBlkSize))}, nil
+}
+
+func (w *directWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		c := copy(w.buf[w.n:], p)
+		w.n += c
+		written += c
+		p = p[c:]
+		if w.n == len(w.buf) {
+			if _, err := w.f.Write(w.buf); err != nil {
+				return written, err
+			}
+			w.n = 0
+		}
+	}
+	return written, nil
+}
+
+// finish - writes the buffered data and closes the file, synced with s.
+func (w *directWriter) finish(s *fileSync) error {
+	aligned := w.n - w.n%directio.BlockSize
+	_, err := w.f.Write(w.buf[:aligned])
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(w.buf[aligned:w.n]); err == nil {
+		err = s.written(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
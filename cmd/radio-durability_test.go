@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ncw/directio"
+)
+
+func TestValidateDurabilityConfig(t *testing.T) {
+	testCases := []struct {
+		c    durabilityConfig
+		errs int
+	}{
+		{durabilityConfig{}, 0},
+		{durabilityConfig{
+			Cache:   durabilityPolicy{Fsync: "interval", FsyncInterval: 5 * time.Second, DirectIO: "64MiB"},
+			Journal: durabilityPolicy{Fsync: "always"},
+		}, 0},
+		{durabilityConfig{Cache: durabilityPolicy{Fsync: "sometimes", DirectIO: "large"}}, 2},
+		{durabilityConfig{Journal: durabilityPolicy{Fsync: "never", FsyncInterval: time.Second}}, 1},
+		{durabilityConfig{Journal: durabilityPolicy{DirectIO: "1MiB"}}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateDurabilityConfig(&errs, "durability", testCase.c)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestFileSyncInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-fsync-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newFileSync(durabilityPolicy{Fsync: fsyncInterval})
+	if s.interval != defaultFsyncInterval {
+		t.Errorf("expected the default interval, got %v", s.interval)
+	}
+	for _, name := range []string{"kept", "removed"} {
+		f, err := os.Create(pathJoin(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.written(f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if len(s.dirty) != 2 {
+		t.Errorf("expected 2 files to sync, got %v", s.dirty)
+	}
+	os.Remove(pathJoin(dir, "removed"))
+	if err = s.syncDirty(); err != nil {
+		t.Errorf("expected removed files to be skipped, got %v", err)
+	}
+	if len(s.dirty) != 0 {
+		t.Errorf("expected no files left to sync, got %v", s.dirty)
+	}
+
+	if s = newFileSync(durabilityPolicy{}); s.enabled() {
+		t.Error("expected writes to be left to the operating system by default")
+	}
+}
+
+func TestDirectWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radio-directio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := pathJoin(dir, cacheDataFile)
+	w, err := createDirect(path)
+	if err != nil {
+		t.Skipf("O_DIRECT is not supported: %v", err)
+	}
+	data := bytes.Repeat([]byte("radio"), int(cacheBlkSize)/2+directio.BlockSize)
+	for i := 0; i < len(data); i += 4000 {
+		end := i + 4000
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err = w.Write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.finish(newFileSync(durabilityPolicy{Fsync: fsyncAlways})); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, data) {
+		t.Errorf("expected %d bytes written, got %d", len(data), len(written))
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio-go/v6/pkg/s3utils"
+)
+
+// Addressing styles of the buckets of remotes.
+const (
+	addressingAuto        = "auto"
+	addressingPath        = "path"
+	addressingVirtualHost = "virtual-host"
+)
+
+// validateEndpointConfig - validates the endpoint of the remote bCfg and
+// how its requests are addressed and signed.
+func validateEndpointConfig(errs *radioConfigErrors, path string, bCfg bucketConfig) {
+	u, err := url.Parse(bCfg.Endpoint)
+	switch {
+	case err != nil:
+		errs.add(path+".endpoint", "%v", err)
+		return
+	case u.Scheme != "http" && u.Scheme != "https":
+		errs.add(path+".endpoint", "scheme must be http or https, found %q", u.Scheme)
+	case u.Hostname() == "":
+		errs.add(path+".endpoint", "host is missing")
+	}
+	if port := u.Port(); port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			errs.add(path+".endpoint", "port must be between 1-65535, found %q", port)
+		}
+	}
+
+	switch bCfg.Addressing {
+	case "", addressingAuto, addressingPath:
+	case addressingVirtualHost:
+		if net.ParseIP(u.Hostname()) != nil {
+			errs.add(path+".addressing", "buckets of an IP address cannot be addressed virtual-host style")
+		}
+	default:
+		errs.add(path+".addressing", "%q is not auto, path or virtual-host", bCfg.Addressing)
+	}
+}
+
+// bucketLookup - returns how minio-go addresses the bucket of a remote
+// with addressing.
+func bucketLookup(addressing string) miniogo.BucketLookupType {
+	switch addressing {
+	case addressingPath:
+		return miniogo.BucketLookupPath
+	case addressingVirtualHost:
+		return miniogo.BucketLookupDNS
+	}
+	return miniogo.BucketLookupAuto
+}
+
+// remoteOptions - returns the options of the client of the remote bCfg
+// at u. Requests are signed for the signing region of the remote, or the
+// region of AWS endpoints, the region of the bucket is looked up
+// otherwise.
+func remoteOptions(bCfg bucketConfig, u *url.URL, creds *credentials.Credentials) *miniogo.Options {
+	region := bCfg.SigningRegion
+	if region == "" {
+		region = s3utils.GetRegionFromURL(*u)
+	}
+	return &miniogo.Options{
+		Creds:        creds,
+		Secure:       u.Scheme == "https",
+		Region:       region,
+		BucketLookup: bucketLookup(bCfg.Addressing),
+	}
+}
+
+// virtualHost - returns true if requests to the remote address its bucket
+// in the host name.
+func (clnt bucketClient) virtualHost() bool {
+	switch clnt.addressing {
+	case addressingPath:
+		return false
+	case addressingVirtualHost:
+		return true
+	}
+	return s3utils.IsVirtualHostSupported(*clnt.EndpointURL(), clnt.Bucket)
+}
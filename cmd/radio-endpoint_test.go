@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"net/url"
+	"testing"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestValidateEndpointConfig(t *testing.T) {
+	testCases := []struct {
+		bCfg bucketConfig
+		errs int
+	}{
+		{bucketConfig{Endpoint: "https://s3.amazonaws.com"}, 0},
+		{bucketConfig{Endpoint: "http://10.0.0.1:9000", Addressing: "path", SigningRegion: "us-east-1"}, 0},
+		{bucketConfig{Endpoint: "https://storage.example.com:8443", Addressing: "virtual-host"}, 0},
+		{bucketConfig{Endpoint: "https://10.0.0.1", Addressing: "virtual-host"}, 1},
+		{bucketConfig{Endpoint: "https://storage.example.com", Addressing: "dns"}, 1},
+		{bucketConfig{Endpoint: "s3://storage.example.com"}, 1},
+		{bucketConfig{Endpoint: "http://:9000"}, 1},
+		{bucketConfig{Endpoint: "http://storage.example.com:99999"}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateEndpointConfig(&errs, "mirror[0].remote[0]", testCase.bCfg)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestRemoteOptions(t *testing.T) {
+	testCases := []struct {
+		bCfg   bucketConfig
+		region string
+		lookup miniogo.BucketLookupType
+		secure bool
+	}{
+		{bucketConfig{Endpoint: "https://s3.eu-west-1.amazonaws.com"}, "eu-west-1", miniogo.BucketLookupAuto, true},
+		{bucketConfig{Endpoint: "https://s3.eu-west-1.amazonaws.com", SigningRegion: "eu-central-1", Addressing: "path"},
+			"eu-central-1", miniogo.BucketLookupPath, true},
+		// Regions of other endpoints are looked up from the bucket.
+		{bucketConfig{Endpoint: "http://storage.example.com:8080", Addressing: "virtual-host"}, "", miniogo.BucketLookupDNS, false},
+		{bucketConfig{Endpoint: "http://storage.example.com:8080", SigningRegion: "garage"}, "garage", miniogo.BucketLookupAuto, false},
+	}
+	for i, testCase := range testCases {
+		u, err := url.Parse(testCase.bCfg.Endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := remoteOptions(testCase.bCfg, u, nil)
+		if opts.Region != testCase.region || opts.BucketLookup != testCase.lookup || opts.Secure != testCase.secure {
+			t.Errorf("Test %d: expected region %q, lookup %v and secure %v, got %q, %v and %v", i+1,
+				testCase.region, testCase.lookup, testCase.secure, opts.Region, opts.BucketLookup, opts.Secure)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// readObjectWithFailover streams the requested range of a mirrored
+// object into w. When the remote serving the object fails mid-stream
+// the remaining bytes are fetched with a ranged GET from the other
+// remotes holding the same version of the object, so the client does
+// not observe a truncated body. Reading stops once ctx is canceled.
+func readObjectWithFailover(ctx context.Context, rs3s mirrorConfig, info ObjectInfo, startOffset, length int64, o ObjectOptions, w io.Writer) error {
+	tag := info.UserDefined["X-Amz-Meta-Radio-Tag"]
+
+	var sent int64
+	var lastErr error
+	for _, index := range rs3s.readOrder(ctx, info.ReplicaIndex) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		clnt := rs3s.clnts[index]
+		if clnt.inMaintenance() {
+			continue
+		}
+
+		opts := miniogo.GetObjectOptions{}
+		opts.ServerSideEncryption = o.ServerSideEncryption
+		if startOffset >= 0 && length >= 0 {
+			if err := opts.SetRange(startOffset+sent, startOffset+length-1); err != nil {
+				return ErrorRespToObjectError(err, info.Bucket, info.Name)
+			}
+		}
+
+		reader, oi, _, err := clnt.GetObject(clnt.Bucket, clnt.remoteKey(info.Name), opts)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if oi.Metadata.Get("x-amz-meta-radio-tag") != tag {
+			// Remote holds a different version of the object,
+			// resuming from it would corrupt the stream.
+			reader.Close()
+			continue
+		}
+		traceRequestRemote(ctx, clnt)
+
+		nw, rerr, werr := copyFromRemote(ctx, w, reader)
+		reader.Close()
+		sent += nw
+		if werr != nil {
+			// Client went away, nothing to fail over.
+			return werr
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if rerr == nil && (length < 0 || sent >= length) {
+			return nil
+		}
+		if rerr == nil {
+			rerr = io.ErrUnexpectedEOF
+		}
+		logger.LogIf(remoteContext(ctx, clnt), rerr)
+		lastErr = rerr
+	}
+	if lastErr == nil {
+		lastErr = InsufficientReadQuorum{}
+	}
+	return ErrorRespToObjectError(lastErr, info.Bucket, info.Name)
+}
+
+// copyFromRemote copies r into w like io.Copy but reports read and
+// write errors separately, a clean EOF is not an error. r is closed
+// once ctx is canceled, so that a blocked read returns.
+func copyFromRemote(ctx context.Context, w io.Writer, r io.ReadCloser) (written int64, rerr, werr error) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-stopCh:
+		}
+	}()
+
+	bufp := globalCopyBuffers.get()
+	defer globalCopyBuffers.put(bufp)
+	buf := *bufp
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err, nil
+		}
+		nr, err := r.Read(buf)
+		if nr > 0 {
+			nw, ew := w.Write(buf[:nr])
+			written += int64(nw)
+			if ew != nil {
+				return written, nil, ew
+			}
+			if nw != nr {
+				return written, nil, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			return written, nil, nil
+		}
+		if err != nil {
+			return written, err, nil
+		}
+	}
+}
+
+// readObjectParallel streams the requested range of a mirrored object
+// into w, fetching parts of partSize from all remotes in parallel. At
+// most one part per remote is buffered for this read and every buffered
+// part holds one of slots, which are shared by all parallel reads. Parts
+// are written in order and a failing remote is replaced like in
+// readObjectWithFailover. Reads of pending parts are canceled once this
+// returns.
+func readObjectParallel(ctx context.Context, rs3s mirrorConfig, info ObjectInfo, startOffset, length, partSize int64, slots chan struct{}, o ObjectOptions, w io.Writer) error {
+	n := len(rs3s.clnts)
+	parts := int((length + partSize - 1) / partSize)
+
+	type partResult struct {
+		buf *bytes.Buffer
+		err error
+	}
+	results := make([]chan partResult, parts)
+	for i := range results {
+		results[i] = make(chan partResult, 1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	local := make(chan struct{}, n)
+	launchedCh := make(chan int, 1)
+	go func() {
+		launched := 0
+		defer func() { launchedCh <- launched }()
+		for ; launched < parts; launched++ {
+			select {
+			case local <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int) {
+				offset := int64(i) * partSize
+				size := partSize
+				if offset+size > length {
+					size = length - offset
+				}
+				pinfo := info
+				pinfo.ReplicaIndex = (info.ReplicaIndex + i) % n
+
+				buf := bytes.NewBuffer(make([]byte, 0, size))
+				err := readObjectWithFailover(ctx, rs3s, pinfo, startOffset+offset, size, o, buf)
+				results[i] <- partResult{buf, err}
+			}(launched)
+		}
+	}()
+
+	consumed := 0
+	defer func() {
+		// Stop launching parts and wait for the pending ones, so
+		// that their slots are released.
+		cancel()
+		launched := <-launchedCh
+		for i := consumed; i < launched; i++ {
+			<-results[i]
+			<-slots
+		}
+	}()
+
+	for i := 0; i < parts; i++ {
+		var res partResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		consumed++
+
+		err := res.err
+		if err == nil {
+			_, err = res.buf.WriteTo(w)
+		}
+		<-slots
+		<-local
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
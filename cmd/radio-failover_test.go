@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+)
+
+// fakeRemote - serves a single object like an S3 remote, honouring
+// range requests.
+type fakeRemote struct {
+	data []byte
+	tag  string
+	// failAfter aborts every response after this many bytes, 0
+	// serves complete responses.
+	failAfter int
+	// delay postpones every response.
+	delay time.Duration
+
+	mu     sync.Mutex
+	starts []int64
+}
+
+func (f *fakeRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start, end := int64(0), int64(len(f.data))-1
+	if rng := r.Header.Get("Range"); rng != "" {
+		fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+	}
+	f.mu.Lock()
+	f.starts = append(f.starts, start)
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+
+	body := f.data[start : end+1]
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("ETag", `"fake-etag"`)
+	w.Header().Set("Last-Modified", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Amz-Meta-Radio-Tag", f.tag)
+	if r.Header.Get("Range") != "" {
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	if f.failAfter > 0 && f.failAfter < len(body) {
+		w.Write(body[:f.failAfter])
+		w.(http.Flusher).Flush()
+		// Drops the connection mid-stream.
+		panic(http.ErrAbortHandler)
+	}
+	w.Write(body)
+}
+
+// Starts: returns the offsets of the ranges requested from the remote.
+func (f *fakeRemote) Starts() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int64(nil), f.starts...)
+}
+
+// newFakeMirror - returns a mirror of the fake remotes, the returned
+// function stops them.
+func newFakeMirror(t *testing.T, remotes ...*fakeRemote) (mirrorConfig, func()) {
+	var rs3s mirrorConfig
+	var servers []*httptest.Server
+	for _, remote := range remotes {
+		server := httptest.NewServer(remote)
+		servers = append(servers, server)
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clnt, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+			Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+			Region:       "us-east-1",
+			BucketLookup: miniogo.BucketLookupPath,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs3s.clnts = append(rs3s.clnts, bucketClient{Core: &miniogo.Core{Client: clnt}, Bucket: "bucket"})
+	}
+	return rs3s, func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+}
+
+func newFakeObjectData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func newFakeObjectInfo(data []byte, tag string) ObjectInfo {
+	return ObjectInfo{
+		Bucket:      "bucket",
+		Name:        "object",
+		Size:        int64(len(data)),
+		UserDefined: map[string]string{"X-Amz-Meta-Radio-Tag": tag},
+	}
+}
+
+// Tests that a read failing mid-stream resumes from the next remote
+// holding the same version, at the offset reached.
+func TestReadObjectWithFailover(t *testing.T) {
+	data := newFakeObjectData(100 * 1024)
+
+	testCases := []struct {
+		remotes     []*fakeRemote
+		startOffset int64
+		length      int64
+		// expected range offsets requested from each remote
+		starts  [][]int64
+		success bool
+	}{
+		// Healthy remote.
+		{
+			remotes:     []*fakeRemote{{data: data, tag: "v1"}, {data: data, tag: "v1"}},
+			startOffset: 0, length: int64(len(data)),
+			starts:  [][]int64{{0}, nil},
+			success: true,
+		},
+		// Resumes at the offset the first remote failed at.
+		{
+			remotes:     []*fakeRemote{{data: data, tag: "v1", failAfter: 40000}, {data: data, tag: "v1"}},
+			startOffset: 0, length: int64(len(data)),
+			starts:  [][]int64{{0}, {40000}},
+			success: true,
+		},
+		{
+			remotes:     []*fakeRemote{{data: data, tag: "v1", failAfter: 1000}, {data: data, tag: "v1"}},
+			startOffset: 500, length: 50000,
+			starts:  [][]int64{{500}, {1500}},
+			success: true,
+		},
+		// Skips remotes holding another version.
+		{
+			remotes: []*fakeRemote{{data: data, tag: "v1", failAfter: 1000},
+				{data: data, tag: "v2"}, {data: data, tag: "v1"}},
+			startOffset: 0, length: int64(len(data)),
+			starts:  [][]int64{{0}, {1000}, {1000}},
+			success: true,
+		},
+		// Every remote fails.
+		{
+			remotes: []*fakeRemote{{data: data, tag: "v1", failAfter: 1000},
+				{data: data, tag: "v1", failAfter: 1000}},
+			startOffset: 0, length: int64(len(data)),
+			starts:  [][]int64{{0}, {1000}},
+			success: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		rs3s, stop := newFakeMirror(t, testCase.remotes...)
+		var buf bytes.Buffer
+		err := readObjectWithFailover(context.Background(), rs3s, newFakeObjectInfo(data, "v1"),
+			testCase.startOffset, testCase.length, ObjectOptions{}, &buf)
+		stop()
+
+		if err != nil && testCase.success {
+			t.Fatalf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Fatalf("Test %d: Expected failure but passed instead", i+1)
+		}
+		expected := data[testCase.startOffset : testCase.startOffset+testCase.length]
+		if testCase.success && !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Test %d: Read %d bytes which do not match the object", i+1, buf.Len())
+		}
+		for j, remote := range testCase.remotes {
+			if starts := remote.Starts(); fmt.Sprint(starts) != fmt.Sprint(testCase.starts[j]) {
+				t.Errorf("Test %d: Expected remote %d to serve ranges from %v, got %v", i+1, j+1, testCase.starts[j], starts)
+			}
+		}
+	}
+}
+
+// Tests that parallel reads write parts in order while remotes fail or
+// serve parts out of order, and release their slots.
+func TestReadObjectParallel(t *testing.T) {
+	data := newFakeObjectData(300 * 1024)
+	const partSize = 32 * 1024
+
+	testCases := []struct {
+		remotes []*fakeRemote
+		slots   int
+		success bool
+	}{
+		{[]*fakeRemote{{data: data, tag: "v1"}, {data: data, tag: "v1"}, {data: data, tag: "v1"}}, 3, true},
+		// Parts of the first remote complete last.
+		{[]*fakeRemote{{data: data, tag: "v1", delay: 20 * time.Millisecond}, {data: data, tag: "v1"}}, 4, true},
+		// Fewer shared slots than remotes.
+		{[]*fakeRemote{{data: data, tag: "v1"}, {data: data, tag: "v1"}, {data: data, tag: "v1"}}, 1, true},
+		// A remote failing every part mid-stream.
+		{[]*fakeRemote{{data: data, tag: "v1", failAfter: 1000}, {data: data, tag: "v1"}}, 2, true},
+		{[]*fakeRemote{{data: data, tag: "v1", failAfter: 1000}, {data: data, tag: "v1", failAfter: 1000}}, 2, false},
+	}
+
+	for i, testCase := range testCases {
+		rs3s, stop := newFakeMirror(t, testCase.remotes...)
+		slots := make(chan struct{}, testCase.slots)
+		var buf bytes.Buffer
+		err := readObjectParallel(context.Background(), rs3s, newFakeObjectInfo(data, "v1"),
+			0, int64(len(data)), partSize, slots, ObjectOptions{}, &buf)
+		stop()
+
+		if err != nil && testCase.success {
+			t.Fatalf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Fatalf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if testCase.success && !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("Test %d: Read %d bytes which do not match the object", i+1, buf.Len())
+		}
+		if len(slots) != 0 {
+			t.Errorf("Test %d: Expected all slots to be released, %d are held", i+1, len(slots))
+		}
+	}
+}
+
+// failingWriter - fails once more than limit bytes were written.
+type failingWriter struct {
+	limit   int
+	written int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.written+len(p) > f.limit {
+		return 0, errors.New("client went away")
+	}
+	f.written += len(p)
+	return len(p), nil
+}
+
+// Tests that a parallel read stops fetching parts once the client went
+// away or the context was canceled.
+func TestReadObjectParallelCancel(t *testing.T) {
+	data := newFakeObjectData(512 * 1024)
+	const partSize = 16 * 1024
+	remotes := []*fakeRemote{{data: data, tag: "v1"}, {data: data, tag: "v1"}}
+	rs3s, stop := newFakeMirror(t, remotes...)
+	defer stop()
+
+	slots := make(chan struct{}, 2)
+	err := readObjectParallel(context.Background(), rs3s, newFakeObjectInfo(data, "v1"),
+		0, int64(len(data)), partSize, slots, ObjectOptions{}, &failingWriter{limit: 2 * partSize})
+	if err == nil || !strings.Contains(err.Error(), "client went away") {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+	if len(slots) != 0 {
+		t.Errorf("Expected all slots to be released, %d are held", len(slots))
+	}
+	if requested := len(remotes[0].Starts()) + len(remotes[1].Starts()); requested >= len(data)/partSize {
+		t.Errorf("Expected remaining parts not to be fetched, %d parts were requested", requested)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = readObjectParallel(ctx, rs3s, newFakeObjectInfo(data, "v1"),
+		0, int64(len(data)), partSize, slots, ObjectOptions{}, &bytes.Buffer{}); err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if len(slots) != 0 {
+		t.Errorf("Expected all slots to be released, %d are held", len(slots))
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"io"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/radio/pkg/streamdup"
+)
+
+// Most a remote lags behind the fastest remote of an upload by default.
+const defaultFanoutMaxLag = 1 << 30
+
+// globalFanout - buffering of the uploads to remotes lagging behind the
+// others, nil if uploads proceed at the pace of the slowest remote.
+var globalFanout *streamdup.Options
+
+// fanoutConfig - buffering of the uploads to the remotes of a mirror.
+type fanoutConfig struct {
+	// Memory shared by the buffers of all uploads, such as 512MiB.
+	// Uploads proceed at the pace of the slowest remote if empty.
+	Memory string `yaml:"memory"`
+	// SpillDir holds the data buffered once Memory is used up, the
+	// default directory for temporary files if empty.
+	SpillDir string `yaml:"spill_dir"`
+	// MaxLag is the most data buffered for a remote, such as 1GiB,
+	// uploads wait for remotes lagging more.
+	MaxLag string `yaml:"max_lag"`
+}
+
+// validateFanoutConfig - validates the fan-out config.
+func validateFanoutConfig(errs *radioConfigErrors, path string, c fanoutConfig) {
+	if c.Memory == "" {
+		if c != (fanoutConfig{}) {
+			errs.add(path+".memory", "required for buffered uploads")
+		}
+		return
+	}
+	if _, err := humanize.ParseBytes(c.Memory); err != nil {
+		errs.add(path+".memory", "%v", err)
+	}
+	if c.MaxLag != "" {
+		if _, err := humanize.ParseBytes(c.MaxLag); err != nil {
+			errs.add(path+".max_lag", "%v", err)
+		}
+	}
+	if c.SpillDir != "" && !isDir(c.SpillDir) {
+		errs.add(path+".spill_dir", "%s: no such directory", c.SpillDir)
+	}
+}
+
+// newFanoutOptions - returns the buffering options of c.
+func newFanoutOptions(c fanoutConfig) (*streamdup.Options, error) {
+	memory, err := humanize.ParseBytes(c.Memory)
+	if err != nil {
+		return nil, err
+	}
+	opts := &streamdup.Options{
+		Pool:     streamdup.NewPool(int64(memory)),
+		SpillDir: c.SpillDir,
+		MaxLag:   defaultFanoutMaxLag,
+		OnSpill: func(n int64) {
+			fanoutSpilledBytes.Add(float64(n))
+		},
+	}
+	if c.MaxLag != "" {
+		maxLag, err := humanize.ParseBytes(c.MaxLag)
+		if err != nil {
+			return nil, err
+		}
+		opts.MaxLag = int64(maxLag)
+	}
+	return opts, nil
+}
+
+// fanoutBufferedBytes - returns the memory buffering uploads.
+func fanoutBufferedBytes() float64 {
+	if globalFanout == nil {
+		return 0
+	}
+	return float64(globalFanout.Pool.InUse())
+}
+
+// duplicateUpload - returns n readers of the upload body r, one per
+// remote.
+func duplicateUpload(r io.Reader, n int) ([]io.Reader, error) {
+	if globalFanout == nil {
+		return streamdup.New(r, n)
+	}
+	return streamdup.NewBuffered(r, n, *globalFanout)
+}
+
+// closeUploadReaders - closes the readers of duplicateUpload once the
+// remotes are done, releasing the data buffered for remotes which failed
+// before reading it all.
+func closeUploadReaders(readers []io.Reader, err error) {
+	for _, r := range readers {
+		if c, ok := r.(interface{ CloseWithError(error) error }); ok {
+			c.CloseWithError(err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestValidateFanoutConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fanout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		c    fanoutConfig
+		errs int
+	}{
+		{fanoutConfig{}, 0},
+		{fanoutConfig{Memory: "512MiB"}, 0},
+		{fanoutConfig{Memory: "512MiB", SpillDir: dir, MaxLag: "2GiB"}, 0},
+		{fanoutConfig{SpillDir: dir}, 1},
+		{fanoutConfig{Memory: "lots"}, 1},
+		{fanoutConfig{Memory: "512MiB", SpillDir: dir + "/missing", MaxLag: "far"}, 2},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateFanoutConfig(&errs, "fanout", testCase.c)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/policy"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Rejected writes listed per fence, further rejections are only counted.
+const fenceMaxAttempts = 1000
+
+var (
+	errFenceNotFound = errors.New("bucket is not fenced")
+	errFenceWindow   = errors.New("fence must end after it starts")
+)
+
+// WriteFence - writes to Bucket are rejected from Start until End, or
+// until the fence is lifted if End is zero, while reads are served. Set
+// through the admin API during a disaster recovery cutover.
+type WriteFence struct {
+	Bucket string    `json:"bucket"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+	// Rejected counts the writes rejected by the fence, the first
+	// fenceMaxAttempts of them are listed in Attempts.
+	Rejected int64         `json:"rejected"`
+	Attempts []FencedWrite `json:"attempts"`
+}
+
+// FencedWrite - a write rejected by a fence.
+type FencedWrite struct {
+	Time       time.Time `json:"time"`
+	API        string    `json:"api,omitempty"`
+	Action     string    `json:"action"`
+	Object     string    `json:"object,omitempty"`
+	AccessKey  string    `json:"accessKey,omitempty"`
+	RemoteHost string    `json:"remoteHost"`
+}
+
+// active - returns true if the fence rejects writes at now.
+func (f *WriteFence) active(now time.Time) bool {
+	return !now.Before(f.Start) && (f.End.IsZero() || now.Before(f.End))
+}
+
+// writeFences - the fences of this server by bucket.
+type writeFences struct {
+	mu     sync.Mutex
+	fences map[string]*WriteFence
+}
+
+var globalWriteFences = &writeFences{fences: make(map[string]*WriteFence)}
+
+// isWriteAction - returns true for the actions modifying a bucket or its
+// objects.
+func isWriteAction(action string) bool {
+	return strings.HasPrefix(action, "s3:Put") || strings.HasPrefix(action, "s3:Delete") ||
+		action == string(policy.AbortMultipartUploadAction)
+}
+
+// admit - returns ErrBucketWriteFenced if auth writes to a fenced bucket,
+// the attempt is recorded by the fence.
+func (fs *writeFences) admit(r *http.Request, auth HookAuth) APIErrorCode {
+	if auth.Bucket == "" || !isWriteAction(auth.Action) {
+		return ErrNone
+	}
+	now := UTCNow()
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.fences[auth.Bucket]
+	if !ok || !f.active(now) {
+		return ErrNone
+	}
+	f.Rejected++
+	if len(f.Attempts) < fenceMaxAttempts {
+		f.Attempts = append(f.Attempts, FencedWrite{
+			Time:       now,
+			API:        logger.GetReqInfo(r.Context()).API,
+			Action:     auth.Action,
+			Object:     auth.Object,
+			AccessKey:  auth.AccessKey,
+			RemoteHost: getSourceIP(r),
+		})
+	}
+	fencedWrites.WithLabelValues(auth.Bucket).Inc()
+	return ErrBucketWriteFenced
+}
+
+// fenced - returns true if writes to bucket are rejected at now.
+func (fs *writeFences) fenced(bucket string, now time.Time) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.fences[bucket]
+	return ok && f.active(now)
+}
+
+// set - fences f.Bucket, replacing its previous fence. Start defaults to
+// now.
+func (fs *writeFences) set(f WriteFence, now time.Time) (WriteFence, error) {
+	if f.Start.IsZero() {
+		f.Start = now
+	}
+	if !f.End.IsZero() && !f.End.After(f.Start) {
+		return f, errFenceWindow
+	}
+	f.Rejected, f.Attempts = 0, nil
+	fs.mu.Lock()
+	fs.fences[f.Bucket] = &f
+	fs.mu.Unlock()
+	return f, nil
+}
+
+// lift - removes the fence of bucket, returns it with the writes it
+// rejected.
+func (fs *writeFences) lift(bucket string) (WriteFence, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.fences[bucket]
+	if !ok {
+		return WriteFence{}, errFenceNotFound
+	}
+	delete(fs.fences, bucket)
+	return *f, nil
+}
+
+// list - returns the fences sorted by bucket, including those which
+// ended but were not lifted.
+func (fs *writeFences) list() []WriteFence {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fences := make([]WriteFence, 0, len(fs.fences))
+	for _, f := range fs.fences {
+		fence := *f
+		fence.Attempts = append([]FencedWrite(nil), f.Attempts...)
+		fences = append(fences, fence)
+	}
+	sort.Slice(fences, func(i, j int) bool { return fences[i].Bucket < fences[j].Bucket })
+	return fences
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/policy"
+)
+
+func TestIsWriteAction(t *testing.T) {
+	testCases := []struct {
+		action policy.Action
+		write  bool
+	}{
+		{policy.PutObjectAction, true},
+		{policy.DeleteObjectAction, true},
+		{policy.AbortMultipartUploadAction, true},
+		{policy.PutBucketPolicyAction, true},
+		{policy.DeleteBucketAction, true},
+		{policy.GetObjectAction, false},
+		{policy.ListBucketAction, false},
+		{policy.ListMultipartUploadPartsAction, false},
+	}
+	for i, testCase := range testCases {
+		if write := isWriteAction(string(testCase.action)); write != testCase.write {
+			t.Errorf("Test %d: %s: expected %v, got %v", i+1, testCase.action, testCase.write, write)
+		}
+	}
+}
+
+func TestWriteFences(t *testing.T) {
+	fs := &writeFences{fences: make(map[string]*WriteFence)}
+	now := UTCNow()
+	r := httptest.NewRequest(http.MethodPut, "/bucket/object", nil)
+	put := HookAuth{AccessKey: "access", Action: string(policy.PutObjectAction), Bucket: "bucket", Object: "object"}
+	get := HookAuth{AccessKey: "access", Action: string(policy.GetObjectAction), Bucket: "bucket", Object: "object"}
+
+	if s3Err := fs.admit(r, put); s3Err != ErrNone {
+		t.Fatalf("expected writes to pass without a fence, got %v", s3Err)
+	}
+	if _, err := fs.set(WriteFence{Bucket: "bucket", Start: now, End: now}, now); err != errFenceWindow {
+		t.Fatalf("expected an empty window to be rejected, got %v", err)
+	}
+	// A window starting in the future does not fence yet.
+	fence, err := fs.set(WriteFence{Bucket: "bucket", Start: now.Add(time.Hour)}, now)
+	if err != nil || !fence.End.IsZero() {
+		t.Fatalf("unexpected fence %v %v", fence, err)
+	}
+	if fs.fenced("bucket", now) || !fs.fenced("bucket", now.Add(2*time.Hour)) {
+		t.Fatal("expected the fence to start in an hour")
+	}
+
+	if fence, err = fs.set(WriteFence{Bucket: "bucket", Reason: "cutover"}, now); err != nil || !fence.Start.Equal(now) {
+		t.Fatalf("expected the fence to start now, got %v %v", fence, err)
+	}
+	if s3Err := fs.admit(r, put); s3Err != ErrBucketWriteFenced {
+		t.Fatalf("expected the write to be fenced, got %v", s3Err)
+	}
+	if s3Err := fs.admit(r, get); s3Err != ErrNone {
+		t.Fatalf("expected reads to pass, got %v", s3Err)
+	}
+	if s3Err := fs.admit(r, HookAuth{Action: string(policy.PutObjectAction), Bucket: "other"}); s3Err != ErrNone {
+		t.Fatalf("expected writes to other buckets to pass, got %v", s3Err)
+	}
+
+	fences := fs.list()
+	if len(fences) != 1 || fences[0].Rejected != 1 || len(fences[0].Attempts) != 1 {
+		t.Fatalf("expected one rejected write, got %v", fences)
+	}
+	if attempt := fences[0].Attempts[0]; attempt.Object != "object" || attempt.AccessKey != "access" ||
+		attempt.Action != string(policy.PutObjectAction) || attempt.RemoteHost == "" {
+		t.Fatalf("unexpected attempt %v", attempt)
+	}
+
+	if fence, err = fs.lift("bucket"); err != nil || fence.Rejected != 1 || fence.Reason != "cutover" {
+		t.Fatalf("unexpected lifted fence %v %v", fence, err)
+	}
+	if _, err = fs.lift("bucket"); err != errFenceNotFound {
+		t.Fatalf("expected the fence to be lifted, got %v", err)
+	}
+	if s3Err := fs.admit(r, put); s3Err != ErrNone {
+		t.Fatalf("expected writes to pass once lifted, got %v", s3Err)
+	}
+}
@@ -0,0 +1,571 @@
+package cmd
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Heal actions reported for each object.
+const (
+	healActionNone = "none"
+	healActionCopy = "copy"
+)
+
+// Checks deciding which objects are healed, from the cheapest to the
+// most thorough.
+const (
+	// healVerifyExistence - only objects missing on some remotes.
+	healVerifyExistence = "existence"
+	// healVerifyVersion - also objects holding another version, the
+	// default.
+	healVerifyVersion = "version"
+	// healVerifyChecksum - also objects whose content differs although
+	// the version is the same, all objects are read from all remotes.
+	healVerifyChecksum = "checksum"
+)
+
+// Interval at which a heal run reports its progress.
+const healProgressInterval = 5 * time.Second
+
+var errHealNoContentQuorum = errors.New("no majority of the remotes holds the same content")
+
+// HealOpts - options of a heal run.
+type HealOpts struct {
+	Prefix string
+	// StartAfter resumes a run after the last object it reported.
+	StartAfter string
+	DryRun     bool
+	// Verify is one of the healVerify checks, healVerifyVersion if
+	// empty.
+	Verify string
+	// Full walks all objects, otherwise only the prefixes which may
+	// have diverged are walked once the whole bucket was healed.
+	Full bool
+}
+
+// Objects larger than this are copied between remotes with a multipart
+// upload, a single PUT is limited to 5GiB.
+const (
+	healMultipartThreshold = 128 * humanize.MiByte
+	healMinPartSize        = 64 * humanize.MiByte
+)
+
+// HealResultItem - result of healing a single object on a single remote,
+// with DryRun set Action is the action which would have been taken.
+type HealResultItem struct {
+	Object string `json:"object"`
+	Remote string `json:"remote"`
+	Action string `json:"action"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealResult - summary of a heal run, objects up to LastObject in key
+// order were handled. An incremental heal walks the Prefixes which may
+// have diverged instead of all objects, it reports no LastObject.
+type HealResult struct {
+	Bucket         string `json:"bucket"`
+	Prefix         string `json:"prefix"`
+	StartAfter     string `json:"startAfter,omitempty"`
+	DryRun         bool   `json:"dryRun"`
+	Verify         string `json:"verify"`
+	Incremental    bool   `json:"incremental,omitempty"`
+	Prefixes       int    `json:"prefixes,omitempty"`
+	ObjectsScanned int    `json:"objectsScanned"`
+	ObjectsHealed  int    `json:"objectsHealed"`
+	ObjectsFailed  int    `json:"objectsFailed"`
+	LastObject     string `json:"lastObject,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// HealUpdate - one entry of the streamed heal response, an item as soon
+// as it was handled, the progress so far at healProgressInterval or the
+// summary once the run completed.
+type HealUpdate struct {
+	Item     *HealResultItem `json:"item,omitempty"`
+	Progress *HealResult     `json:"progress,omitempty"`
+	Result   *HealResult     `json:"result,omitempty"`
+}
+
+// healMetadata - returns the metadata to be preserved when copying
+// an object between remotes.
+func healMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range header {
+		if len(v) == 0 {
+			continue
+		}
+		k = http.CanonicalHeaderKey(k)
+		switch {
+		case HasPrefix(k, "X-Amz-Meta-"):
+		case k == xhttp.ContentType, k == xhttp.ContentEncoding, k == xhttp.ContentLanguage,
+			k == xhttp.ContentDisposition, k == xhttp.CacheControl, k == xhttp.Expires:
+		default:
+			continue
+		}
+		metadata[k] = v[0]
+	}
+	return metadata
+}
+
+// objectVersion - identifies the version of an object on a remote by its
+// radio tag, objects written to the remotes directly have none and are
+// identified by their ETag and size.
+func objectVersion(info miniogo.ObjectInfo) string {
+	if tag := info.Metadata.Get("x-amz-meta-radio-tag"); tag != "" {
+		return tag
+	}
+	return "etag:" + canonicalizeETag(info.ETag) + ":" + strconv.FormatInt(info.Size, 10)
+}
+
+// remoteLister - walks the listing of one remote in key order, the
+// listing is fetched page by page.
+type remoteLister struct {
+	objCh <-chan miniogo.ObjectInfo
+	head  *miniogo.ObjectInfo
+}
+
+// next - advances to the next object, returns an error if the listing failed.
+func (l *remoteLister) next() error {
+	obj, ok := <-l.objCh
+	if !ok {
+		l.head = nil
+		return nil
+	}
+	if obj.Err != nil {
+		l.head = nil
+		return obj.Err
+	}
+	l.head = &obj
+	return nil
+}
+
+// remoteListers - walks the listings of all remotes of a mirror bucket
+// side by side in key order.
+type remoteListers []*remoteLister
+
+// newRemoteListers - starts listing the objects below prefix after
+// startAfter on all remotes, remotes in maintenance are not listed.
+func newRemoteListers(rs3s mirrorConfig, prefix, startAfter string, recursive bool, doneCh <-chan struct{}) (remoteListers, error) {
+	listers := make(remoteListers, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		if clnt.inMaintenance() {
+			// Not listed, writes missed meanwhile are healed
+			// once the maintenance ends.
+			objCh := make(chan miniogo.ObjectInfo)
+			close(objCh)
+			listers[index] = &remoteLister{objCh: objCh}
+			continue
+		}
+		listers[index] = &remoteLister{objCh: clnt.listAllObjects(prefix, startAfter, recursive, doneCh)}
+		if err := listers[index].next(); err != nil {
+			return nil, err
+		}
+	}
+	return listers, nil
+}
+
+// next - returns the next object in key order across all remotes and its
+// listing on every remote, nil where it is not listed. name is empty once
+// all listings are exhausted.
+func (listers remoteListers) next() (name string, heads []*miniogo.ObjectInfo, err error) {
+	for _, lister := range listers {
+		if lister.head != nil && (name == "" || lister.head.Key < name) {
+			name = lister.head.Key
+		}
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	heads = make([]*miniogo.ObjectInfo, len(listers))
+	for index, lister := range listers {
+		if lister.head == nil || lister.head.Key != name {
+			continue
+		}
+		heads[index] = lister.head
+		if err = lister.next(); err != nil {
+			return "", nil, err
+		}
+	}
+	return name, heads, nil
+}
+
+// copyRemoteObject - copies object from one remote to another by streaming
+// it through radio, remotes may not be able to reach each other. Returns
+// errObjectChanged if the source no longer holds version.
+func copyRemoteObject(src, dst bucketClient, object, version string) error {
+	reader, info, _, err := src.GetObject(src.Bucket, src.remoteKey(object), miniogo.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if objectVersion(info) != version {
+		return errObjectChanged
+	}
+
+	metadata := healMetadata(info.Metadata)
+	if info.Size <= healMultipartThreshold {
+		_, err = dst.PutObject(dst.Bucket, dst.remoteKey(object), reader, info.Size, "", "", metadata, nil)
+		return err
+	}
+	return copyRemoteObjectMultipart(dst, object, reader, info.Size, metadata)
+}
+
+// copyRemoteObjectMultipart - uploads size bytes of reader to dst in parts,
+// the upload is aborted on failure.
+func copyRemoteObjectMultipart(dst bucketClient, object string, reader io.Reader, size int64, metadata map[string]string) error {
+	key := dst.remoteKey(object)
+	partSize := int64(healMinPartSize)
+	if size/partSize >= globalMaxPartID {
+		partSize = size/(globalMaxPartID-1) + 1
+	}
+
+	uploadID, err := dst.NewMultipartUpload(dst.Bucket, key, miniogo.PutObjectOptions{UserMetadata: metadata})
+	if err != nil {
+		return err
+	}
+
+	var parts []miniogo.CompletePart
+	for partID, offset := 1, int64(0); offset < size; partID++ {
+		n := partSize
+		if offset+n > size {
+			n = size - offset
+		}
+		part, err := dst.PutObjectPart(dst.Bucket, key, uploadID, partID,
+			io.LimitReader(reader, n), n, "", "", nil)
+		if err != nil {
+			dst.AbortMultipartUpload(dst.Bucket, key, uploadID)
+			return err
+		}
+		parts = append(parts, miniogo.CompletePart{PartNumber: partID, ETag: part.ETag})
+		offset += n
+	}
+
+	if _, err = dst.CompleteMultipartUpload(dst.Bucket, key, uploadID, parts); err != nil {
+		dst.AbortMultipartUpload(dst.Bucket, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// healObject - copies version of object between remotes while holding
+// the object lock.
+func (l *radioObjects) healObject(ctx context.Context, bucket, object, version string, src, dst bucketClient) error {
+	objectLock := l.NewNSLock(ctx, bucket, object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer objectLock.Unlock()
+
+	return copyRemoteObject(src, dst, object, version)
+}
+
+// healVersion - stats object on the remotes listing it and returns the
+// version held by a quorum of remotes, or the newest version if there is
+// no quorum, along with the version found on every remote.
+func healVersion(rs3s mirrorConfig, object string, listed []bool) (string, []string, error) {
+	versions := make([]string, len(rs3s.clnts))
+	counts := make(map[string]int)
+
+	var newest miniogo.ObjectInfo
+	for index, clnt := range rs3s.clnts {
+		if !listed[index] {
+			continue
+		}
+		info, err := clnt.StatObject(clnt.Bucket, clnt.remoteKey(object), miniogo.StatObjectOptions{})
+		if err != nil {
+			if _, ok := ErrorRespToObjectError(err, clnt.Bucket, object).(ObjectNotFound); ok {
+				// removed since it was listed
+				continue
+			}
+			return "", nil, err
+		}
+		versions[index] = objectVersion(info)
+		counts[versions[index]]++
+		if newest.Key == "" || info.LastModified.After(newest.LastModified) {
+			newest = info
+		}
+	}
+
+	for version, count := range counts {
+		if count > len(rs3s.clnts)/2 {
+			return version, versions, nil
+		}
+	}
+	if newest.Key == "" {
+		return "", versions, nil
+	}
+	return objectVersion(newest), versions, nil
+}
+
+// contentHashes - reads object from the remotes listing it and returns
+// the MD5 of the content on every remote.
+func contentHashes(ctx context.Context, rs3s mirrorConfig, object string, listed []bool) ([]string, error) {
+	hashes := make([]string, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		if !listed[index] {
+			continue
+		}
+		reader, _, _, err := clnt.GetObject(clnt.Bucket, clnt.remoteKey(object), miniogo.GetObjectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		h := md5.New()
+		_, rerr, _ := copyFromRemote(ctx, h, reader)
+		reader.Close()
+		if rerr != nil {
+			return nil, rerr
+		}
+		hashes[index] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
+
+// HealBucket - compares all remotes of a mirrored bucket under the prefix
+// and copies objects which fail the opts.Verify check to the remotes
+// lacking them. The version held by a quorum of remotes is authoritative,
+// without quorum the newest version is. The listings are walked page by
+// page, every item is passed to updateFn once it was handled, followed by
+// the progress at healProgressInterval. Once the whole bucket was healed,
+// later heals only walk the prefixes written to since which may have
+// diverged, unless opts.Full is set.
+func (l *radioObjects) HealBucket(ctx context.Context, bucket string, opts HealOpts, updateFn func(HealUpdate)) (HealResult, error) {
+	if opts.Verify == "" {
+		opts.Verify = healVerifyVersion
+	}
+	result := HealResult{
+		Bucket:     bucket,
+		Prefix:     opts.Prefix,
+		StartAfter: opts.StartAfter,
+		DryRun:     opts.DryRun,
+		Verify:     opts.Verify,
+	}
+	switch opts.Verify {
+	case healVerifyExistence, healVerifyVersion, healVerifyChecksum:
+	default:
+		return result, errInvalidArgument
+	}
+
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok {
+		return result, BucketNotFound{Bucket: bucket}
+	}
+
+	// Healing clears the diverged prefixes covered once their versions
+	// were compared and healed on all remotes.
+	inMaintenance := func() bool {
+		_, n := rs3s.activeRemotes()
+		return n < len(rs3s.clnts)
+	}
+	clears := !opts.DryRun && opts.Verify != healVerifyExistence && !inMaintenance()
+
+	// Checksums can differ without any write missing a remote, they are
+	// compared on all objects.
+	prefixes, synced := rs3s.syncState.diverged(opts.Prefix)
+	lastProgress := time.Now()
+	if !synced || opts.Full || opts.Verify == healVerifyChecksum || opts.StartAfter != "" {
+		err := l.healWalk(ctx, bucket, rs3s, opts.Prefix, opts.StartAfter, true, opts, &result, updateFn, &lastProgress)
+		if err != nil || result.ObjectsFailed > 0 || !clears || inMaintenance() || opts.StartAfter != "" {
+			return result, err
+		}
+		for p := range prefixes {
+			if !HasPrefix(p, opts.Prefix) {
+				// Only partly walked.
+				delete(prefixes, p)
+			}
+		}
+		rs3s.syncState.healed(prefixes, opts.Prefix == "")
+		return result, nil
+	}
+
+	result.Incremental = true
+	for _, p := range sortedSyncPrefixes(prefixes) {
+		walk := p
+		if !HasPrefix(p, opts.Prefix) {
+			// opts.Prefix is part of p.
+			walk = opts.Prefix
+		}
+		failed := result.ObjectsFailed
+		err := l.healWalk(ctx, bucket, rs3s, walk, "", isRecursiveSyncPrefix(p), opts, &result, updateFn, &lastProgress)
+		if err != nil {
+			return result, err
+		}
+		result.Prefixes++
+		if walk == p && result.ObjectsFailed == failed && clears && !inMaintenance() {
+			rs3s.syncState.healed(map[string][]uint64{p: prefixes[p]}, false)
+		}
+	}
+	return result, nil
+}
+
+// healWalk - heals the objects below prefix after startAfter, only those
+// directly below prefix without recursive, and adds them to result.
+func (l *radioObjects) healWalk(ctx context.Context, bucket string, rs3s mirrorConfig, prefix, startAfter string, recursive bool,
+	opts HealOpts, result *HealResult, updateFn func(HealUpdate), lastProgress *time.Time) error {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	listers, err := newRemoteListers(rs3s, prefix, startAfter, recursive, doneCh)
+	if err != nil {
+		return ErrorRespToObjectError(err, bucket)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if time.Since(*lastProgress) >= healProgressInterval {
+			progress := *result
+			updateFn(HealUpdate{Progress: &progress})
+			*lastProgress = time.Now()
+		}
+
+		name, heads, err := listers.next()
+		if err != nil {
+			return ErrorRespToObjectError(err, bucket)
+		}
+		if name == "" {
+			return nil
+		}
+
+		listed := make([]bool, len(heads))
+		var first *miniogo.ObjectInfo
+		missing, identical := false, true
+		for index, head := range heads {
+			if head == nil {
+				missing, identical = true, false
+				continue
+			}
+			listed[index] = true
+			if first == nil {
+				first = head
+			} else if canonicalizeETag(head.ETag) != canonicalizeETag(first.ETag) || head.Size != first.Size {
+				identical = false
+			}
+		}
+		result.ObjectsScanned++
+		healed, failed := l.healListedObject(ctx, bucket, name, rs3s, listed, missing, identical, opts, updateFn)
+		if failed {
+			result.ObjectsFailed++
+		} else if healed {
+			result.ObjectsHealed++
+		}
+		if !result.Incremental {
+			result.LastObject = name
+		}
+	}
+}
+
+// healListedObject - heals object listed on the remotes in listed, returns
+// whether a copy was made and whether any step failed.
+func (l *radioObjects) healListedObject(ctx context.Context, bucket, name string, rs3s mirrorConfig, listed []bool,
+	missing, identical bool, opts HealOpts, updateFn func(HealUpdate)) (healed, failed bool) {
+	switch {
+	case opts.Verify == healVerifyExistence && !missing:
+		return false, false
+	case opts.Verify == healVerifyVersion && identical:
+		// Same content everywhere, nothing to heal.
+		return false, false
+	}
+
+	fail := func(err error) (bool, bool) {
+		logger.LogIf(ctx, err)
+		updateFn(HealUpdate{Item: &HealResultItem{Object: name, Action: healActionNone, DryRun: opts.DryRun, Error: err.Error()}})
+		return false, true
+	}
+
+	version, versions, err := healVersion(rs3s, name, listed)
+	if err != nil {
+		return fail(err)
+	}
+	if version == "" {
+		// removed from all remotes since it was listed
+		return false, false
+	}
+
+	// heal - remotes the object is copied to.
+	heal := make([]bool, len(rs3s.clnts))
+	for index := range rs3s.clnts {
+		heal[index] = versions[index] != version
+		if opts.Verify == healVerifyExistence {
+			heal[index] = !listed[index]
+		}
+	}
+	var hashes []string
+	var hash string
+	if opts.Verify == healVerifyChecksum && identical {
+		if hashes, err = contentHashes(ctx, rs3s, name, listed); err != nil {
+			return fail(err)
+		}
+		counts := make(map[string]int)
+		for index := range hashes {
+			if hashes[index] != "" {
+				counts[hashes[index]]++
+			}
+		}
+		for h, count := range counts {
+			if count > len(rs3s.clnts)/2 {
+				hash = h
+			}
+		}
+		if hash == "" {
+			return fail(errHealNoContentQuorum)
+		}
+		for index := range hashes {
+			heal[index] = heal[index] || hashes[index] != hash
+		}
+	}
+
+	srcIndex := -1
+	for index := range versions {
+		if versions[index] == version && (hashes == nil || hashes[index] == hash) {
+			srcIndex = index
+			break
+		}
+	}
+	if srcIndex < 0 {
+		return fail(errHealNoContentQuorum)
+	}
+
+	for index, clnt := range rs3s.clnts {
+		if !heal[index] || clnt.inMaintenance() {
+			continue
+		}
+		item := HealResultItem{
+			Object: name,
+			Remote: clnt.EndpointURL().String() + SlashSeparator + clnt.Bucket,
+			Action: healActionCopy,
+			DryRun: opts.DryRun,
+		}
+		if !opts.DryRun {
+			err = l.healObject(ctx, bucket, name, version, rs3s.clnts[srcIndex], clnt)
+			switch {
+			case err == errObjectChanged:
+				// Overwritten through radio meanwhile, the
+				// write reached all remotes.
+				item.Action = healActionNone
+			case err != nil:
+				logger.LogIf(remoteContext(ctx, clnt), err)
+				item.Error = err.Error()
+				failed = true
+			default:
+				healed = true
+			}
+		}
+		updateFn(HealUpdate{Item: &item})
+	}
+	return healed, failed
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio/pkg/sync/errgroup"
+)
+
+// RemoteInfo - describes a remote bucket backing a radio bucket.
+type RemoteInfo struct {
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+}
+
+// BucketRemotesInfo - describes a radio bucket and its remotes.
+type BucketRemotesInfo struct {
+	Bucket  string       `json:"bucket"`
+	Type    string       `json:"type"`
+	Parity  int          `json:"parity,omitempty"`
+	Remotes []RemoteInfo `json:"remotes"`
+}
+
+// ServerInfo - server properties and statistics reported by the
+// console and the admin API.
+type ServerInfo struct {
+	Version      string              `json:"version"`
+	CommitID     string              `json:"commitID"`
+	DeploymentID string              `json:"deploymentID"`
+	Region       string              `json:"region"`
+	Uptime       time.Duration       `json:"uptime"`
+	Buckets      []BucketRemotesInfo `json:"buckets"`
+	ConnStats    ServerConnStats     `json:"network"`
+	HTTPStats    ServerHTTPStats     `json:"http"`
+}
+
+func remotesInfo(clnts []bucketClient) []RemoteInfo {
+	remotes := make([]RemoteInfo, 0, len(clnts))
+	for _, clnt := range clnts {
+		remotes = append(remotes, RemoteInfo{
+			Endpoint: clnt.EndpointURL().String(),
+			Bucket:   clnt.Bucket,
+		})
+	}
+	return remotes
+}
+
+// getServerInfo - collects the current server properties.
+func getServerInfo() ServerInfo {
+	info := ServerInfo{
+		Version:      Version,
+		CommitID:     CommitID,
+		DeploymentID: globalDeploymentID,
+		Region:       globalServerRegion,
+		Uptime:       UTCNow().Sub(globalBootTime),
+		ConnStats:    globalConnStats.toServerConnStats(),
+		HTTPStats:    globalHTTPStats.toServerHTTPStats(),
+	}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		return info
+	}
+	for bucket, mcfg := range radioObjAPI.mirrorClients {
+		info.Buckets = append(info.Buckets, BucketRemotesInfo{
+			Bucket:  bucket,
+			Type:    "mirror",
+			Remotes: remotesInfo(mcfg.clnts),
+		})
+	}
+	for bucket, ecfg := range radioObjAPI.erasureClients {
+		info.Buckets = append(info.Buckets, BucketRemotesInfo{
+			Bucket:  bucket,
+			Type:    "erasure",
+			Parity:  ecfg.parity,
+			Remotes: remotesInfo(ecfg.clnts),
+		})
+	}
+	return info
+}
+
+// ReplicaStatus - state of an object on one remote of a mirror bucket.
+type ReplicaStatus struct {
+	Endpoint     string    `json:"endpoint"`
+	Bucket       string    `json:"bucket"`
+	Version      string    `json:"version,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+	InSync       bool      `json:"inSync"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// ObjectReplicationStatus - state of an object across all remotes of a
+// mirror bucket, Version is the radio tag held by a quorum of remotes.
+type ObjectReplicationStatus struct {
+	Bucket   string          `json:"bucket"`
+	Object   string          `json:"object"`
+	Version  string          `json:"version,omitempty"`
+	Replicas []ReplicaStatus `json:"replicas"`
+}
+
+// getObjectReplicationStatus - stats an object on every remote of a
+// mirror bucket, remotes holding a different version than the quorum
+// are reported out of sync.
+func getObjectReplicationStatus(bucket, object string) (ObjectReplicationStatus, error) {
+	status := ObjectReplicationStatus{Bucket: bucket, Object: object}
+
+	radioObjAPI, ok := newObjectLayerFn().(*radioObjects)
+	if !ok {
+		return status, NotImplemented{}
+	}
+	rs3s, ok := radioObjAPI.mirrorClients[bucket]
+	if !ok {
+		return status, BucketNotFound{Bucket: bucket}
+	}
+
+	status.Replicas = make([]ReplicaStatus, len(rs3s.clnts))
+	g := errgroup.WithNErrs(len(rs3s.clnts))
+	for index := range rs3s.clnts {
+		index := index
+		g.Go(func() error {
+			clnt := rs3s.clnts[index]
+			replica := ReplicaStatus{
+				Endpoint: clnt.EndpointURL().String(),
+				Bucket:   clnt.Bucket,
+			}
+			oi, err := clnt.StatObject(clnt.Bucket, clnt.remoteKey(object), miniogo.StatObjectOptions{})
+			if err != nil {
+				replica.Error = ErrorRespToObjectError(err, bucket, object).Error()
+			} else {
+				replica.Version = oi.Metadata.Get("x-amz-meta-radio-tag")
+				replica.ETag = canonicalizeETag(oi.ETag)
+				replica.Size = oi.Size
+				replica.LastModified = oi.LastModified
+			}
+			status.Replicas[index] = replica
+			return nil
+		}, index)
+	}
+	g.Wait()
+
+	versions := make(map[string]int)
+	for _, replica := range status.Replicas {
+		if replica.Error == "" {
+			versions[replica.Version]++
+		}
+	}
+	for version, count := range versions {
+		if count > len(status.Replicas)/2 {
+			status.Version = version
+		}
+	}
+	for i := range status.Replicas {
+		status.Replicas[i].InSync = status.Replicas[i].Error == "" &&
+			status.Version != "" && status.Replicas[i].Version == status.Version
+	}
+	return status, nil
+}
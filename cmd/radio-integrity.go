@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Interval between the samplings of a bucket, the objects sampled are
+// per interval.
+const integritySampleInterval = 24 * time.Hour
+
+// Results counted by the integrity sampling metrics.
+const (
+	integrityResultMatch    = "match"
+	integrityResultMismatch = "mismatch"
+	integrityResultChanged  = "changed"
+	integrityResultError    = "error"
+)
+
+// Servers which find a bucket being sampled by another server give up
+// after this time.
+var integrityLockTimeout = newDynamicTimeout(10*time.Second, 10*time.Second)
+
+// integrityConfig - daily sampling of a mirror bucket, Samples objects
+// picked at random are read from every remote and their checksums
+// compared, an early warning of remotes silently diverging.
+type integrityConfig struct {
+	Samples int `yaml:"samples"`
+	// MaxSize of sampled objects, such as 1GiB, larger objects are never
+	// sampled.
+	MaxSize string `yaml:"max_size"`
+}
+
+// enabled - returns true if the bucket is sampled.
+func (c integrityConfig) enabled() bool {
+	return c.Samples > 0
+}
+
+// maxSize - returns the size of the largest object sampled, 0 for any
+// size.
+func (c integrityConfig) maxSize() int64 {
+	size, err := humanize.ParseBytes(c.MaxSize)
+	if err != nil {
+		return 0
+	}
+	return int64(size)
+}
+
+// validateIntegrityConfig - validates the sampling of a mirror bucket,
+// remotes holds the number of remotes serving reads of every mirror
+// bucket.
+func validateIntegrityConfig(errs *radioConfigErrors, path, bucket string, c integrityConfig, remotes map[string]int) {
+	if c.Samples < 0 {
+		errs.add(path+".samples", "must not be negative")
+		return
+	}
+	if !c.enabled() {
+		if c.MaxSize != "" {
+			errs.add(path+".samples", "required for sampling")
+		}
+		return
+	}
+	if c.MaxSize != "" {
+		if _, err := humanize.ParseBytes(c.MaxSize); err != nil {
+			errs.add(path+".max_size", "%v", err)
+		}
+	}
+	if remotes[bucket] < 2 {
+		errs.add(path, "sampling compares the copies of at least two remotes, %q has %d", bucket, remotes[bucket])
+	}
+}
+
+// IntegrityMismatch - the content of an object on a remote differs from
+// the copy held by most remotes, although their listings agree.
+type IntegrityMismatch struct {
+	Bucket      string
+	Object      string
+	Remote      string
+	Checksum    string
+	RefChecksum string
+}
+
+func (e IntegrityMismatch) Error() string {
+	return "Content of " + e.Bucket + SlashSeparator + e.Object + " on " + e.Remote + " has SHA-256 " + e.Checksum +
+		", most remotes hold " + e.RefChecksum
+}
+
+// integritySample - an object picked for sampling and its listing on
+// every remote.
+type integritySample struct {
+	name  string
+	heads []*miniogo.ObjectInfo
+}
+
+// sampleListing - picks n objects of at most maxSize bytes, 0 for any
+// size, at random from the listing walked by next.
+func sampleListing(next func() (string, []*miniogo.ObjectInfo, error), n int, maxSize int64, rnd *rand.Rand) ([]integritySample, error) {
+	var samples []integritySample
+	seen := 0
+	for {
+		name, heads, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return samples, nil
+		}
+		if ref := referenceCopy(heads); maxSize > 0 && ref.Size > maxSize {
+			continue
+		}
+		seen++
+		sample := integritySample{name: name, heads: heads}
+		if len(samples) < n {
+			samples = append(samples, sample)
+		} else if i := rnd.Intn(seen); i < n {
+			samples[i] = sample
+		}
+	}
+}
+
+// integrityReference - returns the checksum of most copies, ties are
+// decided by the order of the remotes. Empty checksums are not compared.
+func integrityReference(sums []string) string {
+	ref, refCount := "", 0
+	for _, sum := range sums {
+		if sum == "" {
+			continue
+		}
+		count := 0
+		for _, other := range sums {
+			if other == sum {
+				count++
+			}
+		}
+		if count > refCount {
+			ref, refCount = sum, count
+		}
+	}
+	return ref
+}
+
+// contentChecksum - reads object from the remote and returns the SHA-256
+// of its content, errObjectChanged if the remote no longer holds the
+// copy listed as head.
+func (clnt bucketClient) contentChecksum(object string, head *miniogo.ObjectInfo) (string, error) {
+	reader, info, _, err := clnt.GetObject(clnt.Bucket, clnt.remoteKey(object), miniogo.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	if canonicalizeETag(info.ETag) != canonicalizeETag(head.ETag) || info.Size != head.Size {
+		return "", errObjectChanged
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkIntegrity - compares the checksums of the copies of a sampled
+// object listed like the reference copy, copies diverging in their
+// listings are reported by the consistency report instead. Returns the
+// result of the sample.
+func (l *radioObjects) checkIntegrity(ctx context.Context, bucket string, rs3s mirrorConfig, sample integritySample) string {
+	ref := referenceCopy(sample.heads)
+	sums := make([]string, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		head := sample.heads[index]
+		if head == nil || len(divergences(head, ref)) > 0 || clnt.inMaintenance() {
+			continue
+		}
+		sum, err := clnt.contentChecksum(sample.name, head)
+		if err == errObjectChanged {
+			// Overwritten since it was listed.
+			return integrityResultChanged
+		}
+		if err != nil {
+			logger.LogIf(remoteContext(ctx, clnt), fmt.Errorf("integrity sampling of %s/%s on %s: %v", bucket, sample.name,
+				clnt.EndpointURL().Host+SlashSeparator+clnt.Bucket, err))
+			return integrityResultError
+		}
+		sums[index] = sum
+	}
+
+	refSum := integrityReference(sums)
+	result := integrityResultMatch
+	for index, sum := range sums {
+		if sum == "" || sum == refSum {
+			continue
+		}
+		clnt := rs3s.clnts[index]
+		remote := clnt.EndpointURL().Host + SlashSeparator + clnt.Bucket
+		logger.LogIf(remoteContext(ctx, clnt), IntegrityMismatch{Bucket: bucket, Object: sample.name, Remote: remote,
+			Checksum: sum, RefChecksum: refSum})
+		integrityMismatches.WithLabelValues(bucket, remote).Inc()
+		result = integrityResultMismatch
+	}
+	return result
+}
+
+// SampleIntegrity - lists bucket on all remotes, picks the configured
+// number of objects at random and compares their copies, see
+// checkIntegrity. Returns the number of objects whose copies mismatched.
+func (l *radioObjects) SampleIntegrity(ctx context.Context, bucket string, rnd *rand.Rand) (int, error) {
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok {
+		return 0, BucketNotFound{Bucket: bucket}
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	listers, err := newRemoteListers(rs3s, "", "", true, doneCh)
+	if err != nil {
+		return 0, ErrorRespToObjectError(err, bucket)
+	}
+	samples, err := sampleListing(listers.next, rs3s.integrity.Samples, rs3s.integrity.maxSize(), rnd)
+	if err != nil {
+		return 0, ErrorRespToObjectError(err, bucket)
+	}
+
+	mismatched := 0
+	for _, sample := range samples {
+		select {
+		case <-ctx.Done():
+			return mismatched, ctx.Err()
+		default:
+		}
+		result := l.checkIntegrity(ctx, bucket, rs3s, sample)
+		if result == integrityResultMismatch {
+			mismatched++
+		}
+		integritySamples.WithLabelValues(bucket, result).Inc()
+	}
+	return mismatched, nil
+}
+
+// runIntegritySampling - samples bucket once per integritySampleInterval.
+func (l *radioObjects) runIntegritySampling(bucket string) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		next := UTCNow().Truncate(integritySampleInterval).Add(integritySampleInterval)
+		time.Sleep(time.Until(next))
+		l.scheduledIntegritySampling(newBackgroundContext("IntegritySampling", bucket, ""), bucket, rnd)
+	}
+}
+
+// scheduledIntegritySampling - samples bucket, unless another server is
+// sampling it.
+func (l *radioObjects) scheduledIntegritySampling(ctx context.Context, bucket string, rnd *rand.Rand) {
+	lock := l.NewNSLock(ctx, minioMetaBucket, "integrity/"+bucket)
+	if err := lock.GetLock(integrityLockTimeout); err != nil {
+		// Being sampled by another server.
+		return
+	}
+	defer lock.Unlock()
+
+	if _, err := l.SampleIntegrity(ctx, bucket, rnd); err != nil {
+		logger.LogIf(ctx, fmt.Errorf("integrity sampling of %s: %v", bucket, err))
+	}
+}
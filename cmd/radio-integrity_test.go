@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestValidateIntegrityConfig(t *testing.T) {
+	remotes := map[string]int{"bucket": 2, "single": 1}
+	testCases := []struct {
+		bucket string
+		c      integrityConfig
+		errs   int
+	}{
+		{"bucket", integrityConfig{}, 0},
+		{"single", integrityConfig{}, 0},
+		{"bucket", integrityConfig{Samples: 10}, 0},
+		{"bucket", integrityConfig{Samples: 10, MaxSize: "1GiB"}, 0},
+		{"bucket", integrityConfig{Samples: 10, MaxSize: "huge"}, 1},
+		{"bucket", integrityConfig{Samples: -1}, 1},
+		{"bucket", integrityConfig{MaxSize: "1GiB"}, 1},
+		{"single", integrityConfig{Samples: 10}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateIntegrityConfig(&errs, "mirror[0].integrity", testCase.bucket, testCase.c, remotes)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestSampleListing(t *testing.T) {
+	// Objects 0 to 99, the odd ones are larger than the sampled maximum.
+	i := 0
+	next := func() (string, []*miniogo.ObjectInfo, error) {
+		if i == 100 {
+			return "", nil, nil
+		}
+		name := strconv.Itoa(i)
+		head := &miniogo.ObjectInfo{Key: name, ETag: "etag", Size: int64(i%2) * 100}
+		i++
+		return name, []*miniogo.ObjectInfo{head, head}, nil
+	}
+	samples, err := sampleListing(next, 10, 50, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 10 {
+		t.Fatalf("expected 10 samples, got %d", len(samples))
+	}
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		n, _ := strconv.Atoi(sample.name)
+		if n%2 == 1 || seen[sample.name] || len(sample.heads) != 2 {
+			t.Fatalf("unexpected sample %v", sample)
+		}
+		seen[sample.name] = true
+	}
+
+	i = 95
+	if samples, err = sampleListing(next, 10, 0, rand.New(rand.NewSource(1))); err != nil || len(samples) != 5 {
+		t.Fatalf("expected all 5 objects, got %v %v", samples, err)
+	}
+}
+
+func TestIntegrityReference(t *testing.T) {
+	testCases := []struct {
+		sums []string
+		ref  string
+	}{
+		{[]string{"a", "a", "b"}, "a"},
+		{[]string{"b", "a", "a"}, "a"},
+		{[]string{"", "b", "a"}, "b"},
+		{[]string{"", ""}, ""},
+	}
+	for i, testCase := range testCases {
+		if ref := integrityReference(testCase.sums); ref != testCase.ref {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.ref, ref)
+		}
+	}
+}
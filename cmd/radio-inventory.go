@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	inventoryFormatCSV = "csv"
+
+	defaultInventoryInterval = 24 * time.Hour
+	minInventoryInterval     = time.Hour
+
+	// Rows per data file of an inventory.
+	inventoryFileRows = 100000
+
+	// Layout of the run in the keys of an inventory, as used by S3
+	// Inventory.
+	inventoryTimeFormat = "2006-01-02T15-04Z"
+)
+
+// Replication status of an object on a remote in an inventory.
+const (
+	inventoryStatusOK          = "ok"
+	inventoryStatusMissing     = "missing"
+	inventoryStatusDiverged    = "diverged"
+	inventoryStatusMaintenance = "maintenance"
+)
+
+// Servers which find the inventory of a run being written by another
+// server give up after this time.
+var inventoryLockTimeout = newDynamicTimeout(10*time.Second, 10*time.Second)
+
+// inventoryConfig - periodic inventory of a mirror bucket, written to the
+// mirror bucket Destination below Prefix every Interval.
+type inventoryConfig struct {
+	Destination string        `yaml:"destination"`
+	Prefix      string        `yaml:"prefix"`
+	Interval    time.Duration `yaml:"interval"`
+	// Format of the data files, only csv is supported.
+	Format string `yaml:"format"`
+}
+
+// enabled - returns true if an inventory is configured.
+func (c inventoryConfig) enabled() bool {
+	return c.Destination != ""
+}
+
+func (c inventoryConfig) interval() time.Duration {
+	if c.Interval == 0 {
+		return defaultInventoryInterval
+	}
+	return c.Interval
+}
+
+// validateInventoryConfig - validates the inventory of a mirror bucket,
+// mirrorBuckets holds all configured mirror buckets.
+func validateInventoryConfig(errs *radioConfigErrors, path string, c inventoryConfig, mirrorBuckets map[string]string) {
+	if !c.enabled() {
+		if c != (inventoryConfig{}) {
+			errs.add(path+".destination", "required for an inventory")
+		}
+		return
+	}
+	if _, ok := mirrorBuckets[c.Destination]; !ok {
+		errs.add(path+".destination", "%q is not a mirror bucket", c.Destination)
+	}
+	if c.Interval != 0 && c.Interval < minInventoryInterval {
+		errs.add(path+".interval", "must be at least %s", minInventoryInterval)
+	}
+	if c.Format != "" && c.Format != inventoryFormatCSV {
+		errs.add(path+".format", "only %s is supported", inventoryFormatCSV)
+	}
+}
+
+// InventoryManifest - manifest.json of an inventory, listing its data
+// files like the manifests of S3 Inventory.
+type InventoryManifest struct {
+	SourceBucket      string `json:"sourceBucket"`
+	DestinationBucket string `json:"destinationBucket"`
+	Version           string `json:"version"`
+	// CreationTimestamp is the time of the run in milliseconds since
+	// the epoch.
+	CreationTimestamp string          `json:"creationTimestamp"`
+	FileFormat        string          `json:"fileFormat"`
+	FileSchema        string          `json:"fileSchema"`
+	Files             []InventoryFile `json:"files"`
+	// Remotes in the order of the replication status columns.
+	Remotes []string `json:"remotes"`
+}
+
+// InventoryFile - a gzipped data file of an inventory.
+type InventoryFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// inventoryDir - returns the prefix the inventory of bucket for the run
+// at t is written below.
+func inventoryDir(c inventoryConfig, bucket string, t time.Time) string {
+	return c.Prefix + bucket + SlashSeparator + t.UTC().Format(inventoryTimeFormat) + SlashSeparator
+}
+
+// inventoryRow - returns the row of object listed as heads on the remotes,
+// with the replication status of every remote against the copy held by
+// most remotes.
+func inventoryRow(bucket, object string, heads []*miniogo.ObjectInfo, maintenance []bool) []string {
+	ref := referenceCopy(heads)
+	row := []string{bucket, object, strconv.FormatInt(ref.Size, 10),
+		ref.LastModified.UTC().Format(time.RFC3339), canonicalizeETag(ref.ETag)}
+	for index, head := range heads {
+		status := inventoryStatusOK
+		switch divs := divergences(head, ref); {
+		case maintenance[index]:
+			status = inventoryStatusMaintenance
+		case head == nil:
+			status = inventoryStatusMissing
+		case len(divs) > 0:
+			status = inventoryStatusDiverged
+		}
+		row = append(row, status)
+	}
+	return row
+}
+
+// putInventoryObject - writes one object of an inventory through radio.
+func (l *radioObjects) putInventoryObject(ctx context.Context, bucket, object string, data []byte, contentType string) error {
+	size := int64(len(data))
+	hashReader, err := hash.NewReader(bytes.NewReader(data), size, "", "", size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		return err
+	}
+	_, err = l.PutObject(ctx, bucket, object, NewPutObjReader(hashReader, nil, nil),
+		ObjectOptions{UserDefined: map[string]string{"content-type": contentType}})
+	return err
+}
+
+// WriteInventory - lists bucket on all remotes and writes its inventory
+// for the run at t: gzipped CSV data files of inventoryFileRows objects,
+// followed by manifest.json and manifest.checksum.
+func (l *radioObjects) WriteInventory(ctx context.Context, bucket string, t time.Time) (InventoryManifest, error) {
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok {
+		return InventoryManifest{}, BucketNotFound{Bucket: bucket}
+	}
+	cfg := rs3s.inventory
+	dir := inventoryDir(cfg, bucket, t)
+
+	manifest := InventoryManifest{
+		SourceBucket:      bucket,
+		DestinationBucket: cfg.Destination,
+		Version:           "2016-11-30",
+		CreationTimestamp: strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10),
+		FileFormat:        "CSV",
+		FileSchema:        "Bucket, Key, Size, LastModifiedDate, ETag",
+	}
+	for index, clnt := range rs3s.clnts {
+		manifest.Remotes = append(manifest.Remotes, clnt.EndpointURL().String()+SlashSeparator+clnt.Bucket)
+		manifest.FileSchema += fmt.Sprintf(", ReplicationStatus%d", index)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	listers, err := newRemoteListers(rs3s, "", "", true, doneCh)
+	if err != nil {
+		return manifest, ErrorRespToObjectError(err, bucket)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := csv.NewWriter(gz)
+	rows := 0
+	flush := func() error {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		data := buf.Bytes()
+		sum := md5.Sum(data)
+		file := InventoryFile{
+			Key:         fmt.Sprintf("%sdata/%d.csv.gz", dir, len(manifest.Files)),
+			Size:        int64(len(data)),
+			MD5Checksum: hex.EncodeToString(sum[:]),
+		}
+		if err := l.putInventoryObject(ctx, cfg.Destination, file.Key, data, "application/gzip"); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, file)
+		buf.Reset()
+		gz.Reset(&buf)
+		w = csv.NewWriter(gz)
+		rows = 0
+		return nil
+	}
+
+	maintenance := make([]bool, len(rs3s.clnts))
+	for {
+		select {
+		case <-ctx.Done():
+			return manifest, ctx.Err()
+		default:
+		}
+
+		name, heads, err := listers.next()
+		if err != nil {
+			return manifest, ErrorRespToObjectError(err, bucket)
+		}
+		if name == "" {
+			break
+		}
+		for index, clnt := range rs3s.clnts {
+			maintenance[index] = clnt.inMaintenance()
+		}
+		if err = w.Write(inventoryRow(bucket, name, heads, maintenance)); err != nil {
+			return manifest, err
+		}
+		if rows++; rows == inventoryFileRows {
+			if err = flush(); err != nil {
+				return manifest, err
+			}
+		}
+	}
+	if rows > 0 || len(manifest.Files) == 0 {
+		if err = flush(); err != nil {
+			return manifest, err
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return manifest, err
+	}
+	if err = l.putInventoryObject(ctx, cfg.Destination, dir+"manifest.json", data, "application/json"); err != nil {
+		return manifest, err
+	}
+	sum := md5.Sum(data)
+	return manifest, l.putInventoryObject(ctx, cfg.Destination, dir+"manifest.checksum",
+		[]byte(hex.EncodeToString(sum[:])), "text/plain")
+}
+
+// runInventory - writes the inventory of bucket at every multiple of its
+// interval.
+func (l *radioObjects) runInventory(bucket string, interval time.Duration) {
+	for {
+		next := UTCNow().Truncate(interval).Add(interval)
+		time.Sleep(time.Until(next))
+		l.scheduledInventory(newBackgroundContext("Inventory", bucket, ""), bucket, next)
+	}
+}
+
+// scheduledInventory - writes the inventory of bucket for the run at t,
+// unless another server wrote it already.
+func (l *radioObjects) scheduledInventory(ctx context.Context, bucket string, t time.Time) {
+	lock := l.NewNSLock(ctx, minioMetaBucket, "inventory/"+bucket)
+	if err := lock.GetLock(inventoryLockTimeout); err != nil {
+		// Being written by another server.
+		return
+	}
+	defer lock.Unlock()
+
+	cfg := l.mirrorClients[bucket].inventory
+	if _, err := l.GetObjectInfo(ctx, cfg.Destination, inventoryDir(cfg, bucket, t)+"manifest.json", ObjectOptions{}); err == nil {
+		return
+	}
+	if _, err := l.WriteInventory(ctx, bucket, t); err != nil {
+		logger.LogIf(ctx, fmt.Errorf("inventory of %s: %v", bucket, err))
+	}
+}
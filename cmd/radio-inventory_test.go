@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestInventoryRow(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &miniogo.ObjectInfo{ETag: `"a"`, Size: 1, LastModified: now}
+	b := &miniogo.ObjectInfo{ETag: "b", Size: 1, LastModified: now}
+
+	row := inventoryRow("bucket", "photos/a.jpg", []*miniogo.ObjectInfo{a, nil, b, a, nil},
+		[]bool{false, false, false, false, true})
+	expected := []string{"bucket", "photos/a.jpg", "1", "2020-01-02T03:04:05Z", "a",
+		inventoryStatusOK, inventoryStatusMissing, inventoryStatusDiverged, inventoryStatusOK, inventoryStatusMaintenance}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("expected %v, got %v", expected, row)
+	}
+}
+
+func TestValidateInventoryConfig(t *testing.T) {
+	mirrorBuckets := map[string]string{"bucket": "mirror[0].local", "inventory": "mirror[1].local"}
+	testCases := []struct {
+		c    inventoryConfig
+		errs int
+	}{
+		{inventoryConfig{}, 0},
+		{inventoryConfig{Destination: "inventory", Prefix: "daily/", Interval: 24 * time.Hour, Format: "csv"}, 0},
+		{inventoryConfig{Prefix: "daily/"}, 1},
+		{inventoryConfig{Destination: "missing"}, 1},
+		{inventoryConfig{Destination: "inventory", Interval: time.Minute, Format: "parquet"}, 2},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateInventoryConfig(&errs, "inventory", testCase.c, mirrorBuckets)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+// Maximum number of shards of a sharded prefix, shards are named by two
+// hex digits.
+const maxKeyShards = 256
+
+// keyMappingConfig - maps the keys of objects to the keys on a remote.
+// Prefix is prepended to every key, keys below one of the sharded
+// prefixes additionally get a shard directory derived from a hash of the
+// key, such as logs/2020/a.log stored as logs/0c/2020/a.log.
+type keyMappingConfig struct {
+	Prefix string         `yaml:"prefix"`
+	Shard  keyShardConfig `yaml:"shard"`
+}
+
+type keyShardConfig struct {
+	Prefixes []string `yaml:"prefixes"`
+	Count    int      `yaml:"count"`
+}
+
+// validateKeyMapping - validates the key mapping of a remote.
+func validateKeyMapping(errs *radioConfigErrors, path string, m keyMappingConfig) {
+	if strings.HasPrefix(m.Prefix, SlashSeparator) {
+		errs.add(path+".prefix", "must not start with %s", SlashSeparator)
+	}
+	if len(m.Shard.Prefixes) == 0 {
+		return
+	}
+	if m.Shard.Count < 2 || m.Shard.Count > maxKeyShards {
+		errs.add(path+".shard.count", "must be between 2 and %d", maxKeyShards)
+	}
+	for i, prefix := range m.Shard.Prefixes {
+		if !strings.HasSuffix(prefix, SlashSeparator) || strings.HasPrefix(prefix, SlashSeparator) {
+			errs.add(fmt.Sprintf("%s.shard.prefixes[%d]", path, i), "%q must end but not start with %s",
+				prefix, SlashSeparator)
+		}
+		for j, other := range m.Shard.Prefixes {
+			if i != j && strings.HasPrefix(prefix, other) {
+				errs.add(fmt.Sprintf("%s.shard.prefixes[%d]", path, i), "%q overlaps %q", prefix, other)
+			}
+		}
+	}
+}
+
+// isZero - returns true if keys are stored on the remote as is.
+func (m keyMappingConfig) isZero() bool {
+	return m.Prefix == "" && len(m.Shard.Prefixes) == 0
+}
+
+// shardDir - returns the shard directory of key.
+func (m keyMappingConfig) shardDir(key string) string {
+	return fmt.Sprintf("%02x/", crc32.ChecksumIEEE([]byte(key))%uint32(m.Shard.Count))
+}
+
+// shardPrefix - returns the sharded prefix key is stored below, if any.
+func (m keyMappingConfig) shardPrefix(key string) (string, bool) {
+	for _, prefix := range m.Shard.Prefixes {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// toRemote - returns the key of object on the remote.
+func (m keyMappingConfig) toRemote(object string) string {
+	if prefix, ok := m.shardPrefix(object); ok {
+		object = prefix + m.shardDir(object) + object[len(prefix):]
+	}
+	return m.Prefix + object
+}
+
+// fromRemote - returns the object stored as key on the remote, false if
+// key was not written through this mapping.
+func (m keyMappingConfig) fromRemote(key string) (string, bool) {
+	if !strings.HasPrefix(key, m.Prefix) {
+		return "", false
+	}
+	key = key[len(m.Prefix):]
+	prefix, ok := m.shardPrefix(key)
+	if !ok {
+		return key, true
+	}
+	rest := key[len(prefix):]
+	if len(rest) <= 3 || rest[2] != '/' {
+		return "", false
+	}
+	return prefix + rest[3:], true
+}
+
+// remoteKey - returns the key of object on the remote.
+func (clnt bucketClient) remoteKey(object string) string {
+	return clnt.keys.toRemote(object)
+}
+
+// keyListEntry - an object or common prefix of a listing, key is the
+// object or prefix as seen by clients.
+type keyListEntry struct {
+	key    string
+	prefix bool
+	info   miniogo.ObjectInfo
+}
+
+// keyListStream - pages through the listing of one prefix of a remote,
+// the listing of a single shard or of the keys outside of the sharded
+// prefixes is in key order after mapping the keys back.
+type keyListStream struct {
+	clnt       bucketClient
+	prefix     string
+	delimiter  string
+	startAfter string
+	token      string
+	pageSize   int
+	done       bool
+	// once stops after the first page.
+	once bool
+	// exclude holds prefixes on the remote listed by other streams.
+	exclude []string
+	entries []keyListEntry
+}
+
+// head - returns the next entry, nil once the listing is exhausted.
+func (s *keyListStream) head() (*keyListEntry, error) {
+	for len(s.entries) == 0 && !s.done {
+		if err := s.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	if len(s.entries) == 0 {
+		return nil, nil
+	}
+	return &s.entries[0], nil
+}
+
+// pop - drops the entry returned by head.
+func (s *keyListStream) pop() {
+	s.entries = s.entries[1:]
+}
+
+func (s *keyListStream) add(key string, prefix bool, info miniogo.ObjectInfo) {
+	for _, exclude := range s.exclude {
+		if strings.HasPrefix(key, exclude) && len(key) > len(exclude) {
+			return
+		}
+	}
+	object, ok := s.clnt.keys.fromRemote(key)
+	if !ok {
+		return
+	}
+	info.Key = object
+	s.entries = append(s.entries, keyListEntry{key: object, prefix: prefix, info: info})
+}
+
+func (s *keyListStream) fetch() error {
+	result, err := s.clnt.ListObjectsV2(s.clnt.Bucket, s.prefix, s.token, false, s.delimiter, s.pageSize, s.startAfter)
+	if err != nil {
+		return err
+	}
+	s.token = result.NextContinuationToken
+	s.done = !result.IsTruncated || s.token == "" || s.once
+	for _, obj := range result.Contents {
+		s.add(obj.Key, false, obj)
+	}
+	for _, p := range result.CommonPrefixes {
+		s.add(p.Prefix, true, miniogo.ObjectInfo{})
+	}
+	return nil
+}
+
+// keyListStreams - returns the streams listing prefix after startAfter on
+// the remote, one for every shard of the sharded prefixes the listing
+// descends into and one for all other keys.
+func (clnt bucketClient) keyListStreams(prefix, startAfter, delimiter string, pageSize int) []*keyListStream {
+	m := clnt.keys
+	var streams []*keyListStream
+	var sharded []string
+	plain, exact := true, false
+	for _, shardPrefix := range m.Shard.Prefixes {
+		var rest string
+		switch {
+		case strings.HasPrefix(prefix, shardPrefix):
+			rest = prefix[len(shardPrefix):]
+			plain = false
+			exact = prefix == shardPrefix
+		case strings.HasPrefix(shardPrefix, prefix):
+			if delimiter != "" && strings.Contains(shardPrefix[len(prefix):], delimiter) {
+				// Rolled up into a common prefix of the
+				// other keys.
+				continue
+			}
+		default:
+			continue
+		}
+
+		for i := 0; i < m.Shard.Count; i++ {
+			dir := m.Prefix + shardPrefix + fmt.Sprintf("%02x/", i)
+			s := &keyListStream{
+				clnt:      clnt,
+				prefix:    dir + rest,
+				delimiter: delimiter,
+				pageSize:  pageSize,
+			}
+			switch {
+			case strings.HasPrefix(startAfter, shardPrefix):
+				s.startAfter = dir + startAfter[len(shardPrefix):]
+			case startAfter > shardPrefix:
+				// Listed before startAfter.
+				continue
+			}
+			streams = append(streams, s)
+		}
+		sharded = append(sharded, m.Prefix+shardPrefix)
+	}
+	if plain || exact {
+		s := &keyListStream{
+			clnt:      clnt,
+			prefix:    m.Prefix + prefix,
+			delimiter: delimiter,
+			pageSize:  pageSize,
+			exclude:   sharded,
+		}
+		if exact {
+			// Only the object named like the sharded prefix,
+			// which is listed first.
+			s.pageSize, s.once = 1, true
+		}
+		if startAfter != "" {
+			s.startAfter = m.Prefix + startAfter
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+// listObjects - lists up to maxKeys objects and common prefixes below
+// prefix after the key startAfter, merging the listings of sharded
+// prefixes into key order. Keys are returned as seen by clients.
+func (clnt bucketClient) listObjects(prefix, startAfter, delimiter string, maxKeys int) (miniogo.ListBucketResult, error) {
+	streams := clnt.keyListStreams(prefix, startAfter, delimiter, maxKeys)
+	sources := make([]listSource, len(streams))
+	for i, s := range streams {
+		sources[i] = s
+	}
+	return mergeListing(sources, prefix, startAfter, delimiter, maxKeys)
+}
+
+// listAllObjects - lists all objects below prefix after startAfter in key
+// order, like miniogo.Client.ListObjectsV2. Without recursive only the
+// objects directly below prefix are listed.
+func (clnt bucketClient) listAllObjects(prefix, startAfter string, recursive bool, doneCh <-chan struct{}) <-chan miniogo.ObjectInfo {
+	var delimiter string
+	if !recursive {
+		delimiter = SlashSeparator
+	}
+
+	// page - returns the next page of the listing.
+	var token string
+	page := func() ([]miniogo.ObjectInfo, bool, error) {
+		if clnt.keys.isZero() {
+			result, err := clnt.ListObjectsV2(clnt.Bucket, prefix, token, false, delimiter, maxObjectList, startAfter)
+			token = result.NextContinuationToken
+			return result.Contents, result.IsTruncated, err
+		}
+		result, err := clnt.listObjects(prefix, startAfter, delimiter, maxObjectList)
+		startAfter = result.NextMarker
+		return result.Contents, result.IsTruncated, err
+	}
+
+	objCh := make(chan miniogo.ObjectInfo, 1)
+	go func() {
+		defer close(objCh)
+		for {
+			contents, truncated, err := page()
+			if err != nil {
+				select {
+				case objCh <- miniogo.ObjectInfo{Err: err}:
+				case <-doneCh:
+				}
+				return
+			}
+			for _, obj := range contents {
+				select {
+				case objCh <- obj:
+				case <-doneCh:
+					return
+				}
+			}
+			if !truncated {
+				return
+			}
+		}
+	}()
+	return objCh
+}
+
+// fromRemoteUploads - maps the keys of a listing of multipart uploads on
+// the remote back to objects, uploads not written through the mapping are
+// dropped. Uploads below sharded prefixes are listed in shard order.
+func (m keyMappingConfig) fromRemoteUploads(result *miniogo.ListMultipartUploadsResult) {
+	if m.isZero() {
+		return
+	}
+	uploads := result.Uploads[:0]
+	for _, upload := range result.Uploads {
+		if object, ok := m.fromRemote(upload.Key); ok {
+			upload.Key = object
+			uploads = append(uploads, upload)
+		}
+	}
+	result.Uploads = uploads
+
+	prefixes := result.CommonPrefixes[:0]
+	for _, p := range result.CommonPrefixes {
+		if prefix, ok := m.fromRemote(p.Prefix); ok {
+			p.Prefix = prefix
+			prefixes = append(prefixes, p)
+		}
+	}
+	result.CommonPrefixes = prefixes
+
+	result.Prefix = strings.TrimPrefix(result.Prefix, m.Prefix)
+	result.KeyMarker, _ = m.fromRemote(result.KeyMarker)
+	result.NextKeyMarker, _ = m.fromRemote(result.NextKeyMarker)
+}
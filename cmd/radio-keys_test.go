@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+)
+
+func TestKeyMapping(t *testing.T) {
+	m := keyMappingConfig{
+		Prefix: "radio/",
+		Shard:  keyShardConfig{Prefixes: []string{"logs/", "hot/data/"}, Count: 16},
+	}
+	testCases := []struct {
+		object  string
+		sharded bool
+	}{
+		{"object", false},
+		{"dir/object", false},
+		{"logs/", false},
+		{"logs/2020/a.log", true},
+		{"hot/data/x", true},
+		{"hot/other", false},
+	}
+	for i, testCase := range testCases {
+		key := m.toRemote(testCase.object)
+		if !strings.HasPrefix(key, "radio/") {
+			t.Errorf("Test %d: expected %q to have the prefix radio/", i+1, key)
+		}
+		if sharded := key != "radio/"+testCase.object; sharded != testCase.sharded {
+			t.Errorf("Test %d: expected sharded %t, got %q", i+1, testCase.sharded, key)
+		}
+		object, ok := m.fromRemote(key)
+		if !ok || object != testCase.object {
+			t.Errorf("Test %d: expected %q to map back to %q, got %q, %t", i+1, key, testCase.object, object, ok)
+		}
+	}
+
+	for _, key := range []string{"object", "radio/logs/x", "radio/logs/zz"} {
+		if object, ok := m.fromRemote(key); ok {
+			t.Errorf("expected %q not to be mapped, got %q", key, object)
+		}
+	}
+}
+
+func TestValidateKeyMapping(t *testing.T) {
+	testCases := []struct {
+		m    keyMappingConfig
+		errs int
+	}{
+		{keyMappingConfig{}, 0},
+		{keyMappingConfig{Prefix: "radio/"}, 0},
+		{keyMappingConfig{Prefix: "/radio/"}, 1},
+		{keyMappingConfig{Shard: keyShardConfig{Prefixes: []string{"logs/"}, Count: 16}}, 0},
+		{keyMappingConfig{Shard: keyShardConfig{Prefixes: []string{"logs/"}}}, 1},
+		{keyMappingConfig{Shard: keyShardConfig{Prefixes: []string{"logs"}, Count: 512}}, 2},
+		{keyMappingConfig{Shard: keyShardConfig{Prefixes: []string{"logs/", "logs/2020/"}, Count: 4}}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateKeyMapping(&errs, "keys", testCase.m)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+// fakeListRemote - serves ListObjectsV2 of keys like an S3 remote, the
+// continuation token is the last key returned.
+type fakeListRemote struct {
+	keys []string
+}
+
+func (f *fakeListRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	after := q.Get("start-after")
+	if token := q.Get("continuation-token"); token != "" {
+		after = token
+	}
+	maxKeys, _ := strconv.Atoi(q.Get("max-keys"))
+
+	result := miniogo.ListBucketV2Result{Name: "bucket", Prefix: prefix, Delimiter: delimiter}
+	var last string
+	for _, key := range f.keys {
+		if !strings.HasPrefix(key, prefix) || key <= after {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				key = key[:len(prefix)+i+len(delimiter)]
+				if key == last || key <= after {
+					continue
+				}
+			}
+		}
+		if len(result.Contents)+len(result.CommonPrefixes) == maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = last
+			break
+		}
+		if strings.HasSuffix(key, delimiter) && delimiter != "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, miniogo.CommonPrefix{Prefix: key})
+		} else {
+			result.Contents = append(result.Contents, miniogo.ObjectInfo{Key: key})
+		}
+		last = key
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func TestBucketClientListObjects(t *testing.T) {
+	m := keyMappingConfig{
+		Prefix: "radio/",
+		Shard:  keyShardConfig{Prefixes: []string{"logs/"}, Count: 4},
+	}
+	objects := []string{"a", "b/1", "b/2", "logs/", "logs/2019/x", "logs/2020/a", "logs/2020/b",
+		"logs/2020/c", "logs/2021/a", "z"}
+	remote := &fakeListRemote{keys: []string{"other"}}
+	for _, object := range objects {
+		remote.keys = append(remote.keys, m.toRemote(object))
+	}
+	sort.Strings(remote.keys)
+
+	server := httptest.NewServer(remote)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+		Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+		Region:       "us-east-1",
+		BucketLookup: miniogo.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt := bucketClient{Core: &miniogo.Core{Client: c}, Bucket: "bucket", keys: m}
+
+	// list - pages through the listing, returns objects and prefixes.
+	list := func(prefix, delimiter string, maxKeys int) []string {
+		var keys []string
+		var marker string
+		for {
+			result, err := clnt.listObjects(prefix, marker, delimiter, maxKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, obj := range result.Contents {
+				keys = append(keys, obj.Key)
+			}
+			for _, p := range result.CommonPrefixes {
+				keys = append(keys, p.Prefix)
+			}
+			if !result.IsTruncated {
+				return keys
+			}
+			marker = result.NextMarker
+		}
+	}
+
+	testCases := []struct {
+		prefix    string
+		delimiter string
+		expected  []string
+	}{
+		{"", "", objects},
+		{"", "/", []string{"a", "b/", "logs/", "z"}},
+		{"logs/", "", objects[3:9]},
+		{"logs/", "/", []string{"logs/", "logs/2019/", "logs/2020/", "logs/2021/"}},
+		{"logs/2020/", "", []string{"logs/2020/a", "logs/2020/b", "logs/2020/c"}},
+		{"b/", "/", []string{"b/1", "b/2"}},
+		// The delimiter spans the sharded prefix and the keys below it.
+		{"", "/2", []string{"a", "b/1", "b/2", "logs/", "logs/2", "z"}},
+		{"logs/", "2", []string{"logs/", "logs/2"}},
+	}
+	for i, testCase := range testCases {
+		for _, maxKeys := range []int{1, 2, 1000} {
+			keys := list(testCase.prefix, testCase.delimiter, maxKeys)
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, testCase.expected) {
+				t.Errorf("Test %d: expected %v with %d keys per page, got %v", i+1, testCase.expected, maxKeys, keys)
+			}
+		}
+	}
+}
+
+func TestBucketClientListAllObjects(t *testing.T) {
+	m := keyMappingConfig{
+		Prefix: "radio/",
+		Shard:  keyShardConfig{Prefixes: []string{"logs/"}, Count: 4},
+	}
+	objects := []string{"a", "logs/2020/a", "logs/2020/b", "logs/2021/a", "z"}
+	remote := &fakeListRemote{}
+	plain := &fakeListRemote{keys: objects}
+	for _, object := range objects {
+		remote.keys = append(remote.keys, m.toRemote(object))
+	}
+	sort.Strings(remote.keys)
+
+	var servers []*httptest.Server
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+	newClient := func(h http.Handler, m keyMappingConfig) bucketClient {
+		server := httptest.NewServer(h)
+		servers = append(servers, server)
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+			Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+			Region:       "us-east-1",
+			BucketLookup: miniogo.BucketLookupPath,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bucketClient{Core: &miniogo.Core{Client: c}, Bucket: "bucket", keys: m}
+	}
+
+	for _, clnt := range []bucketClient{newClient(remote, m), newClient(plain, keyMappingConfig{})} {
+		doneCh := make(chan struct{})
+		var keys []string
+		for obj := range clnt.listAllObjects("logs/", "logs/2020/a", true, doneCh) {
+			if obj.Err != nil {
+				t.Fatal(obj.Err)
+			}
+			keys = append(keys, obj.Key)
+		}
+		close(doneCh)
+		if expected := []string{"logs/2020/b", "logs/2021/a"}; !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected %v, got %v", expected, keys)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+// listMarker - returns the key a v1 listing of prefix after marker starts
+// after on the remotes. A marker naming a common prefix, the NextMarker of
+// a page ending with it, skips all keys the prefix rolls up like S3 does,
+// instead of listing the common prefix again.
+func listMarker(prefix, marker, delimiter string) string {
+	if delimiter == "" || !strings.HasPrefix(marker, prefix) {
+		return marker
+	}
+	rest := marker[len(prefix):]
+	if i := strings.Index(rest, delimiter); i >= 0 && i+len(delimiter) == len(rest) {
+		// Keys below the prefix sort before it followed by the
+		// largest rune.
+		return marker + string(utf8.MaxRune)
+	}
+	return marker
+}
+
+// completeListObjectsV1 - sets the NextMarker of truncated v1 listings
+// to the last key or common prefix listed, for remotes returning it only
+// with a delimiter or not at all.
+func completeListObjectsV1(result *miniogo.ListBucketResult) {
+	if !result.IsTruncated || result.NextMarker != "" {
+		return
+	}
+	if n := len(result.Contents); n > 0 {
+		result.NextMarker = result.Contents[n-1].Key
+	}
+	if n := len(result.CommonPrefixes); n > 0 && result.CommonPrefixes[n-1].Prefix > result.NextMarker {
+		result.NextMarker = result.CommonPrefixes[n-1].Prefix
+	}
+}
+
+// listSource - the entries of a listing in key order, such as the
+// listing of one shard of a remote.
+type listSource interface {
+	// head returns the next entry, nil once the listing is exhausted.
+	head() (*keyListEntry, error)
+	// pop drops the entry returned by head.
+	pop()
+}
+
+// foldKey - returns the common prefix key below prefix is rolled up into
+// on delimiter, false if key is not rolled up.
+func foldKey(key, prefix, delimiter string) (string, bool) {
+	if delimiter == "" || !strings.HasPrefix(key, prefix) {
+		return key, false
+	}
+	if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+		return key[:len(prefix)+i+len(delimiter)], true
+	}
+	return key, false
+}
+
+// mergeListing - lists up to maxKeys objects and common prefixes below
+// prefix after startAfter, merging the listings of sources into key
+// order. Keys are folded on delimiter after the merge, as seen by
+// clients: sources may fold keys on the remote only partly, a common
+// prefix may span several sources and their pages, and a common prefix
+// up to startAfter was returned by the previous page already. Folded
+// keys never sort before the keys folded before them, so that every
+// common prefix is returned once.
+func mergeListing(sources []listSource, prefix, startAfter, delimiter string, maxKeys int) (result miniogo.ListBucketResult, err error) {
+	result.Prefix = prefix
+	result.Marker = startAfter
+	result.Delimiter = delimiter
+	result.MaxKeys = int64(maxKeys)
+	if maxKeys <= 0 {
+		return result, nil
+	}
+
+	var last string
+	for count := 0; ; {
+		var next listSource
+		var entry *keyListEntry
+		for _, s := range sources {
+			e, err := s.head()
+			if err != nil {
+				return result, err
+			}
+			if e != nil && (entry == nil || e.key < entry.key) {
+				next, entry = s, e
+			}
+		}
+		if entry == nil {
+			return result, nil
+		}
+		e := *entry
+		next.pop()
+
+		key, folded := foldKey(e.key, prefix, delimiter)
+		if key <= startAfter || count > 0 && key == last {
+			continue
+		}
+		if count == maxKeys {
+			result.IsTruncated = true
+			result.NextMarker = last
+			return result, nil
+		}
+
+		if folded || e.prefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, miniogo.CommonPrefix{Prefix: key})
+		} else {
+			result.Contents = append(result.Contents, e.info)
+		}
+		last = key
+		count++
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestListMarker(t *testing.T) {
+	testCases := []struct {
+		prefix, marker, delimiter string
+		expected                  string
+	}{
+		{"", "", "/", ""},
+		{"", "photos/", "", "photos/"},
+		{"", "photos/", "/", "photos/" + string(utf8.MaxRune)},
+		{"", "photos/2019/", "/", "photos/2019/"},
+		{"", "photos/a.jpg", "/", "photos/a.jpg"},
+		{"photos/", "photos/", "/", "photos/"},
+		{"photos/", "photos/2019/", "/", "photos/2019/" + string(utf8.MaxRune)},
+		{"photos/", "music/", "/", "music/"},
+		{"photos/", "photos/2019--", "--", "photos/2019--" + string(utf8.MaxRune)},
+	}
+	for i, testCase := range testCases {
+		if got := listMarker(testCase.prefix, testCase.marker, testCase.delimiter); got != testCase.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.expected, got)
+		}
+	}
+}
+
+func TestCompleteListObjectsV1(t *testing.T) {
+	testCases := []struct {
+		result   miniogo.ListBucketResult
+		expected string
+	}{
+		// Complete listings need no marker.
+		{miniogo.ListBucketResult{
+			Contents: []miniogo.ObjectInfo{{Key: "a"}},
+		}, ""},
+		// The marker of the remote is kept.
+		{miniogo.ListBucketResult{
+			IsTruncated: true,
+			NextMarker:  "b",
+			Contents:    []miniogo.ObjectInfo{{Key: "a"}},
+		}, "b"},
+		{miniogo.ListBucketResult{
+			IsTruncated: true,
+			Contents:    []miniogo.ObjectInfo{{Key: "a"}, {Key: "c"}},
+			CommonPrefixes: []miniogo.CommonPrefix{
+				{Prefix: "b/"},
+			},
+		}, "c"},
+		{miniogo.ListBucketResult{
+			IsTruncated: true,
+			Contents:    []miniogo.ObjectInfo{{Key: "a"}},
+			CommonPrefixes: []miniogo.CommonPrefix{
+				{Prefix: "b/"},
+			},
+		}, "b/"},
+	}
+	for i, testCase := range testCases {
+		completeListObjectsV1(&testCase.result)
+		if testCase.result.NextMarker != testCase.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.expected, testCase.result.NextMarker)
+		}
+	}
+}
+
+// sliceListSource - a listing of entries in key order.
+type sliceListSource []keyListEntry
+
+func (s *sliceListSource) head() (*keyListEntry, error) {
+	if len(*s) == 0 {
+		return nil, nil
+	}
+	return &(*s)[0], nil
+}
+
+func (s *sliceListSource) pop() {
+	*s = (*s)[1:]
+}
+
+// errListSource - a listing failing with err.
+type errListSource struct {
+	err error
+}
+
+func (s errListSource) head() (*keyListEntry, error) {
+	return nil, s.err
+}
+
+func (s errListSource) pop() {}
+
+func TestFoldKey(t *testing.T) {
+	testCases := []struct {
+		key, prefix, delimiter string
+		expected               string
+		folded                 bool
+	}{
+		{"a/b/c", "", "", "a/b/c", false},
+		{"a/b/c", "", "/", "a/", true},
+		{"a/b/c", "a/", "/", "a/b/", true},
+		{"a/b/c", "a/b/", "/", "a/b/c", false},
+		{"a/", "", "/", "a/", true},
+		{"a/", "a/", "/", "a/", false},
+		{"logs/2020", "", "/2", "logs/2", true},
+		{"b/c", "a/", "/", "b/c", false},
+	}
+	for i, testCase := range testCases {
+		key, folded := foldKey(testCase.key, testCase.prefix, testCase.delimiter)
+		if key != testCase.expected || folded != testCase.folded {
+			t.Errorf("Test %d: expected %q, %t, got %q, %t", i+1, testCase.expected, testCase.folded, key, folded)
+		}
+	}
+}
+
+func TestMergeListing(t *testing.T) {
+	keys := []string{"a", "a/", "a/1", "a/2", "a/b/1", "a/b/2", "a-b", "b", "b/1", "b/2/3",
+		"c/", "c/1/", "c/1/2", "c/2", "d", "d/e/f", "d/e/g", "d0", "d0/e"}
+	sort.Strings(keys)
+
+	// expected - the keys of a listing of all keys, folded by S3.
+	expected := func(prefix, delimiter string) []string {
+		var listed []string
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			key, _ = foldKey(key, prefix, delimiter)
+			if len(listed) == 0 || listed[len(listed)-1] != key {
+				listed = append(listed, key)
+			}
+		}
+		return listed
+	}
+
+	// newSources - spreads the keys after startAfter over n sources, the
+	// first one folding its keys like a remote.
+	newSources := func(n int, prefix, startAfter, delimiter string) []listSource {
+		sources := make([]sliceListSource, n)
+		for i, key := range keys {
+			if !strings.HasPrefix(key, prefix) || key <= startAfter {
+				continue
+			}
+			s := &sources[i%n]
+			e := keyListEntry{key: key, info: miniogo.ObjectInfo{Key: key}}
+			if i%n == 0 {
+				e.key, e.prefix = foldKey(key, prefix, delimiter)
+				if len(*s) > 0 && (*s)[len(*s)-1].key == e.key {
+					continue
+				}
+			}
+			*s = append(*s, e)
+		}
+		listSources := make([]listSource, n)
+		for i := range sources {
+			listSources[i] = &sources[i]
+		}
+		return listSources
+	}
+
+	for _, prefix := range []string{"", "a", "a/", "c/", "d", "x"} {
+		for _, delimiter := range []string{"", "/", "/1", "b", "e/"} {
+			want := expected(prefix, delimiter)
+			for n := 1; n <= 3; n++ {
+				for maxKeys := 1; maxKeys <= len(keys)+1; maxKeys++ {
+					var got []string
+					var marker string
+					for pages := 0; ; pages++ {
+						if pages > len(keys) {
+							t.Fatalf("prefix %q, delimiter %q: listing does not end", prefix, delimiter)
+						}
+						result, err := mergeListing(newSources(n, prefix, marker, delimiter), prefix, marker, delimiter, maxKeys)
+						if err != nil {
+							t.Fatal(err)
+						}
+						var page []string
+						for _, obj := range result.Contents {
+							page = append(page, obj.Key)
+						}
+						for _, p := range result.CommonPrefixes {
+							page = append(page, p.Prefix)
+						}
+						sort.Strings(page)
+						if len(page) > maxKeys {
+							t.Fatalf("prefix %q, delimiter %q: %d keys listed, at most %d expected", prefix, delimiter, len(page), maxKeys)
+						}
+						got = append(got, page...)
+						if !result.IsTruncated {
+							break
+						}
+						if result.NextMarker != page[len(page)-1] {
+							t.Fatalf("prefix %q, delimiter %q: expected the marker %q, got %q", prefix, delimiter, page[len(page)-1], result.NextMarker)
+						}
+						marker = result.NextMarker
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("prefix %q, delimiter %q, %d sources, %d keys per page: expected %q, got %q",
+							prefix, delimiter, n, maxKeys, want, got)
+					}
+				}
+			}
+		}
+	}
+
+	errList := errors.New("listing failed")
+	sources := []listSource{&sliceListSource{{key: "a"}}, errListSource{errList}}
+	if _, err := mergeListing(sources, "", "", "", 10); err != errList {
+		t.Errorf("expected %v, got %v", errList, err)
+	}
+	if result, err := mergeListing(sources, "", "", "", 0); err != nil || len(result.Contents) != 0 {
+		t.Errorf("expected an empty listing, got %v, %v", result, err)
+	}
+}
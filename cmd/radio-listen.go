@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/minio/minio/pkg/certs"
+	"github.com/minio/radio/cmd/config"
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+var (
+	// globalAdminListenPorts - ports of the listeners serving the admin
+	// API and the console, empty if all listeners serve them.
+	globalAdminListenPorts = map[string]bool{}
+
+	// globalListenCerts - certificates of the listeners with their own.
+	globalListenCerts []*certs.Certs
+
+	// globalListenEndpoints - endpoints of the listeners in addition to
+	// --address, as printed on startup.
+	globalListenEndpoints []string
+)
+
+// listenConfig - an address served in addition to --address, such as an
+// IPv6 address, a specific interface, a separate admin port or a unix
+// domain socket.
+type listenConfig struct {
+	Address string `yaml:"address"`
+	// Socket is the path of a unix domain socket serving the S3 API
+	// without TLS, instead of an address. Mode sets the permissions of
+	// the socket file, such as 0660, and Group its group.
+	Socket string `yaml:"socket"`
+	Mode   string `yaml:"mode"`
+	Group  string `yaml:"group"`
+	// Admin serves the admin API and the web console, which are then
+	// no longer served by the other listeners.
+	Admin bool `yaml:"admin"`
+	// TLS overrides the server certificates for this listener.
+	TLS struct {
+		CertFile string `yaml:"cert_file"`
+		KeyFile  string `yaml:"key_file"`
+		// Disable serves plain HTTP even if the server has
+		// certificates.
+		Disable bool `yaml:"disable"`
+	} `yaml:"tls"`
+}
+
+// validateListenConfig - validates the additional listeners, the admin
+// listeners are recognized by their port so it cannot be shared with
+// other listeners.
+func validateListenConfig(errs *radioConfigErrors, path string, listen []listenConfig) {
+	addrs := make(map[string]bool)
+	ports := make(map[string]bool)
+	for i, l := range listen {
+		lpath := fmt.Sprintf("%s[%d]", path, i)
+		if l.Socket != "" || l.Address == "" {
+			validateListenSocket(errs, lpath, l, addrs)
+			continue
+		}
+		if l.Mode != "" || l.Group != "" {
+			errs.add(lpath+".mode", "mode and group require a socket")
+		}
+		_, port, err := net.SplitHostPort(l.Address)
+		if err != nil {
+			errs.add(lpath+".address", "%v", err)
+			continue
+		}
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			errs.add(lpath+".address", "port must be between 1 and 65535")
+			continue
+		}
+		if addrs[l.Address] {
+			errs.add(lpath+".address", "%s is listed more than once", l.Address)
+		}
+		addrs[l.Address] = true
+		if admin, ok := ports[port]; ok && admin != l.Admin {
+			errs.add(lpath+".address", "port %s is shared by admin and other listeners", port)
+		}
+		ports[port] = l.Admin
+
+		if (l.TLS.CertFile == "") != (l.TLS.KeyFile == "") {
+			errs.add(lpath+".tls", "cert_file and key_file are required together")
+		}
+		if l.TLS.Disable && l.TLS.CertFile != "" {
+			errs.add(lpath+".tls.disable", "cannot be combined with cert_file")
+		}
+		for _, f := range []string{l.TLS.CertFile, l.TLS.KeyFile} {
+			if f != "" && !isFile(f) {
+				errs.add(lpath+".tls", "%s: no such file", f)
+			}
+		}
+	}
+}
+
+// validateListenSocket - validates the unix domain socket listener l.
+func validateListenSocket(errs *radioConfigErrors, lpath string, l listenConfig, paths map[string]bool) {
+	if l.Socket == "" {
+		errs.add(lpath, "address or socket is required")
+		return
+	}
+	if l.Address != "" {
+		errs.add(lpath+".socket", "cannot be combined with address")
+	}
+	if !filepath.IsAbs(l.Socket) {
+		errs.add(lpath+".socket", "must be an absolute path")
+	}
+	if paths[l.Socket] {
+		errs.add(lpath+".socket", "%s is listed more than once", l.Socket)
+	}
+	paths[l.Socket] = true
+	if _, err := parseSocketMode(l.Mode); err != nil {
+		errs.add(lpath+".mode", "%v", err)
+	}
+	if l.Admin {
+		errs.add(lpath+".admin", "sockets serve the S3 API only")
+	}
+	if l.TLS.CertFile != "" || l.TLS.KeyFile != "" {
+		errs.add(lpath+".tls", "sockets are served without TLS")
+	}
+}
+
+// parseSocketMode - parses octal permissions such as 0660, an empty mode
+// keeps the default.
+func parseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m == 0 || m > 0777 {
+		return 0, fmt.Errorf("%s must be octal permissions such as 0660", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// listenSocket - returns the unix domain socket of l.
+func listenSocket(l listenConfig) (xhttp.UnixSocket, error) {
+	s := xhttp.UnixSocket{Path: l.Socket, GID: -1}
+	var err error
+	if s.Mode, err = parseSocketMode(l.Mode); err != nil {
+		return s, err
+	}
+	if l.Group != "" {
+		g, err := user.LookupGroup(l.Group)
+		if err != nil {
+			return s, err
+		}
+		if s.GID, err = strconv.Atoi(g.Gid); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// listenTLSConfig - returns the TLS configuration of l, and false if it
+// uses the server certificates.
+func listenTLSConfig(l listenConfig) (*tls.Config, bool, error) {
+	if l.TLS.Disable {
+		return nil, true, nil
+	}
+	if l.TLS.CertFile == "" {
+		return nil, false, nil
+	}
+	c, err := certs.New(l.TLS.CertFile, l.TLS.KeyFile, config.LoadX509KeyPair)
+	if err != nil {
+		return nil, false, err
+	}
+	globalListenCerts = append(globalListenCerts, c)
+	return xhttp.NewTLSConfig(c.GetCertificate), true, nil
+}
+
+// setupListeners - adds the listeners of listen to server, which serves
+// --address already.
+func setupListeners(server *xhttp.Server, listen []listenConfig) error {
+	for _, l := range listen {
+		if l.Socket != "" {
+			s, err := listenSocket(l)
+			if err != nil {
+				return err
+			}
+			server.UnixSockets = append(server.UnixSockets, s)
+			globalListenEndpoints = append(globalListenEndpoints, "unix://"+l.Socket)
+			continue
+		}
+		host, port, err := net.SplitHostPort(l.Address)
+		if err != nil {
+			return err
+		}
+		if l.Address == globalCLIContext.Addr || (l.Admin && port == globalRadioPort) {
+			return fmt.Errorf("listener %s conflicts with --address %s", l.Address, globalCLIContext.Addr)
+		}
+		if err = checkPortAvailability(host, port); err != nil {
+			return err
+		}
+		tlsConfig, ok, err := listenTLSConfig(l)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if server.AddrTLSConfigs == nil {
+				server.AddrTLSConfigs = make(map[string]*tls.Config)
+			}
+			server.AddrTLSConfigs[l.Address] = tlsConfig
+		}
+		server.Addrs = append(server.Addrs, l.Address)
+
+		secure := tlsConfig != nil || (!ok && server.TLSConfig != nil)
+		endpoint := getURLScheme(secure) + "://" + l.Address
+		if l.Admin {
+			globalAdminListenPorts[port] = true
+			endpoint += " (admin)"
+		}
+		globalListenEndpoints = append(globalListenEndpoints, endpoint)
+	}
+	return nil
+}
+
+// parseIPNets - parses addresses and CIDRs, such as the proxies whose
+// PROXY protocol or forwarding headers are honored.
+func parseIPNets(sources []string) ([]*net.IPNet, error) {
+	var ipNets []*net.IPNet
+	for _, source := range sources {
+		if ip := net.ParseIP(source); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ipNets = append(ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(source)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an address or CIDR", source)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets, nil
+}
+
+// stopListenCerts - stops watching the certificates of the listeners.
+func stopListenCerts() {
+	for _, c := range globalListenCerts {
+		c.Stop()
+	}
+}
+
+// isAdminListenerPath - returns true for the paths served by the admin
+// listeners only.
+func isAdminListenerPath(urlPath string) bool {
+	return HasPrefix(urlPath, adminAPIPathPrefix) || HasPrefix(urlPath, consolePathPrefix)
+}
+
+// isAdminListenerRequest - returns true if r was received by an admin
+// listener.
+func isAdminListenerRequest(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return false
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	return err == nil && globalAdminListenPorts[port]
+}
+
+func setListenerRoleHandler(h http.Handler) http.Handler { return listenerRoleHandler{h} }
+
+// listenerRoleHandler serves the admin API and the console on the admin
+// listeners only once any are configured, and nothing but them, health
+// checks and metrics on the admin listeners.
+type listenerRoleHandler struct{ handler http.Handler }
+
+func (h listenerRoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(globalAdminListenPorts) == 0 || HasPrefix(r.URL.Path, healthCheckPathPrefix) ||
+		r.URL.Path == minioReservedBucketPath+prometheusMetricsPath {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	if isAdminListenerRequest(r) != isAdminListenerPath(r.URL.Path) {
+		discardRequestBody(w, r)
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrAccessDenied), r.URL)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateListenConfig(t *testing.T) {
+	admin := listenConfig{Address: "127.0.0.1:9001", Admin: true}
+	withCert := listenConfig{Address: "[::]:9443"}
+	withCert.TLS.CertFile = "/nonexistent/public.crt"
+	disabled := listenConfig{Address: "10.0.0.5:9000"}
+	disabled.TLS.Disable = true
+	disabledCert := disabled
+	disabledCert.TLS.CertFile = "/nonexistent/public.crt"
+	disabledCert.TLS.KeyFile = "/nonexistent/private.key"
+	socket := listenConfig{Socket: "/run/radio/radio.sock", Mode: "0660", Group: "app"}
+	socketTLS := listenConfig{Socket: "/run/radio/tls.sock", Admin: true}
+	socketTLS.TLS.CertFile = "/etc/radio/public.crt"
+
+	testCases := []struct {
+		listen []listenConfig
+		errs   int
+	}{
+		{nil, 0},
+		{[]listenConfig{{Address: "[::1]:9000"}, {Address: "10.0.0.5:9000"}, admin}, 0},
+		{[]listenConfig{disabled, {Address: "[::1]:9001", Admin: true}, admin}, 0},
+		{[]listenConfig{{Address: "localhost"}}, 1},
+		{[]listenConfig{{Address: ":0"}}, 1},
+		{[]listenConfig{{Address: ":9000"}, {Address: ":9000"}}, 1},
+		{[]listenConfig{{Address: "10.0.0.5:9001"}, admin}, 1},
+		// Missing key, missing cert file.
+		{[]listenConfig{withCert}, 2},
+		// Disabled with a cert, missing cert and key files.
+		{[]listenConfig{disabledCert}, 3},
+		{[]listenConfig{socket, {Address: "[::1]:9000"}}, 0},
+		{[]listenConfig{{}}, 1},
+		{[]listenConfig{socket, socket}, 1},
+		{[]listenConfig{{Socket: "radio.sock", Address: ":9000", Mode: "0999"}}, 3},
+		{[]listenConfig{{Address: ":9000", Mode: "0600"}}, 1},
+		{[]listenConfig{socketTLS}, 2},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateListenConfig(&errs, "listen", testCase.listen)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestListenerRoleHandler(t *testing.T) {
+	defer func(ports map[string]bool) { globalAdminListenPorts = ports }(globalAdminListenPorts)
+
+	h := setListenerRoleHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	testCases := []struct {
+		adminPorts map[string]bool
+		localAddr  string
+		path       string
+		expected   int
+	}{
+		{map[string]bool{}, "127.0.0.1:9000", adminAPIPathPrefix + adminAPIVersionPrefix + "/info", http.StatusOK},
+		{map[string]bool{"9001": true}, "127.0.0.1:9000", "/bucket/object", http.StatusOK},
+		{map[string]bool{"9001": true}, "127.0.0.1:9000", adminAPIPathPrefix + adminAPIVersionPrefix + "/info", http.StatusForbidden},
+		{map[string]bool{"9001": true}, "127.0.0.1:9000", consolePathPrefix + "/", http.StatusForbidden},
+		{map[string]bool{"9001": true}, "127.0.0.1:9001", adminAPIPathPrefix + adminAPIVersionPrefix + "/info", http.StatusOK},
+		{map[string]bool{"9001": true}, "[::1]:9001", consolePathPrefix + "/", http.StatusOK},
+		{map[string]bool{"9001": true}, "127.0.0.1:9001", "/bucket/object", http.StatusForbidden},
+		{map[string]bool{"9001": true}, "127.0.0.1:9001", healthCheckPathPrefix + healthCheckLivenessPath, http.StatusOK},
+		{map[string]bool{"9001": true}, "127.0.0.1:9001", minioReservedBucketPath + prometheusMetricsPath, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		globalAdminListenPorts = testCase.adminPorts
+		addr, err := net.ResolveTCPAddr("tcp", testCase.localAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, addr))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != testCase.expected {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.expected, w.Code)
+		}
+	}
+}
+
+func TestParseIPNets(t *testing.T) {
+	testCases := []struct {
+		sources  []string
+		expected string
+		success  bool
+	}{
+		{nil, "", true},
+		{[]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::/32", "2001:db8::1"}, "10.0.0.0/8 192.0.2.10/32 2001:db8::/32 2001:db8::1/128", true},
+		{[]string{"10.0.0.0/33"}, "", false},
+		{[]string{"proxy.example.com"}, "", false},
+	}
+	for i, testCase := range testCases {
+		ipNets, err := parseIPNets(testCase.sources)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		var nets []string
+		for _, ipNet := range ipNets {
+			nets = append(nets, ipNet.String())
+		}
+		if got := strings.Join(nets, " "); err == nil && got != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, got)
+		}
+	}
+}
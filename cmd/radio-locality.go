@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// localityConfig - locates clients by their address, the reads of mirrors
+// with remotes in several regions are then served by the remotes of the
+// client region.
+type localityConfig struct {
+	// GeoIPFile lists networks and their region, one "network,region"
+	// per line such as "192.0.2.0/24,eu-west", for instance converted
+	// from a GeoIP database. Networks must not overlap.
+	GeoIPFile string `yaml:"geoip_file"`
+}
+
+// geoIPRange - the addresses first to last, in their 16 byte form, are
+// located in region.
+type geoIPRange struct {
+	first, last net.IP
+	region      string
+}
+
+// geoIPTable - networks and their region, sorted by address.
+type geoIPTable struct {
+	ranges []geoIPRange
+}
+
+// loadGeoIPTable - reads the networks of file, empty lines and lines
+// starting with # are skipped.
+func loadGeoIPTable(file string) (*geoIPTable, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &geoIPTable{}
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return nil, fmt.Errorf("%s:%d: expected network,region", file, line)
+		}
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, line, err)
+		}
+		first := ipNet.IP.To16()
+		last := make(net.IP, net.IPv6len)
+		mask := ipNet.Mask
+		if len(mask) == net.IPv4len {
+			mask = append(net.CIDRMask(96, 128)[:12], mask...)
+		}
+		for i := range last {
+			last[i] = first[i] | ^mask[i]
+		}
+		t.ranges = append(t.ranges, geoIPRange{first, last, strings.TrimSpace(fields[1])})
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(t.ranges, func(i, j int) bool {
+		return bytes.Compare(t.ranges[i].first, t.ranges[j].first) < 0
+	})
+	for i := 1; i < len(t.ranges); i++ {
+		if bytes.Compare(t.ranges[i].first, t.ranges[i-1].last) <= 0 {
+			return nil, fmt.Errorf("%s: network of %s overlaps the network of %s", file,
+				t.ranges[i].first, t.ranges[i-1].first)
+		}
+	}
+	return t, nil
+}
+
+// lookup - returns the region of ip, empty if it is not listed.
+func (t *geoIPTable) lookup(ip net.IP) string {
+	if t == nil || ip == nil {
+		return ""
+	}
+	ip = ip.To16()
+	i := sort.Search(len(t.ranges), func(i int) bool {
+		return bytes.Compare(t.ranges[i].first, ip) > 0
+	})
+	if i == 0 || bytes.Compare(ip, t.ranges[i-1].last) > 0 {
+		return ""
+	}
+	return t.ranges[i-1].region
+}
+
+// latencyWeight - weight of a new sample in the latency estimate.
+const latencyWeight = 0.2
+
+// observeLatency - adds a request which took d to the latency estimate of
+// the remote.
+func (clnt bucketClient) observeLatency(d time.Duration) {
+	if clnt.state == nil {
+		return
+	}
+	for {
+		old := clnt.state.latency.Load()
+		next := int64(d)
+		if old > 0 {
+			next = int64(float64(old)*(1-latencyWeight) + float64(d)*latencyWeight)
+		}
+		if clnt.state.latency.CAS(old, next) {
+			return
+		}
+	}
+}
+
+// estimatedLatency - returns the latency estimate of the remote, 0 if it
+// served no requests yet.
+func (clnt bucketClient) estimatedLatency() time.Duration {
+	if clnt.state == nil {
+		return 0
+	}
+	return time.Duration(clnt.state.latency.Load())
+}
+
+// localized - returns true if the reads of rs3s are served by the remotes
+// close to the client.
+func (rs3s mirrorConfig) localized() bool {
+	for _, clnt := range rs3s.clnts {
+		if clnt.region != "" || len(clnt.networks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocal - returns true if the remote serves the client at ip located in
+// region.
+func (clnt bucketClient) isLocal(ip net.IP, region string) bool {
+	for _, ipNet := range clnt.networks {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return region != "" && clnt.region == region
+}
+
+// readOrder - returns the order the remotes of rs3s are read from, the
+// remotes starting with start in turn unless rs3s is localized. The remotes
+// of localized mirrors listing the client network, or else of the client
+// region, are read in turn starting with start, followed by the others by
+// their latency.
+func (rs3s mirrorConfig) readOrder(ctx context.Context, start int) []int {
+	n := len(rs3s.clnts)
+	order := make([]int, 0, n)
+	if !rs3s.localized() {
+		for i := 0; i < n; i++ {
+			order = append(order, (start+i)%n)
+		}
+		return order
+	}
+
+	var ip net.IP
+	if reqInfo := logger.GetReqInfo(ctx); reqInfo != nil {
+		ip = net.ParseIP(reqInfo.RemoteHost)
+	}
+	region := rs3s.geoIP.lookup(ip)
+	var local, remote []int
+	for index, clnt := range rs3s.clnts {
+		if ip != nil && clnt.isLocal(ip, region) {
+			local = append(local, index)
+		} else {
+			remote = append(remote, index)
+		}
+	}
+	for i := range local {
+		order = append(order, local[(start+i)%len(local)])
+	}
+	// Remotes without a latency estimate yet are read last.
+	sort.SliceStable(remote, func(i, j int) bool {
+		li, lj := rs3s.clnts[remote[i]].estimatedLatency(), rs3s.clnts[remote[j]].estimatedLatency()
+		return lj == 0 && li > 0 || li > 0 && li < lj
+	})
+	return append(order, remote...)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+func TestGeoIPTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		data    string
+		success bool
+	}{
+		{"# network,region\n192.0.2.0/24,eu-west\n\n198.51.100.0/25, us-east\n2001:db8::/32,eu-west\n", true},
+		{"192.0.2.0/24\n", false},
+		{"192.0.2.0/33,eu-west\n", false},
+		{"192.0.2.0/24,\n", false},
+		{"192.0.2.0/24,eu-west\n192.0.2.128/25,us-east\n", false},
+	}
+	for i, testCase := range testCases {
+		file := filepath.Join(dir, "geoip.csv")
+		if err = ioutil.WriteFile(file, []byte(testCase.data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err = loadGeoIPTable(file)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+	}
+
+	table, err := loadGeoIPTable(filepath.Join(dir, "missing.csv"))
+	if err == nil {
+		t.Fatal("expected a missing file to fail")
+	}
+	file := filepath.Join(dir, "geoip.csv")
+	if err = ioutil.WriteFile(file, []byte(testCases[0].data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = loadGeoIPTable(file); err != nil {
+		t.Fatal(err)
+	}
+	for ip, region := range map[string]string{
+		"192.0.2.0":      "eu-west",
+		"192.0.2.255":    "eu-west",
+		"192.0.3.0":      "",
+		"198.51.100.64":  "us-east",
+		"198.51.100.128": "",
+		"2001:db8::1":    "eu-west",
+		"2001:db9::1":    "",
+		"10.0.0.1":       "",
+	} {
+		if got := table.lookup(net.ParseIP(ip)); got != region {
+			t.Errorf("%s: expected region %q, got %q", ip, region, got)
+		}
+	}
+}
+
+func TestReadOrder(t *testing.T) {
+	_, office, _ := net.ParseCIDR("10.1.0.0/16")
+	table := &geoIPTable{ranges: []geoIPRange{{
+		first:  net.ParseIP("192.0.2.0"),
+		last:   net.ParseIP("192.0.2.255"),
+		region: "eu-west",
+	}}}
+	newClient := func(region string, networks []*net.IPNet, latency time.Duration) bucketClient {
+		clnt := bucketClient{region: region, networks: networks, state: &remoteState{}}
+		if latency > 0 {
+			clnt.observeLatency(latency)
+		}
+		return clnt
+	}
+	localized := mirrorConfig{
+		clnts: []bucketClient{
+			newClient("us-east", nil, 10*time.Millisecond),
+			newClient("eu-west", nil, 80*time.Millisecond),
+			newClient("us-west", []*net.IPNet{office}, 0),
+			newClient("eu-west", nil, 90*time.Millisecond),
+		},
+		geoIP: table,
+	}
+	plain := mirrorConfig{clnts: []bucketClient{{}, {}, {}}}
+
+	testCases := []struct {
+		rs3s     mirrorConfig
+		client   string
+		start    int
+		expected []int
+	}{
+		{plain, "192.0.2.10", 1, []int{1, 2, 0}},
+		{localized, "192.0.2.10", 0, []int{1, 3, 0, 2}},
+		{localized, "192.0.2.10", 1, []int{3, 1, 0, 2}},
+		{localized, "10.1.2.3", 3, []int{2, 0, 1, 3}},
+		// Unlocated clients are served by latency.
+		{localized, "198.51.100.7", 2, []int{0, 1, 3, 2}},
+		{localized, "", 0, []int{0, 1, 3, 2}},
+	}
+	for i, testCase := range testCases {
+		ctx := logger.SetReqInfo(context.Background(), &logger.ReqInfo{RemoteHost: testCase.client})
+		if order := testCase.rs3s.readOrder(ctx, testCase.start); !reflect.DeepEqual(order, testCase.expected) {
+			t.Errorf("Test %d: expected order %v, got %v", i+1, testCase.expected, order)
+		}
+	}
+
+	// The latency estimate follows the remote.
+	clnt := newClient("", nil, 100*time.Millisecond)
+	for i := 0; i < 20; i++ {
+		clnt.observeLatency(10 * time.Millisecond)
+	}
+	if d := clnt.estimatedLatency(); d < 10*time.Millisecond || d > 15*time.Millisecond {
+		t.Errorf("expected a latency estimate close to 10ms, got %s", d)
+	}
+}
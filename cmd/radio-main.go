@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/minio/cli"
+	"github.com/minio/minio-go/v6/pkg/credentials"
 	xhttp "github.com/minio/radio/cmd/http"
 	"github.com/minio/minio/pkg/auth"
 	"github.com/minio/minio/pkg/certs"
@@ -34,6 +35,10 @@ var radioFlags = []cli.Flag{
 		Name:  "config, c",
 		Usage: "path to radio configuration",
 	},
+	cli.BoolFlag{
+		Name:  "chaos",
+		Usage: "allow injecting faults into the requests to remotes through the admin API, for testing only",
+	},
 }
 
 var (
@@ -52,13 +57,37 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 		logger.FatalIf(errUnexpected, "Radio implementation not initialized")
 	}
 
+	var localCreds []*credentials.Credentials
+	var rotateLocalCreds bool
 	for _, mcfg := range radio.rconfig.Mirror {
-		cred, err := auth.CreateCredentials(mcfg.Local.AccessKey, mcfg.Local.SecretKey)
+		creds, err := newLocalCredentials(mcfg.Local)
 		if err != nil {
 			logger.FatalIf(err, "Invalid credentials")
 		}
-		cred.SessionToken = mcfg.Local.SessionToken
-		globalLocalCreds[mcfg.Local.AccessKey] = cred
+		localCreds = append(localCreds, creds)
+		rotateLocalCreds = rotateLocalCreds || mcfg.Local.Credentials.rotates()
+	}
+	logger.FatalIf(setLocalCredentials(localCreds), "Invalid credentials")
+	if rotateLocalCreds {
+		go refreshLocalCredentials(localCreds)
+	}
+
+	if radio.rconfig.Admin.AccessKey != "" {
+		cred, err := auth.CreateCredentials(radio.rconfig.Admin.AccessKey, radio.rconfig.Admin.SecretKey)
+		if err != nil {
+			logger.FatalIf(err, "Invalid admin credentials")
+		}
+		globalAdminCred = cred
+	}
+
+	if ctx.Bool("chaos") {
+		globalChaos = &chaosInjector{}
+		logger.Info("Fault injection is enabled, requests to remotes may be failed on purpose through the admin API.")
+	}
+
+	// Keep the configured secrets out of the logs, wherever quoted.
+	for _, secret := range config.Secrets(&radio.rconfig) {
+		logger.RegisterSecret(secret)
 	}
 
 	// Disable logging until radio initialization is complete, any
@@ -86,28 +115,24 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 	globalRootCAs, err = config.GetRootCAs(radio.rconfig.Distribute.Certs.CAPath)
 	logger.FatalIf(err, "Failed to read root CAs (%v)", err)
 
+	if radio.rconfig.Admin.PprofAddress != "" {
+		logger.FatalIf(startPprofServer(radio.rconfig.Admin.PprofAddress), "Unable to start the profiling listener")
+	}
+
 	// Set system resources to maximum.
 	logger.LogIf(context.Background(), setMaxResources())
 
 	// Initialize globalConsoleSys system
 	globalConsoleSys = NewConsoleLogger(context.Background(), globalEndpoints)
 
-	if globalCacheConfig.Enabled {
-		// initialize the new disk cache objects.
-		var cacheAPI CacheObjectLayer
-		cacheAPI, err = newServerCacheObjects(context.Background(), globalCacheConfig)
-		logger.FatalIf(err, "Unable to initialize disk caching")
-
-		globalObjLayerMutex.Lock()
-		globalCacheObjectAPI = cacheAPI
-		globalObjLayerMutex.Unlock()
-	}
-
 	// Override any values from ENVs.
 	if err := lookupConfigEnv(radio.rconfig); err != nil {
 		logger.FatalIf(err, "Unable to initialize server config")
 	}
 
+	// Initialize the cache from the configuration looked up above.
+	logger.FatalIf(initCacheObjectLayer(context.Background()), "Unable to initialize disk caching")
+
 	router := mux.NewRouter().SkipClean(true)
 
 	registerLockRESTHandlers(router, globalEndpoints)
@@ -116,7 +141,19 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 	registerHealthCheckRouter(router)
 
 	// Add server metrics router
-	registerMetricsRouter(router)
+	logger.FatalIf(registerMetricsRouter(router, radio.rconfig.Metrics), "Invalid metrics configuration")
+
+	// Add web console router
+	registerConsoleRouter(router)
+
+	// Add admin API router
+	registerAdminRouter(router)
+
+	// Add static website routers, before the API routers
+	registerWebsiteRouter(router, radio.rconfig)
+
+	// Add WebDAV exports router
+	registerWebDAVRouter(router, radio.rconfig.WebDAV)
 
 	for _, lCfg := range radio.rconfig.Mirror {
 		registerAPIRouter(router, lCfg.Local.Bucket)
@@ -133,6 +170,20 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 
 	httpServer := xhttp.NewServer([]string{globalCLIContext.Addr},
 		criticalErrorHandler{registerHandlers(router, globalHandlers...)}, getCert)
+	httpServer.MaxHeaderBytes = globalAPIConfig.MaxHeaderBytes
+	httpServer.MaxHeaderCount = globalAPIConfig.MaxHeaderCount
+	httpServer.ReadHeaderTimeout = globalAPIConfig.ReadHeaderTimeout
+	httpServer.IdleTimeout = globalAPIConfig.IdleTimeout
+	httpServer.MaxConnsPerIP = globalAPIConfig.MaxConnsPerIP
+	httpServer.TrustedProxies, err = parseIPNets(radio.rconfig.API.ProxyProtocolSources)
+	logger.FatalIf(err, "Invalid api configuration")
+	globalTrustedProxies, err = parseIPNets(radio.rconfig.API.TrustedProxies)
+	logger.FatalIf(err, "Invalid api configuration")
+	logger.SourceIP = getSourceIP
+	logger.FatalIf(setupListeners(httpServer, radio.rconfig.Listen), "Unable to start the listeners")
+	if radio.rconfig.SFTP.Address != "" {
+		logger.FatalIf(startSFTPServer(radio.rconfig.SFTP), "Unable to start the SFTP listener")
+	}
 	go func() {
 		globalHTTPServerErrorCh <- httpServer.Start()
 	}()
@@ -147,6 +198,7 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 	if err != nil {
 		// Stop watching for any certificate changes.
 		globalTLSCerts.Stop()
+		stopListenCerts()
 
 		globalHTTPServer.Shutdown()
 		logger.FatalIf(err, "Unable to initialize radio backend")
@@ -177,3 +229,20 @@ func startRadio(ctx *cli.Context, radio *Radio) {
 
 	handleSignals()
 }
+
+// initCacheObjectLayer - initializes the cache objects from
+// globalCacheConfig, once lookupConfigEnv has read it.
+func initCacheObjectLayer(ctx context.Context) error {
+	if !globalCacheConfig.Enabled {
+		return nil
+	}
+	cacheAPI, err := newServerCacheObjects(ctx, globalCacheConfig)
+	if err != nil {
+		return err
+	}
+
+	globalObjLayerMutex.Lock()
+	globalCacheObjectAPI = cacheAPI
+	globalObjLayerMutex.Unlock()
+	return nil
+}
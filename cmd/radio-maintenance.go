@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/radio/cmd/logger"
+	"go.uber.org/atomic"
+)
+
+// globalReadOnly - rejects all writes with 503 while set, toggled
+// through the admin API.
+var globalReadOnly atomic.Bool
+
+var errRemoteMaintenance = errors.New("remote is in maintenance")
+
+// remoteState - runtime state of a remote, shared by all copies of its
+// bucketClient.
+type remoteState struct {
+	maintenance atomic.Bool
+	// latency estimates the time the remote takes to answer, in
+	// nanoseconds.
+	latency atomic.Int64
+
+	mu sync.Mutex
+	// pending holds the objects written while the remote was in
+	// maintenance, they are healed once it ends.
+	pending map[string]struct{}
+	// capabilities are found by the last probe of the remote, nil
+	// until it is probed.
+	capabilities *RemoteCapabilities
+}
+
+// inMaintenance - returns true if the remote is excluded from reads and
+// writes.
+func (clnt bucketClient) inMaintenance() bool {
+	return clnt.state != nil && clnt.state.maintenance.Load()
+}
+
+// queueWrite - records a write of object skipped while the remote is in
+// maintenance.
+func (clnt bucketClient) queueWrite(object string) {
+	clnt.state.mu.Lock()
+	clnt.state.pending[object] = struct{}{}
+	clnt.state.mu.Unlock()
+}
+
+// pendingWrites - returns and forgets the objects written while the
+// remote was in maintenance.
+func (clnt bucketClient) pendingWrites() []string {
+	if clnt.state == nil {
+		return nil
+	}
+	clnt.state.mu.Lock()
+	defer clnt.state.mu.Unlock()
+	objects := make([]string, 0, len(clnt.state.pending))
+	for object := range clnt.state.pending {
+		objects = append(objects, object)
+	}
+	clnt.state.pending = make(map[string]struct{})
+	sort.Strings(objects)
+	return objects
+}
+
+// activeRemotes - returns which remotes of rs3s are not in maintenance
+// and their number, a write quorum is a majority of them.
+func (rs3s mirrorConfig) activeRemotes() (active []bool, n int) {
+	active = make([]bool, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		if !clnt.inMaintenance() {
+			active[index] = true
+			n++
+		}
+	}
+	return active, n
+}
+
+// uploadRemotes - returns which remotes of rs3s take part in the multipart
+// upload with uploadIDs and their number. Remotes put in maintenance since
+// the upload started leave it, object is healed on them later.
+func (rs3s mirrorConfig) uploadRemotes(object string, uploadIDs []string) (active []bool, n int) {
+	active = make([]bool, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		switch {
+		case uploadIDs[index] == "":
+		case clnt.inMaintenance():
+			uploadIDs[index] = ""
+			clnt.queueWrite(object)
+		default:
+			active[index] = true
+			n++
+		}
+	}
+	return active, n
+}
+
+// firstActive - returns the index of the first active remote.
+func firstActive(active []bool) int {
+	for index := range active {
+		if active[index] {
+			return index
+		}
+	}
+	return 0
+}
+
+// listClient - returns the remote serving listings, the first one not
+// in maintenance.
+func (rs3s mirrorConfig) listClient() bucketClient {
+	for _, clnt := range rs3s.clnts {
+		if !clnt.inMaintenance() {
+			return clnt
+		}
+	}
+	return rs3s.clnts[0]
+}
+
+// RemoteMaintenance - maintenance state of one remote of a bucket.
+type RemoteMaintenance struct {
+	Bucket      string `json:"bucket"`
+	Endpoint    string `json:"endpoint"`
+	Remote      string `json:"remote"`
+	Maintenance bool   `json:"maintenance"`
+	Pending     int    `json:"pending"`
+}
+
+// MaintenanceStatus - read-only mode of the server and maintenance state
+// of all remotes of mirror buckets.
+type MaintenanceStatus struct {
+	ReadOnly bool                `json:"readOnly"`
+	Remotes  []RemoteMaintenance `json:"remotes"`
+}
+
+// getMaintenanceStatus - collects the current maintenance state.
+func (l *radioObjects) getMaintenanceStatus() MaintenanceStatus {
+	status := MaintenanceStatus{ReadOnly: globalReadOnly.Load()}
+	for bucket, rs3s := range l.mirrorClients {
+		for _, clnt := range rs3s.clnts {
+			clnt.state.mu.Lock()
+			pending := len(clnt.state.pending)
+			clnt.state.mu.Unlock()
+			status.Remotes = append(status.Remotes, RemoteMaintenance{
+				Bucket:      bucket,
+				Endpoint:    clnt.EndpointURL().String(),
+				Remote:      clnt.Bucket,
+				Maintenance: clnt.inMaintenance(),
+				Pending:     pending,
+			})
+		}
+	}
+	sort.Slice(status.Remotes, func(i, j int) bool {
+		return status.Remotes[i].Bucket < status.Remotes[j].Bucket
+	})
+	return status
+}
+
+// SetRemoteMaintenance - moves the remote bucket at endpoint in or out of
+// maintenance for all mirror buckets it backs. At least one remote of
+// every bucket stays out of maintenance. Writes skipped during the
+// maintenance are healed in the background once it ends.
+func (l *radioObjects) SetRemoteMaintenance(ctx context.Context, endpoint, remote string, enable bool) error {
+	match := func(clnt bucketClient) bool {
+		return clnt.EndpointURL().String() == endpoint && clnt.Bucket == remote
+	}
+
+	found := false
+	for bucket, rs3s := range l.mirrorClients {
+		for _, clnt := range rs3s.clnts {
+			if !match(clnt) {
+				continue
+			}
+			found = true
+			if _, n := rs3s.activeRemotes(); enable && n == 1 && !clnt.inMaintenance() {
+				return errors.New("the last remote of bucket " + bucket + " cannot be put in maintenance")
+			}
+		}
+	}
+	if !found {
+		return errors.New("no remote bucket " + remote + " at " + endpoint)
+	}
+
+	ended := false
+	for _, rs3s := range l.mirrorClients {
+		for _, clnt := range rs3s.clnts {
+			if match(clnt) && clnt.state.maintenance.Swap(enable) && !enable {
+				ended = true
+			}
+		}
+	}
+	if ended {
+		go l.healPendingWrites(newBackgroundContext("HealPendingWrites", "", ""))
+	}
+	return nil
+}
+
+// healSkippedWrites - starts healing writes skipped on remotes of rs3s
+// which are not in maintenance, a copy from a remote in maintenance or a
+// multipart upload outliving the maintenance skips them too.
+func (l *radioObjects) healSkippedWrites(rs3s mirrorConfig, active []bool) {
+	for index, clnt := range rs3s.clnts {
+		if !active[index] && !clnt.inMaintenance() {
+			go l.healPendingWrites(newBackgroundContext("HealPendingWrites", "", ""))
+			return
+		}
+	}
+}
+
+// healPendingWrites - heals the objects skipped on the remotes out of
+// maintenance from the other remotes. Objects removed from the other
+// remotes meanwhile are removed from them.
+func (l *radioObjects) healPendingWrites(ctx context.Context) {
+	for bucket, rs3s := range l.mirrorClients {
+		for index, dst := range rs3s.clnts {
+			if dst.inMaintenance() {
+				// Healed once the maintenance ends.
+				continue
+			}
+			for _, object := range dst.pendingWrites() {
+				if err := l.healPendingWrite(ctx, bucket, object, rs3s, index); err != nil {
+					logger.LogIf(remoteContext(ctx, dst), err)
+					dst.queueWrite(object)
+				}
+			}
+		}
+	}
+}
+
+func (l *radioObjects) healPendingWrite(ctx context.Context, bucket, object string, rs3s mirrorConfig, index int) error {
+	objectLock := l.NewNSLock(ctx, bucket, object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer objectLock.Unlock()
+
+	listed, _ := rs3s.activeRemotes()
+	listed[index] = false
+	version, versions, err := healVersion(rs3s, object, listed)
+	if err != nil {
+		return err
+	}
+
+	dst := rs3s.clnts[index]
+	if version == "" {
+		err = dst.RemoveObject(dst.Bucket, dst.remoteKey(object))
+		return ErrorRespToObjectError(err, bucket, object)
+	}
+	info, err := dst.StatObject(dst.Bucket, dst.remoteKey(object), miniogo.StatObjectOptions{})
+	if err == nil && objectVersion(info) == version {
+		return nil
+	}
+	for srcIndex := range versions {
+		if versions[srcIndex] == version {
+			return copyRemoteObject(rs3s.clnts[srcIndex], dst, object, version)
+		}
+	}
+	return nil
+}
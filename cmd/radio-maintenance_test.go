@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio/pkg/hash"
+)
+
+func TestActiveRemotes(t *testing.T) {
+	var rs3s mirrorConfig
+	for i := 0; i < 3; i++ {
+		rs3s.clnts = append(rs3s.clnts, bucketClient{state: &remoteState{pending: make(map[string]struct{})}})
+	}
+	rs3s.clnts[0].state.maintenance.Store(true)
+
+	active, n := rs3s.activeRemotes()
+	if expected := []bool{false, true, true}; n != 2 || !reflect.DeepEqual(active, expected) {
+		t.Fatalf("expected %v, 2, got %v, %d", expected, active, n)
+	}
+
+	// A remote leaving a multipart upload queues the object.
+	uploadIDs := []string{"a", "b", ""}
+	active, n = rs3s.uploadRemotes("object", uploadIDs)
+	if expected := []bool{false, true, false}; n != 1 || !reflect.DeepEqual(active, expected) {
+		t.Fatalf("expected %v, 1, got %v, %d", expected, active, n)
+	}
+	if uploadIDs[0] != "" {
+		t.Fatalf("expected the upload to be left, got id %q", uploadIDs[0])
+	}
+	if pending := rs3s.clnts[0].pendingWrites(); !reflect.DeepEqual(pending, []string{"object"}) {
+		t.Fatalf("expected object to be pending, got %v", pending)
+	}
+	if pending := rs3s.clnts[0].pendingWrites(); len(pending) != 0 {
+		t.Fatalf("expected pending writes to be forgotten, got %v", pending)
+	}
+}
+
+func TestQuorumInfo(t *testing.T) {
+	info := func(tag string) miniogo.ObjectInfo {
+		return miniogo.ObjectInfo{Metadata: http.Header{"X-Amz-Meta-Radio-Tag": []string{tag}}}
+	}
+	testCases := []struct {
+		infos  []miniogo.ObjectInfo
+		active []bool
+		tag    string
+		index  int
+	}{
+		{[]miniogo.ObjectInfo{info("a"), info("a")}, []bool{true, true}, "a", 0},
+		{[]miniogo.ObjectInfo{info("a"), info("a"), info("b")}, []bool{true, true, true}, "a", 0},
+		{[]miniogo.ObjectInfo{info("b"), info("a"), info("a")}, []bool{true, true, true}, "a", 1},
+		{[]miniogo.ObjectInfo{info("a"), info("b")}, []bool{true, true}, "", -1},
+		// Remotes in maintenance do not count.
+		{[]miniogo.ObjectInfo{info("b"), info("a")}, []bool{false, true}, "a", 1},
+		{[]miniogo.ObjectInfo{info("b"), info("b"), info("a")}, []bool{false, true, true}, "", -1},
+	}
+	for i, testCase := range testCases {
+		oi, index, err := quorumInfo(testCase.infos, testCase.active)
+		if testCase.tag == "" {
+			if err == nil {
+				t.Errorf("Test %d: expected no quorum, got %d", i+1, index)
+			}
+			continue
+		}
+		if err != nil || index != testCase.index || oi.Metadata.Get("x-amz-meta-radio-tag") != testCase.tag {
+			t.Errorf("Test %d: expected %s at %d, got %d, %v", i+1, testCase.tag, testCase.index, index, err)
+		}
+	}
+}
+
+// maintenanceRemote - stores objects like an S3 remote, onPut is called
+// before a PUT is answered.
+type maintenanceRemote struct {
+	onPut func()
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	tags    map[string]string
+}
+
+func (m *maintenanceRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		m.objects[r.URL.Path] = data
+		m.tags[r.URL.Path] = r.Header.Get("X-Amz-Meta-Radio-Tag")
+		if m.onPut != nil {
+			m.onPut()
+		}
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodHead, http.MethodGet:
+		data, ok := m.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Amz-Meta-Radio-Tag", m.tags[r.URL.Path])
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(m.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (m *maintenanceRemote) tag(path string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tags[path]
+}
+
+// Tests that a PUT skipping a remote whose maintenance ended meanwhile
+// is healed on it.
+func TestPutObjectMaintenanceEnded(t *testing.T) {
+	var rs3s mirrorConfig
+	remotes := []*maintenanceRemote{{}, {}}
+	for _, remote := range remotes {
+		remote.objects, remote.tags = make(map[string][]byte), make(map[string]string)
+		server := httptest.NewServer(remote)
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clnt, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+			Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+			Region:       "us-east-1",
+			BucketLookup: miniogo.BucketLookupPath,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs3s.clnts = append(rs3s.clnts, bucketClient{Core: &miniogo.Core{Client: clnt}, Bucket: "bucket",
+			state: &remoteState{pending: make(map[string]struct{})}})
+	}
+	// The maintenance of the first remote ends while the object is
+	// uploaded to the second.
+	rs3s.clnts[0].state.maintenance.Store(true)
+	remotes[1].onPut = func() { rs3s.clnts[0].state.maintenance.Store(false) }
+
+	l := &radioObjects{
+		mirrorClients: map[string]mirrorConfig{"bucket": rs3s},
+		nsMutex:       newNSLock(false),
+	}
+	data := []byte("maintenance")
+	hr, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", "", int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.PutObject(context.Background(), "bucket", "object", NewPutObjReader(hr, nil, nil),
+		ObjectOptions{UserDefined: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := remotes[1].tag("/bucket/object")
+	if expected == "" {
+		t.Fatal("expected the object to be uploaded to the second remote")
+	}
+	for i := 0; remotes[0].tag("/bucket/object") != expected; i++ {
+		if i == 50 {
+			t.Fatalf("expected the object to be healed on the first remote with tag %q", expected)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+// metricsConfig - access to the Prometheus metrics, independent of the
+// S3 and admin credentials.
+type metricsConfig struct {
+	// AuthToken is required as Authorization: Bearer TOKEN from
+	// scrapers, metrics are served without a token if empty.
+	AuthToken string `yaml:"auth_token"`
+	// AllowedSources lists the addresses or CIDRs allowed to scrape
+	// the metrics, all addresses are allowed if empty.
+	AllowedSources []string `yaml:"allowed_sources"`
+}
+
+// validateMetricsConfig - validates the metrics config.
+func validateMetricsConfig(errs *radioConfigErrors, path string, c metricsConfig) {
+	if _, err := parseIPNets(c.AllowedSources); err != nil {
+		errs.add(path+".allowed_sources", "%v", err)
+	}
+}
+
+// metricsSourceIP - returns the client address of r matched against the
+// allowed sources. Forwarding headers are only honored from trusted
+// proxies, never from all clients as by getSourceIP.
+func metricsSourceIP(r *http.Request) net.IP {
+	if len(globalTrustedProxies) > 0 {
+		return net.ParseIP(getSourceIP(r))
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// metricsAuthHandler - serves h to the scrapers of the allowed sources
+// presenting the auth token of c.
+func metricsAuthHandler(c metricsConfig, h http.Handler) (http.Handler, error) {
+	sources, err := parseIPNets(c.AllowedSources)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 && c.AuthToken == "" {
+		return h, nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(sources) > 0 {
+			ip := metricsSourceIP(r)
+			allowed := false
+			for _, ipNet := range sources {
+				if ip != nil && ipNet.Contains(ip) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				writeResponse(w, http.StatusForbidden, nil, mimeNone)
+				return
+			}
+		}
+		if c.AuthToken != "" {
+			token := r.Header.Get(xhttp.Authorization)
+			if !strings.HasPrefix(token, jwtAlgorithm+" ") ||
+				subtle.ConstantTimeCompare([]byte(token[len(jwtAlgorithm)+1:]), []byte(c.AuthToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="radio"`)
+				writeResponse(w, http.StatusUnauthorized, nil, mimeNone)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
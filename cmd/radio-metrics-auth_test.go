@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+func TestMetricsAuthHandler(t *testing.T) {
+	defer func(proxies []*net.IPNet) { globalTrustedProxies = proxies }(globalTrustedProxies)
+	globalTrustedProxies = nil
+
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler, err := metricsAuthHandler(metricsConfig{
+		AuthToken:      "scrape-token",
+		AllowedSources: []string{"10.0.0.0/8", "192.0.2.10"},
+	}, metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(remoteAddr, auth, forwardedFor string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, minioReservedBucketPath+prometheusMetricsPath, nil)
+		r.RemoteAddr = remoteAddr
+		if auth != "" {
+			r.Header.Set(xhttp.Authorization, auth)
+		}
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return r
+	}
+	testCases := []struct {
+		r      *http.Request
+		status int
+	}{
+		{newRequest("10.1.2.3:4711", "Bearer scrape-token", ""), http.StatusOK},
+		{newRequest("192.0.2.10:4711", "Bearer scrape-token", ""), http.StatusOK},
+		{newRequest("192.0.2.11:4711", "Bearer scrape-token", ""), http.StatusForbidden},
+		{newRequest("10.1.2.3:4711", "Bearer other-token", ""), http.StatusUnauthorized},
+		{newRequest("10.1.2.3:4711", "scrape-token", ""), http.StatusUnauthorized},
+		{newRequest("10.1.2.3:4711", "", ""), http.StatusUnauthorized},
+		// Forwarding headers are not honored without trusted proxies.
+		{newRequest("192.0.2.11:4711", "Bearer scrape-token", "10.1.2.3"), http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, testCase.r)
+		if w.Code != testCase.status {
+			t.Errorf("Test %d: expected status %d, got %d", i+1, testCase.status, w.Code)
+		}
+	}
+
+	// Behind a trusted proxy the forwarded client is matched.
+	if globalTrustedProxies, err = parseIPNets([]string{"192.0.2.11"}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest("192.0.2.11:4711", "Bearer scrape-token", "10.1.2.3"))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the forwarded client to be allowed, got %d", w.Code)
+	}
+
+	if _, err = metricsAuthHandler(metricsConfig{AllowedSources: []string{"10.0.0.0/33"}}, metrics); err == nil {
+		t.Error("expected an invalid source to fail")
+	}
+}
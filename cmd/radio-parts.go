@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+)
+
+// journaledPart - a part of a multipart upload as uploaded through radio.
+// ETags holds the ETag of the part on every remote, empty on the remotes
+// the part did not reach.
+type journaledPart struct {
+	Size  int64
+	ETag  string
+	ETags []string
+}
+
+// partJournal - the parts of the multipart uploads in progress by upload
+// ID and part number, against which completions are verified before they
+// are sent to the remotes.
+type partJournal struct {
+	mu      sync.Mutex
+	uploads map[string]map[int]journaledPart
+}
+
+func newPartJournal() *partJournal {
+	return &partJournal{uploads: make(map[string]map[int]journaledPart)}
+}
+
+// record - records part partID of uploadID, replacing a previous upload
+// of the part.
+func (j *partJournal) record(uploadID string, partID int, part journaledPart) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	parts, ok := j.uploads[uploadID]
+	if !ok {
+		parts = make(map[int]journaledPart)
+		j.uploads[uploadID] = parts
+	}
+	parts[partID] = part
+}
+
+// parts - returns the parts recorded for uploadID.
+func (j *partJournal) parts(uploadID string) map[int]journaledPart {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	parts := make(map[int]journaledPart, len(j.uploads[uploadID]))
+	for partID, part := range j.uploads[uploadID] {
+		parts[partID] = part
+	}
+	return parts
+}
+
+// forget - drops the parts of uploadID once it was completed or aborted.
+func (j *partJournal) forget(uploadID string) {
+	j.mu.Lock()
+	delete(j.uploads, uploadID)
+	j.mu.Unlock()
+}
+
+// InvalidPartOrder - the parts of a completion are not in ascending order
+// of part numbers.
+type InvalidPartOrder struct {
+	PartNumber int
+}
+
+func (e InvalidPartOrder) Error() string {
+	return fmt.Sprintf("Part list is not in ascending order at part %d", e.PartNumber)
+}
+
+// verifyCompleteParts - verifies the parts a client completes an upload
+// with against the journal, like S3: part numbers must ascend, every part
+// must have been uploaded with the ETag given and all parts but the last
+// must be at least globalMinPartSize.
+func verifyCompleteParts(parts []CompletePart, journal map[int]journaledPart) error {
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return InvalidPartOrder{PartNumber: part.PartNumber}
+		}
+		jpart, ok := journal[part.PartNumber]
+		if !ok || canonicalizeETag(jpart.ETag) != canonicalizeETag(part.ETag) {
+			return InvalidPart{PartNumber: part.PartNumber, ExpETag: jpart.ETag, GotETag: part.ETag}
+		}
+		if i < len(parts)-1 && jpart.Size < globalMinPartSize {
+			return PartTooSmall{PartSize: jpart.Size, PartNumber: part.PartNumber, PartETag: part.ETag}
+		}
+	}
+	return nil
+}
+
+// remoteCompleteParts - returns the parts completing the upload on the
+// remote index, with the ETags the remote returned for them. Returns
+// false if one of the parts did not reach the remote.
+func remoteCompleteParts(parts []CompletePart, journal map[int]journaledPart, index int) ([]CompletePart, bool) {
+	rparts := make([]CompletePart, len(parts))
+	for i, part := range parts {
+		jpart := journal[part.PartNumber]
+		if index >= len(jpart.ETags) || jpart.ETags[index] == "" {
+			return nil, false
+		}
+		rparts[i] = CompletePart{PartNumber: part.PartNumber, ETag: jpart.ETags[index]}
+	}
+	return rparts, true
+}
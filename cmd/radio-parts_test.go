@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyCompleteParts(t *testing.T) {
+	j := newPartJournal()
+	j.record("upload", 1, journaledPart{Size: globalMinPartSize, ETag: "a", ETags: []string{"a", "a"}})
+	j.record("upload", 2, journaledPart{Size: 1, ETag: "b", ETags: []string{"b", "b"}})
+	j.record("upload", 3, journaledPart{Size: 1, ETag: "c", ETags: []string{"c", ""}})
+	// Parts uploaded again replace the previous upload.
+	j.record("upload", 2, journaledPart{Size: globalMinPartSize, ETag: "b2", ETags: []string{"b2", "x2"}})
+	journal := j.parts("upload")
+
+	testCases := []struct {
+		parts    []CompletePart
+		expected error
+	}{
+		{[]CompletePart{{1, "a"}, {2, `"b2"`}, {3, "c"}}, nil},
+		// Parts may be skipped.
+		{[]CompletePart{{1, "a"}, {3, "c"}}, nil},
+		{[]CompletePart{{2, "b2"}, {1, "a"}}, InvalidPartOrder{PartNumber: 1}},
+		{[]CompletePart{{1, "a"}, {1, "a"}}, InvalidPartOrder{PartNumber: 1}},
+		{[]CompletePart{{1, "a"}, {2, "b"}}, InvalidPart{PartNumber: 2, ExpETag: "b2", GotETag: "b"}},
+		{[]CompletePart{{1, "a"}, {4, "d"}}, InvalidPart{PartNumber: 4, GotETag: "d"}},
+	}
+	for i, testCase := range testCases {
+		if err := verifyCompleteParts(testCase.parts, journal); err != testCase.expected {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.expected, err)
+		}
+	}
+
+	j.record("upload", 4, journaledPart{Size: 1, ETag: "d", ETags: []string{"d", "d"}})
+	err := verifyCompleteParts([]CompletePart{{3, "c"}, {4, "d"}}, j.parts("upload"))
+	if _, ok := err.(PartTooSmall); !ok {
+		t.Errorf("expected PartTooSmall, got %v", err)
+	}
+
+	// Remotes complete with their own ETags.
+	parts := []CompletePart{{1, "a"}, {2, "b2"}}
+	if rparts, ok := remoteCompleteParts(parts, journal, 1); !ok ||
+		!reflect.DeepEqual(rparts, []CompletePart{{1, "a"}, {2, "x2"}}) {
+		t.Errorf("expected the ETags of the remote, got %v", rparts)
+	}
+	if _, ok := remoteCompleteParts(append(parts, CompletePart{3, "c"}), journal, 1); ok {
+		t.Error("expected a part missing on the remote")
+	}
+
+	j.forget("upload")
+	if parts := j.parts("upload"); len(parts) != 0 {
+		t.Errorf("expected the parts to be forgotten, got %v", parts)
+	}
+}
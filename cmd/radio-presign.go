@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+const (
+	defaultPresignExpiry = time.Hour
+	// Longest expiry of presigned URLs accepted by S3.
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// PresignOpts - options of a presigned URL.
+type PresignOpts struct {
+	Method string
+	Bucket string
+	Object string
+	// AccessKey of the front-end credential signing the URL, may be
+	// empty if radio has a single credential.
+	AccessKey string
+	Expiry    time.Duration
+	// Overrides holds response-* parameters overriding the headers of
+	// the response to GET and HEAD requests.
+	Overrides url.Values
+}
+
+// PresignResult - a presigned URL.
+type PresignResult struct {
+	URL     string    `json:"url"`
+	Method  string    `json:"method"`
+	Expires time.Time `json:"expires"`
+}
+
+// presignCredential - returns the front-end credential with accessKey,
+// or the only front-end credential if accessKey is empty.
+func presignCredential(accessKey string) (auth.Credentials, error) {
+	globalLocalCredsMu.RLock()
+	defer globalLocalCredsMu.RUnlock()
+	if accessKey == "" {
+		if len(globalLocalCreds) != 1 {
+			return auth.Credentials{}, errors.New("access-key is required with more than one credential")
+		}
+		for _, cred := range globalLocalCreds {
+			return cred, nil
+		}
+	}
+	cred, ok := globalLocalCreds[accessKey]
+	if !ok {
+		return auth.Credentials{}, fmt.Errorf("access key %q is not a front-end credential", accessKey)
+	}
+	return cred, nil
+}
+
+// validatePresignOpts - validates opts and sets their defaults.
+func validatePresignOpts(opts *PresignOpts) error {
+	opts.Method = strings.ToUpper(opts.Method)
+	switch opts.Method {
+	case "":
+		opts.Method = http.MethodGet
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return fmt.Errorf("method %s can't be presigned", opts.Method)
+	}
+	if opts.Bucket == "" || opts.Object == "" {
+		return errors.New("bucket and object are required")
+	}
+	switch {
+	case opts.Expiry == 0:
+		opts.Expiry = defaultPresignExpiry
+	case opts.Expiry < time.Second || opts.Expiry > maxPresignExpiry:
+		return fmt.Errorf("expiry must be between 1s and %s", maxPresignExpiry)
+	}
+	for k := range opts.Overrides {
+		if _, ok := supportedHeadGetReqParams[k]; !ok {
+			return fmt.Errorf("%s is not a response header override", k)
+		}
+		if opts.Method != http.MethodGet && opts.Method != http.MethodHead {
+			return fmt.Errorf("%s only applies to GET and HEAD", k)
+		}
+	}
+	return nil
+}
+
+// presignV4 - returns u presigned for method with cred, valid for expiry
+// from t, as verified by doesPresignedSignatureMatch. Only the host
+// header is signed, the payload of PUT requests is not.
+func presignV4(method string, u url.URL, cred auth.Credentials, region string, t time.Time, expiry time.Duration) string {
+	t = t.UTC()
+	scope := getScope(t, region)
+	query := u.Query()
+	query.Set(xhttp.AmzAlgorithm, signV4Algorithm)
+	query.Set(xhttp.AmzCredential, cred.AccessKey+SlashSeparator+scope)
+	query.Set(xhttp.AmzDate, t.Format(iso8601Format))
+	query.Set(xhttp.AmzExpires, strconv.Itoa(int(expiry/time.Second)))
+	query.Set(xhttp.AmzSignedHeaders, "host")
+
+	signedHeaders := http.Header{"host": []string{u.Host}}
+	canonicalRequest := getCanonicalRequest(signedHeaders, unsignedPayload, query.Encode(), u.Path, method)
+	signingKey := getSigningKey(cred.SecretKey, t, region, serviceS3)
+	query.Set(xhttp.AmzSignature, getSignature(signingKey, getStringToSign(canonicalRequest, t, scope)))
+
+	// The session token is not part of the signature.
+	if cred.SessionToken != "" {
+		query.Set(xhttp.AmzSecurityToken, cred.SessionToken)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Presign - returns a URL of radio at endpoint, such as
+// https://radio.domain.com:9000, presigned with opts.
+func (l *radioObjects) Presign(endpoint *url.URL, opts PresignOpts) (PresignResult, error) {
+	if err := validatePresignOpts(&opts); err != nil {
+		return PresignResult{}, err
+	}
+	if _, ok := l.mirrorClients[opts.Bucket]; !ok {
+		if _, ok = l.erasureClients[opts.Bucket]; !ok {
+			return PresignResult{}, BucketNotFound{Bucket: opts.Bucket}
+		}
+	}
+	cred, err := presignCredential(opts.AccessKey)
+	if err != nil {
+		return PresignResult{}, err
+	}
+
+	u := url.URL{
+		Scheme:   endpoint.Scheme,
+		Host:     endpoint.Host,
+		Path:     SlashSeparator + opts.Bucket + SlashSeparator + opts.Object,
+		RawQuery: opts.Overrides.Encode(),
+	}
+	region := globalServerRegion
+	if region == "" {
+		region = globalRadioDefaultRegion
+	}
+	now := UTCNow()
+	return PresignResult{
+		URL:     presignV4(opts.Method, u, cred, region, now, opts.Expiry),
+		Method:  opts.Method,
+		Expires: now.Add(opts.Expiry),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+)
+
+// Tests that presigned URLs are accepted by radio until they expire.
+func TestPresignV4(t *testing.T) {
+	creds := globalLocalCreds
+	defer func() { globalLocalCreds = creds }()
+	cred, err := auth.CreateCredentials("radioaccess", "radiosecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalLocalCreds = map[string]auth.Credentials{cred.AccessKey: cred}
+
+	u := url.URL{Scheme: "http", Host: "radio.domain.com:9000", Path: "/bucket/photos/a b+c.jpg",
+		RawQuery: url.Values{"response-content-disposition": {"attachment; filename=a.jpg"}}.Encode()}
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		for _, testCase := range []struct {
+			signed time.Time
+			err    APIErrorCode
+		}{
+			{UTCNow(), ErrNone},
+			{UTCNow().Add(-2 * time.Hour), ErrExpiredPresignRequest},
+		} {
+			r := httptest.NewRequest(method, presignV4(method, u, cred, globalRadioDefaultRegion, testCase.signed, time.Hour), nil)
+			if s3Err := doesPresignedSignatureMatch(unsignedPayload, r, globalRadioDefaultRegion, serviceS3); s3Err != testCase.err {
+				t.Errorf("%s: expected %v, got %v", method, testCase.err, s3Err)
+			}
+		}
+	}
+}
+
+func TestValidatePresignOpts(t *testing.T) {
+	testCases := []struct {
+		opts  PresignOpts
+		valid bool
+	}{
+		{PresignOpts{Bucket: "bucket", Object: "a.jpg"}, true},
+		{PresignOpts{Method: "put", Bucket: "bucket", Object: "a.jpg", Expiry: 24 * time.Hour}, true},
+		{PresignOpts{Bucket: "bucket", Object: "a.jpg", Overrides: url.Values{"response-content-type": {"image/jpeg"}}}, true},
+		{PresignOpts{Method: http.MethodPost, Bucket: "bucket", Object: "a.jpg"}, false},
+		{PresignOpts{Bucket: "bucket"}, false},
+		{PresignOpts{Bucket: "bucket", Object: "a.jpg", Expiry: 8 * 24 * time.Hour}, false},
+		{PresignOpts{Bucket: "bucket", Object: "a.jpg", Overrides: url.Values{"x-amz-acl": {"public-read"}}}, false},
+		{PresignOpts{Method: http.MethodPut, Bucket: "bucket", Object: "a.jpg", Overrides: url.Values{"response-content-type": {"image/jpeg"}}}, false},
+	}
+	for i, testCase := range testCases {
+		opts := testCase.opts
+		if err := validatePresignOpts(&opts); (err == nil) != testCase.valid {
+			t.Errorf("Test %d: expected valid %t, got %v", i+1, testCase.valid, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultPullInterval = time.Hour
+	minPullInterval     = time.Minute
+
+	// Scheduled pulls copy the objects last modified since the start of
+	// the last completed pull, less this margin for the clock of the
+	// source.
+	pullClockSkew = 5 * time.Minute
+
+	// pullETagMetaKey - the ETag of the source object an object was
+	// pulled from, multipart ETags differ from the ETag of the copy.
+	pullETagMetaKey = "X-Amz-Meta-Radio-Pull-Etag"
+)
+
+// Servers which find a source being pulled by another server give up
+// after this time.
+var pullLockTimeout = newDynamicTimeout(10*time.Second, 10*time.Second)
+
+// pullConfig - copies the new or changed objects of Source, an S3 bucket
+// not served by radio, below Prefix to the mirror bucket every Interval,
+// 1h by default, Workers of them in parallel.
+type pullConfig struct {
+	Source   bucketConfig  `yaml:"source"`
+	Prefix   string        `yaml:"prefix"`
+	Interval time.Duration `yaml:"interval"`
+	Workers  int           `yaml:"workers"`
+}
+
+func (c pullConfig) interval() time.Duration {
+	if c.Interval == 0 {
+		return defaultPullInterval
+	}
+	return c.Interval
+}
+
+// validatePullConfig - validates a pull source of a mirror bucket with
+// the remotes.
+func validatePullConfig(errs *radioConfigErrors, path string, c pullConfig, remotes []bucketConfig) {
+	validateBucketConfig(errs, path+".source", c.Source, true)
+	if c.Source.Shadow {
+		errs.add(path+".source.shadow", "only remotes of mirror buckets can be shadows")
+	}
+	if c.Source.Region != "" || len(c.Source.Networks) > 0 {
+		errs.add(path+".source.region", "only remotes of mirror buckets can be located")
+	}
+	for _, rcfg := range remotes {
+		if rcfg.Endpoint == c.Source.Endpoint && rcfg.Bucket == c.Source.Bucket {
+			errs.add(path+".source", "%s at %s is a remote of the bucket", c.Source.Bucket, c.Source.Endpoint)
+		}
+	}
+	if c.Interval != 0 && c.Interval < minPullInterval {
+		errs.add(path+".interval", "must be at least %s", minPullInterval)
+	}
+	if c.Workers < 0 || c.Workers > batchJobMaxWorkers {
+		errs.add(path+".workers", "must be between 0 (default) and %d", batchJobMaxWorkers)
+	}
+}
+
+// pullSource - a pull source of a mirror bucket.
+type pullSource struct {
+	config pullConfig
+	clnt   bucketClient
+
+	mu sync.Mutex
+	// since is the time objects were last modified after which the
+	// next scheduled pull copies, zero until a pull completed.
+	since time.Time
+}
+
+// remote - identifies the source in batch jobs.
+func (p *pullSource) remote() BatchJobRemote {
+	return BatchJobRemote{Endpoint: p.clnt.EndpointURL().String(), Bucket: p.clnt.Bucket}
+}
+
+// pullSource - returns the pull source r of the bucket.
+func (rs3s mirrorConfig) pullSource(r BatchJobRemote) (*pullSource, error) {
+	for _, p := range rs3s.pulls {
+		if p.remote() == r {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no pull source %s at %s", r.Bucket, r.Endpoint)
+}
+
+// batchPull - copies the object info listed on src to the bucket of the
+// job, unless the bucket holds it in the same version.
+func (l *radioObjects) batchPull(ctx context.Context, spec BatchJobSpec, src bucketClient, info miniogo.ObjectInfo) (bool, error) {
+	etag := canonicalizeETag(info.ETag)
+	dstInfo, err := l.GetObjectInfo(ctx, spec.Bucket, info.Key, ObjectOptions{})
+	if err == nil && (dstInfo.UserDefined[pullETagMetaKey] == etag || dstInfo.ETag == etag && dstInfo.Size == info.Size) {
+		return false, nil
+	}
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); !ok {
+			return false, err
+		}
+	}
+	if spec.DryRun {
+		return true, nil
+	}
+
+	reader, srcInfo, _, err := src.GetObject(src.Bucket, src.remoteKey(info.Key), miniogo.GetObjectOptions{})
+	if err != nil {
+		err = ErrorRespToObjectError(err, src.Bucket, info.Key)
+		if _, ok := err.(ObjectNotFound); ok {
+			// removed since it was listed
+			return false, nil
+		}
+		return false, err
+	}
+	defer reader.Close()
+	hashReader, err := hash.NewReader(reader, srcInfo.Size, "", "", srcInfo.Size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		return false, err
+	}
+	metadata := healMetadata(srcInfo.Metadata)
+	metadata[pullETagMetaKey] = canonicalizeETag(srcInfo.ETag)
+	if _, err = l.PutObject(ctx, spec.Bucket, info.Key, NewPutObjReader(hashReader, nil, nil),
+		ObjectOptions{UserDefined: metadata}); err != nil {
+		return false, err
+	}
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		cacheAPI.Invalidate(ctx, spec.Bucket, info.Key)
+	}
+	return true, nil
+}
+
+// runPull - pulls p into bucket every interval of p.
+func (l *radioObjects) runPull(bucket string, p *pullSource) {
+	interval := p.config.interval()
+	for {
+		next := UTCNow().Truncate(interval).Add(interval)
+		time.Sleep(time.Until(next))
+		l.scheduledPull(newBackgroundContext("Pull", bucket, ""), bucket, p)
+	}
+}
+
+// scheduledPull - pulls p into bucket as a batch job and waits for it,
+// unless another server is pulling p or writes to bucket are rejected.
+func (l *radioObjects) scheduledPull(ctx context.Context, bucket string, p *pullSource) {
+	lock := l.NewNSLock(ctx, minioMetaBucket, pathJoin("pull", bucket, p.clnt.EndpointURL().Host, p.clnt.Bucket))
+	if err := lock.GetLock(pullLockTimeout); err != nil {
+		// Being pulled by another server.
+		return
+	}
+	defer lock.Unlock()
+	if globalReadOnly.Load() || globalWriteFences.fenced(bucket, UTCNow()) {
+		return
+	}
+
+	spec := BatchJobSpec{
+		Type:    batchJobPull,
+		Bucket:  bucket,
+		Prefix:  p.config.Prefix,
+		Workers: p.config.Workers,
+		Pull:    &BatchPullSpec{Source: p.remote()},
+	}
+	if spec.Workers == 0 {
+		spec.Workers = batchJobDefaultWorkers
+	}
+	p.mu.Lock()
+	if !p.since.IsZero() {
+		spec.Pull.ModifiedAfter = p.since.Format(time.RFC3339)
+	}
+	p.mu.Unlock()
+
+	status, err := l.startBatchJob(spec, "")
+	if err != nil {
+		logger.LogIf(ctx, fmt.Errorf("pull of %s into %s: %v", p.clnt.Bucket, bucket, err))
+		return
+	}
+	job, ok := l.batchJobs.get(status.ID)
+	if !ok {
+		return
+	}
+	<-job.done
+	if status = job.getStatus(); status.State == batchJobCompleted {
+		p.mu.Lock()
+		p.since = status.Started.Add(-pullClockSkew)
+		p.mu.Unlock()
+	}
+}
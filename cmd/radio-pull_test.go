@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidatePullConfig(t *testing.T) {
+	remotes := []bucketConfig{{Endpoint: "https://storage.example.com", Bucket: "datasets", AccessKey: "a", SecretKey: "s"}}
+	source := bucketConfig{Endpoint: "https://s3.amazonaws.com", Bucket: "producer", AccessKey: "a", SecretKey: "s"}
+	testCases := []struct {
+		c    pullConfig
+		errs int
+	}{
+		{pullConfig{Source: source}, 0},
+		{pullConfig{Source: source, Prefix: "daily/", Interval: 15 * time.Minute, Workers: 8}, 0},
+		{pullConfig{Source: source, Interval: time.Second}, 1},
+		{pullConfig{Source: source, Workers: 1000}, 1},
+		// A remote of the bucket.
+		{pullConfig{Source: remotes[0]}, 1},
+		{pullConfig{Source: bucketConfig{Endpoint: source.Endpoint, Bucket: source.Bucket}}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validatePullConfig(&errs, "mirror[0].pull[0]", testCase.c, remotes)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
+
+	sha256 "github.com/minio/sha256-simd"
+)
+
+// inflightPut - an upload in flight which identical uploads wait for.
+type inflightPut struct {
+	done    chan struct{}
+	objInfo ObjectInfo
+	err     error
+}
+
+// putDedup - coalesces identical concurrent uploads, the body of the
+// first is sent to the remotes and the others get its result.
+type putDedup struct {
+	mu      sync.Mutex
+	uploads map[string]*inflightPut
+}
+
+var globalPutDedup = &putDedup{uploads: make(map[string]*inflightPut)}
+
+// dedupPutKey - returns the key of an upload of size bytes to
+// bucket/object with metadata, identical uploads share it. Returns ""
+// for uploads whose content is not known ahead, sent without Content-MD5
+// nor a signed payload hash.
+func dedupPutKey(bucket, object string, size int64, md5hex, sha256hex string, metadata map[string]string) string {
+	if md5hex == "" && sha256hex == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		io.WriteString(h, k+"\x00"+metadata[k]+"\x00")
+	}
+	return pathJoin(bucket, object) + "\x00" + strconv.FormatInt(size, 10) + "\x00" +
+		md5hex + "\x00" + sha256hex + "\x00" + hex.EncodeToString(h.Sum(nil))
+}
+
+// do - uploads with put unless an upload of key is in flight. The caller
+// then waits for it before reading its body r: once the upload in flight
+// succeeded, r is only read to verify its digests and its result is
+// returned, if it failed, for reasons which may only apply to its own
+// request such as a client gone, the caller uploads r itself, coalesced
+// with the other callers waiting. Uploads with an empty key are never
+// coalesced.
+func (d *putDedup) do(ctx context.Context, bucket, key string, size int64, r io.Reader, put func() (ObjectInfo, error)) (ObjectInfo, error) {
+	if key == "" {
+		return put()
+	}
+	d.mu.Lock()
+	u, ok := d.uploads[key]
+	if !ok {
+		u = &inflightPut{done: make(chan struct{})}
+		d.uploads[key] = u
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		u.objInfo, u.err = put()
+		d.mu.Lock()
+		delete(d.uploads, key)
+		d.mu.Unlock()
+		close(u.done)
+		return u.objInfo, u.err
+	}
+
+	select {
+	case <-u.done:
+	case <-ctx.Done():
+		return ObjectInfo{}, ctx.Err()
+	}
+	if u.err != nil {
+		return d.do(ctx, bucket, key, size, r, put)
+	}
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		putCoalesced.WithLabelValues(bucket, "error").Inc()
+		return ObjectInfo{}, err
+	}
+	putCoalesced.WithLabelValues(bucket, "success").Inc()
+	putCoalescedBytes.WithLabelValues(bucket).Add(float64(size))
+	return u.objInfo, nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/hash"
+)
+
+func TestDedupPutKey(t *testing.T) {
+	md5hex := "5d41402abc4b2a76b9719d911017c592"
+	key := dedupPutKey("bucket", "object", 5, md5hex, "", map[string]string{"content-type": "text/plain"})
+	if key == "" {
+		t.Fatal("Expected uploads with Content-MD5 to be coalesced")
+	}
+	for i, other := range []string{
+		dedupPutKey("bucket", "other", 5, md5hex, "", map[string]string{"content-type": "text/plain"}),
+		dedupPutKey("bucket", "object", 6, md5hex, "", map[string]string{"content-type": "text/plain"}),
+		dedupPutKey("bucket", "object", 5, md5hex, "", map[string]string{"content-type": "text/html"}),
+		dedupPutKey("bucket", "object", 5, md5hex, "", map[string]string{"content-type": "text/plain", "x-amz-meta-a": "b"}),
+	} {
+		if other == key {
+			t.Errorf("Test %d: expected a different key", i+1)
+		}
+	}
+	if key := dedupPutKey("bucket", "object", 5, "", "", nil); key != "" {
+		t.Errorf("Expected uploads of unknown content not to be coalesced, got %q", key)
+	}
+}
+
+// Tests that identical concurrent uploads are sent once and that bodies
+// not matching their digest are rejected.
+func TestPutDedup(t *testing.T) {
+	d := &putDedup{uploads: make(map[string]*inflightPut)}
+	data := []byte("hello")
+	md5hex := "5d41402abc4b2a76b9719d911017c592"
+	key := dedupPutKey("bucket", "object", 5, md5hex, "", nil)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	var puts int
+	put := func() (ObjectInfo, error) {
+		puts++
+		<-release
+		return ObjectInfo{ETag: md5hex}, nil
+	}
+	reader := func(body []byte) *hash.Reader {
+		r, err := hash.NewReader(bytes.NewReader(body), 5, md5hex, "", 5, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	var wg sync.WaitGroup
+	results := make([]error, 4)
+	etags := make([]string, 4)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		objInfo, err := d.do(ctx, "bucket", key, 5, reader(data), put)
+		etags[0], results[0] = objInfo.ETag, err
+	}()
+	for {
+		d.mu.Lock()
+		_, started := d.uploads[key]
+		d.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	bodies := [][]byte{data, data, data, []byte("world")}
+	for i := 1; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			objInfo, err := d.do(ctx, "bucket", key, 5, reader(bodies[i]), put)
+			etags[i], results[i] = objInfo.ETag, err
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	// A body not matching the digest fails.
+	if results[3] == nil {
+		t.Error("Expected a body not matching its Content-MD5 to fail")
+	}
+
+	if puts != 1 {
+		t.Errorf("Expected one upload sent, got %d", puts)
+	}
+	for i := 0; i < 3; i++ {
+		if results[i] != nil || etags[i] != md5hex {
+			t.Errorf("Upload %d: expected ETag %s, got %q, %v", i, md5hex, etags[i], results[i])
+		}
+	}
+	if len(d.uploads) != 0 {
+		t.Errorf("Expected no uploads left in flight, got %d", len(d.uploads))
+	}
+}
+
+// Tests that uploads waiting for an upload which failed, such as one
+// whose client is gone, are uploaded with their own body.
+func TestPutDedupLeaderCanceled(t *testing.T) {
+	d := &putDedup{uploads: make(map[string]*inflightPut)}
+	data := []byte("hello")
+	md5hex := "5d41402abc4b2a76b9719d911017c592"
+	key := dedupPutKey("bucket", "object", 5, md5hex, "", nil)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := d.do(leaderCtx, "bucket", key, 5, bytes.NewReader(nil), func() (ObjectInfo, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return ObjectInfo{}, leaderCtx.Err()
+		})
+		leaderErr <- err
+	}()
+	<-started
+
+	r, err := hash.NewReader(bytes.NewReader(data), 5, md5hex, "", 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	followerDone := make(chan struct{})
+	var (
+		objInfo   ObjectInfo
+		uploaded  []byte
+		followErr error
+	)
+	go func() {
+		defer close(followerDone)
+		objInfo, followErr = d.do(context.Background(), "bucket", key, 5, r, func() (ObjectInfo, error) {
+			body, err := ioutil.ReadAll(r)
+			uploaded = body
+			return ObjectInfo{ETag: md5hex}, err
+		})
+	}()
+	// Let the follower wait for the leader before its client goes.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; err != context.Canceled {
+		t.Errorf("Expected the leader to be canceled, got %v", err)
+	}
+	<-followerDone
+	if followErr != nil || objInfo.ETag != md5hex {
+		t.Fatalf("Expected the follower to succeed, got %q, %v", objInfo.ETag, followErr)
+	}
+	if !bytes.Equal(uploaded, data) {
+		t.Errorf("Expected the follower to upload its body, got %q", uploaded)
+	}
+	if len(d.uploads) != 0 {
+		t.Errorf("Expected no uploads left in flight, got %d", len(d.uploads))
+	}
+}
@@ -0,0 +1,452 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio/pkg/trace"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+	"github.com/minio/radio/cmd/logger/message/audit"
+)
+
+const (
+	defaultReplayConcurrency = 16
+	maxReplayLineSize        = 4 << 20
+)
+
+// replayAPIs - the S3 APIs replayed, others are skipped.
+var replayAPIs = map[string]bool{
+	"PutObject":     true,
+	"GetObject":     true,
+	"HeadObject":    true,
+	"DeleteObject":  true,
+	"ListObjectsV1": true,
+	"ListObjectsV2": true,
+}
+
+// replayOp - an S3 request of a captured log.
+type replayOp struct {
+	Time   time.Time
+	API    string
+	Bucket string
+	Object string
+	// Size of the object uploaded by PutObject.
+	Size int64
+	// Prefix and Delimiter of listings.
+	Prefix    string
+	Delimiter string
+}
+
+// key - returns the key the op is ordered by, ops of the same key are
+// replayed one after the other in the order of the log.
+func (op replayOp) key() string {
+	return op.Bucket + SlashSeparator + op.Object
+}
+
+// replaySize - returns the size of the payload of a request with header,
+// streaming signed uploads carry it in X-Amz-Decoded-Content-Length.
+func replaySize(header func(string) string) int64 {
+	size := header(xhttp.AmzDecodedContentLength)
+	if size == "" {
+		size = header(xhttp.ContentLength)
+	}
+	n, _ := strconv.ParseInt(size, 10, 64)
+	return n
+}
+
+// replayOpFromTrace - returns the op of a trace entry of radio admin
+// trace --json, false if it is no S3 request.
+func replayOpFromTrace(info trace.Info) (replayOp, bool) {
+	if !strings.HasPrefix(info.FuncName, "s3.") {
+		return replayOp{}, false
+	}
+	op := replayOp{
+		Time: info.ReqInfo.Time,
+		API:  strings.TrimPrefix(info.FuncName, "s3."),
+	}
+	op.Bucket, op.Object = urlPath2BucketObjectName(info.ReqInfo.Path)
+	op.Size = replaySize(info.ReqInfo.Headers.Get)
+	if query, err := url.ParseQuery(info.ReqInfo.RawQuery); err == nil {
+		op.Prefix, op.Delimiter = query.Get("prefix"), query.Get("delimiter")
+	}
+	return op, true
+}
+
+// replayOpFromAudit - returns the op of an audit log entry.
+func replayOpFromAudit(entry audit.Entry) (replayOp, error) {
+	t, err := time.Parse(time.RFC3339Nano, entry.Time)
+	if err != nil {
+		return replayOp{}, err
+	}
+	op := replayOp{
+		Time:      t,
+		API:       entry.API.Name,
+		Bucket:    entry.API.Bucket,
+		Object:    entry.API.Object,
+		Prefix:    entry.ReqQuery["prefix"],
+		Delimiter: entry.ReqQuery["delimiter"],
+	}
+	op.Size = replaySize(func(key string) string { return entry.ReqHeader[key] })
+	return op, nil
+}
+
+// parseReplayLog - reads the S3 requests of a log of trace entries, as
+// printed by radio admin trace --json, or of audit entries, one JSON
+// document per line. Returns the replayed ops in the order of the log
+// and the count of the skipped requests by API.
+func parseReplayLog(r io.Reader) ([]replayOp, map[string]int, error) {
+	var ops []replayOp
+	skipped := make(map[string]int)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxReplayLineSize)
+	for line := 1; s.Scan(); line++ {
+		if len(strings.TrimSpace(s.Text())) == 0 {
+			continue
+		}
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(s.Bytes(), &doc); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		var op replayOp
+		switch {
+		case doc["request"] != nil:
+			var info trace.Info
+			if err := json.Unmarshal(s.Bytes(), &info); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			var ok bool
+			if op, ok = replayOpFromTrace(info); !ok {
+				continue
+			}
+		case doc["api"] != nil:
+			var entry audit.Entry
+			err := json.Unmarshal(s.Bytes(), &entry)
+			if err == nil {
+				op, err = replayOpFromAudit(entry)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		default:
+			return nil, nil, fmt.Errorf("line %d: neither a trace nor an audit entry", line)
+		}
+		if !replayAPIs[op.API] || op.Bucket == "" {
+			skipped[op.API]++
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	return ops, skipped, nil
+}
+
+// syntheticReader - size bytes of content derived from seed, so that the
+// same key is always written with the same content.
+type syntheticReader struct {
+	block [sha256.Size]byte
+	off   int64
+	size  int64
+}
+
+func newSyntheticReader(seed string, size int64) *syntheticReader {
+	return &syntheticReader{block: sha256.Sum256([]byte(seed)), size: size}
+}
+
+func (r *syntheticReader) Read(p []byte) (int, error) {
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.size-r.off {
+		p = p[:r.size-r.off]
+	}
+	for i := range p {
+		p[i] = r.block[(r.off+int64(i))%int64(len(r.block))]
+	}
+	r.off += int64(len(p))
+	return len(p), nil
+}
+
+// ReplayAPIStats - the replayed requests of an API.
+type ReplayAPIStats struct {
+	Requests int            `json:"requests"`
+	Errors   map[string]int `json:"errors,omitempty"`
+	// Bytes uploaded or downloaded.
+	Bytes      int64         `json:"bytes"`
+	LatencyAvg time.Duration `json:"latencyAvg"`
+	LatencyP50 time.Duration `json:"latencyP50"`
+	LatencyP99 time.Duration `json:"latencyP99"`
+	LatencyMax time.Duration `json:"latencyMax"`
+
+	latencies []time.Duration
+}
+
+// ReplayReport - the result of a replay.
+type ReplayReport struct {
+	Duration time.Duration             `json:"duration"`
+	APIs     map[string]ReplayAPIStats `json:"apis"`
+	Skipped  map[string]int            `json:"skipped,omitempty"`
+}
+
+// replayer - replays ops against an S3 endpoint.
+type replayer struct {
+	clnt *miniogo.Core
+	// buckets maps the buckets of the log to those replayed to.
+	buckets map[string]string
+	// speed scales the pace of the log, 2 replays twice as fast, 0 as
+	// fast as possible.
+	speed       float64
+	concurrency int
+
+	mu    sync.Mutex
+	stats map[string]*ReplayAPIStats
+}
+
+// do - replays op, returns the bytes transferred.
+func (p *replayer) do(op replayOp) (int64, error) {
+	bucket := op.Bucket
+	if b, ok := p.buckets[bucket]; ok {
+		bucket = b
+	}
+	switch op.API {
+	case "PutObject":
+		_, err := p.clnt.PutObject(bucket, op.Object, newSyntheticReader(op.key(), op.Size), op.Size, "", "", nil, nil)
+		return op.Size, err
+	case "GetObject":
+		reader, _, _, err := p.clnt.GetObject(bucket, op.Object, miniogo.GetObjectOptions{})
+		if err != nil {
+			return 0, err
+		}
+		defer reader.Close()
+		return io.Copy(ioutil.Discard, reader)
+	case "HeadObject":
+		_, err := p.clnt.StatObject(bucket, op.Object, miniogo.StatObjectOptions{})
+		return 0, err
+	case "DeleteObject":
+		return 0, p.clnt.RemoveObject(bucket, op.Object)
+	case "ListObjectsV1":
+		_, err := p.clnt.ListObjects(bucket, op.Prefix, "", op.Delimiter, maxObjectList)
+		return 0, err
+	case "ListObjectsV2":
+		_, err := p.clnt.ListObjectsV2(bucket, op.Prefix, "", false, op.Delimiter, maxObjectList, "")
+		return 0, err
+	}
+	return 0, fmt.Errorf("unsupported api %s", op.API)
+}
+
+// record - counts a replayed request of api.
+func (p *replayer) record(api string, n int64, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats, ok := p.stats[api]
+	if !ok {
+		stats = &ReplayAPIStats{}
+		p.stats[api] = stats
+	}
+	stats.Requests++
+	stats.Bytes += n
+	stats.latencies = append(stats.latencies, latency)
+	if err != nil {
+		code := miniogo.ToErrorResponse(err).Code
+		if code == "" {
+			code = err.Error()
+		}
+		if stats.Errors == nil {
+			stats.Errors = make(map[string]int)
+		}
+		stats.Errors[code]++
+	}
+}
+
+// replayWorker - returns the worker of n replaying the ops of key.
+func replayWorker(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// run - replays ops at the pace of the log scaled by speed. Ops are
+// spread over the workers by key, so that the ops of a key are replayed
+// in the order of the log, whatever the concurrency.
+func (p *replayer) run(ctx context.Context, ops []replayOp) ReplayReport {
+	p.stats = make(map[string]*ReplayAPIStats)
+	queues := make([]chan replayOp, p.concurrency)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan replayOp, p.concurrency)
+		wg.Add(1)
+		go func(queue <-chan replayOp) {
+			defer wg.Done()
+			for op := range queue {
+				start := time.Now()
+				n, err := p.do(op)
+				p.record(op.API, n, time.Since(start), err)
+			}
+		}(queues[i])
+	}
+
+	start := time.Now()
+loop:
+	for _, op := range ops {
+		if p.speed > 0 {
+			offset := time.Duration(float64(op.Time.Sub(ops[0].Time)) / p.speed)
+			if delay := time.Until(start.Add(offset)); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					break loop
+				}
+			}
+		}
+		select {
+		case queues[replayWorker(op.key(), len(queues))] <- op:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+
+	report := ReplayReport{Duration: time.Since(start), APIs: make(map[string]ReplayAPIStats)}
+	for api, stats := range p.stats {
+		sort.Slice(stats.latencies, func(i, j int) bool { return stats.latencies[i] < stats.latencies[j] })
+		var total time.Duration
+		for _, latency := range stats.latencies {
+			total += latency
+		}
+		n := len(stats.latencies)
+		stats.LatencyAvg = total / time.Duration(n)
+		stats.LatencyP50 = stats.latencies[n/2]
+		stats.LatencyP99 = stats.latencies[n*99/100]
+		stats.LatencyMax = stats.latencies[n-1]
+		report.APIs[api] = *stats
+	}
+	return report
+}
+
+// parseReplayBuckets - parses the OLD=NEW bucket mappings of --bucket.
+func parseReplayBuckets(mappings []string) (map[string]string, error) {
+	buckets := make(map[string]string)
+	for _, mapping := range mappings {
+		i := strings.IndexByte(mapping, '=')
+		if i <= 0 || i == len(mapping)-1 {
+			return nil, fmt.Errorf("invalid bucket mapping %s, expected OLD=NEW", mapping)
+		}
+		buckets[mapping[:i]] = mapping[i+1:]
+	}
+	return buckets, nil
+}
+
+var replayCmd = cli.Command{
+	Name:      "replay",
+	Usage:     "replay the S3 requests of a trace or audit log against a server, for capacity and regression testing",
+	ArgsUsage: "FILE",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:   "endpoint",
+			Value:  "http://localhost:" + globalRadioDefaultPort,
+			Usage:  "S3 endpoint to replay against",
+			EnvVar: "RADIO_REPLAY_ENDPOINT",
+		},
+		cli.StringFlag{
+			Name:   "access-key",
+			Usage:  "S3 access key",
+			EnvVar: "RADIO_REPLAY_ACCESS_KEY",
+		},
+		cli.StringFlag{
+			Name:   "secret-key",
+			Usage:  "S3 secret key",
+			EnvVar: "RADIO_REPLAY_SECRET_KEY",
+		},
+		cli.StringSliceFlag{
+			Name:  "bucket",
+			Usage: "replay the requests to bucket OLD against bucket NEW, as OLD=NEW",
+		},
+		cli.Float64Flag{
+			Name:  "speed",
+			Value: 1,
+			Usage: "pace of the replay relative to the log, such as 2 for twice as fast, 0 for as fast as possible",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Value: defaultReplayConcurrency,
+			Usage: "most requests in flight",
+		},
+		cli.BoolFlag{
+			Name:  "insecure",
+			Usage: "disable TLS certificate verification",
+		},
+	},
+	Action: replayMain,
+}
+
+func replayMain(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "replay", 1)
+	}
+	if ctx.Float64("speed") < 0 || ctx.Int("concurrency") < 1 {
+		logger.FatalIf(errors.New("speed must not be negative and concurrency must be at least 1"), "Invalid arguments")
+	}
+	buckets, err := parseReplayBuckets(ctx.StringSlice("bucket"))
+	logger.FatalIf(err, "Invalid arguments")
+
+	f, err := os.Open(ctx.Args().First())
+	logger.FatalIf(err, "Unable to open the log")
+	ops, skipped, err := parseReplayLog(f)
+	f.Close()
+	logger.FatalIf(err, "Unable to read the log %s", ctx.Args().First())
+	if len(ops) == 0 {
+		logger.FatalIf(errors.New("no S3 requests to replay"), "Unable to replay %s", ctx.Args().First())
+	}
+
+	u, err := url.Parse(ctx.String("endpoint"))
+	if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+		err = fmt.Errorf("unsupported endpoint scheme %q, expected http or https", u.Scheme)
+	}
+	logger.FatalIf(err, "Invalid endpoint")
+	clnt, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+		Creds:        credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), ""),
+		Secure:       u.Scheme == "https",
+		BucketLookup: miniogo.BucketLookupPath,
+	})
+	logger.FatalIf(err, "Unable to initialize the S3 client")
+	transport := NewCustomHTTPTransport()
+	if ctx.Bool("insecure") {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	clnt.SetCustomTransport(transport)
+
+	p := &replayer{
+		clnt:        &miniogo.Core{Client: clnt},
+		buckets:     buckets,
+		speed:       ctx.Float64("speed"),
+		concurrency: ctx.Int("concurrency"),
+	}
+	report := p.run(context.Background(), ops)
+	report.Skipped = skipped
+	printJSON(report)
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+)
+
+const testReplayLog = `
+{"nodename":"radio1","funcname":"s3.PutObject","request":{"time":"2020-01-01T00:00:00Z","method":"PUT","path":"/bucket/a.txt","headers":{"Content-Length":["1000"]}},"response":{"statuscode":200}}
+{"nodename":"radio1","funcname":"admin.ServerInfo","request":{"time":"2020-01-01T00:00:00.1Z","method":"GET","path":"/minio/admin/v1/info"},"response":{"statuscode":200}}
+{"nodename":"radio1","funcname":"s3.PutObject","request":{"time":"2020-01-01T00:00:00.2Z","method":"PUT","path":"/bucket/b/c.txt","headers":{"Content-Length":["5200"],"X-Amz-Decoded-Content-Length":["5000"]}},"response":{"statuscode":200}}
+
+{"version":"1","deploymentid":"x","time":"2020-01-01T00:00:00.3Z","api":{"name":"GetObject","bucket":"bucket","object":"a.txt","statusCode":200}}
+{"version":"1","deploymentid":"x","time":"2020-01-01T00:00:00.4Z","api":{"name":"ListObjectsV2","bucket":"bucket","statusCode":200},"requestQuery":{"list-type":"2","prefix":"b/","delimiter":"/"}}
+{"version":"1","deploymentid":"x","time":"2020-01-01T00:00:00.5Z","api":{"name":"CopyObject","bucket":"bucket","object":"d.txt","statusCode":200}}
+{"version":"1","deploymentid":"x","time":"2020-01-01T00:00:00.6Z","api":{"name":"DeleteObject","bucket":"bucket","object":"a.txt","statusCode":204}}
+`
+
+func TestParseReplayLog(t *testing.T) {
+	ops, skipped, err := parseReplayLog(strings.NewReader(testReplayLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []replayOp{
+		{API: "PutObject", Bucket: "bucket", Object: "a.txt", Size: 1000},
+		{API: "PutObject", Bucket: "bucket", Object: "b/c.txt", Size: 5000},
+		{API: "GetObject", Bucket: "bucket", Object: "a.txt"},
+		{API: "ListObjectsV2", Bucket: "bucket", Prefix: "b/", Delimiter: "/"},
+		{API: "DeleteObject", Bucket: "bucket", Object: "a.txt"},
+	}
+	if len(ops) != len(expected) {
+		t.Fatalf("expected %d ops, got %+v", len(expected), ops)
+	}
+	for i, op := range ops {
+		if op.Time.IsZero() {
+			t.Errorf("Test %d: expected the time of the request", i+1)
+		}
+		op.Time = expected[i].Time
+		if op != expected[i] {
+			t.Errorf("Test %d: expected %+v, got %+v", i+1, expected[i], op)
+		}
+	}
+	if len(skipped) != 1 || skipped["CopyObject"] != 1 {
+		t.Errorf("expected CopyObject to be skipped, got %v", skipped)
+	}
+
+	for i, data := range []string{
+		`not json`,
+		`{"nodename":"radio1"}`,
+		`{"time":"yesterday","api":{"name":"GetObject","bucket":"bucket"}}`,
+	} {
+		if _, _, err = parseReplayLog(strings.NewReader(data)); err == nil {
+			t.Errorf("Test %d: expected %s to be invalid", i+1, data)
+		}
+	}
+}
+
+func TestSyntheticReader(t *testing.T) {
+	data, err := ioutil.ReadAll(newSyntheticReader("bucket/a.txt", 1000))
+	if err != nil || len(data) != 1000 {
+		t.Fatalf("expected 1000 bytes, got %d, %v", len(data), err)
+	}
+	again, _ := ioutil.ReadAll(io.LimitReader(newSyntheticReader("bucket/a.txt", 1000), 1000))
+	if !bytes.Equal(data, again) {
+		t.Error("expected the same content for the same key")
+	}
+	other, _ := ioutil.ReadAll(newSyntheticReader("bucket/b.txt", 1000))
+	if bytes.Equal(data, other) {
+		t.Error("expected other content for other keys")
+	}
+}
+
+// replayRemote - a fake S3 remote recording the requests.
+type replayRemote struct {
+	mu       sync.Mutex
+	requests []string
+	objects  map[string][]byte
+}
+
+func (s *replayRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		s.objects[r.URL.Path] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet:
+		if r.URL.Query().Get("list-type") == "2" {
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>replayed</Name></ListBucketResult>`)
+			return
+		}
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestReplayerRun(t *testing.T) {
+	ops, _, err := parseReplayLog(strings.NewReader(testReplayLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A get of a missing object.
+	ops = append(ops, replayOp{Time: ops[len(ops)-1].Time, API: "GetObject", Bucket: "bucket", Object: "a.txt"})
+
+	remote := &replayRemote{objects: make(map[string][]byte)}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+		Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+		Region:       "us-east-1",
+		BucketLookup: miniogo.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &replayer{
+		clnt:        &miniogo.Core{Client: c},
+		buckets:     map[string]string{"bucket": "replayed"},
+		concurrency: 4,
+	}
+	report := p.run(context.Background(), ops)
+
+	// The ops of a.txt are replayed in the order of the log.
+	var requests []string
+	for _, r := range remote.requests {
+		if strings.HasSuffix(r, "/replayed/a.txt") {
+			requests = append(requests, r)
+		}
+	}
+	expected := "PUT /replayed/a.txt,GET /replayed/a.txt,DELETE /replayed/a.txt,GET /replayed/a.txt"
+	if strings.Join(requests, ",") != expected {
+		t.Errorf("expected %s, got %v", expected, requests)
+	}
+	if data := remote.objects["/replayed/b/c.txt"]; len(data) != 5000 {
+		t.Errorf("expected 5000 bytes to be uploaded, got %d", len(data))
+	}
+
+	testCases := []struct {
+		api      string
+		requests int
+		bytes    int64
+		errors   int
+	}{
+		{"PutObject", 2, 6000, 0},
+		{"GetObject", 2, 1000, 1},
+		{"ListObjectsV2", 1, 0, 0},
+		{"DeleteObject", 1, 0, 0},
+	}
+	for _, testCase := range testCases {
+		stats := report.APIs[testCase.api]
+		if stats.Requests != testCase.requests || stats.Bytes != testCase.bytes || len(stats.Errors) != testCase.errors {
+			t.Errorf("%s: unexpected stats %+v", testCase.api, stats)
+		}
+		if stats.LatencyMax < stats.LatencyP50 || stats.LatencyP50 <= 0 {
+			t.Errorf("%s: unexpected latencies %+v", testCase.api, stats)
+		}
+	}
+	if report.APIs["GetObject"].Errors["NoSuchKey"] != 1 {
+		t.Errorf("expected a NoSuchKey error, got %v", report.APIs["GetObject"].Errors)
+	}
+}
+
+func TestParseReplayBuckets(t *testing.T) {
+	buckets, err := parseReplayBuckets([]string{"prod=staging", "logs=logs-replay"})
+	if err != nil || buckets["prod"] != "staging" || buckets["logs"] != "logs-replay" {
+		t.Errorf("unexpected mappings %v, %v", buckets, err)
+	}
+	for _, mapping := range []string{"prod", "=staging", "prod="} {
+		if _, err = parseReplayBuckets([]string{mapping}); err == nil {
+			t.Errorf("expected %s to be invalid", mapping)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+// Divergences of the copy of an object on a remote from the reference
+// copy, the ETag and size held by most remotes.
+const (
+	divergenceMissing = "missing"
+	divergenceETag    = "etag"
+	divergenceSize    = "size"
+	// divergenceNewer - the diverging copy was written after the
+	// reference copy, it may be the latest write.
+	divergenceNewer = "newer"
+)
+
+// DivergenceItem - a remote whose copy of an object diverges from the
+// reference copy.
+type DivergenceItem struct {
+	Object       string    `json:"object"`
+	Remote       string    `json:"remote"`
+	Divergences  []string  `json:"divergences"`
+	ETag         string    `json:"etag,omitempty"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	// Reference copy, the one held by most remotes.
+	RefETag         string    `json:"refETag"`
+	RefSize         int64     `json:"refSize"`
+	RefLastModified time.Time `json:"refLastModified"`
+}
+
+// RemoteDivergence - number of objects diverging on a remote by
+// divergence.
+type RemoteDivergence struct {
+	Remote      string `json:"remote"`
+	Maintenance bool   `json:"maintenance,omitempty"`
+	Missing     int    `json:"missing"`
+	ETag        int    `json:"etag"`
+	Size        int    `json:"size"`
+	Newer       int    `json:"newer"`
+}
+
+// ConsistencyReport - summary of a consistency report.
+type ConsistencyReport struct {
+	Bucket           string             `json:"bucket"`
+	Prefix           string             `json:"prefix"`
+	ObjectsScanned   int                `json:"objectsScanned"`
+	ObjectsDivergent int                `json:"objectsDivergent"`
+	Remotes          []RemoteDivergence `json:"remotes"`
+	Error            string             `json:"error,omitempty"`
+}
+
+// ReportUpdate - one entry of the streamed report, either a diverging
+// copy as soon as it was found or the summary once the walk completed.
+type ReportUpdate struct {
+	Item   *DivergenceItem    `json:"item,omitempty"`
+	Result *ConsistencyReport `json:"result,omitempty"`
+}
+
+// referenceCopy - returns the listing of object held by most remotes,
+// ties are decided by the order of the remotes.
+func referenceCopy(heads []*miniogo.ObjectInfo) *miniogo.ObjectInfo {
+	var ref *miniogo.ObjectInfo
+	refCount := 0
+	for _, head := range heads {
+		if head == nil {
+			continue
+		}
+		count := 0
+		for _, other := range heads {
+			if other != nil && canonicalizeETag(other.ETag) == canonicalizeETag(head.ETag) && other.Size == head.Size {
+				count++
+			}
+		}
+		if count > refCount {
+			ref, refCount = head, count
+		}
+	}
+	return ref
+}
+
+// divergences - returns how the listing head of an object on a remote
+// diverges from the reference copy ref.
+func divergences(head, ref *miniogo.ObjectInfo) []string {
+	if head == nil {
+		return []string{divergenceMissing}
+	}
+	var divs []string
+	if canonicalizeETag(head.ETag) != canonicalizeETag(ref.ETag) {
+		divs = append(divs, divergenceETag)
+	}
+	if head.Size != ref.Size {
+		divs = append(divs, divergenceSize)
+	}
+	if len(divs) > 0 && head.LastModified.After(ref.LastModified) {
+		divs = append(divs, divergenceNewer)
+	}
+	return divs
+}
+
+// ConsistencyReport - compares the listings of all remotes of a mirrored
+// bucket below prefix and passes every copy diverging from the reference
+// copy to itemFn, nothing is repaired. Only listings are read, remotes in
+// maintenance are not compared.
+func (l *radioObjects) ConsistencyReport(ctx context.Context, bucket, prefix string, itemFn func(DivergenceItem)) (ConsistencyReport, error) {
+	report := ConsistencyReport{Bucket: bucket, Prefix: prefix}
+
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok {
+		return report, BucketNotFound{Bucket: bucket}
+	}
+
+	remotes := make([]string, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		remotes[index] = clnt.EndpointURL().String() + SlashSeparator + clnt.Bucket
+		report.Remotes = append(report.Remotes, RemoteDivergence{
+			Remote:      remotes[index],
+			Maintenance: clnt.inMaintenance(),
+		})
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	listers, err := newRemoteListers(rs3s, prefix, "", true, doneCh)
+	if err != nil {
+		return report, ErrorRespToObjectError(err, bucket)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		default:
+		}
+
+		name, heads, err := listers.next()
+		if err != nil {
+			return report, ErrorRespToObjectError(err, bucket)
+		}
+		if name == "" {
+			return report, nil
+		}
+		report.ObjectsScanned++
+
+		ref := referenceCopy(heads)
+		divergent := false
+		for index, head := range heads {
+			if rs3s.clnts[index].inMaintenance() {
+				continue
+			}
+			divs := divergences(head, ref)
+			if len(divs) == 0 {
+				continue
+			}
+			divergent = true
+			item := DivergenceItem{
+				Object:          name,
+				Remote:          remotes[index],
+				Divergences:     divs,
+				RefETag:         canonicalizeETag(ref.ETag),
+				RefSize:         ref.Size,
+				RefLastModified: ref.LastModified,
+			}
+			if head != nil {
+				item.ETag = canonicalizeETag(head.ETag)
+				item.Size = head.Size
+				item.LastModified = head.LastModified
+			}
+			for _, div := range divs {
+				switch div {
+				case divergenceMissing:
+					report.Remotes[index].Missing++
+				case divergenceETag:
+					report.Remotes[index].ETag++
+				case divergenceSize:
+					report.Remotes[index].Size++
+				case divergenceNewer:
+					report.Remotes[index].Newer++
+				}
+			}
+			itemFn(item)
+		}
+		if divergent {
+			report.ObjectsDivergent++
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+)
+
+func TestDivergences(t *testing.T) {
+	now := time.Now()
+	a := &miniogo.ObjectInfo{ETag: `"a"`, Size: 1, LastModified: now}
+	b := &miniogo.ObjectInfo{ETag: "b", Size: 2, LastModified: now.Add(time.Second)}
+	c := &miniogo.ObjectInfo{ETag: "a", Size: 1, LastModified: now.Add(time.Second)}
+	old := &miniogo.ObjectInfo{ETag: "b", Size: 1, LastModified: now.Add(-time.Second)}
+
+	testCases := []struct {
+		heads    []*miniogo.ObjectInfo
+		expected [][]string
+	}{
+		{[]*miniogo.ObjectInfo{a, c}, [][]string{nil, nil}},
+		{[]*miniogo.ObjectInfo{a, nil, c}, [][]string{nil, {divergenceMissing}, nil}},
+		{[]*miniogo.ObjectInfo{b, a, c}, [][]string{{divergenceETag, divergenceSize, divergenceNewer}, nil, nil}},
+		{[]*miniogo.ObjectInfo{a, old, c}, [][]string{nil, {divergenceETag}, nil}},
+		// Without a majority the first remote holds the reference.
+		{[]*miniogo.ObjectInfo{a, b}, [][]string{nil, {divergenceETag, divergenceSize, divergenceNewer}}},
+	}
+	for i, testCase := range testCases {
+		ref := referenceCopy(testCase.heads)
+		for index, head := range testCase.heads {
+			if divs := divergences(head, ref); !reflect.DeepEqual(divs, testCase.expected[index]) {
+				t.Errorf("Test %d: expected %v on remote %d, got %v", i+1, testCase.expected[index], index, divs)
+			}
+		}
+	}
+}
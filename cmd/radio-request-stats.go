@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultRequestStatsQueueSize = 4096
+
+	// Interval the producer batches records for.
+	requestStatsFlushFrequency = 500 * time.Millisecond
+)
+
+// Results counted by the request stats metrics.
+const (
+	requestStatsQueued  = "queued"
+	requestStatsDropped = "dropped"
+	requestStatsFailed  = "failed"
+)
+
+// globalRequestStats - exporter of the completed S3 requests, nil unless
+// configured.
+var globalRequestStats *requestStatsExporter
+
+// requestStatsConfig - export of a record of every completed S3 request
+// to Kafka.
+type requestStatsConfig struct {
+	Kafka struct {
+		Brokers []string `yaml:"brokers"`
+		Topic   string   `yaml:"topic"`
+		TLS     bool     `yaml:"tls"`
+		// TLSSkipVerify disables verification of the broker
+		// certificates.
+		TLSSkipVerify bool `yaml:"tls_skip_verify"`
+		SASL          struct {
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+		} `yaml:"sasl"`
+	} `yaml:"kafka"`
+	// SampleRate is the fraction of the requests exported, such as
+	// 0.01, all requests by default.
+	SampleRate float64 `yaml:"sample_rate"`
+	// QueueSize records waiting for the brokers at most, further
+	// records are dropped.
+	QueueSize int `yaml:"queue_size"`
+}
+
+func (c requestStatsConfig) enabled() bool {
+	return len(c.Kafka.Brokers) > 0
+}
+
+// validateRequestStatsConfig - validates the request stats export.
+func validateRequestStatsConfig(errs *radioConfigErrors, path string, c requestStatsConfig) {
+	if !c.enabled() {
+		if c.Kafka.Topic != "" || c.SampleRate != 0 || c.QueueSize != 0 {
+			errs.add(path+".kafka.brokers", "required to export request stats")
+		}
+		return
+	}
+	if c.Kafka.Topic == "" {
+		errs.add(path+".kafka.topic", "required to export request stats")
+	}
+	if c.Kafka.TLSSkipVerify && !c.Kafka.TLS {
+		errs.add(path+".kafka.tls_skip_verify", "requires tls")
+	}
+	if c.Kafka.SASL.Password != "" && c.Kafka.SASL.Username == "" {
+		errs.add(path+".kafka.sasl.username", "required with a password")
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		errs.add(path+".sample_rate", "must be between 0 and 1")
+	}
+	if c.QueueSize < 0 {
+		errs.add(path+".queue_size", "must not be negative")
+	}
+}
+
+// RequestRecord - a completed S3 request, as exported.
+type RequestRecord struct {
+	Time     time.Time `json:"time"`
+	API      string    `json:"api"`
+	Bucket   string    `json:"bucket,omitempty"`
+	Status   int       `json:"status"`
+	BytesIn  int64     `json:"rx"`
+	BytesOut int64     `json:"tx"`
+	// Duration and TTFB in milliseconds.
+	Duration float64 `json:"duration_ms"`
+	TTFB     float64 `json:"ttfb_ms,omitempty"`
+	// Remote which served the request, if any.
+	Remote string `json:"remote,omitempty"`
+	// SampleRate of the export, each record stands for 1/SampleRate
+	// requests.
+	SampleRate float64 `json:"sample_rate,omitempty"`
+}
+
+// requestStatsExporter - sends RequestRecords to a Kafka topic, keyed by
+// bucket.
+type requestStatsExporter struct {
+	producer   sarama.AsyncProducer
+	topic      string
+	sampleRate float64
+}
+
+// newKafkaProducer - returns a producer batching records for the brokers
+// of c, with at most c.QueueSize records waiting.
+func newKafkaProducer(c requestStatsConfig) (sarama.AsyncProducer, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "radio"
+	config.ChannelBufferSize = c.QueueSize
+	if config.ChannelBufferSize == 0 {
+		config.ChannelBufferSize = defaultRequestStatsQueueSize
+	}
+	config.Producer.RequiredAcks = sarama.WaitForLocal
+	config.Producer.Compression = sarama.CompressionSnappy
+	config.Producer.Flush.Frequency = requestStatsFlushFrequency
+	config.Producer.Return.Errors = true
+	if c.Kafka.TLS {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = &tls.Config{
+			RootCAs:            globalRootCAs,
+			InsecureSkipVerify: c.Kafka.TLSSkipVerify,
+		}
+	}
+	if c.Kafka.SASL.Username != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = c.Kafka.SASL.Username
+		config.Net.SASL.Password = c.Kafka.SASL.Password
+	}
+	return sarama.NewAsyncProducer(c.Kafka.Brokers, config)
+}
+
+func newRequestStatsExporter(producer sarama.AsyncProducer, c requestStatsConfig) *requestStatsExporter {
+	e := &requestStatsExporter{producer: producer, topic: c.Kafka.Topic, sampleRate: c.SampleRate}
+	if e.sampleRate == 0 {
+		e.sampleRate = 1
+	}
+	go func() {
+		for err := range producer.Errors() {
+			requestStatsRecords.WithLabelValues(requestStatsFailed).Inc()
+			logger.LogOnceIf(context.Background(), err.Err, "request-stats-kafka")
+		}
+	}()
+	return e
+}
+
+// sample - returns true if the request about to be served is exported,
+// e may be nil.
+func (e *requestStatsExporter) sample() bool {
+	if e == nil {
+		return false
+	}
+	return e.sampleRate >= 1 || rand.Float64() < e.sampleRate
+}
+
+// export - queues rec for the brokers, rec is dropped if the queue is
+// full rather than delaying the request.
+func (e *requestStatsExporter) export(rec RequestRecord) {
+	if e.sampleRate < 1 {
+		rec.SampleRate = e.sampleRate
+	}
+	value, err := json.Marshal(rec)
+	if err != nil {
+		logger.LogIf(context.Background(), err)
+		return
+	}
+	msg := &sarama.ProducerMessage{
+		Topic: e.topic,
+		Key:   sarama.StringEncoder(rec.Bucket),
+		Value: sarama.ByteEncoder(value),
+	}
+	select {
+	case e.producer.Input() <- msg:
+		requestStatsRecords.WithLabelValues(requestStatsQueued).Inc()
+	default:
+		requestStatsRecords.WithLabelValues(requestStatsDropped).Inc()
+	}
+}
+
+// exportRequestStats - exports the record of an S3 request started at
+// tBefore.
+func exportRequestStats(api string, r *http.Request, stats *recordAPIStats, rt *requestTrace, tBefore time.Time) {
+	rec := RequestRecord{
+		Time:     tBefore,
+		API:      api,
+		Status:   stats.respStatusCode,
+		BytesIn:  stats.bytesIn.Load(),
+		BytesOut: stats.bytesOut.Load(),
+		Duration: float64(UTCNow().Sub(tBefore)) / float64(time.Millisecond),
+	}
+	rec.Bucket, _ = request2BucketObjectName(r)
+	if rec.Status == 0 {
+		rec.Status = http.StatusOK
+	}
+	if stats.firstByteRead {
+		rec.TTFB = float64(stats.TTFB.Sub(tBefore)) / float64(time.Millisecond)
+	}
+	rt.mu.Lock()
+	rec.Remote = rt.remote
+	rt.mu.Unlock()
+	globalRequestStats.export(rec)
+}
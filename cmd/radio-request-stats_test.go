@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+)
+
+func TestValidateRequestStatsConfig(t *testing.T) {
+	var c requestStatsConfig
+	c.Kafka.Brokers = []string{"kafka:9092"}
+	c.Kafka.Topic = "radio-requests"
+	c.SampleRate = 0.01
+
+	var invalid requestStatsConfig
+	invalid.Kafka.Brokers = []string{"kafka:9092"}
+	invalid.Kafka.TLSSkipVerify = true
+	invalid.Kafka.SASL.Password = "secret"
+	invalid.SampleRate = 2
+
+	var missing requestStatsConfig
+	missing.Kafka.Topic = "radio-requests"
+
+	testCases := []struct {
+		c    requestStatsConfig
+		errs int
+	}{
+		{requestStatsConfig{}, 0},
+		{c, 0},
+		{invalid, 4},
+		{missing, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateRequestStatsConfig(&errs, "request_stats", testCase.c)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestRequestStatsExporter(t *testing.T) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, config)
+	defer producer.Close()
+
+	var c requestStatsConfig
+	c.Kafka.Topic = "radio-requests"
+	c.SampleRate = 0.5
+	e := newRequestStatsExporter(producer, c)
+
+	producer.ExpectInputWithCheckerFunctionAndSucceed(func(value []byte) error {
+		var rec RequestRecord
+		if err := json.Unmarshal(value, &rec); err != nil {
+			return err
+		}
+		if rec.API != "getobject" || rec.Bucket != "photos" || rec.Status != 200 || rec.SampleRate != 0.5 {
+			return fmt.Errorf("unexpected record %s", value)
+		}
+		return nil
+	})
+	e.export(RequestRecord{Time: UTCNow(), API: "getobject", Bucket: "photos", Status: 200, BytesOut: 10,
+		Duration: 1.5, Remote: "s3.example.com/photos"})
+
+	select {
+	case msg := <-producer.Successes():
+		if msg.Topic != "radio-requests" {
+			t.Errorf("expected topic radio-requests, got %s", msg.Topic)
+		}
+		if key, _ := msg.Key.Encode(); string(key) != "photos" {
+			t.Errorf("expected key photos, got %s", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("record was not produced")
+	}
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if e.sample() {
+			sampled++
+		}
+	}
+	if sampled < 350 || sampled > 650 {
+		t.Errorf("expected about half of the requests to be sampled, got %d", sampled)
+	}
+	var disabled *requestStatsExporter
+	if disabled.sample() {
+		t.Error("expected no sampling without an exporter")
+	}
+}
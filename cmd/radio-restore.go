@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/s3signer"
+	"github.com/minio/minio-go/v6/pkg/s3utils"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	// Maximum size of a restore request body.
+	maxRestoreRequestSize = 64 * 1024
+
+	// Interval at which radio polls the remotes for the status of a
+	// restore.
+	restorePollInterval = time.Minute
+
+	// Restores not completed within this time are no longer polled,
+	// bulk retrievals from deep archive tiers take up to 48 hours.
+	restorePollTimeout = 72 * time.Hour
+)
+
+// signedRequest - sends a signed request for object to the remote, for
+// the restore APIs which minio-go does not implement. Non 2xx responses
+// are returned as miniogo.ErrorResponse.
+func (clnt bucketClient) signedRequest(ctx context.Context, method, object string, query string, body []byte) (*http.Response, error) {
+	region := clnt.signingRegion
+	if region == "" {
+		var err error
+		if region, err = clnt.GetBucketLocation(clnt.Bucket); err != nil {
+			return nil, err
+		}
+	}
+	creds, err := clnt.creds.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	u := *clnt.EndpointURL()
+	key := clnt.remoteKey(object)
+	if clnt.virtualHost() {
+		u.Host = clnt.Bucket + "." + u.Host
+		u.Path = SlashSeparator + key
+		u.RawPath = SlashSeparator + s3utils.EncodePath(key)
+	} else {
+		u.Path = SlashSeparator + clnt.Bucket + SlashSeparator + key
+		u.RawPath = SlashSeparator + clnt.Bucket + SlashSeparator + s3utils.EncodePath(key)
+	}
+	u.RawQuery = query
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	sum := sha256.Sum256(body)
+	req.Header.Set(xhttp.AmzContentSha256, hex.EncodeToString(sum[:]))
+	req.ContentLength = int64(len(body))
+	req = s3signer.SignV4(*req, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, region)
+
+	resp, err := clnt.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	errResp := miniogo.ErrorResponse{StatusCode: resp.StatusCode}
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxRestoreRequestSize))
+	if err = xml.Unmarshal(data, &errResp); err != nil || errResp.Code == "" {
+		// HEAD responses carry no body.
+		errResp.Code = resp.Status
+		if resp.StatusCode == http.StatusNotFound {
+			errResp.Code = "NoSuchKey"
+		}
+	}
+	return nil, errResp
+}
+
+// restoreObject - passes a restore request for object to the remote,
+// returns 202 if the remote started a restore and 200 if the object was
+// restored already.
+func (clnt bucketClient) restoreObject(ctx context.Context, object string, request []byte) (int, error) {
+	resp, err := clnt.signedRequest(ctx, http.MethodPost, object, "restore", request)
+	if err != nil {
+		return 0, ErrorRespToObjectError(err, clnt.Bucket, object)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// restoreStatus - returns the x-amz-restore header of object on the
+// remote, empty if the remote does not restore the object.
+func (clnt bucketClient) restoreStatus(ctx context.Context, object string) (string, error) {
+	resp, err := clnt.signedRequest(ctx, http.MethodHead, object, "", nil)
+	if err != nil {
+		return "", ErrorRespToObjectError(err, clnt.Bucket, object)
+	}
+	resp.Body.Close()
+	return resp.Header.Get(xhttp.AmzRestore), nil
+}
+
+// parseRestoreStatus - parses an x-amz-restore header such as
+//
+//	ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"
+//
+// the expiry is zero while the restore is ongoing.
+func parseRestoreStatus(status string) (ongoing bool, expiry time.Time) {
+	ongoing = !strings.Contains(status, `ongoing-request="false"`)
+	const expiryKey = `expiry-date="`
+	if i := strings.Index(status, expiryKey); i >= 0 {
+		value := status[i+len(expiryKey):]
+		if j := strings.Index(value, `"`); j >= 0 {
+			expiry, _ = http.ParseTime(value[:j])
+		}
+	}
+	return ongoing, expiry
+}
+
+// restoreEntry - a restore requested through radio, status is the
+// x-amz-restore header returned to clients.
+type restoreEntry struct {
+	etag   string
+	clnts  []bucketClient
+	status string
+	expiry time.Time
+}
+
+// restoreTracker - polls the remotes for the status of the restores
+// requested through radio and caches it, so that HEAD and GET report
+// the restore without asking the remotes on every request.
+type restoreTracker struct {
+	mu      sync.Mutex
+	entries map[string]*restoreEntry
+}
+
+func newRestoreTracker() *restoreTracker {
+	return &restoreTracker{entries: make(map[string]*restoreEntry)}
+}
+
+// track - records a restore of the version etag of object requested on
+// clnts and polls them until the restore completes.
+func (t *restoreTracker) track(bucket, object, etag string, clnts []bucketClient) {
+	e := &restoreEntry{
+		etag:   etag,
+		clnts:  clnts,
+		status: `ongoing-request="true"`,
+	}
+	key := pathJoin(bucket, object)
+	t.mu.Lock()
+	t.entries[key] = e
+	t.mu.Unlock()
+
+	go t.poll(key, object, e)
+}
+
+// poll - updates the status of e right away and then every
+// restorePollInterval until the restore completed on one of its remotes,
+// e was replaced by a new restore or restorePollTimeout passed. Completed
+// restores are forgotten once the restored copy expires.
+func (t *restoreTracker) poll(key, object string, e *restoreEntry) {
+	ticker := time.NewTicker(restorePollInterval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(restorePollTimeout)
+	for {
+		t.mu.Lock()
+		current := t.entries[key] == e
+		t.mu.Unlock()
+		if !current {
+			return
+		}
+
+		for _, clnt := range e.clnts {
+			if clnt.inMaintenance() {
+				continue
+			}
+			ctx := remoteContext(newBackgroundContext("RestoreStatus", "", object), clnt)
+			status, err := clnt.restoreStatus(ctx, object)
+			if err != nil {
+				logger.LogIf(ctx, err)
+				continue
+			}
+			if ongoing, expiry := parseRestoreStatus(status); status != "" && !ongoing {
+				t.mu.Lock()
+				e.status, e.expiry = status, expiry
+				t.mu.Unlock()
+				if expiry.IsZero() {
+					expiry = time.Now().Add(restorePollTimeout)
+				}
+				time.AfterFunc(time.Until(expiry), func() { t.forget(key, e) })
+				return
+			}
+		}
+
+		if now := <-ticker.C; now.After(deadline) {
+			t.forget(key, e)
+			return
+		}
+	}
+}
+
+// forget - removes e unless it was replaced by a new restore.
+func (t *restoreTracker) forget(key string, e *restoreEntry) {
+	t.mu.Lock()
+	if t.entries[key] == e {
+		delete(t.entries, key)
+	}
+	t.mu.Unlock()
+}
+
+// status - returns the x-amz-restore header of the version etag of
+// object, empty unless a restore was requested through radio.
+func (t *restoreTracker) status(bucket, object, etag string) string {
+	key := pathJoin(bucket, object)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return ""
+	}
+	if e.etag != etag || (!e.expiry.IsZero() && time.Now().After(e.expiry)) {
+		// Overwritten or the restored copy expired.
+		delete(t.entries, key)
+		return ""
+	}
+	return e.status
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRestoreStatus(t *testing.T) {
+	testCases := []struct {
+		status  string
+		ongoing bool
+		expiry  time.Time
+	}{
+		{`ongoing-request="true"`, true, time.Time{}},
+		{`ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"`, false, time.Date(2012, 12, 23, 0, 0, 0, 0, time.UTC)},
+		{`ongoing-request="false", expiry-date="invalid"`, false, time.Time{}},
+		{`ongoing-request="false", expiry-date="Fri, 23 Dec`, false, time.Time{}},
+	}
+	for i, testCase := range testCases {
+		ongoing, expiry := parseRestoreStatus(testCase.status)
+		if ongoing != testCase.ongoing || !expiry.Equal(testCase.expiry) {
+			t.Errorf("Test %d: expected %v/%v, got %v/%v", i+1, testCase.ongoing, testCase.expiry, ongoing, expiry)
+		}
+	}
+}
+
+func TestRestoreTrackerStatus(t *testing.T) {
+	tracker := newRestoreTracker()
+	tracker.entries["bucket/ongoing"] = &restoreEntry{etag: "etag", status: `ongoing-request="true"`}
+	tracker.entries["bucket/expired"] = &restoreEntry{etag: "etag", status: `ongoing-request="false"`,
+		expiry: time.Now().Add(-time.Minute)}
+
+	if status := tracker.status("bucket", "ongoing", "etag"); status != `ongoing-request="true"` {
+		t.Errorf("expected ongoing restore, got %q", status)
+	}
+	if status := tracker.status("bucket", "expired", "etag"); status != "" {
+		t.Errorf("expected no status for an expired restore, got %q", status)
+	}
+	if status := tracker.status("bucket", "ongoing", "other"); status != "" {
+		t.Errorf("expected no status for an overwritten object, got %q", status)
+	}
+	if len(tracker.entries) != 0 {
+		t.Errorf("expected expired and overwritten restores to be forgotten, got %d", len(tracker.entries))
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	scanSchemeClamd = "clamd"
+	scanSchemeICAP  = "icap"
+
+	defaultScanTimeout = 30 * time.Second
+
+	// Bytes sent to the scanner per chunk.
+	scanChunkSize = 64 << 10
+
+	// User metadata of quarantined objects naming the detection.
+	scanInfectionMetaKey = "X-Amz-Meta-Radio-Infection"
+)
+
+// Results counted by the content scanning metrics.
+const (
+	scanResultClean    = "clean"
+	scanResultInfected = "infected"
+	scanResultError    = "error"
+	scanResultTooLarge = "too_large"
+)
+
+// scanConfig - scanning of uploads to mirror buckets by a ClamAV daemon,
+// clamd://host:3310, or an ICAP server, icap://host:1344/service.
+type scanConfig struct {
+	Endpoint string        `yaml:"endpoint"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// MaxSize of scanned uploads, such as 100MiB, larger uploads are
+	// rejected.
+	MaxSize string `yaml:"max_size"`
+	// Buckets scanned, all mirror buckets but Quarantine when empty.
+	Buckets []string `yaml:"buckets"`
+	// Quarantine is the mirror bucket infected objects are written to.
+	Quarantine string `yaml:"quarantine"`
+	// FailOpen accepts uploads the scanner could not scan, they are
+	// rejected by default.
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// validateScanConfig - validates content scanning, mirrorBuckets holds all
+// configured mirror buckets.
+func validateScanConfig(errs *radioConfigErrors, path string, c scanConfig, mirrorBuckets map[string]string) {
+	if c.Endpoint == "" {
+		if c.Timeout != 0 || c.MaxSize != "" || len(c.Buckets) > 0 || c.Quarantine != "" || c.FailOpen {
+			errs.add(path+".endpoint", "required for scanning")
+		}
+		return
+	}
+	if _, err := newContentScanner(c); err != nil {
+		errs.add(path, "%v", err)
+	}
+	for _, bucket := range c.Buckets {
+		if _, ok := mirrorBuckets[bucket]; !ok {
+			errs.add(path+".buckets", "%q is not a mirror bucket", bucket)
+		}
+		if bucket == c.Quarantine {
+			errs.add(path+".buckets", "the quarantine bucket %q can't be scanned", bucket)
+		}
+	}
+	if _, ok := mirrorBuckets[c.Quarantine]; c.Quarantine != "" && !ok {
+		errs.add(path+".quarantine", "%q is not a mirror bucket", c.Quarantine)
+	}
+}
+
+// ContentInfected - the scanner detected Signature in an upload.
+type ContentInfected struct {
+	Bucket    string
+	Object    string
+	Signature string
+}
+
+func (e ContentInfected) Error() string {
+	return "Content of " + e.Bucket + SlashSeparator + e.Object + " is infected with " + e.Signature
+}
+
+// ContentScanFailed - an upload could not be scanned.
+type ContentScanFailed struct {
+	Bucket string
+	Object string
+	Err    error
+}
+
+func (e ContentScanFailed) Error() string {
+	return "Unable to scan " + e.Bucket + SlashSeparator + e.Object + ": " + e.Err.Error()
+}
+
+// contentScanner - client of the scanner.
+type contentScanner struct {
+	endpoint *url.URL
+	timeout  time.Duration
+	maxSize  int64
+	// buckets scanned, nil for all.
+	buckets    map[string]bool
+	quarantine string
+	failOpen   bool
+}
+
+func newContentScanner(c scanConfig) (*contentScanner, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != scanSchemeClamd && u.Scheme != scanSchemeICAP) || u.Host == "" {
+		return nil, fmt.Errorf("endpoint %q is not a clamd:// or icap:// URL", c.Endpoint)
+	}
+	s := &contentScanner{endpoint: u, timeout: c.Timeout, quarantine: c.Quarantine, failOpen: c.FailOpen}
+	if s.timeout <= 0 {
+		s.timeout = defaultScanTimeout
+	}
+	if c.MaxSize != "" {
+		size, err := humanize.ParseBytes(c.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("max_size: %v", err)
+		}
+		s.maxSize = int64(size)
+	}
+	if len(c.Buckets) > 0 {
+		s.buckets = make(map[string]bool)
+		for _, bucket := range c.Buckets {
+			s.buckets[bucket] = true
+		}
+	}
+	return s, nil
+}
+
+// scans - returns true if uploads to bucket are scanned.
+func (s *contentScanner) scans(bucket string) bool {
+	if s == nil || bucket == s.quarantine {
+		return false
+	}
+	return s.buckets == nil || s.buckets[bucket]
+}
+
+// scan - sends r to the scanner, returns the signature detected or "" if
+// r is clean.
+func (s *contentScanner) scan(r io.Reader, size int64) (signature string, err error) {
+	conn, err := net.DialTimeout("tcp", s.endpoint.Host, s.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return "", err
+	}
+	if s.endpoint.Scheme == scanSchemeICAP {
+		return s.scanICAP(conn, r, size)
+	}
+	return scanClamd(conn, r)
+}
+
+// scanClamd - scans r with the INSTREAM command of clamd.
+func scanClamd(conn net.Conn, r io.Reader) (string, error) {
+	w := bufio.NewWriterSize(conn, scanChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+	buf := make([]byte, scanChunkSize)
+	var length [4]byte
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(length[:], uint32(n))
+			w.Write(length[:])
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return "", werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	binary.BigEndian.PutUint32(length[:], 0)
+	w.Write(length[:])
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(err == io.EOF && reply != "") {
+		return "", err
+	}
+	reply = strings.TrimPrefix(strings.TrimSuffix(reply, "\x00"), "stream: ")
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	}
+	return "", errors.New("clamd: " + reply)
+}
+
+// scanICAP - scans r as the body of a RESPMOD request. Detections are
+// read from the X-Infection-Found, X-Virus-ID or X-Violations-Found
+// headers of the response.
+func (s *contentScanner) scanICAP(conn net.Conn, r io.Reader, size int64) (string, error) {
+	resHdr := "HTTP/1.1 200 OK\r\nContent-Length: " + strconv.FormatInt(size, 10) + "\r\n\r\n"
+	w := bufio.NewWriterSize(conn, scanChunkSize+16)
+	fmt.Fprintf(w, "RESPMOD %s ICAP/1.0\r\nHost: %s\r\nAllow: 204\r\nEncapsulated: res-hdr=0, res-body=%d\r\n\r\n%s",
+		s.endpoint.String(), s.endpoint.Host, len(resHdr), resHdr)
+	buf := make([]byte, scanChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			fmt.Fprintf(w, "%x\r\n", n)
+			w.Write(buf[:n])
+			if _, werr := w.WriteString("\r\n"); werr != nil {
+				return "", werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	w.WriteString("0\r\n\r\n")
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	tr := textproto.NewReader(bufio.NewReader(conn))
+	status, err := tr.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(status)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return "", fmt.Errorf("icap: malformed status %q", status)
+	}
+	if fields[1] != "200" && fields[1] != "204" {
+		return "", fmt.Errorf("icap: unexpected status %q", status)
+	}
+	header, err := tr.ReadMIMEHeader()
+	if err != nil {
+		return "", err
+	}
+	if found := header.Get("X-Infection-Found"); found != "" {
+		// Type=0; Resolution=2; Threat=Eicar-Test-Signature;
+		for _, field := range strings.Split(found, ";") {
+			if kv := strings.SplitN(strings.TrimSpace(field), "=", 2); len(kv) == 2 && kv[0] == "Threat" {
+				return kv[1], nil
+			}
+		}
+		return found, nil
+	}
+	if virus := header.Get("X-Virus-ID"); virus != "" {
+		return virus, nil
+	}
+	return header.Get("X-Violations-Found"), nil
+}
+
+// scanUpload - scans the spooled body of an upload of size bytes before
+// it is written to the remotes, infected objects are written to the
+// quarantine bucket if quarantine is set. body is rewound for the upload.
+func (l *radioObjects) scanUpload(ctx context.Context, bucket, object string, body io.ReadSeeker, size int64,
+	quarantine bool, userDefined map[string]string) error {
+	s := l.scanner
+	if s.maxSize > 0 && size > s.maxSize {
+		contentScanResults.WithLabelValues(bucket, scanResultTooLarge).Inc()
+		if s.failOpen {
+			return nil
+		}
+		return ContentScanFailed{Bucket: bucket, Object: object,
+			Err: fmt.Errorf("size %d exceeds the scanned maximum of %d", size, s.maxSize)}
+	}
+
+	contentScanBytes.WithLabelValues(bucket).Add(float64(size))
+	signature, err := s.scan(body, size)
+	if _, serr := body.Seek(0, io.SeekStart); serr != nil {
+		return serr
+	}
+	if err != nil {
+		contentScanResults.WithLabelValues(bucket, scanResultError).Inc()
+		err = ContentScanFailed{Bucket: bucket, Object: object, Err: err}
+		logger.LogIf(ctx, err)
+		if s.failOpen {
+			return nil
+		}
+		return err
+	}
+	if signature == "" {
+		contentScanResults.WithLabelValues(bucket, scanResultClean).Inc()
+		return nil
+	}
+
+	contentScanResults.WithLabelValues(bucket, scanResultInfected).Inc()
+	infected := ContentInfected{Bucket: bucket, Object: object, Signature: signature}
+	logger.LogIf(ctx, infected)
+	if quarantine && s.quarantine != "" {
+		if err = l.quarantineUpload(ctx, bucket, object, body, size, signature, userDefined); err != nil {
+			logger.LogIf(ctx, fmt.Errorf("unable to quarantine %s/%s: %v", bucket, object, err))
+		}
+	}
+	return infected
+}
+
+// quarantineUpload - writes an infected upload to the quarantine bucket
+// as bucket/object.
+func (l *radioObjects) quarantineUpload(ctx context.Context, bucket, object string, body io.Reader, size int64,
+	signature string, userDefined map[string]string) error {
+	hashReader, err := hash.NewReader(body, size, "", "", size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		return err
+	}
+	metadata := make(map[string]string, len(userDefined)+1)
+	for k, v := range userDefined {
+		metadata[k] = v
+	}
+	metadata[scanInfectionMetaKey] = signature
+	_, err = l.PutObject(ctx, l.scanner.quarantine, bucket+SlashSeparator+object, NewPutObjReader(hashReader, nil, nil),
+		ObjectOptions{UserDefined: metadata})
+	return err
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+const testSignature = "Eicar-Test-Signature"
+
+// startScanServer - serves one connection per scan with serve on a local
+// port, returns its address.
+func startScanServer(t *testing.T, serve func(conn net.Conn)) (addr string, stop func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return l.Addr().String(), func() { l.Close() }
+}
+
+// serveClamd - answers an INSTREAM command, data containing EICAR is
+// infected.
+func serveClamd(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+		return
+	}
+	var data bytes.Buffer
+	for {
+		var length uint32
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return
+		}
+		if length == 0 {
+			break
+		}
+		if _, err := io.CopyN(&data, r, int64(length)); err != nil {
+			return
+		}
+	}
+	if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+		io.WriteString(conn, "stream: "+testSignature+" FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+// serveICAP - answers a RESPMOD request, bodies containing EICAR are
+// infected.
+func serveICAP(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	tr := textproto.NewReader(r)
+	line, err := tr.ReadLine()
+	if err != nil || !strings.HasPrefix(line, "RESPMOD ") {
+		io.WriteString(conn, "ICAP/1.0 400 Bad Request\r\n\r\n")
+		return
+	}
+	// ICAP headers, then the status and headers of the encapsulated
+	// HTTP response.
+	if _, err = tr.ReadMIMEHeader(); err != nil {
+		return
+	}
+	if _, err = tr.ReadLine(); err != nil {
+		return
+	}
+	if _, err = tr.ReadMIMEHeader(); err != nil {
+		return
+	}
+	data, err := ioutil.ReadAll(httpChunkedReader(r))
+	if err != nil {
+		return
+	}
+	if bytes.Contains(data, []byte("EICAR")) {
+		io.WriteString(conn, "ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat="+testSignature+";\r\n\r\n")
+		return
+	}
+	io.WriteString(conn, "ICAP/1.0 204 No Content\r\n\r\n")
+}
+
+// httpChunkedReader - reads a chunked body from r.
+func httpChunkedReader(r *bufio.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			var n int64
+			for _, c := range strings.TrimSpace(line) {
+				n = n*16 + int64(strings.IndexRune("0123456789abcdef", c))
+			}
+			if n == 0 {
+				r.ReadString('\n')
+				pw.Close()
+				return
+			}
+			if _, err = io.CopyN(pw, r, n); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			r.ReadString('\n')
+		}
+	}()
+	return pr
+}
+
+func TestContentScanner(t *testing.T) {
+	clamd, stopClamd := startScanServer(t, serveClamd)
+	defer stopClamd()
+	icap, stopICAP := startScanServer(t, serveICAP)
+	defer stopICAP()
+
+	clean := bytes.Repeat([]byte("r"), 3*scanChunkSize+1)
+	infected := append(append([]byte{}, clean...), "EICAR"...)
+	for _, endpoint := range []string{"clamd://" + clamd, "icap://" + icap + "/avscan"} {
+		s, err := newContentScanner(scanConfig{Endpoint: endpoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, data := range [][]byte{clean, infected, nil} {
+			signature, err := s.scan(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("%s: %v", endpoint, err)
+			}
+			expected := ""
+			if bytes.Contains(data, []byte("EICAR")) {
+				expected = testSignature
+			}
+			if signature != expected {
+				t.Errorf("%s: expected signature %q, got %q", endpoint, expected, signature)
+			}
+		}
+	}
+}
+
+func TestValidateScanConfig(t *testing.T) {
+	mirrorBuckets := map[string]string{"bucket": "mirror[0].local", "quarantine": "mirror[1].local"}
+	testCases := []struct {
+		c    scanConfig
+		errs int
+	}{
+		{scanConfig{}, 0},
+		{scanConfig{Endpoint: "clamd://clamav:3310", MaxSize: "100MiB", Quarantine: "quarantine"}, 0},
+		{scanConfig{Endpoint: "icap://av:1344/avscan", Buckets: []string{"bucket"}}, 0},
+		{scanConfig{Quarantine: "quarantine"}, 1},
+		{scanConfig{Endpoint: "http://av:1344/avscan"}, 1},
+		{scanConfig{Endpoint: "clamd://clamav:3310", MaxSize: "lots"}, 1},
+		{scanConfig{Endpoint: "clamd://clamav:3310", Buckets: []string{"missing", "quarantine"}, Quarantine: "quarantine"}, 2},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateScanConfig(&errs, "scan", testCase.c, mirrorBuckets)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
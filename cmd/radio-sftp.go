@@ -0,0 +1,704 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/minio/pkg/policy"
+	"github.com/minio/radio/cmd/logger"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Maximum number of objects listed per call of the object layer while
+// reading an SFTP directory.
+const sftpListSize = 1000
+
+// sftpConfig - an SFTP listener exposing the mirror buckets as
+// directories, for clients which cannot speak S3. Users log in with the
+// access key and secret key of a local bucket.
+type sftpConfig struct {
+	// Address such as :2022, SFTP is not served if empty.
+	Address string `yaml:"address"`
+	// HostKeyFile is the PEM encoded private key identifying the
+	// server to clients.
+	HostKeyFile string `yaml:"host_key_file"`
+}
+
+// validateSFTPConfig - validates the SFTP listener.
+func validateSFTPConfig(errs *radioConfigErrors, path string, c sftpConfig) {
+	if c.Address == "" {
+		if c.HostKeyFile != "" {
+			errs.add(path+".address", "required to serve SFTP")
+		}
+		return
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		errs.add(path+".address", "%v", err)
+	}
+	if c.HostKeyFile == "" {
+		errs.add(path+".host_key_file", "required to serve SFTP")
+	} else if !isFile(c.HostKeyFile) {
+		errs.add(path+".host_key_file", "%s: no such file", c.HostKeyFile)
+	}
+}
+
+// sftpAccessKeyExt - permission extension holding the access key a
+// connection logged in with.
+const sftpAccessKeyExt = "radio-access-key"
+
+// sftpPasswordCallback - accepts the access key and secret key of a local
+// bucket as user and password.
+func sftpPasswordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	globalLocalCredsMu.RLock()
+	cred, ok := globalLocalCreds[conn.User()]
+	globalLocalCredsMu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare(password, []byte(cred.SecretKey)) != 1 {
+		return nil, fmt.Errorf("access denied for %s", conn.User())
+	}
+	return &ssh.Permissions{Extensions: map[string]string{sftpAccessKeyExt: cred.AccessKey}}, nil
+}
+
+// startSFTPServer - serves the mirror buckets over SFTP on the address of
+// c.
+func startSFTPServer(c sftpConfig) error {
+	keyBytes, err := ioutil.ReadFile(c.HostKeyFile)
+	if err != nil {
+		return err
+	}
+	hostKey, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %v", c.HostKeyFile, err)
+	}
+	config := &ssh.ServerConfig{PasswordCallback: sftpPasswordCallback}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", c.Address)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				logger.LogIf(context.Background(), err)
+				return
+			}
+			go serveSFTPConn(conn, config)
+		}
+	}()
+	return nil
+}
+
+// serveSFTPConn - serves the sftp subsystem of the sessions of conn.
+func serveSFTPConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		// Failed handshakes and logins are common on open ports.
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			logger.LogIf(context.Background(), err)
+			continue
+		}
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				// Payload of subsystem requests is the string "sftp"
+				// prefixed by its length.
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+			}
+		}(requests)
+
+		fs := &sftpFS{
+			accessKey:  sconn.Permissions.Extensions[sftpAccessKeyExt],
+			remoteAddr: sconn.RemoteAddr().String(),
+		}
+		server := sftp.NewRequestServer(channel, sftp.Handlers{
+			FileGet:  fs,
+			FilePut:  fs,
+			FileCmd:  fs,
+			FileList: fs,
+		})
+		go func() {
+			if err := server.Serve(); err != nil && err != io.EOF {
+				logger.LogIf(fs.context("SFTP", "", ""), err)
+			}
+			server.Close()
+		}()
+	}
+}
+
+// sftpFS - the mirror buckets as seen by a user of an SFTP connection.
+// The root lists the buckets, the directories of a bucket are the
+// prefixes of its keys up to a slash.
+type sftpFS struct {
+	accessKey  string
+	remoteAddr string
+}
+
+// splitSFTPPath - returns the bucket and the object of a path such as
+// /bucket/dir/file, object is empty for the root and the buckets.
+func splitSFTPPath(p string) (bucket, object string) {
+	p = strings.TrimPrefix(path.Clean(SlashSeparator+p), SlashSeparator)
+	if i := strings.Index(p, SlashSeparator); i >= 0 {
+		return p[:i], p[i+1:]
+	}
+	return p, ""
+}
+
+// context - returns the context of an operation, logged like an S3
+// request of api.
+func (fs *sftpFS) context(api, bucket, object string) context.Context {
+	host, _, err := net.SplitHostPort(fs.remoteAddr)
+	if err != nil {
+		host = fs.remoteAddr
+	}
+	reqInfo := &logger.ReqInfo{
+		DeploymentID: globalDeploymentID,
+		RequestID:    mustGetUUID(),
+		RemoteHost:   host,
+		API:          api,
+		BucketName:   bucket,
+		ObjectName:   object,
+		AccessKey:    fs.accessKey,
+	}
+	return logger.SetReqInfo(context.Background(), reqInfo)
+}
+
+// request - returns the S3 request of method passed to the tenants and
+// the request hooks for an action.
+func (fs *sftpFS) request(method string, size int64) *http.Request {
+	return &http.Request{
+		Method:        method,
+		URL:           &url.URL{},
+		Header:        http.Header{},
+		ContentLength: size,
+		RemoteAddr:    fs.remoteAddr,
+	}
+}
+
+// authorize - passes the action to the tenants and the request hooks as
+// an S3 request of method for bucket and object. Writes are rejected
+// while the server is read-only.
+func (fs *sftpFS) authorize(method string, action policy.Action, bucket, object string, size int64) error {
+	r := fs.request(method, size)
+	if globalReadOnly.Load() && isWriteRequest(r) {
+		return fsAuthError(ErrServerReadOnly)
+	}
+	return fsAuthError(frontendAuthHooks(r, HookAuth{AccessKey: fs.accessKey, Action: string(action), Bucket: bucket, Object: object}))
+}
+
+// refund - returns the quota of an upload of size bytes authorized for
+// bucket and object which failed.
+func (fs *sftpFS) refund(bucket, object string, size int64) {
+	globalTenants.refund(fs.request(http.MethodPut, size),
+		HookAuth{AccessKey: fs.accessKey, Action: string(policy.PutObjectAction), Bucket: bucket, Object: object})
+}
+
+// deleteObject - removes an object through the cache layer, if any, so
+// that the cache does not serve it anymore.
+func (fs *sftpFS) deleteObject(ctx context.Context, objectAPI ObjectLayer, bucket, object string) error {
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		return cacheAPI.DeleteObject(ctx, bucket, object)
+	}
+	return objectAPI.DeleteObject(ctx, bucket, object)
+}
+
+// fsAuthError - returns the error of an action denied by the tenants or
+// the request hooks, as understood by the SFTP and WebDAV front-ends.
+func fsAuthError(s3Err APIErrorCode) error {
+	switch s3Err {
+	case ErrNone:
+		return nil
+	case ErrAccessDenied:
+		return os.ErrPermission
+	}
+	return fmt.Errorf("%s", errorCodes.ToAPIErr(s3Err).Description)
+}
+
+// fsError - returns err of the object layer as understood by the SFTP
+// and WebDAV front-ends.
+func fsError(err error) error {
+	switch err.(type) {
+	case BucketNotFound, ObjectNotFound:
+		return os.ErrNotExist
+	case PrefixAccessDenied:
+		return os.ErrPermission
+	}
+	return err
+}
+
+// objectLayer - returns the object layer, errServerNotInitialized until
+// radio started.
+func (fs *sftpFS) objectLayer() (ObjectLayer, error) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return nil, errServerNotInitialized
+	}
+	return objectAPI, nil
+}
+
+// Fileread - opens an object for reading.
+func (fs *sftpFS) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	bucket, object := splitSFTPPath(r.Filepath)
+	if object == "" {
+		return nil, os.ErrInvalid
+	}
+	ctx := fs.context("SFTPGet", bucket, object)
+	objectAPI, err := fs.objectLayer()
+	if err != nil {
+		return nil, err
+	}
+	if err = fs.authorize(http.MethodGet, policy.GetObjectAction, bucket, object, 0); err != nil {
+		return nil, err
+	}
+	getObjectNInfo := objectAPI.GetObjectNInfo
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		getObjectNInfo = cacheAPI.GetObjectNInfo
+	}
+	gr, err := getObjectNInfo(ctx, bucket, object, nil, http.Header{}, ReadLock, ObjectOptions{})
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return &sftpReader{
+		ctx:            ctx,
+		bucket:         bucket,
+		object:         object,
+		getObjectNInfo: getObjectNInfo,
+		gr:             gr,
+		size:           gr.ObjInfo.Size,
+	}, nil
+}
+
+// sftpReader - reads an object at the offsets requested by a client.
+// Clients read files sequentially, so the object is streamed and only
+// read again from the offset of a request out of sequence.
+type sftpReader struct {
+	ctx            context.Context
+	bucket, object string
+	getObjectNInfo func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error)
+
+	mu   sync.Mutex
+	gr   *GetObjectReader
+	pos  int64
+	size int64
+}
+
+// ReadAt - reads len(p) bytes of the object at off.
+func (rd *sftpReader) ReadAt(p []byte, off int64) (int, error) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if off >= rd.size {
+		return 0, io.EOF
+	}
+	if rd.gr == nil || off != rd.pos {
+		if rd.gr != nil {
+			rd.gr.Close()
+			rd.gr = nil
+		}
+		gr, err := rd.getObjectNInfo(rd.ctx, rd.bucket, rd.object, &HTTPRangeSpec{Start: off, End: rd.size - 1},
+			http.Header{}, ReadLock, ObjectOptions{})
+		if err != nil {
+			return 0, fsError(err)
+		}
+		rd.gr, rd.pos = gr, off
+	}
+	if remaining := rd.size - off; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := io.ReadFull(rd.gr, p)
+	rd.pos += int64(n)
+	if err != nil {
+		rd.gr.Close()
+		rd.gr = nil
+		return n, err
+	}
+	if rd.pos >= rd.size {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close - closes the object.
+func (rd *sftpReader) Close() error {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.gr != nil {
+		rd.gr.Close()
+		rd.gr = nil
+	}
+	return nil
+}
+
+// Filewrite - creates an object, the data written by the client is
+// spooled to a temporary file and uploaded to the remotes once the file
+// is closed.
+func (fs *sftpFS) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	bucket, object := splitSFTPPath(r.Filepath)
+	if object == "" || HasSuffix(object, SlashSeparator) {
+		return nil, os.ErrInvalid
+	}
+	if _, err := fs.objectLayer(); err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile("", "radio-sftp-")
+	if err != nil {
+		return nil, err
+	}
+	return &sftpWriter{fs: fs, bucket: bucket, object: object, f: f}, nil
+}
+
+// sftpWriter - the data of an object written by a client.
+type sftpWriter struct {
+	fs             *sftpFS
+	bucket, object string
+	f              *os.File
+
+	mu sync.Mutex
+	// err is the error ending the transfer before the file was closed,
+	// the object is then not uploaded.
+	err error
+}
+
+// WriteAt - writes p at off of the spooled file.
+func (w *sftpWriter) WriteAt(p []byte, off int64) (int, error) {
+	return w.f.WriteAt(p, off)
+}
+
+// TransferError - records the error ending the connection.
+func (w *sftpWriter) TransferError(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// Close - uploads the spooled file, unless the transfer failed.
+func (w *sftpWriter) Close() error {
+	defer func() {
+		w.f.Close()
+		os.Remove(w.f.Name())
+	}()
+	w.mu.Lock()
+	terr := w.err
+	w.mu.Unlock()
+	if terr != nil {
+		return terr
+	}
+
+	fi, err := w.f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+	if isMaxObjectSize(size) {
+		return fmt.Errorf("%s", errorCodes.ToAPIErr(ErrEntityTooLarge).Description)
+	}
+	if err = w.fs.authorize(http.MethodPut, policy.PutObjectAction, w.bucket, w.object, size); err != nil {
+		return err
+	}
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			w.fs.refund(w.bucket, w.object, size)
+		}
+	}()
+	if _, err = w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	ctx := w.fs.context("SFTPPut", w.bucket, w.object)
+	objectAPI, err := w.fs.objectLayer()
+	if err != nil {
+		return err
+	}
+	metadata := map[string]string{}
+	reader, err := globalContentTypes.apply(w.bucket, w.object, metadata, w.f)
+	if err != nil {
+		return err
+	}
+	hashReader, err := hash.NewReader(reader, size, "", "", size, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		return err
+	}
+	putObject := objectAPI.PutObject
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		putObject = cacheAPI.PutObject
+	}
+	if _, err = putObject(ctx, w.bucket, w.object, NewPutObjReader(hashReader, nil, nil),
+		ObjectOptions{UserDefined: metadata}); err != nil {
+		logger.LogIf(ctx, err)
+		return fsError(err)
+	}
+	uploaded = true
+	return nil
+}
+
+// Filecmd - removes and renames objects. Directories are the zero byte
+// objects created by S3 consoles for folders, setting attributes is
+// accepted and ignored.
+func (fs *sftpFS) Filecmd(r *sftp.Request) error {
+	bucket, object := splitSFTPPath(r.Filepath)
+	objectAPI, err := fs.objectLayer()
+	if err != nil {
+		return err
+	}
+	if object == "" && r.Method != "Setstat" {
+		return os.ErrPermission
+	}
+	switch r.Method {
+	case "Setstat":
+		return nil
+	case "Remove":
+		ctx := fs.context("SFTPRemove", bucket, object)
+		if err = fs.authorize(http.MethodDelete, policy.DeleteObjectAction, bucket, object, 0); err != nil {
+			return err
+		}
+		return fsError(fs.deleteObject(ctx, objectAPI, bucket, object))
+	case "Mkdir":
+		ctx := fs.context("SFTPMkdir", bucket, object)
+		dir := object + SlashSeparator
+		if err = fs.authorize(http.MethodPut, policy.PutObjectAction, bucket, dir, 0); err != nil {
+			return err
+		}
+		hashReader, err := hash.NewReader(strings.NewReader(""), 0, "", "", 0, globalCLIContext.StrictS3Compat)
+		if err != nil {
+			return err
+		}
+		_, err = objectAPI.PutObject(ctx, bucket, dir, NewPutObjReader(hashReader, nil, nil), ObjectOptions{UserDefined: map[string]string{}})
+		return fsError(err)
+	case "Rmdir":
+		ctx := fs.context("SFTPRmdir", bucket, object)
+		dir := object + SlashSeparator
+		loi, err := objectAPI.ListObjects(ctx, bucket, dir, "", SlashSeparator, 2)
+		if err != nil {
+			return fsError(err)
+		}
+		for _, oi := range loi.Objects {
+			if oi.Name != dir {
+				return fmt.Errorf("directory %s is not empty", r.Filepath)
+			}
+		}
+		if len(loi.Prefixes) > 0 {
+			return fmt.Errorf("directory %s is not empty", r.Filepath)
+		}
+		if err = fs.authorize(http.MethodDelete, policy.DeleteObjectAction, bucket, dir, 0); err != nil {
+			return err
+		}
+		if err = fs.deleteObject(ctx, objectAPI, bucket, dir); err != nil {
+			if _, ok := err.(ObjectNotFound); !ok {
+				return fsError(err)
+			}
+		}
+		return nil
+	case "Rename":
+		return fs.rename(objectAPI, bucket, object, r.Target)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// rename - copies an object to target and removes it, S3 has no renames.
+func (fs *sftpFS) rename(objectAPI ObjectLayer, bucket, object, target string) error {
+	dstBucket, dstObject := splitSFTPPath(target)
+	if dstObject == "" {
+		return os.ErrPermission
+	}
+	ctx := fs.context("SFTPRename", dstBucket, dstObject)
+	if err := fs.authorize(http.MethodGet, policy.GetObjectAction, bucket, object, 0); err != nil {
+		return err
+	}
+	if err := fs.authorize(http.MethodDelete, policy.DeleteObjectAction, bucket, object, 0); err != nil {
+		return err
+	}
+	srcInfo, err := objectAPI.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		return fsError(err)
+	}
+	if err = fs.authorize(http.MethodPut, policy.PutObjectAction, dstBucket, dstObject, srcInfo.Size); err != nil {
+		return err
+	}
+	if _, err = objectAPI.CopyObject(ctx, bucket, object, dstBucket, dstObject, srcInfo, ObjectOptions{}, ObjectOptions{}); err != nil {
+		fs.refund(dstBucket, dstObject, srcInfo.Size)
+		return fsError(err)
+	}
+	if cacheAPI := newCachedObjectLayerFn(); cacheAPI != nil {
+		cacheAPI.Invalidate(ctx, dstBucket, dstObject)
+	}
+	return fsError(fs.deleteObject(ctx, objectAPI, bucket, object))
+}
+
+// Filelist - lists a directory or stats a path.
+func (fs *sftpFS) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	bucket, object := splitSFTPPath(r.Filepath)
+	objectAPI, err := fs.objectLayer()
+	if err != nil {
+		return nil, err
+	}
+	switch r.Method {
+	case "List":
+		ctx := fs.context("SFTPList", bucket, object)
+		if bucket == "" {
+			return fs.listBuckets(ctx, objectAPI)
+		}
+		if err = fs.authorize(http.MethodGet, policy.ListBucketAction, bucket, "", 0); err != nil {
+			return nil, err
+		}
+		return fs.listDir(ctx, objectAPI, bucket, object)
+	case "Stat":
+		ctx := fs.context("SFTPStat", bucket, object)
+		fi, err := fs.stat(ctx, objectAPI, bucket, object)
+		if err != nil {
+			return nil, err
+		}
+		return sftpListerAt{fi}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// listBuckets - lists the buckets of the tenant of the user.
+func (fs *sftpFS) listBuckets(ctx context.Context, objectAPI ObjectLayer) (sftp.ListerAt, error) {
+	if err := fs.authorize(http.MethodGet, policy.ListAllMyBucketsAction, "", "", 0); err != nil {
+		return nil, err
+	}
+	buckets, err := objectAPI.ListBuckets(ctx)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	buckets = globalTenants.filterBuckets(fs.accessKey, buckets)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	var fis sftpListerAt
+	for _, b := range buckets {
+		fis = append(fis, sftpFileInfo{name: b.Name, modTime: b.Created, dir: true})
+	}
+	return fis, nil
+}
+
+// listDir - lists the objects and prefixes of bucket one level below
+// the directory dir.
+func (fs *sftpFS) listDir(ctx context.Context, objectAPI ObjectLayer, bucket, dir string) (sftp.ListerAt, error) {
+	prefix := dir
+	if prefix != "" {
+		prefix += SlashSeparator
+	}
+	var fis sftpListerAt
+	marker := ""
+	for {
+		loi, err := objectAPI.ListObjects(ctx, bucket, prefix, marker, SlashSeparator, sftpListSize)
+		if err != nil {
+			return nil, fsError(err)
+		}
+		for _, p := range loi.Prefixes {
+			fis = append(fis, sftpFileInfo{name: path.Base(p), dir: true})
+		}
+		for _, oi := range loi.Objects {
+			if oi.Name == prefix {
+				// The folder object of the directory itself.
+				continue
+			}
+			fis = append(fis, sftpFileInfo{name: path.Base(oi.Name), size: oi.Size, modTime: oi.ModTime})
+		}
+		if !loi.IsTruncated {
+			break
+		}
+		marker = loi.NextMarker
+	}
+	if len(fis) == 0 && prefix != "" {
+		// Only an existing directory may be empty.
+		if _, err := fs.stat(ctx, objectAPI, bucket, dir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	return fis, nil
+}
+
+// stat - returns the file info of an object, or of the directory of the
+// prefix object/.
+func (fs *sftpFS) stat(ctx context.Context, objectAPI ObjectLayer, bucket, object string) (os.FileInfo, error) {
+	if bucket == "" {
+		return sftpFileInfo{name: SlashSeparator, dir: true}, nil
+	}
+	if object == "" {
+		if _, err := objectAPI.GetBucketInfo(ctx, bucket); err != nil {
+			return nil, fsError(err)
+		}
+		return sftpFileInfo{name: bucket, dir: true}, nil
+	}
+	if err := fs.authorize(http.MethodHead, policy.GetObjectAction, bucket, object, 0); err != nil {
+		return nil, err
+	}
+	oi, err := objectAPI.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err == nil {
+		return sftpFileInfo{name: path.Base(object), size: oi.Size, modTime: oi.ModTime}, nil
+	}
+	if _, ok := err.(ObjectNotFound); !ok {
+		return nil, fsError(err)
+	}
+	loi, err := objectAPI.ListObjects(ctx, bucket, object+SlashSeparator, "", SlashSeparator, 1)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	if len(loi.Objects) == 0 && len(loi.Prefixes) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return sftpFileInfo{name: path.Base(object), dir: true}, nil
+}
+
+// sftpFileInfo - an object, or a bucket or prefix listed as directory.
+type sftpFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi sftpFileInfo) Name() string       { return fi.name }
+func (fi sftpFileInfo) Size() int64        { return fi.size }
+func (fi sftpFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi sftpFileInfo) IsDir() bool        { return fi.dir }
+func (fi sftpFileInfo) Sys() interface{}   { return nil }
+
+func (fi sftpFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// sftpListerAt - the entries of a directory.
+type sftpListerAt []os.FileInfo
+
+// ListAt - copies the entries from offset to ls.
+func (l sftpListerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/policy"
+)
+
+func TestSplitSFTPPath(t *testing.T) {
+	testCases := []struct {
+		path           string
+		bucket, object string
+	}{
+		{"/", "", ""},
+		{"", "", ""},
+		{"/bucket", "bucket", ""},
+		{"/bucket/", "bucket", ""},
+		{"/bucket/dir/file.csv", "bucket", "dir/file.csv"},
+		{"bucket/dir/../file.csv", "bucket", "file.csv"},
+		{"/../bucket/file", "bucket", "file"},
+	}
+	for i, testCase := range testCases {
+		bucket, object := splitSFTPPath(testCase.path)
+		if bucket != testCase.bucket || object != testCase.object {
+			t.Errorf("Test %d: expected %q %q, got %q %q", i+1, testCase.bucket, testCase.object, bucket, object)
+		}
+	}
+}
+
+func TestSFTPListerAt(t *testing.T) {
+	l := sftpListerAt{
+		sftpFileInfo{name: "a", dir: true},
+		sftpFileInfo{name: "b", size: 1},
+		sftpFileInfo{name: "c", size: 2},
+	}
+	ls := make([]os.FileInfo, 2)
+	if n, err := l.ListAt(ls, 0); n != 2 || err != nil {
+		t.Fatalf("expected 2 entries, got %d %v", n, err)
+	}
+	if !ls[0].IsDir() || ls[0].Mode()&os.ModeDir == 0 || ls[1].Name() != "b" {
+		t.Fatalf("unexpected entries %v", ls)
+	}
+	if n, err := l.ListAt(ls, 2); n != 1 || err != io.EOF || ls[0].Name() != "c" {
+		t.Fatalf("expected the last entry and EOF, got %d %v", n, err)
+	}
+	if n, err := l.ListAt(ls, 3); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF, got %d %v", n, err)
+	}
+}
+
+func TestValidateSFTPConfig(t *testing.T) {
+	testCases := []struct {
+		c    sftpConfig
+		errs int
+	}{
+		{sftpConfig{}, 0},
+		{sftpConfig{Address: ":2022", HostKeyFile: os.Args[0]}, 0},
+		{sftpConfig{Address: ":2022"}, 1},
+		{sftpConfig{Address: "2022", HostKeyFile: os.Args[0]}, 1},
+		{sftpConfig{Address: ":2022", HostKeyFile: os.Args[0] + ".missing"}, 1},
+		{sftpConfig{HostKeyFile: os.Args[0]}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateSFTPConfig(&errs, "sftp", testCase.c)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestSFTPReaderReadAt(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 10))
+	opened := 0
+	getObjectNInfo := func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+		opened++
+		start := int64(0)
+		if rs != nil {
+			start = rs.Start
+		}
+		return NewGetObjectReaderFromReader(bytes.NewReader(data[start:]), ObjectInfo{Size: int64(len(data))}, nil)
+	}
+	rd := &sftpReader{
+		ctx:            context.Background(),
+		bucket:         "bucket",
+		object:         "object",
+		getObjectNInfo: getObjectNInfo,
+		size:           int64(len(data)),
+	}
+	defer rd.Close()
+
+	p := make([]byte, 30)
+	// Sequential reads are served by one stream.
+	for off := int64(0); off < 60; off += 30 {
+		if n, err := rd.ReadAt(p, off); n != 30 || err != nil || !bytes.Equal(p, data[off:off+30]) {
+			t.Fatalf("offset %d: unexpected read %d %v", off, n, err)
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("expected one stream, got %d", opened)
+	}
+	// Reads out of sequence reopen the object at their offset.
+	if n, err := rd.ReadAt(p, 10); n != 30 || err != nil || !bytes.Equal(p, data[10:40]) {
+		t.Fatalf("unexpected read %d %v", n, err)
+	}
+	if n, err := rd.ReadAt(p, 90); n != 10 || err != io.EOF || !bytes.Equal(p[:n], data[90:]) {
+		t.Fatalf("expected the last bytes and EOF, got %d %v", n, err)
+	}
+	if n, err := rd.ReadAt(p, 100); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF, got %d %v", n, err)
+	}
+	if opened != 3 {
+		t.Fatalf("expected three streams, got %d", opened)
+	}
+}
+
+// Tests that writes are rejected while the server is read-only and that
+// refunded uploads are uncounted from the quota.
+func TestSFTPAuthorize(t *testing.T) {
+	defer func(tenants *tenantRegistry) { globalTenants = tenants }(globalTenants)
+	globalTenants = newTestTenantRegistry(t)
+	teamA := globalTenants.byBucket["a1"]
+	teamA.mu.Lock()
+	teamA.used, teamA.admitted, teamA.measured = 512, 0, UTCNow()
+	teamA.mu.Unlock()
+
+	fs := &sftpFS{accessKey: "keya", remoteAddr: "127.0.0.1:2022"}
+
+	globalReadOnly.Store(true)
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		if err := fs.authorize(method, policy.PutObjectAction, "a1", "object", 0); err == nil {
+			t.Errorf("expected %s to be rejected while read-only", method)
+		}
+	}
+	if err := fs.authorize(http.MethodGet, policy.GetObjectAction, "a1", "object", 0); err != nil {
+		t.Errorf("expected reads to be accepted while read-only, got %v", err)
+	}
+	globalReadOnly.Store(false)
+
+	if err := fs.authorize(http.MethodPut, policy.PutObjectAction, "a1", "object", 512); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.authorize(http.MethodPut, policy.PutObjectAction, "a1", "object", 512); err == nil {
+		t.Fatal("expected the upload beyond the quota to be rejected")
+	}
+	fs.refund("a1", "object", 512)
+	if err := fs.authorize(http.MethodPut, policy.PutObjectAction, "a1", "object", 512); err != nil {
+		t.Errorf("expected the upload to be admitted once the failed one was refunded, got %v", err)
+	}
+}
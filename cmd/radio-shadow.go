@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio/pkg/sync/errgroup"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// Maximum amount of an upload buffered for a shadow remote reading the
+// body slower than the other remotes, shadows falling further behind
+// are abandoned instead of slowing down the upload.
+const shadowMaxBuffer = 16 * humanize.MiByte
+
+// Maximum number of sampled reads in flight per shadow remote, further
+// samples are dropped so that a slow shadow never piles up requests.
+const shadowMaxSampledReads = 64
+
+var (
+	errShadowLagging  = errors.New("shadow remote fell behind the upload and was abandoned")
+	errShadowNoUpload = errors.New("multipart upload was not started on the shadow remote")
+)
+
+// splitShadows - separates the shadow remotes from the remotes serving
+// clients.
+func splitShadows(all []bucketClient) (clnts, shadows []bucketClient) {
+	for _, clnt := range all {
+		if clnt.shadow {
+			shadows = append(shadows, clnt)
+		} else {
+			clnts = append(clnts, clnt)
+		}
+	}
+	return clnts, shadows
+}
+
+// shadowDo - starts fn on every shadow remote of rs3s, alongside the
+// request to the other remotes, and returns a function waiting for them.
+// Failures are logged and counted but never returned to the client.
+func (rs3s mirrorConfig) shadowDo(ctx context.Context, api string, fn func(index int, clnt bucketClient) error) (wait func()) {
+	if len(rs3s.shadows) == 0 {
+		return func() {}
+	}
+	g := errgroup.WithNErrs(len(rs3s.shadows))
+	for index := range rs3s.shadows {
+		index := index
+		g.Go(func() error {
+			return fn(index, rs3s.shadows[index])
+		}, index)
+	}
+	return func() {
+		for index, err := range g.Wait() {
+			clnt := rs3s.shadows[index]
+			remote := clnt.EndpointURL().Host + SlashSeparator + clnt.Bucket
+			result := "success"
+			if err != nil {
+				result = "error"
+				logger.LogIf(remoteContext(ctx, clnt), fmt.Errorf("shadow remote %s: %s failed: %v", remote, api, err))
+			}
+			shadowRequestsTotal.WithLabelValues(api, remote, result).Inc()
+		}
+	}
+}
+
+// sampleReads - sends a read of object to the shadow remotes sampling
+// reads, in the background. The body is discarded, the client response
+// never depends on it. Only ranged reads send the range.
+func (rs3s mirrorConfig) sampleReads(object string, ranged bool, startOffset, length int64, o ObjectOptions) {
+	for _, clnt := range rs3s.shadows {
+		if clnt.readSample <= 0 || rand.Float64()*100 >= clnt.readSample {
+			continue
+		}
+		remote := clnt.EndpointURL().Host + SlashSeparator + clnt.Bucket
+		select {
+		case clnt.readSlots <- struct{}{}:
+		default:
+			shadowRequestsTotal.WithLabelValues("getobject", remote, "dropped").Inc()
+			continue
+		}
+		go func(clnt bucketClient) {
+			defer func() { <-clnt.readSlots }()
+
+			start := time.Now()
+			err := clnt.readSampled(object, ranged, startOffset, length, o)
+			shadowReadDuration.WithLabelValues(remote).Observe(time.Since(start).Seconds())
+
+			result := "success"
+			if err != nil {
+				result = "error"
+				ctx := newBackgroundContext("ShadowGetObject", "", object)
+				logger.LogIf(remoteContext(ctx, clnt), fmt.Errorf("shadow remote %s: getobject failed: %v", remote, err))
+			}
+			shadowRequestsTotal.WithLabelValues("getobject", remote, result).Inc()
+		}(clnt)
+	}
+}
+
+// readSampled - reads object from the shadow remote and discards it.
+func (clnt bucketClient) readSampled(object string, ranged bool, startOffset, length int64, o ObjectOptions) error {
+	opts := miniogo.GetObjectOptions{}
+	opts.ServerSideEncryption = o.ServerSideEncryption
+	if ranged {
+		if err := opts.SetRange(startOffset, startOffset+length-1); err != nil {
+			return err
+		}
+	}
+	reader, _, _, err := clnt.GetObject(clnt.Bucket, clnt.remoteKey(object), opts)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(ioutil.Discard, reader)
+	return err
+}
+
+// shadowUploadID - returns the id of a multipart upload on the shadow
+// remote index, uploadIDs holds the ids on the other remotes first.
+func (rs3s mirrorConfig) shadowUploadID(uploadIDs []string, index int) (string, error) {
+	if id := uploadIDs[len(rs3s.clnts)+index]; id != "" {
+		return id, nil
+	}
+	return "", errShadowNoUpload
+}
+
+// shadowFeed - passes an upload body to a shadow remote without ever
+// blocking or failing the writer, up to shadowMaxBuffer is buffered.
+type shadowFeed struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	// err is returned once buf is drained, io.EOF after the whole
+	// body was written.
+	err error
+}
+
+func newShadowFeed() *shadowFeed {
+	f := &shadowFeed{}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *shadowFeed) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return len(p), nil
+	}
+	if f.buf.Len()+len(p) > shadowMaxBuffer {
+		f.buf.Reset()
+		f.err = errShadowLagging
+	} else {
+		f.buf.Write(p)
+	}
+	f.cond.Signal()
+	return len(p), nil
+}
+
+func (f *shadowFeed) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.buf.Len() == 0 && f.err == nil {
+		f.cond.Wait()
+	}
+	if f.buf.Len() > 0 {
+		return f.buf.Read(p)
+	}
+	return 0, f.err
+}
+
+// CloseWithError - ends the body with err, io.EOF if err is nil. Data
+// written afterwards is discarded.
+func (f *shadowFeed) CloseWithError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		if err == nil {
+			err = io.EOF
+		}
+		f.err = err
+	}
+	f.cond.Signal()
+}
+
+// shadowFeeds - duplicates an upload body to the shadow remotes.
+type shadowFeeds []*shadowFeed
+
+func newShadowFeeds(n int) shadowFeeds {
+	feeds := make(shadowFeeds, n)
+	for i := range feeds {
+		feeds[i] = newShadowFeed()
+	}
+	return feeds
+}
+
+// tee - returns a reader of r which writes what it reads to the feeds.
+func (feeds shadowFeeds) tee(r io.Reader) io.Reader {
+	if len(feeds) == 0 {
+		return r
+	}
+	return io.TeeReader(r, feeds)
+}
+
+func (feeds shadowFeeds) Write(p []byte) (int, error) {
+	for _, f := range feeds {
+		f.Write(p)
+	}
+	return len(p), nil
+}
+
+// CloseWithError - ends the body of all feeds with err.
+func (feeds shadowFeeds) CloseWithError(err error) {
+	for _, f := range feeds {
+		f.CloseWithError(err)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	miniogo "github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+)
+
+func TestShadowFeeds(t *testing.T) {
+	data := newFakeObjectData(1024 * 1024)
+	feeds := newShadowFeeds(2)
+
+	results := make(chan []byte, 2)
+	for _, f := range feeds {
+		go func(f *shadowFeed) {
+			b, err := ioutil.ReadAll(f)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- b
+		}(f)
+	}
+	if _, err := io.Copy(ioutil.Discard, feeds.tee(bytes.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	feeds.CloseWithError(nil)
+	for range feeds {
+		if b := <-results; !bytes.Equal(b, data) {
+			t.Fatalf("expected %d bytes, got %d", len(data), len(b))
+		}
+	}
+}
+
+func TestShadowFeedErrors(t *testing.T) {
+	// A shadow which does not keep up is abandoned, the writer never
+	// blocks.
+	f := newShadowFeed()
+	chunk := make([]byte, 1024*1024)
+	for i := 0; i <= shadowMaxBuffer/len(chunk); i++ {
+		if n, err := f.Write(chunk); n != len(chunk) || err != nil {
+			t.Fatalf("expected %d, <nil>, got %d, %v", len(chunk), n, err)
+		}
+	}
+	if _, err := f.Read(chunk); err != errShadowLagging {
+		t.Fatalf("expected %v, got %v", errShadowLagging, err)
+	}
+
+	// The error of a failed upload is passed once the buffered data
+	// was read.
+	errFailed := errors.New("upload failed")
+	f = newShadowFeed()
+	f.Write([]byte("data"))
+	f.CloseWithError(errFailed)
+	b, err := ioutil.ReadAll(f)
+	if err != errFailed || string(b) != "data" {
+		t.Fatalf("expected data, %v, got %q, %v", errFailed, b, err)
+	}
+
+	// Writes after the shadow stopped reading are discarded.
+	f = newShadowFeed()
+	f.CloseWithError(io.ErrClosedPipe)
+	f.Write(chunk)
+	if f.buf.Len() != 0 {
+		t.Fatalf("expected writes to be discarded, %d bytes buffered", f.buf.Len())
+	}
+}
+
+func TestSampleReads(t *testing.T) {
+	ranges := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges <- r.Header.Get("Range")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := miniogo.NewWithOptions(u.Host, &miniogo.Options{
+		Creds:        credentials.NewStaticV4("radioaccesskey", "radiosecretkey", ""),
+		Region:       "us-east-1",
+		BucketLookup: miniogo.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shadow := bucketClient{Core: &miniogo.Core{Client: c}, Bucket: "bucket", shadow: true,
+		readSlots: make(chan struct{}, shadowMaxSampledReads)}
+	rs3s := mirrorConfig{shadows: []bucketClient{shadow}}
+
+	// Never sampled.
+	rs3s.sampleReads("object", false, 0, 4, ObjectOptions{})
+
+	rs3s.shadows[0].readSample = 100
+	rs3s.sampleReads("object", true, 1, 2, ObjectOptions{})
+	select {
+	case r := <-ranges:
+		if r != "bytes=1-2" {
+			t.Fatalf("expected range bytes=1-2, got %q", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a sampled read")
+	}
+	select {
+	case r := <-ranges:
+		t.Fatalf("expected a single sampled read, got another with range %q", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
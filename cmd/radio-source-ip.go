@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio/pkg/handlers"
+)
+
+// globalTrustedProxies - proxies whose X-Forwarded-For, X-Real-IP and
+// Forwarded headers are honored, the headers of all clients are honored
+// if empty.
+var globalTrustedProxies []*net.IPNet
+
+// isTrustedProxy - returns true if ip is a trusted proxy.
+func isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range globalTrustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwardedIP - parses an address of a forwarding header, such as
+// 192.0.2.10, "[2001:db8::10]:4711" or 192.0.2.10:4711.
+func parseForwardedIP(addr string) net.IP {
+	addr = strings.Trim(strings.TrimSpace(addr), `"`)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
+
+// forwardedFor - returns the for= addresses of the Forwarded headers h.
+func forwardedFor(h []string) []string {
+	var addrs []string
+	for _, elem := range strings.Split(strings.Join(h, ","), ",") {
+		for _, pair := range strings.Split(elem, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+				addrs = append(addrs, pair[4:])
+			}
+		}
+	}
+	return addrs
+}
+
+// getSourceIP - returns the client address of r. Once trusted proxies are
+// configured the forwarding headers are only honored on requests of
+// trusted proxies, and the addresses appended by trusted proxies are
+// skipped to find the client, the first untrusted address.
+func getSourceIP(r *http.Request) string {
+	if len(globalTrustedProxies) == 0 {
+		return handlers.GetSourceIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	client := net.ParseIP(host)
+	if client == nil || !isTrustedProxy(client) {
+		return host
+	}
+
+	var chain []string
+	if h := r.Header["X-Forwarded-For"]; len(h) > 0 {
+		chain = strings.Split(strings.Join(h, ","), ",")
+	} else if h := r.Header.Get("X-Real-Ip"); h != "" {
+		chain = []string{h}
+	} else {
+		chain = forwardedFor(r.Header["Forwarded"])
+	}
+	for i := len(chain) - 1; i >= 0 && isTrustedProxy(client); i-- {
+		ip := parseForwardedIP(chain[i])
+		if ip == nil {
+			// Garbage, such as an obfuscated identifier, the last
+			// trusted proxy is the best known client.
+			break
+		}
+		client = ip
+	}
+	return client.String()
+}
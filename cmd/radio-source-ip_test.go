@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetSourceIP(t *testing.T) {
+	defer func(proxies []*net.IPNet) { globalTrustedProxies = proxies }(globalTrustedProxies)
+
+	trusted, err := parseIPNets([]string{"10.0.0.0/8", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		proxies    []*net.IPNet
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		// Without trusted proxies the headers of all clients are honored.
+		{nil, "192.0.2.1:1234", nil, "192.0.2.1"},
+		{nil, "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		// Headers of untrusted clients are ignored.
+		{trusted, "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "192.0.2.1"},
+		{trusted, "192.0.2.1:1234", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "192.0.2.1"},
+		{trusted, "10.0.0.2:1234", nil, "10.0.0.2"},
+		{trusted, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{trusted, "[2001:db8::1]:1234", http.Header{"X-Forwarded-For": {"2001:db8::7"}}, "2001:db8::7"},
+		// Addresses spoofed by the client ahead of the proxies are skipped.
+		{trusted, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"1.2.3.4, 198.51.100.7, 10.0.0.3"}}, "198.51.100.7"},
+		{trusted, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"1.2.3.4", "198.51.100.7,10.0.0.3"}}, "198.51.100.7"},
+		{trusted, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"10.0.0.4, 10.0.0.3"}}, "10.0.0.4"},
+		{trusted, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"unknown, 10.0.0.3"}}, "10.0.0.3"},
+		{trusted, "10.0.0.2:1234", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "198.51.100.7"},
+		{trusted, "10.0.0.2:1234", http.Header{"Forwarded": {`for=1.2.3.4, for="[2001:db8::7]:4711";proto=https`}}, "2001:db8::7"},
+		{trusted, "10.0.0.2:1234", http.Header{"Forwarded": {"For=198.51.100.7:4711;by=10.0.0.2"}}, "198.51.100.7"},
+	}
+	for i, testCase := range testCases {
+		globalTrustedProxies = testCase.proxies
+		r := httptest.NewRequest(http.MethodGet, "/bucket/object", nil)
+		r.RemoteAddr = testCase.remoteAddr
+		for k, v := range testCase.header {
+			r.Header[k] = v
+		}
+		if ip := getSourceIP(r); ip != testCase.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.expected, ip)
+		}
+	}
+}
@@ -109,6 +109,8 @@ func printCLIAccessMsg(endPoint string, alias string) {
 	// Configure 'mc', following block prints platform specific information for minio client.
 	if color.IsTerminal() {
 		logStartupMessage(color.Blue("\nCommand-line Access: ") + mcQuickStartGuide)
+		globalLocalCredsMu.RLock()
+		defer globalLocalCredsMu.RUnlock()
 		for _, cred := range globalLocalCreds {
 			if runtime.GOOS == globalWindowsOSName {
 				mcMessage := fmt.Sprintf("$ mc.exe config host add %s %s %s %s", alias,
@@ -133,6 +135,10 @@ func printRadioStartupMessage(apiEndPoints []string) {
 	}
 	// Prints credential.
 	printRadioCommonMsg(strippedAPIEndpoints)
+	if len(globalListenEndpoints) > 0 {
+		listenStr := strings.Join(globalListenEndpoints, "  ")
+		logStartupMessage(color.Blue("Listeners: ") + color.Bold(fmt.Sprintf(getFormatStr(len(listenStr), 1), listenStr)))
+	}
 
 	// Prints `mc` cli configuration message chooses
 	// first endpoint as default.
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Depth of the prefixes whose sync state is tracked, objects in deeper
+// directories are tracked by their ancestor at this depth.
+const syncStateDepth = 2
+
+// syncPrefix - returns the prefix whose sync state tracks object, objects
+// directly below it or below it at any depth if recursive is set.
+func syncPrefix(object string) (prefix string, recursive bool) {
+	end := 0
+	for depth := 0; depth < syncStateDepth; depth++ {
+		i := strings.Index(object[end:], SlashSeparator)
+		if i < 0 {
+			return object[:end], false
+		}
+		end += i + 1
+	}
+	return object[:end], true
+}
+
+// isRecursiveSyncPrefix - returns true if prefix tracks the objects below
+// it at any depth.
+func isRecursiveSyncPrefix(prefix string) bool {
+	return strings.Count(prefix, SlashSeparator) == syncStateDepth
+}
+
+// syncState - tracks which prefixes of a mirror bucket may have diverged
+// across the remotes since they were last healed, so that heals can skip
+// the others. Every write through radio XORs a random value into the
+// rolling hash of its prefix on each remote it reached, the hashes of a
+// prefix differ once a write missed a remote. Only prefixes with differing
+// hashes are kept. The state lives in memory, writes made to the remotes
+// directly are not tracked.
+type syncState struct {
+	mu sync.Mutex
+	// synced is set once a heal of the whole bucket completed, only
+	// then prefixes without hashes are known to be in sync.
+	synced bool
+	hashes map[string][]uint64
+}
+
+func newSyncState() *syncState {
+	return &syncState{hashes: make(map[string][]uint64)}
+}
+
+// record - records a write of object which reached the remotes in ok.
+func (s *syncState) record(object string, ok []bool) {
+	if s == nil {
+		return
+	}
+	prefix, _ := syncPrefix(object)
+	r := rand.Uint64()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hashes := s.hashes[prefix]
+	if hashes == nil {
+		hashes = make([]uint64, len(ok))
+	}
+	for index := range ok {
+		if ok[index] {
+			hashes[index] ^= r
+		}
+	}
+	if equalHashes(hashes) {
+		delete(s.hashes, prefix)
+		return
+	}
+	s.hashes[prefix] = hashes
+}
+
+func equalHashes(hashes []uint64) bool {
+	for _, h := range hashes {
+		if h != hashes[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// diverged - returns the tracked prefixes holding objects below prefix
+// which may have diverged and a copy of their hashes, false if the whole
+// bucket was never healed.
+func (s *syncState) diverged(prefix string) (map[string][]uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefixes := make(map[string][]uint64)
+	for p, hashes := range s.hashes {
+		switch {
+		case strings.HasPrefix(p, prefix):
+		case strings.HasPrefix(prefix, p) &&
+			(isRecursiveSyncPrefix(p) || !strings.Contains(prefix[len(p):], SlashSeparator)):
+			// prefix is part of p.
+		default:
+			continue
+		}
+		prefixes[p] = append([]uint64(nil), hashes...)
+	}
+	return prefixes, s.synced
+}
+
+// healed - forgets the prefixes healed since their hashes were taken,
+// prefixes written to meanwhile are kept. whole marks a heal of the whole
+// bucket.
+func (s *syncState) healed(prefixes map[string][]uint64, whole bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p, hashes := range prefixes {
+		current, ok := s.hashes[p]
+		if !ok {
+			continue
+		}
+		same := true
+		for index := range hashes {
+			if hashes[index] != current[index] {
+				same = false
+			}
+		}
+		if same {
+			delete(s.hashes, p)
+		}
+	}
+	if whole {
+		s.synced = true
+	}
+}
+
+// sortedSyncPrefixes - returns the prefixes in key order.
+func sortedSyncPrefixes(prefixes map[string][]uint64) []string {
+	sorted := make([]string, 0, len(prefixes))
+	for p := range prefixes {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// writeOK - returns the remotes a write reached.
+func writeOK(errs []error) []bool {
+	ok := make([]bool, len(errs))
+	for index, err := range errs {
+		ok[index] = err == nil
+	}
+	return ok
+}
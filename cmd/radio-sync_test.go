@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Tests the prefixes tracking the sync state of objects.
+func TestSyncPrefix(t *testing.T) {
+	testCases := []struct {
+		object    string
+		prefix    string
+		recursive bool
+	}{
+		{"a.jpg", "", false},
+		{"photos/a.jpg", "photos/", false},
+		{"photos/2019/a.jpg", "photos/2019/", true},
+		{"photos/2019/05/a.jpg", "photos/2019/", true},
+		{"photos/2019/", "photos/2019/", true},
+	}
+	for i, testCase := range testCases {
+		prefix, recursive := syncPrefix(testCase.object)
+		if prefix != testCase.prefix || recursive != testCase.recursive {
+			t.Errorf("Test %d: expected %q %t, got %q %t", i+1, testCase.prefix, testCase.recursive, prefix, recursive)
+		}
+		if isRecursiveSyncPrefix(prefix) != recursive {
+			t.Errorf("Test %d: expected %q to be recursive %t", i+1, prefix, recursive)
+		}
+	}
+}
+
+// Tests that only prefixes with writes missing a remote are diverged, and
+// that heals keep prefixes written to meanwhile.
+func TestSyncState(t *testing.T) {
+	s := newSyncState()
+	s.record("photos/a.jpg", []bool{true, true})
+	s.record("photos/2019/05/a.jpg", []bool{true, false})
+	s.record("docs/a.txt", []bool{false, true})
+	s.record("b.txt", []bool{false, false})
+
+	prefixes, synced := s.diverged("")
+	if synced {
+		t.Fatal("expected a bucket never healed not to be synced")
+	}
+	if got := sortedSyncPrefixes(prefixes); !reflect.DeepEqual(got, []string{"docs/", "photos/2019/"}) {
+		t.Fatalf("unexpected diverged prefixes %v", got)
+	}
+
+	testCases := []struct {
+		prefix   string
+		prefixes []string
+	}{
+		{"photos/", []string{"photos/2019/"}},
+		{"photos/2019/05/", []string{"photos/2019/"}},
+		{"docs/a", []string{"docs/"}},
+		{"docs/sub/", nil},
+		{"music/", nil},
+	}
+	for i, testCase := range testCases {
+		prefixes, _ := s.diverged(testCase.prefix)
+		if got := sortedSyncPrefixes(prefixes); len(got) != len(testCase.prefixes) ||
+			(len(got) > 0 && !reflect.DeepEqual(got, testCase.prefixes)) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.prefixes, got)
+		}
+	}
+
+	// Writes missing different remotes don't cancel out.
+	s.record("docs/b.txt", []bool{true, false})
+	healedPrefixes, _ := s.diverged("")
+	s.record("photos/2019/b.jpg", []bool{false, true})
+	s.healed(healedPrefixes, true)
+
+	prefixes, synced = s.diverged("")
+	if !synced {
+		t.Fatal("expected the bucket to be synced after a whole heal")
+	}
+	if got := sortedSyncPrefixes(prefixes); !reflect.DeepEqual(got, []string{"photos/2019/"}) {
+		t.Fatalf("expected only the prefix written to during the heal, got %v", got)
+	}
+}
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/policy"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	// Interval between the listings measuring the bytes stored by the
+	// tenants with a quota.
+	tenantUsageInterval = 15 * time.Minute
+
+	tenantUsageListSize = 1000
+)
+
+// globalTenants - the tenants owning buckets and credentials, nil unless
+// configured.
+var globalTenants *tenantRegistry
+
+// tenantConfig - a tenant owning the mirror and erasure buckets naming
+// it, which are only accessed with its AccessKeys, and its credentials
+// only access its buckets.
+type tenantConfig struct {
+	Name       string   `yaml:"name"`
+	AccessKeys []string `yaml:"access_keys"`
+	// Quota limits the bytes stored in the buckets of the tenant, such
+	// as 500GiB, unlimited if empty.
+	Quota string `yaml:"quota"`
+	// RequestsPerSecond limits the S3 requests to the buckets of the
+	// tenant served by each server, unlimited if 0.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+}
+
+// validateTenantsConfig - validates the tenants, refs maps the paths of
+// the tenant fields of buckets to the tenants they name.
+func validateTenantsConfig(errs *radioConfigErrors, path string, tenants []tenantConfig, refs map[string]string) {
+	names := make(map[string]string)
+	accessKeys := make(map[string]string)
+	for i, t := range tenants {
+		tpath := fmt.Sprintf("%s[%d]", path, i)
+		if t.Name == "" {
+			errs.add(tpath+".name", "required for a tenant")
+		} else if prev, ok := names[t.Name]; ok {
+			errs.add(tpath+".name", "tenant %q is already configured at %s", t.Name, prev)
+		} else {
+			names[t.Name] = tpath
+		}
+		if len(t.AccessKeys) == 0 {
+			errs.add(tpath+".access_keys", "at least one access key is required")
+		}
+		for _, accessKey := range t.AccessKeys {
+			if prev, ok := accessKeys[accessKey]; ok {
+				errs.add(tpath+".access_keys", "access key %s already belongs to %s", accessKey, prev)
+			}
+			accessKeys[accessKey] = tpath
+		}
+		if t.Quota != "" {
+			if _, err := humanize.ParseBytes(t.Quota); err != nil {
+				errs.add(tpath+".quota", "%v", err)
+			}
+		}
+		if t.RequestsPerSecond < 0 {
+			errs.add(tpath+".requests_per_second", "must not be negative")
+		}
+	}
+
+	paths := make([]string, 0, len(refs))
+	for refPath := range refs {
+		paths = append(paths, refPath)
+	}
+	sort.Strings(paths)
+	for _, refPath := range paths {
+		if name := refs[refPath]; name != "" {
+			if _, ok := names[name]; !ok {
+				errs.add(refPath, "unknown tenant %q", name)
+			}
+		}
+	}
+}
+
+// TenantStatus - the limits and usage of a tenant on this server.
+type TenantStatus struct {
+	Name              string   `json:"name"`
+	Buckets           []string `json:"buckets"`
+	Quota             uint64   `json:"quota,omitempty"`
+	RequestsPerSecond float64  `json:"requestsPerSecond,omitempty"`
+	// Used is the bytes stored at the last listing plus those uploaded
+	// through this server since, as of Measured.
+	Used     uint64    `json:"used"`
+	Measured time.Time `json:"measured,omitempty"`
+	// Throttled counts the requests rejected with SlowDown, QuotaExceeded
+	// the uploads rejected by the quota.
+	Throttled     uint64 `json:"throttled"`
+	QuotaExceeded uint64 `json:"quotaExceeded"`
+}
+
+// TenantsStatus - the tenants of this server.
+type TenantsStatus struct {
+	Tenants []TenantStatus `json:"tenants"`
+}
+
+// tenant - the limits and usage of a tenant.
+type tenant struct {
+	name    string
+	buckets []string
+	quota   uint64
+	limiter *rateLimiter
+
+	mu sync.Mutex
+	// used is the bytes stored at the last listing, admitted the bytes
+	// of the uploads admitted since it started.
+	used          uint64
+	admitted      uint64
+	measured      time.Time
+	throttled     uint64
+	quotaExceeded uint64
+}
+
+// admitUpload - counts an upload of size bytes, returns false if it
+// exceeds the quota.
+func (t *tenant) admitUpload(size uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.quota > 0 && !t.measured.IsZero() && t.used+t.admitted+size > t.quota {
+		t.quotaExceeded++
+		return false
+	}
+	t.admitted += size
+	return true
+}
+
+// refundUpload - uncounts an admitted upload of size bytes which failed.
+func (t *tenant) refundUpload(size uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Uploads admitted before the last listing were uncounted by it.
+	if size > t.admitted {
+		size = t.admitted
+	}
+	t.admitted -= size
+}
+
+// tenantRegistry - the tenants by access key and bucket. Credentials and
+// buckets of no tenant form a namespace of their own.
+type tenantRegistry struct {
+	tenants     []*tenant
+	byAccessKey map[string]*tenant
+	byBucket    map[string]*tenant
+}
+
+// newTenantRegistry - returns the tenants of rconfig, nil if none.
+func newTenantRegistry(rconfig radioConfig) (*tenantRegistry, error) {
+	if len(rconfig.Tenants) == 0 {
+		return nil, nil
+	}
+	reg := &tenantRegistry{
+		byAccessKey: make(map[string]*tenant),
+		byBucket:    make(map[string]*tenant),
+	}
+	byName := make(map[string]*tenant)
+	for _, tcfg := range rconfig.Tenants {
+		t := &tenant{name: tcfg.Name}
+		if tcfg.Quota != "" {
+			quota, err := humanize.ParseBytes(tcfg.Quota)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %s: %v", tcfg.Name, err)
+			}
+			t.quota = quota
+		}
+		if tcfg.RequestsPerSecond > 0 {
+			t.limiter = &rateLimiter{rate: tcfg.RequestsPerSecond}
+		}
+		for _, accessKey := range tcfg.AccessKeys {
+			reg.byAccessKey[accessKey] = t
+		}
+		reg.tenants = append(reg.tenants, t)
+		byName[t.name] = t
+	}
+	own := func(bucket, name string) error {
+		if name == "" {
+			return nil
+		}
+		t, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("bucket %s: unknown tenant %q", bucket, name)
+		}
+		t.buckets = append(t.buckets, bucket)
+		reg.byBucket[bucket] = t
+		return nil
+	}
+	for _, mcfg := range rconfig.Mirror {
+		if err := own(mcfg.Local.Bucket, mcfg.Tenant); err != nil {
+			return nil, err
+		}
+	}
+	for _, ecfg := range rconfig.Erasure {
+		if err := own(ecfg.Local.Bucket, ecfg.Tenant); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// authorize - returns ErrAccessDenied unless the bucket of auth belongs
+// to the tenant of its access key, and ErrTenantQuotaExceeded for uploads
+// beyond the quota of the tenant. Requests for no bucket, such as
+// ListBuckets, and upload tokens, scoped to a bucket by the admin, are
+// allowed.
+func (reg *tenantRegistry) authorize(r *http.Request, auth HookAuth) APIErrorCode {
+	if reg == nil || auth.Bucket == "" {
+		return ErrNone
+	}
+	t := reg.byBucket[auth.Bucket]
+	if !strings.HasPrefix(auth.AccessKey, uploadTokenAccessKeyPrefix) && reg.byAccessKey[auth.AccessKey] != t {
+		return ErrAccessDenied
+	}
+	if t != nil && isTenantUpload(r, auth) && !t.admitUpload(uploadSize(r)) {
+		return ErrTenantQuotaExceeded
+	}
+	return ErrNone
+}
+
+// refund - uncounts the upload of r authorized for auth from the quota of
+// its tenant, once the upload was rejected or failed.
+func (reg *tenantRegistry) refund(r *http.Request, auth HookAuth) {
+	if reg == nil || auth.Bucket == "" {
+		return
+	}
+	if t := reg.byBucket[auth.Bucket]; t != nil && isTenantUpload(r, auth) {
+		t.refundUpload(uploadSize(r))
+	}
+}
+
+// filterBuckets - returns the buckets of the tenant of accessKey.
+func (reg *tenantRegistry) filterBuckets(accessKey string, buckets []BucketInfo) []BucketInfo {
+	if reg == nil {
+		return buckets
+	}
+	t := reg.byAccessKey[accessKey]
+	filtered := buckets[:0]
+	for _, bucket := range buckets {
+		if reg.byBucket[bucket.Name] == t {
+			filtered = append(filtered, bucket)
+		}
+	}
+	return filtered
+}
+
+// isTenantUpload - returns true if r uploads object data counted by the
+// quota of a tenant once authorized for auth.
+func isTenantUpload(r *http.Request, auth HookAuth) bool {
+	return auth.Action == string(policy.PutObjectAction) && isUploadRequest(r, auth.Object)
+}
+
+// isUploadRequest - returns true if r uploads object data.
+func isUploadRequest(r *http.Request, object string) bool {
+	return (r.Method == http.MethodPut && object != "") || isRequestPostPolicySignatureV4(r)
+}
+
+// uploadSize - returns the size of the object data uploaded by r.
+func uploadSize(r *http.Request) uint64 {
+	if size, err := strconv.ParseUint(r.Header.Get(xhttp.AmzDecodedContentLength), 10, 64); err == nil {
+		return size
+	}
+	if r.ContentLength > 0 {
+		return uint64(r.ContentLength)
+	}
+	return 0
+}
+
+// admit - returns ErrSlowDown for requests beyond the request rate of
+// the tenant owning the bucket of r, before they are authenticated.
+func (reg *tenantRegistry) admit(r *http.Request) APIErrorCode {
+	if reg == nil {
+		return ErrNone
+	}
+	bucket, _ := request2BucketObjectName(r)
+	t, ok := reg.byBucket[bucket]
+	if !ok || t.limiter == nil {
+		return ErrNone
+	}
+	if !t.limiter.allow(1, time.Now()) {
+		t.mu.Lock()
+		t.throttled++
+		t.mu.Unlock()
+		return ErrSlowDown
+	}
+	return ErrNone
+}
+
+// status - returns the limits and usage of the tenants.
+func (reg *tenantRegistry) status() TenantsStatus {
+	status := TenantsStatus{Tenants: []TenantStatus{}}
+	if reg == nil {
+		return status
+	}
+	for _, t := range reg.tenants {
+		ts := TenantStatus{
+			Name:    t.name,
+			Buckets: append([]string{}, t.buckets...),
+			Quota:   t.quota,
+		}
+		if t.limiter != nil {
+			ts.RequestsPerSecond = t.limiter.rate
+		}
+		t.mu.Lock()
+		ts.Used = t.used + t.admitted
+		ts.Measured = t.measured
+		ts.Throttled = t.throttled
+		ts.QuotaExceeded = t.quotaExceeded
+		t.mu.Unlock()
+		status.Tenants = append(status.Tenants, ts)
+	}
+	return status
+}
+
+// measureTenantUsage - lists the buckets of t and replaces the bytes it
+// stores. Uploads admitted while listing are counted again until the next
+// listing, so that none are missed.
+func (l *radioObjects) measureTenantUsage(ctx context.Context, t *tenant) error {
+	t.mu.Lock()
+	admitted := t.admitted
+	t.mu.Unlock()
+
+	var used uint64
+	for _, bucket := range t.buckets {
+		marker := ""
+		for {
+			loi, err := l.ListObjects(ctx, bucket, "", marker, "", tenantUsageListSize)
+			if err != nil {
+				return fmt.Errorf("bucket %s: %v", bucket, err)
+			}
+			for _, obj := range loi.Objects {
+				used += uint64(obj.Size)
+			}
+			if !loi.IsTruncated {
+				break
+			}
+			marker = loi.NextMarker
+		}
+	}
+
+	t.mu.Lock()
+	t.used = used
+	// Uploads refunded while listing may have been uncounted already.
+	if admitted > t.admitted {
+		admitted = t.admitted
+	}
+	t.admitted -= admitted
+	t.measured = UTCNow()
+	t.mu.Unlock()
+	return nil
+}
+
+// runTenantUsage - measures the bytes stored by the tenants with a quota
+// at startup and every tenantUsageInterval.
+func (l *radioObjects) runTenantUsage(reg *tenantRegistry) {
+	for {
+		for _, t := range reg.tenants {
+			if t.quota == 0 {
+				continue
+			}
+			ctx := logger.WithTags(newBackgroundContext("TenantUsage", "", ""), "tenant", t.name)
+			if err := l.measureTenantUsage(ctx, t); err != nil {
+				logger.LogIf(ctx, fmt.Errorf("usage of tenant %s: %v", t.name, err))
+			}
+		}
+		time.Sleep(tenantUsageInterval)
+	}
+}
+
+// postPolicyAccessKey - returns the access key signing the policy of a
+// POST upload.
+func postPolicyAccessKey(formValues http.Header) string {
+	if accessKey := formValues.Get(xhttp.AmzAccessKeyID); accessKey != "" {
+		return accessKey
+	}
+	credential := formValues.Get(xhttp.AmzCredential)
+	if i := strings.IndexByte(credential, '/'); i >= 0 {
+		return credential[:i]
+	}
+	return credential
+}
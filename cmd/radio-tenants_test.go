@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/minio/pkg/policy"
+	xhttp "github.com/minio/radio/cmd/http"
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateTenantsConfig(t *testing.T) {
+	testCases := []struct {
+		tenants []tenantConfig
+		refs    map[string]string
+		errs    []string
+	}{
+		{
+			tenants: []tenantConfig{{Name: "a", AccessKeys: []string{"ka"}, Quota: "1TiB", RequestsPerSecond: 10}, {Name: "b", AccessKeys: []string{"kb"}}},
+			refs:    map[string]string{"mirror[0].tenant": "a", "mirror[1].tenant": "", "erasure[0].tenant": "b"},
+		},
+		{
+			tenants: []tenantConfig{{AccessKeys: []string{"ka"}}, {Name: "b"}},
+			errs:    []string{"tenants[0].name", "tenants[1].access_keys"},
+		},
+		{
+			tenants: []tenantConfig{{Name: "a", AccessKeys: []string{"ka"}}, {Name: "a", AccessKeys: []string{"ka"}}},
+			errs:    []string{"tenants[1].name", "tenants[1].access_keys"},
+		},
+		{
+			tenants: []tenantConfig{{Name: "a", AccessKeys: []string{"ka"}, Quota: "lots", RequestsPerSecond: -1}},
+			refs:    map[string]string{"mirror[0].tenant": "c"},
+			errs:    []string{"tenants[0].quota", "tenants[0].requests_per_second", "mirror[0].tenant"},
+		},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateTenantsConfig(&errs, "tenants", testCase.tenants, testCase.refs)
+		if len(errs) != len(testCase.errs) {
+			t.Fatalf("Test %d: expected %d errors, got %v", i+1, len(testCase.errs), errs)
+		}
+		for j, err := range errs {
+			if !strings.HasPrefix(err, testCase.errs[j]+":") {
+				t.Errorf("Test %d: expected an error at %s, got %s", i+1, testCase.errs[j], err)
+			}
+		}
+	}
+}
+
+func newTestTenantRegistry(t *testing.T) *tenantRegistry {
+	var rconfig radioConfig
+	err := yaml.Unmarshal([]byte(`{
+  "tenants": [
+    {"name": "team-a", "access_keys": ["keya", "keya2"], "quota": "1KiB"},
+    {"name": "team-b", "access_keys": ["keyb"], "requests_per_second": 2}
+  ],
+  "mirror": [
+    {"tenant": "team-a", "local": {"bucket": "a1"}},
+    {"tenant": "team-a", "local": {"bucket": "a2"}},
+    {"tenant": "team-b", "local": {"bucket": "b1"}},
+    {"local": {"bucket": "shared"}}
+  ],
+  "erasure": [
+    {"tenant": "team-b", "local": {"bucket": "b2"}}
+  ]
+}`), &rconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := newTenantRegistry(rconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestTenantAuthorize(t *testing.T) {
+	reg := newTestTenantRegistry(t)
+	get := httptest.NewRequest(http.MethodGet, "/bucket/object", nil)
+
+	testCases := []struct {
+		accessKey, bucket string
+		s3Err             APIErrorCode
+	}{
+		{"keya", "a1", ErrNone},
+		{"keya2", "a2", ErrNone},
+		{"keya", "b1", ErrAccessDenied},
+		{"keya", "shared", ErrAccessDenied},
+		{"keyb", "b2", ErrNone},
+		{"keyb", "a1", ErrAccessDenied},
+		// Credentials of no tenant only access the buckets of no tenant.
+		{"other", "shared", ErrNone},
+		{"other", "a1", ErrAccessDenied},
+		{"keya", "", ErrNone},
+		{uploadTokenAccessKeyPrefix + "device-1", "b1", ErrNone},
+	}
+	for i, testCase := range testCases {
+		auth := HookAuth{AccessKey: testCase.accessKey, Action: string(policy.GetObjectAction), Bucket: testCase.bucket, Object: "object"}
+		if s3Err := reg.authorize(get, auth); s3Err != testCase.s3Err {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.s3Err, s3Err)
+		}
+	}
+
+	var nilReg *tenantRegistry
+	if s3Err := nilReg.authorize(get, HookAuth{AccessKey: "keya", Bucket: "b1"}); s3Err != ErrNone {
+		t.Errorf("expected all requests to be allowed without tenants, got %v", s3Err)
+	}
+
+	buckets := []BucketInfo{{Name: "a1"}, {Name: "a2"}, {Name: "b1"}, {Name: "b2"}, {Name: "shared"}}
+	var names []string
+	for _, bucket := range reg.filterBuckets("keyb", append([]BucketInfo{}, buckets...)) {
+		names = append(names, bucket.Name)
+	}
+	if strings.Join(names, ",") != "b1,b2" {
+		t.Errorf("expected the buckets of team-b, got %v", names)
+	}
+	if filtered := nilReg.filterBuckets("keyb", buckets); len(filtered) != len(buckets) {
+		t.Errorf("expected all buckets without tenants, got %v", filtered)
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	reg := newTestTenantRegistry(t)
+	put := func(size int) APIErrorCode {
+		r := httptest.NewRequest(http.MethodPut, "/a1/object", strings.NewReader(strings.Repeat("a", size)))
+		return reg.authorize(r, HookAuth{AccessKey: "keya", Action: string(policy.PutObjectAction), Bucket: "a1", Object: "object"})
+	}
+
+	// Uploads are admitted until the usage was first measured.
+	if s3Err := put(2048); s3Err != ErrNone {
+		t.Fatalf("expected the upload to be admitted before a listing, got %v", s3Err)
+	}
+	teamA := reg.byBucket["a1"]
+	teamA.mu.Lock()
+	teamA.used, teamA.admitted, teamA.measured = 512, 0, UTCNow()
+	teamA.mu.Unlock()
+
+	if s3Err := put(256); s3Err != ErrNone {
+		t.Fatalf("expected the upload within the quota to be admitted, got %v", s3Err)
+	}
+	if s3Err := put(512); s3Err != ErrTenantQuotaExceeded {
+		t.Fatalf("expected the upload beyond the quota to be rejected, got %v", s3Err)
+	}
+	// Streaming uploads are counted by their decoded length.
+	r := httptest.NewRequest(http.MethodPut, "/a1/object", strings.NewReader(strings.Repeat("a", 4096)))
+	r.Header.Set("X-Amz-Decoded-Content-Length", "256")
+	if s3Err := reg.authorize(r, HookAuth{AccessKey: "keya", Action: string(policy.PutObjectAction), Bucket: "a1", Object: "object"}); s3Err != ErrNone {
+		t.Fatalf("expected the streaming upload to be admitted, got %v", s3Err)
+	}
+
+	status := reg.status()
+	if status.Tenants[0].Used != 1024 || status.Tenants[0].QuotaExceeded != 1 || status.Tenants[0].Quota != 1024 {
+		t.Errorf("unexpected status %+v", status.Tenants[0])
+	}
+	// Tenants without a quota are not limited.
+	if !reg.byBucket["b1"].admitUpload(1 << 40) {
+		t.Error("expected uploads without a quota to be admitted")
+	}
+
+	// Failed uploads are uncounted, those uncounted by a listing already
+	// are not uncounted again.
+	reg.refund(r, HookAuth{AccessKey: "keya", Action: string(policy.PutObjectAction), Bucket: "a1", Object: "object"})
+	if status := reg.status(); status.Tenants[0].Used != 768 {
+		t.Errorf("expected the failed upload to be uncounted, got %d", status.Tenants[0].Used)
+	}
+	teamA.refundUpload(1024)
+	if status := reg.status(); status.Tenants[0].Used != 512 {
+		t.Errorf("expected the bytes stored at the listing, got %d", status.Tenants[0].Used)
+	}
+}
+
+// failingPutObjectLayer - an object layer failing uploads, once it called
+// onPut.
+type failingPutObjectLayer struct {
+	ObjectLayer
+	onPut func()
+}
+
+func (l failingPutObjectLayer) PutObject(ctx context.Context, bucket, object string, data *PutObjReader, opts ObjectOptions) (ObjectInfo, error) {
+	l.onPut()
+	return ObjectInfo{}, errors.New("remote unavailable")
+}
+
+func (l failingPutObjectLayer) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *PutObjReader, opts ObjectOptions) (PartInfo, error) {
+	l.onPut()
+	return PartInfo{}, errors.New("remote unavailable")
+}
+
+// Tests that uploads are counted against the quota of their tenant only
+// once they were authenticated, and uncounted once they failed.
+func TestTenantQuotaPut(t *testing.T) {
+	tenants, creds := globalTenants, globalLocalCreds
+	defer func() { globalTenants, globalLocalCreds = tenants, creds }()
+	globalTenants = newTestTenantRegistry(t)
+	cred, err := auth.CreateCredentials("keya", "keyasecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalLocalCreds = map[string]auth.Credentials{cred.AccessKey: cred}
+
+	teamA := globalTenants.byBucket["a1"]
+	used := func() uint64 {
+		return globalTenants.status().Tenants[0].Used
+	}
+	var usedOnPut uint64
+	api := objectAPIHandlers{
+		ObjectAPI: func() ObjectLayer {
+			return failingPutObjectLayer{onPut: func() { usedOnPut = used() }}
+		},
+		CacheAPI: func() CacheObjectLayer { return nil },
+	}
+	handlers := map[string]struct {
+		handler http.HandlerFunc
+		query   url.Values
+	}{
+		"PutObject":     {api.PutObjectHandler, nil},
+		"PutObjectPart": {api.PutObjectPartHandler, url.Values{"uploadId": {"upload"}, "partNumber": {"1"}}},
+	}
+	for name, h := range handlers {
+		u := url.URL{Scheme: "http", Host: "localhost:9000", Path: "/a1/object", RawQuery: h.query.Encode()}
+		for _, forged := range []bool{true, false} {
+			usedOnPut = 0
+			presigned, err := url.Parse(presignV4(http.MethodPut, u, cred, globalServerRegion, UTCNow(), time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if forged {
+				query := presigned.Query()
+				query.Set(xhttp.AmzSignature, strings.Repeat("0", 64))
+				presigned.RawQuery = query.Encode()
+			}
+			r := httptest.NewRequest(http.MethodPut, presigned.String(), strings.NewReader(strings.Repeat("a", 4096)))
+			w := httptest.NewRecorder()
+			h.handler(w, r)
+
+			if w.Code == http.StatusOK {
+				t.Fatalf("%s: expected the upload to fail", name)
+			}
+			if !forged && usedOnPut != 4096 {
+				t.Errorf("%s: expected the upload to be counted while it is in flight, got %d", name, usedOnPut)
+			}
+			if used() != 0 || teamA.quotaExceeded != 0 {
+				t.Errorf("%s: expected the failed upload to be uncounted, got %+v", name, globalTenants.status().Tenants[0])
+			}
+		}
+	}
+}
+
+func TestTenantAdmit(t *testing.T) {
+	reg := newTestTenantRegistry(t)
+	get := func(bucket string) APIErrorCode {
+		return reg.admit(httptest.NewRequest(http.MethodGet, "/"+bucket+"/object", nil))
+	}
+
+	// team-b serves 2 requests per second, with a second of burst.
+	var throttled int
+	for i := 0; i < 10; i++ {
+		if get("b1") == ErrSlowDown {
+			throttled++
+		}
+		if s3Err := get("a1"); s3Err != ErrNone {
+			t.Fatalf("expected the requests of team-a to be unlimited, got %v", s3Err)
+		}
+	}
+	if throttled < 6 || throttled > 8 {
+		t.Errorf("expected 6 to 8 of 10 requests to be throttled, got %d", throttled)
+	}
+	// Requests to other buckets of the tenant share the limit.
+	if s3Err := get("b2"); s3Err != ErrSlowDown {
+		t.Errorf("expected the request to be throttled, got %v", s3Err)
+	}
+	if status := reg.status(); status.Tenants[1].Throttled != uint64(throttled)+1 {
+		t.Errorf("expected %d throttled requests, got %d", throttled+1, status.Tenants[1].Throttled)
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultTrashTTL = 30 * 24 * time.Hour
+
+	// Interval between purges of the expired objects of a trash.
+	trashPurgeInterval = time.Hour
+
+	// Objects listed per page while purging.
+	trashPurgeListSize = 1000
+)
+
+// Objects larger than a single copy are moved to the trash in parts of
+// this size.
+const trashCopyPartSize int64 = globalMaxPartSize
+
+// Servers which find the trash of a bucket being purged by another server
+// give up after this time.
+var trashLockTimeout = newDynamicTimeout(10*time.Second, 10*time.Second)
+
+// trashConfig - recycle bin of a mirror bucket. Deleted objects are moved
+// below Prefix of the mirror bucket Bucket, the bucket itself by default,
+// and purged once they were deleted for TTL.
+type trashConfig struct {
+	Prefix string        `yaml:"prefix"`
+	Bucket string        `yaml:"bucket"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+// enabled - returns true if deleted objects are moved to a trash.
+func (c trashConfig) enabled() bool {
+	return c.Prefix != ""
+}
+
+// ttl - returns the time deleted objects are kept.
+func (c trashConfig) ttl() time.Duration {
+	if c.TTL == 0 {
+		return defaultTrashTTL
+	}
+	return c.TTL
+}
+
+// bucket - returns the bucket holding the trash of bucket.
+func (c trashConfig) bucket(bucket string) string {
+	if c.Bucket == "" {
+		return bucket
+	}
+	return c.Bucket
+}
+
+// holds - returns true if object of bucket is in the trash, such objects
+// are deleted for good.
+func (c trashConfig) holds(bucket, object string) bool {
+	return c.Bucket == "" && strings.HasPrefix(object, c.Prefix)
+}
+
+// validateTrashConfig - validates the trash of a mirror bucket, remotes
+// holds the number of remotes serving reads of every mirror bucket and
+// trashes their trash configs.
+func validateTrashConfig(errs *radioConfigErrors, path, bucket string, c trashConfig, remotes map[string]int,
+	trashes map[string]trashConfig) {
+	if !c.enabled() {
+		if c != (trashConfig{}) {
+			errs.add(path+".prefix", "required for a trash")
+		}
+		return
+	}
+	if !strings.HasSuffix(c.Prefix, SlashSeparator) || strings.HasPrefix(c.Prefix, SlashSeparator) {
+		errs.add(path+".prefix", "%q must end but not start with %s", c.Prefix, SlashSeparator)
+	}
+	if c.TTL < 0 {
+		errs.add(path+".ttl", "must not be negative")
+	}
+	if c.Bucket == "" || c.Bucket == bucket {
+		return
+	}
+	n, ok := remotes[c.Bucket]
+	switch {
+	case !ok:
+		errs.add(path+".bucket", "%q is not a mirror bucket", c.Bucket)
+	case n != remotes[bucket]:
+		errs.add(path+".bucket", "%q must have as many remotes as %q", c.Bucket, bucket)
+	case trashes[c.Bucket].enabled():
+		errs.add(path+".bucket", "%q has a trash itself", c.Bucket)
+	}
+}
+
+// moveToTrash - copies object to the trash of bucket on all remotes, the
+// caller holds the lock of object and deletes it once moved.
+func (l *radioObjects) moveToTrash(ctx context.Context, bucket, object string, trash trashConfig) error {
+	info, err := l.getObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		err = ErrorRespToObjectError(err, bucket, object)
+		if _, ok := err.(ObjectNotFound); ok {
+			// Nothing to keep.
+			return nil
+		}
+		return err
+	}
+	dstBucket, dstObject := trash.bucket(bucket), trash.Prefix+object
+	if info.Size <= trashCopyPartSize {
+		_, err = l.CopyObject(ctx, bucket, object, dstBucket, dstObject, info, ObjectOptions{}, ObjectOptions{})
+		return err
+	}
+
+	uploadID, err := l.NewMultipartUpload(ctx, dstBucket, dstObject, ObjectOptions{UserDefined: info.UserDefined})
+	if err != nil {
+		return err
+	}
+	var parts []CompletePart
+	for offset, partID := int64(0), 1; offset < info.Size; offset, partID = offset+trashCopyPartSize, partID+1 {
+		length := trashCopyPartSize
+		if offset+length > info.Size {
+			length = info.Size - offset
+		}
+		p, err := l.CopyObjectPart(ctx, bucket, object, dstBucket, dstObject, uploadID, partID, offset, length,
+			info, ObjectOptions{}, ObjectOptions{})
+		if err != nil {
+			logger.LogIf(ctx, l.AbortMultipartUpload(ctx, dstBucket, dstObject, uploadID))
+			return err
+		}
+		parts = append(parts, CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	_, err = l.CompleteMultipartUpload(ctx, dstBucket, dstObject, uploadID, parts, ObjectOptions{})
+	return err
+}
+
+// runTrashPurge - purges the trash of bucket every trashPurgeInterval.
+func (l *radioObjects) runTrashPurge(bucket string) {
+	for {
+		time.Sleep(trashPurgeInterval)
+		ctx := newBackgroundContext("TrashPurge", bucket, "")
+		if err := l.purgeTrash(ctx, bucket, UTCNow()); err != nil {
+			logger.LogIf(ctx, fmt.Errorf("trash of %s: %v", bucket, err))
+		}
+	}
+}
+
+// purgeTrash - deletes the objects of the trash of bucket deleted for
+// longer than its TTL at now, unless another server is purging it.
+func (l *radioObjects) purgeTrash(ctx context.Context, bucket string, now time.Time) error {
+	lock := l.NewNSLock(ctx, minioMetaBucket, "trash/"+bucket)
+	if err := lock.GetLock(trashLockTimeout); err != nil {
+		// Being purged by another server.
+		return nil
+	}
+	defer lock.Unlock()
+
+	trash := l.mirrorClients[bucket].trash
+	trashBucket := trash.bucket(bucket)
+	expired := now.Add(-trash.ttl())
+	marker := ""
+	for {
+		loi, err := l.ListObjects(ctx, trashBucket, trash.Prefix, marker, "", trashPurgeListSize)
+		if err != nil {
+			return err
+		}
+		for _, obj := range loi.Objects {
+			if obj.ModTime.Before(expired) {
+				if err = l.DeleteObject(ctx, trashBucket, obj.Name); err != nil {
+					return err
+				}
+			}
+			marker = obj.Name
+		}
+		if !loi.IsTruncated || len(loi.Objects) == 0 {
+			return nil
+		}
+	}
+}
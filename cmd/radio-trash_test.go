@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateTrashConfig(t *testing.T) {
+	remotes := map[string]int{"bucket": 2, "trash": 2, "small": 1, "other": 2}
+	trashes := map[string]trashConfig{"other": {Prefix: ".trash/"}}
+	testCases := []struct {
+		c    trashConfig
+		errs int
+	}{
+		{trashConfig{}, 0},
+		{trashConfig{Prefix: ".trash/", TTL: 24 * time.Hour}, 0},
+		{trashConfig{Prefix: "deleted/", Bucket: "trash"}, 0},
+		{trashConfig{Prefix: ".trash/", Bucket: "bucket"}, 0},
+		{trashConfig{TTL: time.Hour}, 1},
+		{trashConfig{Prefix: ".trash"}, 1},
+		{trashConfig{Prefix: "/trash/", TTL: -time.Hour}, 2},
+		{trashConfig{Prefix: ".trash/", Bucket: "missing"}, 1},
+		{trashConfig{Prefix: ".trash/", Bucket: "small"}, 1},
+		{trashConfig{Prefix: ".trash/", Bucket: "other"}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateTrashConfig(&errs, "trash", "bucket", testCase.c, remotes, trashes)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestTrashConfig(t *testing.T) {
+	inBucket := trashConfig{Prefix: ".trash/"}
+	if inBucket.bucket("bucket") != "bucket" || inBucket.ttl() != defaultTrashTTL {
+		t.Errorf("unexpected trash %+v", inBucket)
+	}
+	if !inBucket.holds("bucket", ".trash/a.txt") || inBucket.holds("bucket", "a.txt") {
+		t.Error("expected only the objects below the prefix to be trashed")
+	}
+
+	separate := trashConfig{Prefix: ".trash/", Bucket: "trash", TTL: time.Hour}
+	if separate.bucket("bucket") != "trash" || separate.ttl() != time.Hour {
+		t.Errorf("unexpected trash %+v", separate)
+	}
+	if separate.holds("bucket", ".trash/a.txt") {
+		t.Error("expected the objects of the bucket not to be trashed")
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+const (
+	// Prefix of upload tokens, sent as Authorization: Bearer TOKEN.
+	uploadTokenPrefix = "rut1."
+	// Prefix of the access keys identifying upload tokens to hooks.
+	uploadTokenAccessKeyPrefix = "upload-token:"
+
+	defaultUploadTokenExpiry = 24 * time.Hour
+	maxUploadTokenExpiry     = 30 * 24 * time.Hour
+)
+
+// UploadTokenClaims - the scope of an upload token, which only uploads
+// objects below Prefix of Bucket, of at most MaxSize bytes if set, with
+// single PUT requests until Expires.
+type UploadTokenClaims struct {
+	ID      string    `json:"id"`
+	Bucket  string    `json:"bucket"`
+	Prefix  string    `json:"prefix,omitempty"`
+	MaxSize int64     `json:"maxSize,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// UploadTokenResult - a minted upload token.
+type UploadTokenResult struct {
+	Token string `json:"token"`
+	UploadTokenClaims
+}
+
+// uploadTokenKey - returns the key upload tokens are signed with, derived
+// from the admin credential so that all servers sharing it accept them.
+// Changing the admin secret key revokes all tokens.
+func uploadTokenKey() ([]byte, bool) {
+	if !globalAdminCred.IsValid() {
+		return nil, false
+	}
+	mac := hmac.New(sha256.New, []byte(globalAdminCred.SecretKey))
+	mac.Write([]byte("radio upload token"))
+	return mac.Sum(nil), true
+}
+
+// signUploadToken - returns the upload token of claims signed with key.
+func signUploadToken(claims UploadTokenClaims, key []byte) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return uploadTokenPrefix + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// parseUploadToken - returns the claims of token if it was signed with
+// key. The signature is checked before the claims are decoded, so that
+// forged tokens cost a single HMAC.
+func parseUploadToken(token string, key []byte) (UploadTokenClaims, error) {
+	var claims UploadTokenClaims
+	if !strings.HasPrefix(token, uploadTokenPrefix) {
+		return claims, errors.New("not an upload token")
+	}
+	token = token[len(uploadTokenPrefix):]
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return claims, errors.New("malformed upload token")
+	}
+	payload := token[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return claims, errors.New("malformed upload token")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return claims, errors.New("invalid upload token signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return claims, errors.New("malformed upload token")
+	}
+	if err = json.Unmarshal(data, &claims); err != nil {
+		return claims, errors.New("malformed upload token")
+	}
+	return claims, nil
+}
+
+// Verify if the request carries an upload token.
+func isRequestUploadToken(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get(xhttp.Authorization), jwtAlgorithm+" "+uploadTokenPrefix)
+}
+
+// verifyUploadToken - verifies the upload token of r and that r is a
+// plain PutObject, returns the claims of the token.
+func verifyUploadToken(r *http.Request, now time.Time) (UploadTokenClaims, APIErrorCode) {
+	key, ok := uploadTokenKey()
+	if !ok {
+		return UploadTokenClaims{}, ErrAccessDenied
+	}
+	token := strings.TrimPrefix(r.Header.Get(xhttp.Authorization), jwtAlgorithm+" ")
+	claims, err := parseUploadToken(token, key)
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if !now.Before(claims.Expires) {
+		return claims, ErrExpiredPresignRequest
+	}
+	// Parts, copies, appends and subresources such as ?tagging are
+	// outside of the scope of any token.
+	_, copySource := r.Header[xhttp.AmzCopySource]
+	_, writeOffset := r.Header[xhttp.AmzWriteOffsetBytes]
+	if r.Method != http.MethodPut || r.URL.RawQuery != "" || copySource || writeOffset {
+		return claims, ErrAccessDenied
+	}
+	if claims.MaxSize > 0 && (r.ContentLength < 0 || r.ContentLength > claims.MaxSize) {
+		return claims, ErrAccessDenied
+	}
+	return claims, ErrNone
+}
+
+// checkUploadToken - verifies the upload token of r like
+// verifyUploadToken and that object of bucket is within its scope.
+func checkUploadToken(r *http.Request, bucket, object string, now time.Time) (UploadTokenClaims, APIErrorCode) {
+	claims, s3Err := verifyUploadToken(r, now)
+	if s3Err != ErrNone {
+		return claims, s3Err
+	}
+	if object == "" || bucket != claims.Bucket || !strings.HasPrefix(object, claims.Prefix) {
+		return claims, ErrAccessDenied
+	}
+	return claims, ErrNone
+}
+
+// MintUploadToken - returns an upload token for claims, expiring after
+// expiry, 24h by default.
+func (l *radioObjects) MintUploadToken(claims UploadTokenClaims, expiry time.Duration) (UploadTokenResult, error) {
+	if _, ok := l.mirrorClients[claims.Bucket]; !ok {
+		if _, ok = l.erasureClients[claims.Bucket]; !ok {
+			return UploadTokenResult{}, BucketNotFound{Bucket: claims.Bucket}
+		}
+	}
+	switch {
+	case expiry == 0:
+		expiry = defaultUploadTokenExpiry
+	case expiry < time.Second || expiry > maxUploadTokenExpiry:
+		return UploadTokenResult{}, fmt.Errorf("expiry must be between 1s and %s", maxUploadTokenExpiry)
+	}
+	if claims.MaxSize < 0 {
+		return UploadTokenResult{}, errors.New("max-size must not be negative")
+	}
+	key, ok := uploadTokenKey()
+	if !ok {
+		return UploadTokenResult{}, errors.New("admin credential is not set")
+	}
+
+	claims.ID = mustGetUUID()
+	claims.Expires = UTCNow().Add(expiry).Truncate(time.Second)
+	token, err := signUploadToken(claims, key)
+	if err != nil {
+		return UploadTokenResult{}, err
+	}
+	return UploadTokenResult{Token: token, UploadTokenClaims: claims}, nil
+}
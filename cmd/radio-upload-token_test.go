@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+	xhttp "github.com/minio/radio/cmd/http"
+)
+
+func TestUploadToken(t *testing.T) {
+	defer func(cred auth.Credentials) { globalAdminCred = cred }(globalAdminCred)
+
+	cred, err := auth.CreateCredentials("ZX7mIIOGC12QBMJ45F0Z", "7ule1ga5JMfMmQXCoEPNcM2jij")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalAdminCred = cred
+	key, _ := uploadTokenKey()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	claims := UploadTokenClaims{
+		ID:      "device-1",
+		Bucket:  "telemetry",
+		Prefix:  "sensors/1/",
+		MaxSize: 1024,
+		Expires: now.Add(time.Hour),
+	}
+	token, err := signUploadToken(claims, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := parseUploadToken(token, key); err != nil || parsed != claims {
+		t.Fatalf("expected %+v, got %+v, %v", claims, parsed, err)
+	}
+
+	newRequest := func(method, target, token string, size int) *http.Request {
+		r := httptest.NewRequest(method, target, strings.NewReader(strings.Repeat("a", size)))
+		r.Header.Set(xhttp.Authorization, "Bearer "+token)
+		return r
+	}
+	testCases := []struct {
+		r              *http.Request
+		bucket, object string
+		now            time.Time
+		s3Err          APIErrorCode
+	}{
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", token, 100), "telemetry", "sensors/1/a.json", now, ErrNone},
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", token, 100), "telemetry", "sensors/1/a.json", now.Add(time.Hour), ErrExpiredPresignRequest},
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", token, 2048), "telemetry", "sensors/1/a.json", now, ErrAccessDenied},
+		{newRequest(http.MethodPut, "/telemetry/sensors/2/a.json", token, 100), "telemetry", "sensors/2/a.json", now, ErrAccessDenied},
+		{newRequest(http.MethodPut, "/logs/sensors/1/a.json", token, 100), "logs", "sensors/1/a.json", now, ErrAccessDenied},
+		{newRequest(http.MethodGet, "/telemetry/sensors/1/a.json", token, 0), "telemetry", "sensors/1/a.json", now, ErrAccessDenied},
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json?partNumber=1&uploadId=x", token, 100), "telemetry", "sensors/1/a.json", now, ErrAccessDenied},
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", token[:len(token)-2], 100), "telemetry", "sensors/1/a.json", now, ErrInvalidToken},
+		{newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", uploadTokenPrefix+"e30.", 100), "telemetry", "sensors/1/a.json", now, ErrInvalidToken},
+	}
+	for i, testCase := range testCases {
+		if _, s3Err := checkUploadToken(testCase.r, testCase.bucket, testCase.object, testCase.now); s3Err != testCase.s3Err {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.s3Err, s3Err)
+		}
+	}
+
+	copyReq := newRequest(http.MethodPut, "/telemetry/sensors/1/a.json", token, 0)
+	copyReq.Header.Set(xhttp.AmzCopySource, "/telemetry/sensors/1/b.json")
+	if _, s3Err := checkUploadToken(copyReq, "telemetry", "sensors/1/a.json", now); s3Err != ErrAccessDenied {
+		t.Errorf("expected copies to be denied, got %v", s3Err)
+	}
+
+	// Tokens are revoked with the admin secret key.
+	if cred, err = auth.CreateCredentials("ZX7mIIOGC12QBMJ45F0Z", "rotated-secret-key-0123"); err != nil {
+		t.Fatal(err)
+	}
+	globalAdminCred = cred
+	if _, s3Err := checkUploadToken(testCases[0].r, "telemetry", "sensors/1/a.json", now); s3Err != ErrInvalidToken {
+		t.Errorf("expected a token of another admin secret to be invalid, got %v", s3Err)
+	}
+}
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultUsageRetention = 400 * 24 * time.Hour
+
+	// Interval between writes of the usage of the current day.
+	usageFlushInterval = time.Minute
+
+	// Layout of the dates of usage reports, and of the names of the
+	// files holding the usage of a day.
+	usageDateLayout = "2006-01-02"
+	usageFileSuffix = ".json"
+
+	// Longest period covered by a usage report.
+	maxUsageReportDays = 400
+)
+
+// Request classes of the usage reports, as priced by S3: writes, copies
+// and listings, reads and other requests, and deletes.
+const (
+	usageClassTier1 = "tier1"
+	usageClassTier2 = "tier2"
+	usageClassFree  = "free"
+)
+
+// globalUsage - usage of the S3 API by access key, nil unless configured.
+var globalUsage *usageTracker
+
+// usageConfig - daily usage per access key, kept in Dir for Retention.
+type usageConfig struct {
+	Dir       string        `yaml:"dir"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// validateUsageConfig - validates the usage config.
+func validateUsageConfig(errs *radioConfigErrors, path string, c usageConfig) {
+	if c.Dir == "" {
+		if c != (usageConfig{}) {
+			errs.add(path+".dir", "required for usage accounting")
+		}
+		return
+	}
+	if c.Retention < 0 {
+		errs.add(path+".retention", "must not be negative")
+	}
+}
+
+// UsageStats - requests served for an access key and their traffic.
+type UsageStats struct {
+	// Requests by class, tier1, tier2 or free.
+	Requests map[string]uint64 `json:"requests"`
+	// APIs counts the requests by API, such as putobject.
+	APIs map[string]uint64 `json:"apis"`
+	// Errors counts the requests failed with a 4xx or 5xx status.
+	Errors   uint64 `json:"errors"`
+	BytesIn  uint64 `json:"bytesIn"`
+	BytesOut uint64 `json:"bytesOut"`
+}
+
+func newUsageStats() *UsageStats {
+	return &UsageStats{Requests: make(map[string]uint64), APIs: make(map[string]uint64)}
+}
+
+// add - adds the usage u to s.
+func (s *UsageStats) add(u *UsageStats) {
+	for class, n := range u.Requests {
+		s.Requests[class] += n
+	}
+	for api, n := range u.APIs {
+		s.APIs[api] += n
+	}
+	s.Errors += u.Errors
+	s.BytesIn += u.BytesIn
+	s.BytesOut += u.BytesOut
+}
+
+// UsageRecord - usage of an access key, of Date or the whole report.
+// Requests not authenticated with an access key are reported with an
+// empty AccessKey.
+type UsageRecord struct {
+	Date      string `json:"date,omitempty"`
+	AccessKey string `json:"accessKey"`
+	UsageStats
+}
+
+// UsageReport - daily usage and its totals by access key, from From to To
+// inclusive.
+type UsageReport struct {
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Days   []UsageRecord `json:"days"`
+	Totals []UsageRecord `json:"totals"`
+}
+
+// usageClass - returns the class of requests to api.
+func usageClass(api string) string {
+	switch {
+	case strings.HasPrefix(api, "delete"), api == "abortmultipartupload":
+		return usageClassFree
+	case strings.HasPrefix(api, "put"), strings.HasPrefix(api, "copy"), strings.HasPrefix(api, "post"),
+		strings.HasPrefix(api, "list"), api == "newmultipartupload", api == "completemutipartupload",
+		api == "restoreobject":
+		return usageClassTier1
+	}
+	return usageClassTier2
+}
+
+// usageTracker - counts the usage of the current day in memory and
+// writes it to a file per day in dir.
+type usageTracker struct {
+	dir       string
+	retention time.Duration
+
+	mu sync.Mutex
+	// days holds the usage by access key of the days counted since the
+	// last write.
+	days map[string]map[string]*UsageStats
+}
+
+// newUsageTracker - creates the usage tracker writing to c.Dir.
+func newUsageTracker(c usageConfig) (*usageTracker, error) {
+	t := &usageTracker{
+		dir:       c.Dir,
+		retention: c.Retention,
+		days:      make(map[string]map[string]*UsageStats),
+	}
+	if t.retention == 0 {
+		t.retention = defaultUsageRetention
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *usageTracker) path(date string) string {
+	return filepath.Join(t.dir, date+usageFileSuffix)
+}
+
+// load - reads the usage of date, empty if none was written.
+func (t *usageTracker) load(date string) (map[string]*UsageStats, error) {
+	usage := make(map[string]*UsageStats)
+	data, err := ioutil.ReadFile(t.path(date))
+	if os.IsNotExist(err) {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("%s: %v", t.path(date), err)
+	}
+	for _, s := range usage {
+		if s.Requests == nil {
+			s.Requests = make(map[string]uint64)
+		}
+		if s.APIs == nil {
+			s.APIs = make(map[string]uint64)
+		}
+	}
+	return usage, nil
+}
+
+// record - counts a request to api by accessKey at now, t may be nil.
+func (t *usageTracker) record(now time.Time, api, accessKey string, statusCode int, bytesIn, bytesOut uint64) {
+	if t == nil {
+		return
+	}
+	date := now.UTC().Format(usageDateLayout)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	day, ok := t.days[date]
+	if !ok {
+		// Continue counting the day written before a restart.
+		var err error
+		if day, err = t.load(date); err != nil {
+			logger.LogIf(context.Background(), fmt.Errorf("usage: %v", err))
+			day = make(map[string]*UsageStats)
+		}
+		t.days[date] = day
+	}
+	s, ok := day[accessKey]
+	if !ok {
+		s = newUsageStats()
+		day[accessKey] = s
+	}
+	s.Requests[usageClass(api)]++
+	s.APIs[api]++
+	if statusCode >= http.StatusBadRequest {
+		s.Errors++
+	}
+	s.BytesIn += bytesIn
+	s.BytesOut += bytesOut
+}
+
+// flush - writes the usage counted in memory, the days before now are
+// forgotten once written.
+func (t *usageTracker) flush(now time.Time) error {
+	today := now.UTC().Format(usageDateLayout)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for date, day := range t.days {
+		data, err := json.Marshal(day)
+		if err != nil {
+			return err
+		}
+		tmp := t.path(date) + ".tmp"
+		if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+			return err
+		}
+		if err = os.Rename(tmp, t.path(date)); err != nil {
+			return err
+		}
+		if date != today {
+			delete(t.days, date)
+		}
+	}
+	return nil
+}
+
+// expire - removes the usage of the days older than the retention.
+func (t *usageTracker) expire(now time.Time) {
+	oldest := now.UTC().Add(-t.retention).Format(usageDateLayout)
+	entries, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		logger.LogIf(context.Background(), fmt.Errorf("usage: %v", err))
+		return
+	}
+	for _, entry := range entries {
+		date := strings.TrimSuffix(entry.Name(), usageFileSuffix)
+		if entry.IsDir() || date == entry.Name() {
+			continue
+		}
+		if _, err = time.Parse(usageDateLayout, date); err == nil && date < oldest {
+			logger.LogIf(context.Background(), os.Remove(filepath.Join(t.dir, entry.Name())))
+		}
+	}
+}
+
+// run - writes the usage every usageFlushInterval.
+func (t *usageTracker) run() {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := t.flush(now); err != nil {
+			logger.LogIf(context.Background(), fmt.Errorf("usage: %v", err))
+		}
+		t.expire(now)
+	}
+}
+
+// report - returns the usage from from to to, of accessKey if set.
+func (t *usageTracker) report(from, to time.Time, accessKey string) (UsageReport, error) {
+	rep := UsageReport{
+		From:   from.Format(usageDateLayout),
+		To:     to.Format(usageDateLayout),
+		Days:   []UsageRecord{},
+		Totals: []UsageRecord{},
+	}
+	totals := make(map[string]*UsageStats)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format(usageDateLayout)
+		t.mu.Lock()
+		day, ok := t.days[date]
+		var records []UsageRecord
+		if ok {
+			// Copy the day while it is counted.
+			for key, s := range day {
+				c := newUsageStats()
+				c.add(s)
+				records = append(records, UsageRecord{Date: date, AccessKey: key, UsageStats: *c})
+			}
+		}
+		t.mu.Unlock()
+		if !ok {
+			day, err := t.load(date)
+			if err != nil {
+				return rep, err
+			}
+			for key, s := range day {
+				records = append(records, UsageRecord{Date: date, AccessKey: key, UsageStats: *s})
+			}
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].AccessKey < records[j].AccessKey })
+		for _, rec := range records {
+			if accessKey != "" && rec.AccessKey != accessKey {
+				continue
+			}
+			rep.Days = append(rep.Days, rec)
+			total, ok := totals[rec.AccessKey]
+			if !ok {
+				total = newUsageStats()
+				totals[rec.AccessKey] = total
+			}
+			total.add(&rec.UsageStats)
+		}
+	}
+	for key, s := range totals {
+		rep.Totals = append(rep.Totals, UsageRecord{AccessKey: key, UsageStats: *s})
+	}
+	sort.Slice(rep.Totals, func(i, j int) bool { return rep.Totals[i].AccessKey < rep.Totals[j].AccessKey })
+	return rep, nil
+}
+
+// recordUsage - counts a completed S3 request to api.
+func recordUsage(api string, stats *recordAPIStats, rt *requestTrace) {
+	statusCode := stats.respStatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	rt.mu.Lock()
+	accessKey := rt.accessKey
+	rt.mu.Unlock()
+	globalUsage.record(UTCNow(), api, accessKey, statusCode, uint64(stats.bytesIn.Load()), uint64(stats.bytesOut.Load()))
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUsageClass(t *testing.T) {
+	testCases := map[string]string{
+		"putobject":              usageClassTier1,
+		"copyobjectpart":         usageClassTier1,
+		"listobjectsv2":          usageClassTier1,
+		"newmultipartupload":     usageClassTier1,
+		"completemutipartupload": usageClassTier1,
+		"postpolicybucket":       usageClassTier1,
+		"getobject":              usageClassTier2,
+		"headobject":             usageClassTier2,
+		"selectobjectcontent":    usageClassTier2,
+		"deleteobject":           usageClassFree,
+		"deletemultipleobjects":  usageClassFree,
+		"abortmultipartupload":   usageClassFree,
+	}
+	for api, class := range testCases {
+		if got := usageClass(api); got != class {
+			t.Errorf("%s: expected class %s, got %s", api, class, got)
+		}
+	}
+}
+
+func TestUsageTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	day1 := time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	tracker, err := newUsageTracker(usageConfig{Dir: dir, Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.record(day1, "putobject", "alice", http.StatusOK, 100, 0)
+	tracker.record(day1, "getobject", "alice", http.StatusOK, 0, 50)
+	tracker.record(day1, "getobject", "", http.StatusForbidden, 0, 10)
+	if err = tracker.flush(day2); err != nil {
+		t.Fatal(err)
+	}
+	tracker.record(day2, "deleteobject", "alice", http.StatusNoContent, 0, 0)
+	if err = tracker.flush(day2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counting continues after a restart.
+	tracker, err = newUsageTracker(usageConfig{Dir: dir, Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.record(day2, "getobject", "alice", http.StatusOK, 0, 25)
+
+	from, to := day1.Truncate(24*time.Hour), day2.Truncate(24*time.Hour)
+	report, err := tracker.report(from, to, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.From != "2020-01-01" || report.To != "2020-01-02" || len(report.Days) != 3 || len(report.Totals) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if d := report.Days[0]; d.Date != "2020-01-01" || d.AccessKey != "" || d.Errors != 1 || d.Requests[usageClassTier2] != 1 {
+		t.Errorf("unexpected anonymous usage %+v", d)
+	}
+	if d := report.Days[2]; d.Date != "2020-01-02" || d.Requests[usageClassFree] != 1 || d.APIs["getobject"] != 1 || d.BytesOut != 25 {
+		t.Errorf("unexpected usage of the second day %+v", d)
+	}
+	total := report.Totals[1]
+	if total.AccessKey != "alice" || total.BytesIn != 100 || total.BytesOut != 75 || total.APIs["getobject"] != 2 ||
+		total.Requests[usageClassTier1] != 1 || total.Requests[usageClassTier2] != 2 || total.Requests[usageClassFree] != 1 {
+		t.Errorf("unexpected total %+v", total)
+	}
+
+	if report, err = tracker.report(from, to, "bob"); err != nil || len(report.Days) != 0 || len(report.Totals) != 0 {
+		t.Errorf("expected no usage of bob, got %+v %v", report, err)
+	}
+
+	// Days older than the retention are removed.
+	tracker.expire(time.Date(2020, 1, 3, 12, 0, 0, 0, time.UTC))
+	if _, err = os.Stat(filepath.Join(dir, "2020-01-01.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the first day to expire, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "2020-01-02.json")); err != nil {
+		t.Errorf("expected the second day to be retained, got %v", err)
+	}
+}
@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/policy"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	webdavPath       = "/webdav"
+	webdavPathPrefix = minioReservedBucketPath + webdavPath
+
+	// Maximum number of objects listed per call of the object layer
+	// while reading a collection.
+	webdavListSize = 1000
+)
+
+// webdavConfig - read-only WebDAV collections of mirror buckets, for
+// tools which cannot speak S3.
+type webdavConfig struct {
+	Exports []webdavExport `yaml:"exports"`
+}
+
+// webdavExport - a collection below /minio/webdav/ serving the objects of
+// a bucket under a prefix.
+type webdavExport struct {
+	Name   string `yaml:"name"`
+	Bucket string `yaml:"bucket"`
+	// Prefix such as datasets/, the whole bucket is exported if empty.
+	Prefix string `yaml:"prefix"`
+}
+
+// validateWebDAVConfig - validates the exports, mirrorBuckets holds the
+// paths of the mirror buckets.
+func validateWebDAVConfig(errs *radioConfigErrors, path string, c webdavConfig, mirrorBuckets map[string]string) {
+	names := make(map[string]string)
+	for i, export := range c.Exports {
+		epath := fmt.Sprintf("%s.exports[%d]", path, i)
+		switch {
+		case export.Name == "":
+			errs.add(epath+".name", "required for an export")
+		case strings.Contains(export.Name, SlashSeparator) || export.Name == "." || export.Name == "..":
+			errs.add(epath+".name", "%q is not a collection name", export.Name)
+		default:
+			if prev, ok := names[export.Name]; ok {
+				errs.add(epath+".name", "export %q is already configured at %s", export.Name, prev)
+			}
+			names[export.Name] = epath
+		}
+		if _, ok := mirrorBuckets[export.Bucket]; !ok {
+			errs.add(epath+".bucket", "%q is not a mirror bucket", export.Bucket)
+		}
+		if export.Prefix != "" && (!HasSuffix(export.Prefix, SlashSeparator) || HasPrefix(export.Prefix, SlashSeparator)) {
+			errs.add(epath+".prefix", "must end with a slash and not start with one")
+		}
+	}
+}
+
+// registerWebDAVRouter - registers the exports of c.
+func registerWebDAVRouter(router *mux.Router, c webdavConfig) {
+	if len(c.Exports) == 0 {
+		return
+	}
+	router.PathPrefix(webdavPathPrefix).HandlerFunc(httpTraceHdrs(webdavHandler(c)))
+}
+
+// webdavAuth - returns the access key of the HTTP basic credentials of r,
+// those of a local bucket, challenging the client otherwise.
+func webdavAuth(w http.ResponseWriter, r *http.Request) (string, bool) {
+	accessKey, secretKey, ok := r.BasicAuth()
+	if ok {
+		globalLocalCredsMu.RLock()
+		cred, found := globalLocalCreds[accessKey]
+		globalLocalCredsMu.RUnlock()
+		if found && subtle.ConstantTimeCompare([]byte(secretKey), []byte(cred.SecretKey)) == 1 {
+			return cred.AccessKey, true
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="radio"`)
+	writeResponse(w, http.StatusUnauthorized, nil, mimeNone)
+	return "", false
+}
+
+// webdavHandler - serves the exports read-only. PROPFIND requests of
+// infinite depth are rejected, they would list whole buckets.
+func webdavHandler(c webdavConfig) http.HandlerFunc {
+	locks := webdav.NewMemLS()
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := newContext(r, w, "WebDAV")
+
+		defer logger.AuditLog(w, r, "WebDAV")
+
+		switch r.Method {
+		case http.MethodOptions, http.MethodGet, http.MethodHead, "PROPFIND":
+		default:
+			w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+			writeResponse(w, http.StatusMethodNotAllowed, nil, mimeNone)
+			return
+		}
+		accessKey, ok := webdavAuth(w, r)
+		if !ok {
+			return
+		}
+		logger.GetReqInfo(ctx).AccessKey = accessKey
+		objectAPI := newObjectLayerFn()
+		if objectAPI == nil {
+			writeResponse(w, http.StatusServiceUnavailable, nil, mimeNone)
+			return
+		}
+		if r.Method == "PROPFIND" {
+			if depth := r.Header.Get("Depth"); depth != "0" && depth != "1" {
+				writeResponse(w, http.StatusForbidden, []byte("propfind-finite-depth"), mimeNone)
+				return
+			}
+		}
+
+		fs := &webdavFS{
+			exports:   c.Exports,
+			objectAPI: objectAPI,
+			cacheAPI:  newCachedObjectLayerFn(),
+			r:         r,
+			accessKey: accessKey,
+			infos:     make(map[string]*webdavFileInfo),
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			// Serve the content type of the object, instead of reading
+			// the object twice to sniff it.
+			if fi, err := fs.Stat(ctx, strings.TrimPrefix(r.URL.Path, webdavPathPrefix)); err == nil && !fi.IsDir() {
+				if contentType := fi.(*webdavFileInfo).info.ContentType; contentType != "" {
+					w.Header().Set(xhttp.ContentType, contentType)
+				}
+			}
+		}
+		h := &webdav.Handler{
+			Prefix:     webdavPathPrefix,
+			FileSystem: fs,
+			LockSystem: locks,
+			Logger: func(r *http.Request, err error) {
+				if err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+					logger.LogIf(ctx, err)
+				}
+			},
+		}
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// webdavFS - the exports as seen by a request. The root lists the
+// exports, the collections of an export are the prefixes of its keys up
+// to a slash. Objects are read from the cache if any, and the infos of
+// the objects listed are kept for the properties of the request.
+type webdavFS struct {
+	exports   []webdavExport
+	objectAPI ObjectLayer
+	cacheAPI  CacheObjectLayer
+	r         *http.Request
+	accessKey string
+	infos     map[string]*webdavFileInfo
+}
+
+// resolve - returns the export of name and the key name maps to, which
+// ends with a slash for the collection of an export. ok is false for
+// the root.
+func (fs *webdavFS) resolve(name string) (export webdavExport, key string, ok bool, err error) {
+	name = strings.TrimPrefix(path.Clean(SlashSeparator+name), SlashSeparator)
+	if name == "" {
+		return export, "", false, nil
+	}
+	exportName, rest := name, ""
+	if i := strings.Index(name, SlashSeparator); i >= 0 {
+		exportName, rest = name[:i], name[i+1:]
+	}
+	for _, export = range fs.exports {
+		if export.Name == exportName {
+			return export, export.Prefix + rest, true, nil
+		}
+	}
+	return export, "", false, os.ErrNotExist
+}
+
+// authorize - passes the action to the tenants and the request hooks.
+func (fs *webdavFS) authorize(action policy.Action, bucket, object string) error {
+	return fsAuthError(frontendAuthHooks(fs.r, HookAuth{AccessKey: fs.accessKey, Action: string(action), Bucket: bucket, Object: object}))
+}
+
+// Mkdir - exports are read-only.
+func (fs *webdavFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+// RemoveAll - exports are read-only.
+func (fs *webdavFS) RemoveAll(ctx context.Context, name string) error {
+	return os.ErrPermission
+}
+
+// Rename - exports are read-only.
+func (fs *webdavFS) Rename(ctx context.Context, oldName, newName string) error {
+	return os.ErrPermission
+}
+
+// Stat - returns the info of the object or the collection name.
+func (fs *webdavFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name = path.Clean(SlashSeparator + name)
+	if fi, ok := fs.infos[name]; ok {
+		return fi, nil
+	}
+	export, key, ok, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &webdavFileInfo{name: SlashSeparator, dir: true}, nil
+	}
+	if key == export.Prefix {
+		if _, err = fs.objectAPI.GetBucketInfo(ctx, export.Bucket); err != nil {
+			return nil, fsError(err)
+		}
+		return &webdavFileInfo{name: export.Name, dir: true}, nil
+	}
+	if err = fs.authorize(policy.GetObjectAction, export.Bucket, key); err != nil {
+		return nil, err
+	}
+	getObjectInfo := fs.objectAPI.GetObjectInfo
+	if fs.cacheAPI != nil {
+		getObjectInfo = fs.cacheAPI.GetObjectInfo
+	}
+	oi, err := getObjectInfo(ctx, export.Bucket, key, ObjectOptions{})
+	if err == nil {
+		fi := &webdavFileInfo{name: path.Base(key), info: oi}
+		fs.infos[name] = fi
+		return fi, nil
+	}
+	if _, ok := err.(ObjectNotFound); !ok {
+		return nil, fsError(err)
+	}
+	loi, err := fs.objectAPI.ListObjects(ctx, export.Bucket, key+SlashSeparator, "", SlashSeparator, 1)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	if len(loi.Objects) == 0 && len(loi.Prefixes) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return &webdavFileInfo{name: path.Base(key), dir: true}, nil
+}
+
+// OpenFile - opens an object or a collection for reading.
+func (fs *webdavFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, os.ErrPermission
+	}
+	fi, err := fs.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &webdavFile{ctx: ctx, fs: fs, name: path.Clean(SlashSeparator + name), fi: fi.(*webdavFileInfo)}, nil
+}
+
+// readdir - lists the collection name, the exports for the root.
+func (fs *webdavFS) readdir(ctx context.Context, name string) ([]os.FileInfo, error) {
+	export, key, ok, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	var fis []os.FileInfo
+	if !ok {
+		for _, export := range fs.exports {
+			fis = append(fis, &webdavFileInfo{name: export.Name, dir: true})
+		}
+		return fis, nil
+	}
+	if err = fs.authorize(policy.ListBucketAction, export.Bucket, ""); err != nil {
+		return nil, err
+	}
+	prefix := key
+	if prefix != "" && !HasSuffix(prefix, SlashSeparator) {
+		prefix += SlashSeparator
+	}
+	marker := ""
+	for {
+		loi, err := fs.objectAPI.ListObjects(ctx, export.Bucket, prefix, marker, SlashSeparator, webdavListSize)
+		if err != nil {
+			return nil, fsError(err)
+		}
+		for _, p := range loi.Prefixes {
+			fis = append(fis, &webdavFileInfo{name: path.Base(p), dir: true})
+		}
+		for _, oi := range loi.Objects {
+			if oi.Name == prefix {
+				// The folder object of the collection itself.
+				continue
+			}
+			fi := &webdavFileInfo{name: path.Base(oi.Name), info: oi}
+			fs.infos[path.Join(name, fi.name)] = fi
+			fis = append(fis, fi)
+		}
+		if !loi.IsTruncated {
+			break
+		}
+		marker = loi.NextMarker
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	return fis, nil
+}
+
+// webdavFileInfo - an object, or an export or prefix served as
+// collection.
+type webdavFileInfo struct {
+	name string
+	dir  bool
+	info ObjectInfo
+}
+
+func (fi *webdavFileInfo) Name() string       { return fi.name }
+func (fi *webdavFileInfo) Size() int64        { return fi.info.Size }
+func (fi *webdavFileInfo) ModTime() time.Time { return fi.info.ModTime }
+func (fi *webdavFileInfo) IsDir() bool        { return fi.dir }
+func (fi *webdavFileInfo) Sys() interface{}   { return nil }
+
+func (fi *webdavFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+// ETag - returns the ETag of the object.
+func (fi *webdavFileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.dir || fi.info.ETag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return "\"" + fi.info.ETag + "\"", nil
+}
+
+// ContentType - returns the content type of the object, listings carry
+// none so it is guessed from the extension.
+func (fi *webdavFileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.info.ContentType != "" {
+		return fi.info.ContentType, nil
+	}
+	if contentType := mime.TypeByExtension(path.Ext(fi.name)); contentType != "" {
+		return contentType, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// webdavFile - an open object or collection. Objects are only read once
+// the handler reads them, from the offset it seeked to.
+type webdavFile struct {
+	ctx  context.Context
+	fs   *webdavFS
+	name string
+	fi   *webdavFileInfo
+
+	gr   *GetObjectReader
+	pos  int64
+	read int64
+
+	// entries of a collection, read on the first call of Readdir.
+	entries []os.FileInfo
+	listed  bool
+}
+
+// Read - reads the object from the current offset.
+func (f *webdavFile) Read(p []byte) (int, error) {
+	if f.fi.dir {
+		return 0, os.ErrInvalid
+	}
+	if f.pos >= f.fi.Size() {
+		return 0, io.EOF
+	}
+	if f.gr == nil || f.read != f.pos {
+		f.closeReader()
+		export, key, _, err := f.fs.resolve(f.name)
+		if err != nil {
+			return 0, err
+		}
+		getObjectNInfo := f.fs.objectAPI.GetObjectNInfo
+		if f.fs.cacheAPI != nil {
+			getObjectNInfo = f.fs.cacheAPI.GetObjectNInfo
+		}
+		rs := &HTTPRangeSpec{Start: f.pos, End: f.fi.Size() - 1}
+		if f.gr, err = getObjectNInfo(f.ctx, export.Bucket, key, rs, http.Header{}, ReadLock, ObjectOptions{}); err != nil {
+			return 0, fsError(err)
+		}
+		f.read = f.pos
+	}
+	n, err := f.gr.Read(p)
+	f.pos += int64(n)
+	f.read += int64(n)
+	return n, err
+}
+
+// Seek - sets the offset of the next Read.
+func (f *webdavFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.fi.Size()
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+// Readdir - returns count entries of the collection, all if count is not
+// positive.
+func (f *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.fi.dir {
+		return nil, os.ErrInvalid
+	}
+	if !f.listed {
+		entries, err := f.fs.readdir(f.ctx, f.name)
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.listed = entries, true
+	}
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+// Stat - returns the info of the object or collection.
+func (f *webdavFile) Stat() (os.FileInfo, error) {
+	return f.fi, nil
+}
+
+// Write - exports are read-only.
+func (f *webdavFile) Write(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+func (f *webdavFile) closeReader() {
+	if f.gr != nil {
+		f.gr.Close()
+		f.gr = nil
+	}
+}
+
+// Close - closes the object.
+func (f *webdavFile) Close() error {
+	f.closeReader()
+	return nil
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestValidateWebDAVConfig(t *testing.T) {
+	mirrorBuckets := map[string]string{"bucket": "mirror.bucket"}
+	testCases := []struct {
+		c    webdavConfig
+		errs int
+	}{
+		{webdavConfig{}, 0},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "bucket"}}}, 0},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "bucket", Prefix: "datasets/"}}}, 0},
+		{webdavConfig{Exports: []webdavExport{{Bucket: "bucket"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "a/b", Bucket: "bucket"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "..", Bucket: "bucket"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "other"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "bucket", Prefix: "datasets"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "bucket", Prefix: "/datasets/"}}}, 1},
+		{webdavConfig{Exports: []webdavExport{{Name: "data", Bucket: "bucket"}, {Name: "data", Bucket: "bucket"}}}, 1},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		validateWebDAVConfig(&errs, "webdav", testCase.c, mirrorBuckets)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestWebDAVResolve(t *testing.T) {
+	fs := &webdavFS{exports: []webdavExport{
+		{Name: "data", Bucket: "bucket", Prefix: "datasets/"},
+		{Name: "all", Bucket: "bucket"},
+	}}
+	testCases := []struct {
+		name   string
+		bucket string
+		key    string
+		ok     bool
+		err    error
+	}{
+		{"/", "", "", false, nil},
+		{"", "", "", false, nil},
+		{"/data", "bucket", "datasets/", true, nil},
+		{"/data/", "bucket", "datasets/", true, nil},
+		{"/data/2019/file.csv", "bucket", "datasets/2019/file.csv", true, nil},
+		{"/data/../all/file.csv", "bucket", "file.csv", true, nil},
+		{"/all", "bucket", "", true, nil},
+		{"/missing/file.csv", "", "", false, os.ErrNotExist},
+	}
+	for i, testCase := range testCases {
+		export, key, ok, err := fs.resolve(testCase.name)
+		if err != testCase.err || ok != testCase.ok || key != testCase.key || (ok && export.Bucket != testCase.bucket) {
+			t.Errorf("Test %d: expected %q %q %v %v, got %q %q %v %v", i+1,
+				testCase.bucket, testCase.key, testCase.ok, testCase.err, export.Bucket, key, ok, err)
+		}
+	}
+}
+
+func TestWebDAVFileInfo(t *testing.T) {
+	testCases := []struct {
+		fi          webdavFileInfo
+		contentType string
+		etag        string
+	}{
+		{webdavFileInfo{name: "a.csv", info: ObjectInfo{ContentType: "text/plain", ETag: "abc"}}, "text/plain", `"abc"`},
+		{webdavFileInfo{name: "a.png", info: ObjectInfo{ETag: "abc"}}, "image/png", `"abc"`},
+		{webdavFileInfo{name: "a.unknown-ext"}, "application/octet-stream", ""},
+		{webdavFileInfo{name: "dir", dir: true}, "application/octet-stream", ""},
+	}
+	for i, testCase := range testCases {
+		if contentType, _ := testCase.fi.ContentType(context.Background()); contentType != testCase.contentType {
+			t.Errorf("Test %d: expected content type %q, got %q", i+1, testCase.contentType, contentType)
+		}
+		if etag, _ := testCase.fi.ETag(context.Background()); etag != testCase.etag {
+			t.Errorf("Test %d: expected ETag %q, got %q", i+1, testCase.etag, etag)
+		}
+	}
+	if mode := (&webdavFileInfo{dir: true}).Mode(); mode&os.ModeDir == 0 {
+		t.Errorf("expected a directory mode, got %v", mode)
+	}
+}
+
+func TestWebDAVFileReaddir(t *testing.T) {
+	fs := &webdavFS{exports: []webdavExport{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	f := &webdavFile{ctx: context.Background(), fs: fs, name: "/", fi: &webdavFileInfo{name: "/", dir: true}}
+	fis, err := f.Readdir(2)
+	if err != nil || len(fis) != 2 || fis[0].Name() != "a" || !fis[1].IsDir() {
+		t.Fatalf("expected the first two exports, got %v %v", fis, err)
+	}
+	if fis, err = f.Readdir(2); err != nil || len(fis) != 1 || fis[0].Name() != "c" {
+		t.Fatalf("expected the last export, got %v %v", fis, err)
+	}
+	if _, err = f.Readdir(2); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if _, err = f.Read(make([]byte, 1)); err != os.ErrInvalid {
+		t.Fatalf("expected a collection not to be readable, got %v", err)
+	}
+}
+
+func TestWebDAVFileSeek(t *testing.T) {
+	f := &webdavFile{fi: &webdavFileInfo{name: "object", info: ObjectInfo{Size: 100}}}
+	testCases := []struct {
+		offset int64
+		whence int
+		pos    int64
+		err    error
+	}{
+		{10, io.SeekStart, 10, nil},
+		{5, io.SeekCurrent, 15, nil},
+		{-10, io.SeekEnd, 90, nil},
+		{-1, io.SeekStart, 90, os.ErrInvalid},
+		{0, 3, 90, os.ErrInvalid},
+	}
+	for i, testCase := range testCases {
+		pos, err := f.Seek(testCase.offset, testCase.whence)
+		if err != testCase.err || f.pos != testCase.pos || (err == nil && pos != testCase.pos) {
+			t.Errorf("Test %d: expected %d %v, got %d %v", i+1, testCase.pos, testCase.err, f.pos, err)
+		}
+	}
+	if n, err := f.Seek(0, io.SeekEnd); n != 100 || err != nil {
+		t.Fatalf("unexpected seek %d %v", n, err)
+	}
+	if n, err := f.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF at the end of the object, got %d %v", n, err)
+	}
+}
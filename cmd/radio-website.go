@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/handlers"
+	"github.com/minio/minio/pkg/policy"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+const (
+	defaultWebsiteRedirectCode = http.StatusMovedPermanently
+
+	// Actions of the bucket website APIs, not defined by the policy
+	// package.
+	getBucketWebsiteAction = policy.Action("s3:GetBucketWebsite")
+	putBucketWebsiteAction = policy.Action("s3:PutBucketWebsite")
+)
+
+// globalWebsiteHosts maps the hosts of static websites to their mirror
+// bucket, requests to these hosts are anonymous.
+var globalWebsiteHosts = make(map[string]string)
+
+// websiteConfig - static website served from a mirror bucket to
+// anonymous GET and HEAD requests for Hosts, as configured by
+// PutBucketWebsite in S3.
+type websiteConfig struct {
+	Hosts []string `yaml:"hosts"`
+	// IndexDocument is the suffix of keys ending with a slash, such
+	// as index.html.
+	IndexDocument string `yaml:"index_document"`
+	// ErrorDocument is the key served for missing keys.
+	ErrorDocument string `yaml:"error_document"`
+	// RedirectAllRequestsTo redirects all requests to another host,
+	// no other setting is allowed with it.
+	RedirectAllRequestsTo websiteRedirect `yaml:"redirect_all_requests_to"`
+	// RoutingRules are applied in order, the first matching rule
+	// redirects the request.
+	RoutingRules []websiteRoutingRule `yaml:"routing_rules"`
+}
+
+// websiteCondition - condition of a routing rule.
+type websiteCondition struct {
+	KeyPrefixEquals string `yaml:"key_prefix_equals" xml:"KeyPrefixEquals,omitempty"`
+	// HTTPErrorCodeReturnedEquals applies the rule after reading the
+	// key failed with this status, rules without it are applied
+	// before reading the key.
+	HTTPErrorCodeReturnedEquals int `yaml:"http_error_code_returned_equals" xml:"HttpErrorCodeReturnedEquals,omitempty"`
+}
+
+// websiteRedirect - redirect of a routing rule, or of all requests.
+type websiteRedirect struct {
+	HostName string `yaml:"host_name" xml:"HostName,omitempty"`
+	Protocol string `yaml:"protocol" xml:"Protocol,omitempty"`
+	// ReplaceKeyPrefixWith replaces the KeyPrefixEquals of the
+	// condition, ReplaceKeyWith replaces the whole key.
+	ReplaceKeyPrefixWith string `yaml:"replace_key_prefix_with" xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `yaml:"replace_key_with" xml:"ReplaceKeyWith,omitempty"`
+	HTTPRedirectCode     int    `yaml:"http_redirect_code" xml:"HttpRedirectCode,omitempty"`
+}
+
+type websiteRoutingRule struct {
+	Condition websiteCondition `yaml:"condition"`
+	Redirect  websiteRedirect  `yaml:"redirect"`
+}
+
+// enabled - returns true if a website is configured.
+func (c websiteConfig) enabled() bool {
+	return len(c.Hosts) > 0
+}
+
+// websiteHostKey - returns host without its port, lower cased.
+func websiteHostKey(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+func validateWebsiteProtocol(errs *radioConfigErrors, path, protocol string) {
+	if protocol != "" && protocol != "http" && protocol != "https" {
+		errs.add(path, "%q is not http or https", protocol)
+	}
+}
+
+// validateWebsiteConfig - validates the website of a mirror bucket,
+// websiteHosts holds the hosts of the websites validated before.
+func validateWebsiteConfig(errs *radioConfigErrors, path string, c websiteConfig, websiteHosts map[string]string) {
+	if !c.enabled() {
+		if c.IndexDocument != "" || c.ErrorDocument != "" || c.RedirectAllRequestsTo != (websiteRedirect{}) || len(c.RoutingRules) > 0 {
+			errs.add(path+".hosts", "required for a website")
+		}
+		return
+	}
+	for _, host := range c.Hosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			errs.add(path+".hosts", "%q is not a host name", host)
+			continue
+		}
+		key := websiteHostKey(host)
+		if prev, ok := websiteHosts[key]; ok {
+			errs.add(path+".hosts", "host %q is already configured at %s", host, prev)
+		}
+		websiteHosts[key] = path
+	}
+
+	if all := c.RedirectAllRequestsTo; all != (websiteRedirect{}) {
+		if all.HostName == "" {
+			errs.add(path+".redirect_all_requests_to.host_name", "required to redirect all requests")
+		}
+		validateWebsiteProtocol(errs, path+".redirect_all_requests_to.protocol", all.Protocol)
+		if all.ReplaceKeyPrefixWith != "" || all.ReplaceKeyWith != "" || all.HTTPRedirectCode != 0 {
+			errs.add(path+".redirect_all_requests_to", "only host_name and protocol are allowed")
+		}
+		if c.IndexDocument != "" || c.ErrorDocument != "" || len(c.RoutingRules) > 0 {
+			errs.add(path+".redirect_all_requests_to", "no other website setting is allowed with it")
+		}
+		return
+	}
+
+	if c.IndexDocument == "" || strings.Contains(c.IndexDocument, SlashSeparator) {
+		errs.add(path+".index_document", "must be a non-empty suffix without slashes")
+	}
+	if strings.HasPrefix(c.ErrorDocument, SlashSeparator) {
+		errs.add(path+".error_document", "must be a key, not a path")
+	}
+	for i, rule := range c.RoutingRules {
+		rpath := fmt.Sprintf("%s.routing_rules[%d]", path, i)
+		if code := rule.Condition.HTTPErrorCodeReturnedEquals; code != 0 && (code < 400 || code > 599) {
+			errs.add(rpath+".condition.http_error_code_returned_equals", "%d is not an error status", code)
+		}
+		rd := rule.Redirect
+		if rd == (websiteRedirect{}) {
+			errs.add(rpath+".redirect", "required for a routing rule")
+		}
+		validateWebsiteProtocol(errs, rpath+".redirect.protocol", rd.Protocol)
+		if rd.ReplaceKeyPrefixWith != "" && rd.ReplaceKeyWith != "" {
+			errs.add(rpath+".redirect", "replace_key_prefix_with and replace_key_with are exclusive")
+		}
+		switch rd.HTTPRedirectCode {
+		case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+			http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			errs.add(rpath+".redirect.http_redirect_code", "%d is not a redirect status", rd.HTTPRedirectCode)
+		}
+	}
+}
+
+// redirectFor - returns the redirect of a request for key and the key
+// redirected to. status is the error status of reading key, 0 before
+// the key is read.
+func (c websiteConfig) redirectFor(key string, status int) (rd websiteRedirect, target string, ok bool) {
+	if c.RedirectAllRequestsTo.HostName != "" {
+		return c.RedirectAllRequestsTo, key, status == 0
+	}
+	for _, rule := range c.RoutingRules {
+		cond := rule.Condition
+		if cond.HTTPErrorCodeReturnedEquals != status || !strings.HasPrefix(key, cond.KeyPrefixEquals) {
+			continue
+		}
+		target = key
+		switch {
+		case rule.Redirect.ReplaceKeyWith != "":
+			target = rule.Redirect.ReplaceKeyWith
+		case rule.Redirect.ReplaceKeyPrefixWith != "":
+			target = rule.Redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, cond.KeyPrefixEquals)
+		}
+		return rule.Redirect, target, true
+	}
+	return rd, "", false
+}
+
+// location - returns the URL redirected to for key, on the host and with
+// the protocol of r unless rd sets them.
+func (rd websiteRedirect) location(r *http.Request, key string) string {
+	u := url.URL{Scheme: rd.Protocol, Host: rd.HostName, Path: SlashSeparator + key}
+	if u.Scheme == "" {
+		if u.Scheme = handlers.GetSourceScheme(r); u.Scheme == "" {
+			u.Scheme = getURLScheme(r.TLS != nil)
+		}
+	}
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	return u.String()
+}
+
+func (rd websiteRedirect) code() int {
+	if rd.HTTPRedirectCode == 0 {
+		return defaultWebsiteRedirectCode
+	}
+	return rd.HTTPRedirectCode
+}
+
+// WebsiteConfiguration - format of the GetBucketWebsite response.
+type WebsiteConfiguration struct {
+	XMLName               xml.Name                `xml:"http://s3.amazonaws.com/doc/2006-03-01/ WebsiteConfiguration" json:"-"`
+	RedirectAllRequestsTo *websiteRedirect        `xml:"RedirectAllRequestsTo,omitempty"`
+	IndexDocument         *websiteIndexDocument   `xml:"IndexDocument,omitempty"`
+	ErrorDocument         *websiteErrorDocument   `xml:"ErrorDocument,omitempty"`
+	RoutingRules          []websiteRoutingRuleXML `xml:"RoutingRules>RoutingRule,omitempty"`
+}
+
+type websiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+type websiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+type websiteRoutingRuleXML struct {
+	Condition *websiteCondition `xml:"Condition,omitempty"`
+	Redirect  websiteRedirect   `xml:"Redirect"`
+}
+
+// toXML - returns the website as returned by GetBucketWebsite.
+func (c websiteConfig) toXML() WebsiteConfiguration {
+	var wc WebsiteConfiguration
+	if c.RedirectAllRequestsTo.HostName != "" {
+		all := c.RedirectAllRequestsTo
+		wc.RedirectAllRequestsTo = &all
+		return wc
+	}
+	wc.IndexDocument = &websiteIndexDocument{Suffix: c.IndexDocument}
+	if c.ErrorDocument != "" {
+		wc.ErrorDocument = &websiteErrorDocument{Key: c.ErrorDocument}
+	}
+	for _, rule := range c.RoutingRules {
+		xrule := websiteRoutingRuleXML{Redirect: rule.Redirect}
+		if rule.Condition != (websiteCondition{}) {
+			cond := rule.Condition
+			xrule.Condition = &cond
+		}
+		wc.RoutingRules = append(wc.RoutingRules, xrule)
+	}
+	return wc
+}
+
+// website - returns the website of bucket, if any.
+func (l *radioObjects) website(bucket string) (websiteConfig, bool) {
+	rs3s, ok := l.mirrorClients[bucket]
+	if !ok || !rs3s.website.enabled() {
+		return websiteConfig{}, false
+	}
+	return rs3s.website, true
+}
+
+// registerWebsiteRouter - registers the static websites of the mirror
+// buckets, before the S3 API routes of the same hosts.
+func registerWebsiteRouter(router *mux.Router, rconfig radioConfig) {
+	api := objectAPIHandlers{
+		ObjectAPI: newObjectLayerFn,
+		CacheAPI:  newCachedObjectLayerFn,
+	}
+	for _, mcfg := range rconfig.Mirror {
+		bucket := mcfg.Local.Bucket
+		for _, host := range mcfg.Website.Hosts {
+			globalWebsiteHosts[websiteHostKey(host)] = bucket
+			handler := collectAPIStats("website", httpTraceHdrs(api.websiteHandler(bucket)))
+			router.Host(host).HandlerFunc(handler)
+			router.Host(host + ":{port:.*}").HandlerFunc(handler)
+		}
+	}
+}
+
+// isWebsiteRequest - returns true if r is sent to the host of a website.
+func isWebsiteRequest(r *http.Request) bool {
+	_, ok := globalWebsiteHosts[websiteHostKey(r.Host)]
+	return ok
+}
+
+// writeWebsiteError - writes a minimal HTML page for status.
+func writeWebsiteError(w http.ResponseWriter, r *http.Request, status int) {
+	w.Header().Set(xhttp.ContentType, "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		text := fmt.Sprintf("%d %s", status, http.StatusText(status))
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body><h1>%s</h1></body></html>\n", text, text)
+	}
+}
+
+// websiteStatus - returns the status of the website response for err.
+func websiteStatus(ctx context.Context, err error) int {
+	switch err.(type) {
+	case ObjectNotFound, BucketNotFound:
+		return http.StatusNotFound
+	}
+	if err == errInvalidRange {
+		return http.StatusRequestedRangeNotSatisfiable
+	}
+	return toAPIError(ctx, err).HTTPStatusCode
+}
+
+// websiteHandler - serves bucket as a static website: keys ending with a
+// slash are served with the index document, the error document is served
+// for missing keys and routing rules redirect requests.
+func (api objectAPIHandlers) websiteHandler(bucket string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := newContext(r, w, "Website")
+
+		defer logger.AuditLog(w, r, "Website")
+
+		objectAPI := api.ObjectAPI()
+		if objectAPI == nil {
+			writeWebsiteError(w, r, http.StatusServiceUnavailable)
+			return
+		}
+		var cfg websiteConfig
+		if l, ok := objectAPI.(*radioObjects); ok {
+			cfg, _ = l.website(bucket)
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeWebsiteError(w, r, http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, SlashSeparator)
+		if rd, target, ok := cfg.redirectFor(key, 0); ok {
+			http.Redirect(w, r, rd.location(r, target), rd.code())
+			return
+		}
+
+		object := key
+		if object == "" || HasSuffix(object, SlashSeparator) {
+			object += cfg.IndexDocument
+		}
+		err := api.serveWebsiteObject(ctx, w, r, bucket, object, http.StatusOK)
+		if err == nil {
+			return
+		}
+		status := websiteStatus(ctx, err)
+
+		// Redirect a "directory" without its trailing slash to the
+		// key of its index document.
+		if status == http.StatusNotFound && object == key && key != "" {
+			if _, ierr := api.websiteObjectInfo(ctx, bucket, key+SlashSeparator+cfg.IndexDocument); ierr == nil {
+				http.Redirect(w, r, SlashSeparator+key+SlashSeparator, http.StatusFound)
+				return
+			}
+		}
+		if rd, target, ok := cfg.redirectFor(key, status); ok {
+			http.Redirect(w, r, rd.location(r, target), rd.code())
+			return
+		}
+		if status == http.StatusNotFound && cfg.ErrorDocument != "" {
+			if err = api.serveWebsiteObject(ctx, w, r, bucket, cfg.ErrorDocument, status); err == nil {
+				return
+			}
+			if websiteStatus(ctx, err) != http.StatusNotFound {
+				logger.LogIf(ctx, err)
+			}
+		} else if status >= http.StatusInternalServerError {
+			logger.LogIf(ctx, err)
+		}
+		writeWebsiteError(w, r, status)
+	}
+}
+
+// websiteObjectInfo - returns the info of object, from the cache if any.
+func (api objectAPIHandlers) websiteObjectInfo(ctx context.Context, bucket, object string) (ObjectInfo, error) {
+	getObjectInfo := api.ObjectAPI().GetObjectInfo
+	if api.CacheAPI() != nil {
+		getObjectInfo = api.CacheAPI().GetObjectInfo
+	}
+	return getObjectInfo(ctx, bucket, object, ObjectOptions{})
+}
+
+// serveWebsiteObject - writes object with status, the range and the
+// preconditions of r only apply to 200 responses. Returns the error of
+// reading object if nothing was written.
+func (api objectAPIHandlers) serveWebsiteObject(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	bucket, object string, status int) error {
+	var rs *HTTPRangeSpec
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && status == http.StatusOK {
+		var err error
+		if rs, err = parseRequestRangeSpec(rangeHeader); err != nil {
+			// Like GetObject, ignore unparsable ranges.
+			if err == errInvalidRange {
+				return err
+			}
+			rs = nil
+		}
+	}
+
+	var objInfo ObjectInfo
+	var body io.Reader
+	if r.Method == http.MethodGet {
+		getObjectNInfo := api.ObjectAPI().GetObjectNInfo
+		if api.CacheAPI() != nil {
+			getObjectNInfo = api.CacheAPI().GetObjectNInfo
+		}
+		gr, err := getObjectNInfo(ctx, bucket, object, rs, r.Header, ReadLock, ObjectOptions{})
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		objInfo, body = gr.ObjInfo, gr
+	} else {
+		var err error
+		if objInfo, err = api.websiteObjectInfo(ctx, bucket, object); err != nil {
+			return err
+		}
+	}
+
+	if status == http.StatusOK && checkPreconditions(ctx, w, r, objInfo) {
+		return nil
+	}
+	if err := setObjectHeaders(w, objInfo, rs); err != nil {
+		return err
+	}
+	switch {
+	case rs != nil:
+		w.WriteHeader(http.StatusPartialContent)
+	case status != http.StatusOK:
+		w.WriteHeader(status)
+	}
+	if body != nil {
+		// Headers are written, errors can only be logged.
+		if _, err := copyBuffer(w, body); err != nil {
+			logger.LogIf(ctx, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebsiteConfig(t *testing.T) {
+	testCases := []struct {
+		c    websiteConfig
+		errs int
+	}{
+		{websiteConfig{}, 0},
+		{websiteConfig{Hosts: []string{"www.example.com"}, IndexDocument: "index.html", ErrorDocument: "404.html"}, 0},
+		{websiteConfig{Hosts: []string{"example.com"}, RedirectAllRequestsTo: websiteRedirect{HostName: "www.example.com", Protocol: "https"}}, 0},
+		{websiteConfig{IndexDocument: "index.html"}, 1},
+		{websiteConfig{Hosts: []string{"www.example.com"}}, 1},
+		{websiteConfig{Hosts: []string{"site.example.com:9000"}, IndexDocument: "a/index.html"}, 2},
+		{websiteConfig{Hosts: []string{"TAKEN.example.com"}, IndexDocument: "index.html"}, 1},
+		{websiteConfig{Hosts: []string{"a.example.com"}, IndexDocument: "index.html",
+			RedirectAllRequestsTo: websiteRedirect{HostName: "b.example.com", Protocol: "ftp", HTTPRedirectCode: 302}}, 3},
+		{websiteConfig{Hosts: []string{"c.example.com"}, IndexDocument: "index.html", RoutingRules: []websiteRoutingRule{
+			{Condition: websiteCondition{KeyPrefixEquals: "docs/"}, Redirect: websiteRedirect{ReplaceKeyPrefixWith: "documents/"}},
+			{Condition: websiteCondition{HTTPErrorCodeReturnedEquals: 404}, Redirect: websiteRedirect{HostName: "d.example.com", HTTPRedirectCode: 302}},
+			{Condition: websiteCondition{HTTPErrorCodeReturnedEquals: 200}},
+			{Redirect: websiteRedirect{ReplaceKeyWith: "a", ReplaceKeyPrefixWith: "b", HTTPRedirectCode: 200}},
+		}}, 4},
+	}
+	for i, testCase := range testCases {
+		var errs radioConfigErrors
+		websiteHosts := map[string]string{"taken.example.com": "mirror[0].website"}
+		validateWebsiteConfig(&errs, "website", testCase.c, websiteHosts)
+		if len(errs) != testCase.errs {
+			t.Errorf("Test %d: expected %d errors, got %v", i+1, testCase.errs, errs)
+		}
+	}
+}
+
+func TestWebsiteRedirect(t *testing.T) {
+	c := websiteConfig{
+		Hosts:         []string{"www.example.com"},
+		IndexDocument: "index.html",
+		RoutingRules: []websiteRoutingRule{
+			{Condition: websiteCondition{KeyPrefixEquals: "docs/"}, Redirect: websiteRedirect{ReplaceKeyPrefixWith: "documents/"}},
+			{Condition: websiteCondition{KeyPrefixEquals: "old"}, Redirect: websiteRedirect{ReplaceKeyWith: "new.html", HTTPRedirectCode: 302}},
+			{Condition: websiteCondition{HTTPErrorCodeReturnedEquals: 404}, Redirect: websiteRedirect{HostName: "fallback.example.com", Protocol: "https"}},
+		},
+	}
+	testCases := []struct {
+		key      string
+		status   int
+		ok       bool
+		location string
+		code     int
+	}{
+		{"docs/a.html", 0, true, "http://www.example.com/documents/a.html", 301},
+		{"old/page.html", 0, true, "http://www.example.com/new.html", 302},
+		{"index.html", 0, false, "", 0},
+		{"missing.html", 404, true, "https://fallback.example.com/missing.html", 301},
+		{"missing.html", 403, false, "", 0},
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.com/", nil)
+	for i, testCase := range testCases {
+		rd, target, ok := c.redirectFor(testCase.key, testCase.status)
+		if ok != testCase.ok {
+			t.Fatalf("Test %d: expected redirect %v, got %v", i+1, testCase.ok, ok)
+		}
+		if !ok {
+			continue
+		}
+		if location := rd.location(r, target); location != testCase.location {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.location, location)
+		}
+		if rd.code() != testCase.code {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.code, rd.code())
+		}
+	}
+
+	all := websiteConfig{Hosts: []string{"example.com"}, RedirectAllRequestsTo: websiteRedirect{HostName: "www.example.com"}}
+	rd, target, ok := all.redirectFor("a/b.html", 0)
+	if !ok || rd.location(r, target) != "http://www.example.com/a/b.html" {
+		t.Errorf("expected all requests to be redirected, got %v %s", ok, rd.location(r, target))
+	}
+}
+
+func TestWebsiteConfigXML(t *testing.T) {
+	c := websiteConfig{
+		Hosts:         []string{"www.example.com"},
+		IndexDocument: "index.html",
+		ErrorDocument: "error.html",
+		RoutingRules: []websiteRoutingRule{
+			{Condition: websiteCondition{KeyPrefixEquals: "docs/"}, Redirect: websiteRedirect{ReplaceKeyPrefixWith: "documents/"}},
+		},
+	}
+	data, err := xml.Marshal(c.toXML())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>error.html</Key></ErrorDocument>` +
+		`<RoutingRules><RoutingRule><Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>` +
+		`<Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules>` +
+		`</WebsiteConfiguration>`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestWriteWebsiteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeWebsiteError(w, httptest.NewRequest(http.MethodGet, "/missing", nil), http.StatusNotFound)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "404 Not Found") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	writeWebsiteError(w, httptest.NewRequest(http.MethodHead, "/missing", nil), http.StatusNotFound)
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no body for HEAD, got %s", w.Body.String())
+	}
+}
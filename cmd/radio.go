@@ -5,21 +5,21 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/minio/cli"
 	miniogo "github.com/minio/minio-go/v6"
 	"github.com/minio/minio-go/v6/pkg/credentials"
 	"github.com/minio/minio/pkg/dsync"
-	"gopkg.in/yaml.v2"
 
 	"github.com/minio/minio-go/v6/pkg/encrypt"
-	"github.com/minio/minio-go/v6/pkg/s3utils"
 	"github.com/minio/minio/pkg/sync/errgroup"
+	xhttp "github.com/minio/radio/cmd/http"
 	"github.com/minio/radio/cmd/logger"
-	"github.com/minio/radio/pkg/streamdup"
 )
 
 const radioTemplate = `NAME:
@@ -39,15 +39,15 @@ EXAMPLES:
 
 // Handler for 'minio radio s3' command line.
 func radioMain(ctx *cli.Context) {
-	data, err := ioutil.ReadFile(ctx.String("config"))
+	globalRadioConfigFile = ctx.String("config")
+	data, err := ioutil.ReadFile(globalRadioConfigFile)
 	if err != nil {
 		logger.FatalIf(err, "Invalid command line arguments")
 	}
 
-	rconfig := radioConfig{}
-	err = yaml.Unmarshal(data, &rconfig)
+	rconfig, err := loadServerConfig(data)
 	if err != nil {
-		logger.FatalIf(err, "Invalid command line arguments")
+		logger.FatalIf(err, "Invalid configuration")
 	}
 
 	endpoints, err := createServerEndpoints(ctx.String("address"), rconfig.Distribute.Peers)
@@ -66,29 +66,22 @@ type Radio struct {
 	rconfig   radioConfig
 }
 
-// newS3 - Initializes a new client by auto probing S3 server signature.
-func newS3(bucket, urlStr, accessKey, secretKey, sessionToken string) (*miniogo.Core, error) {
-	u, err := url.Parse(urlStr)
+// newS3 - Initializes a new client of the remote bCfg.
+func newS3(bCfg bucketConfig, creds *credentials.Credentials) (*miniogo.Core, error) {
+	u, err := url.Parse(bCfg.Endpoint)
 	if err != nil {
 		return nil, err
 	}
-	options := miniogo.Options{
-		Creds:        credentials.NewStaticV4(accessKey, secretKey, sessionToken),
-		Secure:       u.Scheme == "https",
-		Region:       s3utils.GetRegionFromURL(*u),
-		BucketLookup: miniogo.BucketLookupAuto,
-	}
-
-	clnt, err := miniogo.NewWithOptions(u.Host, &options)
+	clnt, err := miniogo.NewWithOptions(u.Host, remoteOptions(bCfg, u, creds))
 	if err != nil {
 		return nil, err
 	}
 
 	// Set custom transport
-	clnt.SetCustomTransport(NewCustomHTTPTransport())
+	clnt.SetCustomTransport(newChaosTransport(clnt.EndpointURL().String(), bCfg.Bucket, NewCustomHTTPTransport()))
 
 	// Check if the provided keys are valid.
-	if _, err = clnt.BucketExists(bucket); err != nil {
+	if _, err = clnt.BucketExists(bCfg.Bucket); err != nil {
 		return nil, err
 	}
 
@@ -101,10 +94,49 @@ type bucketConfig struct {
 	AccessKey    string `yaml:"access_key"`
 	SecretKey    string `yaml:"secret_key"`
 	SessionToken string `yaml:"sessionToken"`
+
+	Credentials credentialsConfig `yaml:"credentials"`
+
+	// StorageClass maps the storage class requested by clients to the
+	// classes of a remote, Default is used when clients request none.
+	StorageClass storageClassConfig `yaml:"storage_class"`
+
+	// Keys maps the keys of objects to the keys on a remote.
+	Keys keyMappingConfig `yaml:"keys"`
+
+	// Shadow receives the writes of a mirror without serving reads,
+	// its failures never fail requests.
+	Shadow bool `yaml:"shadow"`
+
+	// ReadSample is the percentage of GET requests also sent to a
+	// shadow, the responses are discarded.
+	ReadSample float64 `yaml:"read_sample"`
+
+	// Region of a mirror remote, its reads are preferably served to
+	// the clients located in the same region. Networks lists the
+	// addresses and CIDRs of the clients it preferably serves.
+	Region   string   `yaml:"region"`
+	Networks []string `yaml:"networks"`
+
+	// MaxPartSize is the largest part the remote accepts, such as
+	// 100MiB, 5GiB if empty. Larger parts are rejected before they are
+	// sent to any remote.
+	MaxPartSize string `yaml:"max_part_size"`
+
+	// SigningRegion signs the requests to a remote, looked up from the
+	// bucket if empty. Addressing is auto, path or virtual-host.
+	SigningRegion string `yaml:"signing_region"`
+	Addressing    string `yaml:"addressing"`
+}
+
+type storageClassConfig struct {
+	Default string            `yaml:"default"`
+	Map     map[string]string `yaml:"map"`
 }
 
 // radioConfig radio configuration
 type radioConfig struct {
+	Version    int `yaml:"version"`
 	Distribute struct {
 		Peers string `yaml:"peers"`
 		Token string `yaml:"token"`
@@ -114,33 +146,175 @@ type radioConfig struct {
 			CAPath   string `yaml:"ca_path"`
 		} `yaml:"certs"`
 	} `yaml:"distribute"`
+	// Cache is overridden by the RADIO_CACHE_ ENVs of config/cache.
 	Cache struct {
 		Drives  []string `yaml:"drives"`
 		Exclude []string `yaml:"exclude"`
 		Quota   int      `yaml:"quota"`
 		Expiry  int      `yaml:"expiry"`
-	} `yaml:"cache"`
+		// RAMSize enables the in-memory tier for objects up to
+		// RAMMaxObjectSize, such as 256MiB and 1MiB.
+		RAMSize          string `yaml:"ram_size"`
+		RAMMaxObjectSize string `yaml:"ram_max_object_size"`
+		// Policy is lru or lfu, Admission is all or tinylfu.
+		Policy    string `yaml:"policy"`
+		Admission string `yaml:"admission"`
+		// PrefetchWindow is read ahead of sequential range reads,
+		// such as 8MiB.
+		PrefetchWindow string `yaml:"prefetch_window"`
+		// Dedup stores the identical data of cached objects, such as
+		// container layers, once per drive.
+		Dedup bool `yaml:"dedup"`
+		// Index keeps an index of the objects cached on each drive
+		// instead of walking the drives to evict objects.
+		Index bool `yaml:"index"`
+	} `yaml:"cache" env:"-"`
+	Admin struct {
+		AccessKey string `yaml:"access_key"`
+		SecretKey string `yaml:"secret_key"`
+		// PprofAddress - ADDRESS:PORT of an optional listener serving
+		// the Go profiles to the admin credential.
+		PprofAddress string `yaml:"pprof_address"`
+	} `yaml:"admin"`
+	API struct {
+		MaxObjectSize   string `yaml:"max_object_size"`
+		MaxHeaderSize   string `yaml:"max_header_size"`
+		MaxMetadataSize string `yaml:"max_metadata_size"`
+		JSONErrors      bool   `yaml:"json_errors"`
+		// ParallelGetThreshold enables reading objects of at least
+		// this size in ParallelGetPartSize parts from all remotes.
+		ParallelGetThreshold string `yaml:"parallel_get_threshold"`
+		ParallelGetPartSize  string `yaml:"parallel_get_part_size"`
+		// ParallelGetMaxMemory limits the parts buffered by all
+		// parallel reads together.
+		ParallelGetMaxMemory string `yaml:"parallel_get_max_memory"`
+		// SlowRequestThreshold logs S3 requests taking at least this
+		// long, such as 2s.
+		SlowRequestThreshold string `yaml:"slow_request_threshold"`
+		// CopyBufferSize of the pooled buffers copying object data
+		// between clients and remotes, such as 128KiB.
+		CopyBufferSize string `yaml:"copy_buffer_size"`
+		// MaxHeaderBytes limits the request line and headers read from
+		// clients, MaxHeaderCount the header values of a request.
+		MaxHeaderBytes string `yaml:"max_header_bytes"`
+		MaxHeaderCount int    `yaml:"max_header_count"`
+		// ReadHeaderTimeout limits the time to read the headers of a
+		// request, IdleTimeout the time a keep-alive connection waits
+		// for the next request, such as 10s.
+		ReadHeaderTimeout string `yaml:"read_header_timeout"`
+		IdleTimeout       string `yaml:"idle_timeout"`
+		// MaxConnsPerIP limits the open connections of a client
+		// address, unlimited by default.
+		MaxConnsPerIP int `yaml:"max_conns_per_ip"`
+		// ProxyProtocolSources lists the addresses or CIDRs of the
+		// proxies whose PROXY protocol headers are honored.
+		ProxyProtocolSources []string `yaml:"proxy_protocol_sources"`
+		// TrustedProxies lists the addresses or CIDRs of the proxies
+		// whose X-Forwarded-For, X-Real-IP and Forwarded headers are
+		// honored, those of all clients are honored if empty.
+		TrustedProxies []string `yaml:"trusted_proxies"`
+	} `yaml:"api"`
+	// Listen adds listeners to --address, with their own TLS settings.
+	Listen []listenConfig `yaml:"listen"`
+	// Metrics limits the scrapers of the Prometheus metrics.
+	Metrics metricsConfig `yaml:"metrics"`
+	// Authorizer is called to allow every authenticated request.
+	Authorizer authorizerConfig `yaml:"authorizer"`
+	// Scan scans uploads for malware.
+	Scan scanConfig `yaml:"scan"`
+	// Changes logs the mutations performed through this server.
+	Changes changeFeedConfig `yaml:"changes"`
+	// Durability syncs the writes of the cache and the journals.
+	Durability durabilityConfig `yaml:"durability"`
+	// RequestStats exports a record of every S3 request.
+	RequestStats requestStatsConfig `yaml:"request_stats"`
+	// Fanout buffers the uploads to remotes lagging behind the others.
+	Fanout fanoutConfig `yaml:"fanout"`
+	// Usage accounts the requests of every access key by day.
+	Usage usageConfig `yaml:"usage"`
+	// ConfigAudit logs the config changes made through the admin API.
+	ConfigAudit configAuditConfig `yaml:"config_audit"`
+	// Probe tests the capabilities of the remotes of mirrors.
+	Probe probeConfig `yaml:"probe"`
+	// Locality locates clients for the reads of mirrors with remotes
+	// in several regions.
+	Locality localityConfig `yaml:"locality"`
+	// Tenants own buckets and credentials, isolated from each other.
+	Tenants []tenantConfig `yaml:"tenants"`
+	// SFTP serves the mirror buckets to SFTP clients.
+	SFTP sftpConfig `yaml:"sftp"`
+	// ContentType detects the Content-Type of uploads without one.
+	ContentType contentTypeConfig `yaml:"content_type"`
+	// WebDAV exports mirror buckets read-only to WebDAV clients.
+	WebDAV webdavConfig `yaml:"webdav"`
 	Mirror []struct {
 		Local  bucketConfig   `yaml:"local"`
 		Remote []bucketConfig `yaml:"remote"`
+		// Tenant owning the bucket.
+		Tenant string `yaml:"tenant"`
+		// Metadata transforms the metadata of objects written
+		// to the remotes.
+		Metadata metadataRulesConfig `yaml:"metadata"`
+		// Inventory writes periodic listings of the bucket to
+		// another mirror bucket.
+		Inventory inventoryConfig `yaml:"inventory"`
+		// Website serves the bucket as a static website.
+		Website websiteConfig `yaml:"website"`
+		// Trash keeps deleted objects for a while.
+		Trash trashConfig `yaml:"trash"`
+		// Integrity compares the copies of sampled objects on
+		// the remotes.
+		Integrity integrityConfig `yaml:"integrity"`
+		// ContentType overrides the detection of Content-Types
+		// for the bucket.
+		ContentType contentTypeConfig `yaml:"content_type"`
+		// Pull copies the objects of buckets not served by radio
+		// to the bucket periodically.
+		Pull []pullConfig `yaml:"pull"`
 	} `yaml:"mirror"`
 	Erasure []struct {
 		Parity int            `yaml:"parity"`
 		Local  bucketConfig   `yaml:"local"`
 		Remote []bucketConfig `yaml:"remote"`
+		Tenant string         `yaml:"tenant"`
 	} `yaml:"erasure"`
 }
 
 func newBucketClients(bcfgs []bucketConfig) ([]bucketClient, error) {
 	var clnts []bucketClient
 	for _, bCfg := range bcfgs {
-		clnt, err := newS3(bCfg.Bucket, bCfg.Endpoint, bCfg.AccessKey, bCfg.SecretKey, bCfg.SessionToken)
+		creds, err := newRemoteCredentials(bCfg)
+		if err != nil {
+			return nil, err
+		}
+		clnt, err := newS3(bCfg, creds)
+		if err != nil {
+			return nil, err
+		}
+		networks, err := parseIPNets(bCfg.Networks)
+		if err != nil {
+			return nil, err
+		}
+		maxPartSize, err := parseMaxPartSize(bCfg.MaxPartSize)
 		if err != nil {
 			return nil, err
 		}
 		clnts = append(clnts, bucketClient{
-			Core:   clnt,
-			Bucket: bCfg.Bucket,
+			Core:          clnt,
+			Bucket:        bCfg.Bucket,
+			storageClass:  bCfg.StorageClass,
+			keys:          bCfg.Keys,
+			shadow:        bCfg.Shadow,
+			readSample:    bCfg.ReadSample,
+			readSlots:     make(chan struct{}, shadowMaxSampledReads),
+			region:        bCfg.Region,
+			networks:      networks,
+			maxPartSize:   maxPartSize,
+			signingRegion: bCfg.SigningRegion,
+			addressing:    bCfg.Addressing,
+			state:         &remoteState{pending: make(map[string]struct{})},
+			creds:         creds,
+			httpClient:    &http.Client{Transport: newChaosTransport(clnt.EndpointURL().String(), bCfg.Bucket, NewCustomHTTPTransport())},
 		})
 	}
 	return clnts, nil
@@ -155,11 +329,37 @@ func (g *Radio) NewRadioLayer() (ObjectLayer, error) {
 
 	s := radioObjects{
 		multipartUploadIDMap: make(map[string][]string),
+		parts:                newPartJournal(),
 		endpoints:            g.endpoints,
 		radioLockers:         radioLockers,
 		nsMutex:              newNSLock(len(radioLockers) > 0),
 		mirrorClients:        make(map[string]mirrorConfig),
 		erasureClients:       make(map[string]erasureConfig),
+		restores:             newRestoreTracker(),
+		batchJobs:            newBatchJobTracker(),
+	}
+	if g.rconfig.Scan.Endpoint != "" {
+		scanner, err := newContentScanner(g.rconfig.Scan)
+		if err != nil {
+			return nil, err
+		}
+		s.scanner = scanner
+	}
+	if g.rconfig.Changes.Dir != "" {
+		changes, err := newChangeFeed(g.rconfig.Changes)
+		if err != nil {
+			return nil, err
+		}
+		s.changes = changes
+		go changes.runExpiry()
+	}
+
+	var geoIP *geoIPTable
+	if g.rconfig.Locality.GeoIPFile != "" {
+		var err error
+		if geoIP, err = loadGeoIPTable(g.rconfig.Locality.GeoIPFile); err != nil {
+			return nil, err
+		}
 	}
 
 	// creds are ignored here, since S3 radio implements chaining all credentials.
@@ -168,8 +368,26 @@ func (g *Radio) NewRadioLayer() (ObjectLayer, error) {
 		if err != nil {
 			return nil, err
 		}
+		clnts, shadows := splitShadows(clnts)
+		var pulls []*pullSource
+		for _, pcfg := range remotes.Pull {
+			srcs, err := newBucketClients([]bucketConfig{pcfg.Source})
+			if err != nil {
+				return nil, err
+			}
+			pulls = append(pulls, &pullSource{config: pcfg, clnt: srcs[0]})
+		}
 		s.mirrorClients[remotes.Local.Bucket] = mirrorConfig{
-			clnts: clnts,
+			clnts:         clnts,
+			shadows:       shadows,
+			metadataRules: remotes.Metadata,
+			inventory:     remotes.Inventory,
+			website:       remotes.Website,
+			trash:         remotes.Trash,
+			integrity:     remotes.Integrity,
+			syncState:     newSyncState(),
+			geoIP:         geoIP,
+			pulls:         pulls,
 		}
 	}
 	for _, remotes := range g.rconfig.Erasure {
@@ -182,6 +400,26 @@ func (g *Radio) NewRadioLayer() (ObjectLayer, error) {
 			clnts:  clnts,
 		}
 	}
+	for bucket, rs3s := range s.mirrorClients {
+		if rs3s.inventory.enabled() {
+			go s.runInventory(bucket, rs3s.inventory.interval())
+		}
+		if rs3s.trash.enabled() {
+			go s.runTrashPurge(bucket)
+		}
+		if rs3s.integrity.enabled() {
+			go s.runIntegritySampling(bucket)
+		}
+		for _, p := range rs3s.pulls {
+			go s.runPull(bucket, p)
+		}
+	}
+	if !g.rconfig.Probe.SkipStartup {
+		go s.probeRemotes(newBackgroundContext("ProbeRemotes", "", ""))
+	}
+	if globalTenants != nil {
+		go s.runTenantUsage(globalTenants)
+	}
 	return &s, nil
 }
 
@@ -193,10 +431,65 @@ func (g *Radio) Production() bool {
 type bucketClient struct {
 	*miniogo.Core
 	Bucket string
+
+	storageClass storageClassConfig
+	keys         keyMappingConfig
+	shadow       bool
+	readSample   float64
+	// readSlots limits the sampled reads in flight.
+	readSlots chan struct{}
+	// region and networks locate the clients the remote serves reads.
+	region   string
+	networks []*net.IPNet
+	// maxPartSize is the largest part the remote accepts.
+	maxPartSize int64
+	// signingRegion and addressing override the region requests are
+	// signed for and how the bucket is addressed.
+	signingRegion string
+	addressing    string
+
+	// state is shared by all copies of the client.
+	state *remoteState
+
+	// creds and httpClient send the requests minio-go does not
+	// implement, see signedRequest.
+	creds      *credentials.Credentials
+	httpClient *http.Client
+}
+
+// remoteMetadata - returns the metadata sent to the remote, with the
+// storage class requested by the client mapped to the class of the
+// remote.
+func (clnt bucketClient) remoteMetadata(metadata map[string]string) map[string]string {
+	mm := ToMinioClientMetadata(metadata)
+	key := http.CanonicalHeaderKey(xhttp.AmzStorageClass)
+	switch sc := mm[key]; {
+	case sc == "":
+		if clnt.storageClass.Default != "" {
+			mm[key] = clnt.storageClass.Default
+		}
+	case clnt.storageClass.Map[sc] != "":
+		mm[key] = clnt.storageClass.Map[sc]
+	}
+	return mm
 }
 
 type mirrorConfig struct {
 	clnts []bucketClient
+	// shadows receive the writes to clnts but serve no reads.
+	shadows       []bucketClient
+	metadataRules metadataRulesConfig
+	inventory     inventoryConfig
+	website       websiteConfig
+	trash         trashConfig
+	integrity     integrityConfig
+	// syncState tracks the prefixes which may have diverged.
+	syncState *syncState
+	// geoIP locates the clients of localized mirrors, nil if not
+	// configured.
+	geoIP *geoIPTable
+	// pulls are the buckets not served by radio copied to the bucket.
+	pulls []*pullSource
 }
 
 type erasureConfig struct {
@@ -211,7 +504,14 @@ type radioObjects struct {
 	mirrorClients        map[string]mirrorConfig
 	erasureClients       map[string]erasureConfig
 	multipartUploadIDMap map[string][]string
+	parts                *partJournal
 	nsMutex              *NSLockMap
+	restores             *restoreTracker
+	batchJobs            *batchJobTracker
+	// scanner scans uploads, nil if scanning is not configured.
+	scanner *contentScanner
+	// changes logs mutations, nil if the change feed is not configured.
+	changes *changeFeed
 }
 
 func (l *radioObjects) NewNSLock(ctx context.Context, bucket string, object string) RWLocker {
@@ -246,29 +546,58 @@ func (l *radioObjects) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
 
 // ListObjects lists all blobs in S3 bucket filtered by prefix
 func (l *radioObjects) ListObjects(ctx context.Context, bucket string, prefix string, marker string, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
+	if err := preBackendHooks(ctx, "ListObjects", bucket, prefix); err != nil {
+		return loi, err
+	}
 	rs3, ok := l.mirrorClients[bucket]
 	if !ok {
 		return loi, BucketNotFound{
 			Bucket: bucket,
 		}
 	}
-	result, err := rs3.clnts[0].ListObjects(rs3.clnts[0].Bucket, prefix, marker, delimiter, maxKeys)
+	clnt := rs3.listClient()
+	var result miniogo.ListBucketResult
+	var err error
+	if clnt.keys.isZero() {
+		result, err = clnt.ListObjects(clnt.Bucket, prefix, listMarker(prefix, marker, delimiter), delimiter, maxKeys)
+	} else {
+		result, err = clnt.listObjects(prefix, listMarker(prefix, marker, delimiter), delimiter, maxKeys)
+	}
 	if err != nil {
 		return loi, ErrorRespToObjectError(err, bucket)
 	}
+	completeListObjectsV1(&result)
 
 	return FromMinioClientListBucketResult(bucket, result), nil
 }
 
 // ListObjectsV2 lists all blobs in S3 bucket filtered by prefix
 func (l *radioObjects) ListObjectsV2(ctx context.Context, bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (loi ListObjectsV2Info, e error) {
+	if err := preBackendHooks(ctx, "ListObjectsV2", bucket, prefix); err != nil {
+		return loi, err
+	}
 	rs3, ok := l.mirrorClients[bucket]
 	if !ok {
 		return loi, BucketNotFound{
 			Bucket: bucket,
 		}
 	}
-	result, err := rs3.clnts[0].ListObjectsV2(rs3.clnts[0].Bucket, prefix,
+	clnt := rs3.listClient()
+	if !clnt.keys.isZero() {
+		// The continuation token is the last key listed.
+		if continuationToken != "" {
+			startAfter = continuationToken
+		}
+		result, err := clnt.listObjects(prefix, startAfter, delimiter, maxKeys)
+		if err != nil {
+			return loi, ErrorRespToObjectError(err, bucket)
+		}
+		loi = FromMinioClientListBucketResultToV2Info(bucket, result)
+		loi.ContinuationToken = continuationToken
+		return loi, nil
+	}
+
+	result, err := clnt.ListObjectsV2(clnt.Bucket, prefix,
 		continuationToken, fetchOwner, delimiter, maxKeys, startAfter)
 	if err != nil {
 		return loi, ErrorRespToObjectError(err, bucket)
@@ -279,6 +608,9 @@ func (l *radioObjects) ListObjectsV2(ctx context.Context, bucket, prefix, contin
 
 // GetObjectNInfo - returns object info and locked object ReadCloser
 func (l *radioObjects) GetObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, o ObjectOptions) (gr *GetObjectReader, err error) {
+	if err := preBackendHooks(ctx, "GetObjectNInfo", bucket, object); err != nil {
+		return nil, err
+	}
 	var nsUnlocker = func() {}
 
 	// Acquire lock
@@ -314,28 +646,18 @@ func (l *radioObjects) GetObjectNInfo(ctx context.Context, bucket, object string
 	if err != nil {
 		return nil, ErrorRespToObjectError(err, bucket, object)
 	}
+	traceRequestSize(ctx, info.Size)
+	rs3s.sampleReads(object, rs != nil, startOffset, length, o)
 
 	pr, pw := io.Pipe()
 	go func() {
-		opts := miniogo.GetObjectOptions{}
-		opts.ServerSideEncryption = o.ServerSideEncryption
-
-		if startOffset >= 0 && length >= 0 {
-			if err := opts.SetRange(startOffset, startOffset+length-1); err != nil {
-				pw.CloseWithError(ErrorRespToObjectError(err, bucket, object))
-				return
-			}
-		}
-
-		reader, _, _, err := rs3s.clnts[info.ReplicaIndex].GetObject(rs3s.clnts[info.ReplicaIndex].Bucket, object, opts)
-		if err != nil {
-			pw.CloseWithError(ErrorRespToObjectError(err, bucket, object))
+		apiCfg := globalAPIConfig
+		if apiCfg.ParallelGetThreshold > 0 && length >= apiCfg.ParallelGetThreshold && len(rs3s.clnts) > 1 {
+			pw.CloseWithError(readObjectParallel(ctx, rs3s, info, startOffset, length, apiCfg.ParallelGetPartSize,
+				globalParallelGetSlots, o, pw))
 			return
 		}
-		defer reader.Close()
-
-		_, err = io.Copy(pw, reader)
-		pw.CloseWithError(ErrorRespToObjectError(err, bucket, object))
+		pw.CloseWithError(readObjectWithFailover(ctx, rs3s, info, startOffset, length, o, pw))
 	}()
 
 	// Setup cleanup function to cause the above go-routine to
@@ -354,9 +676,16 @@ func (l *radioObjects) GetObject(ctx context.Context, bucket string, object stri
 	return NotImplemented{}
 }
 
-func quorumInfo(infos []miniogo.ObjectInfo) (miniogo.ObjectInfo, int, error) {
+// quorumInfo - returns the info held by a majority of the active remotes
+// and the index of a remote holding it.
+func quorumInfo(infos []miniogo.ObjectInfo, active []bool) (miniogo.ObjectInfo, int, error) {
 	tagCounter := map[string]int{}
-	for _, info := range infos {
+	n := 0
+	for index, info := range infos {
+		if !active[index] {
+			continue
+		}
+		n++
 		uuid := info.Metadata.Get("x-amz-meta-radio-tag")
 		_, ok := tagCounter[uuid]
 		if !ok {
@@ -367,7 +696,7 @@ func quorumInfo(infos []miniogo.ObjectInfo) (miniogo.ObjectInfo, int, error) {
 	}
 	var maximalUUID string
 	for uuid, count := range tagCounter {
-		if count > len(infos)/2+1 {
+		if count >= n/2+1 {
 			maximalUUID = uuid
 			break
 		}
@@ -380,7 +709,7 @@ func quorumInfo(infos []miniogo.ObjectInfo) (miniogo.ObjectInfo, int, error) {
 	var index int
 	for index, info = range infos {
 		uuid := info.Metadata.Get("x-amz-meta-radio-tag")
-		if uuid != maximalUUID {
+		if !active[index] || uuid != maximalUUID {
 			continue
 		}
 		break
@@ -396,14 +725,24 @@ func (l *radioObjects) getObjectInfo(ctx context.Context, bucket string, object
 		}
 	}
 
+	active, n := rs3s.activeRemotes()
 	oinfos := make([]miniogo.ObjectInfo, len(rs3s.clnts))
 	g := errgroup.WithNErrs(len(rs3s.clnts))
 	for index := range rs3s.clnts {
 		index := index
 		g.Go(func() error {
+			if !active[index] {
+				return errRemoteMaintenance
+			}
 			var perr error
+			start := time.Now()
+			defer func() {
+				if perr == nil {
+					rs3s.clnts[index].observeLatency(time.Since(start))
+				}
+			}()
 			oinfos[index], perr = rs3s.clnts[index].StatObject(rs3s.clnts[index].Bucket,
-				object, miniogo.StatObjectOptions{
+				rs3s.clnts[index].remoteKey(object), miniogo.StatObjectOptions{
 					GetObjectOptions: miniogo.GetObjectOptions{
 						ServerSideEncryption: opts.ServerSideEncryption,
 					},
@@ -412,20 +751,105 @@ func (l *radioObjects) getObjectInfo(ctx context.Context, bucket string, object
 		}, index)
 	}
 
-	if maxErr := reduceReadQuorumErrs(ctx, g.Wait(), nil, len(rs3s.clnts)/2+1); maxErr != nil {
+	if maxErr := reduceReadQuorumErrs(ctx, g.Wait(), []error{errRemoteMaintenance}, n/2+1); maxErr != nil {
 		return ObjectInfo{}, maxErr
 	}
 
-	info, rindex, err := quorumInfo(oinfos)
+	info, rindex, err := quorumInfo(oinfos, active)
 	if err != nil {
 		return ObjectInfo{}, ErrorRespToObjectError(err, bucket, object)
 	}
 
-	return FromMinioClientObjectInfo(bucket, info, rindex), nil
+	info.Key = object
+	objInfo = FromMinioClientObjectInfo(bucket, info, rindex)
+	if status := l.restores.status(bucket, object, objInfo.ETag); status != "" {
+		objInfo.UserDefined[xhttp.AmzRestore] = status
+	}
+	return objInfo, nil
+}
+
+// RestoreObject passes a restore request to the remotes holding object
+// archived, restored is true if all of them restored the object already.
+// The status of the restore is polled and reported by GetObjectInfo.
+func (l *radioObjects) RestoreObject(ctx context.Context, bucket string, object string, request []byte) (restored bool, err error) {
+	objInfo, err := l.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		return false, err
+	}
+	rs3s := l.mirrorClients[bucket]
+
+	active, _ := rs3s.activeRemotes()
+	statuses := make([]int, len(rs3s.clnts))
+	g := errgroup.WithNErrs(len(rs3s.clnts))
+	for index := range rs3s.clnts {
+		index := index
+		g.Go(func() error {
+			if !active[index] {
+				return errRemoteMaintenance
+			}
+			var rerr error
+			statuses[index], rerr = rs3s.clnts[index].restoreObject(ctx, object, request)
+			return rerr
+		}, index)
+	}
+
+	var clnts []bucketClient
+	var inProgress int
+	restored = true
+	for index, rerr := range g.Wait() {
+		switch rerr.(type) {
+		case nil:
+			clnts = append(clnts, rs3s.clnts[index])
+			if statuses[index] != http.StatusOK {
+				restored = false
+			}
+		case ObjectRestoreInProgress:
+			clnts = append(clnts, rs3s.clnts[index])
+			inProgress++
+			restored = false
+		case InvalidObjectState:
+			// Not archived on this remote.
+		default:
+			if rerr == errRemoteMaintenance {
+				continue
+			}
+			logger.LogIf(ctx, rerr)
+			if err == nil {
+				err = rerr
+			}
+		}
+	}
+	if len(clnts) == 0 {
+		if err == nil {
+			err = InvalidObjectState{Bucket: bucket, Object: object}
+		}
+		return false, err
+	}
+
+	l.restores.track(bucket, object, objInfo.ETag, clnts)
+	if inProgress == len(clnts) {
+		return false, ObjectRestoreInProgress{Bucket: bucket, Object: object}
+	}
+	return restored, nil
+}
+
+// setRadioTag - records a new radio tag identifying the version of an
+// object written by radio in metadata, replacing any tag copied from a
+// source object.
+func setRadioTag(metadata map[string]string) {
+	for k := range metadata {
+		if strings.EqualFold(k, "x-amz-meta-radio-tag") {
+			delete(metadata, k)
+		}
+	}
+	metadata["x-amz-meta-radio-tag"] = mustGetUUID()
 }
 
 // GetObjectInfo reads object info and replies back ObjectInfo
 func (l *radioObjects) GetObjectInfo(ctx context.Context, bucket string, object string, opts ObjectOptions) (objInfo ObjectInfo, err error) {
+	if err := preBackendHooks(ctx, "GetObjectInfo", bucket, object); err != nil {
+		return objInfo, err
+	}
 	// Lock the object before reading.
 	objectLock := l.NewNSLock(ctx, bucket, object)
 	if err := objectLock.GetRLock(globalObjectTimeout); err != nil {
@@ -438,6 +862,9 @@ func (l *radioObjects) GetObjectInfo(ctx context.Context, bucket string, object
 
 // PutObject creates a new object with the incoming data,
 func (l *radioObjects) PutObject(ctx context.Context, bucket string, object string, r *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error) {
+	if err := preBackendHooks(ctx, "PutObject", bucket, object); err != nil {
+		return objInfo, err
+	}
 	data := r.Reader
 
 	// Lock the object before reading.
@@ -452,23 +879,77 @@ func (l *radioObjects) PutObject(ctx context.Context, bucket string, object stri
 		return objInfo, BucketNotFound{Bucket: bucket}
 	}
 
-	readers, err := streamdup.New(data, len(rs3s.clnts))
+	var body io.Reader = data
+	scan := l.scanner.scans(bucket)
+	if c := opts.Checksum; scan || (c != nil && c.trailing) {
+		// A trailing checksum is only known once the body was read,
+		// read it before the upload so that the remotes receive the
+		// verified checksum with the object metadata. Scanned bodies
+		// are read before they reach any remote.
+		spooled, cleanup, err := spoolUpload(data, data.Size())
+		if err != nil {
+			return objInfo, err
+		}
+		defer cleanup()
+		if scan {
+			if err = l.scanUpload(ctx, bucket, object, spooled, data.Size(), true, opts.UserDefined); err != nil {
+				return objInfo, err
+			}
+		}
+		body = spooled
+	}
+
+	traceRequestSize(ctx, data.Size())
+
+	active, n := rs3s.activeRemotes()
+	if n == 0 {
+		return objInfo, InsufficientWriteQuorum{}
+	}
+
+	src := newHoldbackReader(body)
+	feeds := newShadowFeeds(len(rs3s.shadows))
+	readers, err := duplicateUpload(feeds.tee(src), n)
 	if err != nil {
 		return objInfo, ErrorRespToObjectError(err, bucket, object)
 	}
+	defer closeUploadReaders(readers, io.ErrClosedPipe)
+
+	opts.UserDefined = rs3s.metadataRules.apply(ctx, bucket, object, opts.UserDefined)
+	setRadioTag(opts.UserDefined)
+	if c := opts.Checksum; c != nil {
+		opts.UserDefined[c.MetaKey()] = c.expected
+	}
 
-	opts.UserDefined["x-amz-meta-radio-tag"] = mustGetUUID()
+	stopAbort := abortUploadOnCancel(ctx, readers)
+	defer stopAbort()
+
+	waitShadows := rs3s.shadowDo(ctx, "putobject", func(index int, clnt bucketClient) error {
+		defer feeds[index].CloseWithError(io.ErrClosedPipe)
+		_, err := clnt.PutObject(clnt.Bucket, clnt.remoteKey(object), feeds[index], data.Size(),
+			data.MD5Base64String(), data.SHA256HexString(),
+			clnt.remoteMetadata(opts.UserDefined), opts.ServerSideEncryption)
+		return err
+	})
 
 	oinfos := make([]miniogo.ObjectInfo, len(rs3s.clnts))
 	g := errgroup.WithNErrs(len(rs3s.clnts))
+	next := 0
 	for index := range rs3s.clnts {
 		index := index
+		if !active[index] {
+			rs3s.clnts[index].queueWrite(object)
+			g.Go(func() error { return errRemoteMaintenance }, index)
+			continue
+		}
+		reader := readers[next]
+		next++
 		g.Go(func() error {
 			var perr error
-			oinfos[index], perr = rs3s.clnts[index].PutObject(rs3s.clnts[index].Bucket, object,
-				readers[index], data.Size(),
+			oinfos[index], perr = rs3s.clnts[index].PutObject(rs3s.clnts[index].Bucket, rs3s.clnts[index].remoteKey(object),
+				reader, data.Size(),
 				data.MD5Base64String(), data.SHA256HexString(),
-				ToMinioClientMetadata(opts.UserDefined), opts.ServerSideEncryption)
+				rs3s.clnts[index].remoteMetadata(opts.UserDefined), opts.ServerSideEncryption)
+			traceRequestRemote(ctx, rs3s.clnts[index])
 			oinfos[index].Key = object
 			oinfos[index].Metadata = ToMinioClientObjectInfoMetadata(opts.UserDefined)
 			return perr
@@ -476,25 +957,73 @@ func (l *radioObjects) PutObject(ctx context.Context, bucket string, object stri
 	}
 
 	errs := g.Wait()
-	if maxErr := reduceWriteQuorumErrs(ctx, errs, nil, len(rs3s.clnts)/2+1); maxErr != nil {
+	rs3s.syncState.record(object, writeOK(errs))
+	maxErr := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, n/2+1)
+	if verr := src.Err(); verr != nil {
+		// Content-MD5, payload hash or the body read failed, remotes
+		// report this as a transport error.
+		maxErr = verr
+	} else if cerr := ctx.Err(); cerr != nil && maxErr != nil {
+		maxErr = cerr
+	}
+	feeds.CloseWithError(maxErr)
+	waitShadows()
+	if maxErr != nil {
+		// Roll back remotes which committed the object anyway.
 		for index, err := range errs {
 			if err == nil {
-				rs3s.clnts[index].RemoveObject(rs3s.clnts[index].Bucket, object)
+				rs3s.clnts[index].RemoveObject(rs3s.clnts[index].Bucket, rs3s.clnts[index].remoteKey(object))
 			}
 		}
+		rs3s.shadowDo(ctx, "removeobject", func(index int, clnt bucketClient) error {
+			return clnt.RemoveObject(clnt.Bucket, clnt.remoteKey(object))
+		})()
 		return objInfo, maxErr
 	}
+	l.healSkippedWrites(rs3s, active)
 
-	info, rindex, err := quorumInfo(oinfos)
+	info, rindex, err := quorumInfo(oinfos, active)
 	if err != nil {
 		return objInfo, err
 	}
 
-	return FromMinioClientObjectInfo(bucket, info, rindex), nil
+	objInfo = FromMinioClientObjectInfo(bucket, info, rindex)
+	l.changes.record(ctx, changeOpPut, objInfo)
+	return objInfo, nil
+}
+
+// abortUploadOnCancel - fails the duplicated upload streams once ctx is
+// canceled, remotes waiting for the body of a client which went away
+// abort the upload instead of waiting for it to time out. The returned
+// function stops watching ctx.
+func abortUploadOnCancel(ctx context.Context, readers []io.Reader) func() {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		select {
+		case <-ctx.Done():
+			select {
+			case <-stopCh:
+				// Stopped before ctx was canceled.
+				return
+			default:
+			}
+			closeUploadReaders(readers, ctx.Err())
+		case <-stopCh:
+		}
+	}()
+	return func() {
+		close(stopCh)
+		<-doneCh
+	}
 }
 
 // CopyObject copies an object from source bucket to a destination bucket.
 func (l *radioObjects) CopyObject(ctx context.Context, srcBucket string, srcObject string, dstBucket string, dstObject string, srcInfo ObjectInfo, srcOpts, dstOpts ObjectOptions) (objInfo ObjectInfo, err error) {
+	if err := preBackendHooks(ctx, "CopyObject", dstBucket, dstObject); err != nil {
+		return objInfo, err
+	}
 	// Check if this request is only metadata update.
 	cpSrcDstSame := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
 	if !cpSrcDstSame {
@@ -514,6 +1043,9 @@ func (l *radioObjects) CopyObject(ctx context.Context, srcBucket string, srcObje
 	// So preserve it by adding "REPLACE" directive to save all the metadata set by CopyObject API.
 	srcInfo.UserDefined["x-amz-metadata-directive"] = "REPLACE"
 	srcInfo.UserDefined["x-amz-copy-source-if-match"] = srcInfo.ETag
+	srcInfo.UserDefined = l.mirrorClients[dstBucket].metadataRules.apply(ctx, dstBucket, dstObject, srcInfo.UserDefined)
+	// The copy is a new version of the destination.
+	setRadioTag(srcInfo.UserDefined)
 	header := make(http.Header)
 	if srcOpts.ServerSideEncryption != nil {
 		encrypt.SSECopy(srcOpts.ServerSideEncryption).Marshal(header)
@@ -531,36 +1063,78 @@ func (l *radioObjects) CopyObject(ctx context.Context, srcBucket string, srcObje
 	if len(rs3sSrc.clnts) != len(rs3sDest.clnts) {
 		return objInfo, errors.New("unexpected")
 	}
+	if err = rs3sSrc.requireCapability(copyCapability); err == nil {
+		err = rs3sDest.requireCapability(copyCapability)
+	}
+	if err != nil {
+		return objInfo, err
+	}
+
+	waitShadows := rs3sDest.shadowDo(ctx, "copyobject", func(index int, clnt bucketClient) error {
+		if len(rs3sSrc.shadows) != len(rs3sDest.shadows) {
+			return errors.New("source bucket has no matching shadow remote")
+		}
+		src := rs3sSrc.shadows[index]
+		_, err := src.CopyObject(src.Bucket, src.remoteKey(srcObject),
+			clnt.Bucket, clnt.remoteKey(dstObject), clnt.remoteMetadata(srcInfo.UserDefined))
+		return err
+	})
+
+	// Remotes in maintenance on either side are skipped, the copy is
+	// healed on the destination later.
+	active, n := rs3sDest.activeRemotes()
+	for index, clnt := range rs3sSrc.clnts {
+		if active[index] && clnt.inMaintenance() {
+			active[index] = false
+			n--
+		}
+	}
+	if n == 0 {
+		return objInfo, InsufficientWriteQuorum{}
+	}
 
-	n := len(rs3sDest.clnts)
-	oinfos := make([]miniogo.ObjectInfo, n)
+	oinfos := make([]miniogo.ObjectInfo, len(rs3sDest.clnts))
 
-	g := errgroup.WithNErrs(n)
-	for index := 0; index < n; index++ {
+	g := errgroup.WithNErrs(len(rs3sDest.clnts))
+	for index := range rs3sDest.clnts {
 		index := index
 		g.Go(func() error {
+			if !active[index] {
+				rs3sDest.clnts[index].queueWrite(dstObject)
+				return errRemoteMaintenance
+			}
 			var err error
-			oinfos[index], err = rs3sSrc.clnts[index].CopyObject(rs3sSrc.clnts[index].Bucket, srcObject,
-				rs3sDest.clnts[index].Bucket, dstObject, srcInfo.UserDefined)
+			oinfos[index], err = rs3sSrc.clnts[index].CopyObject(rs3sSrc.clnts[index].Bucket, rs3sSrc.clnts[index].remoteKey(srcObject),
+				rs3sDest.clnts[index].Bucket, rs3sDest.clnts[index].remoteKey(dstObject), rs3sDest.clnts[index].remoteMetadata(srcInfo.UserDefined))
 			return err
 		}, index)
 	}
 
 	errs := g.Wait()
-	if maxErr := reduceWriteQuorumErrs(ctx, errs, nil, len(rs3sSrc.clnts)/2+1); maxErr != nil {
+	waitShadows()
+	rs3sDest.syncState.record(dstObject, writeOK(errs))
+	if maxErr := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, n/2+1); maxErr != nil {
 		for index, err := range errs {
 			if err == nil {
-				rs3sDest.clnts[index].RemoveObject(rs3sDest.clnts[index].Bucket, dstObject)
+				rs3sDest.clnts[index].RemoveObject(rs3sDest.clnts[index].Bucket, rs3sDest.clnts[index].remoteKey(dstObject))
 			}
 		}
 		return objInfo, maxErr
 	}
+	l.healSkippedWrites(rs3sDest, active)
 
-	return l.getObjectInfo(ctx, dstBucket, dstObject, dstOpts)
+	objInfo, err = l.getObjectInfo(ctx, dstBucket, dstObject, dstOpts)
+	if err == nil {
+		l.changes.record(ctx, changeOpCopy, objInfo)
+	}
+	return objInfo, err
 }
 
 // DeleteObject deletes a blob in bucket
 func (l *radioObjects) DeleteObject(ctx context.Context, bucket string, object string) error {
+	if err := preBackendHooks(ctx, "DeleteObject", bucket, object); err != nil {
+		return err
+	}
 	objectLock := l.NewNSLock(ctx, bucket, object)
 	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
 		return err
@@ -574,16 +1148,40 @@ func (l *radioObjects) DeleteObject(ctx context.Context, bucket string, object s
 		}
 	}
 
-	n := len(rs3s.clnts)
-	g := errgroup.WithNErrs(n)
-	for index := 0; index < n; index++ {
+	if rs3s.trash.enabled() && !rs3s.trash.holds(bucket, object) {
+		if err := l.moveToTrash(ctx, bucket, object, rs3s.trash); err != nil {
+			return err
+		}
+	}
+
+	waitShadows := rs3s.shadowDo(ctx, "deleteobject", func(index int, clnt bucketClient) error {
+		return clnt.RemoveObject(clnt.Bucket, clnt.remoteKey(object))
+	})
+
+	active, n := rs3s.activeRemotes()
+	g := errgroup.WithNErrs(len(rs3s.clnts))
+	for index := range rs3s.clnts {
 		index := index
 		g.Go(func() error {
-			return rs3s.clnts[index].RemoveObject(rs3s.clnts[index].Bucket, object)
+			if !active[index] {
+				rs3s.clnts[index].queueWrite(object)
+				return errRemoteMaintenance
+			}
+			return rs3s.clnts[index].RemoveObject(rs3s.clnts[index].Bucket, rs3s.clnts[index].remoteKey(object))
 		}, index)
 	}
 
-	return reduceWriteQuorumErrs(ctx, g.Wait(), nil, len(rs3s.clnts)/2+1)
+	errs := g.Wait()
+	waitShadows()
+	rs3s.syncState.record(object, writeOK(errs))
+	if n == 0 {
+		return InsufficientWriteQuorum{}
+	}
+	if err := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, n/2+1); err != nil {
+		return err
+	}
+	l.changes.record(ctx, changeOpDelete, ObjectInfo{Bucket: bucket, Name: object})
+	return nil
 }
 
 func (l *radioObjects) DeleteObjects(ctx context.Context, bucket string, objects []string) ([]error, error) {
@@ -601,20 +1199,31 @@ func (l *radioObjects) ListMultipartUploads(ctx context.Context, bucket string,
 		return lmi, BucketNotFound{Bucket: bucket}
 	}
 
-	result, err := rs3.clnts[0].ListMultipartUploads(rs3.clnts[0].Bucket, prefix,
+	clnt := rs3.listClient()
+	if keyMarker != "" {
+		keyMarker = clnt.remoteKey(keyMarker)
+	}
+	result, err := clnt.ListMultipartUploads(clnt.Bucket, clnt.keys.Prefix+prefix,
 		keyMarker, uploadIDMarker, delimiter, maxUploads)
 	if err != nil {
 		return lmi, err
 	}
+	clnt.keys.fromRemoteUploads(&result)
 
 	return FromMinioClientListMultipartsInfo(result), nil
 }
 
 // NewMultipartUpload upload object in multiple parts
 func (l *radioObjects) NewMultipartUpload(ctx context.Context, bucket string, object string, o ObjectOptions) (string, error) {
+	if err := preBackendHooks(ctx, "NewMultipartUpload", bucket, object); err != nil {
+		return "", err
+	}
+	if o.UserDefined == nil {
+		o.UserDefined = make(map[string]string)
+	}
+	o.UserDefined = l.mirrorClients[bucket].metadataRules.apply(ctx, bucket, object, o.UserDefined)
+	setRadioTag(o.UserDefined)
 
-	// Create PutObject options
-	opts := miniogo.PutObjectOptions{UserMetadata: o.UserDefined, ServerSideEncryption: o.ServerSideEncryption}
 	uploadID := mustGetUUID()
 
 	uploadIDLock := l.NewNSLock(ctx, bucket, pathJoin(object, uploadID))
@@ -628,21 +1237,55 @@ func (l *radioObjects) NewMultipartUpload(ctx context.Context, bucket string, ob
 		return uploadID, BucketNotFound{Bucket: bucket}
 	}
 
+	if _, n := rs3s.activeRemotes(); n == 0 {
+		return uploadID, InsufficientWriteQuorum{}
+	}
+	if err := rs3s.requireCapability(multipartCapability); err != nil {
+		return uploadID, err
+	}
+
 	for _, clnt := range rs3s.clnts {
-		id, err := clnt.NewMultipartUpload(clnt.Bucket, object, opts)
+		if clnt.inMaintenance() {
+			// Not part of the upload, healed later.
+			clnt.queueWrite(object)
+			l.multipartUploadIDMap[uploadID] = append(l.multipartUploadIDMap[uploadID], "")
+			continue
+		}
+		// Create PutObject options
+		opts := miniogo.PutObjectOptions{
+			UserMetadata:         clnt.remoteMetadata(o.UserDefined),
+			ServerSideEncryption: o.ServerSideEncryption,
+		}
+		id, err := clnt.NewMultipartUpload(clnt.Bucket, clnt.remoteKey(object), opts)
 		if err != nil {
 			// Abort any failed uploads to one of the radios
-			clnt.AbortMultipartUpload(clnt.Bucket, object, uploadID)
+			clnt.AbortMultipartUpload(clnt.Bucket, clnt.remoteKey(object), uploadID)
 			return uploadID, ErrorRespToObjectError(err, bucket, object)
 		}
 		l.multipartUploadIDMap[uploadID] = append(l.multipartUploadIDMap[uploadID], id)
 
 	}
+
+	// The ids on the shadow remotes follow, empty where the upload
+	// could not be started.
+	shadowIDs := make([]string, len(rs3s.shadows))
+	rs3s.shadowDo(ctx, "newmultipartupload", func(index int, clnt bucketClient) error {
+		var err error
+		shadowIDs[index], err = clnt.NewMultipartUpload(clnt.Bucket, clnt.remoteKey(object), miniogo.PutObjectOptions{
+			UserMetadata:         clnt.remoteMetadata(o.UserDefined),
+			ServerSideEncryption: o.ServerSideEncryption,
+		})
+		return err
+	})()
+	l.multipartUploadIDMap[uploadID] = append(l.multipartUploadIDMap[uploadID], shadowIDs...)
 	return uploadID, nil
 }
 
 // PutObjectPart puts a part of object in bucket
 func (l *radioObjects) PutObjectPart(ctx context.Context, bucket string, object string, uploadID string, partID int, r *PutObjReader, opts ObjectOptions) (pi PartInfo, e error) {
+	if err := preBackendHooks(ctx, "PutObjectPart", bucket, object); err != nil {
+		return pi, err
+	}
 	data := r.Reader
 
 	uploadIDLock := l.NewNSLock(ctx, bucket, pathJoin(object, uploadID))
@@ -659,38 +1302,106 @@ func (l *radioObjects) PutObjectPart(ctx context.Context, bucket string, object
 			UploadID: uploadID,
 		}
 	}
+	if data.Size() > l.mirrorClients[bucket].maxPartSize() {
+		return pi, PartTooBig{}
+	}
+
+	var body io.Reader = data
+	if l.scanner.scans(bucket) {
+		// Parts are scanned one by one, infected parts are rejected.
+		spooled, cleanup, err := spoolUpload(data, data.Size())
+		if err != nil {
+			return pi, err
+		}
+		defer cleanup()
+		if err = l.scanUpload(ctx, bucket, object, spooled, data.Size(), false, nil); err != nil {
+			return pi, err
+		}
+		body = spooled
+	}
 
 	rs3s := l.mirrorClients[bucket]
+	traceRequestSize(ctx, data.Size())
 
-	readers, err := streamdup.New(data, len(rs3s.clnts))
+	active, n := rs3s.uploadRemotes(object, uploadIDs)
+	if n == 0 {
+		return pi, InsufficientWriteQuorum{}
+	}
+
+	src := newHoldbackReader(body)
+	feeds := newShadowFeeds(len(rs3s.shadows))
+	readers, err := duplicateUpload(feeds.tee(src), n)
 	if err != nil {
 		return pi, err
 	}
+	defer closeUploadReaders(readers, io.ErrClosedPipe)
+
+	stopAbort := abortUploadOnCancel(ctx, readers)
+	defer stopAbort()
+
+	waitShadows := rs3s.shadowDo(ctx, "putobjectpart", func(index int, clnt bucketClient) error {
+		defer feeds[index].CloseWithError(io.ErrClosedPipe)
+		id, err := rs3s.shadowUploadID(uploadIDs, index)
+		if err != nil {
+			return err
+		}
+		_, err = clnt.PutObjectPart(clnt.Bucket, clnt.remoteKey(object), id, partID, feeds[index], data.Size(),
+			data.MD5Base64String(), data.SHA256HexString(), opts.ServerSideEncryption)
+		return err
+	})
 
 	pinfos := make([]miniogo.ObjectPart, len(rs3s.clnts))
 	g := errgroup.WithNErrs(len(rs3s.clnts))
+	next := 0
 	for index := range rs3s.clnts {
 		index := index
+		if !active[index] {
+			g.Go(func() error { return errRemoteMaintenance }, index)
+			continue
+		}
+		reader := readers[next]
+		next++
 		g.Go(func() error {
 			var err error
-			pinfos[index], err = rs3s.clnts[index].PutObjectPart(rs3s.clnts[index].Bucket, object,
-				uploadIDs[index], partID, readers[index], data.Size(),
+			pinfos[index], err = rs3s.clnts[index].PutObjectPart(rs3s.clnts[index].Bucket, rs3s.clnts[index].remoteKey(object),
+				uploadIDs[index], partID, reader, data.Size(),
 				data.MD5Base64String(), data.SHA256HexString(), opts.ServerSideEncryption)
+			traceRequestRemote(ctx, rs3s.clnts[index])
 			return err
 		}, index)
 	}
 
-	if maxErr := reduceWriteQuorumErrs(ctx, g.Wait(), nil, len(rs3s.clnts)/2+1); maxErr != nil {
+	errs := g.Wait()
+	maxErr := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, n/2+1)
+	if verr := src.Err(); verr != nil {
+		maxErr = verr
+	} else if cerr := ctx.Err(); cerr != nil && maxErr != nil {
+		maxErr = cerr
+	}
+	feeds.CloseWithError(maxErr)
+	waitShadows()
+	if maxErr != nil {
 		return pi, maxErr
 	}
 
-	return FromMinioClientObjectPart(pinfos[0]), nil
+	first := firstActive(active)
+	etags := make([]string, len(pinfos))
+	for index := range pinfos {
+		if errs[index] == nil {
+			etags[index] = pinfos[index].ETag
+		}
+	}
+	l.parts.record(uploadID, partID, journaledPart{Size: data.Size(), ETag: pinfos[first].ETag, ETags: etags})
+	return FromMinioClientObjectPart(pinfos[first]), nil
 }
 
 // CopyObjectPart creates a part in a multipart upload by copying
 // existing object or a part of it.
 func (l *radioObjects) CopyObjectPart(ctx context.Context, srcBucket, srcObject, destBucket, destObject, uploadID string,
 	partID int, startOffset, length int64, srcInfo ObjectInfo, srcOpts, dstOpts ObjectOptions) (p PartInfo, err error) {
+	if err := preBackendHooks(ctx, "CopyObjectPart", destBucket, destObject); err != nil {
+		return p, err
+	}
 
 	uploadIDLock := l.NewNSLock(ctx, destBucket, pathJoin(destObject, uploadID))
 	if err := uploadIDLock.GetLock(globalOperationTimeout); err != nil {
@@ -731,28 +1442,80 @@ func (l *radioObjects) CopyObjectPart(ctx context.Context, srcBucket, srcObject,
 	if len(rs3sSrc.clnts) != len(rs3sDest.clnts) {
 		return p, errors.New("unexpected")
 	}
+	if length > rs3sDest.maxPartSize() {
+		return p, PartTooBig{}
+	}
+	if err = rs3sSrc.requireCapability(copyCapability); err == nil {
+		err = rs3sDest.requireCapability(copyCapability)
+	}
+	if err != nil {
+		return p, err
+	}
 
-	n := len(rs3sDest.clnts)
-	pinfos := make([]miniogo.CompletePart, n)
+	waitShadows := rs3sDest.shadowDo(ctx, "copyobjectpart", func(index int, clnt bucketClient) error {
+		if len(rs3sSrc.shadows) != len(rs3sDest.shadows) {
+			return errors.New("source bucket has no matching shadow remote")
+		}
+		id, err := rs3sDest.shadowUploadID(uploadIDs, index)
+		if err != nil {
+			return err
+		}
+		src := rs3sSrc.shadows[index]
+		_, err = src.CopyObjectPart(src.Bucket, src.remoteKey(srcObject), clnt.Bucket, clnt.remoteKey(destObject),
+			id, partID, startOffset, length, srcInfo.UserDefined)
+		return err
+	})
+
+	active, n := rs3sDest.uploadRemotes(destObject, uploadIDs)
+	for index, clnt := range rs3sSrc.clnts {
+		if active[index] && clnt.inMaintenance() {
+			// The source is in maintenance, the destination
+			// leaves the upload.
+			active[index] = false
+			n--
+			uploadIDs[index] = ""
+			rs3sDest.clnts[index].queueWrite(destObject)
+		}
+	}
+	if n == 0 {
+		waitShadows()
+		return p, InsufficientWriteQuorum{}
+	}
 
-	g := errgroup.WithNErrs(n)
-	for index := 0; index < n; index++ {
+	pinfos := make([]miniogo.CompletePart, len(rs3sDest.clnts))
+
+	g := errgroup.WithNErrs(len(rs3sDest.clnts))
+	for index := range rs3sDest.clnts {
 		index := index
 		g.Go(func() error {
+			if !active[index] {
+				return errRemoteMaintenance
+			}
 			var err error
 			pinfos[index], err = rs3sSrc.clnts[index].CopyObjectPart(rs3sSrc.clnts[index].Bucket,
-				srcObject, rs3sDest.clnts[index].Bucket, destObject,
+				rs3sSrc.clnts[index].remoteKey(srcObject), rs3sDest.clnts[index].Bucket,
+				rs3sDest.clnts[index].remoteKey(destObject),
 				uploadIDs[index], partID, startOffset, length, srcInfo.UserDefined)
 			return err
 		}, index)
 	}
 
-	if maxErr := reduceWriteQuorumErrs(ctx, g.Wait(), nil, len(rs3sDest.clnts)/2+1); maxErr != nil {
+	errs := g.Wait()
+	waitShadows()
+	if maxErr := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, n/2+1); maxErr != nil {
 		return p, maxErr
 	}
 
-	p.PartNumber = pinfos[0].PartNumber
-	p.ETag = pinfos[0].ETag
+	first := firstActive(active)
+	p.PartNumber = pinfos[first].PartNumber
+	p.ETag = pinfos[first].ETag
+	etags := make([]string, len(pinfos))
+	for index := range pinfos {
+		if errs[index] == nil {
+			etags[index] = pinfos[index].ETag
+		}
+	}
+	l.parts.record(uploadID, partID, journaledPart{Size: length, ETag: p.ETag, ETags: etags})
 	return p, nil
 }
 
@@ -763,6 +1526,9 @@ func (l *radioObjects) ListObjectParts(ctx context.Context, bucket string, objec
 
 // AbortMultipartUpload aborts a ongoing multipart upload
 func (l *radioObjects) AbortMultipartUpload(ctx context.Context, bucket string, object string, uploadID string) error {
+	if err := preBackendHooks(ctx, "AbortMultipartUpload", bucket, object); err != nil {
+		return err
+	}
 	uploadIDLock := l.NewNSLock(ctx, bucket, pathJoin(object, uploadID))
 	if err := uploadIDLock.GetLock(globalOperationTimeout); err != nil {
 		return err
@@ -779,17 +1545,31 @@ func (l *radioObjects) AbortMultipartUpload(ctx context.Context, bucket string,
 	}
 
 	rs3s := l.mirrorClients[bucket]
-	for index, id := range uploadIDs {
-		if err := rs3s.clnts[index].AbortMultipartUpload(rs3s.clnts[index].Bucket, object, id); err != nil {
+	rs3s.shadowDo(ctx, "abortmultipartupload", func(index int, clnt bucketClient) error {
+		id, err := rs3s.shadowUploadID(uploadIDs, index)
+		if err != nil {
+			return err
+		}
+		return clnt.AbortMultipartUpload(clnt.Bucket, clnt.remoteKey(object), id)
+	})()
+	for index, clnt := range rs3s.clnts {
+		if uploadIDs[index] == "" || clnt.inMaintenance() {
+			continue
+		}
+		if err := clnt.AbortMultipartUpload(clnt.Bucket, clnt.remoteKey(object), uploadIDs[index]); err != nil {
 			return ErrorRespToObjectError(err, bucket, object)
 		}
 	}
 	delete(l.multipartUploadIDMap, uploadID)
+	l.parts.forget(uploadID)
 	return nil
 }
 
 // CompleteMultipartUpload completes ongoing multipart upload and finalizes object
 func (l *radioObjects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, uploadedParts []CompletePart, opts ObjectOptions) (oi ObjectInfo, err error) {
+	if err := preBackendHooks(ctx, "CompleteMultipartUpload", bucket, object); err != nil {
+		return oi, err
+	}
 
 	// Hold read-locks to verify uploaded parts, also disallows
 	// parallel part uploads as well.
@@ -816,15 +1596,87 @@ func (l *radioObjects) CompleteMultipartUpload(ctx context.Context, bucket strin
 		}
 	}
 
+	journal := l.parts.parts(uploadID)
+	if err = verifyCompleteParts(uploadedParts, journal); err != nil {
+		return oi, err
+	}
+
 	rs3s := l.mirrorClients[bucket]
-	var etag string
-	for index, id := range uploadIDs {
-		etag, err = rs3s.clnts[index].CompleteMultipartUpload(rs3s.clnts[index].Bucket,
-			object, id, ToMinioClientCompleteParts(uploadedParts))
+	rs3s.shadowDo(ctx, "completemultipartupload", func(index int, clnt bucketClient) error {
+		id, err := rs3s.shadowUploadID(uploadIDs, index)
 		if err != nil {
-			return oi, ErrorRespToObjectError(err, bucket, object)
+			return err
+		}
+		_, err = clnt.CompleteMultipartUpload(clnt.Bucket, clnt.remoteKey(object), id,
+			ToMinioClientCompleteParts(uploadedParts))
+		return err
+	})()
+	active, n := rs3s.uploadRemotes(object, uploadIDs)
+	if n == 0 {
+		return oi, InsufficientWriteQuorum{}
+	}
+	writeQuorum := n/2 + 1
+
+	// Every remote completes with the ETags it returned for the parts,
+	// remotes a part did not reach leave the upload instead of
+	// completing a different object, they are healed later.
+	rparts := make([][]CompletePart, len(rs3s.clnts))
+	for index, clnt := range rs3s.clnts {
+		if !active[index] {
+			continue
+		}
+		var ok bool
+		if rparts[index], ok = remoteCompleteParts(uploadedParts, journal, index); !ok {
+			logger.LogIf(remoteContext(ctx, clnt), clnt.AbortMultipartUpload(clnt.Bucket, clnt.remoteKey(object), uploadIDs[index]))
+			active[index] = false
+			n--
+		}
+	}
+	if n < writeQuorum {
+		return oi, InsufficientWriteQuorum{}
+	}
+
+	etags := make([]string, len(rs3s.clnts))
+	g := errgroup.WithNErrs(len(rs3s.clnts))
+	for index := range rs3s.clnts {
+		index := index
+		if !active[index] {
+			g.Go(func() error { return errRemoteMaintenance }, index)
+			continue
 		}
+		g.Go(func() error {
+			clnt := rs3s.clnts[index]
+			var err error
+			etags[index], err = clnt.CompleteMultipartUpload(clnt.Bucket, clnt.remoteKey(object), uploadIDs[index],
+				ToMinioClientCompleteParts(rparts[index]))
+			return err
+		}, index)
+	}
+	errs := g.Wait()
+	completed := make([]bool, len(rs3s.clnts))
+	for index := range errs {
+		completed[index] = errs[index] == nil
+	}
+	rs3s.syncState.record(object, completed)
+	if maxErr := reduceWriteQuorumErrs(ctx, errs, []error{errRemoteMaintenance}, writeQuorum); maxErr != nil {
+		return oi, ErrorRespToObjectError(maxErr, bucket, object)
 	}
 	delete(l.multipartUploadIDMap, uploadID)
-	return ObjectInfo{Bucket: bucket, Name: object, ETag: etag}, nil
+	l.parts.forget(uploadID)
+	// Remotes which left the upload missed the object.
+	for index, clnt := range rs3s.clnts {
+		if !completed[index] {
+			clnt.queueWrite(object)
+		}
+	}
+	l.healSkippedWrites(rs3s, completed)
+	oi = ObjectInfo{Bucket: bucket, Name: object, ETag: etags[firstActive(completed)]}
+	if l.changes != nil {
+		// The size of the object is only known to the remotes.
+		if info, ierr := l.getObjectInfo(ctx, bucket, object, opts); ierr == nil {
+			oi.Size = info.Size
+		}
+		l.changes.record(ctx, changeOpComplete, oi)
+	}
+	return oi, nil
 }
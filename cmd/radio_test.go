@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestAbortUploadOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr1, pw1 := io.Pipe()
+	pr2, pw2 := io.Pipe()
+	defer pw1.Close()
+	defer pw2.Close()
+
+	stop := abortUploadOnCancel(ctx, []io.Reader{pr1, pr2})
+	defer stop()
+
+	errCh := make(chan error, 2)
+	for _, r := range []io.Reader{pr1, pr2} {
+		go func(r io.Reader) {
+			_, err := ioutil.ReadAll(r)
+			errCh <- err
+		}(r)
+	}
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errCh:
+			if err != io.ErrClosedPipe {
+				t.Fatalf("expected %v, got %v", io.ErrClosedPipe, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("upload stream not aborted")
+		}
+	}
+	// The duplicating writer fails with the cancellation.
+	if _, err := pw1.Write([]byte("data")); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	// Streams are left alone once watching stopped.
+	ctx, cancel = context.WithCancel(context.Background())
+	pr3, pw3 := io.Pipe()
+	abortUploadOnCancel(ctx, []io.Reader{pr3})()
+	cancel()
+	go func() {
+		pw3.Write([]byte("data"))
+		pw3.Close()
+	}()
+	data, err := ioutil.ReadAll(pr3)
+	if err != nil || string(data) != "data" {
+		t.Fatalf("expected data, got %q, %v", data, err)
+	}
+}
+
+func TestBucketClientRemoteMetadata(t *testing.T) {
+	clnt := bucketClient{storageClass: storageClassConfig{
+		Default: "COLD",
+		Map:     map[string]string{"STANDARD": "WARM"},
+	}}
+	testCases := []struct {
+		clnt     bucketClient
+		metadata map[string]string
+		expected string
+	}{
+		{clnt, map[string]string{}, "COLD"},
+		{clnt, map[string]string{"x-amz-storage-class": "STANDARD"}, "WARM"},
+		{clnt, map[string]string{"x-amz-storage-class": "GLACIER"}, "GLACIER"},
+		{bucketClient{}, map[string]string{}, ""},
+		{bucketClient{}, map[string]string{"x-amz-storage-class": "STANDARD"}, "STANDARD"},
+	}
+	for i, testCase := range testCases {
+		mm := testCase.clnt.remoteMetadata(testCase.metadata)
+		if sc := mm["X-Amz-Storage-Class"]; sc != testCase.expected {
+			t.Errorf("Test %d: expected storage class %q, got %q", i+1, testCase.expected, sc)
+		}
+		if _, ok := testCase.metadata["X-Amz-Storage-Class"]; ok {
+			t.Errorf("Test %d: metadata of the client was modified", i+1)
+		}
+	}
+}
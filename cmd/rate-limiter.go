@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiterBurst - transfers idle for this long may catch up at once.
+const rateLimiterBurst = time.Second
+
+// rateLimiter - paces transfers to a number of bytes per second, shared
+// by concurrent transfers.
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate float64 // bytes per second
+	next time.Time
+}
+
+// newRateLimiter returns a limiter to bytesPerSec bytes per second.
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	return &rateLimiter{rate: float64(bytesPerSec)}
+}
+
+// reserve counts n bytes transferred at now, returns how long to wait
+// before transferring them.
+func (l *rateLimiter) reserve(n int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if earliest := now.Add(-rateLimiterBurst); l.next.Before(earliest) {
+		l.next = earliest
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	return l.next.Sub(now)
+}
+
+// allow counts n bytes transferred at now, unless the transfers counted
+// before are still ahead of now, returns true if they were counted.
+func (l *rateLimiter) allow(n int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if earliest := now.Add(-rateLimiterBurst); l.next.Before(earliest) {
+		l.next = earliest
+	}
+	if l.next.After(now) {
+		return false
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	return true
+}
+
+// wait blocks until n more bytes may be transferred or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	delay := l.reserve(n, time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitedWriter - writes to w paced by l.
+type rateLimitedWriter struct {
+	ctx context.Context
+	l   *rateLimiter
+	w   io.Writer
+}
+
+func (w rateLimitedWriter) Write(p []byte) (int, error) {
+	if err := w.l.wait(w.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(1000)
+
+	// A second of idle time is transferred at once.
+	if delay := l.reserve(1000, now); delay > 0 {
+		t.Fatalf("expected no delay within the burst, got %v", delay)
+	}
+	if delay := l.reserve(500, now); delay != 500*time.Millisecond {
+		t.Fatalf("expected a delay of 500ms, got %v", delay)
+	}
+	// Reservations queue up behind each other.
+	if delay := l.reserve(1000, now); delay != 1500*time.Millisecond {
+		t.Fatalf("expected a delay of 1.5s, got %v", delay)
+	}
+
+	// Idle time beyond the burst is not saved up.
+	now = now.Add(time.Hour)
+	if delay := l.reserve(2000, now); delay != time.Second {
+		t.Fatalf("expected a delay of 1s after a long idle, got %v", delay)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 1000000); err != context.Canceled {
+		t.Fatalf("expected the wait to be canceled, got %v", err)
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := &rateLimiter{rate: 2}
+	var allowed int
+	for i := 0; i < 10; i++ {
+		if l.allow(1, now) {
+			allowed++
+		}
+	}
+	// A second of burst, and the request starting it.
+	if allowed != 3 {
+		t.Fatalf("expected 3 requests to be allowed at once, got %d", allowed)
+	}
+	if !l.allow(1, now.Add(500*time.Millisecond)) || l.allow(1, now.Add(500*time.Millisecond)) {
+		t.Fatal("expected one more request to be allowed after 500ms")
+	}
+	// Rates below one request per second still allow requests.
+	l = &rateLimiter{rate: 0.5}
+	if !l.allow(1, now) || l.allow(1, now.Add(500*time.Millisecond)) || !l.allow(1, now.Add(time.Second)) ||
+		l.allow(1, now.Add(2*time.Second)) || !l.allow(1, now.Add(3*time.Second)) {
+		t.Fatal("expected a request every 2s")
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rateMeterTickInterval = 5 * time.Second // interval at which the rate is updated
+	rateMeterWindow       = time.Minute     // window over which the rate is averaged
+)
+
+// alpha of an exponentially weighted moving average over rateMeterWindow
+// updated every rateMeterTickInterval.
+var rateMeterAlpha = 1 - math.Exp(-float64(rateMeterTickInterval)/float64(rateMeterWindow))
+
+// rateMeter - exponentially weighted moving average of the number of
+// bytes per second counted, ticks lazily on Add and Rate so idle servers
+// need no background goroutine.
+type rateMeter struct {
+	uncounted int64 // bytes counted since the last tick
+	nextTick  int64 // unix nanoseconds of the next tick
+
+	mutex sync.Mutex
+	rate  float64 // bytes per second
+	init  bool
+}
+
+// newRateMeter returns a new rate meter starting at now
+func newRateMeter(now time.Time) *rateMeter {
+	return &rateMeter{nextTick: now.Add(rateMeterTickInterval).UnixNano()}
+}
+
+// Add counts n bytes transferred at now
+func (m *rateMeter) Add(n int, now time.Time) {
+	m.tickIfDue(now)
+	atomic.AddInt64(&m.uncounted, int64(n))
+}
+
+// Rate returns the average bytes per second at now
+func (m *rateMeter) Rate(now time.Time) uint64 {
+	m.tickIfDue(now)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return uint64(m.rate)
+}
+
+// tickIfDue updates the rate once for every interval passed since the
+// last tick, intervals without calls count as idle.
+func (m *rateMeter) tickIfDue(now time.Time) {
+	if now.UnixNano() < atomic.LoadInt64(&m.nextTick) {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	nextTick := atomic.LoadInt64(&m.nextTick)
+	for ; nextTick <= now.UnixNano(); nextTick += int64(rateMeterTickInterval) {
+		count := atomic.SwapInt64(&m.uncounted, 0)
+		instant := float64(count) / rateMeterTickInterval.Seconds()
+		if m.init {
+			m.rate += rateMeterAlpha * (instant - m.rate)
+		} else {
+			m.rate = instant
+			m.init = true
+		}
+		if m.rate == 0 && now.UnixNano()-nextTick > int64(rateMeterWindow) {
+			// Long idle, skip the intervals left.
+			nextTick = now.UnixNano() - (now.UnixNano()-nextTick)%int64(rateMeterTickInterval)
+		}
+	}
+	atomic.StoreInt64(&m.nextTick, nextTick)
+}
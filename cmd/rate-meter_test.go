@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateMeter(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := newRateMeter(now)
+
+	if rate := m.Rate(now); rate != 0 {
+		t.Fatalf("expected no rate before the first tick, got %d", rate)
+	}
+
+	// 1000 bytes per second for a minute.
+	for i := 0; i < 60; i++ {
+		m.Add(1000, now)
+		now = now.Add(time.Second)
+	}
+	if rate := m.Rate(now); rate != 1000 {
+		t.Fatalf("expected a rate of 1000, got %d", rate)
+	}
+
+	// Idle for a minute decays the rate by 1/e.
+	now = now.Add(rateMeterWindow)
+	if rate := m.Rate(now); rate < 360 || rate > 380 {
+		t.Fatalf("expected a rate of about 368 after a minute idle, got %d", rate)
+	}
+
+	// Idle for long the rate drops to zero.
+	now = now.Add(24 * time.Hour)
+	if rate := m.Rate(now); rate != 0 {
+		t.Fatalf("expected no rate after a day idle, got %d", rate)
+	}
+
+	// Traffic after a long idle is averaged from zero.
+	for i := 0; i < 5; i++ {
+		m.Add(1000, now)
+		now = now.Add(time.Second)
+	}
+	if rate := m.Rate(now); rate != uint64(1000*rateMeterAlpha) {
+		t.Fatalf("expected a rate of %d after the first interval, got %d", uint64(1000*rateMeterAlpha), rate)
+	}
+}
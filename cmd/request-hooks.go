@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+)
+
+// RequestHook - site specific logic compiled into radio, such as custom
+// authorization, header policies or content scanning, run at the stages
+// of every S3 request. Embed NopRequestHook to implement only some of
+// the stages. A hook rejects a request by returning an error, which is
+// logged and sent to the client as AccessDenied.
+type RequestHook interface {
+	// PreAuth runs before the request is authenticated.
+	PreAuth(r *http.Request, api string) error
+	// PostAuth runs once the request was authenticated for an action,
+	// several times for requests authorized for more than one object
+	// such as copies and multi-object deletes.
+	PostAuth(r *http.Request, auth HookAuth) error
+	// PreBackend runs before the object layer calls the remotes on
+	// behalf of a request, ctx is the context of the request.
+	PreBackend(ctx context.Context, op HookOperation) error
+	// PostResponse runs once the response was sent.
+	PostResponse(r *http.Request, resp HookResponse)
+}
+
+// HookAuth - an authenticated action of a request.
+type HookAuth struct {
+	AccessKey string
+	// Action such as s3:GetObject.
+	Action string
+	Bucket string
+	Object string
+}
+
+// HookOperation - a call of the object layer.
+type HookOperation struct {
+	// API of the request such as PutObject, Method of the object layer
+	// such as NewMultipartUpload.
+	API    string
+	Method string
+	Bucket string
+	Object string
+}
+
+// HookResponse - the response sent for a request.
+type HookResponse struct {
+	API        string
+	StatusCode int
+	Duration   time.Duration
+}
+
+// NopRequestHook - a RequestHook accepting every request.
+type NopRequestHook struct{}
+
+// PreAuth - accepts the request.
+func (NopRequestHook) PreAuth(r *http.Request, api string) error { return nil }
+
+// PostAuth - accepts the request.
+func (NopRequestHook) PostAuth(r *http.Request, auth HookAuth) error { return nil }
+
+// PreBackend - accepts the call.
+func (NopRequestHook) PreBackend(ctx context.Context, op HookOperation) error { return nil }
+
+// PostResponse - does nothing.
+func (NopRequestHook) PostResponse(r *http.Request, resp HookResponse) {}
+
+// globalRequestHooks - hooks in the order they were registered.
+var globalRequestHooks []RequestHook
+
+// RegisterRequestHook - adds a hook run after the hooks registered before
+// it. Hooks are registered before the server starts, such as from an init
+// function in a file added to package main.
+func RegisterRequestHook(hook RequestHook) {
+	globalRequestHooks = append(globalRequestHooks, hook)
+}
+
+// preAuthHooks - runs the PreAuth hooks until one rejects the request.
+func preAuthHooks(r *http.Request, api string) APIErrorCode {
+	for _, hook := range globalRequestHooks {
+		if err := hook.PreAuth(r, api); err != nil {
+			logger.LogIf(r.Context(), err)
+			return ErrAccessDenied
+		}
+	}
+	return ErrNone
+}
+
+// postAuthHooks - runs the PostAuth hooks until one rejects the request,
+// once the request was allowed by the write fences and the tenants. The
+// access key is recorded as the requester of the request.
+func postAuthHooks(r *http.Request, auth HookAuth) APIErrorCode {
+	if reqInfo, ok := logger.LookupReqInfo(r.Context()); ok && auth.AccessKey != "" {
+		reqInfo.Lock()
+		reqInfo.AccessKey = auth.AccessKey
+		reqInfo.Unlock()
+	}
+	if s3Err := globalWriteFences.admit(r, auth); s3Err != ErrNone {
+		return s3Err
+	}
+	if s3Err := globalTenants.authorize(r, auth); s3Err != ErrNone {
+		return s3Err
+	}
+	for _, hook := range globalRequestHooks {
+		if err := hook.PostAuth(r, auth); err != nil {
+			logger.LogIf(r.Context(), err)
+			globalTenants.refund(r, auth)
+			return ErrAccessDenied
+		}
+	}
+	return ErrNone
+}
+
+// frontendAuthHooks - passes an action of a front-end other than S3,
+// such as SFTP or WebDAV, to the tenants and the PostAuth hooks as an S3
+// request for the bucket and object of auth. r carries the method and
+// the client of the action.
+func frontendAuthHooks(r *http.Request, auth HookAuth) APIErrorCode {
+	u := &url.URL{Path: SlashSeparator + auth.Bucket}
+	if auth.Object != "" {
+		u.Path += SlashSeparator + auth.Object
+	}
+	r = r.WithContext(r.Context())
+	r.URL = u
+	if s3Err := globalTenants.admit(r); s3Err != ErrNone {
+		return s3Err
+	}
+	return postAuthHooks(r, auth)
+}
+
+// preBackendHooks - runs the PreBackend hooks for a call of the object
+// layer made on behalf of a request, calls made by radio itself such as
+// heals and batch jobs are not passed to the hooks.
+func preBackendHooks(ctx context.Context, method, bucket, object string) error {
+	if len(globalRequestHooks) == 0 {
+		return nil
+	}
+	api := logger.GetReqInfo(ctx).API
+	if api == "" {
+		return nil
+	}
+	op := HookOperation{API: api, Method: method, Bucket: bucket, Object: object}
+	for _, hook := range globalRequestHooks {
+		if err := hook.PreBackend(ctx, op); err != nil {
+			logger.LogIf(ctx, err)
+			return PrefixAccessDenied{Bucket: bucket, Object: object}
+		}
+	}
+	return nil
+}
+
+// postResponseHooks - runs the PostResponse hooks.
+func postResponseHooks(r *http.Request, resp HookResponse) {
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	for _, hook := range globalRequestHooks {
+		hook.PostResponse(r, resp)
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+	xhttp "github.com/minio/radio/cmd/http"
+	"github.com/minio/radio/cmd/logger"
+)
+
+// testRequestHook - rejects requests for the path and the bucket denied.
+type testRequestHook struct {
+	NopRequestHook
+	ops []HookOperation
+}
+
+func (h *testRequestHook) PreAuth(r *http.Request, api string) error {
+	if r.URL.Path == "/denied" {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func (h *testRequestHook) PreBackend(ctx context.Context, op HookOperation) error {
+	h.ops = append(h.ops, op)
+	if op.Bucket == "denied" {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func TestRequestHooks(t *testing.T) {
+	hooks := globalRequestHooks
+	defer func() { globalRequestHooks = hooks }()
+	globalRequestHooks = nil
+
+	hook := &testRequestHook{}
+	RegisterRequestHook(hook)
+
+	for path, expected := range map[string]APIErrorCode{"/bucket": ErrNone, "/denied": ErrAccessDenied} {
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9000"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s3Err := preAuthHooks(r, "GetObject"); s3Err != expected {
+			t.Errorf("%s: expected %v, got %v", path, expected, s3Err)
+		}
+	}
+
+	// Calls made by radio itself are not passed to the hooks.
+	if err := preBackendHooks(context.Background(), "PutObject", "denied", "object"); err != nil {
+		t.Errorf("expected no hooks without a request, got %v", err)
+	}
+	if err := preBackendHooks(newBackgroundContext("Inventory", "denied", ""), "PutObject", "denied", "object"); err != nil {
+		t.Errorf("expected no hooks for background jobs, got %v", err)
+	}
+	if len(hook.ops) != 0 {
+		t.Errorf("expected no calls, got %v", hook.ops)
+	}
+
+	ctx := logger.SetReqInfo(context.Background(), &logger.ReqInfo{API: "PutObject"})
+	if err := preBackendHooks(ctx, "PutObject", "bucket", "object"); err != nil {
+		t.Errorf("expected the call to be accepted, got %v", err)
+	}
+	if _, ok := preBackendHooks(ctx, "NewMultipartUpload", "denied", "object").(PrefixAccessDenied); !ok {
+		t.Error("expected the call to be rejected with PrefixAccessDenied")
+	}
+	expected := HookOperation{API: "PutObject", Method: "NewMultipartUpload", Bucket: "denied", Object: "object"}
+	if len(hook.ops) != 2 || hook.ops[1] != expected {
+		t.Errorf("expected %v, got %v", expected, hook.ops)
+	}
+}
+
+// postAuthRecorder - records the actions passed to PostAuth and rejects
+// them.
+type postAuthRecorder struct {
+	NopRequestHook
+	auths []HookAuth
+}
+
+func (h *postAuthRecorder) PostAuth(r *http.Request, auth HookAuth) error {
+	h.auths = append(h.auths, auth)
+	return errors.New("denied")
+}
+
+// Tests that uploads only reach the PostAuth hooks once their signature
+// was verified.
+func TestPutPostAuthHooks(t *testing.T) {
+	hooks, creds := globalRequestHooks, globalLocalCreds
+	defer func() { globalRequestHooks, globalLocalCreds = hooks, creds }()
+	globalRequestHooks = nil
+	hook := &postAuthRecorder{}
+	RegisterRequestHook(hook)
+
+	cred, err := auth.CreateCredentials("radioaccess", "radiosecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalLocalCreds = map[string]auth.Credentials{cred.AccessKey: cred}
+
+	api := objectAPIHandlers{
+		ObjectAPI: func() ObjectLayer { return struct{ ObjectLayer }{} },
+		CacheAPI:  func() CacheObjectLayer { return nil },
+	}
+	handlers := map[string]struct {
+		handler http.HandlerFunc
+		query   url.Values
+	}{
+		"PutObject":     {api.PutObjectHandler, nil},
+		"PutObjectPart": {api.PutObjectPartHandler, url.Values{"uploadId": {"upload"}, "partNumber": {"1"}}},
+	}
+	for name, h := range handlers {
+		u := url.URL{Scheme: "http", Host: "localhost:9000", Path: "/bucket/object", RawQuery: h.query.Encode()}
+		for _, testCase := range []struct {
+			forged bool
+			s3Err  APIErrorCode
+			calls  int
+		}{
+			{true, ErrSignatureDoesNotMatch, 0},
+			{false, ErrAccessDenied, 1},
+		} {
+			hook.auths = nil
+			presigned, err := url.Parse(presignV4(http.MethodPut, u, cred, globalServerRegion, UTCNow(), time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if testCase.forged {
+				query := presigned.Query()
+				query.Set(xhttp.AmzSignature, strings.Repeat("0", 64))
+				presigned.RawQuery = query.Encode()
+			}
+			r := httptest.NewRequest(http.MethodPut, presigned.String(), strings.NewReader("data"))
+			w := httptest.NewRecorder()
+			h.handler(w, r)
+
+			if code := errorCodes.ToAPIErr(testCase.s3Err).Code; !strings.Contains(w.Body.String(), "<Code>"+code+"</Code>") {
+				t.Errorf("%s: expected %s, got %s", name, code, w.Body.String())
+			}
+			if len(hook.auths) != testCase.calls {
+				t.Errorf("%s: expected %d PostAuth calls, got %v", name, testCase.calls, hook.auths)
+			}
+		}
+		expected := HookAuth{AccessKey: cred.AccessKey, Action: "s3:PutObject", Bucket: "bucket", Object: "object"}
+		if len(hook.auths) == 1 && hook.auths[0] != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, hook.auths[0])
+		}
+	}
+}
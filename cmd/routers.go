@@ -29,5 +29,15 @@ var globalHandlers = []HandlerFunc{
 	// filters HTTP headers which are treated as metadata and are reserved
 	// for internal use only.
 	filterReservedMetadata,
+	// Rejects writes while the server is in read-only mode.
+	setReadOnlyHandler,
+	// Serves the admin API and the console on the admin listeners.
+	setListenerRoleHandler,
+	// Sends 100 Continue only once the body of a request is read, must
+	// wrap all handlers replacing the body.
+	setExpectContinueHandler,
+	// Selects the error response format, must be the outer most
+	// handler so that errors from all other handlers honor it.
+	setErrorResponseFormatHandler,
 	// Add new handlers here.
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"runtime"
+	"time"
+)
+
+// RuntimeInfo - Go runtime and process statistics reported by the
+// admin API for production debugging.
+type RuntimeInfo struct {
+	Goroutines       int           `json:"goroutines"`
+	HeapAlloc        uint64        `json:"heapAlloc"`
+	HeapInuse        uint64        `json:"heapInuse"`
+	HeapSys          uint64        `json:"heapSys"`
+	HeapObjects      uint64        `json:"heapObjects"`
+	NumGC            uint32        `json:"numGC"`
+	LastGCPause      time.Duration `json:"lastGCPause"`
+	TotalGCPause     time.Duration `json:"totalGCPause"`
+	OpenFDs          int           `json:"openFDs,omitempty"`
+	Connections      int32         `json:"connections"`
+	RequestsInFlight int32         `json:"requestsInFlight"`
+}
+
+// countOpenFDs - returns the number of open file descriptors of the
+// process, 0 on platforms without /proc.
+func countOpenFDs() int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0
+	}
+	return len(fds)
+}
+
+// getRuntimeInfo - collects the current runtime statistics.
+func getRuntimeInfo() RuntimeInfo {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	info := RuntimeInfo{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		HeapSys:      memStats.HeapSys,
+		HeapObjects:  memStats.HeapObjects,
+		NumGC:        memStats.NumGC,
+		TotalGCPause: time.Duration(memStats.PauseTotalNs),
+		OpenFDs:      countOpenFDs(),
+	}
+	if memStats.NumGC > 0 {
+		info.LastGCPause = time.Duration(memStats.PauseNs[(memStats.NumGC+255)%256])
+	}
+	if httpServer := newHTTPServerFn(); httpServer != nil {
+		info.Connections = httpServer.GetConnCount()
+		info.RequestsInFlight = httpServer.GetRequestCount()
+	}
+	return info
+}
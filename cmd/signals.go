@@ -23,12 +23,19 @@ func handleSignals() {
 
 		// Stop watching for any certificate changes.
 		globalTLSCerts.Stop()
+		stopListenCerts()
 
 		if httpServer := newHTTPServerFn(); httpServer != nil {
 			err = httpServer.Shutdown()
 			logger.LogIf(context.Background(), err)
 		}
 
+		// Write the usage counted since the last write.
+		if globalUsage != nil {
+			oerr = globalUsage.flush(UTCNow())
+			logger.LogIf(context.Background(), oerr)
+		}
+
 		// send signal to various go-routines that they need to quit.
 		close(GlobalServiceDoneCh)
 
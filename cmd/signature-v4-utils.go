@@ -34,7 +34,7 @@ func skipContentSha256Cksum(r *http.Request) bool {
 
 	// If x-amz-content-sha256 is set and the value is not
 	// 'UNSIGNED-PAYLOAD' we should validate the content sha256.
-	return !(ok && v[0] != unsignedPayload)
+	return !(ok && v[0] != unsignedPayload && v[0] != unsignedPayloadTrailer)
 }
 
 // Returns SHA256 for calculating canonical-request.
@@ -89,13 +89,24 @@ func isValidRegion(reqRegion string, confRegion string) bool {
 // check if the access key is valid and recognized, additionally
 // also returns if the access key is owner/admin.
 func checkKeyValid(accessKey string) (auth.Credentials, APIErrorCode) {
+	globalLocalCredsMu.RLock()
 	cred, ok := globalLocalCreds[accessKey]
+	globalLocalCredsMu.RUnlock()
 	if !ok {
 		return cred, ErrInvalidAccessKeyID
 	}
 	return cred, ErrNone
 }
 
+// check if the access key is the admin access key, which is only
+// accepted by the admin API and never for bucket requests.
+func checkAdminKeyValid(accessKey string) (auth.Credentials, APIErrorCode) {
+	if !globalAdminCred.IsValid() || accessKey != globalAdminCred.AccessKey {
+		return auth.Credentials{}, ErrInvalidAccessKeyID
+	}
+	return globalAdminCred, ErrNone
+}
+
 // sumHMAC calculate hmac between two input byte array.
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
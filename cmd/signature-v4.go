@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/minio/minio-go/v6/pkg/s3utils"
+	"github.com/minio/minio/pkg/auth"
 	xhttp "github.com/minio/radio/cmd/http"
 	sha256 "github.com/minio/sha256-simd"
 )
@@ -306,6 +307,13 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
 // returns ErrNone if signature matches.
 func doesSignatureMatch(hashedPayload string, r *http.Request, region string, stype serviceType) APIErrorCode {
+	return doesSignatureMatchKey(hashedPayload, r, region, stype, checkKeyValid)
+}
+
+// doesSignatureMatchKey - like doesSignatureMatch, with the secret key of
+// the request looked up by checkKey.
+func doesSignatureMatchKey(hashedPayload string, r *http.Request, region string, stype serviceType,
+	checkKey func(accessKey string) (auth.Credentials, APIErrorCode)) APIErrorCode {
 	// Copy request.
 	req := *r
 
@@ -324,7 +332,7 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string, st
 		return errCode
 	}
 
-	cred, s3Err := checkKeyValid(signV4Values.Credential.accessKey)
+	cred, s3Err := checkKey(signV4Values.Credential.accessKey)
 	if s3Err != ErrNone {
 		return s3Err
 	}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/radio/cmd/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestTraceKey - context key of the requestTrace of an S3 request.
+type requestTraceKey struct{}
+
+// requestTrace - details of an S3 request filled in by the object layer
+// for the slow request log, remote is the remote which completed last.
+// accessKey is the access key the request was authenticated with, for the
+// usage accounting.
+type requestTrace struct {
+	mu        sync.Mutex
+	remote    string
+	size      int64
+	accessKey string
+}
+
+// withRequestTrace - returns r with a requestTrace the object layer can
+// fill in.
+func withRequestTrace(r *http.Request) (*http.Request, *requestTrace) {
+	rt := &requestTrace{size: -1}
+	return r.WithContext(context.WithValue(r.Context(), requestTraceKey{}, rt)), rt
+}
+
+// traceRequestRemote - records the remote a request was served from,
+// a no-op unless the slow request log is enabled.
+func traceRequestRemote(ctx context.Context, clnt bucketClient) {
+	if rt, ok := ctx.Value(requestTraceKey{}).(*requestTrace); ok {
+		rt.mu.Lock()
+		rt.remote = clnt.EndpointURL().Host + SlashSeparator + clnt.Bucket
+		rt.mu.Unlock()
+	}
+}
+
+// traceRequestSize - records the size of the object of a request, a
+// no-op unless the slow request log is enabled.
+func traceRequestSize(ctx context.Context, size int64) {
+	if rt, ok := ctx.Value(requestTraceKey{}).(*requestTrace); ok {
+		rt.mu.Lock()
+		rt.size = size
+		rt.mu.Unlock()
+	}
+}
+
+// traceRequestAccessKey - records the access key a request was
+// authenticated with, a no-op unless the usage is accounted.
+func traceRequestAccessKey(ctx context.Context, accessKey string) {
+	if rt, ok := ctx.Value(requestTraceKey{}).(*requestTrace); ok {
+		rt.mu.Lock()
+		rt.accessKey = accessKey
+		rt.mu.Unlock()
+	}
+}
+
+// logSlowRequest - logs and counts a request which took longer than the
+// configured threshold.
+func logSlowRequest(api string, r *http.Request, rt *requestTrace, duration, ttfb time.Duration) {
+	httpSlowRequests.With(prometheus.Labels{"api": api}).Inc()
+
+	rt.mu.Lock()
+	remote, size := rt.remote, rt.size
+	rt.mu.Unlock()
+	if remote == "" {
+		remote = "-"
+	}
+
+	bucket, object := request2BucketObjectName(r)
+	logger.Info("Slow request: api=%s bucket=%s object=%s client=%s remote=%s duration=%s ttfb=%s size=%d",
+		api, bucket, object, getSourceIP(r), remote, duration, ttfb, size)
+}
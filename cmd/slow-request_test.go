@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestTrace(t *testing.T) {
+	data := newFakeObjectData(64 * 1024)
+	remotes := []*fakeRemote{{data: data, tag: "v1", failAfter: 1000}, {data: data, tag: "v1"}}
+	rs3s, stop := newFakeMirror(t, remotes...)
+	defer stop()
+
+	// Without a trace the object layer records nothing.
+	var buf bytes.Buffer
+	if err := readObjectWithFailover(context.Background(), rs3s, newFakeObjectInfo(data, "v1"),
+		0, int64(len(data)), ObjectOptions{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r, rt := withRequestTrace(httptest.NewRequest(http.MethodGet, "/bucket/object", nil))
+	if rt.remote != "" || rt.size != -1 {
+		t.Fatalf("expected an empty trace, got %q/%d", rt.remote, rt.size)
+	}
+
+	ctx := r.Context()
+	traceRequestSize(ctx, int64(len(data)))
+	buf.Reset()
+	if err := readObjectWithFailover(ctx, rs3s, newFakeObjectInfo(data, "v1"),
+		0, int64(len(data)), ObjectOptions{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first remote failed mid-stream, the second served the rest.
+	expected := rs3s.clnts[1].EndpointURL().Host + SlashSeparator + rs3s.clnts[1].Bucket
+	if rt.remote != expected {
+		t.Fatalf("expected remote %s, got %s", expected, rt.remote)
+	}
+	if rt.size != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), rt.size)
+	}
+}
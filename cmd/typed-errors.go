@@ -28,3 +28,10 @@ var errInvalidRange = errors.New("Invalid range")
 // errInvalidRangeSource - returned when given range value exceeds
 // the source object size.
 var errInvalidRangeSource = errors.New("Range specified exceeds source object size")
+
+// errObjectChanged - the object was overwritten while it was being read.
+var errObjectChanged = errors.New("Object changed while it was being read")
+
+// errServerNotInitialized - returned by the front-ends other than S3
+// until the object layer is initialized.
+var errServerNotInitialized = errors.New("Server not initialized, please try again")
@@ -16,7 +16,6 @@ import (
 	"time"
 
 	xhttp "github.com/minio/radio/cmd/http"
-	"github.com/minio/minio/pkg/handlers"
 	"github.com/minio/radio/cmd/logger"
 
 	humanize "github.com/dustin/go-humanize"
@@ -86,11 +85,9 @@ func checkValidMD5(h http.Header) ([]byte, error) {
 
 /// http://docs.aws.amazon.com/AmazonS3/latest/dev/UploadingObjects.html
 const (
-	// Maximum object size per PUT request is 5TB.
-	// This is a divergence from S3 limit on purpose to support
-	// use cases where users are going to upload large files
-	// using 'curl' and presigned URL.
-	globalMaxObjectSize = 5 * humanize.TiByte
+	// Minimum Part size for multipart upload is 5MiB, the last part
+	// may be smaller
+	globalMinPartSize = 5 * humanize.MiByte
 
 	// Maximum Part size for multipart upload is 5GiB
 	globalMaxPartSize = 5 * humanize.GiByte
@@ -106,7 +103,7 @@ const (
 
 // isMaxObjectSize - verify if max object size
 func isMaxObjectSize(size int64) bool {
-	return size > globalMaxObjectSize
+	return size > globalAPIConfig.MaxObjectSize
 }
 
 // // Check if part size is more than maximum allowed size.
@@ -140,6 +137,15 @@ func isFile(path string) bool {
 	return false
 }
 
+// isDir - returns whether given path is a directory or not.
+func isDir(path string) bool {
+	if fi, err := os.Stat(path); err == nil {
+		return fi.IsDir()
+	}
+
+	return false
+}
+
 // UTCNow - returns current UTC time.
 func UTCNow() time.Time {
 	return time.Now().UTC()
@@ -238,20 +244,46 @@ func jsonSave(f interface {
 	return nil
 }
 
-// Returns context with ReqInfo details set in the context.
+// Returns context with ReqInfo details set in the context. The ReqInfo
+// attached to r by collectAPIStats is filled in, so that the hooks and
+// everything else reading the context of r see the same ReqInfo as the
+// handler, including the access key once the request is authenticated.
 func newContext(r *http.Request, w http.ResponseWriter, api string) context.Context {
 	bucket, object := request2BucketObjectName(r)
+	reqInfo, ok := logger.LookupReqInfo(r.Context())
+	if !ok {
+		reqInfo = &logger.ReqInfo{}
+	}
+	reqInfo.Lock()
+	reqInfo.DeploymentID = globalDeploymentID
+	reqInfo.RequestID = w.Header().Get(xhttp.AmzRequestID)
+	reqInfo.RemoteHost = getSourceIP(r)
+	reqInfo.Host = getHostName(r)
+	reqInfo.UserAgent = r.UserAgent()
+	reqInfo.API = api
+	reqInfo.BucketName = bucket
+	reqInfo.ObjectName = object
+	reqInfo.Unlock()
+	return logger.SetReqInfo(r.Context(), reqInfo)
+}
+
+// newBackgroundContext - returns the context of work radio does on its
+// own, such as heals, inventories and purges, logged with the job, bucket
+// and object. The API is left empty, calls made by radio itself are not
+// passed to the request hooks.
+func newBackgroundContext(job, bucket, object string) context.Context {
 	reqInfo := &logger.ReqInfo{
 		DeploymentID: globalDeploymentID,
-		RequestID:    w.Header().Get(xhttp.AmzRequestID),
-		RemoteHost:   handlers.GetSourceIP(r),
-		Host:         getHostName(r),
-		UserAgent:    r.UserAgent(),
-		API:          api,
 		BucketName:   bucket,
 		ObjectName:   object,
 	}
-	return logger.SetReqInfo(r.Context(), reqInfo)
+	return logger.SetReqInfo(context.Background(), reqInfo.AppendTags("job", job))
+}
+
+// remoteContext - returns ctx tagged with the remote clnt, for the logs
+// of a call to one remote of a bucket.
+func remoteContext(ctx context.Context, clnt bucketClient) context.Context {
+	return logger.WithTags(ctx, "remote", clnt.EndpointURL().Host+SlashSeparator+clnt.Bucket)
 }
 
 // Used for registering with rest handlers (have a look at registerStorageRESTHandlers for usage example)
@@ -3,6 +3,7 @@ module github.com/minio/radio
 go 1.13
 
 require (
+	github.com/Shopify/sarama v1.24.1
 	github.com/djherbis/atime v1.0.0
 	github.com/dustin/go-humanize v1.0.0
 	github.com/gorilla/mux v1.7.0
@@ -14,6 +15,7 @@ require (
 	github.com/minio/minio-go/v6 v6.0.44
 	github.com/minio/sha256-simd v0.1.1
 	github.com/ncw/directio v1.0.5
+	github.com/pkg/sftp v1.11.0
 	github.com/prometheus/client_golang v0.9.3-0.20190127221311-3c4408c8b829
 	github.com/rs/cors v1.6.0
 	github.com/secure-io/sio-go v0.3.0
@@ -21,6 +23,7 @@ require (
 	github.com/valyala/tcplisten v0.0.0-20161114210144-ceec8f93295a
 	go.uber.org/atomic v1.3.2
 	golang.org/x/crypto v0.0.0-20190923035154-9ee001bba392
+	golang.org/x/net v0.0.0-20190923162816-aa69164e4478
 	gopkg.in/yaml.v2 v2.2.2
 )
 
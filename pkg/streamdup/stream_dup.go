@@ -35,8 +35,14 @@ var streamPool = sync.Pool{
 	},
 }
 
+// writer - the writing end of a duplicate.
+type writer interface {
+	io.Writer
+	CloseWithError(err error) error
+}
+
 type multiWriter struct {
-	writers []*io.PipeWriter
+	writers []writer
 }
 
 func (t *multiWriter) CloseWithError(err error) error {
@@ -48,7 +54,7 @@ func (t *multiWriter) CloseWithError(err error) error {
 
 func (t *multiWriter) Close() error {
 	for _, closer := range t.writers {
-		closer.Close()
+		closer.CloseWithError(nil)
 	}
 	return nil
 }
@@ -87,7 +93,7 @@ func New(r io.Reader, dupN int) ([]io.Reader, error) {
 		return []io.Reader{r}, nil
 	}
 	readers := make([]io.Reader, dupN)
-	writers := make([]*io.PipeWriter, dupN)
+	writers := make([]writer, dupN)
 	for i := range readers {
 		readers[i], writers[i] = io.Pipe()
 	}
@@ -0,0 +1,245 @@
+// This file is part of Radio
+// Copyright (c) 2019 MinIO, Inc.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package streamdup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Pool - memory shared by the buffers of the readers of NewBuffered.
+type Pool struct {
+	mu   sync.Mutex
+	size int64
+	used int64
+}
+
+// NewPool - returns a pool of size bytes.
+func NewPool(size int64) *Pool {
+	return &Pool{size: size}
+}
+
+func (p *Pool) acquire(n int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.used+n > p.size {
+		return false
+	}
+	p.used += n
+	return true
+}
+
+func (p *Pool) release(n int64) {
+	p.mu.Lock()
+	p.used -= n
+	p.mu.Unlock()
+}
+
+// InUse - returns the bytes of the pool buffering data.
+func (p *Pool) InUse() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.used
+}
+
+// Options of NewBuffered.
+type Options struct {
+	// Pool buffers the data not read yet in memory, it must be set.
+	Pool *Pool
+	// SpillDir holds the data not read yet once Pool is used up, the
+	// default directory for temporary files if empty.
+	SpillDir string
+	// MaxLag is the most data buffered for a reader, the input is not
+	// read further until a reader lagging more catches up. Unlimited
+	// if 0.
+	MaxLag int64
+	// OnSpill, if set, is called with the number of bytes written to
+	// SpillDir.
+	OnSpill func(n int64)
+}
+
+// spillBuffer - data written but not read yet by a reader, in memory
+// and then in a temporary file. Data in memory is always older than
+// the data in the file.
+type spillBuffer struct {
+	opts *Options
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	mem  bytes.Buffer
+	file *os.File
+	// Offsets of the next read and write of file.
+	fileR, fileW int64
+	// rerr is set once the reader is closed, werr once all data was
+	// written, io.EOF on success.
+	rerr, werr error
+}
+
+func (b *spillBuffer) lag() int64 {
+	return int64(b.mem.Len()) + b.fileW - b.fileR
+}
+
+// Write - buffers p, waiting while the reader lags more than MaxLag.
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.rerr == nil && b.opts.MaxLag > 0 && b.lag() > 0 && b.lag()+int64(len(p)) > b.opts.MaxLag {
+		b.cond.Wait()
+	}
+	if b.rerr != nil {
+		return 0, b.rerr
+	}
+	defer b.cond.Broadcast()
+	if b.fileW == b.fileR && b.opts.Pool.acquire(int64(len(p))) {
+		return b.mem.Write(p)
+	}
+	if b.file == nil {
+		f, err := ioutil.TempFile(b.opts.SpillDir, "radio-fanout-")
+		if err != nil {
+			return 0, err
+		}
+		b.file = f
+	}
+	n, err := b.file.WriteAt(p, b.fileW)
+	b.fileW += int64(n)
+	if b.opts.OnSpill != nil {
+		b.opts.OnSpill(int64(n))
+	}
+	return n, err
+}
+
+// CloseWithError - ends the data with err, io.EOF if err is nil.
+func (b *spillBuffer) CloseWithError(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.werr == nil {
+		if err == nil {
+			err = io.EOF
+		}
+		b.werr = err
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+// bufferedReader - the reading end of a spillBuffer.
+type bufferedReader struct {
+	b *spillBuffer
+}
+
+func (r bufferedReader) Read(p []byte) (int, error) {
+	b := r.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.rerr == nil && b.lag() == 0 && b.werr == nil {
+		b.cond.Wait()
+	}
+	if b.rerr != nil {
+		return 0, b.rerr
+	}
+	if b.lag() == 0 || b.werr != nil && b.werr != io.EOF {
+		// Data not read yet is useless once r failed.
+		b.free()
+		return 0, b.werr
+	}
+	defer b.cond.Broadcast()
+	if b.mem.Len() > 0 {
+		n, _ := b.mem.Read(p)
+		b.opts.Pool.release(int64(n))
+		return n, nil
+	}
+	if int64(len(p)) > b.fileW-b.fileR {
+		p = p[:b.fileW-b.fileR]
+	}
+	n, err := b.file.ReadAt(p, b.fileR)
+	b.fileR += int64(n)
+	if b.fileR == b.fileW {
+		// Reuse the file from its start.
+		b.fileR, b.fileW = 0, 0
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Close - closes the reader, further writes fail.
+func (r bufferedReader) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError - closes the reader, further writes fail with err,
+// io.ErrClosedPipe if err is nil.
+func (r bufferedReader) CloseWithError(err error) error {
+	b := r.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rerr == nil {
+		if err == nil {
+			err = io.ErrClosedPipe
+		}
+		b.rerr = err
+	}
+	b.free()
+	b.cond.Broadcast()
+	return nil
+}
+
+// free - releases the memory and removes the file of the buffer.
+func (b *spillBuffer) free() {
+	b.opts.Pool.release(int64(b.mem.Len()))
+	b.mem = bytes.Buffer{}
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+	b.fileR, b.fileW = 0, 0
+}
+
+// NewBuffered - returns dupN readers of r like New, but readers lagging
+// behind the others do not slow them down: the data they did not read
+// yet is buffered in opts.Pool and then in opts.SpillDir, up to
+// opts.MaxLag per reader. Readers return the error of r once they read
+// all data, and should be closed if they stop reading early.
+func NewBuffered(r io.Reader, dupN int, opts Options) ([]io.Reader, error) {
+	if dupN < 0 || opts.Pool == nil {
+		return nil, errors.New("invalid argument")
+	}
+	if dupN == 0 {
+		return []io.Reader{r}, nil
+	}
+	readers := make([]io.Reader, dupN)
+	writers := make([]writer, dupN)
+	for i := range readers {
+		b := &spillBuffer{opts: &opts}
+		b.cond = sync.NewCond(&b.mu)
+		readers[i], writers[i] = bufferedReader{b}, b
+	}
+	w := &multiWriter{writers: writers}
+	bufp := streamPool.Get().(*[]byte)
+	go func() {
+		defer streamPool.Put(bufp)
+		_, err := io.CopyBuffer(w, r, *bufp)
+		w.CloseWithError(err)
+	}()
+	return readers, nil
+}
@@ -0,0 +1,114 @@
+// This file is part of Radio
+// Copyright (c) 2019 MinIO, Inc.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package streamdup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+func TestNewBuffered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "streamdup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := bytes.Repeat([]byte("10101010"), 4*humanize.MiByte)
+	var spilled int64
+	opts := Options{
+		Pool:     NewPool(6 * humanize.MiByte),
+		SpillDir: dir,
+		OnSpill:  func(n int64) { atomic.AddInt64(&spilled, n) },
+	}
+	// Hide the WriteTo of bytes.Reader, so that data is copied in blocks.
+	readers, err := NewBuffered(struct{ io.Reader }{bytes.NewReader(data)}, 2, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first reader reads all data before the second starts, the
+	// data beyond the pool is spilled.
+	for i, rd := range readers {
+		got, err := ioutil.ReadAll(rd)
+		if err != nil {
+			t.Fatalf("reader %d: %v", i, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("reader %d: expected %d bytes, got %d", i, len(data), len(got))
+		}
+	}
+	if spilled == 0 {
+		t.Error("expected the data of the lagging reader to be spilled")
+	}
+	if n := opts.Pool.InUse(); n != 0 {
+		t.Errorf("expected the pool to be released, %d bytes in use", n)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected the spilled data to be removed, found %d files", len(files))
+	}
+
+	if _, err = NewBuffered(bytes.NewReader(data), 2, Options{}); err == nil {
+		t.Error("expected a missing pool to fail")
+	}
+}
+
+func TestNewBufferedMaxLag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "streamdup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := bytes.Repeat([]byte("10101010"), 4*humanize.MiByte)
+	opts := Options{
+		Pool:     NewPool(humanize.MiByte),
+		SpillDir: dir,
+		MaxLag:   8 * humanize.MiByte,
+	}
+	// Hide the WriteTo of bytes.Reader, so that data is copied in blocks.
+	readers, err := NewBuffered(struct{ io.Reader }{bytes.NewReader(data)}, 2, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first reader waits for the second, which does not read, once
+	// it lags by MaxLag.
+	buf := make([]byte, opts.MaxLag)
+	n, err := io.ReadFull(readers[0], buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeErr := errors.New("remote failed")
+	readers[1].(interface{ CloseWithError(error) error }).CloseWithError(closeErr)
+	if _, err = io.Copy(ioutil.Discard, readers[0]); err != closeErr {
+		t.Errorf("expected the closed reader to fail the others, got %v after %d bytes", err, n)
+	}
+	if n := opts.Pool.InUse(); n != 0 {
+		t.Errorf("expected the pool to be released, %d bytes in use", n)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected the spilled data to be removed, found %d files", len(files))
+	}
+}